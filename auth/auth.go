@@ -0,0 +1,152 @@
+package auth
+
+import (
+	"Task_Manager/model/errs"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HeaderUserID carries the ID of the user performing the request. Clients can send any value, so it is
+// only believed along with a valid HeaderSignature, or, with Config.TrustHeader, because a trusted front
+// end sets it and strips it from the requests of clients.
+const HeaderUserID = "X-User-ID"
+
+// HeaderSignature carries the proof that HeaderUserID was set by a holder of the shared secret, as
+// returned by Sign
+const HeaderSignature = "X-User-Signature"
+
+// DefaultMaxAge is how long a signature is accepted when Config sets no MaxAge
+const DefaultMaxAge = 5 * time.Minute
+
+var (
+	ErrUserInvalid = errs.Invalid("user_header_invalid", "Invalid "+HeaderUserID+" header",
+		errs.FieldError{Field: HeaderUserID, Message: "must be a positive user ID"})
+	ErrSignatureInvalid = &errs.Unauthorized{Code: "user_signature_invalid",
+		Message: "missing, invalid or expired " + HeaderSignature + " header"}
+	ErrUserUnverifiable = &errs.Unauthorized{Code: "user_header_unverifiable",
+		Message: HeaderUserID + " cannot be verified: the server has neither a signing secret nor a trusted front end"}
+)
+
+// Actor is the user on whose behalf a request is executed. A zero UserID means anonymous.
+type Actor struct {
+	UserID int
+	Admin  bool
+}
+
+type ctxKey struct{}
+
+// WithActor returns a copy of ctx carrying the actor
+func WithActor(ctx context.Context, a Actor) context.Context {
+	return context.WithValue(ctx, ctxKey{}, a)
+}
+
+// FromContext returns the actor stored in ctx, or an anonymous actor
+func FromContext(ctx context.Context) Actor {
+	a, _ := ctx.Value(ctxKey{}).(Actor)
+	return a
+}
+
+// Config configures how the acting user is resolved
+type Config struct {
+	// Secret verifies the signature sent with the acting user
+	Secret []byte
+	// TrustHeader takes the user header as is when no Secret is set, which is only safe behind a front
+	// end that sets it itself. Without either, requests naming a user are rejected.
+	TrustHeader bool
+	// MaxAge is how long a signature is accepted after it was made, either way to allow for clock skew
+	MaxAge time.Duration
+	// AdminIDs are the users acting as admins
+	AdminIDs []int
+}
+
+// Authenticator resolves the actor of REST requests and gRPC calls alike
+type Authenticator struct {
+	cfg    Config
+	admins map[int]bool
+	now    func() time.Time
+}
+
+func New(cfg Config) *Authenticator {
+	if cfg.MaxAge == 0 {
+		cfg.MaxAge = DefaultMaxAge
+	}
+
+	admins := make(map[int]bool, len(cfg.AdminIDs))
+	for _, id := range cfg.AdminIDs {
+		admins[id] = true
+	}
+
+	return &Authenticator{cfg: cfg, admins: admins, now: time.Now}
+}
+
+// Verifies reports whether user headers are checked against a signature rather than trusted as is
+func (a *Authenticator) Verifies() bool {
+	return len(a.cfg.Secret) > 0
+}
+
+// Identifies reports whether requests may name a user at all, being either verified or trusted
+func (a *Authenticator) Identifies() bool {
+	return a.Verifies() || a.cfg.TrustHeader
+}
+
+// Actor returns the actor named by the raw user header and its signature. No user means anonymous; a
+// user that is not a positive ID fails with ErrUserInvalid, one whose signature does not verify with
+// ErrSignatureInvalid, and any user at all with ErrUserUnverifiable when nothing can vouch for it.
+func (a *Authenticator) Actor(user, signature string) (Actor, error) {
+	if user == "" {
+		return Actor{}, nil
+	}
+
+	id, err := strconv.Atoi(user)
+	if err != nil || id <= 0 {
+		return Actor{}, ErrUserInvalid
+	}
+
+	switch {
+	case a.Verifies():
+		if !a.verify(id, signature) {
+			return Actor{}, ErrSignatureInvalid
+		}
+	case !a.cfg.TrustHeader:
+		return Actor{}, ErrUserUnverifiable
+	}
+
+	return Actor{UserID: id, Admin: a.admins[id]}, nil
+}
+
+// verify checks a signature of the form "<unix time>.<hex HMAC>" made by Sign
+func (a *Authenticator) verify(userID int, signature string) bool {
+	raw, _, ok := strings.Cut(signature, ".")
+	if !ok {
+		return false
+	}
+
+	at, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return false
+	}
+
+	age := a.now().Sub(time.Unix(at, 0))
+	if age > a.cfg.MaxAge || age < -a.cfg.MaxAge {
+		return false
+	}
+
+	return hmac.Equal([]byte(signature), []byte(Sign(a.cfg.Secret, userID, time.Unix(at, 0))))
+}
+
+// Sign returns the signature of userID made at the given time, for front ends and services holding the
+// secret to send along with the user header. It is an HMAC-SHA256 of "<user ID>.<unix time>", sent as
+// "<unix time>.<hex HMAC>".
+func Sign(secret []byte, userID int, at time.Time) string {
+	unix := strconv.FormatInt(at.Unix(), 10)
+
+	mac := hmac.New(sha256.New, secret)
+	_, _ = mac.Write([]byte(strconv.Itoa(userID) + "." + unix))
+
+	return unix + "." + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Actor(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      Config
+		user     string
+		expErr   error
+		expActor Actor
+	}{
+		{"Anonymous", Config{}, "", nil, Actor{}},
+		{"Trusted user", Config{TrustHeader: true, AdminIDs: []int{1}}, "7", nil, Actor{UserID: 7}},
+		{"Trusted admin", Config{TrustHeader: true, AdminIDs: []int{1}}, "1", nil, Actor{UserID: 1, Admin: true}},
+		{"Malformed user", Config{TrustHeader: true}, "abc", ErrUserInvalid, Actor{}},
+		{"Negative id", Config{TrustHeader: true}, "-3", ErrUserInvalid, Actor{}},
+		// Without a secret or a trusted front end nothing vouches for the header
+		{"Unverifiable user", Config{AdminIDs: []int{1}}, "1", ErrUserUnverifiable, Actor{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := New(tt.cfg).Actor(tt.user, "")
+			assert.ErrorIs(t, err, tt.expErr)
+			assert.Equal(t, tt.expActor, got)
+		})
+	}
+}
+
+func Test_Actor_Signed(t *testing.T) {
+	secret := []byte("s3cret")
+	now := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		user      string
+		signature string
+		expErr    error
+		expActor  Actor
+	}{
+		{"Anonymous", "", "", nil, Actor{}},
+		{"Signed admin", "1", Sign(secret, 1, now), nil, Actor{UserID: 1, Admin: true}},
+		{"Slightly ahead clock", "7", Sign(secret, 7, now.Add(time.Minute)), nil, Actor{UserID: 7}},
+		{"Unsigned", "1", "", ErrSignatureInvalid, Actor{}},
+		{"Signed for another user", "1", Sign(secret, 7, now), ErrSignatureInvalid, Actor{}},
+		{"Other secret", "1", Sign([]byte("guess"), 1, now), ErrSignatureInvalid, Actor{}},
+		{"Expired", "1", Sign(secret, 1, now.Add(-DefaultMaxAge-time.Second)), ErrSignatureInvalid, Actor{}},
+		{"Malformed signature", "1", "abc", ErrSignatureInvalid, Actor{}},
+		{"Malformed user", "abc", Sign(secret, 1, now), ErrUserInvalid, Actor{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// A secret takes precedence over trusting the header
+			a := New(Config{Secret: secret, TrustHeader: true, AdminIDs: []int{1}})
+			a.now = func() time.Time { return now }
+
+			got, err := a.Actor(tt.user, tt.signature)
+			assert.ErrorIs(t, err, tt.expErr)
+			assert.Equal(t, tt.expActor, got)
+		})
+	}
+}
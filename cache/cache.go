@@ -0,0 +1,102 @@
+// Package cache keeps the results of frequent lookups for a while, so they are not read from the
+// database each time. Values are stored as JSON in a Backend: an in-process LRU for a single instance,
+// or a Redis server shared by every instance, where an invalidation is seen by all of them.
+package cache
+
+import (
+	"Task_Manager/logging"
+	"Task_Manager/metrics"
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+var requests = metrics.Default.NewCounterVec("cache_requests_total",
+	"Cache lookups by cache and result: hit, miss, or error when the backend failed and the store was read instead", "cache", "result")
+
+// Backend stores the cached values. Counters are kept apart from values: they are never evicted or
+// expired, since a counter starting over could bring stale values back.
+type Backend interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+	Counter(ctx context.Context, key string) (int64, error)
+	Incr(ctx context.Context, key string) (int64, error)
+}
+
+// Cache reads through to the loader on a miss and keeps what was loaded for ttl. The cache is an
+// optimisation only: when the backend fails, values are loaded as if nothing were cached.
+type Cache struct {
+	backend Backend
+	ttl     time.Duration
+}
+
+func New(backend Backend, ttl time.Duration) *Cache {
+	return &Cache{backend: backend, ttl: ttl}
+}
+
+// Fetch returns the value cached under key, loading and caching it on a miss. name labels the metrics
+// of the lookup. Errors of load are returned and never cached.
+func Fetch[T any](ctx context.Context, c *Cache, name, key string, load func() (T, error)) (T, error) {
+	raw, ok, err := c.backend.Get(ctx, key)
+	if err != nil {
+		requests.Inc(name, "error")
+		logging.FromContext(ctx).Warn("cache read failed", "cache", name, "error", err)
+
+		return load()
+	}
+
+	if ok {
+		var v T
+		if err := json.Unmarshal(raw, &v); err == nil {
+			requests.Inc(name, "hit")
+			return v, nil
+		}
+	}
+
+	requests.Inc(name, "miss")
+
+	v, err := load()
+	if err != nil {
+		return v, err
+	}
+
+	if raw, err := json.Marshal(v); err == nil {
+		if err := c.backend.Set(ctx, key, raw, c.ttl); err != nil {
+			logging.FromContext(ctx).Warn("cache write failed", "cache", name, "error", err)
+		}
+	}
+
+	return v, nil
+}
+
+// Invalidate drops the values cached under keys. A failure is logged: the values then go stale until
+// they expire.
+func (c *Cache) Invalidate(ctx context.Context, keys ...string) {
+	if err := c.backend.Delete(ctx, keys...); err != nil {
+		logging.FromContext(ctx).Error("cache invalidation failed", "keys", keys, "error", err)
+	}
+}
+
+// Generation returns the current generation of a namespace. Keys built with it are all invalidated at
+// once by Bump, without having to be listed. ok is false when the backend failed, and the cache should
+// then not be used.
+func (c *Cache) Generation(ctx context.Context, namespace string) (prefix string, ok bool) {
+	n, err := c.backend.Counter(ctx, namespace+":generation")
+	if err != nil {
+		requests.Inc(namespace, "error")
+		logging.FromContext(ctx).Warn("cache read failed", "cache", namespace, "error", err)
+
+		return "", false
+	}
+
+	return namespace + ":" + strconv.FormatInt(n, 10) + ":", true
+}
+
+// Bump invalidates every key built with the current generation of a namespace
+func (c *Cache) Bump(ctx context.Context, namespace string) {
+	if _, err := c.backend.Incr(ctx, namespace+":generation"); err != nil {
+		logging.FromContext(ctx).Error("cache invalidation failed", "cache", namespace, "error", err)
+	}
+}
@@ -0,0 +1,152 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type item struct {
+	ID   int
+	Name string
+}
+
+// failing is a backend that is down
+type failing struct{}
+
+func (failing) Get(context.Context, string) ([]byte, bool, error) {
+	return nil, false, errors.New("down")
+}
+func (failing) Set(context.Context, string, []byte, time.Duration) error {
+	return errors.New("down")
+}
+func (failing) Delete(context.Context, ...string) error        { return errors.New("down") }
+func (failing) Counter(context.Context, string) (int64, error) { return 0, errors.New("down") }
+func (failing) Incr(context.Context, string) (int64, error)    { return 0, errors.New("down") }
+
+func Test_Fetch(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Loads on a miss and serves hits from the cache", func(t *testing.T) {
+		c := New(NewLRU(10), time.Minute)
+		loads := 0
+		load := func() (item, error) {
+			loads++
+			return item{ID: 1, Name: "a"}, nil
+		}
+
+		for range 3 {
+			v, err := Fetch(ctx, c, "item", "item:1", load)
+			require.NoError(t, err)
+			assert.Equal(t, item{ID: 1, Name: "a"}, v)
+		}
+
+		assert.Equal(t, 1, loads)
+	})
+
+	t.Run("Errors are not cached", func(t *testing.T) {
+		c := New(NewLRU(10), time.Minute)
+		loads := 0
+		load := func() (item, error) {
+			loads++
+			return item{}, errors.New("not found")
+		}
+
+		_, err := Fetch(ctx, c, "item", "item:1", load)
+		require.Error(t, err)
+		_, err = Fetch(ctx, c, "item", "item:1", load)
+		require.Error(t, err)
+
+		assert.Equal(t, 2, loads)
+	})
+
+	t.Run("Invalidated values are loaded again", func(t *testing.T) {
+		c := New(NewLRU(10), time.Minute)
+		name := "a"
+		load := func() (item, error) { return item{ID: 1, Name: name}, nil }
+
+		_, _ = Fetch(ctx, c, "item", "item:1", load)
+		name = "b"
+		c.Invalidate(ctx, "item:1")
+
+		v, err := Fetch(ctx, c, "item", "item:1", load)
+		require.NoError(t, err)
+		assert.Equal(t, "b", v.Name)
+	})
+
+	t.Run("A failing backend falls back to the loader", func(t *testing.T) {
+		c := New(failing{}, time.Minute)
+
+		v, err := Fetch(ctx, c, "item", "item:1", func() (item, error) { return item{ID: 1}, nil })
+		require.NoError(t, err)
+		assert.Equal(t, 1, v.ID)
+
+		_, ok := c.Generation(ctx, "items")
+		assert.False(t, ok)
+	})
+}
+
+func Test_Generation(t *testing.T) {
+	ctx := context.Background()
+	c := New(NewLRU(10), time.Minute)
+
+	first, ok := c.Generation(ctx, "tasks")
+	require.True(t, ok)
+	assert.Equal(t, "tasks:0:", first)
+
+	c.Bump(ctx, "tasks")
+
+	second, ok := c.Generation(ctx, "tasks")
+	require.True(t, ok)
+	assert.Equal(t, "tasks:1:", second)
+}
+
+func Test_LRU(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	l := NewLRU(2)
+	l.now = func() time.Time { return now }
+
+	require.NoError(t, l.Set(ctx, "a", []byte("1"), time.Minute))
+	require.NoError(t, l.Set(ctx, "b", []byte("2"), time.Minute))
+
+	// Reading a makes b the least recently used, evicted by c
+	_, ok, _ := l.Get(ctx, "a")
+	require.True(t, ok)
+	require.NoError(t, l.Set(ctx, "c", []byte("3"), time.Second))
+
+	_, ok, _ = l.Get(ctx, "b")
+	assert.False(t, ok)
+	assert.Equal(t, 2, l.Len())
+
+	// Values expire after their TTL
+	now = now.Add(time.Second)
+	_, ok, _ = l.Get(ctx, "c")
+	assert.False(t, ok)
+
+	v, ok, _ := l.Get(ctx, "a")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("1"), v)
+
+	// Counters are never evicted
+	for range 3 {
+		_, _ = l.Incr(ctx, "gen")
+	}
+
+	for i := range 5 {
+		require.NoError(t, l.Set(ctx, string(rune('d'+i)), nil, time.Minute))
+	}
+
+	n, err := l.Counter(ctx, "gen")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), n)
+
+	require.NoError(t, l.Delete(ctx, "h", "missing"))
+	_, ok, _ = l.Get(ctx, "h")
+	assert.False(t, ok)
+}
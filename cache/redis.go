@@ -0,0 +1,241 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+// RedisConfig locates the Redis server, or any server speaking its protocol
+type RedisConfig struct {
+	Addr     string
+	Password string
+	DB       int
+	// Timeout bounds each command, including dialing; a cache slower than the database is no use
+	Timeout time.Duration
+	// PoolSize is the number of idle connections kept open
+	PoolSize int
+}
+
+// Redis keeps the cached values in a Redis server, shared by every instance of the API
+type Redis struct {
+	cfg  RedisConfig
+	idle chan *redisConn
+}
+
+// redisError is an error reply of the server
+type redisError string
+
+func (e redisError) Error() string { return "redis: " + string(e) }
+
+type redisConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func NewRedis(cfg RedisConfig) *Redis {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 200 * time.Millisecond
+	}
+
+	if cfg.PoolSize <= 0 {
+		cfg.PoolSize = 10
+	}
+
+	return &Redis{cfg: cfg, idle: make(chan *redisConn, cfg.PoolSize)}
+}
+
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	reply, err := r.do(ctx, "GET", key)
+	if err != nil || reply == nil {
+		return nil, false, err
+	}
+
+	b, ok := reply.([]byte)
+	if !ok {
+		return nil, false, fmt.Errorf("redis: unexpected reply %T to GET", reply)
+	}
+
+	return b, true, nil
+}
+
+func (r *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	_, err := r.do(ctx, "SET", key, string(value), "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	return err
+}
+
+func (r *Redis) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	_, err := r.do(ctx, append([]string{"DEL"}, keys...)...)
+
+	return err
+}
+
+func (r *Redis) Counter(ctx context.Context, key string) (int64, error) {
+	b, ok, err := r.Get(ctx, key)
+	if err != nil || !ok {
+		return 0, err
+	}
+
+	return strconv.ParseInt(string(b), 10, 64)
+}
+
+func (r *Redis) Incr(ctx context.Context, key string) (int64, error) {
+	reply, err := r.do(ctx, "INCR", key)
+	if err != nil {
+		return 0, err
+	}
+
+	n, ok := reply.(int64)
+	if !ok {
+		return 0, fmt.Errorf("redis: unexpected reply %T to INCR", reply)
+	}
+
+	return n, nil
+}
+
+// Close closes the idle connections
+func (r *Redis) Close() error {
+	for {
+		select {
+		case c := <-r.idle:
+			_ = c.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+// do sends a command and reads its reply: nil, a string for a status, an int64, or []byte for a bulk
+// string. Error replies are returned as errors, and leave the connection usable.
+func (r *Redis) do(ctx context.Context, args ...string) (any, error) {
+	c, err := r.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := c.command(ctx, r.cfg.Timeout, args...)
+
+	var replyErr redisError
+	if err != nil && !errors.As(err, &replyErr) {
+		// The connection is in an unknown state, with maybe half a reply left to read
+		_ = c.Close()
+		return nil, err
+	}
+
+	select {
+	case r.idle <- c:
+	default:
+		_ = c.Close()
+	}
+
+	return reply, err
+}
+
+// conn takes an idle connection, or dials a new one
+func (r *Redis) conn(ctx context.Context) (*redisConn, error) {
+	select {
+	case c := <-r.idle:
+		return c, nil
+	default:
+	}
+
+	d := net.Dialer{Timeout: r.cfg.Timeout}
+
+	nc, err := d.DialContext(ctx, "tcp", r.cfg.Addr)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &redisConn{Conn: nc, r: bufio.NewReader(nc)}
+
+	if r.cfg.Password != "" {
+		if _, err := c.command(ctx, r.cfg.Timeout, "AUTH", r.cfg.Password); err != nil {
+			_ = c.Close()
+			return nil, err
+		}
+	}
+
+	if r.cfg.DB != 0 {
+		if _, err := c.command(ctx, r.cfg.Timeout, "SELECT", strconv.Itoa(r.cfg.DB)); err != nil {
+			_ = c.Close()
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+func (c *redisConn) command(ctx context.Context, timeout time.Duration, args ...string) (any, error) {
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+
+	if err := c.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	buf := []byte("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, a := range args {
+		buf = append(buf, '$')
+		buf = strconv.AppendInt(buf, int64(len(a)), 10)
+		buf = append(buf, "\r\n"...)
+		buf = append(buf, a...)
+		buf = append(buf, "\r\n"...)
+	}
+
+	if _, err := c.Write(buf); err != nil {
+		return nil, err
+	}
+
+	return readReply(c.r)
+}
+
+func readReply(r *bufio.Reader) (any, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("redis: malformed reply %q", line)
+	}
+
+	kind, body := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return body, nil
+	case '-':
+		return nil, redisError(body)
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, fmt.Errorf("redis: malformed bulk length %q", body)
+		}
+
+		if n < 0 {
+			return nil, nil
+		}
+
+		b := make([]byte, n+2)
+		if _, err := io.ReadFull(r, b); err != nil {
+			return nil, err
+		}
+
+		return b[:n], nil
+	default:
+		return nil, fmt.Errorf("redis: unsupported reply type %q", kind)
+	}
+}
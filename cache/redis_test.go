@@ -0,0 +1,228 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// standIn speaks enough of the Redis protocol to serve the Redis backend
+type standIn struct {
+	net.Listener
+	password string
+
+	mu     sync.Mutex
+	values map[string]string
+	ttls   map[string]string
+}
+
+func newStandIn(t *testing.T, password string) *standIn {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	s := &standIn{Listener: l, password: password, values: make(map[string]string), ttls: make(map[string]string)}
+	t.Cleanup(func() { _ = l.Close() })
+
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			go s.serve(c)
+		}
+	}()
+
+	return s
+}
+
+func (s *standIn) serve(c net.Conn) {
+	defer func() { _ = c.Close() }()
+
+	r := bufio.NewReader(c)
+	authed := s.password == ""
+
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+
+		cmd := strings.ToUpper(args[0])
+		if !authed && cmd != "AUTH" {
+			_, _ = io.WriteString(c, "-NOAUTH Authentication required.\r\n")
+			continue
+		}
+
+		_, _ = io.WriteString(c, s.reply(cmd, args[1:], &authed))
+	}
+}
+
+func (s *standIn) reply(cmd string, args []string, authed *bool) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch cmd {
+	case "AUTH":
+		if args[0] != s.password {
+			return "-WRONGPASS invalid password\r\n"
+		}
+
+		*authed = true
+
+		return "+OK\r\n"
+	case "SELECT":
+		return "+OK\r\n"
+	case "GET":
+		v, ok := s.values[args[0]]
+		if !ok {
+			return "$-1\r\n"
+		}
+
+		return fmt.Sprintf("$%d\r\n%s\r\n", len(v), v)
+	case "SET":
+		s.values[args[0]] = args[1]
+		if len(args) == 4 && strings.ToUpper(args[2]) == "PX" {
+			s.ttls[args[0]] = args[3]
+		}
+
+		return "+OK\r\n"
+	case "DEL":
+		n := 0
+
+		for _, k := range args {
+			if _, ok := s.values[k]; ok {
+				delete(s.values, k)
+				n++
+			}
+		}
+
+		return fmt.Sprintf(":%d\r\n", n)
+	case "INCR":
+		n, err := strconv.ParseInt(s.values[args[0]], 10, 64)
+		if err != nil && s.values[args[0]] != "" {
+			return "-ERR value is not an integer or out of range\r\n"
+		}
+
+		n++
+		s.values[args[0]] = strconv.FormatInt(n, 10)
+
+		return fmt.Sprintf(":%d\r\n", n)
+	default:
+		return "-ERR unknown command '" + cmd + "'\r\n"
+	}
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, n)
+	for i := range args {
+		if line, err = r.ReadString('\n'); err != nil {
+			return nil, err
+		}
+
+		size, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+		if err != nil {
+			return nil, err
+		}
+
+		b := make([]byte, size+2)
+		if _, err := io.ReadFull(r, b); err != nil {
+			return nil, err
+		}
+
+		args[i] = string(b[:size])
+	}
+
+	return args, nil
+}
+
+func Test_Redis(t *testing.T) {
+	ctx := context.Background()
+	s := newStandIn(t, "secret")
+
+	r := NewRedis(RedisConfig{Addr: s.Addr().String(), Password: "secret", DB: 2})
+	defer func() { _ = r.Close() }()
+
+	_, ok, err := r.Get(ctx, "task:1")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// Values survive the trip byte for byte, line breaks included
+	value := []byte("{\"desc\":\"two\r\nlines\"}")
+	require.NoError(t, r.Set(ctx, "task:1", value, 1500*time.Millisecond))
+
+	got, ok, err := r.Get(ctx, "task:1")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, value, got)
+	assert.Equal(t, "1500", s.ttls["task:1"])
+
+	require.NoError(t, r.Delete(ctx, "task:1", "task:2"))
+	_, ok, _ = r.Get(ctx, "task:1")
+	assert.False(t, ok)
+
+	n, err := r.Counter(ctx, "tasks:generation")
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	for range 2 {
+		_, err = r.Incr(ctx, "tasks:generation")
+		require.NoError(t, err)
+	}
+
+	n, err = r.Counter(ctx, "tasks:generation")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	// An error reply leaves the connection usable
+	_, err = r.do(ctx, "FLUSHALL")
+	assert.ErrorContains(t, err, "unknown command")
+	_, err = r.Incr(ctx, "tasks:generation")
+	assert.NoError(t, err)
+}
+
+func Test_RedisUnavailable(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Wrong password", func(t *testing.T) {
+		s := newStandIn(t, "secret")
+		r := NewRedis(RedisConfig{Addr: s.Addr().String(), Password: "guess"})
+
+		_, _, err := r.Get(ctx, "k")
+		assert.ErrorContains(t, err, "WRONGPASS")
+	})
+
+	t.Run("Server down falls back to the loader", func(t *testing.T) {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		addr := l.Addr().String()
+		_ = l.Close()
+
+		c := New(NewRedis(RedisConfig{Addr: addr, Timeout: 50 * time.Millisecond}), time.Minute)
+
+		v, err := Fetch(ctx, c, "item", "item:1", func() (item, error) { return item{ID: 1}, nil })
+		require.NoError(t, err)
+		assert.Equal(t, 1, v.ID)
+	})
+}
@@ -0,0 +1,268 @@
+// Package client is the Go SDK of the Task Manager REST API. Its methods mirror the task and user
+// routes and exchange the model types, so programs calling the API share the server's view of tasks
+// and users instead of each keeping their own. Failed calls return an *Error decoded from the problem
+// details of the response, and calls that are safe to repeat are retried with backoff.
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// Headers of the API; the client tests check them against the server's
+const (
+	headerUserID      = "X-User-ID"
+	headerSignature   = "X-User-Signature"
+	headerAPIKey      = "X-API-Key"
+	headerIdempotency = "Idempotency-Key"
+)
+
+// UserAgent is sent with every request
+const UserAgent = "task-manager-go-client"
+
+// Config configures a Client
+type Config struct {
+	// BaseURL is where the API is served, e.g. https://tasks.example.com
+	BaseURL string
+	// UserID is the user the calls act on behalf of, unless AsUser names another; zero is anonymous
+	UserID int
+	// Secret signs the acting user of each call with the API's AUTH_SECRET. Programs behind a front end
+	// that sets the user themselves leave it empty.
+	Secret []byte
+	// APIKey identifies the calling program, which the API rate limits apart from other programs when
+	// the hash of the key is among its API_KEY_HASHES
+	APIKey string
+	// Retry is the retry policy; its zero value gets DefaultRetry
+	Retry RetryPolicy
+}
+
+// Client calls the API. It is safe for concurrent use.
+type Client struct {
+	cfg    Config
+	base   *url.URL
+	client *http.Client
+	now    func() time.Time
+}
+
+// New returns a client of the API at cfg.BaseURL, sending its requests through client, or
+// http.DefaultClient when nil
+func New(cfg Config, client *http.Client) (*Client, error) {
+	base, err := url.Parse(cfg.BaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if base.Scheme == "" || base.Host == "" {
+		return nil, errors.New("client base URL must be absolute, e.g. https://tasks.example.com")
+	}
+
+	if cfg.Retry == (RetryPolicy{}) {
+		cfg.Retry = DefaultRetry
+	}
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &Client{cfg: cfg, base: base, client: client, now: time.Now}, nil
+}
+
+type userKey struct{}
+
+// AsUser returns a copy of ctx whose calls act on behalf of userID instead of Config.UserID, for
+// programs serving several users through one client
+func AsUser(ctx context.Context, userID int) context.Context {
+	return context.WithValue(ctx, userKey{}, userID)
+}
+
+type idempotencyKey struct{}
+
+// WithIdempotencyKey returns a copy of ctx whose creations are sent with key. Each creation otherwise
+// gets a random key, kept across its retries; a key of the caller's own lets it retry the creation
+// later, e.g. after a restart, without creating it twice.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKey{}, key)
+}
+
+// call is one request to the API
+type call struct {
+	method string
+	path   string
+	query  url.Values
+	// body is sent as JSON unless nil
+	body   any
+	accept string
+	// idempotent requests may be retried after a failure that leaves unknown whether they were applied
+	idempotent bool
+	// create requests get an idempotency key, which makes them idempotent
+	create bool
+	// answers are the unsuccessful statuses the route answers with its regular body, returned to the
+	// caller like successes rather than as an *Error
+	answers []int
+}
+
+// do sends the call, retrying it as the policy allows, and returns the response of a successful call
+// for the caller to read and close. Unsuccessful responses are returned as an *Error.
+func (c *Client) do(ctx context.Context, cl call) (*http.Response, error) {
+	var payload []byte
+
+	if cl.body != nil {
+		var err error
+		if payload, err = json.Marshal(cl.body); err != nil {
+			return nil, err
+		}
+	}
+
+	header := c.header(ctx, cl)
+	if cl.create {
+		cl.idempotent = true
+	}
+
+	for attempt := 1; ; attempt++ {
+		req, err := c.request(ctx, cl, header, payload)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := c.client.Do(req)
+		if !c.cfg.Retry.retries(ctx, cl, attempt, resp, err) {
+			if err != nil {
+				return nil, err
+			}
+
+			if resp.StatusCode >= http.StatusBadRequest && !slices.Contains(cl.answers, resp.StatusCode) {
+				return nil, decodeError(resp)
+			}
+
+			return resp, nil
+		}
+
+		var retryAfter string
+		if resp != nil {
+			retryAfter = resp.Header.Get("Retry-After")
+			discard(resp)
+		}
+
+		if err := c.cfg.Retry.wait(ctx, attempt, retryAfter); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// header builds the headers shared by every attempt of a call
+func (c *Client) header(ctx context.Context, cl call) http.Header {
+	h := http.Header{}
+	h.Set("User-Agent", UserAgent)
+
+	if cl.body != nil {
+		h.Set("Content-Type", "application/json")
+	}
+
+	if cl.accept != "" {
+		h.Set("Accept", cl.accept)
+	}
+
+	userID := c.cfg.UserID
+	if id, ok := ctx.Value(userKey{}).(int); ok {
+		userID = id
+	}
+
+	if userID != 0 {
+		h.Set(headerUserID, strconv.Itoa(userID))
+
+		if len(c.cfg.Secret) > 0 {
+			h.Set(headerSignature, sign(c.cfg.Secret, userID, c.now()))
+		}
+	}
+
+	if c.cfg.APIKey != "" {
+		h.Set(headerAPIKey, c.cfg.APIKey)
+	}
+
+	if cl.create {
+		key, _ := ctx.Value(idempotencyKey{}).(string)
+		if key == "" {
+			key = newKey()
+		}
+
+		h.Set(headerIdempotency, key)
+	}
+
+	return h
+}
+
+func (c *Client) request(ctx context.Context, cl call, header http.Header, payload []byte) (*http.Request, error) {
+	u := c.base.JoinPath(cl.path)
+	u.RawQuery = cl.query.Encode()
+
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, cl.method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header = header.Clone()
+
+	return req, nil
+}
+
+// json sends the call and decodes the response into out, or discards it when out is nil
+func (c *Client) json(ctx context.Context, cl call, out any) error {
+	resp, err := c.do(ctx, cl)
+	if err != nil {
+		return err
+	}
+
+	defer discard(resp)
+
+	if out == nil {
+		return nil
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("task manager: undecodable response: %w", err)
+	}
+
+	return nil
+}
+
+// discard reads what is left of a response, so its connection can be reused, and closes it
+func discard(resp *http.Response) {
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxErrorBody))
+	_ = resp.Body.Close()
+}
+
+func newKey() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
+
+// sign returns the signature the API expects of the acting user: "<unix time>.<hex HMAC-SHA256 of
+// "<user ID>.<unix time>">"
+func sign(secret []byte, userID int, at time.Time) string {
+	unix := strconv.FormatInt(at.Unix(), 10)
+
+	mac := hmac.New(sha256.New, secret)
+	_, _ = mac.Write([]byte(strconv.Itoa(userID) + "." + unix))
+
+	return unix + "." + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,400 @@
+package client
+
+import (
+	"Task_Manager/auth"
+	"Task_Manager/handler/authn"
+	taskHandler "Task_Manager/handler/task"
+	userHandler "Task_Manager/handler/user"
+	"Task_Manager/idempotency"
+	"Task_Manager/model/errs"
+	modelIdempotency "Task_Manager/model/idempotency"
+	"Task_Manager/model/task"
+	"Task_Manager/ratelimit"
+	"Task_Manager/request"
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// testRetry retries without slowing the tests down
+var testRetry = RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond}
+
+// api is an httptest server wired to the real task and user handlers, with mocked services
+type api struct {
+	*httptest.Server
+	tasks  *taskHandler.MockTaskServiceInterface
+	users  *userHandler.MockUserServiceInterface
+	faults *faults
+}
+
+// setup serves the API with user 1 as an admin, and returns a client of it acting as user 1
+func setup(t *testing.T) (*Client, *api) {
+	ctrl := gomock.NewController(t)
+	a := &api{
+		tasks:  taskHandler.NewMockTaskServiceInterface(ctrl),
+		users:  userHandler.NewMockUserServiceInterface(ctrl),
+		faults: &faults{},
+	}
+
+	th := taskHandler.NewHandler(a.tasks)
+	uh := userHandler.NewUserHandler(a.users)
+	keys := idempotency.New(newMemoryStore(), time.Hour)
+
+	r := mux.NewRouter()
+	r.Use(authn.Middleware(auth.New(auth.Config{Secret: []byte("shared"), AdminIDs: []int{1}})))
+	r.Handle("/task", keys.Middleware(http.HandlerFunc(th.Create))).Methods("POST")
+	r.HandleFunc("/task/bulk", th.Bulk).Methods("POST")
+	r.HandleFunc("/task/{id}", th.GetTask).Methods("GET")
+	r.HandleFunc("/task/{id}", th.Complete).Methods("PUT")
+	r.HandleFunc("/task/{id}", th.Delete).Methods("DELETE")
+	r.HandleFunc("/task", th.All).Methods("GET")
+	r.HandleFunc("/task/user/{userid}", th.GetTasksByUserID).Methods("GET")
+	r.HandleFunc("/task/{id}/restore", th.Restore).Methods("POST")
+	r.HandleFunc("/task/{id}/revisions", th.Revisions).Methods("GET")
+	r.HandleFunc("/task/{id}/revisions/diff", th.DiffRevisions).Methods("GET")
+	r.HandleFunc("/task/{id}/revert", th.Revert).Methods("POST")
+	r.HandleFunc("/trash/tasks", th.Trash).Methods("GET")
+	r.Handle("/users", keys.Middleware(http.HandlerFunc(uh.CreateUser))).Methods("POST")
+	r.HandleFunc("/users", uh.GetAllUsers).Methods("GET")
+	r.HandleFunc("/users/{id}", uh.GetUser).Methods("GET")
+	r.HandleFunc("/users/{id}", uh.UpdateUser).Methods("PATCH")
+	r.HandleFunc("/users/{id}", uh.DeleteUser).Methods("DELETE")
+	r.HandleFunc("/users/{id}/email", uh.ChangeEmail).Methods("POST")
+	r.HandleFunc("/users/{id}/email/confirm", uh.ConfirmEmail).Methods("POST")
+	r.HandleFunc("/users/{id}/restore", uh.RestoreUser).Methods("POST")
+	r.HandleFunc("/trash/users", uh.GetTrash).Methods("GET")
+
+	a.Server = httptest.NewServer(a.faults.wrap(request.Middleware(r)))
+	t.Cleanup(a.Close)
+
+	c, err := New(Config{BaseURL: a.URL, UserID: 1, Secret: []byte("shared"), APIKey: "secret", Retry: testRetry}, a.Client())
+	require.NoError(t, err)
+
+	return c, a
+}
+
+// faults answers requests with the injected failures, then lets them through to the API. A lost
+// response is served by the API and then replaced by a 502, as a proxy timing out would do.
+type faults struct {
+	mu       sync.Mutex
+	statuses []int
+	lost     int
+	headers  []http.Header
+}
+
+func (f *faults) fail(statuses ...int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.statuses = append(f.statuses, statuses...)
+}
+
+func (f *faults) lose(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.lost += n
+}
+
+// seen returns the headers of the requests received
+func (f *faults) seen() []http.Header {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.headers
+}
+
+func (f *faults) wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		f.headers = append(f.headers, r.Header.Clone())
+
+		status, lose := 0, false
+		if len(f.statuses) > 0 {
+			status, f.statuses = f.statuses[0], f.statuses[1:]
+		} else if f.lost > 0 {
+			f.lost--
+			lose = true
+		}
+		f.mu.Unlock()
+
+		switch {
+		case status == http.StatusTooManyRequests:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(status)
+		case status != 0:
+			w.WriteHeader(status)
+		case lose:
+			next.ServeHTTP(httptest.NewRecorder(), r)
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// memoryStore keeps idempotency records the way the database store does
+type memoryStore struct {
+	mu      sync.Mutex
+	records map[string]modelIdempotency.Record
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{records: make(map[string]modelIdempotency.Record)}
+}
+
+func (m *memoryStore) ClaimIdempotency(_ context.Context, rec modelIdempotency.Record, expired time.Time) (modelIdempotency.Record, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if existing, ok := m.records[rec.Key]; ok && !existing.CreatedAt.Before(expired) {
+		return existing, false, nil
+	}
+
+	m.records[rec.Key] = rec
+
+	return rec, true, nil
+}
+
+func (m *memoryStore) CompleteIdempotency(_ context.Context, key string, status int, contentType string, body []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rec, ok := m.records[key]
+	if !ok {
+		return sql.ErrNoRows
+	}
+
+	rec.Status, rec.ContentType, rec.Body = status, contentType, body
+	m.records[key] = rec
+
+	return nil
+}
+
+func (m *memoryStore) ReleaseIdempotency(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.records[key].Done() {
+		delete(m.records, key)
+	}
+
+	return nil
+}
+
+func Test_New(t *testing.T) {
+	for _, base := range []string{"", "tasks.example.com", "/api", "http://%zz"} {
+		_, err := New(Config{BaseURL: base}, nil)
+		assert.Error(t, err, base)
+	}
+
+	c, err := New(Config{BaseURL: "https://tasks.example.com/api"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultRetry, c.cfg.Retry)
+	assert.Equal(t, http.DefaultClient, c.client)
+}
+
+// Test_Headers checks that the client sends the headers the server reads
+func Test_Headers(t *testing.T) {
+	assert.Equal(t, auth.HeaderUserID, headerUserID)
+	assert.Equal(t, auth.HeaderSignature, headerSignature)
+	assert.Equal(t, ratelimit.HeaderAPIKey, headerAPIKey)
+	assert.Equal(t, idempotency.Header, headerIdempotency)
+
+	c, a := setup(t)
+
+	a.tasks.EXPECT().GetTask(gomock.Any(), 4).DoAndReturn(func(ctx context.Context, id int) (task.Task, error) {
+		assert.Equal(t, auth.Actor{UserID: 1, Admin: true}, auth.FromContext(ctx))
+		return task.Task{ID: id}, nil
+	})
+	a.tasks.EXPECT().GetTask(gomock.Any(), 5).DoAndReturn(func(ctx context.Context, id int) (task.Task, error) {
+		assert.Equal(t, auth.Actor{UserID: 7}, auth.FromContext(ctx))
+		return task.Task{ID: id}, nil
+	})
+
+	_, err := c.GetTask(context.Background(), 4)
+	require.NoError(t, err)
+
+	_, err = c.GetTask(AsUser(context.Background(), 7), 5)
+	require.NoError(t, err)
+
+	for _, h := range a.faults.seen() {
+		assert.Equal(t, "secret", h.Get(headerAPIKey))
+		assert.Equal(t, UserAgent, h.Get("User-Agent"))
+	}
+
+	// The server's signatures are the client's
+	at := time.Unix(1714550400, 0)
+	assert.Equal(t, auth.Sign([]byte("shared"), 7, at), sign([]byte("shared"), 7, at))
+
+	// Without the secret the acting user is not believed
+	c.cfg.Secret = nil
+
+	_, err = c.GetTask(context.Background(), 4)
+	assert.ErrorIs(t, err, auth.ErrSignatureInvalid)
+}
+
+func Test_Retries(t *testing.T) {
+	tests := []struct {
+		name      string
+		statuses  []int
+		call      func(c *Client) error
+		expect    func(a *api)
+		expStatus int
+		expSent   int
+	}{
+		{
+			name:     "Read retried while unavailable",
+			statuses: []int{http.StatusServiceUnavailable, http.StatusGatewayTimeout},
+			call:     func(c *Client) error { _, err := c.GetTask(context.Background(), 4); return err },
+			expect:   func(a *api) { a.tasks.EXPECT().GetTask(gomock.Any(), 4).Return(task.Task{ID: 4}, nil) },
+			expSent:  3,
+		},
+		{
+			name:      "Attempts exhausted",
+			statuses:  []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable},
+			call:      func(c *Client) error { return c.CompleteTask(context.Background(), 4) },
+			expect:    func(a *api) {},
+			expStatus: http.StatusServiceUnavailable,
+			expSent:   3,
+		},
+		{
+			name:      "Unsafe call not repeated",
+			statuses:  []int{http.StatusServiceUnavailable},
+			call:      func(c *Client) error { return c.RestoreTask(context.Background(), 4) },
+			expect:    func(a *api) {},
+			expStatus: http.StatusServiceUnavailable,
+			expSent:   1,
+		},
+		{
+			name:     "Rate limited call repeated",
+			statuses: []int{http.StatusTooManyRequests},
+			call:     func(c *Client) error { return c.RestoreTask(context.Background(), 4) },
+			expect:   func(a *api) { a.tasks.EXPECT().Restore(gomock.Any(), 4).Return(nil) },
+			expSent:  2,
+		},
+		{
+			name:      "Internal errors not repeated",
+			statuses:  []int{http.StatusInternalServerError},
+			call:      func(c *Client) error { _, err := c.GetTask(context.Background(), 4); return err },
+			expect:    func(a *api) {},
+			expStatus: http.StatusInternalServerError,
+			expSent:   1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, a := setup(t)
+			a.faults.fail(tt.statuses...)
+			tt.expect(a)
+
+			err := tt.call(c)
+
+			if tt.expStatus == 0 {
+				require.NoError(t, err)
+			} else {
+				var e *Error
+				require.ErrorAs(t, err, &e)
+				assert.Equal(t, tt.expStatus, e.Status)
+			}
+
+			assert.Len(t, a.faults.seen(), tt.expSent)
+		})
+	}
+}
+
+func Test_RetriedCreation(t *testing.T) {
+	c, a := setup(t)
+
+	// The task is created once, though the first response is lost
+	a.tasks.EXPECT().Create(gomock.Any(), task.Task{Desc: "Write"}).Return(task.Task{ID: 9, Desc: "Write"}, nil)
+	a.faults.lose(1)
+
+	created, err := c.CreateTask(context.Background(), task.Task{Desc: "Write"})
+	require.NoError(t, err)
+	assert.Equal(t, task.Task{ID: 9, Desc: "Write"}, created)
+
+	seen := a.faults.seen()
+	require.Len(t, seen, 2)
+	assert.NotEmpty(t, seen[0].Get(headerIdempotency))
+	assert.Equal(t, seen[0].Get(headerIdempotency), seen[1].Get(headerIdempotency))
+
+	// A key of the caller's own is sent as is
+	a.tasks.EXPECT().Create(gomock.Any(), task.Task{Desc: "Ship"}).Return(task.Task{ID: 10, Desc: "Ship"}, nil)
+
+	_, err = c.CreateTask(WithIdempotencyKey(context.Background(), "order-42"), task.Task{Desc: "Ship"})
+	require.NoError(t, err)
+	assert.Equal(t, "order-42", a.faults.seen()[2].Get(headerIdempotency))
+}
+
+func Test_RetryCancelled(t *testing.T) {
+	c, a := setup(t)
+	c.cfg.Retry = RetryPolicy{MaxAttempts: 3, MinBackoff: time.Hour, MaxBackoff: time.Hour}
+	a.faults.fail(http.StatusServiceUnavailable)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := c.GetTask(ctx, 4)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func Test_Errors(t *testing.T) {
+	c, a := setup(t)
+
+	a.tasks.EXPECT().GetTask(gomock.Any(), 4).Return(task.Task{}, task.ErrNotFound)
+	a.tasks.EXPECT().Create(gomock.Any(), gomock.Any()).Return(task.Task{}, task.ErrEmptyDesc)
+
+	_, err := c.GetTask(context.Background(), 4)
+	assert.ErrorIs(t, err, task.ErrNotFound)
+	assert.NotErrorIs(t, err, task.ErrNoRevision)
+
+	var e *Error
+	require.ErrorAs(t, err, &e)
+	assert.Equal(t, http.StatusNotFound, e.Status)
+	assert.Equal(t, "task_not_found", e.Code)
+	assert.Equal(t, "/task/4", e.Instance)
+	assert.NotEmpty(t, e.RequestID)
+	assert.Contains(t, e.Error(), "404 Not Found (task_not_found): task not found")
+
+	var notFound *errs.NotFound
+	assert.ErrorAs(t, err, &notFound)
+
+	_, err = c.CreateTask(context.Background(), task.Task{})
+
+	var invalid *errs.Validation
+	require.ErrorAs(t, err, &invalid)
+	assert.Equal(t, []errs.FieldError{{Field: "desc", Message: "cannot be empty"}}, invalid.Fields)
+
+	// Responses without problem details keep their status
+	c.cfg.Retry.MaxAttempts = 1
+	a.faults.fail(http.StatusBadGateway)
+
+	_, err = c.GetTask(context.Background(), 4)
+	require.ErrorAs(t, err, &e)
+	assert.Equal(t, &Error{Status: http.StatusBadGateway, Title: "Bad Gateway"}, e)
+	assert.Nil(t, errors.Unwrap(err))
+}
+
+func Test_Backoff(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 5, MinBackoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond}
+
+	for attempt, expMax := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 3: 300 * time.Millisecond, 4: 300 * time.Millisecond} {
+		for range 20 {
+			d := p.backoff(attempt)
+			assert.GreaterOrEqual(t, d, expMax/2)
+			assert.LessOrEqual(t, d, expMax)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package client
+
+import (
+	"Task_Manager/model/errs"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxErrorBody caps how much of an unsuccessful response is read
+const maxErrorBody = 64 << 10
+
+// Error is an unsuccessful response, decoded from the RFC 7807 problem details the API answers with.
+// Responses without problem details, such as those of a proxy, keep their status and their body as
+// Detail. Code is stable and meant for programs to branch on; Title and Detail are meant for people.
+//
+// errors.Is matches an Error with the domain error of the same code, so errors.Is(err, task.ErrNotFound)
+// holds for a task that does not exist, and errors.As reaches the domain error kind of its status, so
+// a *errs.Validation holds the invalid fields of a 400.
+type Error struct {
+	Type      string            `json:"type"`
+	Title     string            `json:"title"`
+	Status    int               `json:"status"`
+	Detail    string            `json:"detail,omitempty"`
+	Instance  string            `json:"instance,omitempty"`
+	Code      string            `json:"code"`
+	Errors    []errs.FieldError `json:"errors,omitempty"`
+	RequestID string            `json:"request_id,omitempty"`
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("task manager: %d %s", e.Status, e.Title)
+	if e.Code != "" {
+		msg += " (" + e.Code + ")"
+	}
+
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	}
+
+	if e.RequestID != "" {
+		msg += " [request " + e.RequestID + "]"
+	}
+
+	return msg
+}
+
+// Is reports whether target is a domain error with the code of e
+func (e *Error) Is(target error) bool {
+	var code string
+
+	switch t := target.(type) {
+	case *errs.NotFound:
+		code = t.Code
+	case *errs.Validation:
+		code = t.Code
+	case *errs.Conflict:
+		code = t.Code
+	case *errs.Unauthorized:
+		code = t.Code
+	case *errs.Forbidden:
+		code = t.Code
+	case *errs.TooLarge:
+		code = t.Code
+	case *errs.Unsupported:
+		code = t.Code
+	case *errs.Unprocessable:
+		code = t.Code
+	case *errs.RateLimited:
+		code = t.Code
+	}
+
+	return code != "" && code == e.Code
+}
+
+// Unwrap returns the domain error kind the API maps onto the status of e, or nil for statuses no kind
+// maps onto
+func (e *Error) Unwrap() error {
+	switch e.Status {
+	case http.StatusNotFound:
+		return &errs.NotFound{Code: e.Code, Message: e.Detail}
+	case http.StatusBadRequest:
+		return &errs.Validation{Code: e.Code, Message: e.Detail, Fields: e.Errors}
+	case http.StatusConflict:
+		return &errs.Conflict{Code: e.Code, Message: e.Detail}
+	case http.StatusUnauthorized:
+		return &errs.Unauthorized{Code: e.Code, Message: e.Detail}
+	case http.StatusForbidden:
+		return &errs.Forbidden{Code: e.Code, Message: e.Detail}
+	case http.StatusRequestEntityTooLarge:
+		return &errs.TooLarge{Code: e.Code, Message: e.Detail}
+	case http.StatusUnsupportedMediaType:
+		return &errs.Unsupported{Code: e.Code, Message: e.Detail}
+	case http.StatusUnprocessableEntity:
+		return &errs.Unprocessable{Code: e.Code, Message: e.Detail}
+	case http.StatusTooManyRequests:
+		return &errs.RateLimited{Code: e.Code, Message: e.Detail}
+	default:
+		return nil
+	}
+}
+
+// decodeError reads the error of an unsuccessful response and closes it
+func decodeError(resp *http.Response) *Error {
+	defer discard(resp)
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+
+	var e Error
+	if err := json.Unmarshal(body, &e); err != nil || e.Code == "" {
+		e = Error{Title: http.StatusText(resp.StatusCode), Detail: strings.TrimSpace(string(body))}
+	}
+
+	e.Status = resp.StatusCode
+
+	return &e
+}
@@ -0,0 +1,96 @@
+package client
+
+import (
+	"context"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy decides how failed calls are retried. A call is retried when the API rate limited it,
+// which it does before serving it, and, if repeating it is harmless, when it failed in transit or the
+// API was unavailable (502, 503 or 504). Creations are sent with an idempotency key, so repeating them
+// is harmless; so is repeating reads, and the updates and deletions that leave the same state however
+// often they are applied. A repeated deletion may fail as not found when the first one was applied.
+type RetryPolicy struct {
+	// MaxAttempts caps the attempts of a call, the first one included; 1 disables retries
+	MaxAttempts int
+	// MinBackoff is the wait before the first retry. It doubles with each retry up to MaxBackoff, and each
+	// wait is shortened by a random amount of up to half, so clients failing together retry apart.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+// DefaultRetry makes up to three attempts, waiting about 100ms then 200ms
+var DefaultRetry = RetryPolicy{MaxAttempts: 3, MinBackoff: 100 * time.Millisecond, MaxBackoff: 5 * time.Second}
+
+// retries reports whether attempt, which got resp or err, is followed by another
+func (p RetryPolicy) retries(ctx context.Context, cl call, attempt int, resp *http.Response, err error) bool {
+	if attempt >= p.MaxAttempts || ctx.Err() != nil {
+		return false
+	}
+
+	if err != nil {
+		return cl.idempotent
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		// A wait longer than the policy allows would hold up the caller; it gets the error instead
+		wait, ok := retryAfter(resp.Header.Get("Retry-After"))
+		return !ok || wait <= p.MaxBackoff
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return cl.idempotent
+	default:
+		return false
+	}
+}
+
+// wait sleeps before the retry following attempt, for as long as the API asked with Retry-After or
+// else for the backoff of the policy
+func (p RetryPolicy) wait(ctx context.Context, attempt int, after string) error {
+	d, ok := retryAfter(after)
+	if !ok {
+		d = p.backoff(attempt)
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// backoff is the wait after attempt, from MinBackoff doubled per earlier retry up to MaxBackoff, less a
+// random jitter of up to half
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.MinBackoff
+	for i := 1; i < attempt && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+
+	if d <= 0 {
+		return 0
+	}
+
+	return d - rand.N(d/2+1)
+}
+
+// retryAfter parses a Retry-After header given in seconds, as the API sends it
+func retryAfter(v string) (time.Duration, bool) {
+	secs, err := strconv.Atoi(v)
+	if err != nil || secs < 0 {
+		return 0, false
+	}
+
+	return time.Duration(secs) * time.Second, true
+}
@@ -0,0 +1,217 @@
+package client
+
+import (
+	"Task_Manager/model/task"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// mediaNDJSON is the export format the task iterators read
+const mediaNDJSON = "application/x-ndjson"
+
+// LabelFilter selects the tasks tagged with any of Names, or all of them when MatchAll is set.
+// WorkspaceID restricts the names to one workspace; zero matches them in every workspace.
+type LabelFilter struct {
+	Names       []string
+	MatchAll    bool
+	WorkspaceID int
+}
+
+func (f LabelFilter) query() url.Values {
+	q := url.Values{}
+	if len(f.Names) == 0 {
+		return q
+	}
+
+	q.Set("labels", strings.Join(f.Names, ","))
+
+	if f.MatchAll {
+		q.Set("match", "all")
+	}
+
+	if f.WorkspaceID != 0 {
+		q.Set("workspace", strconv.Itoa(f.WorkspaceID))
+	}
+
+	return q
+}
+
+// CreateTask creates a task and returns it with its ID (POST /task)
+func (c *Client) CreateTask(ctx context.Context, t task.Task) (task.Task, error) {
+	var created task.Task
+	err := c.json(ctx, call{method: http.MethodPost, path: "/task", body: t, create: true}, &created)
+
+	return created, err
+}
+
+// GetTask returns a task (GET /task/{id})
+func (c *Client) GetTask(ctx context.Context, id int) (task.Task, error) {
+	var t task.Task
+	err := c.json(ctx, call{method: http.MethodGet, path: "/task/" + strconv.Itoa(id), idempotent: true}, &t)
+
+	return t, err
+}
+
+// GetTaskAsOf returns a task as it was at a point in time (GET /task/{id}?as_of=)
+func (c *Client) GetTaskAsOf(ctx context.Context, id int, at time.Time) (task.Task, error) {
+	var t task.Task
+	err := c.json(ctx, call{method: http.MethodGet, path: "/task/" + strconv.Itoa(id),
+		query: url.Values{"as_of": {at.Format(time.RFC3339)}}, idempotent: true}, &t)
+
+	return t, err
+}
+
+// CompleteTask marks a task as done (PUT /task/{id})
+func (c *Client) CompleteTask(ctx context.Context, id int) error {
+	return c.json(ctx, call{method: http.MethodPut, path: "/task/" + strconv.Itoa(id), idempotent: true}, nil)
+}
+
+// DeleteTask moves a task to the trash (DELETE /task/{id})
+func (c *Client) DeleteTask(ctx context.Context, id int) error {
+	return c.json(ctx, call{method: http.MethodDelete, path: "/task/" + strconv.Itoa(id), idempotent: true}, nil)
+}
+
+// Tasks returns the live tasks, only those matching f when it names labels (GET /task)
+func (c *Client) Tasks(ctx context.Context, f LabelFilter) ([]task.Task, error) {
+	var tasks []task.Task
+	err := c.json(ctx, call{method: http.MethodGet, path: "/task", query: f.query(), idempotent: true}, &tasks)
+
+	return tasks, err
+}
+
+// TasksOfUser returns the tasks assigned to a user (GET /task/user/{userid})
+func (c *Client) TasksOfUser(ctx context.Context, userID int) ([]task.Task, error) {
+	var tasks []task.Task
+	err := c.json(ctx, call{method: http.MethodGet, path: "/task/user/" + strconv.Itoa(userID), idempotent: true}, &tasks)
+
+	return tasks, err
+}
+
+// EachTask iterates over the tasks Tasks returns, reading them one at a time from the NDJSON export
+// rather than all at once, so listing many tasks takes little memory. An error ends the iteration.
+//
+// The API cannot report a failure once the export has started; it ends the export early instead, so
+// an iteration may end before the last task without an error.
+func (c *Client) EachTask(ctx context.Context, f LabelFilter) iter.Seq2[task.Task, error] {
+	return c.each(ctx, call{method: http.MethodGet, path: "/task", query: f.query(), accept: mediaNDJSON, idempotent: true})
+}
+
+// EachTaskOfUser iterates over the tasks TasksOfUser returns, as EachTask does
+func (c *Client) EachTaskOfUser(ctx context.Context, userID int) iter.Seq2[task.Task, error] {
+	return c.each(ctx, call{method: http.MethodGet, path: "/task/user/" + strconv.Itoa(userID), accept: mediaNDJSON, idempotent: true})
+}
+
+func (c *Client) each(ctx context.Context, cl call) iter.Seq2[task.Task, error] {
+	return func(yield func(task.Task, error) bool) {
+		resp, err := c.do(ctx, cl)
+		if err != nil {
+			yield(task.Task{}, err)
+			return
+		}
+
+		defer discard(resp)
+
+		dec := json.NewDecoder(resp.Body)
+
+		for {
+			var t task.Task
+
+			err := dec.Decode(&t)
+			if errors.Is(err, io.EOF) {
+				return
+			}
+
+			if err != nil {
+				yield(task.Task{}, err)
+				return
+			}
+
+			if !yield(t, nil) {
+				return
+			}
+		}
+	}
+}
+
+// TrashedTasks returns the deleted tasks that can still be restored (GET /trash/tasks)
+func (c *Client) TrashedTasks(ctx context.Context) ([]task.Trashed, error) {
+	var tasks []task.Trashed
+	err := c.json(ctx, call{method: http.MethodGet, path: "/trash/tasks", idempotent: true}, &tasks)
+
+	return tasks, err
+}
+
+// RestoreTask takes a task out of the trash (POST /task/{id}/restore)
+func (c *Client) RestoreTask(ctx context.Context, id int) error {
+	return c.json(ctx, call{method: http.MethodPost, path: "/task/" + strconv.Itoa(id) + "/restore"}, nil)
+}
+
+// Revisions returns the revisions of a task, oldest first (GET /task/{id}/revisions)
+func (c *Client) Revisions(ctx context.Context, id int) ([]task.Revision, error) {
+	var revisions []task.Revision
+	err := c.json(ctx, call{method: http.MethodGet, path: "/task/" + strconv.Itoa(id) + "/revisions", idempotent: true}, &revisions)
+
+	return revisions, err
+}
+
+// DiffRevisions lists the fields changed between two revisions of a task (GET /task/{id}/revisions/diff)
+func (c *Client) DiffRevisions(ctx context.Context, id, from, to int) ([]task.FieldChange, error) {
+	var changes []task.FieldChange
+	err := c.json(ctx, call{method: http.MethodGet, path: "/task/" + strconv.Itoa(id) + "/revisions/diff",
+		query: url.Values{"from": {strconv.Itoa(from)}, "to": {strconv.Itoa(to)}}, idempotent: true}, &changes)
+
+	return changes, err
+}
+
+// RevertTask returns a task to an earlier revision (POST /task/{id}/revert)
+func (c *Client) RevertTask(ctx context.Context, id, revision int) (task.Task, error) {
+	var t task.Task
+	err := c.json(ctx, call{method: http.MethodPost, path: "/task/" + strconv.Itoa(id) + "/revert",
+		body: map[string]int{"revision": revision}}, &t)
+
+	return t, err
+}
+
+// bulkResponse is the body answering POST /task/bulk
+type bulkResponse struct {
+	Results []task.BulkResult `json:"results"`
+}
+
+// BulkTasks applies a batch of operations (POST /task/bulk); atomic batches apply all of them or none.
+// A failed atomic batch returns the result of every operation, telling which to fix, together with
+// an error matching task.ErrBulkFailed.
+func (c *Client) BulkTasks(ctx context.Context, ops []task.BulkOp, atomic bool) ([]task.BulkResult, error) {
+	mode := "best_effort"
+	if atomic {
+		mode = "atomic"
+	}
+
+	resp, err := c.do(ctx, call{method: http.MethodPost, path: "/task/bulk", body: map[string]any{"mode": mode, "ops": ops},
+		answers: []int{http.StatusUnprocessableEntity}})
+	if err != nil {
+		return nil, err
+	}
+
+	defer discard(resp)
+
+	var body bulkResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("task manager: undecodable response: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusUnprocessableEntity {
+		return body.Results, &Error{Type: "/problems/" + task.ErrBulkFailed.Code, Title: http.StatusText(resp.StatusCode),
+			Status: resp.StatusCode, Detail: task.ErrBulkFailed.Message, Code: task.ErrBulkFailed.Code}
+	}
+
+	return body.Results, nil
+}
@@ -0,0 +1,186 @@
+package client
+
+import (
+	"Task_Manager/model/task"
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func Test_TaskLifecycle(t *testing.T) {
+	c, a := setup(t)
+	ctx := context.Background()
+	due := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	at := time.Date(2024, 5, 7, 12, 0, 0, 0, time.UTC)
+
+	gomock.InOrder(
+		a.tasks.EXPECT().Create(gomock.Any(), task.Task{Desc: "Write", Userid: 2, Due: &due}).
+			Return(task.Task{ID: 4, Desc: "Write", Userid: 2, Due: &due}, nil),
+		a.tasks.EXPECT().GetTask(gomock.Any(), 4).Return(task.Task{ID: 4, Desc: "Write", Userid: 2, Due: &due}, nil),
+		a.tasks.EXPECT().Complete(gomock.Any(), 4).Return(nil),
+		a.tasks.EXPECT().AsOf(gomock.Any(), 4, at).Return(task.Task{ID: 4, Desc: "Write"}, nil),
+		a.tasks.EXPECT().Delete(gomock.Any(), 4).Return(nil),
+		a.tasks.EXPECT().Trash(gomock.Any()).Return([]task.Trashed{{Task: task.Task{ID: 4, Desc: "Write"}, DeletedAt: at}}, nil),
+		a.tasks.EXPECT().Restore(gomock.Any(), 4).Return(nil),
+		a.tasks.EXPECT().Revert(gomock.Any(), 4, 1).Return(task.Task{ID: 4, Desc: "Write"}, nil),
+	)
+
+	created, err := c.CreateTask(ctx, task.Task{Desc: "Write", Userid: 2, Due: &due})
+	require.NoError(t, err)
+	assert.Equal(t, 4, created.ID)
+
+	got, err := c.GetTask(ctx, 4)
+	require.NoError(t, err)
+	assert.True(t, got.Due.Equal(due))
+
+	require.NoError(t, c.CompleteTask(ctx, 4))
+
+	past, err := c.GetTaskAsOf(ctx, 4, at)
+	require.NoError(t, err)
+	assert.Equal(t, "Write", past.Desc)
+
+	require.NoError(t, c.DeleteTask(ctx, 4))
+
+	trashed, err := c.TrashedTasks(ctx)
+	require.NoError(t, err)
+	require.Len(t, trashed, 1)
+	assert.True(t, trashed[0].DeletedAt.Equal(at))
+
+	require.NoError(t, c.RestoreTask(ctx, 4))
+
+	reverted, err := c.RevertTask(ctx, 4, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 4, reverted.ID)
+}
+
+func Test_Tasks(t *testing.T) {
+	c, a := setup(t)
+	ctx := context.Background()
+
+	a.tasks.EXPECT().All(gomock.Any()).Return([]task.Task{{ID: 1}, {ID: 2}}, nil)
+	a.tasks.EXPECT().ByLabels(gomock.Any(), 3, []string{"bug", "urgent"}, true).Return([]task.Task{{ID: 2}}, nil)
+	a.tasks.EXPECT().GetTasksByUserID(gomock.Any(), 7).Return([]task.Task{{ID: 1, Userid: 7}}, nil)
+
+	all, err := c.Tasks(ctx, LabelFilter{})
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+
+	labelled, err := c.Tasks(ctx, LabelFilter{Names: []string{"bug", "urgent"}, MatchAll: true, WorkspaceID: 3})
+	require.NoError(t, err)
+	assert.Equal(t, []task.Task{{ID: 2}}, labelled)
+
+	assigned, err := c.TasksOfUser(ctx, 7)
+	require.NoError(t, err)
+	assert.Equal(t, []task.Task{{ID: 1, Userid: 7}}, assigned)
+}
+
+// export answers an export with tasks 1 to n
+func export(n int) func(context.Context, task.Filter, func(task.Task) error) error {
+	return func(_ context.Context, _ task.Filter, fn func(task.Task) error) error {
+		for id := 1; id <= n; id++ {
+			if err := fn(task.Task{ID: id, Desc: "Task"}); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+func Test_EachTask(t *testing.T) {
+	c, a := setup(t)
+	ctx := context.Background()
+
+	a.tasks.EXPECT().Export(gomock.Any(), task.Filter{Labels: []string{"bug"}}, gomock.Any()).DoAndReturn(export(250))
+	a.tasks.EXPECT().Export(gomock.Any(), task.Filter{UserID: 7}, gomock.Any()).DoAndReturn(export(250))
+
+	var ids []int
+	for got, err := range c.EachTask(ctx, LabelFilter{Names: []string{"bug"}}) {
+		require.NoError(t, err)
+		ids = append(ids, got.ID)
+	}
+
+	require.Len(t, ids, 250)
+	assert.Equal(t, 250, ids[249])
+
+	// Stopping early closes the export
+	n := 0
+	for _, err := range c.EachTaskOfUser(ctx, 7) {
+		require.NoError(t, err)
+
+		if n++; n == 3 {
+			break
+		}
+	}
+
+	assert.Equal(t, 3, n)
+}
+
+func Test_EachTaskFailure(t *testing.T) {
+	c, a := setup(t)
+
+	a.tasks.EXPECT().Export(gomock.Any(), task.Filter{}, gomock.Any()).Return(errors.New("connection refused"))
+
+	var errs []error
+	for _, err := range c.EachTask(context.Background(), LabelFilter{}) {
+		errs = append(errs, err)
+	}
+
+	require.Len(t, errs, 1)
+
+	var e *Error
+	require.ErrorAs(t, errs[0], &e)
+	assert.Equal(t, http.StatusInternalServerError, e.Status)
+}
+
+func Test_Revisions(t *testing.T) {
+	c, a := setup(t)
+	ctx := context.Background()
+	at := time.Date(2024, 5, 7, 12, 0, 0, 0, time.UTC)
+
+	a.tasks.EXPECT().Revisions(gomock.Any(), 4).Return([]task.Revision{
+		{TaskID: 4, Number: 1, At: at, Task: task.Task{ID: 4, Desc: "Write"}},
+		{TaskID: 4, Number: 2, At: at, Task: task.Task{ID: 4, Desc: "Write", Status: true}},
+	}, nil)
+	a.tasks.EXPECT().DiffRevisions(gomock.Any(), 4, 1, 2).Return([]task.FieldChange{{Field: "status", From: false, To: true}}, nil)
+
+	revisions, err := c.Revisions(ctx, 4)
+	require.NoError(t, err)
+	require.Len(t, revisions, 2)
+	assert.True(t, revisions[1].Task.Status)
+
+	changes, err := c.DiffRevisions(ctx, 4, 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []task.FieldChange{{Field: "status", From: false, To: true}}, changes)
+}
+
+func Test_BulkTasks(t *testing.T) {
+	c, a := setup(t)
+	ctx := context.Background()
+	ops := []task.BulkOp{{Op: task.BulkComplete, ID: 1}, {Op: task.BulkDelete, ID: 2}}
+
+	a.tasks.EXPECT().Bulk(gomock.Any(), ops, false).Return([]task.BulkResult{
+		{Index: 0, Op: task.BulkComplete, ID: 1, Status: task.BulkOK},
+		{Index: 1, Op: task.BulkDelete, ID: 2, Status: task.BulkFailed, Error: "task not found"},
+	}, nil)
+	a.tasks.EXPECT().Bulk(gomock.Any(), ops, true).Return([]task.BulkResult{
+		{Index: 0, Op: task.BulkComplete, ID: 1, Status: task.BulkRolledBack},
+		{Index: 1, Op: task.BulkDelete, ID: 2, Status: task.BulkFailed, Error: "task not found"},
+	}, task.ErrBulkFailed)
+
+	results, err := c.BulkTasks(ctx, ops, false)
+	require.NoError(t, err)
+	assert.Equal(t, task.BulkFailed, results[1].Status)
+
+	// A failed atomic batch still tells which operations failed
+	results, err = c.BulkTasks(ctx, ops, true)
+	assert.ErrorIs(t, err, task.ErrBulkFailed)
+	require.Len(t, results, 2)
+	assert.Equal(t, task.BulkRolledBack, results[0].Status)
+}
@@ -0,0 +1,97 @@
+package client
+
+import (
+	"Task_Manager/model/user"
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// CreateUser creates a user and returns it with its ID and normalised email address (POST /users)
+func (c *Client) CreateUser(ctx context.Context, u user.User) (user.User, error) {
+	var created user.User
+	err := c.json(ctx, call{method: http.MethodPost, path: "/users", body: u, create: true}, &created)
+
+	return created, err
+}
+
+// GetUser returns a user (GET /users/{id})
+func (c *Client) GetUser(ctx context.Context, id int) (user.User, error) {
+	var u user.User
+	err := c.json(ctx, call{method: http.MethodGet, path: "/users/" + strconv.Itoa(id), idempotent: true}, &u)
+
+	return u, err
+}
+
+// Users returns the live users (GET /users)
+func (c *Client) Users(ctx context.Context) ([]user.User, error) {
+	var users []user.User
+	err := c.json(ctx, call{method: http.MethodGet, path: "/users", idempotent: true}, &users)
+
+	return users, err
+}
+
+// UpdateUser changes the set fields of the profile of a user (PATCH /users/{id}). Only the user or an
+// admin may change it.
+func (c *Client) UpdateUser(ctx context.Context, id int, p user.Profile) (user.User, error) {
+	var u user.User
+	err := c.json(ctx, call{method: http.MethodPatch, path: "/users/" + strconv.Itoa(id), body: p, idempotent: true}, &u)
+
+	return u, err
+}
+
+// ChangeEmail requests a new email address for a user, which takes effect once confirmed with the
+// token sent to it (POST /users/{id}/email)
+func (c *Client) ChangeEmail(ctx context.Context, id int, email string) (user.EmailChange, error) {
+	var change user.EmailChange
+	err := c.json(ctx, call{method: http.MethodPost, path: "/users/" + strconv.Itoa(id) + "/email",
+		body: map[string]string{"email": email}}, &change)
+
+	return change, err
+}
+
+// ConfirmEmail applies a requested email change with its confirmation token (POST /users/{id}/email/confirm)
+func (c *Client) ConfirmEmail(ctx context.Context, id int, token string) (user.User, error) {
+	var u user.User
+	err := c.json(ctx, call{method: http.MethodPost, path: "/users/" + strconv.Itoa(id) + "/email/confirm",
+		body: map[string]string{"token": token}}, &u)
+
+	return u, err
+}
+
+// DeleteUser moves a user to the trash, treating the user's tasks as opts says, or with opts.DryRun
+// reports what doing so would change (DELETE /users/{id}). An empty policy rejects the deletion of a
+// user who still has tasks, with an error matching user.ErrHasTasks.
+func (c *Client) DeleteUser(ctx context.Context, id int, opts user.DeleteOptions) (user.DeleteReport, error) {
+	q := url.Values{}
+	if opts.Policy != "" {
+		q.Set("policy", string(opts.Policy))
+	}
+
+	if opts.ReassignTo != 0 {
+		q.Set("reassign_to", strconv.Itoa(opts.ReassignTo))
+	}
+
+	if opts.DryRun {
+		q.Set("dry_run", "true")
+	}
+
+	var report user.DeleteReport
+	err := c.json(ctx, call{method: http.MethodDelete, path: "/users/" + strconv.Itoa(id), query: q, idempotent: true}, &report)
+
+	return report, err
+}
+
+// TrashedUsers returns the deleted users that can still be restored (GET /trash/users)
+func (c *Client) TrashedUsers(ctx context.Context) ([]user.Trashed, error) {
+	var users []user.Trashed
+	err := c.json(ctx, call{method: http.MethodGet, path: "/trash/users", idempotent: true}, &users)
+
+	return users, err
+}
+
+// RestoreUser takes a user out of the trash (POST /users/{id}/restore)
+func (c *Client) RestoreUser(ctx context.Context, id int) error {
+	return c.json(ctx, call{method: http.MethodPost, path: "/users/" + strconv.Itoa(id) + "/restore"}, nil)
+}
@@ -0,0 +1,93 @@
+package client
+
+import (
+	"Task_Manager/auth"
+	"Task_Manager/model/errs"
+	"Task_Manager/model/user"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func Test_UserLifecycle(t *testing.T) {
+	c, a := setup(t)
+	ctx := context.Background()
+	name := "Alice B."
+	expires := time.Date(2024, 5, 8, 12, 0, 0, 0, time.UTC)
+
+	gomock.InOrder(
+		a.users.EXPECT().Create(gomock.Any(), user.User{Name: "Alice", Email: "Alice@Example.com"}).
+			Return(user.User{ID: 2, Name: "Alice", Email: "alice@example.com"}, nil),
+		a.users.EXPECT().Get(gomock.Any(), 2).Return(user.User{ID: 2, Name: "Alice", Email: "alice@example.com"}, nil),
+		a.users.EXPECT().Update(gomock.Any(), auth.Actor{UserID: 1, Admin: true}, 2, user.Profile{Name: &name}).
+			Return(user.User{ID: 2, Name: name, Email: "alice@example.com"}, nil),
+		a.users.EXPECT().RequestEmailChange(gomock.Any(), auth.Actor{UserID: 1, Admin: true}, 2, "alice@work.example").
+			Return(user.EmailChange{UserID: 2, Email: "alice@work.example", ExpiresAt: expires}, nil),
+		a.users.EXPECT().ConfirmEmailChange(gomock.Any(), 2, "token").
+			Return(user.User{ID: 2, Name: name, Email: "alice@work.example"}, nil),
+		a.users.EXPECT().All(gomock.Any()).Return([]user.User{{ID: 1, Name: "Root"}, {ID: 2, Name: name}}, nil),
+		a.users.EXPECT().DeleteWithPolicy(gomock.Any(), 2, user.DeleteOptions{Policy: user.PolicyReassign, ReassignTo: 1}).
+			Return(user.DeleteReport{UserID: 2, Policy: user.PolicyReassign, ReassignTo: 1, Tasks: []int{4}}, nil),
+		a.users.EXPECT().Trash(gomock.Any()).Return([]user.Trashed{{User: user.User{ID: 2, Name: name}, DeletedAt: expires}}, nil),
+		a.users.EXPECT().Restore(gomock.Any(), 2).Return(nil),
+	)
+
+	created, err := c.CreateUser(ctx, user.User{Name: "Alice", Email: "Alice@Example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, "alice@example.com", created.Email)
+
+	got, err := c.GetUser(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, created, got)
+
+	updated, err := c.UpdateUser(ctx, 2, user.Profile{Name: &name})
+	require.NoError(t, err)
+	assert.Equal(t, name, updated.Name)
+
+	change, err := c.ChangeEmail(ctx, 2, "alice@work.example")
+	require.NoError(t, err)
+	assert.True(t, change.ExpiresAt.Equal(expires))
+
+	confirmed, err := c.ConfirmEmail(ctx, 2, "token")
+	require.NoError(t, err)
+	assert.Equal(t, "alice@work.example", confirmed.Email)
+
+	users, err := c.Users(ctx)
+	require.NoError(t, err)
+	assert.Len(t, users, 2)
+
+	report, err := c.DeleteUser(ctx, 2, user.DeleteOptions{Policy: user.PolicyReassign, ReassignTo: 1})
+	require.NoError(t, err)
+	assert.Equal(t, []int{4}, report.Tasks)
+
+	trashed, err := c.TrashedUsers(ctx)
+	require.NoError(t, err)
+	require.Len(t, trashed, 1)
+	assert.Equal(t, 2, trashed[0].ID)
+
+	require.NoError(t, c.RestoreUser(ctx, 2))
+}
+
+func Test_DeleteUserErrors(t *testing.T) {
+	c, a := setup(t)
+	ctx := context.Background()
+
+	a.users.EXPECT().DeleteWithPolicy(gomock.Any(), 2, user.DeleteOptions{}).
+		Return(user.DeleteReport{UserID: 2, Tasks: []int{4, 5}}, user.ErrHasTasks)
+	a.users.EXPECT().DeleteWithPolicy(gomock.Any(), 2, user.DeleteOptions{Policy: "archive", DryRun: true}).
+		Return(user.DeleteReport{}, user.ErrInvalidPolicy)
+
+	_, err := c.DeleteUser(ctx, 2, user.DeleteOptions{})
+	assert.ErrorIs(t, err, user.ErrHasTasks)
+
+	var conflict *errs.Conflict
+	require.ErrorAs(t, err, &conflict)
+	assert.Equal(t, "user 2 still has 2 tasks; choose a delete policy", conflict.Message)
+
+	_, err = c.DeleteUser(ctx, 2, user.DeleteOptions{Policy: "archive", DryRun: true})
+	assert.ErrorIs(t, err, user.ErrInvalidPolicy)
+}
@@ -1,26 +1,68 @@
 package config
 
 import (
+	"Task_Manager/store/replica"
+	"Task_Manager/tracing"
 	"database/sql"
-	"fmt"
-	"log"
+	"log/slog"
+	"os"
 
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
 )
 
 var DB *sql.DB
 
-func DataBaseConfig() {
+// Replicas are the read replicas of DB, empty when none are configured
+var Replicas *replica.Set
+
+func DataBaseConfig(settings Settings) {
 	var err error
-	DB, err = sql.Open("mysql", "root:root123@tcp(localhost:3306)/test_db?parseTime=true")
+	DB, err = openDB(settings, settings.DatabaseDSN)
 
 	if err != nil {
-		log.Fatal("DB connection error:", err)
+		slog.Error("DB connection error", "error", err)
+		os.Exit(1)
 	}
 
 	if err := DB.Ping(); err != nil {
-		log.Fatal("Cannot connect to DB:", err)
+		slog.Error("Cannot connect to DB", "error", err)
+		os.Exit(1)
+	}
+
+	slog.Info("Successfully connected to MySQL")
+
+	Replicas = replica.New(settings.DBReplicaMaxLag)
+
+	for _, dsn := range settings.DBReplicaDSNs {
+		cfg, err := mysql.ParseDSN(dsn)
+		if err != nil {
+			// The DSN is not logged, it may hold a password
+			slog.Error("Invalid replica DSN", "error", err)
+			os.Exit(1)
+		}
+
+		db, err := openDB(settings, dsn)
+		if err != nil {
+			slog.Error("Replica connection error", "replica", cfg.Addr, "error", err)
+			os.Exit(1)
+		}
+
+		// A replica that is down is only read once the health checks find it up
+		Replicas.Add(cfg.Addr, db)
+	}
+}
+
+// openDB opens a connection pool sized by the settings
+func openDB(settings Settings, dsn string) (*sql.DB, error) {
+	// Opened through tracing so every statement shows up in the trace of the request running it
+	db, err := tracing.OpenDB(&mysql.MySQLDriver{}, dsn)
+	if err != nil {
+		return nil, err
 	}
 
-	fmt.Println("Successfully connected to MySQL!")
+	db.SetMaxOpenConns(settings.DBMaxOpenConns)
+	db.SetMaxIdleConns(settings.DBMaxIdleConns)
+	db.SetConnMaxLifetime(settings.DBConnMaxLifetime)
+
+	return db, nil
 }
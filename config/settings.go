@@ -0,0 +1,294 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultAttachmentMaxBytes = 10 << 20
+
+// Settings holds the runtime options read from the environment
+type Settings struct {
+	// LogLevel is the lowest level logged: "debug", "info", "warn" or "error"
+	LogLevel string
+	// LogFormat is "json" for machine readable logs or "text" for reading in a terminal
+	LogFormat string
+
+	// TraceExporter is where spans are sent: "none", "stdout", "file" or "otlp"
+	TraceExporter string
+	// TraceFile receives the spans of the file exporter
+	TraceFile string
+	// TraceEndpoint is the OTLP/HTTP collector URL; the OTEL_EXPORTER_OTLP_* variables apply when unset
+	TraceEndpoint string
+	// TraceSampleRatio is the share of new traces recorded, from 0 to 1
+	TraceSampleRatio float64
+
+	// DatabaseDSN locates the primary database, which every write goes to
+	DatabaseDSN string
+	// DBMaxOpenConns caps the connections of each pool, in use or idle; zero means no limit
+	DBMaxOpenConns int
+	// DBMaxIdleConns is how many idle connections each pool keeps open
+	DBMaxIdleConns int
+	// DBConnMaxLifetime closes connections after a while, so they move to a failed-over or rebalanced server
+	DBConnMaxLifetime time.Duration
+
+	// DBReplicaDSNs locate the read replicas, comma separated; without any every read goes to the primary
+	DBReplicaDSNs []string
+	// DBReplicaMaxLag is how far behind the primary a replica may be and still be read
+	DBReplicaMaxLag time.Duration
+	// DBReplicaCheckInterval is how often the health and delay of the replicas are checked
+	DBReplicaCheckInterval time.Duration
+	// DBReadYourWritesWindow is how long the reads of a client go to the primary after it wrote. It should
+	// exceed DBReplicaMaxLag plus DBReplicaCheckInterval, the longest a healthy replica can be behind.
+	DBReadYourWritesWindow time.Duration
+
+	// RateLimitBackend keeps the token buckets in "memory", per instance, or in the "db", shared by all instances
+	RateLimitBackend string
+	// RateLimits are the per-route limits, e.g. "POST /task=10/1m:20, *=100/1s"; empty disables rate limiting
+	RateLimits string
+	// RateLimitCleanupInterval is how often idle buckets are removed from the database
+	RateLimitCleanupInterval time.Duration
+	// APIKeyHashes are the hex encoded SHA-256 hashes of the X-API-Key values clients are told apart by.
+	// Requests with any other key are limited by acting user or address.
+	APIKeyHashes []string
+
+	// IdempotencyKeyTTL is how long a retry with the same Idempotency-Key gets the first response
+	IdempotencyKeyTTL time.Duration
+	// IdempotencyCleanupInterval is how often expired idempotency keys are removed
+	IdempotencyCleanupInterval time.Duration
+
+	// CacheBackend keeps the cached task and user lookups in "memory", per instance, in "redis", shared by
+	// all instances, or "none" to read them from the database every time
+	CacheBackend string
+	// CacheSize caps the number of values of the memory backend
+	CacheSize int
+	// CacheTTL is how long a cached value is used; it bounds how stale a value missed by an invalidation gets
+	CacheTTL time.Duration
+
+	RedisAddr     string
+	RedisPassword string
+	RedisDB       int
+
+	// TaskQuotaDefault caps the tasks of each workspace; zero means no limit
+	TaskQuotaDefault int
+	// TaskQuotas overrides TaskQuotaDefault for some workspaces
+	TaskQuotas map[int]int
+
+	// AdminUserIDs are the users allowed to moderate content owned by others
+	AdminUserIDs []int
+	// AuthSecret verifies the X-User-Signature sent with X-User-ID
+	AuthSecret string
+	// AuthTrustHeader trusts X-User-ID as is when AuthSecret is empty, for deployments behind a front end
+	// that sets it and strips it from client requests. With neither, requests naming a user are rejected.
+	AuthTrustHeader bool
+	// AuthSignatureMaxAge is how long a signature is accepted after it was made
+	AuthSignatureMaxAge time.Duration
+
+	// GRPCAddr is where the gRPC API listens
+	GRPCAddr string
+
+	// AttachmentBackend selects where attachment content is kept: "local" or "s3"
+	AttachmentBackend string
+	// AttachmentDir is the root directory of the local attachment backend
+	AttachmentDir string
+	// AttachmentMaxBytes caps the size of a single attachment
+	AttachmentMaxBytes int64
+	// AttachmentTypes lists the MIME types accepted for attachments
+	AttachmentTypes []string
+
+	// TrashRetention is how long deleted tasks and users stay restorable before being purged
+	TrashRetention time.Duration
+	// TrashPurgeInterval is how often the purge job runs
+	TrashPurgeInterval time.Duration
+
+	S3Endpoint  string
+	S3Bucket    string
+	S3Region    string
+	S3AccessKey string
+	S3SecretKey string
+
+	// SMTPHost is the mail server notifications are sent through; without it messages are only logged
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	// SMTPFrom is the sender address of notifications
+	SMTPFrom string
+	// NotificationQueueSize bounds the notifications waiting to be sent
+	NotificationQueueSize int
+	// DigestInterval is how often the notifications kept for digests are sent
+	DigestInterval time.Duration
+}
+
+// LoadSettings reads Settings from environment variables, falling back to defaults
+func LoadSettings() Settings {
+	return Settings{
+		LogLevel:  envOr("LOG_LEVEL", "info"),
+		LogFormat: envOr("LOG_FORMAT", "json"),
+
+		TraceExporter:    envOr("TRACE_EXPORTER", "none"),
+		TraceFile:        envOr("TRACE_FILE", "traces.json"),
+		TraceEndpoint:    os.Getenv("TRACE_OTLP_ENDPOINT"),
+		TraceSampleRatio: envFloat("TRACE_SAMPLE_RATIO", 1),
+
+		DatabaseDSN:       envOr("DATABASE_DSN", "root:root123@tcp(localhost:3306)/test_db?parseTime=true"),
+		DBMaxOpenConns:    envInt("DB_MAX_OPEN_CONNS", 25),
+		DBMaxIdleConns:    envInt("DB_MAX_IDLE_CONNS", 10),
+		DBConnMaxLifetime: envDuration("DB_CONN_MAX_LIFETIME", 5*time.Minute),
+
+		DBReplicaDSNs:          strList(os.Getenv("DB_REPLICA_DSNS")),
+		DBReplicaMaxLag:        envDuration("DB_REPLICA_MAX_LAG", 5*time.Second),
+		DBReplicaCheckInterval: envDuration("DB_REPLICA_CHECK_INTERVAL", 5*time.Second),
+		DBReadYourWritesWindow: envDuration("DB_READ_YOUR_WRITES_WINDOW", 15*time.Second),
+
+		RateLimitBackend:         envOr("RATE_LIMIT_BACKEND", "memory"),
+		RateLimits:               os.Getenv("RATE_LIMITS"),
+		RateLimitCleanupInterval: envDuration("RATE_LIMIT_CLEANUP_INTERVAL", 10*time.Minute),
+		APIKeyHashes:             strList(os.Getenv("API_KEY_HASHES")),
+
+		IdempotencyKeyTTL:          envDuration("IDEMPOTENCY_KEY_TTL", 24*time.Hour),
+		IdempotencyCleanupInterval: envDuration("IDEMPOTENCY_CLEANUP_INTERVAL", time.Hour),
+
+		CacheBackend: envOr("CACHE_BACKEND", "memory"),
+		CacheSize:    envInt("CACHE_SIZE", 10000),
+		CacheTTL:     envDuration("CACHE_TTL", 30*time.Second),
+
+		RedisAddr:     envOr("REDIS_ADDR", "localhost:6379"),
+		RedisPassword: os.Getenv("REDIS_PASSWORD"),
+		RedisDB:       envInt("REDIS_DB", 0),
+
+		TaskQuotaDefault: envInt("TASK_QUOTA_DEFAULT", 0),
+		TaskQuotas:       intMap(os.Getenv("TASK_QUOTAS")),
+
+		AdminUserIDs:        intList(os.Getenv("ADMIN_USER_IDS")),
+		AuthSecret:          os.Getenv("AUTH_SECRET"),
+		AuthTrustHeader:     envBool("AUTH_TRUST_HEADER", false),
+		AuthSignatureMaxAge: envDuration("AUTH_SIGNATURE_MAX_AGE", 5*time.Minute),
+
+		GRPCAddr: envOr("GRPC_ADDR", ":9000"),
+
+		AttachmentBackend:  envOr("ATTACHMENT_BACKEND", "local"),
+		AttachmentDir:      envOr("ATTACHMENT_DIR", "data/attachments"),
+		AttachmentMaxBytes: int64(envInt("ATTACHMENT_MAX_BYTES", defaultAttachmentMaxBytes)),
+		AttachmentTypes: strList(envOr("ATTACHMENT_TYPES",
+			"image/png,image/jpeg,image/gif,image/webp,text/plain,application/pdf,application/json,application/zip,application/x-gzip")),
+
+		TrashRetention:     envDuration("TRASH_RETENTION", 30*24*time.Hour),
+		TrashPurgeInterval: envDuration("TRASH_PURGE_INTERVAL", time.Hour),
+
+		S3Endpoint:  os.Getenv("S3_ENDPOINT"),
+		S3Bucket:    os.Getenv("S3_BUCKET"),
+		S3Region:    os.Getenv("S3_REGION"),
+		S3AccessKey: os.Getenv("S3_ACCESS_KEY"),
+		S3SecretKey: os.Getenv("S3_SECRET_KEY"),
+
+		SMTPHost:              os.Getenv("SMTP_HOST"),
+		SMTPPort:              envInt("SMTP_PORT", 587),
+		SMTPUsername:          os.Getenv("SMTP_USERNAME"),
+		SMTPPassword:          os.Getenv("SMTP_PASSWORD"),
+		SMTPFrom:              envOr("SMTP_FROM", "tasks@localhost"),
+		NotificationQueueSize: envInt("NOTIFICATION_QUEUE_SIZE", 1000),
+		DigestInterval:        envDuration("DIGEST_INTERVAL", 24*time.Hour),
+	}
+}
+
+func envOr(key, fallback string) string {
+	if v, ok := os.LookupEnv(key); ok && v != "" {
+		return v
+	}
+
+	return fallback
+}
+
+func envInt(key string, fallback int) int {
+	v, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+
+	return v
+}
+
+func envFloat(key string, fallback float64) float64 {
+	v, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil {
+		return fallback
+	}
+
+	return v
+}
+
+func envBool(key string, fallback bool) bool {
+	v, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+
+	return v
+}
+
+func envDuration(key string, fallback time.Duration) time.Duration {
+	d, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || d <= 0 {
+		return fallback
+	}
+
+	return d
+}
+
+// strList parses a comma separated list, skipping empty entries
+func strList(raw string) []string {
+	var list []string
+
+	for _, part := range strings.Split(raw, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			list = append(list, part)
+		}
+	}
+
+	return list
+}
+
+// intList parses a comma separated list of integers, skipping malformed entries
+func intList(raw string) []int {
+	var ids []int
+
+	for _, part := range strings.Split(raw, ",") {
+		id, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids
+}
+
+// intMap parses a comma separated list of key:value integer pairs, skipping malformed entries
+func intMap(raw string) map[int]int {
+	m := make(map[int]int)
+
+	for _, part := range strings.Split(raw, ",") {
+		k, v, ok := strings.Cut(part, ":")
+		if !ok {
+			continue
+		}
+
+		key, err := strconv.Atoi(strings.TrimSpace(k))
+		if err != nil {
+			continue
+		}
+
+		value, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			continue
+		}
+
+		m[key] = value
+	}
+
+	return m
+}
@@ -0,0 +1,195 @@
+package attachment
+
+import (
+	"Task_Manager/auth"
+	"Task_Manager/handler/problem"
+	"Task_Manager/logging"
+	"Task_Manager/model/errs"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gorilla/mux"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+)
+
+// HeaderChecksum carries the hex SHA-256 of an attachment, sent by clients on upload and returned on download
+const HeaderChecksum = "X-Checksum-Sha256"
+
+// filePart is the multipart form field holding the uploaded file
+const filePart = "file"
+
+type Handler struct {
+	svc AttachmentServiceInterface
+}
+
+// NewHandler : Factory function to implement and return behaviour
+func NewHandler(s AttachmentServiceInterface) *Handler {
+	return &Handler{svc: s}
+}
+
+// Upload attachment as multipart/form-data (POST /task/{id}/attachments)
+func (h *Handler) Upload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	taskID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		problem.BadRequest(w, r, "invalid_id", "Invalid task ID")
+		return
+	}
+
+	mr, err := r.MultipartReader()
+	if err != nil {
+		problem.BadRequest(w, r, "invalid_body", "Expected multipart/form-data body: "+err.Error())
+		return
+	}
+
+	// Parts are consumed as a stream so the file is never buffered whole in memory
+	for {
+		part, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			problem.BadRequest(w, r, "attachment_file_required", "Missing \""+filePart+"\" part",
+				errs.FieldError{Field: filePart, Message: "is required"})
+			return
+		}
+
+		if err != nil {
+			problem.BadRequest(w, r, "invalid_body", "Malformed multipart body: "+err.Error())
+			return
+		}
+
+		if part.FormName() != filePart {
+			_ = part.Close()
+			continue
+		}
+
+		created, err := h.svc.Upload(r.Context(), auth.FromContext(r.Context()), taskID, part.FileName(), part, r.Header.Get(HeaderChecksum))
+		_ = part.Close()
+
+		if err != nil {
+			problem.Write(w, r, err)
+			return
+		}
+
+		writeJSON(w, r, http.StatusCreated, created)
+
+		return
+	}
+}
+
+// List attachments of a task (GET /task/{id}/attachments)
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	taskID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		problem.BadRequest(w, r, "invalid_id", "Invalid task ID")
+		return
+	}
+
+	attachments, err := h.svc.List(r.Context(), taskID)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, attachments)
+}
+
+// Download attachment content, honouring Range requests (GET /task/{id}/attachments/{attachmentid})
+func (h *Handler) Download(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	taskID, id, ok := ids(w, r)
+	if !ok {
+		return
+	}
+
+	a, content, err := h.svc.Open(r.Context(), taskID, id)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	defer func(content io.Closer) {
+		_ = content.Close()
+	}(content)
+
+	// Uploaded HTML or SVG must never render on the API origin: browsers download it and do not sniff a type
+	w.Header().Set("Content-Type", a.ContentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": a.Filename}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Etag", `"`+a.Checksum+`"`)
+	w.Header().Set(HeaderChecksum, a.Checksum)
+
+	http.ServeContent(w, r, a.Filename, a.CreatedAt, content)
+}
+
+// Delete attachment (DELETE /task/{id}/attachments/{attachmentid})
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	taskID, id, ok := ids(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.svc.Delete(r.Context(), auth.FromContext(r.Context()), taskID, id); err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := w.Write([]byte(fmt.Sprintf("Attachment %d deleted", id))); err != nil {
+		logging.FromContext(r.Context()).Error("response write failed", "error", err)
+	}
+}
+
+// ids parses the task and attachment IDs from the route, writing a 400 when either is malformed
+func ids(w http.ResponseWriter, r *http.Request) (taskID, id int, ok bool) {
+	vars := mux.Vars(r)
+
+	taskID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		problem.BadRequest(w, r, "invalid_id", "Invalid task ID")
+		return 0, 0, false
+	}
+
+	id, err = strconv.Atoi(vars["attachmentid"])
+	if err != nil {
+		problem.BadRequest(w, r, "invalid_id", "Invalid attachment ID")
+		return 0, 0, false
+	}
+
+	return taskID, id, true
+}
+
+func writeJSON(w http.ResponseWriter, r *http.Request, status int, v any) {
+	resp, err := json.Marshal(v)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if _, err := w.Write(resp); err != nil {
+		logging.FromContext(r.Context()).Error("response write failed", "error", err)
+	}
+}
@@ -0,0 +1,259 @@
+package attachment
+
+import (
+	"Task_Manager/auth"
+	"Task_Manager/model/attachment"
+	"bytes"
+	"database/sql"
+	"errors"
+	"github.com/gorilla/mux"
+	"go.uber.org/mock/gomock"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type readSeekCloser struct {
+	*strings.Reader
+}
+
+func (readSeekCloser) Close() error {
+	return nil
+}
+
+func multipartBody(t *testing.T, field, filename, content string) (*bytes.Buffer, string) {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	mw := multipart.NewWriter(&buf)
+	_ = mw.WriteField("note", "ignored")
+
+	fw, err := mw.CreateFormFile(field, filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, _ = fw.Write([]byte(content))
+	_ = mw.Close()
+
+	return &buf, mw.FormDataContentType()
+}
+
+// Test_NewHandler : To test that interface is correctly implemented or not
+func Test_NewHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockSvc := NewMockAttachmentServiceInterface(ctrl)
+
+	if h := NewHandler(mockSvc); h.svc != mockSvc {
+		t.Error("Expected service to be assigned correctly")
+	}
+}
+
+// Test_Upload : Tests attachment is uploaded or not
+func Test_Upload(t *testing.T) {
+	tests := []struct {
+		name        string
+		taskID      string
+		field       string
+		contentType string
+		mockErr     error
+		callSvc     bool
+		expCode     int
+	}{
+		{"Valid upload", "1", "file", "", nil, true, http.StatusCreated},
+		{"Invalid task id", "abc", "file", "", nil, false, http.StatusBadRequest},
+		{"Not multipart", "1", "file", "application/json", nil, false, http.StatusBadRequest},
+		{"Missing file part", "1", "other", "", nil, false, http.StatusBadRequest},
+		{"Too large", "1", "file", "", attachment.ErrTooLarge, true, http.StatusRequestEntityTooLarge},
+		{"Type not allowed", "1", "file", "", attachment.ErrTypeNotAllowed, true, http.StatusUnsupportedMediaType},
+		{"Checksum mismatch", "1", "file", "", attachment.ErrChecksumMismatch, true, http.StatusBadRequest},
+		{"Task missing", "1", "file", "", sql.ErrNoRows, true, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mock := NewMockAttachmentServiceInterface(ctrl)
+			h := NewHandler(mock)
+
+			if tt.callSvc {
+				mock.EXPECT().Upload(gomock.Any(), auth.Actor{UserID: 2}, 1, "shot.png", gomock.Any(), "abc").
+					DoAndReturn(func(_, _ any, _ int, _ string, r io.Reader, _ string) (attachment.Attachment, error) {
+						body, _ := io.ReadAll(r)
+						if string(body) != "PNGDATA" {
+							t.Errorf("unexpected body %q", body)
+						}
+
+						return attachment.Attachment{ID: 1}, tt.mockErr
+					})
+			}
+
+			body, ct := multipartBody(t, tt.field, "shot.png", "PNGDATA")
+			if tt.contentType != "" {
+				ct = tt.contentType
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/task/1/attachments", body)
+			req.Header.Set("Content-Type", ct)
+			req.Header.Set(HeaderChecksum, "abc")
+			req = req.WithContext(auth.WithActor(req.Context(), auth.Actor{UserID: 2}))
+			req = mux.SetURLVars(req, map[string]string{"id": tt.taskID})
+
+			rec := httptest.NewRecorder()
+			h.Upload(rec, req)
+
+			if rec.Code != tt.expCode {
+				t.Errorf("Expected status %d, got %d: %s", tt.expCode, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+// Test_Upload_WrongMethod : Tests that only POST is accepted
+func Test_Upload_WrongMethod(t *testing.T) {
+	h := NewHandler(NewMockAttachmentServiceInterface(gomock.NewController(t)))
+
+	rec := httptest.NewRecorder()
+	h.Upload(rec, httptest.NewRequest(http.MethodGet, "/task/1/attachments", nil))
+
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status %d, got %d", http.StatusMethodNotAllowed, rec.Code)
+	}
+}
+
+// Test_List : Tests attachments are listed or not
+func Test_List(t *testing.T) {
+	tests := []struct {
+		name    string
+		taskID  string
+		mockErr error
+		callSvc bool
+		expCode int
+	}{
+		{"Valid task", "1", nil, true, http.StatusOK},
+		{"Invalid task id", "x", nil, false, http.StatusBadRequest},
+		{"Task missing", "1", sql.ErrNoRows, true, http.StatusNotFound},
+		{"Store failure", "1", errors.New("db down"), true, http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mock := NewMockAttachmentServiceInterface(ctrl)
+			h := NewHandler(mock)
+
+			if tt.callSvc {
+				mock.EXPECT().List(gomock.Any(), 1).Return([]attachment.Attachment{{ID: 1}}, tt.mockErr)
+			}
+
+			req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/task/1/attachments", nil), map[string]string{"id": tt.taskID})
+			rec := httptest.NewRecorder()
+			h.List(rec, req)
+
+			if rec.Code != tt.expCode {
+				t.Errorf("Expected status %d, got %d", tt.expCode, rec.Code)
+			}
+		})
+	}
+}
+
+// Test_Download : Tests attachment content is served with Range support
+func Test_Download(t *testing.T) {
+	stored := attachment.Attachment{ID: 4, TaskID: 1, Filename: "log.txt", ContentType: "text/plain", Checksum: "abc", CreatedAt: time.Now()}
+	vars := map[string]string{"id": "1", "attachmentid": "4"}
+
+	t.Run("Full content", func(t *testing.T) {
+		mock := NewMockAttachmentServiceInterface(gomock.NewController(t))
+		mock.EXPECT().Open(gomock.Any(), 1, 4).Return(stored, readSeekCloser{strings.NewReader("0123456789")}, nil)
+
+		rec := httptest.NewRecorder()
+		NewHandler(mock).Download(rec, mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/", nil), vars))
+
+		if rec.Code != http.StatusOK || rec.Body.String() != "0123456789" {
+			t.Errorf("unexpected response %d %q", rec.Code, rec.Body.String())
+		}
+
+		if rec.Header().Get(HeaderChecksum) != "abc" || rec.Header().Get("Content-Disposition") != `attachment; filename=log.txt` ||
+			rec.Header().Get("X-Content-Type-Options") != "nosniff" {
+			t.Errorf("missing download headers: %v", rec.Header())
+		}
+	})
+
+	t.Run("Range request", func(t *testing.T) {
+		mock := NewMockAttachmentServiceInterface(gomock.NewController(t))
+		mock.EXPECT().Open(gomock.Any(), 1, 4).Return(stored, readSeekCloser{strings.NewReader("0123456789")}, nil)
+
+		req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/", nil), vars)
+		req.Header.Set("Range", "bytes=2-4")
+
+		rec := httptest.NewRecorder()
+		NewHandler(mock).Download(rec, req)
+
+		if rec.Code != http.StatusPartialContent || rec.Body.String() != "234" {
+			t.Errorf("unexpected response %d %q", rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		mock := NewMockAttachmentServiceInterface(gomock.NewController(t))
+		mock.EXPECT().Open(gomock.Any(), 1, 4).Return(attachment.Attachment{}, nil, attachment.ErrNotFound)
+
+		rec := httptest.NewRecorder()
+		NewHandler(mock).Download(rec, mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/", nil), vars))
+
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d, got %d", http.StatusNotFound, rec.Code)
+		}
+	})
+
+	t.Run("Invalid attachment id", func(t *testing.T) {
+		mock := NewMockAttachmentServiceInterface(gomock.NewController(t))
+
+		rec := httptest.NewRecorder()
+		NewHandler(mock).Download(rec, mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/", nil),
+			map[string]string{"id": "1", "attachmentid": "x"}))
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, rec.Code)
+		}
+	})
+}
+
+// Test_Delete : Tests attachment is deleted or not
+func Test_Delete(t *testing.T) {
+	vars := map[string]string{"id": "1", "attachmentid": "4"}
+
+	tests := []struct {
+		name    string
+		method  string
+		mockErr error
+		callSvc bool
+		expCode int
+	}{
+		{"Valid delete", http.MethodDelete, nil, true, http.StatusOK},
+		{"Not found", http.MethodDelete, attachment.ErrNotFound, true, http.StatusNotFound},
+		{"wrong HTTP method", http.MethodGet, nil, false, http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := NewMockAttachmentServiceInterface(gomock.NewController(t))
+
+			if tt.callSvc {
+				mock.EXPECT().Delete(gomock.Any(), gomock.Any(), 1, 4).Return(tt.mockErr)
+			}
+
+			rec := httptest.NewRecorder()
+			NewHandler(mock).Delete(rec, mux.SetURLVars(httptest.NewRequest(tt.method, "/", nil), vars))
+
+			if rec.Code != tt.expCode {
+				t.Errorf("Expected status %d, got %d", tt.expCode, rec.Code)
+			}
+		})
+	}
+}
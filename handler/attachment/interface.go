@@ -0,0 +1,15 @@
+package attachment
+
+import (
+	"Task_Manager/auth"
+	"Task_Manager/model/attachment"
+	"context"
+	"io"
+)
+
+type AttachmentServiceInterface interface {
+	Upload(ctx context.Context, actor auth.Actor, taskID int, filename string, r io.Reader, checksum string) (attachment.Attachment, error)
+	List(ctx context.Context, taskID int) ([]attachment.Attachment, error)
+	Open(ctx context.Context, taskID, id int) (attachment.Attachment, io.ReadSeekCloser, error)
+	Delete(ctx context.Context, actor auth.Actor, taskID, id int) error
+}
@@ -0,0 +1,104 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: interface.go
+//
+// Generated by this command:
+//
+//	mockgen -source=interface.go -destination=mock_interface.go -package=attachment
+//
+
+// Package attachment is a generated GoMock package.
+package attachment
+
+import (
+	auth "Task_Manager/auth"
+	attachment "Task_Manager/model/attachment"
+	context "context"
+	io "io"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAttachmentServiceInterface is a mock of AttachmentServiceInterface interface.
+type MockAttachmentServiceInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockAttachmentServiceInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockAttachmentServiceInterfaceMockRecorder is the mock recorder for MockAttachmentServiceInterface.
+type MockAttachmentServiceInterfaceMockRecorder struct {
+	mock *MockAttachmentServiceInterface
+}
+
+// NewMockAttachmentServiceInterface creates a new mock instance.
+func NewMockAttachmentServiceInterface(ctrl *gomock.Controller) *MockAttachmentServiceInterface {
+	mock := &MockAttachmentServiceInterface{ctrl: ctrl}
+	mock.recorder = &MockAttachmentServiceInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAttachmentServiceInterface) EXPECT() *MockAttachmentServiceInterfaceMockRecorder {
+	return m.recorder
+}
+
+// Delete mocks base method.
+func (m *MockAttachmentServiceInterface) Delete(ctx context.Context, actor auth.Actor, taskID, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, actor, taskID, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockAttachmentServiceInterfaceMockRecorder) Delete(ctx, actor, taskID, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockAttachmentServiceInterface)(nil).Delete), ctx, actor, taskID, id)
+}
+
+// List mocks base method.
+func (m *MockAttachmentServiceInterface) List(ctx context.Context, taskID int) ([]attachment.Attachment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, taskID)
+	ret0, _ := ret[0].([]attachment.Attachment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockAttachmentServiceInterfaceMockRecorder) List(ctx, taskID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockAttachmentServiceInterface)(nil).List), ctx, taskID)
+}
+
+// Open mocks base method.
+func (m *MockAttachmentServiceInterface) Open(ctx context.Context, taskID, id int) (attachment.Attachment, io.ReadSeekCloser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Open", ctx, taskID, id)
+	ret0, _ := ret[0].(attachment.Attachment)
+	ret1, _ := ret[1].(io.ReadSeekCloser)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Open indicates an expected call of Open.
+func (mr *MockAttachmentServiceInterfaceMockRecorder) Open(ctx, taskID, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Open", reflect.TypeOf((*MockAttachmentServiceInterface)(nil).Open), ctx, taskID, id)
+}
+
+// Upload mocks base method.
+func (m *MockAttachmentServiceInterface) Upload(ctx context.Context, actor auth.Actor, taskID int, filename string, r io.Reader, checksum string) (attachment.Attachment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Upload", ctx, actor, taskID, filename, r, checksum)
+	ret0, _ := ret[0].(attachment.Attachment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Upload indicates an expected call of Upload.
+func (mr *MockAttachmentServiceInterfaceMockRecorder) Upload(ctx, actor, taskID, filename, r, checksum any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Upload", reflect.TypeOf((*MockAttachmentServiceInterface)(nil).Upload), ctx, actor, taskID, filename, r, checksum)
+}
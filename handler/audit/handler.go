@@ -0,0 +1,186 @@
+package audit
+
+import (
+	"Task_Manager/auth"
+	"Task_Manager/handler/problem"
+	"Task_Manager/logging"
+	"Task_Manager/model/audit"
+	"Task_Manager/model/errs"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+type Handler struct {
+	svc AuditServiceInterface
+}
+
+// NewHandler : Factory function to implement and return behaviour
+func NewHandler(s AuditServiceInterface) *Handler {
+	return &Handler{svc: s}
+}
+
+// List audit entries (GET /audit?actor=&action=&entity=&entity_id=&since=&until=&after_id=&limit=)
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	f, err := parseFilter(r.URL.Query())
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	entries, err := h.svc.Query(r.Context(), auth.FromContext(r.Context()), f)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	if entries == nil {
+		entries = []audit.Entry{}
+	}
+
+	writeJSON(w, r, http.StatusOK, entries)
+}
+
+// Export audit entries as NDJSON (GET /audit/export), accepting the same filters as List
+func (h *Handler) Export(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	f, err := parseFilter(r.URL.Query())
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	enc := json.NewEncoder(w)
+	flusher, _ := w.(http.Flusher)
+	started := false
+	written := 0
+
+	err = h.svc.Export(r.Context(), auth.FromContext(r.Context()), f, func(e audit.Entry) error {
+		if !started {
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			w.Header().Set("Content-Disposition", `attachment; filename="audit.ndjson"`)
+			w.WriteHeader(http.StatusOK)
+			started = true
+		}
+
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+
+		written++
+		if flusher != nil && written%100 == 0 {
+			flusher.Flush()
+		}
+
+		return nil
+	})
+
+	switch {
+	case err != nil && !started:
+		problem.Write(w, r, err)
+	case err != nil:
+		// The status line is already sent; all that can be done is to cut the stream short
+		logging.FromContext(r.Context()).Error("audit export aborted", "error", err)
+	case !started:
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// Verify the audit hash chain (GET /audit/verify)
+func (h *Handler) Verify(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	report, err := h.svc.Verify(r.Context(), auth.FromContext(r.Context()))
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, report)
+}
+
+func parseFilter(q url.Values) (audit.Filter, error) {
+	var (
+		f   audit.Filter
+		err error
+	)
+
+	f.Action = q.Get("action")
+	f.Entity = q.Get("entity")
+
+	ints := []struct {
+		key string
+		dst *int
+	}{
+		{"actor", &f.ActorID},
+		{"entity_id", &f.EntityID},
+		{"limit", &f.Limit},
+	}
+
+	for _, p := range ints {
+		if raw := q.Get(p.key); raw != "" {
+			if *p.dst, err = strconv.Atoi(raw); err != nil {
+				return f, invalidParam(p.key, "must be a number")
+			}
+		}
+	}
+
+	if raw := q.Get("after_id"); raw != "" {
+		if f.AfterID, err = strconv.ParseInt(raw, 10, 64); err != nil {
+			return f, invalidParam("after_id", "must be a number")
+		}
+	}
+
+	times := []struct {
+		key string
+		dst *time.Time
+	}{
+		{"since", &f.Since},
+		{"until", &f.Until},
+	}
+
+	for _, p := range times {
+		if raw := q.Get(p.key); raw != "" {
+			if *p.dst, err = time.Parse(time.RFC3339, raw); err != nil {
+				return f, invalidParam(p.key, "must be an RFC 3339 time")
+			}
+		}
+	}
+
+	return f, nil
+}
+
+// invalidParam reports a malformed query parameter as an invalid audit filter
+func invalidParam(key, message string) error {
+	return errs.Invalid(audit.ErrInvalidFilter.Code, "invalid "+key, errs.FieldError{Field: key, Message: message})
+}
+
+func writeJSON(w http.ResponseWriter, r *http.Request, status int, v any) {
+	resp, err := json.Marshal(v)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if _, err := w.Write(resp); err != nil {
+		logging.FromContext(r.Context()).Error("response write failed", "error", err)
+	}
+}
@@ -0,0 +1,146 @@
+package audit
+
+import (
+	"Task_Manager/auth"
+	"Task_Manager/model/audit"
+	"bufio"
+	"context"
+	"errors"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var admin = auth.Actor{UserID: 1, Admin: true}
+
+func adminRequest(method, target string) *http.Request {
+	req := httptest.NewRequest(method, target, nil)
+	return req.WithContext(auth.WithActor(context.Background(), admin))
+}
+
+// Test_NewHandler : To test that interface is correctly implemented or not
+func Test_NewHandler(t *testing.T) {
+	mockSvc := NewMockAuditServiceInterface(gomock.NewController(t))
+
+	if h := NewHandler(mockSvc); h.svc != mockSvc {
+		t.Error("Expected service to be assigned correctly")
+	}
+}
+
+// Test_List : Tests audit entries are listed with filters
+func Test_List(t *testing.T) {
+	since := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		method  string
+		query   string
+		filter  audit.Filter
+		mockErr error
+		callSvc bool
+		expCode int
+	}{
+		{"All filters", http.MethodGet, "?actor=3&action=delete&entity=task&entity_id=9&since=2024-05-01T00:00:00Z&after_id=10&limit=20",
+			audit.Filter{ActorID: 3, Action: "delete", Entity: "task", EntityID: 9, Since: since, AfterID: 10, Limit: 20}, nil, true, http.StatusOK},
+		{"No filters", http.MethodGet, "", audit.Filter{}, nil, true, http.StatusOK},
+		{"Invalid actor", http.MethodGet, "?actor=x", audit.Filter{}, nil, false, http.StatusBadRequest},
+		{"Invalid since", http.MethodGet, "?since=yesterday", audit.Filter{}, nil, false, http.StatusBadRequest},
+		{"Invalid after_id", http.MethodGet, "?after_id=x", audit.Filter{}, nil, false, http.StatusBadRequest},
+		{"Forbidden", http.MethodGet, "", audit.Filter{}, audit.ErrForbidden, true, http.StatusForbidden},
+		{"Invalid filter", http.MethodGet, "?limit=9999", audit.Filter{Limit: 9999}, audit.ErrInvalidFilter, true, http.StatusBadRequest},
+		{"Store error", http.MethodGet, "", audit.Filter{}, errors.New("db down"), true, http.StatusInternalServerError},
+		{"Wrong method", http.MethodPost, "", audit.Filter{}, nil, false, http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := NewMockAuditServiceInterface(gomock.NewController(t))
+			h := NewHandler(mockSvc)
+
+			if tt.callSvc {
+				mockSvc.EXPECT().Query(gomock.Any(), admin, tt.filter).Return(nil, tt.mockErr)
+			}
+
+			rec := httptest.NewRecorder()
+			h.List(rec, adminRequest(tt.method, "/audit"+tt.query))
+
+			if rec.Code != tt.expCode {
+				t.Errorf("Expected status %d, got %d", tt.expCode, rec.Code)
+			}
+
+			if rec.Code == http.StatusOK && strings.TrimSpace(rec.Body.String()) != "[]" {
+				t.Errorf("Expected an empty JSON array, got %q", rec.Body.String())
+			}
+		})
+	}
+}
+
+// Test_Export : Tests audit entries are streamed as NDJSON
+func Test_Export(t *testing.T) {
+	mockSvc := NewMockAuditServiceInterface(gomock.NewController(t))
+	h := NewHandler(mockSvc)
+
+	mockSvc.EXPECT().Export(gomock.Any(), admin, audit.Filter{Entity: "user"}, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ auth.Actor, _ audit.Filter, fn func(audit.Entry) error) error {
+			for i := 1; i <= 3; i++ {
+				if err := fn(audit.Entry{ID: int64(i), Entity: "user"}); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+
+	rec := httptest.NewRecorder()
+	h.Export(rec, adminRequest(http.MethodGet, "/audit/export?entity=user"))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+
+	if ct := rec.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("Expected NDJSON content type, got %q", ct)
+	}
+
+	lines := 0
+	for sc := bufio.NewScanner(rec.Body); sc.Scan(); lines++ {
+	}
+
+	if lines != 3 {
+		t.Errorf("Expected 3 lines, got %d", lines)
+	}
+
+	mockSvc.EXPECT().Export(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(audit.ErrForbidden)
+
+	rec = httptest.NewRecorder()
+	h.Export(rec, httptest.NewRequest(http.MethodGet, "/audit/export", nil))
+
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403, got %d", rec.Code)
+	}
+}
+
+// Test_Verify : Tests the hash chain report is returned
+func Test_Verify(t *testing.T) {
+	mockSvc := NewMockAuditServiceInterface(gomock.NewController(t))
+	h := NewHandler(mockSvc)
+
+	mockSvc.EXPECT().Verify(gomock.Any(), admin).Return(audit.VerifyReport{OK: false, Entries: 5, BrokenAt: 4}, nil)
+
+	rec := httptest.NewRecorder()
+	h.Verify(rec, adminRequest(http.MethodGet, "/audit/verify"))
+
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"broken_at":4`) {
+		t.Errorf("Unexpected response %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	h.Verify(rec, adminRequest(http.MethodPost, "/audit/verify"))
+
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405, got %d", rec.Code)
+	}
+}
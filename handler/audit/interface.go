@@ -0,0 +1,13 @@
+package audit
+
+import (
+	"Task_Manager/auth"
+	"Task_Manager/model/audit"
+	"context"
+)
+
+type AuditServiceInterface interface {
+	Query(ctx context.Context, actor auth.Actor, f audit.Filter) ([]audit.Entry, error)
+	Export(ctx context.Context, actor auth.Actor, f audit.Filter, fn func(audit.Entry) error) error
+	Verify(ctx context.Context, actor auth.Actor) (audit.VerifyReport, error)
+}
@@ -0,0 +1,87 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: interface.go
+//
+// Generated by this command:
+//
+//	mockgen -source=interface.go -destination=mock_interface.go -package=audit
+//
+
+// Package audit is a generated GoMock package.
+package audit
+
+import (
+	auth "Task_Manager/auth"
+	audit "Task_Manager/model/audit"
+	context "context"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAuditServiceInterface is a mock of AuditServiceInterface interface.
+type MockAuditServiceInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuditServiceInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockAuditServiceInterfaceMockRecorder is the mock recorder for MockAuditServiceInterface.
+type MockAuditServiceInterfaceMockRecorder struct {
+	mock *MockAuditServiceInterface
+}
+
+// NewMockAuditServiceInterface creates a new mock instance.
+func NewMockAuditServiceInterface(ctrl *gomock.Controller) *MockAuditServiceInterface {
+	mock := &MockAuditServiceInterface{ctrl: ctrl}
+	mock.recorder = &MockAuditServiceInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuditServiceInterface) EXPECT() *MockAuditServiceInterfaceMockRecorder {
+	return m.recorder
+}
+
+// Export mocks base method.
+func (m *MockAuditServiceInterface) Export(ctx context.Context, actor auth.Actor, f audit.Filter, fn func(audit.Entry) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Export", ctx, actor, f, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Export indicates an expected call of Export.
+func (mr *MockAuditServiceInterfaceMockRecorder) Export(ctx, actor, f, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Export", reflect.TypeOf((*MockAuditServiceInterface)(nil).Export), ctx, actor, f, fn)
+}
+
+// Query mocks base method.
+func (m *MockAuditServiceInterface) Query(ctx context.Context, actor auth.Actor, f audit.Filter) ([]audit.Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Query", ctx, actor, f)
+	ret0, _ := ret[0].([]audit.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Query indicates an expected call of Query.
+func (mr *MockAuditServiceInterfaceMockRecorder) Query(ctx, actor, f any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Query", reflect.TypeOf((*MockAuditServiceInterface)(nil).Query), ctx, actor, f)
+}
+
+// Verify mocks base method.
+func (m *MockAuditServiceInterface) Verify(ctx context.Context, actor auth.Actor) (audit.VerifyReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Verify", ctx, actor)
+	ret0, _ := ret[0].(audit.VerifyReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Verify indicates an expected call of Verify.
+func (mr *MockAuditServiceInterfaceMockRecorder) Verify(ctx, actor any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Verify", reflect.TypeOf((*MockAuditServiceInterface)(nil).Verify), ctx, actor)
+}
@@ -0,0 +1,30 @@
+// Package authn resolves the acting user of REST requests, answering with problem details when the
+// user headers do not hold up
+package authn
+
+import (
+	"Task_Manager/auth"
+	"Task_Manager/handler/problem"
+	"net/http"
+)
+
+// Middleware resolves the acting user from the X-User-ID and X-User-Signature headers and stores it
+// in the request context
+func Middleware(a *auth.Authenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			actor, err := a.Actor(r.Header.Get(auth.HeaderUserID), r.Header.Get(auth.HeaderSignature))
+			if err != nil {
+				problem.Write(w, r, err)
+				return
+			}
+
+			if actor.UserID == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(auth.WithActor(r.Context(), actor)))
+		})
+	}
+}
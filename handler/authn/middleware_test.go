@@ -0,0 +1,56 @@
+package authn
+
+import (
+	"Task_Manager/auth"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Middleware(t *testing.T) {
+	secret := []byte("s3cret")
+
+	tests := []struct {
+		name      string
+		cfg       auth.Config
+		header    string
+		signature string
+		expCode   int
+		expActor  auth.Actor
+	}{
+		{"Anonymous", auth.Config{}, "", "", http.StatusOK, auth.Actor{}},
+		{"Trusted admin", auth.Config{TrustHeader: true, AdminIDs: []int{1}}, "1", "", http.StatusOK, auth.Actor{UserID: 1, Admin: true}},
+		{"Malformed header", auth.Config{TrustHeader: true}, "abc", "", http.StatusBadRequest, auth.Actor{}},
+		{"Unverifiable header", auth.Config{AdminIDs: []int{1}}, "1", "", http.StatusUnauthorized, auth.Actor{}},
+		{"Signed user", auth.Config{Secret: secret}, "7", auth.Sign(secret, 7, time.Now()), http.StatusOK, auth.Actor{UserID: 7}},
+		{"Unsigned user", auth.Config{Secret: secret}, "7", "", http.StatusUnauthorized, auth.Actor{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got auth.Actor
+
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = auth.FromContext(r.Context())
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(auth.HeaderUserID, tt.header)
+			}
+
+			if tt.signature != "" {
+				req.Header.Set(auth.HeaderSignature, tt.signature)
+			}
+
+			rec := httptest.NewRecorder()
+			Middleware(auth.New(tt.cfg))(next).ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expCode, rec.Code)
+			assert.Equal(t, tt.expActor, got)
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package comment
+
+import (
+	"Task_Manager/auth"
+	"Task_Manager/handler/problem"
+	"Task_Manager/logging"
+	"Task_Manager/model/comment"
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/mux"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+type Handler struct {
+	svc CommentServiceInterface
+}
+
+// NewHandler : Factory function to implement and return behaviour
+func NewHandler(s CommentServiceInterface) *Handler {
+	return &Handler{svc: s}
+}
+
+// Create comment or reply (POST /task/{id}/comments)
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	taskID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		problem.BadRequest(w, r, "invalid_id", "Invalid task ID")
+		return
+	}
+
+	var c comment.Comment
+	if !decodeBody(w, r, &c) {
+		return
+	}
+
+	c.TaskID = taskID
+
+	created, err := h.svc.Create(r.Context(), auth.FromContext(r.Context()), c)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusCreated, created)
+}
+
+// List comments of a task as a thread (GET /task/{id}/comments)
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	taskID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		problem.BadRequest(w, r, "invalid_id", "Invalid task ID")
+		return
+	}
+
+	comments, err := h.svc.List(r.Context(), taskID)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, comments)
+}
+
+// Edit comment body (PUT /task/{id}/comments/{commentid})
+func (h *Handler) Edit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	taskID, id, ok := ids(w, r)
+	if !ok {
+		return
+	}
+
+	var c comment.Comment
+	if !decodeBody(w, r, &c) {
+		return
+	}
+
+	updated, err := h.svc.Edit(r.Context(), auth.FromContext(r.Context()), taskID, id, c.Body)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, updated)
+}
+
+// Delete comment (DELETE /task/{id}/comments/{commentid})
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	taskID, id, ok := ids(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.svc.Delete(r.Context(), auth.FromContext(r.Context()), taskID, id); err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := w.Write([]byte(fmt.Sprintf("Comment %d deleted", id))); err != nil {
+		logging.FromContext(r.Context()).Error("response write failed", "error", err)
+	}
+}
+
+// History of a comment's previous bodies (GET /task/{id}/comments/{commentid}/history)
+func (h *Handler) History(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	taskID, id, ok := ids(w, r)
+	if !ok {
+		return
+	}
+
+	edits, err := h.svc.History(r.Context(), taskID, id)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, edits)
+}
+
+// ids parses the task and comment IDs from the route, writing a 400 when either is malformed
+func ids(w http.ResponseWriter, r *http.Request) (taskID, id int, ok bool) {
+	vars := mux.Vars(r)
+
+	taskID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		problem.BadRequest(w, r, "invalid_id", "Invalid task ID")
+		return 0, 0, false
+	}
+
+	id, err = strconv.Atoi(vars["commentid"])
+	if err != nil {
+		problem.BadRequest(w, r, "invalid_id", "Invalid comment ID")
+		return 0, 0, false
+	}
+
+	return taskID, id, true
+}
+
+func decodeBody(w http.ResponseWriter, r *http.Request, v any) bool {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		problem.BadRequest(w, r, "invalid_body", "Failed to read request body: "+err.Error())
+		return false
+	}
+
+	defer func(Body io.ReadCloser) {
+		_ = Body.Close()
+	}(r.Body)
+
+	if err = json.Unmarshal(body, v); err != nil {
+		problem.BadRequest(w, r, "invalid_body", "Invalid JSON format: "+err.Error())
+		return false
+	}
+
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, r *http.Request, status int, v any) {
+	resp, err := json.Marshal(v)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if _, err := w.Write(resp); err != nil {
+		logging.FromContext(r.Context()).Error("response write failed", "error", err)
+	}
+}
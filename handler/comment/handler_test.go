@@ -0,0 +1,242 @@
+package comment
+
+import (
+	"Task_Manager/auth"
+	"Task_Manager/model/comment"
+	"database/sql"
+	"errors"
+	"github.com/gorilla/mux"
+	"go.uber.org/mock/gomock"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// errReader : Functionality is used to pass the empty and incorrect body to handle the edge case
+type errReader struct{}
+
+func (errReader) Read(_ []byte) (int, error) {
+	return 0, io.ErrUnexpectedEOF
+}
+
+func newRequest(method, body string, vars map[string]string, actor auth.Actor) *http.Request {
+	var rd io.Reader = strings.NewReader(body)
+	if body == "<err>" {
+		rd = errReader{}
+	}
+
+	req := httptest.NewRequest(method, "/task/1/comments", rd)
+	req = req.WithContext(auth.WithActor(req.Context(), actor))
+
+	return mux.SetURLVars(req, vars)
+}
+
+// Test_NewHandler : To test that interface is correctly implemented or not
+func Test_NewHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockSvc := NewMockCommentServiceInterface(ctrl)
+
+	h := NewHandler(mockSvc)
+
+	if h.svc != mockSvc {
+		t.Error("Expected service to be assigned correctly")
+	}
+}
+
+// Test_Create : Tests comment is created or not
+func Test_Create(t *testing.T) {
+	actor := auth.Actor{UserID: 2}
+
+	tests := []struct {
+		name    string
+		method  string
+		taskID  string
+		body    string
+		mockErr error
+		callSvc bool
+		expCode int
+	}{
+		{"Valid comment", http.MethodPost, "1", `{"body":"hello"}`, nil, true, http.StatusCreated},
+		{"Invalid task id", http.MethodPost, "abc", `{"body":"hello"}`, nil, false, http.StatusBadRequest},
+		{"Invalid JSON", http.MethodPost, "1", `bad json`, nil, false, http.StatusBadRequest},
+		{"Read error", http.MethodPost, "1", `<err>`, nil, false, http.StatusBadRequest},
+		{"Validation error", http.MethodPost, "1", `{"body":""}`, comment.ErrEmptyBody, true, http.StatusBadRequest},
+		{"Forbidden", http.MethodPost, "1", `{"body":"hello"}`, comment.ErrForbidden, true, http.StatusForbidden},
+		{"Task missing", http.MethodPost, "1", `{"body":"hello"}`, sql.ErrNoRows, true, http.StatusNotFound},
+		{"wrong HTTP method", http.MethodGet, "1", ``, nil, false, http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mock := NewMockCommentServiceInterface(ctrl)
+			h := NewHandler(mock)
+
+			if tt.callSvc {
+				mock.EXPECT().Create(gomock.Any(), actor, gomock.Any()).Return(comment.Comment{ID: 1, TaskID: 1}, tt.mockErr)
+			}
+
+			rec := httptest.NewRecorder()
+			h.Create(rec, newRequest(tt.method, tt.body, map[string]string{"id": tt.taskID}, actor))
+
+			if rec.Code != tt.expCode {
+				t.Errorf("Expected status %d, got %d", tt.expCode, rec.Code)
+			}
+		})
+	}
+}
+
+// Test_List : Tests comment thread is retrieved or not
+func Test_List(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		taskID  string
+		mockErr error
+		callSvc bool
+		expCode int
+	}{
+		{"Valid task", http.MethodGet, "1", nil, true, http.StatusOK},
+		{"Invalid task id", http.MethodGet, "abc", nil, false, http.StatusBadRequest},
+		{"Task missing", http.MethodGet, "1", sql.ErrNoRows, true, http.StatusNotFound},
+		{"Store failure", http.MethodGet, "1", errors.New("db down"), true, http.StatusInternalServerError},
+		{"wrong HTTP method", http.MethodPost, "1", nil, false, http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mock := NewMockCommentServiceInterface(ctrl)
+			h := NewHandler(mock)
+
+			if tt.callSvc {
+				mock.EXPECT().List(gomock.Any(), 1).Return([]comment.Comment{{ID: 1}}, tt.mockErr)
+			}
+
+			rec := httptest.NewRecorder()
+			h.List(rec, newRequest(tt.method, "", map[string]string{"id": tt.taskID}, auth.Actor{}))
+
+			if rec.Code != tt.expCode {
+				t.Errorf("Expected status %d, got %d", tt.expCode, rec.Code)
+			}
+		})
+	}
+}
+
+// Test_Edit : Tests comment is edited or not
+func Test_Edit(t *testing.T) {
+	actor := auth.Actor{UserID: 2}
+
+	tests := []struct {
+		name    string
+		method  string
+		vars    map[string]string
+		body    string
+		mockErr error
+		callSvc bool
+		expCode int
+	}{
+		{"Valid edit", http.MethodPut, map[string]string{"id": "1", "commentid": "3"}, `{"body":"new"}`, nil, true, http.StatusOK},
+		{"Invalid task id", http.MethodPut, map[string]string{"id": "x", "commentid": "3"}, `{"body":"new"}`, nil, false, http.StatusBadRequest},
+		{"Invalid comment id", http.MethodPut, map[string]string{"id": "1", "commentid": "x"}, `{"body":"new"}`, nil, false, http.StatusBadRequest},
+		{"Invalid JSON", http.MethodPut, map[string]string{"id": "1", "commentid": "3"}, `nope`, nil, false, http.StatusBadRequest},
+		{"Not author", http.MethodPut, map[string]string{"id": "1", "commentid": "3"}, `{"body":"new"}`, comment.ErrForbidden, true, http.StatusForbidden},
+		{"Not found", http.MethodPut, map[string]string{"id": "1", "commentid": "3"}, `{"body":"new"}`, comment.ErrNotFound, true, http.StatusNotFound},
+		{"wrong HTTP method", http.MethodPost, map[string]string{"id": "1", "commentid": "3"}, ``, nil, false, http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mock := NewMockCommentServiceInterface(ctrl)
+			h := NewHandler(mock)
+
+			if tt.callSvc {
+				mock.EXPECT().Edit(gomock.Any(), actor, 1, 3, "new").Return(comment.Comment{ID: 3, Body: "new"}, tt.mockErr)
+			}
+
+			rec := httptest.NewRecorder()
+			h.Edit(rec, newRequest(tt.method, tt.body, tt.vars, actor))
+
+			if rec.Code != tt.expCode {
+				t.Errorf("Expected status %d, got %d", tt.expCode, rec.Code)
+			}
+		})
+	}
+}
+
+// Test_Delete : Tests comment is deleted or not
+func Test_Delete(t *testing.T) {
+	actor := auth.Actor{UserID: 2}
+	vars := map[string]string{"id": "1", "commentid": "3"}
+
+	tests := []struct {
+		name    string
+		method  string
+		mockErr error
+		callSvc bool
+		expCode int
+	}{
+		{"Valid delete", http.MethodDelete, nil, true, http.StatusOK},
+		{"Not author", http.MethodDelete, comment.ErrForbidden, true, http.StatusForbidden},
+		{"Not found", http.MethodDelete, comment.ErrNotFound, true, http.StatusNotFound},
+		{"wrong HTTP method", http.MethodGet, nil, false, http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mock := NewMockCommentServiceInterface(ctrl)
+			h := NewHandler(mock)
+
+			if tt.callSvc {
+				mock.EXPECT().Delete(gomock.Any(), actor, 1, 3).Return(tt.mockErr)
+			}
+
+			rec := httptest.NewRecorder()
+			h.Delete(rec, newRequest(tt.method, "", vars, actor))
+
+			if rec.Code != tt.expCode {
+				t.Errorf("Expected status %d, got %d", tt.expCode, rec.Code)
+			}
+		})
+	}
+}
+
+// Test_History : Tests edit history is retrieved or not
+func Test_History(t *testing.T) {
+	vars := map[string]string{"id": "1", "commentid": "3"}
+
+	tests := []struct {
+		name    string
+		method  string
+		mockErr error
+		callSvc bool
+		expCode int
+	}{
+		{"Valid history", http.MethodGet, nil, true, http.StatusOK},
+		{"Not found", http.MethodGet, comment.ErrNotFound, true, http.StatusNotFound},
+		{"wrong HTTP method", http.MethodPost, nil, false, http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mock := NewMockCommentServiceInterface(ctrl)
+			h := NewHandler(mock)
+
+			if tt.callSvc {
+				mock.EXPECT().History(gomock.Any(), 1, 3).Return([]comment.Edit{{CommentID: 3, Body: "v1"}}, tt.mockErr)
+			}
+
+			rec := httptest.NewRecorder()
+			h.History(rec, newRequest(tt.method, "", vars, auth.Actor{}))
+
+			if rec.Code != tt.expCode {
+				t.Errorf("Expected status %d, got %d", tt.expCode, rec.Code)
+			}
+		})
+	}
+}
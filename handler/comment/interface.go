@@ -0,0 +1,15 @@
+package comment
+
+import (
+	"Task_Manager/auth"
+	"Task_Manager/model/comment"
+	"context"
+)
+
+type CommentServiceInterface interface {
+	Create(ctx context.Context, actor auth.Actor, c comment.Comment) (comment.Comment, error)
+	List(ctx context.Context, taskID int) ([]comment.Comment, error)
+	Edit(ctx context.Context, actor auth.Actor, taskID, id int, body string) (comment.Comment, error)
+	Delete(ctx context.Context, actor auth.Actor, taskID, id int) error
+	History(ctx context.Context, taskID, id int) ([]comment.Edit, error)
+}
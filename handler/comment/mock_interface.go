@@ -0,0 +1,117 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: interface.go
+//
+// Generated by this command:
+//
+//	mockgen -source=interface.go -destination=mock_interface.go -package=comment
+//
+
+// Package comment is a generated GoMock package.
+package comment
+
+import (
+	auth "Task_Manager/auth"
+	comment "Task_Manager/model/comment"
+	context "context"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockCommentServiceInterface is a mock of CommentServiceInterface interface.
+type MockCommentServiceInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockCommentServiceInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockCommentServiceInterfaceMockRecorder is the mock recorder for MockCommentServiceInterface.
+type MockCommentServiceInterfaceMockRecorder struct {
+	mock *MockCommentServiceInterface
+}
+
+// NewMockCommentServiceInterface creates a new mock instance.
+func NewMockCommentServiceInterface(ctrl *gomock.Controller) *MockCommentServiceInterface {
+	mock := &MockCommentServiceInterface{ctrl: ctrl}
+	mock.recorder = &MockCommentServiceInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCommentServiceInterface) EXPECT() *MockCommentServiceInterfaceMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockCommentServiceInterface) Create(ctx context.Context, actor auth.Actor, c comment.Comment) (comment.Comment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, actor, c)
+	ret0, _ := ret[0].(comment.Comment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockCommentServiceInterfaceMockRecorder) Create(ctx, actor, c any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockCommentServiceInterface)(nil).Create), ctx, actor, c)
+}
+
+// Delete mocks base method.
+func (m *MockCommentServiceInterface) Delete(ctx context.Context, actor auth.Actor, taskID, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, actor, taskID, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockCommentServiceInterfaceMockRecorder) Delete(ctx, actor, taskID, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockCommentServiceInterface)(nil).Delete), ctx, actor, taskID, id)
+}
+
+// Edit mocks base method.
+func (m *MockCommentServiceInterface) Edit(ctx context.Context, actor auth.Actor, taskID, id int, body string) (comment.Comment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Edit", ctx, actor, taskID, id, body)
+	ret0, _ := ret[0].(comment.Comment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Edit indicates an expected call of Edit.
+func (mr *MockCommentServiceInterfaceMockRecorder) Edit(ctx, actor, taskID, id, body any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Edit", reflect.TypeOf((*MockCommentServiceInterface)(nil).Edit), ctx, actor, taskID, id, body)
+}
+
+// History mocks base method.
+func (m *MockCommentServiceInterface) History(ctx context.Context, taskID, id int) ([]comment.Edit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "History", ctx, taskID, id)
+	ret0, _ := ret[0].([]comment.Edit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// History indicates an expected call of History.
+func (mr *MockCommentServiceInterfaceMockRecorder) History(ctx, taskID, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "History", reflect.TypeOf((*MockCommentServiceInterface)(nil).History), ctx, taskID, id)
+}
+
+// List mocks base method.
+func (m *MockCommentServiceInterface) List(ctx context.Context, taskID int) ([]comment.Comment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, taskID)
+	ret0, _ := ret[0].([]comment.Comment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockCommentServiceInterfaceMockRecorder) List(ctx, taskID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockCommentServiceInterface)(nil).List), ctx, taskID)
+}
@@ -0,0 +1,82 @@
+package graphql
+
+import (
+	"Task_Manager/metrics"
+	"Task_Manager/model/task"
+	"context"
+	"sync"
+)
+
+var dropped = metrics.Default.NewCounterVec("graphql_task_changes_dropped_total",
+	"Task changes not delivered to a subscription whose client fell behind")
+
+// SubscriptionBuffer is how many changes a subscription holds for its client. Changes arriving while it
+// is full are dropped rather than holding up the request that made them.
+const SubscriptionBuffer = 64
+
+// Feed hands the task changes made through this instance to the subscriptions of its clients. It is
+// kept per instance: behind a load balancer a client only sees the changes made through the instance
+// it subscribed to.
+type Feed struct {
+	mu   sync.Mutex
+	subs map[*subscription]struct{}
+}
+
+type subscription struct {
+	// userID selects the changes of the tasks assigned to one user; zero selects every change
+	userID  int
+	changes chan task.Change
+}
+
+func NewFeed() *Feed {
+	return &Feed{subs: make(map[*subscription]struct{})}
+}
+
+// Publish hands c to every subscription interested in it. It never blocks, so it can run as a change
+// hook of the task service.
+func (f *Feed) Publish(c task.Change) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for s := range f.subs {
+		if s.userID != 0 && (c.Task == nil || c.Task.Userid != s.userID) {
+			continue
+		}
+
+		select {
+		case s.changes <- c:
+		default:
+			dropped.Inc()
+		}
+	}
+}
+
+// Subscribe returns the changes of the tasks assigned to userID, of every task when it is zero, until
+// ctx is done; the channel is then closed.
+func (f *Feed) Subscribe(ctx context.Context, userID int) <-chan task.Change {
+	s := &subscription{userID: userID, changes: make(chan task.Change, SubscriptionBuffer)}
+
+	f.mu.Lock()
+	f.subs[s] = struct{}{}
+	f.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		f.mu.Lock()
+		delete(f.subs, s)
+		f.mu.Unlock()
+
+		close(s.changes)
+	}()
+
+	return s.changes
+}
+
+// Len is the number of open subscriptions
+func (f *Feed) Len() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return len(f.subs)
+}
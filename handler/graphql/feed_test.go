@@ -0,0 +1,62 @@
+package graphql
+
+import (
+	"Task_Manager/model/task"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Feed(t *testing.T) {
+	t.Run("Subscriptions get the changes they select", func(t *testing.T) {
+		f := NewFeed()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		all := f.Subscribe(ctx, 0)
+		bob := f.Subscribe(ctx, 2)
+
+		f.Publish(task.Change{Action: "create", TaskID: 1, Task: &task.Task{ID: 1, Userid: 2}})
+		f.Publish(task.Change{Action: "create", TaskID: 2, Task: &task.Task{ID: 2, Userid: 3}})
+		f.Publish(task.Change{Action: "purge", TaskID: 1})
+
+		require.Len(t, all, 3)
+		require.Len(t, bob, 1)
+		require.Equal(t, 1, (<-bob).TaskID)
+	})
+
+	t.Run("A full subscription drops changes instead of blocking", func(t *testing.T) {
+		f := NewFeed()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		changes := f.Subscribe(ctx, 0)
+
+		for i := range SubscriptionBuffer + 5 {
+			f.Publish(task.Change{TaskID: i})
+		}
+
+		require.Len(t, changes, SubscriptionBuffer)
+	})
+
+	t.Run("Ending the subscription closes its changes", func(t *testing.T) {
+		f := NewFeed()
+		ctx, cancel := context.WithCancel(context.Background())
+
+		changes := f.Subscribe(ctx, 0)
+		require.Equal(t, 1, f.Len())
+
+		cancel()
+
+		select {
+		case _, ok := <-changes:
+			require.False(t, ok)
+		case <-time.After(time.Second):
+			t.Fatal("changes not closed")
+		}
+
+		require.Zero(t, f.Len())
+	})
+}
@@ -0,0 +1,222 @@
+// Package graphql serves tasks, users and their relationships over GraphQL at /graphql, next to the
+// REST routes and through the same services. Each request batches its lookups of related users and
+// tasks, so listing users with their tasks takes one query for the users and one for all their tasks.
+// Subscriptions are streamed as server-sent events.
+package graphql
+
+import (
+	"Task_Manager/handler/problem"
+	"Task_Manager/logging"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	gql "github.com/graph-gophers/graphql-go"
+)
+
+//go:embed schema.graphql
+var schema string
+
+// MaxBody caps the size of a request body
+const MaxBody = 1 << 20
+
+// MaxDepth bounds the nesting of a query, and with it the stores it can reach from one request
+const MaxDepth = 8
+
+// KeepAlive is how often an idle subscription sends a comment, so proxies keep its connection open
+var KeepAlive = 15 * time.Second
+
+// mediaEvents is the media type of server-sent events
+const mediaEvents = "text/event-stream"
+
+type Handler struct {
+	schema *gql.Schema
+	tasks  TaskServiceInterface
+	users  UserServiceInterface
+}
+
+// NewHandler : Factory function to implement and return behaviour. Subscriptions receive the changes
+// published to feed.
+func NewHandler(tasks TaskServiceInterface, users UserServiceInterface, feed *Feed) *Handler {
+	r := &resolver{tasks: tasks, users: users, feed: feed}
+
+	return &Handler{
+		schema: gql.MustParseSchema(schema, r, gql.UseStringDescriptions(), gql.MaxDepth(MaxDepth)),
+		tasks:  tasks,
+		users:  users,
+	}
+}
+
+// params are the parameters of a GraphQL request
+type params struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// ServeHTTP runs a GraphQL request (POST /graphql, or GET /graphql?query=&operationName=&variables=).
+// Requests sent with GET may only query; their reads can go to the replicas. A subscription is
+// answered with server-sent events when the client accepts text/event-stream.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var p params
+
+	switch r.Method {
+	case http.MethodGet:
+		q := r.URL.Query()
+		p.Query, p.OperationName = q.Get("query"), q.Get("operationName")
+
+		if raw := q.Get("variables"); raw != "" {
+			if err := json.Unmarshal([]byte(raw), &p.Variables); err != nil {
+				problem.BadRequest(w, r, "invalid_variables", "Invalid JSON in variables: "+err.Error())
+				return
+			}
+		}
+	case http.MethodPost:
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxBody))
+		if err != nil {
+			problem.Write(w, r, err)
+			return
+		}
+
+		if err := json.Unmarshal(body, &p); err != nil {
+			problem.BadRequest(w, r, "invalid_body", "Invalid JSON format: "+err.Error())
+			return
+		}
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if strings.TrimSpace(p.Query) == "" {
+		problem.BadRequest(w, r, "query_required", "A GraphQL query is required")
+		return
+	}
+
+	ctx := withLoaders(r.Context(), newLoaders(h.tasks, h.users))
+	if r.Method == http.MethodGet {
+		ctx = readOnly(ctx)
+	}
+
+	if acceptsEvents(r.Header.Get("Accept")) {
+		h.stream(w, r.WithContext(ctx), p)
+		return
+	}
+
+	resp := h.schema.Exec(ctx, p.Query, p.OperationName, p.Variables)
+	writeJSON(w, r, http.StatusOK, resp)
+}
+
+// acceptsEvents reports whether the Accept header asks for server-sent events
+func acceptsEvents(accept string) bool {
+	for _, part := range strings.Split(accept, ",") {
+		if mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part)); err == nil && mediaType == mediaEvents {
+			return true
+		}
+	}
+
+	return false
+}
+
+// stream answers with server-sent events: a next event per result, and a complete event once the
+// operation ends. Queries and mutations send one result; subscriptions send one per change until the
+// client disconnects.
+func (h *Handler) stream(w http.ResponseWriter, r *http.Request, p params) {
+	ctx, cancel := context.WithCancel(r.Context())
+
+	results, err := h.schema.Subscribe(ctx, p.Query, p.OperationName, p.Variables)
+	if err != nil {
+		cancel()
+		problem.Write(w, r, err)
+
+		return
+	}
+
+	// The results are relayed by a goroutine that blocks until each is taken; draining them once the
+	// subscription is cancelled lets it end
+	defer func() {
+		cancel()
+
+		go func() {
+			for range results {
+			}
+		}()
+	}()
+
+	rc := http.NewResponseController(w)
+
+	w.Header().Set("Content-Type", mediaEvents)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	send := func(event string, data []byte) error {
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data); err != nil {
+			return err
+		}
+
+		return rc.Flush()
+	}
+
+	if err := rc.Flush(); err != nil {
+		logging.FromContext(ctx).Error("response flush failed", "error", err)
+		return
+	}
+
+	keepAlive := time.NewTicker(KeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case res, ok := <-results:
+			if !ok {
+				if err := send("complete", nil); err != nil {
+					logging.FromContext(ctx).Warn("subscription write failed", "error", err)
+				}
+
+				return
+			}
+
+			data, err := json.Marshal(res)
+			if err != nil {
+				logging.FromContext(ctx).Error("subscription result encoding failed", "error", err)
+				return
+			}
+
+			if err := send("next", data); err != nil {
+				// The client is gone; cancelling ends the subscription
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := io.WriteString(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func writeJSON(w http.ResponseWriter, r *http.Request, status int, v any) {
+	resp, err := json.Marshal(v)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if _, err := w.Write(resp); err != nil {
+		logging.FromContext(r.Context()).Error("response write failed", "error", err)
+	}
+}
@@ -0,0 +1,320 @@
+package graphql
+
+import (
+	"Task_Manager/auth"
+	"Task_Manager/model/task"
+	"Task_Manager/model/user"
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+type response struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string         `json:"message"`
+		Extensions map[string]any `json:"extensions"`
+	} `json:"errors"`
+}
+
+func setup(t *testing.T) (*Handler, *MockTaskServiceInterface, *MockUserServiceInterface, *Feed) {
+	ctrl := gomock.NewController(t)
+	tasks := NewMockTaskServiceInterface(ctrl)
+	users := NewMockUserServiceInterface(ctrl)
+	feed := NewFeed()
+
+	return NewHandler(tasks, users, feed), tasks, users, feed
+}
+
+func post(t *testing.T, h http.Handler, query string, variables map[string]any) response {
+	body, err := json.Marshal(params{Query: query, Variables: variables})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body)))
+	req = req.WithContext(auth.WithActor(req.Context(), auth.Actor{UserID: 1}))
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	var resp response
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+	return resp
+}
+
+func Test_UsersWithTasks(t *testing.T) {
+	h, tasks, users, _ := setup(t)
+
+	users.EXPECT().All(gomock.Any()).Return([]user.User{
+		{ID: 3, Name: "Carol", Email: "carol@example.com"},
+		{ID: 1, Name: "Alice", Email: "alice@example.com", Timezone: "Europe/Berlin"},
+		{ID: 2, Name: "Bob", Email: "bob@example.com"},
+	}, nil)
+	// The tasks of every user on the page are read together, not once per user
+	tasks.EXPECT().GetTasksByUserIDs(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, ids []int) (map[int][]task.Task, error) {
+			require.ElementsMatch(t, []int{1, 2}, ids)
+
+			return map[int][]task.Task{
+				1: {{ID: 4, Desc: "Write", Userid: 1}, {ID: 9, Desc: "Ship", Status: true, Userid: 1}},
+			}, nil
+		})
+
+	resp := post(t, h, `{ users(limit: 2) { items { id name timezone tasks(status: false) { items { id desc } } } nextAfterId } }`, nil)
+	require.Empty(t, resp.Errors)
+	require.JSONEq(t, `{"users": {"items": [
+		{"id": "1", "name": "Alice", "timezone": "Europe/Berlin", "tasks": {"items": [{"id": "4", "desc": "Write"}]}},
+		{"id": "2", "name": "Bob", "timezone": null, "tasks": {"items": []}}
+	], "nextAfterId": "2"}}`, string(resp.Data))
+}
+
+func Test_TasksWithAssignees(t *testing.T) {
+	h, tasks, users, _ := setup(t)
+
+	done := true
+	tasks.EXPECT().Export(gomock.Any(), task.Filter{UserID: 0, Status: &done, Labels: []string{"bug"}, AfterID: 3, Limit: 3}, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ task.Filter, fn func(task.Task) error) error {
+			for _, t := range []task.Task{{ID: 4, Status: true, Userid: 2}, {ID: 5, Status: true}, {ID: 6, Status: true, Userid: 7}} {
+				if err := fn(t); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+	// One lookup for the assignees of the page; the task past the page is not looked up
+	users.EXPECT().GetMany(gomock.Any(), []int{2}).Return(map[int]user.User{2: {ID: 2, Name: "Bob"}}, nil)
+
+	resp := post(t, h, `query($after: ID) { tasks(filter: {status: true, labels: ["bug"]}, afterId: $after, limit: 2) {
+		items { id assignee { name } } nextAfterId } }`, map[string]any{"after": "3"})
+	require.Empty(t, resp.Errors)
+	require.JSONEq(t, `{"tasks": {"items": [{"id": "4", "assignee": {"name": "Bob"}}, {"id": "5", "assignee": null}], "nextAfterId": "5"}}`,
+		string(resp.Data))
+}
+
+func Test_Lookups(t *testing.T) {
+	h, tasks, users, _ := setup(t)
+
+	t.Run("Missing user is null", func(t *testing.T) {
+		users.EXPECT().Get(gomock.Any(), 8).Return(user.User{}, user.ErrNotFound)
+
+		resp := post(t, h, `{ user(id: "8") { name } }`, nil)
+		require.Empty(t, resp.Errors)
+		require.JSONEq(t, `{"user": null}`, string(resp.Data))
+	})
+
+	t.Run("Task with its assignee's other tasks", func(t *testing.T) {
+		due := time.Date(2024, 5, 7, 12, 0, 0, 0, time.UTC)
+		tasks.EXPECT().GetTask(gomock.Any(), 4).Return(task.Task{ID: 4, Desc: "Write", Userid: 2, Due: &due, WorkspaceID: 3}, nil)
+		users.EXPECT().GetMany(gomock.Any(), []int{2}).Return(map[int]user.User{2: {ID: 2, Name: "Bob"}}, nil)
+		tasks.EXPECT().GetTasksByUserIDs(gomock.Any(), []int{2}).
+			Return(map[int][]task.Task{2: {{ID: 4, Desc: "Write", Userid: 2}, {ID: 6, Desc: "Test", Userid: 2}}}, nil)
+
+		resp := post(t, h, `{ task(id: "4") { due workspaceId assignee { name tasks(afterId: "4") { items { id } } } } }`, nil)
+		require.Empty(t, resp.Errors)
+		require.JSONEq(t, `{"task": {"due": "2024-05-07T12:00:00Z", "workspaceId": "3",
+			"assignee": {"name": "Bob", "tasks": {"items": [{"id": "6"}]}}}}`, string(resp.Data))
+	})
+
+	t.Run("Invalid page", func(t *testing.T) {
+		resp := post(t, h, `{ users(limit: 501) { items { id } } }`, nil)
+		require.Len(t, resp.Errors, 1)
+		require.Equal(t, "invalid_page", resp.Errors[0].Extensions["code"])
+		require.EqualValues(t, http.StatusBadRequest, resp.Errors[0].Extensions["status"])
+	})
+}
+
+func Test_Mutations(t *testing.T) {
+	h, tasks, users, _ := setup(t)
+
+	t.Run("Create task", func(t *testing.T) {
+		due := time.Date(2024, 5, 7, 12, 0, 0, 0, time.UTC)
+		tasks.EXPECT().Create(gomock.Any(), task.Task{Desc: "Write", Userid: 2, Due: &due}).
+			Return(task.Task{ID: 4, Desc: "Write", Userid: 2, Due: &due}, nil)
+
+		resp := post(t, h, `mutation { createTask(input: {desc: "Write", userId: "2", due: "2024-05-07T12:00:00Z"}) { id desc } }`, nil)
+		require.Empty(t, resp.Errors)
+		require.JSONEq(t, `{"createTask": {"id": "4", "desc": "Write"}}`, string(resp.Data))
+	})
+
+	t.Run("Domain errors keep their code", func(t *testing.T) {
+		tasks.EXPECT().Create(gomock.Any(), task.Task{}).Return(task.Task{}, task.ErrEmptyDesc)
+
+		resp := post(t, h, `mutation { createTask(input: {desc: ""}) { id } }`, nil)
+		require.Len(t, resp.Errors, 1)
+		require.Equal(t, "description cannot be empty", resp.Errors[0].Message)
+		require.Equal(t, "task_desc_required", resp.Errors[0].Extensions["code"])
+		require.EqualValues(t, http.StatusBadRequest, resp.Errors[0].Extensions["status"])
+		require.NotEmpty(t, resp.Errors[0].Extensions["errors"])
+	})
+
+	t.Run("Internal errors are withheld", func(t *testing.T) {
+		tasks.EXPECT().Complete(gomock.Any(), 4).Return(errors.New("connection refused"))
+
+		resp := post(t, h, `mutation { completeTask(id: "4") { id } }`, nil)
+		require.Len(t, resp.Errors, 1)
+		require.Equal(t, "an unexpected error occurred", resp.Errors[0].Message)
+		require.Equal(t, "internal", resp.Errors[0].Extensions["code"])
+	})
+
+	t.Run("Complete reads the task back", func(t *testing.T) {
+		tasks.EXPECT().Complete(gomock.Any(), 4).Return(nil)
+		tasks.EXPECT().GetTask(gomock.Any(), 4).Return(task.Task{ID: 4, Status: true}, nil)
+
+		resp := post(t, h, `mutation { completeTask(id: "4") { status } }`, nil)
+		require.Empty(t, resp.Errors)
+		require.JSONEq(t, `{"completeTask": {"status": true}}`, string(resp.Data))
+	})
+
+	t.Run("Update user as the actor", func(t *testing.T) {
+		name := "Al"
+		users.EXPECT().Update(gomock.Any(), auth.Actor{UserID: 1}, 1, user.Profile{Name: &name}).
+			Return(user.User{ID: 1, Name: "Al"}, nil)
+
+		resp := post(t, h, `mutation { updateUser(id: "1", input: {name: "Al"}) { name } }`, nil)
+		require.Empty(t, resp.Errors)
+		require.JSONEq(t, `{"updateUser": {"name": "Al"}}`, string(resp.Data))
+	})
+
+	t.Run("Delete user with a policy", func(t *testing.T) {
+		users.EXPECT().DeleteWithPolicy(gomock.Any(), 2, user.DeleteOptions{Policy: user.PolicyReassign, ReassignTo: 3}).
+			Return(user.DeleteReport{UserID: 2, Policy: user.PolicyReassign, ReassignTo: 3, Tasks: []int{4, 6}}, nil)
+
+		resp := post(t, h, `mutation { deleteUser(id: "2", policy: REASSIGN, reassignTo: "3") { policy reassignTo tasks } }`, nil)
+		require.Empty(t, resp.Errors)
+		require.JSONEq(t, `{"deleteUser": {"policy": "REASSIGN", "reassignTo": "3", "tasks": ["4", "6"]}}`, string(resp.Data))
+	})
+
+	t.Run("Delete user with tasks is refused", func(t *testing.T) {
+		users.EXPECT().DeleteWithPolicy(gomock.Any(), 2, user.DeleteOptions{Policy: user.PolicyReject}).
+			Return(user.DeleteReport{Tasks: []int{4}}, user.ErrHasTasks)
+
+		resp := post(t, h, `mutation { deleteUser(id: "2") { userId } }`, nil)
+		require.Len(t, resp.Errors, 1)
+		require.Equal(t, "user 2 still has 1 tasks; choose a delete policy", resp.Errors[0].Message)
+		require.EqualValues(t, http.StatusConflict, resp.Errors[0].Extensions["status"])
+	})
+}
+
+func Test_Get(t *testing.T) {
+	h, _, users, _ := setup(t)
+
+	get := func(query string) response {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/graphql?"+url.Values{"query": {query}}.Encode(), nil))
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp response
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+		return resp
+	}
+
+	users.EXPECT().Get(gomock.Any(), 1).Return(user.User{ID: 1, Name: "Alice"}, nil)
+
+	resp := get(`{ user(id: "1") { name } }`)
+	require.Empty(t, resp.Errors)
+	require.JSONEq(t, `{"user": {"name": "Alice"}}`, string(resp.Data))
+
+	// The service is never called
+	resp = get(`mutation { restoreUser(id: "1") { name } }`)
+	require.Len(t, resp.Errors, 1)
+	require.Equal(t, "mutation_requires_post", resp.Errors[0].Extensions["code"])
+}
+
+func Test_BadRequests(t *testing.T) {
+	h, _, _, _ := setup(t)
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		status int
+	}{
+		{"Invalid JSON", http.MethodPost, "/graphql", "{", http.StatusBadRequest},
+		{"Missing query", http.MethodPost, "/graphql", `{"query": " "}`, http.StatusBadRequest},
+		{"Invalid variables", http.MethodGet, "/graphql?query=%7B%7D&variables=%7B", "", http.StatusBadRequest},
+		{"Body too large", http.MethodPost, "/graphql", `{"query": "` + strings.Repeat("a", MaxBody) + `"}`, http.StatusRequestEntityTooLarge},
+		{"Method not allowed", http.MethodPut, "/graphql", "", http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body)))
+			require.Equal(t, tt.status, rr.Code)
+		})
+	}
+}
+
+func Test_Subscription(t *testing.T) {
+	h, _, users, feed := setup(t)
+
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	users.EXPECT().Get(gomock.Any(), 2).Return(user.User{ID: 2}, nil)
+	users.EXPECT().GetMany(gomock.Any(), []int{2}).Return(map[int]user.User{2: {ID: 2, Name: "Bob"}}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	query := url.Values{"query": {`subscription { taskChanged(userId: "2") { action taskId task { desc assignee { name } } } }`}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/graphql?"+query.Encode(), nil)
+	require.NoError(t, err)
+	req.Header.Set("Accept", "text/event-stream")
+
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer func() { _ = res.Body.Close() }()
+
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	require.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+	require.Eventually(t, func() bool { return feed.Len() == 1 }, time.Second, 5*time.Millisecond)
+
+	// Changes of other users' tasks are not sent
+	feed.Publish(task.Change{Action: "create", TaskID: 3, Task: &task.Task{ID: 3, Userid: 5}})
+	feed.Publish(task.Change{Action: "complete", TaskID: 4, Task: &task.Task{ID: 4, Desc: "Write", Userid: 2}})
+
+	lines := bufio.NewScanner(res.Body)
+	require.True(t, lines.Scan())
+	require.Equal(t, "event: next", lines.Text())
+	require.True(t, lines.Scan())
+
+	var resp response
+	require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(lines.Text(), "data: ")), &resp))
+	require.JSONEq(t, `{"taskChanged": {"action": "complete", "taskId": "4", "task": {"desc": "Write", "assignee": {"name": "Bob"}}}}`,
+		string(resp.Data))
+
+	// Disconnecting ends the subscription
+	cancel()
+	require.Eventually(t, func() bool { return feed.Len() == 0 }, time.Second, 5*time.Millisecond)
+}
+
+func Test_SubscriptionUnknownUser(t *testing.T) {
+	h, _, users, _ := setup(t)
+
+	users.EXPECT().Get(gomock.Any(), 9).Return(user.User{}, user.ErrNotFound)
+
+	req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query": "subscription { taskChanged(userId: \"9\") { taskId } }"}`))
+	req.Header.Set("Accept", "text/event-stream")
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Contains(t, rr.Body.String(), "event: next\ndata: ")
+	require.Contains(t, rr.Body.String(), `"code":"user_not_found"`)
+	require.True(t, strings.HasSuffix(rr.Body.String(), "event: complete\ndata: \n\n"))
+}
@@ -0,0 +1,29 @@
+package graphql
+
+import (
+	"Task_Manager/auth"
+	"Task_Manager/model/task"
+	"Task_Manager/model/user"
+	"context"
+)
+
+type TaskServiceInterface interface {
+	Create(ctx context.Context, t task.Task) (task.Task, error)
+	GetTask(ctx context.Context, id int) (task.Task, error)
+	Complete(ctx context.Context, id int) error
+	Delete(ctx context.Context, id int) error
+	Restore(ctx context.Context, id int) error
+	Revert(ctx context.Context, id, number int) (task.Task, error)
+	Export(ctx context.Context, f task.Filter, fn func(task.Task) error) error
+	GetTasksByUserIDs(ctx context.Context, userids []int) (map[int][]task.Task, error)
+}
+
+type UserServiceInterface interface {
+	Create(ctx context.Context, u user.User) (user.User, error)
+	Get(ctx context.Context, id int) (user.User, error)
+	GetMany(ctx context.Context, ids []int) (map[int]user.User, error)
+	Update(ctx context.Context, actor auth.Actor, id int, p user.Profile) (user.User, error)
+	DeleteWithPolicy(ctx context.Context, id int, opts user.DeleteOptions) (user.DeleteReport, error)
+	All(ctx context.Context) ([]user.User, error)
+	Restore(ctx context.Context, id int) error
+}
@@ -0,0 +1,109 @@
+package graphql
+
+import (
+	"Task_Manager/model/task"
+	"Task_Manager/model/user"
+	"context"
+	"sync"
+)
+
+// loader batches the lookups of one request by ID. The resolver of a list announces the IDs its items
+// will look up with want; the first load then fetches every wanted ID not fetched yet in one call,
+// instead of one call per item. Results are kept for the rest of the request.
+type loader[V any] struct {
+	fetch func(ctx context.Context, ids []int) (map[int]V, error)
+
+	mu      sync.Mutex
+	wanted  map[int]bool
+	batches map[int]*batch[V]
+}
+
+// batch is one call of fetch, shared by every ID it covers
+type batch[V any] struct {
+	done   chan struct{}
+	values map[int]V
+	err    error
+}
+
+func newLoader[V any](fetch func(ctx context.Context, ids []int) (map[int]V, error)) *loader[V] {
+	return &loader[V]{fetch: fetch, wanted: make(map[int]bool), batches: make(map[int]*batch[V])}
+}
+
+// want announces IDs that will be loaded, so they are fetched together with the first one
+func (l *loader[V]) want(ids ...int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, id := range ids {
+		if _, ok := l.batches[id]; !ok {
+			l.wanted[id] = true
+		}
+	}
+}
+
+// load returns the value of id, and false when fetch found none. Loads running at the same time wait
+// for the batch that covers their ID.
+func (l *loader[V]) load(ctx context.Context, id int) (V, bool, error) {
+	l.mu.Lock()
+
+	b, ok := l.batches[id]
+	if ok {
+		l.mu.Unlock()
+
+		select {
+		case <-b.done:
+		case <-ctx.Done():
+			var zero V
+			return zero, false, ctx.Err()
+		}
+
+		v, found := b.values[id]
+
+		return v, found, b.err
+	}
+
+	b = &batch[V]{done: make(chan struct{})}
+	ids := []int{id}
+	l.batches[id] = b
+
+	for w := range l.wanted {
+		if w != id {
+			ids = append(ids, w)
+			l.batches[w] = b
+		}
+
+		delete(l.wanted, w)
+	}
+	l.mu.Unlock()
+
+	b.values, b.err = l.fetch(ctx, ids)
+	close(b.done)
+
+	v, found := b.values[id]
+
+	return v, found, b.err
+}
+
+// loaders are the loaders of one request
+type loaders struct {
+	users        *loader[user.User]
+	tasksByUsers *loader[[]task.Task]
+}
+
+func newLoaders(tasks TaskServiceInterface, users UserServiceInterface) *loaders {
+	return &loaders{
+		users:        newLoader(users.GetMany),
+		tasksByUsers: newLoader(tasks.GetTasksByUserIDs),
+	}
+}
+
+type loadersKey struct{}
+
+func withLoaders(ctx context.Context, l *loaders) context.Context {
+	return context.WithValue(ctx, loadersKey{}, l)
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	l, _ := ctx.Value(loadersKey{}).(*loaders)
+	return l
+}
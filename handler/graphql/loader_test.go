@@ -0,0 +1,102 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// counter fetches the squares of the IDs, leaving out those above 10, and records the batches
+type counter struct {
+	mu      sync.Mutex
+	batches [][]int
+	err     error
+}
+
+func (c *counter) fetch(_ context.Context, ids []int) (map[int]int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.batches = append(c.batches, ids)
+
+	values := make(map[int]int)
+	for _, id := range ids {
+		if id <= 10 {
+			values[id] = id * id
+		}
+	}
+
+	return values, c.err
+}
+
+func Test_Loader(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Wanted IDs are fetched together", func(t *testing.T) {
+		c := &counter{}
+		l := newLoader(c.fetch)
+		l.want(1, 2, 3)
+
+		var wg sync.WaitGroup
+		for _, id := range []int{1, 2, 3} {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				v, ok, err := l.load(ctx, id)
+				require.NoError(t, err)
+				require.True(t, ok)
+				require.Equal(t, id*id, v)
+			}()
+		}
+
+		wg.Wait()
+
+		require.Len(t, c.batches, 1)
+		require.ElementsMatch(t, []int{1, 2, 3}, c.batches[0])
+	})
+
+	t.Run("Results are kept for the request", func(t *testing.T) {
+		c := &counter{}
+		l := newLoader(c.fetch)
+
+		for range 2 {
+			v, ok, err := l.load(ctx, 4)
+			require.NoError(t, err)
+			require.True(t, ok)
+			require.Equal(t, 16, v)
+		}
+
+		// Wanting an ID already fetched does not fetch it again
+		l.want(4)
+		_, _, err := l.load(ctx, 5)
+		require.NoError(t, err)
+
+		require.Equal(t, [][]int{{4}, {5}}, c.batches)
+	})
+
+	t.Run("Missing IDs are reported", func(t *testing.T) {
+		l := newLoader((&counter{}).fetch)
+
+		_, ok, err := l.load(ctx, 11)
+		require.NoError(t, err)
+		require.False(t, ok)
+	})
+
+	t.Run("Errors reach every load of the batch", func(t *testing.T) {
+		c := &counter{err: errors.New("db down")}
+		l := newLoader(c.fetch)
+		l.want(1, 2)
+
+		_, _, err := l.load(ctx, 1)
+		require.EqualError(t, err, "db down")
+
+		_, _, err = l.load(ctx, 2)
+		require.EqualError(t, err, "db down")
+		require.Len(t, c.batches, 1)
+	})
+}
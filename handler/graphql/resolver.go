@@ -0,0 +1,714 @@
+package graphql
+
+import (
+	"Task_Manager/auth"
+	"Task_Manager/handler/problem"
+	"Task_Manager/logging"
+	"Task_Manager/model/errs"
+	"Task_Manager/model/task"
+	"Task_Manager/model/user"
+	"Task_Manager/request"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	gql "github.com/graph-gophers/graphql-go"
+	qerrors "github.com/graph-gophers/graphql-go/errors"
+)
+
+// Page sizes of the lists
+const (
+	DefaultLimit = 50
+	MaxLimit     = 500
+)
+
+var (
+	errPage = errs.Invalid("invalid_page", fmt.Sprintf("limit must be between 1 and %d", MaxLimit),
+		errs.FieldError{Field: "limit", Message: fmt.Sprintf("must be between 1 and %d", MaxLimit)})
+	errReadOnly = errs.Invalid("mutation_requires_post", "mutations must be sent with POST")
+)
+
+// resolver resolves the fields of Query, Mutation and Subscription
+type resolver struct {
+	tasks TaskServiceInterface
+	users UserServiceInterface
+	feed  *Feed
+}
+
+// resolverError reports a failed field with the code and status REST would answer with, in the
+// extensions of the GraphQL error
+type resolverError struct {
+	p problem.Problem
+}
+
+func (e resolverError) Error() string { return e.p.Detail }
+
+func (e resolverError) Extensions() map[string]interface{} {
+	ext := map[string]interface{}{"code": e.p.Code, "status": e.p.Status}
+	if len(e.p.Errors) > 0 {
+		ext["errors"] = e.p.Errors
+	}
+
+	if e.p.RequestID != "" {
+		ext["request_id"] = e.p.RequestID
+	}
+
+	return ext
+}
+
+// fail maps err onto a resolver error as problem.Write does; internal errors are logged and their
+// message withheld
+func fail(ctx context.Context, err error) error {
+	p := problem.From(err)
+	p.RequestID = request.ID(ctx)
+
+	if p.Status == http.StatusInternalServerError {
+		logging.FromContext(ctx).Error("internal error", "path", "/graphql", "error", err)
+	}
+
+	return resolverError{p: p}
+}
+
+// failSubscription is fail for the resolver of a subscription, whose errors only keep their extensions
+// when they are query errors already
+func failSubscription(ctx context.Context, err error) error {
+	e := fail(ctx, err).(resolverError)
+
+	return &qerrors.QueryError{Err: err, Message: e.Error(), ResolverError: err, Extensions: e.Extensions()}
+}
+
+func toID(id int) gql.ID {
+	return gql.ID(strconv.Itoa(id))
+}
+
+// optionalID is nil for zero, which stands for no ID in the models
+func optionalID(id int) *gql.ID {
+	if id == 0 {
+		return nil
+	}
+
+	v := toID(id)
+
+	return &v
+}
+
+func parseID(field string, id gql.ID) (int, error) {
+	n, err := strconv.Atoi(string(id))
+	if err != nil || n <= 0 {
+		return 0, errs.Invalid("invalid_id", "invalid "+field, errs.FieldError{Field: field, Message: "must be a positive integer"})
+	}
+
+	return n, nil
+}
+
+// parseOptionalID is zero when id is not set
+func parseOptionalID(field string, id *gql.ID) (int, error) {
+	if id == nil {
+		return 0, nil
+	}
+
+	return parseID(field, *id)
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+
+	return &s
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+
+	return *s
+}
+
+// page reads the page arguments of a list
+func page(afterID *gql.ID, limit *int32) (int, int, error) {
+	after, err := parseOptionalID("afterId", afterID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if limit == nil {
+		return after, DefaultLimit, nil
+	}
+
+	if *limit < 1 || *limit > MaxLimit {
+		return 0, 0, errPage
+	}
+
+	return after, int(*limit), nil
+}
+
+// cut keeps the first limit of items, which are in ID order and hold up to one more, and returns the
+// afterId of the next page, nil when there is none
+func cut[T any](items []T, limit int, id func(T) int) ([]T, *gql.ID) {
+	if len(items) <= limit {
+		return items, nil
+	}
+
+	items = items[:limit]
+
+	return items, optionalID(id(items[limit-1]))
+}
+
+// readOnlyKey marks requests sent with GET, which may only query
+type readOnlyKey struct{}
+
+func readOnly(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readOnlyKey{}, true)
+}
+
+// mutable refuses mutations sent with GET: they would bypass the checks of unsafe methods, and the
+// read-your-writes pinning to the primary database
+func mutable(ctx context.Context) error {
+	if ro, _ := ctx.Value(readOnlyKey{}).(bool); ro {
+		return fail(ctx, errReadOnly)
+	}
+
+	return nil
+}
+
+func (r *resolver) User(ctx context.Context, args struct{ ID gql.ID }) (*userResolver, error) {
+	id, err := parseID("id", args.ID)
+	if err != nil {
+		return nil, fail(ctx, err)
+	}
+
+	u, err := r.users.Get(ctx, id)
+	if errors.Is(err, user.ErrNotFound) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fail(ctx, err)
+	}
+
+	return newUsers(loadersFrom(ctx), u)[0], nil
+}
+
+func (r *resolver) Users(ctx context.Context, args struct {
+	AfterID *gql.ID
+	Limit   *int32
+}) (*userPage, error) {
+	after, limit, err := page(args.AfterID, args.Limit)
+	if err != nil {
+		return nil, fail(ctx, err)
+	}
+
+	all, err := r.users.All(ctx)
+	if err != nil {
+		return nil, fail(ctx, err)
+	}
+
+	slices.SortFunc(all, func(a, b user.User) int { return a.ID - b.ID })
+
+	start, _ := slices.BinarySearchFunc(all, after+1, func(u user.User, id int) int { return u.ID - id })
+	items, next := cut(all[start:], limit, func(u user.User) int { return u.ID })
+
+	return &userPage{items: newUsers(loadersFrom(ctx), items...), next: next}, nil
+}
+
+func (r *resolver) Task(ctx context.Context, args struct{ ID gql.ID }) (*taskResolver, error) {
+	id, err := parseID("id", args.ID)
+	if err != nil {
+		return nil, fail(ctx, err)
+	}
+
+	t, err := r.tasks.GetTask(ctx, id)
+	if errors.Is(err, task.ErrNotFound) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fail(ctx, err)
+	}
+
+	return newTasks(loadersFrom(ctx), t)[0], nil
+}
+
+// taskFilter is the TaskFilter input
+type taskFilter struct {
+	UserID      *gql.ID
+	Status      *bool
+	Labels      *[]string
+	MatchAll    *bool
+	WorkspaceID *gql.ID
+}
+
+func (r *resolver) Tasks(ctx context.Context, args struct {
+	Filter  *taskFilter
+	AfterID *gql.ID
+	Limit   *int32
+}) (*taskPage, error) {
+	after, limit, err := page(args.AfterID, args.Limit)
+	if err != nil {
+		return nil, fail(ctx, err)
+	}
+
+	// One more than the page, to tell whether another follows
+	f := task.Filter{AfterID: after, Limit: limit + 1}
+
+	if in := args.Filter; in != nil {
+		if f.UserID, err = parseOptionalID("filter.userId", in.UserID); err != nil {
+			return nil, fail(ctx, err)
+		}
+
+		if f.WorkspaceID, err = parseOptionalID("filter.workspaceId", in.WorkspaceID); err != nil {
+			return nil, fail(ctx, err)
+		}
+
+		f.Status = in.Status
+
+		if in.Labels != nil {
+			f.Labels = *in.Labels
+		}
+
+		if in.MatchAll != nil {
+			f.MatchAll = *in.MatchAll
+		}
+	}
+
+	var tasks []task.Task
+
+	err = r.tasks.Export(ctx, f, func(t task.Task) error {
+		tasks = append(tasks, t)
+		return nil
+	})
+	if err != nil {
+		return nil, fail(ctx, err)
+	}
+
+	items, next := cut(tasks, limit, func(t task.Task) int { return t.ID })
+
+	return &taskPage{items: newTasks(loadersFrom(ctx), items...), next: next}, nil
+}
+
+// taskInput is the TaskInput input
+type taskInput struct {
+	Desc        string
+	UserID      *gql.ID
+	Due         *gql.Time
+	WorkspaceID *gql.ID
+}
+
+func (r *resolver) CreateTask(ctx context.Context, args struct{ Input taskInput }) (*taskResolver, error) {
+	if err := mutable(ctx); err != nil {
+		return nil, err
+	}
+
+	t := task.Task{Desc: args.Input.Desc}
+
+	var err error
+	if t.Userid, err = parseOptionalID("input.userId", args.Input.UserID); err != nil {
+		return nil, fail(ctx, err)
+	}
+
+	if t.WorkspaceID, err = parseOptionalID("input.workspaceId", args.Input.WorkspaceID); err != nil {
+		return nil, fail(ctx, err)
+	}
+
+	if args.Input.Due != nil {
+		due := args.Input.Due.UTC()
+		t.Due = &due
+	}
+
+	created, err := r.tasks.Create(ctx, t)
+	if err != nil {
+		return nil, fail(ctx, err)
+	}
+
+	return newTasks(loadersFrom(ctx), created)[0], nil
+}
+
+// change runs a mutation of the task with the given ID, then reads the task back
+func (r *resolver) change(ctx context.Context, rawID gql.ID, fn func(id int) error) (*taskResolver, error) {
+	if err := mutable(ctx); err != nil {
+		return nil, err
+	}
+
+	id, err := parseID("id", rawID)
+	if err != nil {
+		return nil, fail(ctx, err)
+	}
+
+	if err := fn(id); err != nil {
+		return nil, fail(ctx, err)
+	}
+
+	t, err := r.tasks.GetTask(ctx, id)
+	if err != nil {
+		return nil, fail(ctx, err)
+	}
+
+	return newTasks(loadersFrom(ctx), t)[0], nil
+}
+
+func (r *resolver) CompleteTask(ctx context.Context, args struct{ ID gql.ID }) (*taskResolver, error) {
+	return r.change(ctx, args.ID, func(id int) error { return r.tasks.Complete(ctx, id) })
+}
+
+func (r *resolver) DeleteTask(ctx context.Context, args struct{ ID gql.ID }) (bool, error) {
+	if err := mutable(ctx); err != nil {
+		return false, err
+	}
+
+	id, err := parseID("id", args.ID)
+	if err != nil {
+		return false, fail(ctx, err)
+	}
+
+	if err := r.tasks.Delete(ctx, id); err != nil {
+		return false, fail(ctx, err)
+	}
+
+	return true, nil
+}
+
+func (r *resolver) RestoreTask(ctx context.Context, args struct{ ID gql.ID }) (*taskResolver, error) {
+	return r.change(ctx, args.ID, func(id int) error { return r.tasks.Restore(ctx, id) })
+}
+
+func (r *resolver) RevertTask(ctx context.Context, args struct {
+	ID       gql.ID
+	Revision int32
+}) (*taskResolver, error) {
+	if err := mutable(ctx); err != nil {
+		return nil, err
+	}
+
+	id, err := parseID("id", args.ID)
+	if err != nil {
+		return nil, fail(ctx, err)
+	}
+
+	t, err := r.tasks.Revert(ctx, id, int(args.Revision))
+	if err != nil {
+		return nil, fail(ctx, err)
+	}
+
+	return newTasks(loadersFrom(ctx), t)[0], nil
+}
+
+// userInput is the UserInput input
+type userInput struct {
+	Name        string
+	Email       string
+	DisplayName *string
+	AvatarURL   *string
+	Timezone    *string
+}
+
+func (r *resolver) CreateUser(ctx context.Context, args struct{ Input userInput }) (*userResolver, error) {
+	if err := mutable(ctx); err != nil {
+		return nil, err
+	}
+
+	in := args.Input
+
+	created, err := r.users.Create(ctx, user.User{
+		Name:        in.Name,
+		Email:       in.Email,
+		DisplayName: deref(in.DisplayName),
+		AvatarURL:   deref(in.AvatarURL),
+		Timezone:    deref(in.Timezone),
+	})
+	if err != nil {
+		return nil, fail(ctx, err)
+	}
+
+	return newUsers(loadersFrom(ctx), created)[0], nil
+}
+
+func (r *resolver) UpdateUser(ctx context.Context, args struct {
+	ID    gql.ID
+	Input user.Profile
+}) (*userResolver, error) {
+	if err := mutable(ctx); err != nil {
+		return nil, err
+	}
+
+	id, err := parseID("id", args.ID)
+	if err != nil {
+		return nil, fail(ctx, err)
+	}
+
+	updated, err := r.users.Update(ctx, auth.FromContext(ctx), id, args.Input)
+	if err != nil {
+		return nil, fail(ctx, err)
+	}
+
+	return newUsers(loadersFrom(ctx), updated)[0], nil
+}
+
+func (r *resolver) DeleteUser(ctx context.Context, args struct {
+	ID         gql.ID
+	Policy     string
+	ReassignTo *gql.ID
+	DryRun     bool
+}) (*deleteReport, error) {
+	if err := mutable(ctx); err != nil {
+		return nil, err
+	}
+
+	id, err := parseID("id", args.ID)
+	if err != nil {
+		return nil, fail(ctx, err)
+	}
+
+	opts := user.DeleteOptions{Policy: user.DeletePolicy(strings.ToLower(args.Policy)), DryRun: args.DryRun}
+	if opts.ReassignTo, err = parseOptionalID("reassignTo", args.ReassignTo); err != nil {
+		return nil, fail(ctx, err)
+	}
+
+	report, err := r.users.DeleteWithPolicy(ctx, id, opts)
+	if errors.Is(err, user.ErrHasTasks) {
+		err = &errs.Conflict{Code: user.ErrHasTasks.Code,
+			Message: fmt.Sprintf("user %d still has %d tasks; choose a delete policy", id, len(report.Tasks))}
+	}
+
+	if err != nil {
+		return nil, fail(ctx, err)
+	}
+
+	return &deleteReport{r: report}, nil
+}
+
+func (r *resolver) RestoreUser(ctx context.Context, args struct{ ID gql.ID }) (*userResolver, error) {
+	if err := mutable(ctx); err != nil {
+		return nil, err
+	}
+
+	id, err := parseID("id", args.ID)
+	if err != nil {
+		return nil, fail(ctx, err)
+	}
+
+	if err := r.users.Restore(ctx, id); err != nil {
+		return nil, fail(ctx, err)
+	}
+
+	u, err := r.users.Get(ctx, id)
+	if err != nil {
+		return nil, fail(ctx, err)
+	}
+
+	return newUsers(loadersFrom(ctx), u)[0], nil
+}
+
+// TaskChanged streams the task changes until the subscription ends. Each change gets loaders of its
+// own, since what was loaded for an earlier one may have changed since.
+func (r *resolver) TaskChanged(ctx context.Context, args struct{ UserID *gql.ID }) (<-chan *changeResolver, error) {
+	userID, err := parseOptionalID("userId", args.UserID)
+	if err != nil {
+		return nil, failSubscription(ctx, err)
+	}
+
+	if userID != 0 {
+		if _, err := r.users.Get(ctx, userID); err != nil {
+			return nil, failSubscription(ctx, err)
+		}
+	}
+
+	changes := r.feed.Subscribe(ctx, userID)
+	out := make(chan *changeResolver)
+
+	go func() {
+		defer close(out)
+
+		for c := range changes {
+			select {
+			case out <- &changeResolver{c: c, l: newLoaders(r.tasks, r.users)}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+type userResolver struct {
+	u user.User
+	l *loaders
+}
+
+// newUsers resolves users, announcing them to the loader of their tasks
+func newUsers(l *loaders, users ...user.User) []*userResolver {
+	resolvers := make([]*userResolver, len(users))
+	ids := make([]int, len(users))
+
+	for i, u := range users {
+		resolvers[i] = &userResolver{u: u, l: l}
+		ids[i] = u.ID
+	}
+
+	l.tasksByUsers.want(ids...)
+
+	return resolvers
+}
+
+func (r *userResolver) ID() gql.ID { return toID(r.u.ID) }
+
+func (r *userResolver) Name() string { return r.u.Name }
+
+func (r *userResolver) Email() string { return r.u.Email }
+
+func (r *userResolver) DisplayName() *string { return optionalString(r.u.DisplayName) }
+
+func (r *userResolver) AvatarURL() *string { return optionalString(r.u.AvatarURL) }
+
+func (r *userResolver) Timezone() *string { return optionalString(r.u.Timezone) }
+
+func (r *userResolver) Tasks(ctx context.Context, args struct {
+	Status  *bool
+	AfterID *gql.ID
+	Limit   *int32
+}) (*taskPage, error) {
+	after, limit, err := page(args.AfterID, args.Limit)
+	if err != nil {
+		return nil, fail(ctx, err)
+	}
+
+	all, _, err := r.l.tasksByUsers.load(ctx, r.u.ID)
+	if err != nil {
+		return nil, fail(ctx, err)
+	}
+
+	var tasks []task.Task
+
+	for _, t := range all {
+		if t.ID <= after || (args.Status != nil && t.Status != *args.Status) {
+			continue
+		}
+
+		tasks = append(tasks, t)
+	}
+
+	items, next := cut(tasks, limit, func(t task.Task) int { return t.ID })
+
+	return &taskPage{items: newTasks(r.l, items...), next: next}, nil
+}
+
+type taskResolver struct {
+	t task.Task
+	l *loaders
+}
+
+// newTasks resolves tasks, announcing their assignees to the loader of users
+func newTasks(l *loaders, tasks ...task.Task) []*taskResolver {
+	resolvers := make([]*taskResolver, len(tasks))
+	ids := make([]int, 0, len(tasks))
+
+	for i, t := range tasks {
+		resolvers[i] = &taskResolver{t: t, l: l}
+
+		if t.Userid != 0 {
+			ids = append(ids, t.Userid)
+		}
+	}
+
+	l.users.want(ids...)
+
+	return resolvers
+}
+
+func (r *taskResolver) ID() gql.ID { return toID(r.t.ID) }
+
+func (r *taskResolver) Desc() string { return r.t.Desc }
+
+func (r *taskResolver) Status() bool { return r.t.Status }
+
+func (r *taskResolver) WorkspaceID() *gql.ID { return optionalID(r.t.WorkspaceID) }
+
+func (r *taskResolver) Due() *gql.Time {
+	if r.t.Due == nil {
+		return nil
+	}
+
+	return &gql.Time{Time: *r.t.Due}
+}
+
+// Assignee is null for an unassigned task, and for one whose assignee was deleted since it was read
+func (r *taskResolver) Assignee(ctx context.Context) (*userResolver, error) {
+	if r.t.Userid == 0 {
+		return nil, nil
+	}
+
+	u, ok, err := r.l.users.load(ctx, r.t.Userid)
+	if err != nil {
+		return nil, fail(ctx, err)
+	}
+
+	if !ok {
+		return nil, nil
+	}
+
+	return &userResolver{u: u, l: r.l}, nil
+}
+
+type userPage struct {
+	items []*userResolver
+	next  *gql.ID
+}
+
+func (p *userPage) Items() []*userResolver { return p.items }
+
+func (p *userPage) NextAfterID() *gql.ID { return p.next }
+
+type taskPage struct {
+	items []*taskResolver
+	next  *gql.ID
+}
+
+func (p *taskPage) Items() []*taskResolver { return p.items }
+
+func (p *taskPage) NextAfterID() *gql.ID { return p.next }
+
+type changeResolver struct {
+	c task.Change
+	l *loaders
+}
+
+func (r *changeResolver) Action() string { return r.c.Action }
+
+func (r *changeResolver) TaskID() gql.ID { return toID(r.c.TaskID) }
+
+func (r *changeResolver) At() gql.Time { return gql.Time{Time: r.c.At} }
+
+func (r *changeResolver) Task() *taskResolver {
+	if r.c.Task == nil {
+		return nil
+	}
+
+	return newTasks(r.l, *r.c.Task)[0]
+}
+
+type deleteReport struct {
+	r user.DeleteReport
+}
+
+func (d *deleteReport) UserID() gql.ID { return toID(d.r.UserID) }
+
+func (d *deleteReport) Policy() string { return strings.ToUpper(string(d.r.Policy)) }
+
+func (d *deleteReport) ReassignTo() *gql.ID { return optionalID(d.r.ReassignTo) }
+
+func (d *deleteReport) DryRun() bool { return d.r.DryRun }
+
+func (d *deleteReport) Tasks() []gql.ID {
+	ids := make([]gql.ID, len(d.r.Tasks))
+	for i, id := range d.r.Tasks {
+		ids[i] = toID(id)
+	}
+
+	return ids
+}
@@ -0,0 +1,152 @@
+package imports
+
+import (
+	"Task_Manager/handler/problem"
+	"Task_Manager/logging"
+	"Task_Manager/model/errs"
+	"Task_Manager/model/imports"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+)
+
+type Handler struct {
+	svc ImportServiceInterface
+}
+
+// NewHandler : Factory function to implement and return behaviour
+func NewHandler(s ImportServiceInterface) *Handler {
+	return &Handler{svc: s}
+}
+
+// importFunc is the signature shared by the import service methods
+type importFunc func(ctx context.Context, r io.Reader, format string, dryRun bool, emit func(imports.Row) error) (imports.Summary, error)
+
+// summaryLine is the last line of an import report
+type summaryLine struct {
+	Summary imports.Summary `json:"summary"`
+	Error   string          `json:"error,omitempty"`
+}
+
+// Tasks import (POST /import/tasks?format=csv|ndjson&dry_run=true) from a CSV or NDJSON request body
+func (h *Handler) Tasks(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, h.svc.Tasks)
+}
+
+// Users import (POST /import/users?format=csv|ndjson&dry_run=true) from a CSV or NDJSON request body
+func (h *Handler) Users(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, h.svc.Users)
+}
+
+// serve streams the per-row report as NDJSON while the body is still being read, ending with a summary line.
+// Errors found before the first row are answered with problem details; later ones end up in the summary line.
+func (h *Handler) serve(w http.ResponseWriter, r *http.Request, run importFunc) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	format, err := formatOf(r)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	dryRun := false
+	if raw := r.URL.Query().Get("dry_run"); raw != "" {
+		if dryRun, err = strconv.ParseBool(raw); err != nil {
+			problem.BadRequest(w, r, "dry_run_invalid", "Invalid dry_run",
+				errs.FieldError{Field: "dry_run", Message: "must be true or false"})
+			return
+		}
+	}
+
+	// HTTP/1.x servers close the request body once the response starts, unless full duplex is enabled.
+	// Where it cannot be, the report is held back until the whole body has been read.
+	var (
+		out  io.Writer = w
+		held *bytes.Buffer
+	)
+
+	if r.ProtoMajor == 1 {
+		if err := http.NewResponseController(w).EnableFullDuplex(); err != nil {
+			held = new(bytes.Buffer)
+			out = held
+		}
+	}
+
+	enc := json.NewEncoder(out)
+	flusher, _ := w.(http.Flusher)
+	started := false
+	written := 0
+
+	start := func() {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.WriteHeader(http.StatusOK)
+		started = true
+	}
+
+	summary, err := run(r.Context(), r.Body, format, dryRun, func(row imports.Row) error {
+		if !started {
+			start()
+		}
+
+		if err := enc.Encode(row); err != nil {
+			return err
+		}
+
+		written++
+		if flusher != nil && held == nil && written%100 == 0 {
+			flusher.Flush()
+		}
+
+		return nil
+	})
+
+	if err != nil && !started {
+		problem.Write(w, r, err)
+		return
+	}
+
+	if !started {
+		start()
+	}
+
+	last := summaryLine{Summary: summary}
+	if err != nil {
+		last.Error = err.Error()
+	}
+
+	if err := enc.Encode(last); err != nil {
+		logging.FromContext(r.Context()).Error("import report aborted", "error", err)
+		return
+	}
+
+	if held != nil {
+		if _, err := held.WriteTo(w); err != nil {
+			logging.FromContext(r.Context()).Error("import report aborted", "error", err)
+		}
+	}
+}
+
+// formatOf takes the file format from the format query parameter, falling back to the Content-Type
+func formatOf(r *http.Request) (string, error) {
+	if f := r.URL.Query().Get("format"); f != "" {
+		return f, nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	switch mediaType {
+	case "text/csv":
+		return imports.FormatCSV, nil
+	case "application/x-ndjson", "application/ndjson", "application/jsonl":
+		return imports.FormatNDJSON, nil
+	default:
+		return "", imports.ErrFormat
+	}
+}
@@ -0,0 +1,173 @@
+package imports
+
+import (
+	"Task_Manager/model/imports"
+	"bufio"
+	"context"
+	"errors"
+	"go.uber.org/mock/gomock"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func Test_Import(t *testing.T) {
+	rows := []imports.Row{
+		{Line: 2, Status: imports.RowCreated, ID: 7},
+		{Line: 3, Status: imports.RowFailed, Error: "description cannot be empty"},
+	}
+
+	tests := []struct {
+		name        string
+		method      string
+		target      string
+		contentType string
+		callSvc     bool
+		format      string
+		dryRun      bool
+		emitRows    bool
+		mockErr     error
+		ExpCode     int
+		ExpBody     string
+	}{
+		{"CSV by content type", http.MethodPost, "/import/tasks", "text/csv", true, imports.FormatCSV, false, true, nil, http.StatusOK,
+			`{"line":2,"status":"created","id":7}` + "\n" +
+				`{"line":3,"status":"failed","error":"description cannot be empty"}` + "\n" +
+				`{"summary":{"dry_run":false,"created":1,"skipped":0,"failed":1}}` + "\n"},
+		{"NDJSON dry run by query", http.MethodPost, "/import/tasks?format=ndjson&dry_run=true", "", true, imports.FormatNDJSON, true, false, nil, http.StatusOK,
+			`{"summary":{"dry_run":true,"created":1,"skipped":0,"failed":1}}` + "\n"},
+		{"Error after the first row ends the report", http.MethodPost, "/import/tasks", "application/x-ndjson", true, imports.FormatNDJSON, false, true, errors.New("unexpected EOF"), http.StatusOK,
+			`{"line":2,"status":"created","id":7}` + "\n" +
+				`{"line":3,"status":"failed","error":"description cannot be empty"}` + "\n" +
+				`{"summary":{"dry_run":false,"created":1,"skipped":0,"failed":1},"error":"unexpected EOF"}` + "\n"},
+		{"Missing header", http.MethodPost, "/import/tasks", "text/csv", true, imports.FormatCSV, false, false, imports.ErrHeader, http.StatusBadRequest, ""},
+		{"Unknown format", http.MethodPost, "/import/tasks", "application/pdf", false, "", false, false, nil, http.StatusBadRequest, ""},
+		{"Invalid dry_run", http.MethodPost, "/import/tasks?dry_run=maybe", "text/csv", false, "", false, false, nil, http.StatusBadRequest, ""},
+		{"wrong HTTP method", http.MethodGet, "/import/tasks", "text/csv", false, "", false, false, nil, http.StatusMethodNotAllowed, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mock := NewMockImportServiceInterface(ctrl)
+			h := NewHandler(mock)
+
+			if tt.callSvc {
+				mock.EXPECT().Tasks(gomock.Any(), gomock.Any(), tt.format, tt.dryRun, gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ io.Reader, _ string, dryRun bool, emit func(imports.Row) error) (imports.Summary, error) {
+						if tt.emitRows {
+							for _, row := range rows {
+								if err := emit(row); err != nil {
+									return imports.Summary{}, err
+								}
+							}
+						}
+
+						return imports.Summary{DryRun: dryRun, Created: 1, Failed: 1}, tt.mockErr
+					})
+			}
+
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader("desc\n"))
+			req.Header.Set("Content-Type", tt.contentType)
+			rec := httptest.NewRecorder()
+			h.Tasks(rec, req)
+
+			if rec.Code != tt.ExpCode {
+				t.Errorf("[%s] Expected status %d, got %d", tt.name, tt.ExpCode, rec.Code)
+			}
+
+			if tt.ExpBody != "" && rec.Body.String() != tt.ExpBody {
+				t.Errorf("[%s] Expected body %q, got %q", tt.name, tt.ExpBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func Test_ImportUsers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mock := NewMockImportServiceInterface(ctrl)
+	h := NewHandler(mock)
+
+	mock.EXPECT().Users(gomock.Any(), gomock.Any(), imports.FormatCSV, false, gomock.Any()).Return(imports.Summary{}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/import/users?format=csv", strings.NewReader("name,email\n"))
+	rec := httptest.NewRecorder()
+	h.Users(rec, req)
+
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/x-ndjson" {
+		t.Errorf("Expected an NDJSON report, got %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+}
+
+// Test_ImportStreamsOverHTTP1 imports through a real server, which on HTTP/1.x stops reading the request
+// body once the response starts unless the handler asks for full duplex
+func Test_ImportStreamsOverHTTP1(t *testing.T) {
+	const lines = 2000
+
+	ctrl := gomock.NewController(t)
+	mock := NewMockImportServiceInterface(ctrl)
+
+	mock.EXPECT().Tasks(gomock.Any(), gomock.Any(), imports.FormatNDJSON, false, gomock.Any()).
+		DoAndReturn(func(_ context.Context, r io.Reader, _ string, _ bool, emit func(imports.Row) error) (imports.Summary, error) {
+			var summary imports.Summary
+
+			scanner := bufio.NewScanner(r)
+			for line := 1; scanner.Scan(); line++ {
+				if err := emit(imports.Row{Line: line, Status: imports.RowCreated, ID: line}); err != nil {
+					return summary, err
+				}
+
+				summary.Created++
+			}
+
+			return summary, scanner.Err()
+		})
+
+	srv := httptest.NewServer(http.HandlerFunc(NewHandler(mock).Tasks))
+	defer srv.Close()
+
+	var body strings.Builder
+	for i := 0; i < lines; i++ {
+		body.WriteString(`{"desc":"Task ` + strconv.Itoa(i) + `","userid":1}` + "\n")
+	}
+
+	// The pipe hands the body over in small writes, so the report starts long before it is all sent
+	pr, pw := io.Pipe()
+	go func() {
+		data := body.String()
+		for len(data) > 0 {
+			n := min(len(data), 512)
+			if _, err := pw.Write([]byte(data[:n])); err != nil {
+				return
+			}
+
+			data = data[n:]
+		}
+
+		_ = pw.Close()
+	}()
+
+	resp, err := http.Post(srv.URL+"/import/tasks", "application/x-ndjson", pr)
+	if err != nil {
+		t.Fatalf("Import request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	report, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Reading the report failed: %v", err)
+	}
+
+	got := strings.Split(strings.TrimSuffix(string(report), "\n"), "\n")
+	if len(got) != lines+1 {
+		t.Fatalf("Expected %d rows and a summary, got %d lines ending with %s", lines, len(got), got[len(got)-1])
+	}
+
+	want := `{"summary":{"dry_run":false,"created":2000,"skipped":0,"failed":0}}`
+	if got[lines] != want {
+		t.Errorf("Expected summary %s, got %s", want, got[lines])
+	}
+}
@@ -0,0 +1,12 @@
+package imports
+
+import (
+	"Task_Manager/model/imports"
+	"context"
+	"io"
+)
+
+type ImportServiceInterface interface {
+	Tasks(ctx context.Context, r io.Reader, format string, dryRun bool, emit func(imports.Row) error) (imports.Summary, error)
+	Users(ctx context.Context, r io.Reader, format string, dryRun bool, emit func(imports.Row) error) (imports.Summary, error)
+}
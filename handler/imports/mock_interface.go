@@ -0,0 +1,73 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: interface.go
+//
+// Generated by this command:
+//
+//	mockgen -source=interface.go -destination=mock_interface.go -package=imports
+//
+
+// Package imports is a generated GoMock package.
+package imports
+
+import (
+	imports "Task_Manager/model/imports"
+	context "context"
+	io "io"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockImportServiceInterface is a mock of ImportServiceInterface interface.
+type MockImportServiceInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockImportServiceInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockImportServiceInterfaceMockRecorder is the mock recorder for MockImportServiceInterface.
+type MockImportServiceInterfaceMockRecorder struct {
+	mock *MockImportServiceInterface
+}
+
+// NewMockImportServiceInterface creates a new mock instance.
+func NewMockImportServiceInterface(ctrl *gomock.Controller) *MockImportServiceInterface {
+	mock := &MockImportServiceInterface{ctrl: ctrl}
+	mock.recorder = &MockImportServiceInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockImportServiceInterface) EXPECT() *MockImportServiceInterfaceMockRecorder {
+	return m.recorder
+}
+
+// Tasks mocks base method.
+func (m *MockImportServiceInterface) Tasks(ctx context.Context, r io.Reader, format string, dryRun bool, emit func(imports.Row) error) (imports.Summary, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Tasks", ctx, r, format, dryRun, emit)
+	ret0, _ := ret[0].(imports.Summary)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Tasks indicates an expected call of Tasks.
+func (mr *MockImportServiceInterfaceMockRecorder) Tasks(ctx, r, format, dryRun, emit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Tasks", reflect.TypeOf((*MockImportServiceInterface)(nil).Tasks), ctx, r, format, dryRun, emit)
+}
+
+// Users mocks base method.
+func (m *MockImportServiceInterface) Users(ctx context.Context, r io.Reader, format string, dryRun bool, emit func(imports.Row) error) (imports.Summary, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Users", ctx, r, format, dryRun, emit)
+	ret0, _ := ret[0].(imports.Summary)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Users indicates an expected call of Users.
+func (mr *MockImportServiceInterfaceMockRecorder) Users(ctx, r, format, dryRun, emit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Users", reflect.TypeOf((*MockImportServiceInterface)(nil).Users), ctx, r, format, dryRun, emit)
+}
@@ -0,0 +1,242 @@
+package label
+
+import (
+	"Task_Manager/handler/problem"
+	"Task_Manager/logging"
+	"Task_Manager/model/label"
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/mux"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+type Handler struct {
+	svc LabelServiceInterface
+}
+
+// attachRequest is the body of POST /task/{id}/labels
+type attachRequest struct {
+	LabelIDs []int `json:"label_ids"`
+}
+
+// relabelRequest is the body of POST /labels/bulk
+type relabelRequest struct {
+	TaskIDs []int `json:"task_ids"`
+	Add     []int `json:"add"`
+	Remove  []int `json:"remove"`
+}
+
+// NewHandler : Factory function to implement and return behaviour
+func NewHandler(s LabelServiceInterface) *Handler {
+	return &Handler{svc: s}
+}
+
+// Create label in a workspace (POST /workspaces/{workspaceid}/labels)
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	workspaceID, ok := pathID(w, r, "workspaceid")
+	if !ok {
+		return
+	}
+
+	var l label.Label
+	if !decodeBody(w, r, &l) {
+		return
+	}
+
+	l.WorkspaceID = workspaceID
+
+	created, err := h.svc.Create(r.Context(), l)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusCreated, created)
+}
+
+// ListWorkspace labels (GET /workspaces/{workspaceid}/labels)
+func (h *Handler) ListWorkspace(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	workspaceID, ok := pathID(w, r, "workspaceid")
+	if !ok {
+		return
+	}
+
+	labels, err := h.svc.ListWorkspace(r.Context(), workspaceID)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, labels)
+}
+
+// Delete label and detach it from every task (DELETE /labels/{id})
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.svc.Delete(r.Context(), id); err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := w.Write([]byte(fmt.Sprintf("Label %d deleted", id))); err != nil {
+		logging.FromContext(r.Context()).Error("response write failed", "error", err)
+	}
+}
+
+// ListForTask labels attached to a task (GET /task/{id}/labels)
+func (h *Handler) ListForTask(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	taskID, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	labels, err := h.svc.ListForTask(r.Context(), taskID)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, labels)
+}
+
+// Attach labels to a task (POST /task/{id}/labels)
+func (h *Handler) Attach(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	taskID, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var req attachRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	if err := h.svc.Attach(r.Context(), taskID, req.LabelIDs); err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Detach label from a task (DELETE /task/{id}/labels/{labelid})
+func (h *Handler) Detach(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	taskID, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	labelID, ok := pathID(w, r, "labelid")
+	if !ok {
+		return
+	}
+
+	if err := h.svc.Detach(r.Context(), taskID, labelID); err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Relabel many tasks at once (POST /labels/bulk)
+func (h *Handler) Relabel(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req relabelRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	if err := h.svc.Relabel(r.Context(), req.TaskIDs, req.Add, req.Remove); err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func pathID(w http.ResponseWriter, r *http.Request, name string) (int, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)[name])
+	if err != nil {
+		problem.BadRequest(w, r, "invalid_id", "Invalid "+name)
+		return 0, false
+	}
+
+	return id, true
+}
+
+func decodeBody(w http.ResponseWriter, r *http.Request, v any) bool {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		problem.BadRequest(w, r, "invalid_body", "Failed to read request body: "+err.Error())
+		return false
+	}
+
+	defer func(Body io.ReadCloser) {
+		_ = Body.Close()
+	}(r.Body)
+
+	if err = json.Unmarshal(body, v); err != nil {
+		problem.BadRequest(w, r, "invalid_body", "Invalid JSON format: "+err.Error())
+		return false
+	}
+
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, r *http.Request, status int, v any) {
+	resp, err := json.Marshal(v)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if _, err := w.Write(resp); err != nil {
+		logging.FromContext(r.Context()).Error("response write failed", "error", err)
+	}
+}
@@ -0,0 +1,213 @@
+package label
+
+import (
+	"Task_Manager/model/label"
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/gorilla/mux"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newRequest(method, body string, vars map[string]string) *http.Request {
+	return mux.SetURLVars(httptest.NewRequest(method, "/", strings.NewReader(body)), vars)
+}
+
+// Test_NewHandler : To test that interface is correctly implemented or not
+func Test_NewHandler(t *testing.T) {
+	mockSvc := NewMockLabelServiceInterface(gomock.NewController(t))
+
+	if h := NewHandler(mockSvc); h.svc != mockSvc {
+		t.Error("Expected service to be assigned correctly")
+	}
+}
+
+// Test_Create : Tests label is created or not
+func Test_Create(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		vars    map[string]string
+		body    string
+		mockErr error
+		callSvc bool
+		expCode int
+	}{
+		{"Valid label", http.MethodPost, map[string]string{"workspaceid": "1"}, `{"name":"bug","color":"#ff0000"}`, nil, true, http.StatusCreated},
+		{"Invalid workspace", http.MethodPost, map[string]string{"workspaceid": "x"}, `{}`, nil, false, http.StatusBadRequest},
+		{"Invalid JSON", http.MethodPost, map[string]string{"workspaceid": "1"}, `nope`, nil, false, http.StatusBadRequest},
+		{"Invalid color", http.MethodPost, map[string]string{"workspaceid": "1"}, `{"name":"bug"}`, label.ErrInvalidColor, true, http.StatusBadRequest},
+		{"Duplicate", http.MethodPost, map[string]string{"workspaceid": "1"}, `{"name":"bug","color":"#ff0000"}`, label.ErrDuplicate, true, http.StatusConflict},
+		{"wrong HTTP method", http.MethodGet, map[string]string{"workspaceid": "1"}, ``, nil, false, http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := NewMockLabelServiceInterface(gomock.NewController(t))
+
+			if tt.callSvc {
+				mock.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, l label.Label) (label.Label, error) {
+					if l.WorkspaceID != 1 {
+						t.Errorf("workspace not taken from path: %d", l.WorkspaceID)
+					}
+
+					return l, tt.mockErr
+				})
+			}
+
+			rec := httptest.NewRecorder()
+			NewHandler(mock).Create(rec, newRequest(tt.method, tt.body, tt.vars))
+
+			if rec.Code != tt.expCode {
+				t.Errorf("Expected status %d, got %d", tt.expCode, rec.Code)
+			}
+		})
+	}
+}
+
+// Test_ListWorkspace : Tests workspace labels are listed or not
+func Test_ListWorkspace(t *testing.T) {
+	mock := NewMockLabelServiceInterface(gomock.NewController(t))
+	mock.EXPECT().ListWorkspace(gomock.Any(), 1).Return([]label.Label{{ID: 1}}, nil)
+	mock.EXPECT().ListWorkspace(gomock.Any(), 2).Return(nil, errors.New("db down"))
+
+	h := NewHandler(mock)
+
+	rec := httptest.NewRecorder()
+	h.ListWorkspace(rec, newRequest(http.MethodGet, "", map[string]string{"workspaceid": "1"}))
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ListWorkspace(rec, newRequest(http.MethodGet, "", map[string]string{"workspaceid": "2"}))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status %d, got %d", http.StatusInternalServerError, rec.Code)
+	}
+}
+
+// Test_Delete : Tests label is deleted or not
+func Test_Delete(t *testing.T) {
+	mock := NewMockLabelServiceInterface(gomock.NewController(t))
+	mock.EXPECT().Delete(gomock.Any(), 1).Return(nil)
+	mock.EXPECT().Delete(gomock.Any(), 2).Return(label.ErrNotFound)
+
+	h := NewHandler(mock)
+
+	for id, expCode := range map[string]int{"1": http.StatusOK, "2": http.StatusNotFound, "x": http.StatusBadRequest} {
+		rec := httptest.NewRecorder()
+		h.Delete(rec, newRequest(http.MethodDelete, "", map[string]string{"id": id}))
+
+		if rec.Code != expCode {
+			t.Errorf("[%s] Expected status %d, got %d", id, expCode, rec.Code)
+		}
+	}
+}
+
+// Test_ListForTask : Tests labels of a task are listed or not
+func Test_ListForTask(t *testing.T) {
+	mock := NewMockLabelServiceInterface(gomock.NewController(t))
+	mock.EXPECT().ListForTask(gomock.Any(), 1).Return([]label.Label{{ID: 1}}, nil)
+	mock.EXPECT().ListForTask(gomock.Any(), 2).Return(nil, sql.ErrNoRows)
+
+	h := NewHandler(mock)
+
+	for id, expCode := range map[string]int{"1": http.StatusOK, "2": http.StatusNotFound} {
+		rec := httptest.NewRecorder()
+		h.ListForTask(rec, newRequest(http.MethodGet, "", map[string]string{"id": id}))
+
+		if rec.Code != expCode {
+			t.Errorf("[%s] Expected status %d, got %d", id, expCode, rec.Code)
+		}
+	}
+}
+
+// Test_Attach : Tests labels are attached or not
+func Test_Attach(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		mockErr error
+		callSvc bool
+		expCode int
+	}{
+		{"Valid attach", `{"label_ids":[3,4]}`, nil, true, http.StatusNoContent},
+		{"Invalid JSON", `nope`, nil, false, http.StatusBadRequest},
+		{"Unknown label", `{"label_ids":[3,4]}`, label.ErrUnknownTarget, true, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := NewMockLabelServiceInterface(gomock.NewController(t))
+
+			if tt.callSvc {
+				mock.EXPECT().Attach(gomock.Any(), 1, []int{3, 4}).Return(tt.mockErr)
+			}
+
+			rec := httptest.NewRecorder()
+			NewHandler(mock).Attach(rec, newRequest(http.MethodPost, tt.body, map[string]string{"id": "1"}))
+
+			if rec.Code != tt.expCode {
+				t.Errorf("Expected status %d, got %d", tt.expCode, rec.Code)
+			}
+		})
+	}
+}
+
+// Test_Detach : Tests label is detached or not
+func Test_Detach(t *testing.T) {
+	mock := NewMockLabelServiceInterface(gomock.NewController(t))
+	mock.EXPECT().Detach(gomock.Any(), 1, 3).Return(nil)
+	mock.EXPECT().Detach(gomock.Any(), 1, 4).Return(label.ErrNotFound)
+
+	h := NewHandler(mock)
+
+	for id, expCode := range map[string]int{"3": http.StatusNoContent, "4": http.StatusNotFound, "x": http.StatusBadRequest} {
+		rec := httptest.NewRecorder()
+		h.Detach(rec, newRequest(http.MethodDelete, "", map[string]string{"id": "1", "labelid": id}))
+
+		if rec.Code != expCode {
+			t.Errorf("[%s] Expected status %d, got %d", id, expCode, rec.Code)
+		}
+	}
+}
+
+// Test_Relabel : Tests bulk relabelling
+func Test_Relabel(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		body    string
+		mockErr error
+		callSvc bool
+		expCode int
+	}{
+		{"Valid relabel", http.MethodPost, `{"task_ids":[1,2],"add":[3],"remove":[4]}`, nil, true, http.StatusNoContent},
+		{"Too many", http.MethodPost, `{"task_ids":[1,2],"add":[3],"remove":[4]}`, label.ErrTooManyTargets, true, http.StatusBadRequest},
+		{"Invalid JSON", http.MethodPost, `[`, nil, false, http.StatusBadRequest},
+		{"wrong HTTP method", http.MethodGet, ``, nil, false, http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := NewMockLabelServiceInterface(gomock.NewController(t))
+
+			if tt.callSvc {
+				mock.EXPECT().Relabel(gomock.Any(), []int{1, 2}, []int{3}, []int{4}).Return(tt.mockErr)
+			}
+
+			rec := httptest.NewRecorder()
+			NewHandler(mock).Relabel(rec, newRequest(tt.method, tt.body, nil))
+
+			if rec.Code != tt.expCode {
+				t.Errorf("Expected status %d, got %d", tt.expCode, rec.Code)
+			}
+		})
+	}
+}
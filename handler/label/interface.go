@@ -0,0 +1,16 @@
+package label
+
+import (
+	"Task_Manager/model/label"
+	"context"
+)
+
+type LabelServiceInterface interface {
+	Create(ctx context.Context, l label.Label) (label.Label, error)
+	ListWorkspace(ctx context.Context, workspaceID int) ([]label.Label, error)
+	Delete(ctx context.Context, id int) error
+	ListForTask(ctx context.Context, taskID int) ([]label.Label, error)
+	Attach(ctx context.Context, taskID int, labelIDs []int) error
+	Detach(ctx context.Context, taskID, labelID int) error
+	Relabel(ctx context.Context, taskIDs, add, remove []int) error
+}
@@ -0,0 +1,143 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: interface.go
+//
+// Generated by this command:
+//
+//	mockgen -source=interface.go -destination=mock_interface.go -package=label
+//
+
+// Package label is a generated GoMock package.
+package label
+
+import (
+	label "Task_Manager/model/label"
+	context "context"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockLabelServiceInterface is a mock of LabelServiceInterface interface.
+type MockLabelServiceInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockLabelServiceInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockLabelServiceInterfaceMockRecorder is the mock recorder for MockLabelServiceInterface.
+type MockLabelServiceInterfaceMockRecorder struct {
+	mock *MockLabelServiceInterface
+}
+
+// NewMockLabelServiceInterface creates a new mock instance.
+func NewMockLabelServiceInterface(ctrl *gomock.Controller) *MockLabelServiceInterface {
+	mock := &MockLabelServiceInterface{ctrl: ctrl}
+	mock.recorder = &MockLabelServiceInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLabelServiceInterface) EXPECT() *MockLabelServiceInterfaceMockRecorder {
+	return m.recorder
+}
+
+// Attach mocks base method.
+func (m *MockLabelServiceInterface) Attach(ctx context.Context, taskID int, labelIDs []int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Attach", ctx, taskID, labelIDs)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Attach indicates an expected call of Attach.
+func (mr *MockLabelServiceInterfaceMockRecorder) Attach(ctx, taskID, labelIDs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Attach", reflect.TypeOf((*MockLabelServiceInterface)(nil).Attach), ctx, taskID, labelIDs)
+}
+
+// Create mocks base method.
+func (m *MockLabelServiceInterface) Create(ctx context.Context, l label.Label) (label.Label, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, l)
+	ret0, _ := ret[0].(label.Label)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockLabelServiceInterfaceMockRecorder) Create(ctx, l any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockLabelServiceInterface)(nil).Create), ctx, l)
+}
+
+// Delete mocks base method.
+func (m *MockLabelServiceInterface) Delete(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockLabelServiceInterfaceMockRecorder) Delete(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockLabelServiceInterface)(nil).Delete), ctx, id)
+}
+
+// Detach mocks base method.
+func (m *MockLabelServiceInterface) Detach(ctx context.Context, taskID, labelID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Detach", ctx, taskID, labelID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Detach indicates an expected call of Detach.
+func (mr *MockLabelServiceInterfaceMockRecorder) Detach(ctx, taskID, labelID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Detach", reflect.TypeOf((*MockLabelServiceInterface)(nil).Detach), ctx, taskID, labelID)
+}
+
+// ListForTask mocks base method.
+func (m *MockLabelServiceInterface) ListForTask(ctx context.Context, taskID int) ([]label.Label, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListForTask", ctx, taskID)
+	ret0, _ := ret[0].([]label.Label)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListForTask indicates an expected call of ListForTask.
+func (mr *MockLabelServiceInterfaceMockRecorder) ListForTask(ctx, taskID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListForTask", reflect.TypeOf((*MockLabelServiceInterface)(nil).ListForTask), ctx, taskID)
+}
+
+// ListWorkspace mocks base method.
+func (m *MockLabelServiceInterface) ListWorkspace(ctx context.Context, workspaceID int) ([]label.Label, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWorkspace", ctx, workspaceID)
+	ret0, _ := ret[0].([]label.Label)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWorkspace indicates an expected call of ListWorkspace.
+func (mr *MockLabelServiceInterfaceMockRecorder) ListWorkspace(ctx, workspaceID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWorkspace", reflect.TypeOf((*MockLabelServiceInterface)(nil).ListWorkspace), ctx, workspaceID)
+}
+
+// Relabel mocks base method.
+func (m *MockLabelServiceInterface) Relabel(ctx context.Context, taskIDs, add, remove []int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Relabel", ctx, taskIDs, add, remove)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Relabel indicates an expected call of Relabel.
+func (mr *MockLabelServiceInterfaceMockRecorder) Relabel(ctx, taskIDs, add, remove any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Relabel", reflect.TypeOf((*MockLabelServiceInterface)(nil).Relabel), ctx, taskIDs, add, remove)
+}
@@ -0,0 +1,108 @@
+package notification
+
+import (
+	"Task_Manager/auth"
+	"Task_Manager/handler/problem"
+	"Task_Manager/logging"
+	"Task_Manager/model/notification"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	svc NotificationServiceInterface
+}
+
+// NewHandler : Factory function to implement and return behaviour
+func NewHandler(s NotificationServiceInterface) *Handler {
+	return &Handler{svc: s}
+}
+
+// Get notification preferences of a user (GET /users/{id}/notifications)
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		problem.BadRequest(w, r, "invalid_id", "Invalid user ID")
+		return
+	}
+
+	p, err := h.svc.Preferences(r.Context(), id)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, p)
+}
+
+// Update replaces the notification preferences of a user (PUT /users/{id}/notifications)
+func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		problem.BadRequest(w, r, "invalid_id", "Invalid user ID")
+		return
+	}
+
+	var p notification.Preferences
+	if !decodeBody(w, r, &p) {
+		return
+	}
+
+	p.UserID = id
+
+	saved, err := h.svc.SetPreferences(r.Context(), auth.FromContext(r.Context()), p)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, saved)
+}
+
+func decodeBody(w http.ResponseWriter, r *http.Request, v any) bool {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		problem.BadRequest(w, r, "invalid_body", "Failed to read request body: "+err.Error())
+		return false
+	}
+
+	defer func(Body io.ReadCloser) {
+		_ = Body.Close()
+	}(r.Body)
+
+	if err = json.Unmarshal(body, v); err != nil {
+		problem.BadRequest(w, r, "invalid_body", "Invalid JSON format: "+err.Error())
+		return false
+	}
+
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, r *http.Request, status int, v any) {
+	resp, err := json.Marshal(v)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if _, err := w.Write(resp); err != nil {
+		logging.FromContext(r.Context()).Error("response write failed", "error", err)
+	}
+}
@@ -0,0 +1,117 @@
+package notification
+
+import (
+	"Task_Manager/auth"
+	"Task_Manager/model/errs"
+	"Task_Manager/model/notification"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/mock/gomock"
+)
+
+// Test_NewHandler : To test that interface is correctly implemented or not
+func Test_NewHandler(t *testing.T) {
+	mockSvc := NewMockNotificationServiceInterface(gomock.NewController(t))
+
+	if h := NewHandler(mockSvc); h.svc != mockSvc {
+		t.Error("Expected service to be assigned correctly")
+	}
+}
+
+// Test_Get : Tests the preferences of a user are returned
+func Test_Get(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		id      string
+		callSvc bool
+		mockErr error
+		expCode int
+	}{
+		{"Success", http.MethodGet, "4", true, nil, http.StatusOK},
+		{"Invalid ID", http.MethodGet, "x", false, nil, http.StatusBadRequest},
+		{"Store error", http.MethodGet, "4", true, errors.New("db down"), http.StatusInternalServerError},
+		{"Wrong method", http.MethodPost, "4", false, nil, http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := NewMockNotificationServiceInterface(gomock.NewController(t))
+			h := NewHandler(mockSvc)
+
+			if tt.callSvc {
+				mockSvc.EXPECT().Preferences(gomock.Any(), 4).Return(notification.DefaultPreferences(4), tt.mockErr)
+			}
+
+			req := mux.SetURLVars(httptest.NewRequest(tt.method, "/users/"+tt.id+"/notifications", nil), map[string]string{"id": tt.id})
+			rec := httptest.NewRecorder()
+
+			h.Get(rec, req)
+
+			if rec.Code != tt.expCode {
+				t.Errorf("Expected status %d, got %d", tt.expCode, rec.Code)
+			}
+
+			if tt.expCode == http.StatusOK {
+				var p notification.Preferences
+				if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil || p != notification.DefaultPreferences(4) {
+					t.Errorf("Unexpected body %s", rec.Body.String())
+				}
+			}
+		})
+	}
+}
+
+// Test_Update : Tests the preferences of a user are replaced by the acting user
+func Test_Update(t *testing.T) {
+	actor := auth.Actor{UserID: 4}
+	prefs := notification.Preferences{UserID: 4, TaskAssigned: notification.DeliveryDigest, TaskCompleted: notification.DeliveryOff}
+
+	tests := []struct {
+		name    string
+		method  string
+		id      string
+		body    string
+		callSvc bool
+		mockErr error
+		expCode int
+	}{
+		{"Success", http.MethodPut, "4", `{"task_assigned":"digest","task_completed":"off"}`, true, nil, http.StatusOK},
+		{"Path wins over body", http.MethodPut, "4", `{"user_id":9,"task_assigned":"digest","task_completed":"off"}`, true, nil, http.StatusOK},
+		{"Forbidden", http.MethodPut, "4", `{"task_assigned":"digest","task_completed":"off"}`, true,
+			notification.ErrForbidden, http.StatusForbidden},
+		{"Invalid delivery", http.MethodPut, "4", `{"task_assigned":"digest","task_completed":"off"}`, true,
+			errs.Invalid("notification_delivery_invalid", "delivery must be immediate, digest or off"), http.StatusBadRequest},
+		{"Invalid JSON", http.MethodPut, "4", `{`, false, nil, http.StatusBadRequest},
+		{"Invalid ID", http.MethodPut, "x", `{}`, false, nil, http.StatusBadRequest},
+		{"Wrong method", http.MethodGet, "4", `{}`, false, nil, http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := NewMockNotificationServiceInterface(gomock.NewController(t))
+			h := NewHandler(mockSvc)
+
+			if tt.callSvc {
+				mockSvc.EXPECT().SetPreferences(gomock.Any(), actor, prefs).Return(prefs, tt.mockErr)
+			}
+
+			req := httptest.NewRequest(tt.method, "/users/"+tt.id+"/notifications", strings.NewReader(tt.body))
+			req = mux.SetURLVars(req.WithContext(auth.WithActor(context.Background(), actor)), map[string]string{"id": tt.id})
+			rec := httptest.NewRecorder()
+
+			h.Update(rec, req)
+
+			if rec.Code != tt.expCode {
+				t.Errorf("Expected status %d, got %d", tt.expCode, rec.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,12 @@
+package notification
+
+import (
+	"Task_Manager/auth"
+	"Task_Manager/model/notification"
+	"context"
+)
+
+type NotificationServiceInterface interface {
+	Preferences(ctx context.Context, userID int) (notification.Preferences, error)
+	SetPreferences(ctx context.Context, actor auth.Actor, p notification.Preferences) (notification.Preferences, error)
+}
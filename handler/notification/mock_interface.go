@@ -0,0 +1,73 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: interface.go
+//
+// Generated by this command:
+//
+//	mockgen -source=interface.go -destination=mock_interface.go -package=notification
+//
+
+// Package notification is a generated GoMock package.
+package notification
+
+import (
+	auth "Task_Manager/auth"
+	notification "Task_Manager/model/notification"
+	context "context"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockNotificationServiceInterface is a mock of NotificationServiceInterface interface.
+type MockNotificationServiceInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockNotificationServiceInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockNotificationServiceInterfaceMockRecorder is the mock recorder for MockNotificationServiceInterface.
+type MockNotificationServiceInterfaceMockRecorder struct {
+	mock *MockNotificationServiceInterface
+}
+
+// NewMockNotificationServiceInterface creates a new mock instance.
+func NewMockNotificationServiceInterface(ctrl *gomock.Controller) *MockNotificationServiceInterface {
+	mock := &MockNotificationServiceInterface{ctrl: ctrl}
+	mock.recorder = &MockNotificationServiceInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockNotificationServiceInterface) EXPECT() *MockNotificationServiceInterfaceMockRecorder {
+	return m.recorder
+}
+
+// Preferences mocks base method.
+func (m *MockNotificationServiceInterface) Preferences(ctx context.Context, userID int) (notification.Preferences, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Preferences", ctx, userID)
+	ret0, _ := ret[0].(notification.Preferences)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Preferences indicates an expected call of Preferences.
+func (mr *MockNotificationServiceInterfaceMockRecorder) Preferences(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Preferences", reflect.TypeOf((*MockNotificationServiceInterface)(nil).Preferences), ctx, userID)
+}
+
+// SetPreferences mocks base method.
+func (m *MockNotificationServiceInterface) SetPreferences(ctx context.Context, actor auth.Actor, p notification.Preferences) (notification.Preferences, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPreferences", ctx, actor, p)
+	ret0, _ := ret[0].(notification.Preferences)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetPreferences indicates an expected call of SetPreferences.
+func (mr *MockNotificationServiceInterfaceMockRecorder) SetPreferences(ctx, actor, p any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPreferences", reflect.TypeOf((*MockNotificationServiceInterface)(nil).SetPreferences), ctx, actor, p)
+}
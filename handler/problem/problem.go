@@ -0,0 +1,125 @@
+// Package problem is the single place where errors become HTTP responses. Every handler reports
+// failures through Write, which answers with RFC 7807 problem details.
+package problem
+
+import (
+	"Task_Manager/logging"
+	"Task_Manager/model/errs"
+	"Task_Manager/request"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ContentType is the media type of RFC 7807 problem details
+const ContentType = "application/problem+json"
+
+// Codes of problems that do not come from a domain error
+const (
+	CodeNotFound     = "not_found"
+	CodeBodyTooLarge = "body_too_large"
+	CodeInternal     = "internal"
+)
+
+// Problem is an RFC 7807 problem details object. Code is stable and meant for programs to branch on;
+// Title and Detail are meant for people and may change.
+type Problem struct {
+	Type      string            `json:"type"`
+	Title     string            `json:"title"`
+	Status    int               `json:"status"`
+	Detail    string            `json:"detail,omitempty"`
+	Instance  string            `json:"instance,omitempty"`
+	Code      string            `json:"code"`
+	Errors    []errs.FieldError `json:"errors,omitempty"`
+	RequestID string            `json:"request_id,omitempty"`
+}
+
+func newProblem(status int, code, detail string) Problem {
+	return Problem{
+		Type:   "/problems/" + code,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+// From maps err onto problem details. Domain errors keep their code and message, store lookups that
+// found no row become not_found, and anything else is an internal error whose message is withheld.
+func From(err error) Problem {
+	var (
+		notFound      *errs.NotFound
+		invalid       *errs.Validation
+		conflict      *errs.Conflict
+		unauthorized  *errs.Unauthorized
+		forbidden     *errs.Forbidden
+		tooLarge      *errs.TooLarge
+		unsupported   *errs.Unsupported
+		unprocessable *errs.Unprocessable
+		limited       *errs.RateLimited
+		maxBytes      *http.MaxBytesError
+	)
+
+	switch {
+	case errors.As(err, &notFound):
+		return newProblem(http.StatusNotFound, notFound.Code, err.Error())
+	case errors.As(err, &invalid):
+		p := newProblem(http.StatusBadRequest, invalid.Code, err.Error())
+		p.Errors = invalid.Fields
+
+		return p
+	case errors.As(err, &conflict):
+		return newProblem(http.StatusConflict, conflict.Code, err.Error())
+	case errors.As(err, &unauthorized):
+		return newProblem(http.StatusUnauthorized, unauthorized.Code, err.Error())
+	case errors.As(err, &forbidden):
+		return newProblem(http.StatusForbidden, forbidden.Code, err.Error())
+	case errors.As(err, &tooLarge):
+		return newProblem(http.StatusRequestEntityTooLarge, tooLarge.Code, err.Error())
+	case errors.As(err, &unsupported):
+		return newProblem(http.StatusUnsupportedMediaType, unsupported.Code, err.Error())
+	case errors.As(err, &unprocessable):
+		return newProblem(http.StatusUnprocessableEntity, unprocessable.Code, err.Error())
+	case errors.As(err, &limited):
+		return newProblem(http.StatusTooManyRequests, limited.Code, err.Error())
+	case errors.As(err, &maxBytes):
+		return newProblem(http.StatusRequestEntityTooLarge, CodeBodyTooLarge, fmt.Sprintf("request body exceeds %d bytes", maxBytes.Limit))
+	case errors.Is(err, sql.ErrNoRows):
+		return newProblem(http.StatusNotFound, CodeNotFound, "resource not found")
+	default:
+		return newProblem(http.StatusInternalServerError, CodeInternal, "an unexpected error occurred")
+	}
+}
+
+// Write answers the request with the problem details of err. Internal errors are logged with the
+// request ID, which the client also receives, so a report can be matched with the log.
+func Write(w http.ResponseWriter, r *http.Request, err error) {
+	p := From(err)
+	p.Instance = r.URL.Path
+	p.RequestID = request.ID(r.Context())
+
+	if p.Status == http.StatusInternalServerError {
+		logging.FromContext(r.Context()).Error("internal error", "method", r.Method, "path", r.URL.Path, "error", err)
+	}
+
+	body, err := json.Marshal(p)
+	if err != nil {
+		http.Error(w, p.Detail, p.Status)
+		return
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+
+	if _, err := w.Write(body); err != nil {
+		logging.FromContext(r.Context()).Error("response write failed", "error", err)
+	}
+}
+
+// BadRequest rejects input the handler itself could not parse, such as a malformed path parameter or body
+func BadRequest(w http.ResponseWriter, r *http.Request, code, detail string, fields ...errs.FieldError) {
+	Write(w, r, errs.Invalid(code, detail, fields...))
+}
@@ -0,0 +1,107 @@
+package problem
+
+import (
+	"Task_Manager/model/errs"
+	"Task_Manager/request"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_From(t *testing.T) {
+	fields := []errs.FieldError{{Field: "email", Message: "cannot be empty"}}
+
+	tests := []struct {
+		name      string
+		err       error
+		expStatus int
+		expCode   string
+		expDetail string
+		expErrors []errs.FieldError
+	}{
+		{"Not found", &errs.NotFound{Code: "task_not_found", Message: "task not found"},
+			http.StatusNotFound, "task_not_found", "task not found", nil},
+		{"Validation with fields", errs.Invalid("user_invalid", "email cannot be empty", fields...),
+			http.StatusBadRequest, "user_invalid", "email cannot be empty", fields},
+		{"Conflict", &errs.Conflict{Code: "label_exists", Message: "label exists"},
+			http.StatusConflict, "label_exists", "label exists", nil},
+		{"Unauthorized", &errs.Unauthorized{Code: "user_signature_invalid", Message: "bad signature"},
+			http.StatusUnauthorized, "user_signature_invalid", "bad signature", nil},
+		{"Forbidden", &errs.Forbidden{Code: "audit_forbidden", Message: "admins only"},
+			http.StatusForbidden, "audit_forbidden", "admins only", nil},
+		{"Too large", &errs.TooLarge{Code: "attachment_too_large", Message: "too large"},
+			http.StatusRequestEntityTooLarge, "attachment_too_large", "too large", nil},
+		{"Unsupported", &errs.Unsupported{Code: "attachment_type_not_allowed", Message: "not allowed"},
+			http.StatusUnsupportedMediaType, "attachment_type_not_allowed", "not allowed", nil},
+		{"Unprocessable", &errs.Unprocessable{Code: "idempotency_key_reused", Message: "reused"},
+			http.StatusUnprocessableEntity, "idempotency_key_reused", "reused", nil},
+		{"Rate limited", &errs.RateLimited{Code: "rate_limited", Message: "slow down"},
+			http.StatusTooManyRequests, "rate_limited", "slow down", nil},
+		{"Body over limit", &http.MaxBytesError{Limit: 1024},
+			http.StatusRequestEntityTooLarge, CodeBodyTooLarge, "request body exceeds 1024 bytes", nil},
+		{"No rows", sql.ErrNoRows,
+			http.StatusNotFound, CodeNotFound, "resource not found", nil},
+		{"Wrapped domain error", fmt.Errorf("loading task 3: %w", &errs.NotFound{Code: "task_not_found", Message: "task not found"}),
+			http.StatusNotFound, "task_not_found", "loading task 3: task not found", nil},
+		{"Internal error is withheld", errors.New("dial tcp 10.0.0.5:3306: connection refused"),
+			http.StatusInternalServerError, CodeInternal, "an unexpected error occurred", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := From(tt.err)
+
+			assert.Equal(t, tt.expStatus, p.Status)
+			assert.Equal(t, tt.expCode, p.Code)
+			assert.Equal(t, tt.expDetail, p.Detail)
+			assert.Equal(t, tt.expErrors, p.Errors)
+			assert.Equal(t, "/problems/"+tt.expCode, p.Type)
+			assert.Equal(t, http.StatusText(tt.expStatus), p.Title)
+		})
+	}
+}
+
+func Test_Write(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/task/9", nil)
+	req = req.WithContext(request.WithInfo(req.Context(), "req-42", "192.0.2.1"))
+	rec := httptest.NewRecorder()
+
+	Write(rec, req, &errs.NotFound{Code: "task_not_found", Message: "task not found"})
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, ContentType, rec.Header().Get("Content-Type"))
+	assert.Equal(t, "nosniff", rec.Header().Get("X-Content-Type-Options"))
+
+	var p Problem
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &p))
+	assert.Equal(t, Problem{
+		Type:      "/problems/task_not_found",
+		Title:     "Not Found",
+		Status:    http.StatusNotFound,
+		Detail:    "task not found",
+		Instance:  "/task/9",
+		Code:      "task_not_found",
+		RequestID: "req-42",
+	}, p)
+}
+
+func Test_BadRequest(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/task/abc", nil)
+	rec := httptest.NewRecorder()
+
+	BadRequest(rec, req, "invalid_id", "Invalid ID", errs.FieldError{Field: "id", Message: "must be a number"})
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	var p Problem
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &p))
+	assert.Equal(t, "invalid_id", p.Code)
+	assert.Equal(t, []errs.FieldError{{Field: "id", Message: "must be a number"}}, p.Errors)
+	assert.Empty(t, p.RequestID)
+}
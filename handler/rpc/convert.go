@@ -0,0 +1,92 @@
+package rpc
+
+import (
+	"Task_Manager/model/task"
+	"Task_Manager/model/user"
+	pb "Task_Manager/proto/taskmanager/v1"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// policies maps the delete policies of the API onto the model; an unspecified policy rejects
+var policies = map[pb.DeletePolicy]user.DeletePolicy{
+	pb.DeletePolicy_DELETE_POLICY_UNSPECIFIED: user.PolicyReject,
+	pb.DeletePolicy_DELETE_POLICY_REJECT:      user.PolicyReject,
+	pb.DeletePolicy_DELETE_POLICY_REASSIGN:    user.PolicyReassign,
+	pb.DeletePolicy_DELETE_POLICY_UNASSIGN:    user.PolicyUnassign,
+	pb.DeletePolicy_DELETE_POLICY_CASCADE:     user.PolicyCascade,
+}
+
+func taskToProto(t task.Task) *pb.Task {
+	out := &pb.Task{
+		Id:          int64(t.ID),
+		Desc:        t.Desc,
+		Status:      t.Status,
+		UserId:      int64(t.Userid),
+		WorkspaceId: int64(t.WorkspaceID),
+	}
+
+	if t.Due != nil {
+		out.Due = timestamppb.New(*t.Due)
+	}
+
+	return out
+}
+
+func taskFromProto(t *pb.Task) task.Task {
+	out := task.Task{
+		Desc:        t.GetDesc(),
+		Status:      t.GetStatus(),
+		Userid:      int(t.GetUserId()),
+		WorkspaceID: int(t.GetWorkspaceId()),
+	}
+
+	if t.GetDue() != nil {
+		due := t.GetDue().AsTime()
+		out.Due = &due
+	}
+
+	return out
+}
+
+func userToProto(u user.User) *pb.User {
+	return &pb.User{
+		Id:          int64(u.ID),
+		Name:        u.Name,
+		Email:       u.Email,
+		DisplayName: u.DisplayName,
+		AvatarUrl:   u.AvatarURL,
+		Timezone:    u.Timezone,
+	}
+}
+
+func userFromProto(u *pb.User) user.User {
+	return user.User{
+		Name:        u.GetName(),
+		Email:       u.GetEmail(),
+		DisplayName: u.GetDisplayName(),
+		AvatarURL:   u.GetAvatarUrl(),
+		Timezone:    u.GetTimezone(),
+	}
+}
+
+func reportToProto(r user.DeleteReport) *pb.DeleteReport {
+	out := &pb.DeleteReport{
+		UserId:     int64(r.UserID),
+		ReassignTo: int64(r.ReassignTo),
+		DryRun:     r.DryRun,
+		Tasks:      make([]int64, len(r.Tasks)),
+	}
+
+	for p, m := range policies {
+		if m == r.Policy && p != pb.DeletePolicy_DELETE_POLICY_UNSPECIFIED {
+			out.Policy = p
+		}
+	}
+
+	for i, id := range r.Tasks {
+		out.Tasks[i] = int64(id)
+	}
+
+	return out
+}
@@ -0,0 +1,76 @@
+package rpc
+
+import (
+	"Task_Manager/auth"
+	pb "Task_Manager/proto/taskmanager/v1"
+	"Task_Manager/request"
+	"context"
+	"net/http"
+	"net/textproto"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"go.opentelemetry.io/otel"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// NewGateway returns a handler translating the REST routes annotated in the protobuf definitions into
+// calls on the gRPC server reached through conn, so they run through its interceptors and the same
+// services as the gRPC API. Mounted under a prefix, the prefix must be stripped before the handler.
+//
+// The acting user headers are passed on as x-user-id and x-user-signature and checked again by the
+// server, the request ID and trace of the HTTP request are carried over, and messages use the field names
+// of the definitions, with 64-bit integers written as strings as protojson does. Failed calls answer with the HTTP status matching
+// the gRPC code and the status as JSON.
+func NewGateway(ctx context.Context, conn *grpc.ClientConn) (http.Handler, error) {
+	mux := runtime.NewServeMux(
+		runtime.WithIncomingHeaderMatcher(incomingHeader),
+		runtime.WithOutgoingHeaderMatcher(outgoingHeader),
+		runtime.WithMetadata(gatewayMetadata),
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.JSONPb{
+			MarshalOptions:   protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true},
+			UnmarshalOptions: protojson.UnmarshalOptions{DiscardUnknown: true},
+		}),
+	)
+
+	if err := pb.RegisterTaskServiceHandler(ctx, mux, conn); err != nil {
+		return nil, err
+	}
+
+	if err := pb.RegisterUserServiceHandler(ctx, mux, conn); err != nil {
+		return nil, err
+	}
+
+	return mux, nil
+}
+
+// incomingHeader passes the acting user headers on to the server, along with the headers the gateway
+// forwards by default
+func incomingHeader(key string) (string, bool) {
+	switch textproto.CanonicalMIMEHeaderKey(key) {
+	case textproto.CanonicalMIMEHeaderKey(auth.HeaderUserID):
+		return MetadataUserID, true
+	case textproto.CanonicalMIMEHeaderKey(auth.HeaderSignature):
+		return MetadataSignature, true
+	}
+
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+// outgoingHeader leaves out the request ID echoed by the server, the HTTP middleware already sets it
+func outgoingHeader(key string) (string, bool) {
+	if key == MetadataRequestID {
+		return "", false
+	}
+
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+// gatewayMetadata carries the request ID and trace of the HTTP request over to the call
+func gatewayMetadata(ctx context.Context, _ *http.Request) metadata.MD {
+	md := metadata.Pairs(MetadataRequestID, request.ID(ctx))
+	otel.GetTextMapPropagator().Inject(ctx, metadataCarrier(md))
+
+	return md
+}
@@ -0,0 +1,234 @@
+package rpc
+
+import (
+	"Task_Manager/auth"
+	"Task_Manager/logging"
+	"Task_Manager/metrics"
+	"Task_Manager/request"
+	"Task_Manager/tracing"
+	"context"
+	"log/slog"
+	"net"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Metadata keys read from and sent to callers; gRPC metadata keys are lower case forms of the REST headers
+var (
+	MetadataUserID    = strings.ToLower(auth.HeaderUserID)
+	MetadataSignature = strings.ToLower(auth.HeaderSignature)
+	MetadataRequestID = strings.ToLower(request.HeaderID)
+)
+
+var (
+	rpcTracer = tracing.Tracer("handler/rpc")
+
+	grpcRequests = metrics.Default.NewCounterVec("grpc_requests_total",
+		"gRPC calls served, by method and status code", "method", "code")
+	grpcDuration = metrics.Default.NewHistogramVec("grpc_request_duration_seconds",
+		"Time taken to serve gRPC calls, by method and status code", metrics.DefaultBuckets, "method", "code")
+)
+
+// interceptor does for each call what the REST middleware does for each request. It assigns the
+// request ID, echoed in the x-request-id header, continues the caller's trace, stores a logger and the
+// acting user in the context, and once the call ends turns its error into a status, counts and times
+// it and writes one access log record.
+type interceptor struct {
+	logger *slog.Logger
+	authn  *auth.Authenticator
+}
+
+func newInterceptor(logger *slog.Logger, authn *auth.Authenticator) *interceptor {
+	return &interceptor{logger: logger, authn: authn}
+}
+
+func (i *interceptor) unary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	c, ctx, err := i.begin(ctx, info.FullMethod)
+	_ = grpc.SetHeader(ctx, metadata.Pairs(MetadataRequestID, request.ID(ctx)))
+
+	var resp any
+	if err == nil {
+		resp, err = handler(ctx, req)
+	}
+
+	return resp, c.end(ctx, err)
+}
+
+func (i *interceptor) stream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	c, ctx, err := i.begin(ss.Context(), info.FullMethod)
+	_ = ss.SetHeader(metadata.Pairs(MetadataRequestID, request.ID(ctx)))
+
+	if err == nil {
+		err = handler(srv, &serverStream{ServerStream: ss, ctx: ctx, call: c})
+	}
+
+	return c.end(ctx, err)
+}
+
+// call is one call being served
+type call struct {
+	method    string
+	start     time.Time
+	logger    *slog.Logger
+	span      trace.Span
+	userAgent string
+	// sent counts the messages sent on a stream
+	sent int
+}
+
+// begin prepares the context of a call. The error rejects a call whose x-user-id is not a user ID or,
+// when signatures are verified, is not signed by x-user-signature.
+func (i *interceptor) begin(ctx context.Context, method string) (*call, context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	first := func(key string) string {
+		if v := md.Get(key); len(v) > 0 {
+			return v[0]
+		}
+
+		return ""
+	}
+
+	c := &call{method: method, start: time.Now(), userAgent: first("user-agent")}
+
+	ctx = otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+	ctx, c.span = rpcTracer.Start(ctx, strings.TrimPrefix(method, "/"),
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(append(rpcAttributes(method), semconv.RPCSystemGRPC)...))
+
+	var ip string
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		ip = p.Addr.String()
+		if host, _, err := net.SplitHostPort(ip); err == nil {
+			ip = host
+		}
+	}
+
+	ctx = request.WithInfo(ctx, request.AcceptID(first(MetadataRequestID)), ip)
+
+	c.logger = i.logger.With("request_id", request.ID(ctx))
+	if sc := c.span.SpanContext(); sc.IsValid() {
+		c.logger = c.logger.With("trace_id", sc.TraceID().String())
+	}
+
+	ctx = logging.WithLogger(ctx, c.logger)
+
+	actor, err := i.authn.Actor(first(MetadataUserID), first(MetadataSignature))
+	if err != nil || actor.UserID == 0 {
+		return c, ctx, err
+	}
+
+	return c, auth.WithActor(ctx, actor), nil
+}
+
+// end reports on the call and returns its error as a status
+func (c *call) end(ctx context.Context, err error) error {
+	defer c.span.End()
+
+	var s *status.Status
+	if err != nil {
+		s = toStatus(ctx, c.method, err)
+	}
+
+	code := s.Code()
+	latency := time.Since(c.start)
+
+	grpcRequests.Inc(c.method, code.String())
+	grpcDuration.Observe(latency.Seconds(), c.method, code.String())
+
+	c.span.SetAttributes(semconv.RPCGRPCStatusCodeKey.Int(int(code)))
+
+	level := slog.LevelInfo
+	if serverFault(code) {
+		level = slog.LevelError
+		c.span.SetStatus(otelcodes.Error, s.Message())
+	}
+
+	c.logger.LogAttrs(ctx, level, "request",
+		slog.String("method", c.method),
+		slog.String("code", code.String()),
+		slog.Int("messages", c.sent),
+		slog.Duration("latency", latency),
+		slog.String("remote_ip", request.RemoteIP(ctx)),
+		slog.String("user_agent", c.userAgent),
+	)
+
+	return s.Err()
+}
+
+// serverFault reports whether code blames the server rather than the caller, as 5xx statuses do
+func serverFault(code codes.Code) bool {
+	switch code {
+	case codes.Unknown, codes.Internal, codes.Unavailable, codes.DataLoss, codes.Unimplemented:
+		return true
+	default:
+		return false
+	}
+}
+
+// rpcAttributes names the service and method of a full method name such as /pkg.Service/Method
+func rpcAttributes(method string) []attribute.KeyValue {
+	service, name, ok := strings.Cut(strings.TrimPrefix(method, "/"), "/")
+	if !ok {
+		return nil
+	}
+
+	return []attribute.KeyValue{semconv.RPCService(service), semconv.RPCMethod(name)}
+}
+
+// serverStream hands the handler of a streaming call the context prepared for it, and counts the
+// messages it sends
+type serverStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	call *call
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *serverStream) SendMsg(m any) error {
+	if err := s.ServerStream.SendMsg(m); err != nil {
+		return err
+	}
+
+	s.call.sent++
+
+	return nil
+}
+
+// metadataCarrier reads and writes trace context in gRPC metadata, as propagation.HeaderCarrier does in
+// HTTP headers
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	if v := metadata.MD(c).Get(key); len(v) > 0 {
+		return v[0]
+	}
+
+	return ""
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+
+	return keys
+}
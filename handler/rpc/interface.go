@@ -0,0 +1,22 @@
+package rpc
+
+import (
+	"Task_Manager/model/task"
+	"Task_Manager/model/user"
+	"context"
+)
+
+type TaskServiceInterface interface {
+	Create(ctx context.Context, t task.Task) (task.Task, error)
+	GetTask(ctx context.Context, id int) (task.Task, error)
+	Complete(ctx context.Context, id int) error
+	Delete(ctx context.Context, id int) error
+	Export(ctx context.Context, f task.Filter, fn func(task.Task) error) error
+}
+
+type UserServiceInterface interface {
+	Create(ctx context.Context, u user.User) (user.User, error)
+	Get(ctx context.Context, id int) (user.User, error)
+	DeleteWithPolicy(ctx context.Context, id int, opts user.DeleteOptions) (user.DeleteReport, error)
+	All(ctx context.Context) ([]user.User, error)
+}
@@ -0,0 +1,199 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: interface.go
+//
+// Generated by this command:
+//
+//	mockgen -source=interface.go -destination=mock_interface.go -package=rpc
+//
+
+// Package rpc is a generated GoMock package.
+package rpc
+
+import (
+	task "Task_Manager/model/task"
+	user "Task_Manager/model/user"
+	context "context"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockTaskServiceInterface is a mock of TaskServiceInterface interface.
+type MockTaskServiceInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockTaskServiceInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockTaskServiceInterfaceMockRecorder is the mock recorder for MockTaskServiceInterface.
+type MockTaskServiceInterfaceMockRecorder struct {
+	mock *MockTaskServiceInterface
+}
+
+// NewMockTaskServiceInterface creates a new mock instance.
+func NewMockTaskServiceInterface(ctrl *gomock.Controller) *MockTaskServiceInterface {
+	mock := &MockTaskServiceInterface{ctrl: ctrl}
+	mock.recorder = &MockTaskServiceInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTaskServiceInterface) EXPECT() *MockTaskServiceInterfaceMockRecorder {
+	return m.recorder
+}
+
+// Complete mocks base method.
+func (m *MockTaskServiceInterface) Complete(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Complete", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Complete indicates an expected call of Complete.
+func (mr *MockTaskServiceInterfaceMockRecorder) Complete(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Complete", reflect.TypeOf((*MockTaskServiceInterface)(nil).Complete), ctx, id)
+}
+
+// Create mocks base method.
+func (m *MockTaskServiceInterface) Create(ctx context.Context, t task.Task) (task.Task, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, t)
+	ret0, _ := ret[0].(task.Task)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockTaskServiceInterfaceMockRecorder) Create(ctx, t any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockTaskServiceInterface)(nil).Create), ctx, t)
+}
+
+// Delete mocks base method.
+func (m *MockTaskServiceInterface) Delete(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockTaskServiceInterfaceMockRecorder) Delete(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockTaskServiceInterface)(nil).Delete), ctx, id)
+}
+
+// Export mocks base method.
+func (m *MockTaskServiceInterface) Export(ctx context.Context, f task.Filter, fn func(task.Task) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Export", ctx, f, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Export indicates an expected call of Export.
+func (mr *MockTaskServiceInterfaceMockRecorder) Export(ctx, f, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Export", reflect.TypeOf((*MockTaskServiceInterface)(nil).Export), ctx, f, fn)
+}
+
+// GetTask mocks base method.
+func (m *MockTaskServiceInterface) GetTask(ctx context.Context, id int) (task.Task, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTask", ctx, id)
+	ret0, _ := ret[0].(task.Task)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTask indicates an expected call of GetTask.
+func (mr *MockTaskServiceInterfaceMockRecorder) GetTask(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTask", reflect.TypeOf((*MockTaskServiceInterface)(nil).GetTask), ctx, id)
+}
+
+// MockUserServiceInterface is a mock of UserServiceInterface interface.
+type MockUserServiceInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserServiceInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockUserServiceInterfaceMockRecorder is the mock recorder for MockUserServiceInterface.
+type MockUserServiceInterfaceMockRecorder struct {
+	mock *MockUserServiceInterface
+}
+
+// NewMockUserServiceInterface creates a new mock instance.
+func NewMockUserServiceInterface(ctrl *gomock.Controller) *MockUserServiceInterface {
+	mock := &MockUserServiceInterface{ctrl: ctrl}
+	mock.recorder = &MockUserServiceInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUserServiceInterface) EXPECT() *MockUserServiceInterfaceMockRecorder {
+	return m.recorder
+}
+
+// All mocks base method.
+func (m *MockUserServiceInterface) All(ctx context.Context) ([]user.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "All", ctx)
+	ret0, _ := ret[0].([]user.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// All indicates an expected call of All.
+func (mr *MockUserServiceInterfaceMockRecorder) All(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "All", reflect.TypeOf((*MockUserServiceInterface)(nil).All), ctx)
+}
+
+// Create mocks base method.
+func (m *MockUserServiceInterface) Create(ctx context.Context, u user.User) (user.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, u)
+	ret0, _ := ret[0].(user.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockUserServiceInterfaceMockRecorder) Create(ctx, u any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockUserServiceInterface)(nil).Create), ctx, u)
+}
+
+// DeleteWithPolicy mocks base method.
+func (m *MockUserServiceInterface) DeleteWithPolicy(ctx context.Context, id int, opts user.DeleteOptions) (user.DeleteReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWithPolicy", ctx, id, opts)
+	ret0, _ := ret[0].(user.DeleteReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteWithPolicy indicates an expected call of DeleteWithPolicy.
+func (mr *MockUserServiceInterfaceMockRecorder) DeleteWithPolicy(ctx, id, opts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWithPolicy", reflect.TypeOf((*MockUserServiceInterface)(nil).DeleteWithPolicy), ctx, id, opts)
+}
+
+// Get mocks base method.
+func (m *MockUserServiceInterface) Get(ctx context.Context, id int) (user.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, id)
+	ret0, _ := ret[0].(user.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockUserServiceInterfaceMockRecorder) Get(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockUserServiceInterface)(nil).Get), ctx, id)
+}
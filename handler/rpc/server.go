@@ -0,0 +1,170 @@
+// Package rpc serves tasks and users over gRPC, next to the REST routes and through the same services.
+// The services are defined in proto/taskmanager/v1, where each method is annotated with the REST route
+// it corresponds to, and NewGateway serves those routes by calling the gRPC server. Calls pass through
+// interceptors matching the REST middleware: request IDs, traces, access logs, metrics and the acting
+// user.
+package rpc
+
+import (
+	"Task_Manager/auth"
+	"Task_Manager/model/errs"
+	"Task_Manager/model/task"
+	"Task_Manager/model/user"
+	pb "Task_Manager/proto/taskmanager/v1"
+	replicaStore "Task_Manager/store/replica"
+	"context"
+	"log/slog"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// NewServer returns a gRPC server exposing the task and user services. The acting user of calls is
+// resolved by authn from their x-user-id and x-user-signature metadata, as it is from the REST headers.
+func NewServer(tasks TaskServiceInterface, users UserServiceInterface, logger *slog.Logger, authn *auth.Authenticator, opts ...grpc.ServerOption) *grpc.Server {
+	i := newInterceptor(logger, authn)
+	opts = append(opts, grpc.ChainUnaryInterceptor(i.unary), grpc.ChainStreamInterceptor(i.stream))
+
+	s := grpc.NewServer(opts...)
+	pb.RegisterTaskServiceServer(s, NewTaskServer(tasks))
+	pb.RegisterUserServiceServer(s, NewUserServer(users))
+
+	return s
+}
+
+// TaskServer implements taskmanager.v1.TaskService
+type TaskServer struct {
+	pb.UnimplementedTaskServiceServer
+	svc TaskServiceInterface
+}
+
+// NewTaskServer : Factory function to implement and return behaviour
+func NewTaskServer(svc TaskServiceInterface) *TaskServer {
+	return &TaskServer{svc: svc}
+}
+
+// CreateTask creates a task (POST /task)
+func (s *TaskServer) CreateTask(ctx context.Context, req *pb.CreateTaskRequest) (*pb.Task, error) {
+	created, err := s.svc.Create(replicaStore.Primary(ctx), taskFromProto(req.GetTask()))
+	if err != nil {
+		return nil, err
+	}
+
+	return taskToProto(created), nil
+}
+
+// GetTask returns a task (GET /task/{id})
+func (s *TaskServer) GetTask(ctx context.Context, req *pb.GetTaskRequest) (*pb.Task, error) {
+	t, err := s.svc.GetTask(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, err
+	}
+
+	return taskToProto(t), nil
+}
+
+// CompleteTask marks a task as done (PUT /task/{id})
+func (s *TaskServer) CompleteTask(ctx context.Context, req *pb.CompleteTaskRequest) (*emptypb.Empty, error) {
+	if err := s.svc.Complete(replicaStore.Primary(ctx), int(req.GetId())); err != nil {
+		return nil, err
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// DeleteTask moves a task to the trash (DELETE /task/{id})
+func (s *TaskServer) DeleteTask(ctx context.Context, req *pb.DeleteTaskRequest) (*emptypb.Empty, error) {
+	if err := s.svc.Delete(replicaStore.Primary(ctx), int(req.GetId())); err != nil {
+		return nil, err
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// ListTasks streams the tasks matching the request as they are read from the store (GET /task)
+func (s *TaskServer) ListTasks(req *pb.ListTasksRequest, stream grpc.ServerStreamingServer[pb.Task]) error {
+	if req.GetLimit() < 0 {
+		return errs.Invalid("invalid_limit", "limit cannot be negative",
+			errs.FieldError{Field: "limit", Message: "must be zero or more"})
+	}
+
+	f := task.Filter{
+		UserID:      int(req.GetUserId()),
+		WorkspaceID: int(req.GetWorkspaceId()),
+		Labels:      req.GetLabels(),
+		MatchAll:    req.GetMatchAll(),
+		Status:      req.Status,
+		AfterID:     int(req.GetAfterId()),
+		Limit:       int(req.GetLimit()),
+	}
+
+	return s.svc.Export(stream.Context(), f, func(t task.Task) error {
+		return stream.Send(taskToProto(t))
+	})
+}
+
+// UserServer implements taskmanager.v1.UserService
+type UserServer struct {
+	pb.UnimplementedUserServiceServer
+	svc UserServiceInterface
+}
+
+// NewUserServer : Factory function to implement and return behaviour
+func NewUserServer(svc UserServiceInterface) *UserServer {
+	return &UserServer{svc: svc}
+}
+
+// CreateUser creates a user (POST /users)
+func (s *UserServer) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.User, error) {
+	created, err := s.svc.Create(replicaStore.Primary(ctx), userFromProto(req.GetUser()))
+	if err != nil {
+		return nil, err
+	}
+
+	return userToProto(created), nil
+}
+
+// GetUser returns a user (GET /users/{id})
+func (s *UserServer) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.User, error) {
+	u, err := s.svc.Get(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, err
+	}
+
+	return userToProto(u), nil
+}
+
+// DeleteUser moves a user to the trash, or reports what doing so would change (DELETE /users/{id})
+func (s *UserServer) DeleteUser(ctx context.Context, req *pb.DeleteUserRequest) (*pb.DeleteReport, error) {
+	policy, ok := policies[req.GetPolicy()]
+	if !ok {
+		return nil, user.ErrInvalidPolicy
+	}
+
+	report, err := s.svc.DeleteWithPolicy(replicaStore.Primary(ctx), int(req.GetId()), user.DeleteOptions{
+		Policy:     policy,
+		ReassignTo: int(req.GetReassignTo()),
+		DryRun:     req.GetDryRun(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reportToProto(report), nil
+}
+
+// ListUsers streams the users (GET /users)
+func (s *UserServer) ListUsers(_ *pb.ListUsersRequest, stream grpc.ServerStreamingServer[pb.User]) error {
+	users, err := s.svc.All(stream.Context())
+	if err != nil {
+		return err
+	}
+
+	for _, u := range users {
+		if err := stream.Send(userToProto(u)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,376 @@
+package rpc
+
+import (
+	"Task_Manager/auth"
+	"Task_Manager/model/task"
+	"Task_Manager/model/user"
+	pb "Task_Manager/proto/taskmanager/v1"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type clients struct {
+	conn  *grpc.ClientConn
+	tasks pb.TaskServiceClient
+	users pb.UserServiceClient
+}
+
+// setup serves the gRPC API over an in-memory connection, with user 1 as an admin
+func setup(t *testing.T) (clients, *MockTaskServiceInterface, *MockUserServiceInterface) {
+	return setupAuth(t, auth.Config{TrustHeader: true, AdminIDs: []int{1}})
+}
+
+// setupAuth serves the gRPC API over an in-memory connection, resolving actors as cfg says
+func setupAuth(t *testing.T, cfg auth.Config) (clients, *MockTaskServiceInterface, *MockUserServiceInterface) {
+	ctrl := gomock.NewController(t)
+	tasks := NewMockTaskServiceInterface(ctrl)
+	users := NewMockUserServiceInterface(ctrl)
+
+	lis := bufconn.Listen(1 << 20)
+	s := NewServer(tasks, users, slog.New(slog.NewTextHandler(io.Discard, nil)), auth.New(cfg))
+
+	go func() { _ = s.Serve(lis) }()
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return clients{conn: conn, tasks: pb.NewTaskServiceClient(conn), users: pb.NewUserServiceClient(conn)}, tasks, users
+}
+
+func Test_CreateTask(t *testing.T) {
+	c, tasks, _ := setup(t)
+	due := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+
+	tasks.EXPECT().Create(gomock.Any(), task.Task{Desc: "Write", Userid: 2, Due: &due}).
+		DoAndReturn(func(ctx context.Context, t task.Task) (task.Task, error) {
+			t.ID = 7
+			return t, nil
+		})
+
+	var header metadata.MD
+	ctx := metadata.AppendToOutgoingContext(context.Background(), MetadataRequestID, "req-1")
+
+	got, err := c.tasks.CreateTask(ctx, &pb.CreateTaskRequest{Task: &pb.Task{Id: 99, Desc: "Write", UserId: 2, Due: timestamppb.New(due)}},
+		grpc.Header(&header))
+	require.NoError(t, err)
+	assert.True(t, proto.Equal(&pb.Task{Id: 7, Desc: "Write", UserId: 2, Due: timestamppb.New(due)}, got))
+	// The caller's request ID is kept and echoed
+	assert.Equal(t, []string{"req-1"}, header.Get(MetadataRequestID))
+}
+
+func Test_CompleteAndDeleteTask(t *testing.T) {
+	c, tasks, _ := setup(t)
+
+	tasks.EXPECT().Complete(gomock.Any(), 4).Return(nil)
+	tasks.EXPECT().Delete(gomock.Any(), 4).Return(nil)
+
+	_, err := c.tasks.CompleteTask(context.Background(), &pb.CompleteTaskRequest{Id: 4})
+	require.NoError(t, err)
+
+	_, err = c.tasks.DeleteTask(context.Background(), &pb.DeleteTaskRequest{Id: 4})
+	require.NoError(t, err)
+}
+
+func Test_ListTasks(t *testing.T) {
+	c, tasks, _ := setup(t)
+	done := true
+
+	tasks.EXPECT().Export(gomock.Any(), task.Filter{UserID: 2, Status: &done, Labels: []string{"ops"}, WorkspaceID: 3, AfterID: 10, Limit: 2}, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ task.Filter, fn func(task.Task) error) error {
+			for _, t := range []task.Task{{ID: 11, Desc: "A", Status: true, Userid: 2}, {ID: 12, Desc: "B", Status: true, Userid: 2}} {
+				if err := fn(t); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+
+	stream, err := c.tasks.ListTasks(context.Background(), &pb.ListTasksRequest{
+		UserId: 2, Status: &done, Labels: []string{"ops"}, WorkspaceId: 3, AfterId: 10, Limit: 2,
+	})
+	require.NoError(t, err)
+
+	var ids []int64
+	for {
+		got, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		require.NoError(t, err)
+		ids = append(ids, got.GetId())
+	}
+
+	assert.Equal(t, []int64{11, 12}, ids)
+}
+
+func Test_ListTasksFailure(t *testing.T) {
+	c, tasks, _ := setup(t)
+
+	// Failures after the first task end the stream with their status
+	tasks.EXPECT().Export(gomock.Any(), task.Filter{}, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ task.Filter, fn func(task.Task) error) error {
+			require.NoError(t, fn(task.Task{ID: 1, Desc: "A"}))
+			return errors.New("connection reset")
+		})
+
+	stream, err := c.tasks.ListTasks(context.Background(), &pb.ListTasksRequest{})
+	require.NoError(t, err)
+
+	_, err = stream.Recv()
+	require.NoError(t, err)
+
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Internal, status.Code(err))
+
+	stream, err = c.tasks.ListTasks(context.Background(), &pb.ListTasksRequest{Limit: -1})
+	require.NoError(t, err)
+
+	_, err = stream.Recv()
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func Test_Errors(t *testing.T) {
+	c, tasks, users := setup(t)
+
+	tasks.EXPECT().GetTask(gomock.Any(), 5).Return(task.Task{}, task.ErrNotFound)
+	tasks.EXPECT().Create(gomock.Any(), gomock.Any()).Return(task.Task{}, task.ErrEmptyDesc)
+	tasks.EXPECT().Complete(gomock.Any(), 5).Return(errors.New("connection refused"))
+	users.EXPECT().DeleteWithPolicy(gomock.Any(), 5, gomock.Any()).Return(user.DeleteReport{}, user.ErrHasTasks)
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), MetadataRequestID, "req-2")
+
+	_, err := c.tasks.GetTask(ctx, &pb.GetTaskRequest{Id: 5})
+	s := status.Convert(err)
+	assert.Equal(t, codes.NotFound, s.Code())
+	assert.Equal(t, "task not found", s.Message())
+	assert.Contains(t, detailsOf(s), "ErrorInfo:task_not_found")
+	assert.Contains(t, detailsOf(s), "RequestInfo:req-2")
+
+	_, err = c.tasks.CreateTask(ctx, &pb.CreateTaskRequest{Task: &pb.Task{}})
+	s = status.Convert(err)
+	assert.Equal(t, codes.InvalidArgument, s.Code())
+	assert.Contains(t, detailsOf(s), "BadRequest:desc")
+
+	// Unexpected errors are not told to the caller
+	_, err = c.tasks.CompleteTask(ctx, &pb.CompleteTaskRequest{Id: 5})
+	s = status.Convert(err)
+	assert.Equal(t, codes.Internal, s.Code())
+	assert.Equal(t, "an unexpected error occurred", s.Message())
+
+	_, err = c.users.DeleteUser(ctx, &pb.DeleteUserRequest{Id: 5})
+	s = status.Convert(err)
+	assert.Equal(t, codes.FailedPrecondition, s.Code())
+	assert.Contains(t, detailsOf(s), "ErrorInfo:user_has_tasks")
+}
+
+// detailsOf lists the details of s as kind:value
+func detailsOf(s *status.Status) []string {
+	var out []string
+
+	for _, d := range s.Details() {
+		switch d := d.(type) {
+		case *errdetails.ErrorInfo:
+			out = append(out, "ErrorInfo:"+d.GetReason())
+		case *errdetails.RequestInfo:
+			out = append(out, "RequestInfo:"+d.GetRequestId())
+		case *errdetails.BadRequest:
+			for _, v := range d.GetFieldViolations() {
+				out = append(out, "BadRequest:"+v.GetField())
+			}
+		}
+	}
+
+	return out
+}
+
+func Test_Actor(t *testing.T) {
+	tests := []struct {
+		name     string
+		userID   string
+		expCode  codes.Code
+		expActor auth.Actor
+	}{
+		{"Anonymous", "", codes.OK, auth.Actor{}},
+		{"Regular user", "7", codes.OK, auth.Actor{UserID: 7}},
+		{"Admin user", "1", codes.OK, auth.Actor{UserID: 1, Admin: true}},
+		{"Malformed user", "abc", codes.InvalidArgument, auth.Actor{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _, users := setup(t)
+
+			if tt.expCode == codes.OK {
+				users.EXPECT().Get(gomock.Any(), 3).DoAndReturn(func(ctx context.Context, id int) (user.User, error) {
+					assert.Equal(t, tt.expActor, auth.FromContext(ctx))
+					return user.User{ID: 3}, nil
+				})
+			}
+
+			ctx := context.Background()
+			if tt.userID != "" {
+				ctx = metadata.AppendToOutgoingContext(ctx, MetadataUserID, tt.userID)
+			}
+
+			_, err := c.users.GetUser(ctx, &pb.GetUserRequest{Id: 3})
+			assert.Equal(t, tt.expCode, status.Code(err))
+		})
+	}
+}
+
+func Test_SignedActor(t *testing.T) {
+	secret := []byte("shared")
+	c, _, users := setupAuth(t, auth.Config{Secret: secret, AdminIDs: []int{1}})
+
+	users.EXPECT().Get(gomock.Any(), 3).DoAndReturn(func(ctx context.Context, id int) (user.User, error) {
+		assert.Equal(t, auth.Actor{UserID: 1, Admin: true}, auth.FromContext(ctx))
+		return user.User{ID: 3}, nil
+	})
+
+	signed := metadata.AppendToOutgoingContext(context.Background(),
+		MetadataUserID, "1", MetadataSignature, auth.Sign(secret, 1, time.Now()))
+	_, err := c.users.GetUser(signed, &pb.GetUserRequest{Id: 3})
+	require.NoError(t, err)
+
+	unsigned := metadata.AppendToOutgoingContext(context.Background(), MetadataUserID, "1")
+	_, err = c.users.GetUser(unsigned, &pb.GetUserRequest{Id: 3})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func Test_DeleteUser(t *testing.T) {
+	c, _, users := setup(t)
+
+	users.EXPECT().DeleteWithPolicy(gomock.Any(), 2, user.DeleteOptions{Policy: user.PolicyReassign, ReassignTo: 3, DryRun: true}).
+		Return(user.DeleteReport{UserID: 2, Policy: user.PolicyReassign, ReassignTo: 3, DryRun: true, Tasks: []int{4, 5}}, nil)
+
+	got, err := c.users.DeleteUser(context.Background(), &pb.DeleteUserRequest{
+		Id: 2, Policy: pb.DeletePolicy_DELETE_POLICY_REASSIGN, ReassignTo: 3, DryRun: true,
+	})
+	require.NoError(t, err)
+	assert.True(t, proto.Equal(&pb.DeleteReport{
+		UserId: 2, Policy: pb.DeletePolicy_DELETE_POLICY_REASSIGN, ReassignTo: 3, DryRun: true, Tasks: []int64{4, 5},
+	}, got))
+
+	_, err = c.users.DeleteUser(context.Background(), &pb.DeleteUserRequest{Id: 2, Policy: pb.DeletePolicy(42)})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func Test_CreateAndListUsers(t *testing.T) {
+	c, _, users := setup(t)
+
+	users.EXPECT().Create(gomock.Any(), user.User{Name: "Alice", Email: "Alice@Example.com", Timezone: "Europe/Berlin"}).
+		Return(user.User{ID: 1, Name: "Alice", Email: "alice@example.com", Timezone: "Europe/Berlin"}, nil)
+	users.EXPECT().All(gomock.Any()).Return([]user.User{{ID: 1, Name: "Alice"}, {ID: 2, Name: "Bob"}}, nil)
+
+	created, err := c.users.CreateUser(context.Background(), &pb.CreateUserRequest{
+		User: &pb.User{Name: "Alice", Email: "Alice@Example.com", Timezone: "Europe/Berlin"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "alice@example.com", created.GetEmail())
+
+	stream, err := c.users.ListUsers(context.Background(), &pb.ListUsersRequest{})
+	require.NoError(t, err)
+
+	var names []string
+	for {
+		got, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		require.NoError(t, err)
+		names = append(names, got.GetName())
+	}
+
+	assert.Equal(t, []string{"Alice", "Bob"}, names)
+}
+
+// Test_HTTPRules checks that every method is mapped onto a REST route
+func Test_HTTPRules(t *testing.T) {
+	for _, sd := range []protoreflect.ServiceDescriptor{
+		pb.File_taskmanager_v1_task_proto.Services().ByName("TaskService"),
+		pb.File_taskmanager_v1_user_proto.Services().ByName("UserService"),
+	} {
+		methods := sd.Methods()
+		for i := 0; i < methods.Len(); i++ {
+			m := methods.Get(i)
+			rule, _ := proto.GetExtension(m.Options(), annotations.E_Http).(*annotations.HttpRule)
+			assert.NotNil(t, rule.GetPattern(), "%s has no HTTP rule", m.FullName())
+		}
+	}
+}
+
+func Test_Gateway(t *testing.T) {
+	c, tasks, _ := setup(t)
+
+	gw, err := NewGateway(context.Background(), c.conn)
+	require.NoError(t, err)
+
+	tasks.EXPECT().Create(gomock.Any(), task.Task{Desc: "Write", Userid: 2}).
+		DoAndReturn(func(ctx context.Context, created task.Task) (task.Task, error) {
+			// The acting user reaches the service through the interceptors
+			assert.Equal(t, auth.Actor{UserID: 1, Admin: true}, auth.FromContext(ctx))
+			created.ID = 7
+			return created, nil
+		})
+
+	req := httptest.NewRequest(http.MethodPost, "/task", strings.NewReader(`{"desc":"Write","user_id":2}`))
+	req.Header.Set(auth.HeaderUserID, "1")
+	rec := httptest.NewRecorder()
+	gw.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"id":"7","desc":"Write","status":false,"user_id":"2","due":null,"workspace_id":"0"}`, rec.Body.String())
+
+	tasks.EXPECT().GetTask(gomock.Any(), 8).Return(task.Task{}, task.ErrNotFound)
+
+	rec = httptest.NewRecorder()
+	gw.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/task/8", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	tasks.EXPECT().Export(gomock.Any(), task.Filter{UserID: 2, Limit: 1}, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ task.Filter, fn func(task.Task) error) error {
+			return fn(task.Task{ID: 11, Desc: "A", Userid: 2})
+		})
+
+	rec = httptest.NewRecorder()
+	gw.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/task?user_id=2&limit=1", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"result":{"id":"11","desc":"A","status":false,"user_id":"2","due":null,"workspace_id":"0"}}`, rec.Body.String())
+
+	// A malformed acting user is still rejected by the server
+	req = httptest.NewRequest(http.MethodDelete, "/task/7", nil)
+	req.Header.Set(auth.HeaderUserID, "abc")
+	rec = httptest.NewRecorder()
+	gw.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
@@ -0,0 +1,82 @@
+package rpc
+
+import (
+	"Task_Manager/handler/problem"
+	"Task_Manager/logging"
+	"Task_Manager/request"
+	"context"
+	"errors"
+	"net/http"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+// ErrorDomain names this API in the ErrorInfo details of failed calls
+const ErrorDomain = "taskmanager"
+
+// codeOf maps the statuses of problem details onto gRPC codes, so a failure gets the same code however
+// it is reached. Conflicts fail a precondition of the current state, as unprocessable requests do.
+var codeOf = map[int]codes.Code{
+	http.StatusBadRequest:            codes.InvalidArgument,
+	http.StatusUnauthorized:          codes.Unauthenticated,
+	http.StatusForbidden:             codes.PermissionDenied,
+	http.StatusNotFound:              codes.NotFound,
+	http.StatusConflict:              codes.FailedPrecondition,
+	http.StatusRequestEntityTooLarge: codes.InvalidArgument,
+	http.StatusUnsupportedMediaType:  codes.InvalidArgument,
+	http.StatusUnprocessableEntity:   codes.FailedPrecondition,
+	http.StatusTooManyRequests:       codes.ResourceExhausted,
+	http.StatusInternalServerError:   codes.Internal,
+}
+
+// toStatus is the gRPC counterpart of problem.Write. Domain errors keep their message, with their code
+// as the reason of an ErrorInfo detail and their invalid fields as a BadRequest detail; anything else is
+// an internal error whose message is withheld and which is logged with the request ID, also sent to the
+// client. Errors that already are a status pass through.
+func toStatus(ctx context.Context, method string, err error) *status.Status {
+	if s, ok := status.FromError(err); ok {
+		return s
+	}
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return status.New(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.New(codes.DeadlineExceeded, err.Error())
+	}
+
+	p := problem.From(err)
+
+	code, ok := codeOf[p.Status]
+	if !ok {
+		code = codes.Unknown
+	}
+
+	if code == codes.Internal {
+		logging.FromContext(ctx).Error("internal error", "method", method, "error", err)
+	}
+
+	details := []protoadapt.MessageV1{
+		&errdetails.ErrorInfo{Reason: p.Code, Domain: ErrorDomain},
+		&errdetails.RequestInfo{RequestId: request.ID(ctx)},
+	}
+
+	if len(p.Errors) > 0 {
+		violations := make([]*errdetails.BadRequest_FieldViolation, len(p.Errors))
+		for i, f := range p.Errors {
+			violations[i] = &errdetails.BadRequest_FieldViolation{Field: f.Field, Description: f.Message}
+		}
+
+		details = append(details, &errdetails.BadRequest{FieldViolations: violations})
+	}
+
+	s := status.New(code, p.Detail)
+	if withDetails, err := s.WithDetails(details...); err == nil {
+		return withDetails
+	}
+
+	return s
+}
@@ -0,0 +1,251 @@
+package task
+
+import (
+	"Task_Manager/model/task"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Media types offered by GET /task and GET /task/user/{userid}
+const (
+	mediaJSON     = "application/json"
+	mediaCSV      = "text/csv"
+	mediaNDJSON   = "application/x-ndjson"
+	mediaMarkdown = "text/markdown"
+	mediaCalendar = "text/calendar"
+)
+
+// exportFiles names the download of each export format
+var exportFiles = map[string]string{
+	mediaCSV:      "tasks.csv",
+	mediaNDJSON:   "tasks.ndjson",
+	mediaMarkdown: "tasks.md",
+	mediaCalendar: "tasks.ics",
+}
+
+// negotiate picks the media type to answer with from an Accept header, preferring the highest quality
+// and then the earliest listed. Anything it cannot satisfy, including a missing header, gets JSON.
+func negotiate(accept string) string {
+	best, bestQ := mediaJSON, 0.0
+
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+		if raw, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(raw, 64); err != nil {
+				continue
+			}
+		}
+
+		if mediaType == "*/*" {
+			mediaType = mediaJSON
+		}
+
+		if _, ok := exportFiles[mediaType]; !ok && mediaType != mediaJSON {
+			continue
+		}
+
+		if q > bestQ {
+			best, bestQ = mediaType, q
+		}
+	}
+
+	return best
+}
+
+// encoder writes tasks in one export format: begin before the first task, end after the last
+type encoder interface {
+	begin() error
+	task(t task.Task) error
+	end() error
+}
+
+func newEncoder(mediaType string, w io.Writer) encoder {
+	switch mediaType {
+	case mediaCSV:
+		return &csvEncoder{w: csv.NewWriter(w)}
+	case mediaMarkdown:
+		return &markdownEncoder{w: w}
+	case mediaCalendar:
+		return &calendarEncoder{w: w, stamp: time.Now().UTC()}
+	default:
+		return &ndjsonEncoder{enc: json.NewEncoder(w)}
+	}
+}
+
+// due renders an optional due date as RFC 3339, or empty when there is none
+func due(t task.Task) string {
+	if t.Due == nil {
+		return ""
+	}
+
+	return t.Due.UTC().Format(time.RFC3339)
+}
+
+type csvEncoder struct {
+	w *csv.Writer
+}
+
+func (e *csvEncoder) begin() error {
+	return e.write([]string{"id", "desc", "status", "userid", "due"})
+}
+
+func (e *csvEncoder) task(t task.Task) error {
+	userid := ""
+	if t.Userid != 0 {
+		userid = strconv.Itoa(t.Userid)
+	}
+
+	return e.write([]string{strconv.Itoa(t.ID), csvCell(t.Desc), strconv.FormatBool(t.Status), userid, due(t)})
+}
+
+// csvCell quotes text that a spreadsheet would otherwise evaluate as a formula
+func csvCell(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+
+	return s
+}
+
+// write passes each record straight through, so rows leave as soon as the caller flushes its writer
+func (e *csvEncoder) write(record []string) error {
+	if err := e.w.Write(record); err != nil {
+		return err
+	}
+
+	e.w.Flush()
+
+	return e.w.Error()
+}
+
+func (e *csvEncoder) end() error { return nil }
+
+type ndjsonEncoder struct {
+	enc *json.Encoder
+}
+
+func (e *ndjsonEncoder) begin() error { return nil }
+
+func (e *ndjsonEncoder) task(t task.Task) error { return e.enc.Encode(t) }
+
+func (e *ndjsonEncoder) end() error { return nil }
+
+// markdownEncoder writes a GitHub flavoured Markdown table
+type markdownEncoder struct {
+	w io.Writer
+}
+
+var markdownCell = strings.NewReplacer("\\", "\\\\", "|", "\\|", "\r\n", "<br>", "\n", "<br>", "\r", "<br>")
+
+func (e *markdownEncoder) begin() error {
+	_, err := io.WriteString(e.w, "| ID | Description | Status | Assignee | Due |\n| ---: | --- | --- | ---: | --- |\n")
+	return err
+}
+
+func (e *markdownEncoder) task(t task.Task) error {
+	status, assignee := "open", ""
+	if t.Status {
+		status = "done"
+	}
+
+	if t.Userid != 0 {
+		assignee = strconv.Itoa(t.Userid)
+	}
+
+	_, err := fmt.Fprintf(e.w, "| %d | %s | %s | %s | %s |\n", t.ID, markdownCell.Replace(t.Desc), status, assignee, due(t))
+
+	return err
+}
+
+func (e *markdownEncoder) end() error { return nil }
+
+// calendarEncoder writes an iCalendar (RFC 5545) object with one VTODO per task
+type calendarEncoder struct {
+	w     io.Writer
+	stamp time.Time
+}
+
+const icalTime = "20060102T150405Z"
+
+// maxLineOctets is the longest content line RFC 5545 allows before it has to be folded
+const maxLineOctets = 75
+
+var icalText = strings.NewReplacer("\\", "\\\\", ";", "\\;", ",", "\\,", "\r\n", "\\n", "\n", "\\n", "\r", "\\n")
+
+func (e *calendarEncoder) begin() error {
+	return e.lines("BEGIN:VCALENDAR", "VERSION:2.0", "PRODID:-//Task_Manager//Tasks//EN")
+}
+
+func (e *calendarEncoder) task(t task.Task) error {
+	status := "NEEDS-ACTION"
+	if t.Status {
+		status = "COMPLETED"
+	}
+
+	lines := []string{
+		"BEGIN:VTODO",
+		fmt.Sprintf("UID:task-%d@task-manager", t.ID),
+		"DTSTAMP:" + e.stamp.Format(icalTime),
+		"SUMMARY:" + icalText.Replace(t.Desc),
+		"STATUS:" + status,
+	}
+
+	if t.Due != nil {
+		lines = append(lines, "DUE:"+t.Due.UTC().Format(icalTime))
+	}
+
+	return e.lines(append(lines, "END:VTODO")...)
+}
+
+func (e *calendarEncoder) end() error {
+	return e.lines("END:VCALENDAR")
+}
+
+func (e *calendarEncoder) lines(lines ...string) error {
+	for _, line := range lines {
+		if _, err := io.WriteString(e.w, fold(line)+"\r\n"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// fold splits a content line into chunks of at most 75 octets, continuing each on a new line that
+// starts with a space, without cutting a UTF-8 sequence apart
+func fold(line string) string {
+	if len(line) <= maxLineOctets {
+		return line
+	}
+
+	var b strings.Builder
+
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// The leading space of a continuation line counts towards its length
+		limit = maxLineOctets - 1
+	}
+
+	b.WriteString(line)
+
+	return b.String()
+}
@@ -0,0 +1,195 @@
+package task
+
+import (
+	"Task_Manager/model/task"
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Negotiate(t *testing.T) {
+	tests := []struct {
+		accept string
+		exp    string
+	}{
+		{"", mediaJSON},
+		{"*/*", mediaJSON},
+		{"text/csv", mediaCSV},
+		{"text/csv; charset=utf-8", mediaCSV},
+		{"application/json, text/csv", mediaJSON},
+		{"text/csv;q=0.5, text/calendar", mediaCalendar},
+		{"image/png, application/x-ndjson;q=0.1", mediaNDJSON},
+		{"image/png", mediaJSON},
+		{"text/markdown;q=abc, text/markdown", mediaMarkdown},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.exp, negotiate(tt.accept), tt.accept)
+	}
+}
+
+func Test_Fold(t *testing.T) {
+	assert.Equal(t, "SUMMARY:short", fold("SUMMARY:short"))
+
+	long := "SUMMARY:" + strings.Repeat("é", 60)
+	folded := fold(long)
+
+	for _, line := range strings.Split(folded, "\r\n") {
+		assert.LessOrEqual(t, len(line), maxLineOctets)
+		assert.True(t, strings.ToValidUTF8(line, "?") == line, "line cut inside a UTF-8 sequence: %q", line)
+	}
+
+	assert.Equal(t, long, strings.ReplaceAll(folded, "\r\n ", ""))
+}
+
+func Test_CSVCell(t *testing.T) {
+	tests := []struct {
+		in, exp string
+	}{
+		{"", ""},
+		{"Write docs", "Write docs"},
+		{"a=b", "a=b"},
+		{"=HYPERLINK(\"http://evil\")", "'=HYPERLINK(\"http://evil\")"},
+		{"+1", "'+1"},
+		{"-2+3", "'-2+3"},
+		{"@SUM(A1)", "'@SUM(A1)"},
+		{"\t=1", "'\t=1"},
+		{"\r=1", "'\r=1"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.exp, csvCell(tt.in), tt.in)
+	}
+
+	var buf bytes.Buffer
+
+	enc := newEncoder(mediaCSV, &buf)
+	assert.NoError(t, enc.task(task.Task{ID: 3, Desc: "=1+1"}))
+	assert.Equal(t, "3,'=1+1,false,,\n", buf.String())
+}
+
+func Test_Encoders(t *testing.T) {
+	due := time.Date(2024, 5, 7, 12, 30, 0, 0, time.UTC)
+	tasks := []task.Task{
+		{ID: 1, Desc: "Write docs, then | review", Status: true, Userid: 2, Due: &due},
+		{ID: 2, Desc: "Line one\nline two; done"},
+	}
+
+	tests := []struct {
+		mediaType string
+		exp       string
+	}{
+		{mediaCSV, "id,desc,status,userid,due\n" +
+			"1,\"Write docs, then | review\",true,2,2024-05-07T12:30:00Z\n" +
+			"2,\"Line one\nline two; done\",false,,\n"},
+		{mediaNDJSON, `{"id":1,"desc":"Write docs, then | review","status":true,"userid":2,"due":"2024-05-07T12:30:00Z"}` + "\n" +
+			`{"id":2,"desc":"Line one\nline two; done","status":false,"userid":0}` + "\n"},
+		{mediaMarkdown, "| ID | Description | Status | Assignee | Due |\n| ---: | --- | --- | ---: | --- |\n" +
+			"| 1 | Write docs, then \\| review | done | 2 | 2024-05-07T12:30:00Z |\n" +
+			"| 2 | Line one<br>line two; done | open |  |  |\n"},
+		{mediaCalendar, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//Task_Manager//Tasks//EN\r\n" +
+			"BEGIN:VTODO\r\nUID:task-1@task-manager\r\nDTSTAMP:20240501T090000Z\r\nSUMMARY:Write docs\\, then | review\r\n" +
+			"STATUS:COMPLETED\r\nDUE:20240507T123000Z\r\nEND:VTODO\r\n" +
+			"BEGIN:VTODO\r\nUID:task-2@task-manager\r\nDTSTAMP:20240501T090000Z\r\nSUMMARY:Line one\\nline two\\; done\r\n" +
+			"STATUS:NEEDS-ACTION\r\nEND:VTODO\r\nEND:VCALENDAR\r\n"},
+	}
+
+	for _, tt := range tests {
+		var buf bytes.Buffer
+
+		enc := newEncoder(tt.mediaType, &buf)
+		if c, ok := enc.(*calendarEncoder); ok {
+			c.stamp = time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+		}
+
+		assert.NoError(t, enc.begin())
+
+		for _, tsk := range tasks {
+			assert.NoError(t, enc.task(tsk))
+		}
+
+		assert.NoError(t, enc.end())
+		assert.Equal(t, tt.exp, buf.String(), tt.mediaType)
+	}
+}
+
+func Test_Export(t *testing.T) {
+	tests := []struct {
+		name      string
+		target    string
+		vars      map[string]string
+		accept    string
+		filter    task.Filter
+		mockErr   error
+		ExpCode   int
+		ExpType   string
+		ExpPrefix string
+	}{
+		{"All as CSV", "/task", nil, "text/csv", task.Filter{}, nil, http.StatusOK, "text/csv; charset=utf-8", "id,desc,status,userid,due\n1,Working,false,1,\n"},
+		{"Labelled as Markdown", "/task?labels=bug&match=all", nil, "text/markdown", task.Filter{Labels: []string{"bug"}, MatchAll: true}, nil, http.StatusOK, "text/markdown; charset=utf-8", "| ID |"},
+		{"User tasks as iCalendar", "/task/user/1", map[string]string{"userid": "1"}, "text/calendar", task.Filter{UserID: 1}, nil, http.StatusOK, "text/calendar; charset=utf-8", "BEGIN:VCALENDAR\r\n"},
+		{"Unknown user", "/task/user/9", map[string]string{"userid": "9"}, "text/csv", task.Filter{UserID: 9}, sql.ErrNoRows, http.StatusNotFound, "", ""},
+		{"Store error", "/task", nil, "application/x-ndjson", task.Filter{}, errors.New("db down"), http.StatusInternalServerError, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mock := NewMockTaskServiceInterface(ctrl)
+			h := &Handler{mock}
+
+			mock.EXPECT().Export(gomock.Any(), tt.filter, gomock.Any()).DoAndReturn(func(_ context.Context, _ task.Filter, fn func(task.Task) error) error {
+				if tt.mockErr != nil {
+					return tt.mockErr
+				}
+
+				return fn(task.Task{ID: 1, Desc: "Working", Userid: 1})
+			})
+
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			req.Header.Set("Accept", tt.accept)
+			rec := httptest.NewRecorder()
+
+			if tt.vars != nil {
+				h.GetTasksByUserID(rec, mux.SetURLVars(req, tt.vars))
+			} else {
+				h.All(rec, req)
+			}
+
+			assert.Equal(t, tt.ExpCode, rec.Code)
+			assert.Equal(t, "Accept", rec.Header().Get("Vary"))
+
+			if tt.ExpCode == http.StatusOK {
+				assert.Equal(t, tt.ExpType, rec.Header().Get("Content-Type"))
+				assert.Contains(t, rec.Header().Get("Content-Disposition"), "attachment")
+				assert.True(t, strings.HasPrefix(rec.Body.String(), tt.ExpPrefix), rec.Body.String())
+			}
+		})
+	}
+}
+
+func Test_ExportEmpty(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mock := NewMockTaskServiceInterface(ctrl)
+	h := &Handler{mock}
+
+	mock.EXPECT().Export(gomock.Any(), task.Filter{}, gomock.Any()).Return(nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/task", nil)
+	req.Header.Set("Accept", "text/csv")
+	rec := httptest.NewRecorder()
+	h.All(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "id,desc,status,userid,due\n", rec.Body.String())
+}
@@ -1,13 +1,21 @@
 package task
 
 import (
+	"Task_Manager/handler/problem"
+	"Task_Manager/logging"
+	"Task_Manager/model/errs"
 	"Task_Manager/model/task"
+	"bufio"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/gorilla/mux"
 	"io"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 )
 
 type Handler struct {
@@ -28,7 +36,7 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		http.Error(w, "Failed to read request body: "+err.Error(), http.StatusBadRequest)
+		problem.BadRequest(w, r, "invalid_body", "Failed to read request body: "+err.Error())
 		return
 	}
 
@@ -39,20 +47,20 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 	var t task.Task
 
 	if err = json.Unmarshal(body, &t); err != nil {
-		http.Error(w, "Invalid JSON format: "+err.Error(), http.StatusBadRequest)
+		problem.BadRequest(w, r, "invalid_body", "Invalid JSON format: "+err.Error())
 		return
 	}
 
-	task1, err := h.svc.Create(t)
+	task1, err := h.svc.Create(r.Context(), t)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		problem.Write(w, r, err)
 		return
 	}
 
 	resp, err := json.Marshal(task1)
 
 	if err != nil {
-		http.Error(w, "Failed to marshal task: "+err.Error(), http.StatusInternalServerError)
+		problem.Write(w, r, err)
 		return
 	}
 
@@ -60,12 +68,12 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusCreated)
 
 	if _, err := w.Write(resp); err != nil {
-		fmt.Println("Write failed:", err)
+		logging.FromContext(r.Context()).Error("response write failed", "error", err)
 	}
 
 }
 
-// GetTask by ID (GET /task/{id})
+// GetTask by ID (GET /task/{id}), or as it was at a point in time (GET /task/{id}?as_of=2024-05-07T12:00:00Z)
 func (h *Handler) GetTask(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		w.WriteHeader(http.StatusMethodNotAllowed)
@@ -76,20 +84,34 @@ func (h *Handler) GetTask(w http.ResponseWriter, r *http.Request) {
 
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		problem.BadRequest(w, r, "invalid_id", "Invalid ID")
 		return
 	}
 
-	task1, err := h.svc.GetTask(id)
-	if err != nil {
-		http.Error(w, "Task not found", http.StatusNotFound)
+	var task1 task.Task
+
+	if raw := r.URL.Query().Get("as_of"); raw != "" {
+		at, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			problem.BadRequest(w, r, "invalid_as_of", "Invalid as_of, expected RFC 3339",
+				errs.FieldError{Field: "as_of", Message: "must be an RFC 3339 time"})
+			return
+		}
+
+		task1, err = h.svc.AsOf(r.Context(), id, at)
+		if err != nil {
+			problem.Write(w, r, err)
+			return
+		}
+	} else if task1, err = h.svc.GetTask(r.Context(), id); err != nil {
+		problem.Write(w, r, err)
 		return
 	}
 
 	resp, err := json.Marshal(task1)
 
 	if err != nil {
-		http.Error(w, "Failed to marshal response: "+err.Error(), http.StatusInternalServerError)
+		problem.Write(w, r, err)
 		return
 	}
 
@@ -97,11 +119,11 @@ func (h *Handler) GetTask(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 
 	if _, err := w.Write(resp); err != nil {
-		fmt.Println("Write failed:", err)
+		logging.FromContext(r.Context()).Error("response write failed", "error", err)
 	}
 }
 
-// GetTasksByUserID which are assigned to user_id
+// GetTasksByUserID which are assigned to user_id, exported as CSV, NDJSON, Markdown or iCalendar when the Accept header asks for it
 func (h *Handler) GetTasksByUserID(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		w.WriteHeader(http.StatusMethodNotAllowed)
@@ -112,30 +134,35 @@ func (h *Handler) GetTasksByUserID(w http.ResponseWriter, r *http.Request) {
 
 	userid, err := strconv.Atoi(idStr)
 	if err != nil {
-		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		problem.BadRequest(w, r, "invalid_id", "Invalid ID")
+		return
+	}
+
+	w.Header().Add("Vary", "Accept")
+
+	if mediaType := negotiate(r.Header.Get("Accept")); mediaType != mediaJSON {
+		h.export(w, r, task.Filter{UserID: userid}, mediaType)
 		return
 	}
 
-	tasks, err := h.svc.GetTasksByUserID(userid)
+	tasks, err := h.svc.GetTasksByUserID(r.Context(), userid)
 	if err != nil {
-		http.Error(w, "Task not found", http.StatusNotFound)
+		problem.Write(w, r, err)
 		return
 	}
 
 	resp, err := json.Marshal(tasks)
 
 	if err != nil {
-		http.Error(w, "Failed to marshal response: "+err.Error(), http.StatusInternalServerError)
+		problem.Write(w, r, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 
-	_, err = w.Write(resp)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+	if _, err := w.Write(resp); err != nil {
+		logging.FromContext(r.Context()).Error("response write failed", "error", err)
 	}
 }
 
@@ -150,12 +177,12 @@ func (h *Handler) Complete(w http.ResponseWriter, r *http.Request) {
 
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		problem.BadRequest(w, r, "invalid_id", "Invalid ID")
 		return
 	}
 
-	if err = h.svc.Complete(id); err != nil {
-		http.Error(w, "Task not found", http.StatusNotFound)
+	if err = h.svc.Complete(r.Context(), id); err != nil {
+		problem.Write(w, r, err)
 		return
 	}
 
@@ -163,7 +190,7 @@ func (h *Handler) Complete(w http.ResponseWriter, r *http.Request) {
 
 	_, err = w.Write([]byte(fmt.Sprintf("Task %d marked as complete", id)))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		logging.FromContext(r.Context()).Error("response write failed", "error", err)
 	}
 }
 
@@ -178,12 +205,12 @@ func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		problem.BadRequest(w, r, "invalid_id", "Invalid ID")
 		return
 	}
 
-	if err = h.svc.Delete(id); err != nil {
-		http.Error(w, "Task not found", http.StatusNotFound)
+	if err = h.svc.Delete(r.Context(), id); err != nil {
+		problem.Write(w, r, err)
 		return
 	}
 
@@ -191,27 +218,54 @@ func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 
 	_, err = w.Write([]byte(fmt.Sprintf("Task %d deleted", id)))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		logging.FromContext(r.Context()).Error("response write failed", "error", err)
 	}
 }
 
-// All Tasks (GET /task)
+// All Tasks (GET /task), optionally filtered with ?labels=bug,urgent&match=any|all&workspace={id}.
+// Accept: text/csv, application/x-ndjson, text/markdown or text/calendar exports them instead.
 func (h *Handler) All(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		return
 	}
 
-	tasks, err := h.svc.All()
+	var (
+		tasks  []task.Task
+		err    error
+		filter task.Filter
+	)
+
+	q := r.URL.Query()
+	if q.Has("labels") {
+		var ok bool
+		if filter.Labels, filter.WorkspaceID, filter.MatchAll, ok = labelFilter(w, r, q); !ok {
+			return
+		}
+	}
+
+	w.Header().Add("Vary", "Accept")
+
+	if mediaType := negotiate(r.Header.Get("Accept")); mediaType != mediaJSON {
+		h.export(w, r, filter, mediaType)
+		return
+	}
+
+	if q.Has("labels") {
+		tasks, err = h.svc.ByLabels(r.Context(), filter.WorkspaceID, filter.Labels, filter.MatchAll)
+	} else {
+		tasks, err = h.svc.All(r.Context())
+	}
+
 	if err != nil {
-		http.Error(w, "Failed to fetch tasks", http.StatusInternalServerError)
+		problem.Write(w, r, err)
 		return
 	}
 
 	resp, err := json.Marshal(tasks)
 
 	if err != nil {
-		http.Error(w, "Failed to marshal response: "+err.Error(), http.StatusInternalServerError)
+		problem.Write(w, r, err)
 		return
 	}
 
@@ -219,7 +273,309 @@ func (h *Handler) All(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 
 	if _, err := w.Write(resp); err != nil {
-		http.Error(w, "Write failed: "+err.Error(), http.StatusInternalServerError)
+		logging.FromContext(r.Context()).Error("response write failed", "error", err)
+	}
+}
+
+// export streams the tasks matching f in the given media type while they are read from the store.
+// Failures before the first task get an error status; later ones can only cut the download short.
+func (h *Handler) export(w http.ResponseWriter, r *http.Request, f task.Filter, mediaType string) {
+	out := bufio.NewWriter(w)
+	enc := newEncoder(mediaType, out)
+	flusher, _ := w.(http.Flusher)
+	started := false
+	written := 0
+
+	start := func() error {
+		w.Header().Set("Content-Type", mediaType+"; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="`+exportFiles[mediaType]+`"`)
+		w.WriteHeader(http.StatusOK)
+		started = true
+
+		return enc.begin()
+	}
+
+	err := h.svc.Export(r.Context(), f, func(t task.Task) error {
+		if !started {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+
+		if err := enc.task(t); err != nil {
+			return err
+		}
+
+		written++
+		if written%100 == 0 {
+			if err := out.Flush(); err != nil {
+				return err
+			}
+
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+
+		return nil
+	})
+
+	switch {
+	case err != nil && !started:
+		problem.Write(w, r, err)
+		return
+	case err != nil:
+		logging.FromContext(r.Context()).Error("task export aborted", "error", err)
+		return
+	case !started:
+		err = start()
+	}
+
+	if err == nil {
+		err = enc.end()
+	}
+
+	if err == nil {
+		err = out.Flush()
+	}
+
+	if err != nil {
+		logging.FromContext(r.Context()).Error("task export aborted", "error", err)
+	}
+}
+
+// labelFilter parses the label query parameters of GET /task, writing a 400 when they are malformed
+func labelFilter(w http.ResponseWriter, r *http.Request, q url.Values) (labels []string, workspaceID int, matchAll, ok bool) {
+	for _, name := range strings.Split(q.Get("labels"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			labels = append(labels, name)
+		}
+	}
+
+	switch q.Get("match") {
+	case "", "any":
+	case "all":
+		matchAll = true
+	default:
+		problem.BadRequest(w, r, "invalid_match", "Invalid match, expected any or all",
+			errs.FieldError{Field: "match", Message: "must be any or all"})
+		return nil, 0, false, false
+	}
+
+	if raw := q.Get("workspace"); raw != "" {
+		id, err := strconv.Atoi(raw)
+		if err != nil {
+			problem.BadRequest(w, r, "invalid_workspace", "Invalid workspace",
+				errs.FieldError{Field: "workspace", Message: "must be a number"})
+			return nil, 0, false, false
+		}
+
+		workspaceID = id
+	}
+
+	return labels, workspaceID, matchAll, true
+}
+
+// Trash Tasks (GET /trash/tasks)
+func (h *Handler) Trash(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	tasks, err := h.svc.Trash(r.Context())
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	resp, _ := json.Marshal(tasks)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	_, err = w.Write(resp)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("response write failed", "error", err)
+	}
+}
+
+// Restore Task (POST /task/{id}/restore)
+func (h *Handler) Restore(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		problem.BadRequest(w, r, "invalid_id", "Invalid ID")
+		return
+	}
+
+	if err = h.svc.Restore(r.Context(), id); err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+
+	_, err = w.Write([]byte(fmt.Sprintf("Task %d restored", id)))
+	if err != nil {
+		logging.FromContext(r.Context()).Error("response write failed", "error", err)
+	}
+}
+
+// revertRequest is the body of POST /task/{id}/revert
+type revertRequest struct {
+	Revision int `json:"revision"`
+}
+
+// Revisions of a Task (GET /task/{id}/revisions)
+func (h *Handler) Revisions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		problem.BadRequest(w, r, "invalid_id", "Invalid ID")
+		return
+	}
+
+	revisions, err := h.svc.Revisions(r.Context(), id)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, revisions)
+}
+
+// DiffRevisions of a Task (GET /task/{id}/revisions/diff?from=1&to=3)
+func (h *Handler) DiffRevisions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		problem.BadRequest(w, r, "invalid_id", "Invalid ID")
+		return
+	}
+
+	from, errFrom := strconv.Atoi(r.URL.Query().Get("from"))
+	to, errTo := strconv.Atoi(r.URL.Query().Get("to"))
+
+	if errFrom != nil || errTo != nil {
+		problem.BadRequest(w, r, "invalid_revision_range", "from and to must be revision numbers")
+		return
+	}
+
+	changes, err := h.svc.DiffRevisions(r.Context(), id, from, to)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, changes)
+}
+
+// Revert Task to an earlier revision (POST /task/{id}/revert)
+func (h *Handler) Revert(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		problem.BadRequest(w, r, "invalid_id", "Invalid ID")
 		return
 	}
+
+	var req revertRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Revision <= 0 {
+		problem.BadRequest(w, r, "invalid_body", "Body must be {\"revision\": <number>}",
+			errs.FieldError{Field: "revision", Message: "must be a positive number"})
+		return
+	}
+
+	reverted, err := h.svc.Revert(r.Context(), id, req.Revision)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, reverted)
+}
+
+// Bulk modes
+const (
+	ModeAtomic     = "atomic"
+	ModeBestEffort = "best_effort"
+)
+
+// bulkRequest is the body of POST /task/bulk
+type bulkRequest struct {
+	Mode string        `json:"mode"`
+	Ops  []task.BulkOp `json:"ops"`
+}
+
+type bulkResponse struct {
+	Mode    string            `json:"mode"`
+	Results []task.BulkResult `json:"results"`
+}
+
+// Bulk Task operations (POST /task/bulk) with {"mode":"atomic"|"best_effort","ops":[{"op":"complete","id":1}]}
+func (h *Handler) Bulk(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req bulkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		problem.BadRequest(w, r, "invalid_body", "Invalid request body")
+		return
+	}
+
+	if req.Mode == "" {
+		req.Mode = ModeAtomic
+	}
+
+	if req.Mode != ModeAtomic && req.Mode != ModeBestEffort {
+		problem.BadRequest(w, r, "invalid_mode", "Mode must be atomic or best_effort",
+			errs.FieldError{Field: "mode", Message: "must be atomic or best_effort"})
+		return
+	}
+
+	results, err := h.svc.Bulk(r.Context(), req.Ops, req.Mode == ModeAtomic)
+
+	// A failed atomic batch answers with the result of every operation rather than a problem, so the
+	// client can tell which operations to fix
+	switch {
+	case errors.Is(err, task.ErrBulkFailed):
+		writeJSON(w, r, http.StatusUnprocessableEntity, bulkResponse{Mode: req.Mode, Results: results})
+	case err != nil:
+		problem.Write(w, r, err)
+	default:
+		writeJSON(w, r, http.StatusOK, bulkResponse{Mode: req.Mode, Results: results})
+	}
+}
+
+func writeJSON(w http.ResponseWriter, r *http.Request, status int, v any) {
+	resp, err := json.Marshal(v)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if _, err := w.Write(resp); err != nil {
+		logging.FromContext(r.Context()).Error("response write failed", "error", err)
+	}
 }
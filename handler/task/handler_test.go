@@ -1,8 +1,10 @@
 package task
 
 import (
+	"Task_Manager/handler/problem"
 	"Task_Manager/model/task"
 	"bytes"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"github.com/gorilla/mux"
@@ -12,6 +14,7 @@ import (
 	"net/http/httptest"
 	"strconv"
 	"testing"
+	"time"
 )
 
 // errReader : Functionality is used to pass the empty and incorrect body to handle the edge case
@@ -59,11 +62,11 @@ func Test_CreateTasK(t *testing.T) {
 		ExpCode     int
 		isWriteErr  bool
 	}{
-		{"Valid Input", "application/json", task.Task{1, "Working", false, 1}, task.Task{1, "Working", false, 1}, nil, http.StatusCreated, false},
+		{"Valid Input", "application/json", task.Task{ID: 1, Desc: "Working", Status: false, Userid: 1}, task.Task{ID: 1, Desc: "Working", Status: false, Userid: 1}, nil, http.StatusCreated, false},
 		{"Invalid Json", "application/json", "bad json", task.Task{}, nil, http.StatusBadRequest, false},
-		{"Creation error", "application/json", task.Task{1, "Working", false, 1}, task.Task{}, errors.New("creation error"), http.StatusBadRequest, false},
-		{"wrong HTTP method", "application/json", task.Task{1, "Working", false, 1}, task.Task{}, nil, http.StatusMethodNotAllowed, false},
-		{"Write Error", "application/json", task.Task{1, "Working", false, 1}, task.Task{1, "Working", false, 1}, nil, http.StatusCreated, true},
+		{"Creation error", "application/json", task.Task{ID: 1, Desc: "Working", Status: false, Userid: 1}, task.Task{}, task.ErrEmptyDesc, http.StatusBadRequest, false},
+		{"wrong HTTP method", "application/json", task.Task{ID: 1, Desc: "Working", Status: false, Userid: 1}, task.Task{}, nil, http.StatusMethodNotAllowed, false},
+		{"Write Error", "application/json", task.Task{ID: 1, Desc: "Working", Status: false, Userid: 1}, task.Task{ID: 1, Desc: "Working", Status: false, Userid: 1}, nil, http.StatusCreated, true},
 	}
 
 	for _, tt := range tests {
@@ -88,7 +91,7 @@ func Test_CreateTasK(t *testing.T) {
 			}
 
 			if method == http.MethodPost && tt.name != "Invalid Json" {
-				mock.EXPECT().Create(gomock.Any()).Return(tt.mockOutput, tt.mockError).AnyTimes()
+				mock.EXPECT().Create(gomock.Any(), gomock.Any()).Return(tt.mockOutput, tt.mockError).AnyTimes()
 			}
 
 			req := httptest.NewRequest(method, "/task", bytes.NewReader(body))
@@ -141,11 +144,11 @@ func Test_GetTasK(t *testing.T) {
 		ExpCode    int
 		isWriteErr bool
 	}{
-		{"valid id", "1", task.Task{1, "Working", false, 1}, nil, http.StatusOK, false},
-		{"Invalid user id", "abc", task.Task{1, "Working", false, 1}, nil, http.StatusBadRequest, false},
-		{"Id not found", "99", task.Task{}, errors.New("Id Not found"), http.StatusNotFound, false},
+		{"valid id", "1", task.Task{ID: 1, Desc: "Working", Status: false, Userid: 1}, nil, http.StatusOK, false},
+		{"Invalid user id", "abc", task.Task{ID: 1, Desc: "Working", Status: false, Userid: 1}, nil, http.StatusBadRequest, false},
+		{"Id not found", "99", task.Task{}, task.ErrNotFound, http.StatusNotFound, false},
 		{"wrong HTTP method", "abc", task.Task{}, nil, http.StatusMethodNotAllowed, false},
-		{"Write Error", "1", task.Task{1, "Working", false, 1}, nil, http.StatusOK, true},
+		{"Write Error", "1", task.Task{ID: 1, Desc: "Working", Status: false, Userid: 1}, nil, http.StatusOK, true},
 	}
 
 	for _, tt := range tests {
@@ -161,7 +164,7 @@ func Test_GetTasK(t *testing.T) {
 		}
 
 		if tt.ExpErr != nil || tt.ExpCode == http.StatusOK || tt.isWriteErr {
-			mock.EXPECT().GetTask(gomock.Any()).Return(tt.ExpOutput, tt.ExpErr).AnyTimes()
+			mock.EXPECT().GetTask(gomock.Any(), gomock.Any()).Return(tt.ExpOutput, tt.ExpErr).AnyTimes()
 		}
 
 		req := httptest.NewRequest(method, "/task/"+tt.id, nil)
@@ -183,6 +186,34 @@ func Test_GetTasK(t *testing.T) {
 	}
 }
 
+// Test_GetTaskProblem : Tests a missing task is reported as problem details with a stable code
+func Test_GetTaskProblem(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mock := NewMockTaskServiceInterface(ctrl)
+	h := &Handler{mock}
+
+	mock.EXPECT().GetTask(gomock.Any(), 99).Return(task.Task{}, task.ErrNotFound)
+
+	req := httptest.NewRequest(http.MethodGet, "/task/99", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "99"})
+	rec := httptest.NewRecorder()
+
+	h.GetTask(rec, req)
+
+	var p problem.Problem
+	if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+		t.Fatalf("Invalid problem body: %v", err)
+	}
+
+	if ct := rec.Header().Get("Content-Type"); ct != problem.ContentType {
+		t.Errorf("Expected Content-Type %s, got %s", problem.ContentType, ct)
+	}
+
+	if p.Status != http.StatusNotFound || p.Code != "task_not_found" || p.Instance != "/task/99" {
+		t.Errorf("Unexpected problem %+v", p)
+	}
+}
+
 // Test_GetTasksByUserID : Tests task is retrieved or not of User
 func Test_GetTasksByUserID(t *testing.T) {
 	tests := []struct {
@@ -193,11 +224,11 @@ func Test_GetTasksByUserID(t *testing.T) {
 		ExpCode    int
 		isWriteErr bool
 	}{
-		{"valid id", "1", []task.Task{{1, "Working", false, 1}}, nil, http.StatusOK, false},
+		{"valid id", "1", []task.Task{{ID: 1, Desc: "Working", Status: false, Userid: 1}}, nil, http.StatusOK, false},
 		{"Invalid user id", "abc", nil, nil, http.StatusBadRequest, false},
-		{"Id not found", "99", []task.Task{}, errors.New("Id Not found"), http.StatusNotFound, false},
+		{"Id not found", "99", []task.Task{}, sql.ErrNoRows, http.StatusNotFound, false},
 		{"wrong HTTP method", "1", nil, nil, http.StatusMethodNotAllowed, false},
-		{"Write Error", "1", []task.Task{{1, "Working", false, 1}}, nil, http.StatusOK, true},
+		{"Write Error", "1", []task.Task{{ID: 1, Desc: "Working", Status: false, Userid: 1}}, nil, http.StatusOK, true},
 	}
 
 	ctrl := gomock.NewController(t)
@@ -213,7 +244,7 @@ func Test_GetTasksByUserID(t *testing.T) {
 		}
 
 		if id, err := strconv.Atoi(tt.userid); err == nil && method == http.MethodGet && (tt.ExpCode != http.StatusBadRequest || tt.isWriteErr) {
-			mock.EXPECT().GetTasksByUserID(id).Return(tt.ExpOutput, tt.ExpErr).AnyTimes()
+			mock.EXPECT().GetTasksByUserID(gomock.Any(), id).Return(tt.ExpOutput, tt.ExpErr).AnyTimes()
 		}
 
 		req := httptest.NewRequest(method, "/task/user/"+tt.userid, nil)
@@ -244,11 +275,11 @@ func Test_CompleteTask(t *testing.T) {
 		ExpCode    int
 		isWriteErr bool
 	}{
-		{"valid id", "1", task.Task{1, "Working", true, 1}, nil, http.StatusOK, false},
-		{"Invalid user id", "abc", task.Task{1, "Working", true, 1}, nil, http.StatusBadRequest, false},
-		{"Id not found", "99", task.Task{}, errors.New("Id Not found"), http.StatusNotFound, false},
-		{"wrong HTTP method", "1", task.Task{1, "Working", true, 1}, nil, http.StatusMethodNotAllowed, false},
-		{"Write Error", "1", task.Task{1, "Working", true, 1}, nil, http.StatusOK, true},
+		{"valid id", "1", task.Task{ID: 1, Desc: "Working", Status: true, Userid: 1}, nil, http.StatusOK, false},
+		{"Invalid user id", "abc", task.Task{ID: 1, Desc: "Working", Status: true, Userid: 1}, nil, http.StatusBadRequest, false},
+		{"Id not found", "99", task.Task{}, task.ErrNotFound, http.StatusNotFound, false},
+		{"wrong HTTP method", "1", task.Task{ID: 1, Desc: "Working", Status: true, Userid: 1}, nil, http.StatusMethodNotAllowed, false},
+		{"Write Error", "1", task.Task{ID: 1, Desc: "Working", Status: true, Userid: 1}, nil, http.StatusOK, true},
 	}
 
 	for _, tt := range tests {
@@ -265,7 +296,7 @@ func Test_CompleteTask(t *testing.T) {
 		}
 
 		if id, err := strconv.Atoi(tt.id); err == nil && method == http.MethodPut && (tt.ExpCode != http.StatusBadRequest || tt.isWriteErr) {
-			mock.EXPECT().Complete(id).Return(tt.ExpErr).AnyTimes()
+			mock.EXPECT().Complete(gomock.Any(), id).Return(tt.ExpErr).AnyTimes()
 		}
 
 		req := httptest.NewRequest(method, "/task/"+tt.id, nil)
@@ -300,8 +331,8 @@ func Test_AllTasks(t *testing.T) {
 	}{
 		{
 			name:      "Successfully retrieved",
-			input:     []task.Task{{1, "Working", true, 1}},
-			ExpOutput: []task.Task{{1, "Working", true, 1}},
+			input:     []task.Task{{ID: 1, Desc: "Working", Status: true, Userid: 1}},
+			ExpOutput: []task.Task{{ID: 1, Desc: "Working", Status: true, Userid: 1}},
 			ExpErr:    nil,
 			ExpCode:   http.StatusOK,
 		},
@@ -321,8 +352,8 @@ func Test_AllTasks(t *testing.T) {
 		},
 		{
 			name:       "Write failure after successful fetch",
-			input:      []task.Task{{1, "desc", true, 1}},
-			ExpOutput:  []task.Task{{1, "desc", true, 1}},
+			input:      []task.Task{{ID: 1, Desc: "desc", Status: true, Userid: 1}},
+			ExpOutput:  []task.Task{{ID: 1, Desc: "desc", Status: true, Userid: 1}},
 			ExpErr:     nil,
 			ExpCode:    http.StatusOK, // write failure still results in 200 OK
 			isWriteErr: true,
@@ -344,7 +375,7 @@ func Test_AllTasks(t *testing.T) {
 			}
 
 			if tt.ExpErr != nil || tt.ExpCode == http.StatusOK || tt.isWriteErr {
-				mock.EXPECT().All().Return(tt.ExpOutput, tt.ExpErr).AnyTimes()
+				mock.EXPECT().All(gomock.Any()).Return(tt.ExpOutput, tt.ExpErr).AnyTimes()
 			}
 
 			req := httptest.NewRequest(method, "/task", nil)
@@ -377,7 +408,7 @@ func Test_DeleteTask(t *testing.T) {
 	}{
 		{"Valid delete", "1", nil, http.StatusOK, false},
 		{"InValid user id", "abc", errors.New("Invalid user id "), http.StatusBadRequest, false},
-		{"Delete error", "99", errors.New("Delete error"), http.StatusNotFound, false},
+		{"Delete error", "99", task.ErrNotFound, http.StatusNotFound, false},
 		{"wrong HTTP method", "abc", nil, http.StatusMethodNotAllowed, false},
 		{"Write Error", "1", nil, http.StatusOK, true},
 	}
@@ -388,7 +419,7 @@ func Test_DeleteTask(t *testing.T) {
 		mock := NewMockTaskServiceInterface(ctrl)
 
 		if tt.ExpErr != nil || tt.ExpCode == http.StatusOK || tt.isWriteErr {
-			mock.EXPECT().Delete(gomock.Any()).Return(tt.ExpErr).AnyTimes()
+			mock.EXPECT().Delete(gomock.Any(), gomock.Any()).Return(tt.ExpErr).AnyTimes()
 		}
 
 		method := http.MethodDelete
@@ -415,3 +446,304 @@ func Test_DeleteTask(t *testing.T) {
 		}
 	}
 }
+
+// Test_AllTasks_LabelFilter : Tests tasks are filtered by labels
+func Test_AllTasks_LabelFilter(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		workspace int
+		labels    []string
+		matchAll  bool
+		callSvc   bool
+		ExpCode   int
+	}{
+		{"Any label", "?labels=bug,urgent", 0, []string{"bug", "urgent"}, false, true, http.StatusOK},
+		{"All labels in workspace", "?labels=bug,%20urgent,&match=all&workspace=2", 2, []string{"bug", "urgent"}, true, true, http.StatusOK},
+		{"Invalid match", "?labels=bug&match=some", 0, nil, false, false, http.StatusBadRequest},
+		{"Invalid workspace", "?labels=bug&workspace=x", 0, nil, false, false, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mock := NewMockTaskServiceInterface(ctrl)
+			h := &Handler{mock}
+
+			if tt.callSvc {
+				mock.EXPECT().ByLabels(gomock.Any(), tt.workspace, tt.labels, tt.matchAll).Return([]task.Task{{ID: 1, Desc: "Working", Userid: 1}}, nil)
+			}
+
+			rec := httptest.NewRecorder()
+			h.All(rec, httptest.NewRequest(http.MethodGet, "/task"+tt.query, nil))
+
+			if rec.Code != tt.ExpCode {
+				t.Errorf("[%s] Expected status %d, got %d", tt.name, tt.ExpCode, rec.Code)
+			}
+		})
+	}
+}
+
+// Test_Trash : Tests deleted tasks are listed
+func Test_Trash(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		mockOutput []task.Trashed
+		mockErr    error
+		ExpCode    int
+	}{
+		{"Trash fetched", http.MethodGet, []task.Trashed{{Task: task.Task{ID: 1, Desc: "Working", Userid: 1}}}, nil, http.StatusOK},
+		{"Fetch error", http.MethodGet, nil, errors.New("db error"), http.StatusInternalServerError},
+		{"wrong HTTP method", http.MethodPost, nil, nil, http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mock := NewMockTaskServiceInterface(ctrl)
+			h := &Handler{mock}
+
+			if tt.method == http.MethodGet {
+				mock.EXPECT().Trash(gomock.Any()).Return(tt.mockOutput, tt.mockErr)
+			}
+
+			rec := httptest.NewRecorder()
+			h.Trash(rec, httptest.NewRequest(tt.method, "/trash/tasks", nil))
+
+			if rec.Code != tt.ExpCode {
+				t.Errorf("[%s] Expected status %d, got %d", tt.name, tt.ExpCode, rec.Code)
+			}
+		})
+	}
+}
+
+// Test_Restore : Tests a deleted task is restored
+func Test_Restore(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		id      string
+		mockErr error
+		callSvc bool
+		ExpCode int
+	}{
+		{"Restored", http.MethodPost, "1", nil, true, http.StatusOK},
+		{"Not in trash", http.MethodPost, "2", sql.ErrNoRows, true, http.StatusNotFound},
+		{"Restore error", http.MethodPost, "3", errors.New("db error"), true, http.StatusInternalServerError},
+		{"Invalid id", http.MethodPost, "abc", nil, false, http.StatusBadRequest},
+		{"wrong HTTP method", http.MethodGet, "1", nil, false, http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mock := NewMockTaskServiceInterface(ctrl)
+			h := &Handler{mock}
+
+			if tt.callSvc {
+				mock.EXPECT().Restore(gomock.Any(), gomock.Any()).Return(tt.mockErr)
+			}
+
+			req := httptest.NewRequest(tt.method, "/task/"+tt.id+"/restore", nil)
+			req = mux.SetURLVars(req, map[string]string{"id": tt.id})
+			rec := httptest.NewRecorder()
+			h.Restore(rec, req)
+
+			if rec.Code != tt.ExpCode {
+				t.Errorf("[%s] Expected status %d, got %d", tt.name, tt.ExpCode, rec.Code)
+			}
+		})
+	}
+}
+
+// Test_GetTask_AsOf : Tests a task is returned as it was at a point in time
+func Test_GetTask_AsOf(t *testing.T) {
+	at := time.Date(2024, 5, 7, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		query   string
+		callSvc bool
+		mockErr error
+		ExpCode int
+	}{
+		{"Past version", "?as_of=2024-05-07T12:00:00Z", true, nil, http.StatusOK},
+		{"No version then", "?as_of=2024-05-07T12:00:00Z", true, task.ErrNoRevision, http.StatusNotFound},
+		{"Invalid time", "?as_of=last-tuesday", false, nil, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mock := NewMockTaskServiceInterface(ctrl)
+			h := &Handler{mock}
+
+			if tt.callSvc {
+				mock.EXPECT().AsOf(gomock.Any(), 1, at).Return(task.Task{ID: 1, Desc: "Then"}, tt.mockErr)
+			}
+
+			req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/task/1"+tt.query, nil), map[string]string{"id": "1"})
+			rec := httptest.NewRecorder()
+			h.GetTask(rec, req)
+
+			if rec.Code != tt.ExpCode {
+				t.Errorf("[%s] Expected status %d, got %d", tt.name, tt.ExpCode, rec.Code)
+			}
+		})
+	}
+}
+
+// Test_Revisions : Tests the revisions of a task are listed
+func Test_Revisions(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		callSvc bool
+		mockErr error
+		ExpCode int
+	}{
+		{"Listed", "1", true, nil, http.StatusOK},
+		{"Unknown task", "2", true, task.ErrNoRevision, http.StatusNotFound},
+		{"Store error", "3", true, errors.New("db down"), http.StatusInternalServerError},
+		{"Invalid id", "x", false, nil, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mock := NewMockTaskServiceInterface(ctrl)
+			h := &Handler{mock}
+
+			if tt.callSvc {
+				mock.EXPECT().Revisions(gomock.Any(), gomock.Any()).Return([]task.Revision{{TaskID: 1, Number: 1}}, tt.mockErr)
+			}
+
+			req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/task/"+tt.id+"/revisions", nil), map[string]string{"id": tt.id})
+			rec := httptest.NewRecorder()
+			h.Revisions(rec, req)
+
+			if rec.Code != tt.ExpCode {
+				t.Errorf("[%s] Expected status %d, got %d", tt.name, tt.ExpCode, rec.Code)
+			}
+		})
+	}
+}
+
+// Test_DiffRevisions : Tests the field level diff between two revisions
+func Test_DiffRevisions(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		callSvc bool
+		mockErr error
+		ExpCode int
+	}{
+		{"Diffed", "?from=1&to=3", true, nil, http.StatusOK},
+		{"Unknown revision", "?from=1&to=9", true, task.ErrNoRevision, http.StatusNotFound},
+		{"Missing to", "?from=1", false, nil, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mock := NewMockTaskServiceInterface(ctrl)
+			h := &Handler{mock}
+
+			if tt.callSvc {
+				mock.EXPECT().DiffRevisions(gomock.Any(), 1, 1, gomock.Any()).
+					Return([]task.FieldChange{{Field: "status", From: false, To: true}}, tt.mockErr)
+			}
+
+			req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/task/1/revisions/diff"+tt.query, nil), map[string]string{"id": "1"})
+			rec := httptest.NewRecorder()
+			h.DiffRevisions(rec, req)
+
+			if rec.Code != tt.ExpCode {
+				t.Errorf("[%s] Expected status %d, got %d", tt.name, tt.ExpCode, rec.Code)
+			}
+		})
+	}
+}
+
+// Test_Revert : Tests a task is reverted to an earlier revision
+func Test_Revert(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		body    string
+		callSvc bool
+		mockErr error
+		ExpCode int
+	}{
+		{"Reverted", http.MethodPost, `{"revision":2}`, true, nil, http.StatusOK},
+		{"Task in trash", http.MethodPost, `{"revision":2}`, true, sql.ErrNoRows, http.StatusNotFound},
+		{"Missing revision", http.MethodPost, `{}`, false, nil, http.StatusBadRequest},
+		{"Invalid JSON", http.MethodPost, `{`, false, nil, http.StatusBadRequest},
+		{"wrong HTTP method", http.MethodGet, "", false, nil, http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mock := NewMockTaskServiceInterface(ctrl)
+			h := &Handler{mock}
+
+			if tt.callSvc {
+				mock.EXPECT().Revert(gomock.Any(), 1, 2).Return(task.Task{ID: 1, Desc: "Draft"}, tt.mockErr)
+			}
+
+			req := mux.SetURLVars(httptest.NewRequest(tt.method, "/task/1/revert", bytes.NewBufferString(tt.body)), map[string]string{"id": "1"})
+			rec := httptest.NewRecorder()
+			h.Revert(rec, req)
+
+			if rec.Code != tt.ExpCode {
+				t.Errorf("[%s] Expected status %d, got %d", tt.name, tt.ExpCode, rec.Code)
+			}
+		})
+	}
+}
+
+func Test_Bulk(t *testing.T) {
+	ops := []task.BulkOp{{Op: task.BulkComplete, ID: 1}}
+	results := []task.BulkResult{{Index: 0, Op: task.BulkComplete, ID: 1, Status: task.BulkOK}}
+
+	tests := []struct {
+		name    string
+		method  string
+		body    string
+		atomic  bool
+		callSvc bool
+		mockErr error
+		ExpCode int
+	}{
+		{"Atomic by default", http.MethodPost, `{"ops":[{"op":"complete","id":1}]}`, true, true, nil, http.StatusOK},
+		{"Best effort", http.MethodPost, `{"mode":"best_effort","ops":[{"op":"complete","id":1}]}`, false, true, nil, http.StatusOK},
+		{"Atomic batch failed", http.MethodPost, `{"mode":"atomic","ops":[{"op":"complete","id":1}]}`, true, true, task.ErrBulkFailed, http.StatusUnprocessableEntity},
+		{"Batch too large", http.MethodPost, `{"ops":[{"op":"complete","id":1}]}`, true, true, task.ErrBulkSize, http.StatusBadRequest},
+		{"Store error", http.MethodPost, `{"ops":[{"op":"complete","id":1}]}`, true, true, errors.New("db down"), http.StatusInternalServerError},
+		{"Unknown mode", http.MethodPost, `{"mode":"eventual","ops":[]}`, false, false, nil, http.StatusBadRequest},
+		{"Invalid JSON", http.MethodPost, `{`, false, false, nil, http.StatusBadRequest},
+		{"wrong HTTP method", http.MethodGet, "", false, false, nil, http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mock := NewMockTaskServiceInterface(ctrl)
+			h := &Handler{mock}
+
+			if tt.callSvc {
+				mock.EXPECT().Bulk(gomock.Any(), ops, tt.atomic).Return(results, tt.mockErr)
+			}
+
+			req := httptest.NewRequest(tt.method, "/task/bulk", bytes.NewBufferString(tt.body))
+			rec := httptest.NewRecorder()
+			h.Bulk(rec, req)
+
+			if rec.Code != tt.ExpCode {
+				t.Errorf("[%s] Expected status %d, got %d", tt.name, tt.ExpCode, rec.Code)
+			}
+		})
+	}
+}
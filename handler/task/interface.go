@@ -1,12 +1,25 @@
 package task
 
-import "Task_Manager/model/task"
+import (
+	"Task_Manager/model/task"
+	"context"
+	"time"
+)
 
 type TaskServiceInterface interface {
-	Create(t task.Task) (task.Task, error)
-	GetTask(id int) (task.Task, error)
-	Complete(id int) error
-	Delete(id int) error
-	All() ([]task.Task, error)
-	GetTasksByUserID(userId int) ([]task.Task, error)
+	Create(ctx context.Context, t task.Task) (task.Task, error)
+	GetTask(ctx context.Context, id int) (task.Task, error)
+	Complete(ctx context.Context, id int) error
+	Delete(ctx context.Context, id int) error
+	All(ctx context.Context) ([]task.Task, error)
+	GetTasksByUserID(ctx context.Context, userId int) ([]task.Task, error)
+	ByLabels(ctx context.Context, workspaceID int, labels []string, matchAll bool) ([]task.Task, error)
+	Export(ctx context.Context, f task.Filter, fn func(task.Task) error) error
+	Trash(ctx context.Context) ([]task.Trashed, error)
+	Restore(ctx context.Context, id int) error
+	Revisions(ctx context.Context, id int) ([]task.Revision, error)
+	AsOf(ctx context.Context, id int, at time.Time) (task.Task, error)
+	DiffRevisions(ctx context.Context, id, from, to int) ([]task.FieldChange, error)
+	Revert(ctx context.Context, id, number int) (task.Task, error)
+	Bulk(ctx context.Context, ops []task.BulkOp, atomic bool) ([]task.BulkResult, error)
 }
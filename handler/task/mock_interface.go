@@ -11,7 +11,9 @@ package task
 
 import (
 	task "Task_Manager/model/task"
+	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "go.uber.org/mock/gomock"
 )
@@ -41,90 +43,222 @@ func (m *MockTaskServiceInterface) EXPECT() *MockTaskServiceInterfaceMockRecorde
 }
 
 // All mocks base method.
-func (m *MockTaskServiceInterface) All() ([]task.Task, error) {
+func (m *MockTaskServiceInterface) All(ctx context.Context) ([]task.Task, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "All")
+	ret := m.ctrl.Call(m, "All", ctx)
 	ret0, _ := ret[0].([]task.Task)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // All indicates an expected call of All.
-func (mr *MockTaskServiceInterfaceMockRecorder) All() *gomock.Call {
+func (mr *MockTaskServiceInterfaceMockRecorder) All(ctx any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "All", reflect.TypeOf((*MockTaskServiceInterface)(nil).All))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "All", reflect.TypeOf((*MockTaskServiceInterface)(nil).All), ctx)
+}
+
+// AsOf mocks base method.
+func (m *MockTaskServiceInterface) AsOf(ctx context.Context, id int, at time.Time) (task.Task, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AsOf", ctx, id, at)
+	ret0, _ := ret[0].(task.Task)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AsOf indicates an expected call of AsOf.
+func (mr *MockTaskServiceInterfaceMockRecorder) AsOf(ctx, id, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AsOf", reflect.TypeOf((*MockTaskServiceInterface)(nil).AsOf), ctx, id, at)
+}
+
+// Bulk mocks base method.
+func (m *MockTaskServiceInterface) Bulk(ctx context.Context, ops []task.BulkOp, atomic bool) ([]task.BulkResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Bulk", ctx, ops, atomic)
+	ret0, _ := ret[0].([]task.BulkResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Bulk indicates an expected call of Bulk.
+func (mr *MockTaskServiceInterfaceMockRecorder) Bulk(ctx, ops, atomic any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Bulk", reflect.TypeOf((*MockTaskServiceInterface)(nil).Bulk), ctx, ops, atomic)
+}
+
+// ByLabels mocks base method.
+func (m *MockTaskServiceInterface) ByLabels(ctx context.Context, workspaceID int, labels []string, matchAll bool) ([]task.Task, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ByLabels", ctx, workspaceID, labels, matchAll)
+	ret0, _ := ret[0].([]task.Task)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ByLabels indicates an expected call of ByLabels.
+func (mr *MockTaskServiceInterfaceMockRecorder) ByLabels(ctx, workspaceID, labels, matchAll any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ByLabels", reflect.TypeOf((*MockTaskServiceInterface)(nil).ByLabels), ctx, workspaceID, labels, matchAll)
 }
 
 // Complete mocks base method.
-func (m *MockTaskServiceInterface) Complete(id int) error {
+func (m *MockTaskServiceInterface) Complete(ctx context.Context, id int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Complete", id)
+	ret := m.ctrl.Call(m, "Complete", ctx, id)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Complete indicates an expected call of Complete.
-func (mr *MockTaskServiceInterfaceMockRecorder) Complete(id any) *gomock.Call {
+func (mr *MockTaskServiceInterfaceMockRecorder) Complete(ctx, id any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Complete", reflect.TypeOf((*MockTaskServiceInterface)(nil).Complete), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Complete", reflect.TypeOf((*MockTaskServiceInterface)(nil).Complete), ctx, id)
 }
 
 // Create mocks base method.
-func (m *MockTaskServiceInterface) Create(t task.Task) (task.Task, error) {
+func (m *MockTaskServiceInterface) Create(ctx context.Context, t task.Task) (task.Task, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Create", t)
+	ret := m.ctrl.Call(m, "Create", ctx, t)
 	ret0, _ := ret[0].(task.Task)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Create indicates an expected call of Create.
-func (mr *MockTaskServiceInterfaceMockRecorder) Create(t any) *gomock.Call {
+func (mr *MockTaskServiceInterfaceMockRecorder) Create(ctx, t any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockTaskServiceInterface)(nil).Create), t)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockTaskServiceInterface)(nil).Create), ctx, t)
 }
 
 // Delete mocks base method.
-func (m *MockTaskServiceInterface) Delete(id int) error {
+func (m *MockTaskServiceInterface) Delete(ctx context.Context, id int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Delete", id)
+	ret := m.ctrl.Call(m, "Delete", ctx, id)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Delete indicates an expected call of Delete.
-func (mr *MockTaskServiceInterfaceMockRecorder) Delete(id any) *gomock.Call {
+func (mr *MockTaskServiceInterfaceMockRecorder) Delete(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockTaskServiceInterface)(nil).Delete), ctx, id)
+}
+
+// DiffRevisions mocks base method.
+func (m *MockTaskServiceInterface) DiffRevisions(ctx context.Context, id, from, to int) ([]task.FieldChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DiffRevisions", ctx, id, from, to)
+	ret0, _ := ret[0].([]task.FieldChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DiffRevisions indicates an expected call of DiffRevisions.
+func (mr *MockTaskServiceInterfaceMockRecorder) DiffRevisions(ctx, id, from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DiffRevisions", reflect.TypeOf((*MockTaskServiceInterface)(nil).DiffRevisions), ctx, id, from, to)
+}
+
+// Export mocks base method.
+func (m *MockTaskServiceInterface) Export(ctx context.Context, f task.Filter, fn func(task.Task) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Export", ctx, f, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Export indicates an expected call of Export.
+func (mr *MockTaskServiceInterfaceMockRecorder) Export(ctx, f, fn any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockTaskServiceInterface)(nil).Delete), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Export", reflect.TypeOf((*MockTaskServiceInterface)(nil).Export), ctx, f, fn)
 }
 
 // GetTask mocks base method.
-func (m *MockTaskServiceInterface) GetTask(id int) (task.Task, error) {
+func (m *MockTaskServiceInterface) GetTask(ctx context.Context, id int) (task.Task, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetTask", id)
+	ret := m.ctrl.Call(m, "GetTask", ctx, id)
 	ret0, _ := ret[0].(task.Task)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetTask indicates an expected call of GetTask.
-func (mr *MockTaskServiceInterfaceMockRecorder) GetTask(id any) *gomock.Call {
+func (mr *MockTaskServiceInterfaceMockRecorder) GetTask(ctx, id any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTask", reflect.TypeOf((*MockTaskServiceInterface)(nil).GetTask), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTask", reflect.TypeOf((*MockTaskServiceInterface)(nil).GetTask), ctx, id)
 }
 
 // GetTasksByUserID mocks base method.
-func (m *MockTaskServiceInterface) GetTasksByUserID(userId int) ([]task.Task, error) {
+func (m *MockTaskServiceInterface) GetTasksByUserID(ctx context.Context, userId int) ([]task.Task, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetTasksByUserID", userId)
+	ret := m.ctrl.Call(m, "GetTasksByUserID", ctx, userId)
 	ret0, _ := ret[0].([]task.Task)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-
 // GetTasksByUserID indicates an expected call of GetTasksByUserID.
-func (mr *MockTaskServiceInterfaceMockRecorder) GetTasksByUserID(userId any) *gomock.Call {
+func (mr *MockTaskServiceInterfaceMockRecorder) GetTasksByUserID(ctx, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTasksByUserID", reflect.TypeOf((*MockTaskServiceInterface)(nil).GetTasksByUserID), ctx, userId)
+}
+
+// Restore mocks base method.
+func (m *MockTaskServiceInterface) Restore(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Restore indicates an expected call of Restore.
+func (mr *MockTaskServiceInterfaceMockRecorder) Restore(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockTaskServiceInterface)(nil).Restore), ctx, id)
+}
+
+// Revert mocks base method.
+func (m *MockTaskServiceInterface) Revert(ctx context.Context, id, number int) (task.Task, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revert", ctx, id, number)
+	ret0, _ := ret[0].(task.Task)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Revert indicates an expected call of Revert.
+func (mr *MockTaskServiceInterfaceMockRecorder) Revert(ctx, id, number any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revert", reflect.TypeOf((*MockTaskServiceInterface)(nil).Revert), ctx, id, number)
+}
+
+// Revisions mocks base method.
+func (m *MockTaskServiceInterface) Revisions(ctx context.Context, id int) ([]task.Revision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revisions", ctx, id)
+	ret0, _ := ret[0].([]task.Revision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Revisions indicates an expected call of Revisions.
+func (mr *MockTaskServiceInterfaceMockRecorder) Revisions(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revisions", reflect.TypeOf((*MockTaskServiceInterface)(nil).Revisions), ctx, id)
+}
+
+// Trash mocks base method.
+func (m *MockTaskServiceInterface) Trash(ctx context.Context) ([]task.Trashed, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Trash", ctx)
+	ret0, _ := ret[0].([]task.Trashed)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Trash indicates an expected call of Trash.
+func (mr *MockTaskServiceInterfaceMockRecorder) Trash(ctx any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTasksByUserID", reflect.TypeOf((*MockTaskServiceInterface)(nil).GetTasksByUserID), userId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Trash", reflect.TypeOf((*MockTaskServiceInterface)(nil).Trash), ctx)
 }
@@ -1,12 +1,18 @@
 package user
 
 import (
+	"Task_Manager/auth"
+	"Task_Manager/handler/problem"
+	"Task_Manager/logging"
+	"Task_Manager/model/errs"
 	"Task_Manager/model/user"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/gorilla/mux"
 	"io"
 	"net/http"
+	"net/url"
 	"strconv"
 )
 
@@ -30,34 +36,34 @@ func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	// Read entire request body
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		http.Error(w, "Failed to read request body: "+err.Error(), http.StatusBadRequest)
+		problem.BadRequest(w, r, "invalid_body", "Failed to read request body: "+err.Error())
 		return
 	}
 
 	defer func(Body io.ReadCloser) {
 		err := Body.Close()
 		if err != nil {
-			fmt.Println("Failed to close body")
+			logging.FromContext(r.Context()).Warn("failed to close request body", "error", err)
 		}
 	}(r.Body)
 	// Unmarshal into struct
 	var user1 user.User
 
 	if err = json.Unmarshal(body, &user1); err != nil {
-		http.Error(w, "Invalid JSON format: "+err.Error(), http.StatusBadRequest)
+		problem.BadRequest(w, r, "invalid_body", "Invalid JSON format: "+err.Error())
 		return
 	}
 	// Validate and save
-	createdUser, err := h.Service.Create(user1)
+	createdUser, err := h.Service.Create(r.Context(), user1)
 	if err != nil {
-		http.Error(w, "Error creating user: "+err.Error(), http.StatusInternalServerError)
+		problem.Write(w, r, err)
 		return
 	}
 	// Respond with created user
 	w.WriteHeader(http.StatusCreated)
 
 	if err := json.NewEncoder(w).Encode(createdUser); err != nil {
-		http.Error(w, "Failed to write response", http.StatusInternalServerError)
+		logging.FromContext(r.Context()).Error("response write failed", "error", err)
 	}
 }
 
@@ -73,13 +79,13 @@ func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(idStr)
 
 	if err != nil {
-		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		problem.BadRequest(w, r, "invalid_id", "Invalid user ID")
 		return
 	}
 
-	user1, err := h.Service.Get(id)
+	user1, err := h.Service.Get(r.Context(), id)
 	if err != nil {
-		http.Error(w, "User not found: "+err.Error(), http.StatusNotFound)
+		problem.Write(w, r, err)
 		return
 	}
 
@@ -87,11 +93,117 @@ func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	_, err = w.Write(resp)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		logging.FromContext(r.Context()).Error("response write failed", "error", err)
 	}
 }
 
-// DeleteUser : To delete user with user-id
+// updateRequest is the body of PATCH /users/{id}. Email is only there to reject attempts to change it without confirmation.
+type updateRequest struct {
+	user.Profile
+	Email *string `json:"email"`
+}
+
+// UpdateUser : To change the name, display name, avatar or timezone of a user (PATCH /users/{id})
+func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodPatch {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		problem.BadRequest(w, r, "invalid_id", "Invalid user ID")
+		return
+	}
+
+	var req updateRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	if req.Email != nil {
+		problem.BadRequest(w, r, "email_change_requires_confirmation", "Change the email address with POST /users/{id}/email",
+			errs.FieldError{Field: "email", Message: "cannot be changed here"})
+		return
+	}
+
+	updated, err := h.Service.Update(r.Context(), auth.FromContext(r.Context()), id, req.Profile)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, updated)
+}
+
+// emailRequest is the body of POST /users/{id}/email
+type emailRequest struct {
+	Email string `json:"email"`
+}
+
+// confirmRequest is the body of POST /users/{id}/email/confirm
+type confirmRequest struct {
+	Token string `json:"token"`
+}
+
+// ChangeEmail : To request a new email address, which takes effect once confirmed with the token sent to it (POST /users/{id}/email)
+func (h *UserHandler) ChangeEmail(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		problem.BadRequest(w, r, "invalid_id", "Invalid user ID")
+		return
+	}
+
+	var req emailRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	change, err := h.Service.RequestEmailChange(r.Context(), auth.FromContext(r.Context()), id, req.Email)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusAccepted, change)
+}
+
+// ConfirmEmail : To apply a requested email change with its confirmation token (POST /users/{id}/email/confirm)
+func (h *UserHandler) ConfirmEmail(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		problem.BadRequest(w, r, "invalid_id", "Invalid user ID")
+		return
+	}
+
+	var req confirmRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	updated, err := h.Service.ConfirmEmailChange(r.Context(), id, req.Token)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, updated)
+}
+
+// DeleteUser : To delete user with user-id (DELETE /users/{id}?policy=reject|reassign|unassign|cascade&reassign_to=&dry_run=)
 func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 
 	if r.Method != http.MethodDelete {
@@ -102,21 +214,61 @@ func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		problem.BadRequest(w, r, "invalid_id", "Invalid user ID")
+		return
+	}
+
+	opts, err := deleteOptions(r.URL.Query())
+	if err != nil {
+		problem.Write(w, r, err)
 		return
 	}
 
-	if err = h.Service.Delete(id); err != nil {
-		http.Error(w, "Failed to delete user: "+err.Error(), http.StatusInternalServerError)
+	report, err := h.Service.DeleteWithPolicy(r.Context(), id, opts)
+	if errors.Is(err, user.ErrHasTasks) {
+		err = &errs.Conflict{Code: user.ErrHasTasks.Code,
+			Message: fmt.Sprintf("user %d still has %d tasks; choose a delete policy", id, len(report.Tasks))}
+	}
+
+	if err != nil {
+		problem.Write(w, r, err)
 		return
 	}
 
+	resp, _ := json.Marshal(report) // Convert struct to JSON
 	w.WriteHeader(http.StatusOK)
 
-	_, err = w.Write([]byte(fmt.Sprintf("User %d Removed", id)))
+	_, err = w.Write(resp)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		logging.FromContext(r.Context()).Error("response write failed", "error", err)
+	}
+}
+
+// deleteOptions reads the delete policy from the query string
+func deleteOptions(q url.Values) (user.DeleteOptions, error) {
+	opts := user.DeleteOptions{Policy: user.DeletePolicy(q.Get("policy"))}
+
+	if raw := q.Get("reassign_to"); raw != "" {
+		id, err := strconv.Atoi(raw)
+		if err != nil {
+			return opts, errs.Invalid("reassign_to_invalid", "invalid reassign_to",
+				errs.FieldError{Field: "reassign_to", Message: "must be a number"})
+		}
+
+		opts.ReassignTo = id
 	}
+
+	if raw := q.Get("dry_run"); raw != "" {
+		dryRun, err := strconv.ParseBool(raw)
+		if err != nil {
+			return opts, errs.Invalid("dry_run_invalid", "invalid dry_run",
+				errs.FieldError{Field: "dry_run", Message: "must be true or false"})
+		}
+
+		opts.DryRun = dryRun
+	}
+
+	return opts, nil
 }
 
 // GetAllUsers : To retrieve all users
@@ -126,9 +278,9 @@ func (h *UserHandler) GetAllUsers(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		return
 	}
-	users, err := h.Service.All()
+	users, err := h.Service.All(r.Context())
 	if err != nil {
-		http.Error(w, "Failed to fetch users", http.StatusInternalServerError)
+		problem.Write(w, r, err)
 		return
 	}
 
@@ -136,6 +288,88 @@ func (h *UserHandler) GetAllUsers(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	_, err = w.Write(resp)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		logging.FromContext(r.Context()).Error("response write failed", "error", err)
+	}
+}
+
+// GetTrash : To retrieve the deleted users that can still be restored
+func (h *UserHandler) GetTrash(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	users, err := h.Service.Trash(r.Context())
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	resp, _ := json.Marshal(users) // Convert struct to JSON
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(resp)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("response write failed", "error", err)
+	}
+}
+
+// RestoreUser : To take a deleted user out of the trash
+func (h *UserHandler) RestoreUser(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		problem.BadRequest(w, r, "invalid_id", "Invalid user ID")
+		return
+	}
+
+	if err = h.Service.Restore(r.Context(), id); err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+
+	_, err = w.Write([]byte(fmt.Sprintf("User %d Restored", id)))
+	if err != nil {
+		logging.FromContext(r.Context()).Error("response write failed", "error", err)
+	}
+}
+
+func decodeBody(w http.ResponseWriter, r *http.Request, v any) bool {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		problem.BadRequest(w, r, "invalid_body", "Failed to read request body: "+err.Error())
+		return false
+	}
+
+	defer func(Body io.ReadCloser) {
+		_ = Body.Close()
+	}(r.Body)
+
+	if err = json.Unmarshal(body, v); err != nil {
+		problem.BadRequest(w, r, "invalid_body", "Invalid JSON format: "+err.Error())
+		return false
+	}
+
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, r *http.Request, status int, v any) {
+	resp, err := json.Marshal(v)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if _, err := w.Write(resp); err != nil {
+		logging.FromContext(r.Context()).Error("response write failed", "error", err)
 	}
 }
@@ -1,8 +1,10 @@
 package user
 
 import (
+	"Task_Manager/auth"
 	"Task_Manager/model/user"
 	"bytes"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"github.com/gorilla/mux"
@@ -10,7 +12,9 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 )
 
 // errReader : Functionality is used to pass the empty and incorrect body to handle the edge case
@@ -71,6 +75,13 @@ func Test_CreateUseR(t *testing.T) {
 			mockError:    errors.New("creation error"),
 			expectedCode: http.StatusInternalServerError,
 		},
+		{
+			name:         "validation failure",
+			contentType:  "application/json",
+			input:        user.User{Name: "john"},
+			mockError:    (&user.User{Name: "john"}).Validate(),
+			expectedCode: http.StatusBadRequest,
+		},
 		{
 			name:         "bad JSON",
 			contentType:  "application/json",
@@ -109,8 +120,8 @@ func Test_CreateUseR(t *testing.T) {
 			req.Header.Set("Content-Type", tt.contentType)
 			w := httptest.NewRecorder()
 
-			if tt.expectedCode == http.StatusCreated || tt.expectedCode == http.StatusInternalServerError {
-				mockService.EXPECT().Create(gomock.Any()).Return(tt.mockReturn, tt.mockError).AnyTimes()
+			if tt.expectedCode == http.StatusCreated || tt.mockError != nil {
+				mockService.EXPECT().Create(gomock.Any(), gomock.Any()).Return(tt.mockReturn, tt.mockError).AnyTimes()
 			}
 
 			handler.CreateUser(w, req)
@@ -152,7 +163,7 @@ func Test_GetUseR(t *testing.T) {
 	}{
 		{"valid id", "1", user.User{ID: 1, Name: "john", Email: "john@gamil.com"}, nil, http.StatusOK, false},
 		{"Invalid user id", "abc", user.User{ID: 1, Name: "john", Email: "john@gamil.com"}, nil, http.StatusBadRequest, false},
-		{"Id not found", "99", user.User{}, errors.New("Id Not found"), http.StatusNotFound, false},
+		{"Id not found", "99", user.User{}, user.ErrNotFound, http.StatusNotFound, false},
 		{"wrong HTTP method", "abc", user.User{}, nil, http.StatusMethodNotAllowed, false},
 		{"Write Error", "1", user.User{ID: 1, Name: "john", Email: "john@gamil.com"}, nil, http.StatusOK, true},
 	}
@@ -170,7 +181,7 @@ func Test_GetUseR(t *testing.T) {
 			}
 
 			if tt.ExpErr != nil || tt.ExpCode == http.StatusOK || tt.isWriteErr {
-				mock.EXPECT().Get(gomock.Any()).Return(tt.ExpOutput, tt.ExpErr).AnyTimes()
+				mock.EXPECT().Get(gomock.Any(), gomock.Any()).Return(tt.ExpOutput, tt.ExpErr).AnyTimes()
 			}
 
 			req := httptest.NewRequest(method, "/users/"+tt.id, nil)
@@ -194,15 +205,25 @@ func Test_DeleteUseR(t *testing.T) {
 	tests := []struct {
 		name       string
 		id         string
+		query      string
+		opts       user.DeleteOptions
+		callSvc    bool
 		ExpErr     error
 		ExpCode    int
 		isWriteErr bool
 	}{
-		{"Valid delete", "1", nil, http.StatusOK, false},
-		{"InValid user id", "abc", errors.New("Invalid user id "), http.StatusBadRequest, false},
-		{"Delete error", "99", errors.New("Delete error"), http.StatusInternalServerError, false},
-		{"wrong HTTP method", "abc", nil, http.StatusMethodNotAllowed, false},
-		{"Write Error", "1", nil, http.StatusOK, true},
+		{"Valid delete", "1", "", user.DeleteOptions{}, true, nil, http.StatusOK, false},
+		{"Reassign", "1", "?policy=reassign&reassign_to=2", user.DeleteOptions{Policy: user.PolicyReassign, ReassignTo: 2}, true, nil, http.StatusOK, false},
+		{"Dry run cascade", "1", "?policy=cascade&dry_run=true", user.DeleteOptions{Policy: user.PolicyCascade, DryRun: true}, true, nil, http.StatusOK, false},
+		{"InValid user id", "abc", "", user.DeleteOptions{}, false, nil, http.StatusBadRequest, false},
+		{"Invalid reassign_to", "1", "?policy=reassign&reassign_to=x", user.DeleteOptions{}, false, nil, http.StatusBadRequest, false},
+		{"Invalid dry_run", "1", "?dry_run=maybe", user.DeleteOptions{}, false, nil, http.StatusBadRequest, false},
+		{"Invalid policy", "1", "?policy=archive", user.DeleteOptions{Policy: "archive"}, true, user.ErrInvalidPolicy, http.StatusBadRequest, false},
+		{"Has tasks", "1", "", user.DeleteOptions{}, true, user.ErrHasTasks, http.StatusConflict, false},
+		{"Delete error", "99", "", user.DeleteOptions{}, true, errors.New("Delete error"), http.StatusInternalServerError, false},
+		{"Not found", "98", "", user.DeleteOptions{}, true, sql.ErrNoRows, http.StatusNotFound, false},
+		{"wrong HTTP method", "abc", "", user.DeleteOptions{}, false, nil, http.StatusMethodNotAllowed, false},
+		{"Write Error", "1", "", user.DeleteOptions{}, true, nil, http.StatusOK, true},
 	}
 
 	for _, tt := range tests {
@@ -216,11 +237,11 @@ func Test_DeleteUseR(t *testing.T) {
 			if tt.name == "wrong HTTP method" {
 				method = http.MethodGet
 			}
-			if tt.ExpErr != nil || tt.ExpCode == http.StatusOK || tt.isWriteErr {
-				mock.EXPECT().Delete(gomock.Any()).Return(tt.ExpErr).AnyTimes()
+			if tt.callSvc {
+				mock.EXPECT().DeleteWithPolicy(gomock.Any(), gomock.Any(), tt.opts).Return(user.DeleteReport{}, tt.ExpErr)
 			}
 
-			req := httptest.NewRequest(method, "/users/"+tt.id, nil)
+			req := httptest.NewRequest(method, "/users/"+tt.id+tt.query, nil)
 			req = mux.SetURLVars(req, map[string]string{"id": tt.id})
 			rec := httptest.NewRecorder()
 			var w http.ResponseWriter = rec
@@ -246,10 +267,10 @@ func Test_GetAllUsers(t *testing.T) {
 		ExpCode    int
 		isWriteErr bool
 	}{
-		{"Successfully retried", []user.User{{1, "John", "John@gmail.com"}, {2, "John", "John@gmail.com"}}, []user.User{{1, "John", "John@gmail.com"}, {2, "John", "John@gmail.com"}}, nil, http.StatusOK, false},
-		{"Unable to fetch user data", []user.User{{1, "John", "John@gmail.com"}, {2, "John", "John@gmail.com"}}, nil, errors.New("Failed to fetch user's data"), http.StatusInternalServerError, false},
-		{"wrong HTTP method", []user.User{{1, "John", "John@gmail.com"}, {2, "John", "John@gmail.com"}}, nil, nil, http.StatusMethodNotAllowed, false},
-		{"Write Error", []user.User{{1, "John", "John@gmail.com"}, {2, "John", "John@gmail.com"}}, []user.User{{1, "John", "John@gmail.com"}, {2, "John", "John@gmail.com"}}, nil, http.StatusOK, true},
+		{"Successfully retried", []user.User{{ID: 1, Name: "John", Email: "John@gmail.com"}, {ID: 2, Name: "John", Email: "John@gmail.com"}}, []user.User{{ID: 1, Name: "John", Email: "John@gmail.com"}, {ID: 2, Name: "John", Email: "John@gmail.com"}}, nil, http.StatusOK, false},
+		{"Unable to fetch user data", []user.User{{ID: 1, Name: "John", Email: "John@gmail.com"}, {ID: 2, Name: "John", Email: "John@gmail.com"}}, nil, errors.New("Failed to fetch user's data"), http.StatusInternalServerError, false},
+		{"wrong HTTP method", []user.User{{ID: 1, Name: "John", Email: "John@gmail.com"}, {ID: 2, Name: "John", Email: "John@gmail.com"}}, nil, nil, http.StatusMethodNotAllowed, false},
+		{"Write Error", []user.User{{ID: 1, Name: "John", Email: "John@gmail.com"}, {ID: 2, Name: "John", Email: "John@gmail.com"}}, []user.User{{ID: 1, Name: "John", Email: "John@gmail.com"}, {ID: 2, Name: "John", Email: "John@gmail.com"}}, nil, http.StatusOK, true},
 	}
 
 	for _, tt := range tests {
@@ -264,7 +285,7 @@ func Test_GetAllUsers(t *testing.T) {
 				method = http.MethodPost
 			}
 			if tt.ExpErr != nil || tt.ExpCode == http.StatusOK || tt.isWriteErr {
-				mock.EXPECT().All().Return(tt.ExpOutput, tt.ExpErr).AnyTimes()
+				mock.EXPECT().All(gomock.Any()).Return(tt.ExpOutput, tt.ExpErr).AnyTimes()
 			}
 
 			req := httptest.NewRequest(method, "/users", nil)
@@ -281,3 +302,192 @@ func Test_GetAllUsers(t *testing.T) {
 		})
 	}
 }
+
+// Test_GetTrash : Tests deleted users are listed
+func Test_GetTrash(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		mockOutput []user.Trashed
+		mockErr    error
+		ExpCode    int
+	}{
+		{"Trash fetched", http.MethodGet, []user.Trashed{{User: user.User{ID: 1, Name: "John", Email: "john@example.com"}}}, nil, http.StatusOK},
+		{"Fetch error", http.MethodGet, nil, errors.New("db error"), http.StatusInternalServerError},
+		{"wrong HTTP method", http.MethodPost, nil, nil, http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mock := NewMockUserServiceInterface(ctrl)
+			h := &UserHandler{mock}
+
+			if tt.method == http.MethodGet {
+				mock.EXPECT().Trash(gomock.Any()).Return(tt.mockOutput, tt.mockErr)
+			}
+
+			rec := httptest.NewRecorder()
+			h.GetTrash(rec, httptest.NewRequest(tt.method, "/trash/users", nil))
+
+			if rec.Code != tt.ExpCode {
+				t.Errorf("GetTrash() = %v, want %v", rec.Code, tt.ExpCode)
+			}
+		})
+	}
+}
+
+// Test_RestoreUser : Tests a deleted user is restored
+func Test_RestoreUser(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		id      string
+		mockErr error
+		callSvc bool
+		ExpCode int
+	}{
+		{"Restored", http.MethodPost, "1", nil, true, http.StatusOK},
+		{"Not in trash", http.MethodPost, "2", sql.ErrNoRows, true, http.StatusNotFound},
+		{"Restore error", http.MethodPost, "3", errors.New("db error"), true, http.StatusInternalServerError},
+		{"Invalid id", http.MethodPost, "abc", nil, false, http.StatusBadRequest},
+		{"wrong HTTP method", http.MethodGet, "1", nil, false, http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mock := NewMockUserServiceInterface(ctrl)
+			h := &UserHandler{mock}
+
+			if tt.callSvc {
+				mock.EXPECT().Restore(gomock.Any(), gomock.Any()).Return(tt.mockErr)
+			}
+
+			req := httptest.NewRequest(tt.method, "/users/"+tt.id+"/restore", nil)
+			req = mux.SetURLVars(req, map[string]string{"id": tt.id})
+			rec := httptest.NewRecorder()
+			h.RestoreUser(rec, req)
+
+			if rec.Code != tt.ExpCode {
+				t.Errorf("RestoreUser() = %v, want %v", rec.Code, tt.ExpCode)
+			}
+		})
+	}
+}
+
+func Test_UpdateUser(t *testing.T) {
+	updated := user.User{ID: 1, Name: "John", Email: "john@example.com", Timezone: "Europe/Berlin"}
+
+	tests := []struct {
+		name    string
+		method  string
+		id      string
+		body    string
+		mockErr error
+		callSvc bool
+		ExpCode int
+	}{
+		{"Updated", http.MethodPatch, "1", `{"timezone":"Europe/Berlin"}`, nil, true, http.StatusOK},
+		{"Someone else's profile", http.MethodPatch, "1", `{"name":"Eve"}`, user.ErrForbidden, true, http.StatusForbidden},
+		{"Email in body", http.MethodPatch, "1", `{"email":"new@example.com"}`, nil, false, http.StatusBadRequest},
+		{"Invalid JSON", http.MethodPatch, "1", `{`, nil, false, http.StatusBadRequest},
+		{"Invalid id", http.MethodPatch, "abc", `{}`, nil, false, http.StatusBadRequest},
+		{"wrong HTTP method", http.MethodPut, "1", `{}`, nil, false, http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mock := NewMockUserServiceInterface(ctrl)
+			h := &UserHandler{mock}
+
+			if tt.callSvc {
+				mock.EXPECT().Update(gomock.Any(), auth.Actor{UserID: 1}, 1, gomock.Any()).Return(updated, tt.mockErr)
+			}
+
+			req := httptest.NewRequest(tt.method, "/users/"+tt.id, strings.NewReader(tt.body))
+			req = mux.SetURLVars(req, map[string]string{"id": tt.id})
+			req = req.WithContext(auth.WithActor(req.Context(), auth.Actor{UserID: 1}))
+			rec := httptest.NewRecorder()
+			h.UpdateUser(rec, req)
+
+			if rec.Code != tt.ExpCode {
+				t.Errorf("UpdateUser() = %v, want %v", rec.Code, tt.ExpCode)
+			}
+		})
+	}
+}
+
+func Test_ChangeEmail(t *testing.T) {
+	change := user.EmailChange{UserID: 1, Email: "new@example.com", ExpiresAt: time.Now().Add(user.EmailChangeTTL)}
+
+	tests := []struct {
+		name    string
+		body    string
+		mockErr error
+		callSvc bool
+		ExpCode int
+	}{
+		{"Requested", `{"email":"new@example.com"}`, nil, true, http.StatusAccepted},
+		{"Address taken", `{"email":"alice@example.com"}`, user.ErrEmailTaken, true, http.StatusConflict},
+		{"Invalid JSON", `{`, nil, false, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mock := NewMockUserServiceInterface(ctrl)
+			h := &UserHandler{mock}
+
+			if tt.callSvc {
+				mock.EXPECT().RequestEmailChange(gomock.Any(), gomock.Any(), 1, gomock.Any()).Return(change, tt.mockErr)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/users/1/email", strings.NewReader(tt.body))
+			req = mux.SetURLVars(req, map[string]string{"id": "1"})
+			rec := httptest.NewRecorder()
+			h.ChangeEmail(rec, req)
+
+			if rec.Code != tt.ExpCode {
+				t.Errorf("ChangeEmail() = %v, want %v", rec.Code, tt.ExpCode)
+			}
+		})
+	}
+}
+
+func Test_ConfirmEmail(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		mockErr error
+		callSvc bool
+		ExpCode int
+	}{
+		{"Confirmed", `{"token":"abc"}`, nil, true, http.StatusOK},
+		{"Expired token", `{"token":"abc"}`, user.ErrEmailToken, true, http.StatusBadRequest},
+		{"Address taken meanwhile", `{"token":"abc"}`, user.ErrEmailTaken, true, http.StatusConflict},
+		{"Invalid JSON", `{`, nil, false, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mock := NewMockUserServiceInterface(ctrl)
+			h := &UserHandler{mock}
+
+			if tt.callSvc {
+				mock.EXPECT().ConfirmEmailChange(gomock.Any(), 1, "abc").Return(user.User{ID: 1, Name: "John", Email: "new@example.com"}, tt.mockErr)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/users/1/email/confirm", strings.NewReader(tt.body))
+			req = mux.SetURLVars(req, map[string]string{"id": "1"})
+			rec := httptest.NewRecorder()
+			h.ConfirmEmail(rec, req)
+
+			if rec.Code != tt.ExpCode {
+				t.Errorf("ConfirmEmail() = %v, want %v", rec.Code, tt.ExpCode)
+			}
+		})
+	}
+}
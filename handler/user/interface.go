@@ -1,10 +1,19 @@
 package user
 
-import "Task_Manager/model/user"
+import (
+	"Task_Manager/auth"
+	"Task_Manager/model/user"
+	"context"
+)
 
 type UserServiceInterface interface {
-	Create(u user.User) (user.User, error)
-	Get(id int) (user.User, error)
-	Delete(id int) error
-	All() ([]user.User, error)
+	Create(ctx context.Context, u user.User) (user.User, error)
+	Get(ctx context.Context, id int) (user.User, error)
+	Update(ctx context.Context, actor auth.Actor, id int, p user.Profile) (user.User, error)
+	RequestEmailChange(ctx context.Context, actor auth.Actor, id int, email string) (user.EmailChange, error)
+	ConfirmEmailChange(ctx context.Context, id int, token string) (user.User, error)
+	DeleteWithPolicy(ctx context.Context, id int, opts user.DeleteOptions) (user.DeleteReport, error)
+	All(ctx context.Context) ([]user.User, error)
+	Trash(ctx context.Context) ([]user.Trashed, error)
+	Restore(ctx context.Context, id int) error
 }
@@ -10,7 +10,9 @@
 package user
 
 import (
+	auth "Task_Manager/auth"
 	user "Task_Manager/model/user"
+	context "context"
 	reflect "reflect"
 
 	gomock "go.uber.org/mock/gomock"
@@ -41,61 +43,135 @@ func (m *MockUserServiceInterface) EXPECT() *MockUserServiceInterfaceMockRecorde
 }
 
 // All mocks base method.
-func (m *MockUserServiceInterface) All() ([]user.User, error) {
+func (m *MockUserServiceInterface) All(ctx context.Context) ([]user.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "All")
+	ret := m.ctrl.Call(m, "All", ctx)
 	ret0, _ := ret[0].([]user.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // All indicates an expected call of All.
-func (mr *MockUserServiceInterfaceMockRecorder) All() *gomock.Call {
+func (mr *MockUserServiceInterfaceMockRecorder) All(ctx any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "All", reflect.TypeOf((*MockUserServiceInterface)(nil).All))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "All", reflect.TypeOf((*MockUserServiceInterface)(nil).All), ctx)
 }
 
-// Create mocks base method.
-func (m *MockUserServiceInterface) Create(u user.User) (user.User, error) {
+// ConfirmEmailChange mocks base method.
+func (m *MockUserServiceInterface) ConfirmEmailChange(ctx context.Context, id int, token string) (user.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Create", u)
+	ret := m.ctrl.Call(m, "ConfirmEmailChange", ctx, id, token)
 	ret0, _ := ret[0].(user.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
+// ConfirmEmailChange indicates an expected call of ConfirmEmailChange.
+func (mr *MockUserServiceInterfaceMockRecorder) ConfirmEmailChange(ctx, id, token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmEmailChange", reflect.TypeOf((*MockUserServiceInterface)(nil).ConfirmEmailChange), ctx, id, token)
+}
+
+// Create mocks base method.
+func (m *MockUserServiceInterface) Create(ctx context.Context, u user.User) (user.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, u)
+	ret0, _ := ret[0].(user.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
 
 // Create indicates an expected call of Create.
-func (mr *MockUserServiceInterfaceMockRecorder) Create(u any) *gomock.Call {
+func (mr *MockUserServiceInterfaceMockRecorder) Create(ctx, u any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockUserServiceInterface)(nil).Create), u)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockUserServiceInterface)(nil).Create), ctx, u)
 }
 
-// Delete mocks base method.
-func (m *MockUserServiceInterface) Delete(id int) error {
+// DeleteWithPolicy mocks base method.
+func (m *MockUserServiceInterface) DeleteWithPolicy(ctx context.Context, id int, opts user.DeleteOptions) (user.DeleteReport, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Delete", id)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "DeleteWithPolicy", ctx, id, opts)
+	ret0, _ := ret[0].(user.DeleteReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// Delete indicates an expected call of Delete.
-func (mr *MockUserServiceInterfaceMockRecorder) Delete(id any) *gomock.Call {
+// DeleteWithPolicy indicates an expected call of DeleteWithPolicy.
+func (mr *MockUserServiceInterfaceMockRecorder) DeleteWithPolicy(ctx, id, opts any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockUserServiceInterface)(nil).Delete), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWithPolicy", reflect.TypeOf((*MockUserServiceInterface)(nil).DeleteWithPolicy), ctx, id, opts)
 }
 
 // Get mocks base method.
-func (m *MockUserServiceInterface) Get(id int) (user.User, error) {
+func (m *MockUserServiceInterface) Get(ctx context.Context, id int) (user.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Get", id)
+	ret := m.ctrl.Call(m, "Get", ctx, id)
 	ret0, _ := ret[0].(user.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Get indicates an expected call of Get.
-func (mr *MockUserServiceInterfaceMockRecorder) Get(id any) *gomock.Call {
+func (mr *MockUserServiceInterfaceMockRecorder) Get(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockUserServiceInterface)(nil).Get), ctx, id)
+}
+
+// RequestEmailChange mocks base method.
+func (m *MockUserServiceInterface) RequestEmailChange(ctx context.Context, actor auth.Actor, id int, email string) (user.EmailChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequestEmailChange", ctx, actor, id, email)
+	ret0, _ := ret[0].(user.EmailChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RequestEmailChange indicates an expected call of RequestEmailChange.
+func (mr *MockUserServiceInterfaceMockRecorder) RequestEmailChange(ctx, actor, id, email any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestEmailChange", reflect.TypeOf((*MockUserServiceInterface)(nil).RequestEmailChange), ctx, actor, id, email)
+}
+
+// Restore mocks base method.
+func (m *MockUserServiceInterface) Restore(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Restore indicates an expected call of Restore.
+func (mr *MockUserServiceInterfaceMockRecorder) Restore(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockUserServiceInterface)(nil).Restore), ctx, id)
+}
+
+// Trash mocks base method.
+func (m *MockUserServiceInterface) Trash(ctx context.Context) ([]user.Trashed, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Trash", ctx)
+	ret0, _ := ret[0].([]user.Trashed)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Trash indicates an expected call of Trash.
+func (mr *MockUserServiceInterfaceMockRecorder) Trash(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Trash", reflect.TypeOf((*MockUserServiceInterface)(nil).Trash), ctx)
+}
+
+// Update mocks base method.
+func (m *MockUserServiceInterface) Update(ctx context.Context, actor auth.Actor, id int, p user.Profile) (user.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, actor, id, p)
+	ret0, _ := ret[0].(user.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockUserServiceInterfaceMockRecorder) Update(ctx, actor, id, p any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockUserServiceInterface)(nil).Get), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockUserServiceInterface)(nil).Update), ctx, actor, id, p)
 }
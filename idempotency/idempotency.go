@@ -0,0 +1,182 @@
+// Package idempotency makes retried requests safe. A client sends an Idempotency-Key header with a
+// request that must not be applied twice; the first request with a key is served and its response
+// kept, and retries with the same key and body get that response again instead of being served anew.
+package idempotency
+
+import (
+	"Task_Manager/handler/problem"
+	"Task_Manager/logging"
+	"Task_Manager/model/idempotency"
+	"Task_Manager/ratelimit"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	// Header carries the key chosen by the client, unique to each operation it wants applied once
+	Header = "Idempotency-Key"
+	// HeaderReplayed marks a response replayed from a previous request
+	HeaderReplayed = "Idempotent-Replayed"
+	// MaxBody caps the bodies of requests sent with a key, which are read in full to be fingerprinted
+	MaxBody = 1 << 20
+)
+
+// Store keeps the records of the keys in use
+type Store interface {
+	ClaimIdempotency(ctx context.Context, rec idempotency.Record, expired time.Time) (idempotency.Record, bool, error)
+	CompleteIdempotency(ctx context.Context, key string, status int, contentType string, body []byte) error
+	ReleaseIdempotency(ctx context.Context, key string) error
+}
+
+// Keys serves the requests sent with an idempotency key
+type Keys struct {
+	store Store
+	ttl   time.Duration
+	now   func() time.Time
+}
+
+// New builds Keys that remember each key for ttl, after which it may be used again
+func New(store Store, ttl time.Duration) *Keys {
+	return &Keys{store: store, ttl: ttl, now: time.Now}
+}
+
+// Middleware applies idempotency keys to the handler it wraps; requests without a key are served as
+// usual. A retry with the same key gets the kept response with the Idempotent-Replayed header, a key
+// reused with a different body is rejected with 422 Unprocessable Entity, and a retry arriving while
+// the first request is still served gets 409 Conflict. Server errors are not kept, so the request can
+// be retried with the same key.
+func (k *Keys) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(Header)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if err := idempotency.ValidKey(key); err != nil {
+			problem.Write(w, r, err)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxBody))
+		if err != nil {
+			problem.Write(w, r, err)
+			return
+		}
+
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		now := k.now()
+		rec := idempotency.Record{
+			Key:         hash(ratelimit.ClientKey(r), r.Method+" "+r.URL.Path, key),
+			Fingerprint: hash(string(body)),
+			CreatedAt:   now,
+		}
+
+		existing, claimed, err := k.store.ClaimIdempotency(r.Context(), rec, now.Add(-k.ttl))
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			// The first request released the key between our claim and our read; it is being retried
+			problem.Write(w, r, idempotency.ErrInProgress)
+			return
+		case err != nil:
+			problem.Write(w, r, err)
+			return
+		case !claimed:
+			replay(w, r, existing, rec.Fingerprint)
+			return
+		}
+
+		rw := &recorder{ResponseWriter: w}
+		// The response is sent already; keeping it must not depend on the client waiting
+		ctx := context.WithoutCancel(r.Context())
+		// A handler that panics leaves no response to keep
+		defer k.finish(ctx, rec.Key, rw)
+
+		next.ServeHTTP(rw, r)
+		rw.done = true
+	})
+}
+
+// finish keeps the response for the retries of the request, or releases the key when there is no
+// response worth replaying
+func (k *Keys) finish(ctx context.Context, key string, rw *recorder) {
+	var err error
+	if !rw.done || rw.status == 0 || rw.status >= http.StatusInternalServerError {
+		err = k.store.ReleaseIdempotency(ctx, key)
+	} else {
+		err = k.store.CompleteIdempotency(ctx, key, rw.status, rw.Header().Get("Content-Type"), rw.body.Bytes())
+	}
+
+	if err != nil {
+		logging.FromContext(ctx).Error("idempotency key update failed", "error", err)
+	}
+}
+
+// replay answers a retry with the response kept for its key
+func replay(w http.ResponseWriter, r *http.Request, rec idempotency.Record, fingerprint string) {
+	switch {
+	case rec.Fingerprint != fingerprint:
+		problem.Write(w, r, idempotency.ErrKeyReused)
+		return
+	case !rec.Done():
+		problem.Write(w, r, idempotency.ErrInProgress)
+		return
+	}
+
+	if rec.ContentType != "" {
+		w.Header().Set("Content-Type", rec.ContentType)
+	}
+
+	w.Header().Set(HeaderReplayed, "true")
+	w.WriteHeader(rec.Status)
+
+	if _, err := w.Write(rec.Body); err != nil {
+		logging.FromContext(r.Context()).Error("response write failed", "error", err)
+	}
+}
+
+// hash joins parts unambiguously and hashes them to a fixed length hex string
+func hash(parts ...string) string {
+	h := sha256.New()
+	for _, p := range parts {
+		_, _ = io.WriteString(h, p)
+		_, _ = h.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// recorder passes the response through while keeping a copy of it
+type recorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+	// done is set once the handler has returned
+	done bool
+}
+
+func (w *recorder) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *recorder) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	w.body.Write(b)
+
+	return w.ResponseWriter.Write(b)
+}
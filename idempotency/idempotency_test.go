@@ -0,0 +1,225 @@
+package idempotency
+
+import (
+	"Task_Manager/auth"
+	"Task_Manager/handler/authn"
+	"Task_Manager/model/idempotency"
+	"context"
+	"database/sql"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryStore keeps records the way the database store does
+type memoryStore struct {
+	mu      sync.Mutex
+	records map[string]idempotency.Record
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{records: make(map[string]idempotency.Record)}
+}
+
+func (m *memoryStore) ClaimIdempotency(_ context.Context, rec idempotency.Record, expired time.Time) (idempotency.Record, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if existing, ok := m.records[rec.Key]; ok && !existing.CreatedAt.Before(expired) {
+		return existing, false, nil
+	}
+
+	m.records[rec.Key] = rec
+
+	return rec, true, nil
+}
+
+func (m *memoryStore) CompleteIdempotency(_ context.Context, key string, status int, contentType string, body []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rec, ok := m.records[key]
+	if !ok {
+		return sql.ErrNoRows
+	}
+
+	rec.Status, rec.ContentType, rec.Body = status, contentType, body
+	m.records[key] = rec
+
+	return nil
+}
+
+func (m *memoryStore) ReleaseIdempotency(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.records[key].Done() {
+		delete(m.records, key)
+	}
+
+	return nil
+}
+
+// creator answers each request with the next ID, and the given status
+type creator struct {
+	calls  int
+	status int
+}
+
+func (c *creator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.calls++
+	body, _ := io.ReadAll(r.Body)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(c.status)
+	_, _ = w.Write([]byte(`{"id":` + strconv.Itoa(c.calls) + `,"body":` + string(body) + `}`))
+}
+
+// send posts body with key as user through h
+func send(h http.Handler, key, user, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/task", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(Header, key)
+	}
+
+	req.Header.Set(auth.HeaderUserID, user)
+
+	rec := httptest.NewRecorder()
+	authn.Middleware(auth.New(auth.Config{TrustHeader: true}))(h).ServeHTTP(rec, req)
+
+	return rec
+}
+
+func Test_Middleware(t *testing.T) {
+	t.Run("Retries replay the first response", func(t *testing.T) {
+		c := &creator{status: http.StatusCreated}
+		h := New(newMemoryStore(), time.Hour).Middleware(c)
+
+		first := send(h, "abc", "7", `"x"`)
+		retry := send(h, "abc", "7", `"x"`)
+
+		assert.Equal(t, 1, c.calls)
+		assert.Equal(t, http.StatusCreated, retry.Code)
+		assert.Equal(t, first.Body.String(), retry.Body.String())
+		assert.Equal(t, "application/json", retry.Header().Get("Content-Type"))
+		assert.Equal(t, "true", retry.Header().Get(HeaderReplayed))
+		assert.Empty(t, first.Header().Get(HeaderReplayed))
+	})
+
+	t.Run("Requests without a key are always served", func(t *testing.T) {
+		c := &creator{status: http.StatusCreated}
+		h := New(newMemoryStore(), time.Hour).Middleware(c)
+
+		send(h, "", "7", `"x"`)
+		send(h, "", "7", `"x"`)
+
+		assert.Equal(t, 2, c.calls)
+	})
+
+	t.Run("Keys of different clients do not collide", func(t *testing.T) {
+		c := &creator{status: http.StatusCreated}
+		h := New(newMemoryStore(), time.Hour).Middleware(c)
+
+		send(h, "abc", "7", `"x"`)
+		send(h, "abc", "8", `"x"`)
+
+		assert.Equal(t, 2, c.calls)
+	})
+
+	t.Run("Reusing a key with another body is rejected", func(t *testing.T) {
+		c := &creator{status: http.StatusCreated}
+		h := New(newMemoryStore(), time.Hour).Middleware(c)
+
+		send(h, "abc", "7", `"x"`)
+		rec := send(h, "abc", "7", `"y"`)
+
+		assert.Equal(t, 1, c.calls)
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"code":"idempotency_key_reused"`)
+	})
+
+	t.Run("A retry during the first request conflicts", func(t *testing.T) {
+		store := newMemoryStore()
+		k := New(store, time.Hour)
+
+		var rec *httptest.ResponseRecorder
+		h := k.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rec = send(k.Middleware(&creator{status: http.StatusCreated}), "abc", "7", `"x"`)
+			w.WriteHeader(http.StatusCreated)
+		}))
+
+		send(h, "abc", "7", `"x"`)
+
+		require.NotNil(t, rec)
+		assert.Equal(t, http.StatusConflict, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"code":"idempotency_key_in_progress"`)
+	})
+
+	t.Run("Server errors are not replayed", func(t *testing.T) {
+		c := &creator{status: http.StatusServiceUnavailable}
+		h := New(newMemoryStore(), time.Hour).Middleware(c)
+
+		send(h, "abc", "7", `"x"`)
+		c.status = http.StatusCreated
+		rec := send(h, "abc", "7", `"x"`)
+
+		assert.Equal(t, 2, c.calls)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+	})
+
+	t.Run("Client errors are replayed", func(t *testing.T) {
+		c := &creator{status: http.StatusBadRequest}
+		h := New(newMemoryStore(), time.Hour).Middleware(c)
+
+		send(h, "abc", "7", `"x"`)
+		rec := send(h, "abc", "7", `"x"`)
+
+		assert.Equal(t, 1, c.calls)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("A panicking handler releases its key", func(t *testing.T) {
+		store := newMemoryStore()
+		h := New(store, time.Hour).Middleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+			panic("boom")
+		}))
+
+		assert.Panics(t, func() { send(h, "abc", "7", `"x"`) })
+		assert.Empty(t, store.records)
+	})
+
+	t.Run("Keys expire", func(t *testing.T) {
+		c := &creator{status: http.StatusCreated}
+		k := New(newMemoryStore(), time.Hour)
+		now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+		k.now = func() time.Time { return now }
+		h := k.Middleware(c)
+
+		send(h, "abc", "7", `"x"`)
+		now = now.Add(time.Hour + time.Second)
+		send(h, "abc", "7", `"x"`)
+
+		assert.Equal(t, 2, c.calls)
+	})
+
+	t.Run("Invalid keys are rejected", func(t *testing.T) {
+		c := &creator{status: http.StatusCreated}
+		h := New(newMemoryStore(), time.Hour).Middleware(c)
+
+		for _, key := range []string{strings.Repeat("k", idempotency.MaxKeyLength+1), "café"} {
+			rec := send(h, key, "7", `"x"`)
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Contains(t, rec.Body.String(), `"code":"idempotency_key_invalid"`)
+		}
+
+		assert.Zero(t, c.calls)
+	})
+}
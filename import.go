@@ -0,0 +1,111 @@
+package main
+
+import (
+	"Task_Manager/model/imports"
+	Import2 "Task_Manager/service/imports"
+	Replica3 "Task_Manager/store/replica"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const importUsage = `usage: Task_Manager import tasks|users [-format csv|ndjson] [-dry-run] FILE
+
+Imports FILE, or standard input when FILE is "-", and prints one NDJSON report line per row.
+The format defaults to the file extension: .csv, or .ndjson/.jsonl.
+`
+
+// runImport implements the import command and returns the process exit code: 0 when every row was
+// created or skipped, 1 when some rows failed or the import stopped early, 2 on usage errors.
+func runImport(svc *Import2.ImportService, args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() { fmt.Fprint(stderr, importUsage) }
+
+	if len(args) == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	entity := args[0]
+	format := fs.String("format", "", "file format: csv or ndjson")
+	dryRun := fs.Bool("dry-run", false, "validate and report without creating anything")
+
+	if err := fs.Parse(args[1:]); err != nil || fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	var run func(ctx context.Context, r io.Reader, format string, dryRun bool, emit func(imports.Row) error) (imports.Summary, error)
+
+	switch entity {
+	case "tasks":
+		run = svc.Tasks
+	case "users":
+		run = svc.Users
+	default:
+		fs.Usage()
+		return 2
+	}
+
+	path := fs.Arg(0)
+	if *format == "" {
+		*format = formatFromExt(path)
+	}
+
+	in := io.Reader(os.Stdin)
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			fmt.Fprintln(stderr, "Import failed:", err)
+			return 1
+		}
+
+		defer func(f *os.File) {
+			_ = f.Close()
+		}(f)
+
+		in = f
+	}
+
+	enc := json.NewEncoder(stdout)
+	// Duplicates are looked up among the rows just imported, which the replicas may not have yet
+	ctx := Replica3.Primary(context.Background())
+	summary, err := run(ctx, in, *format, *dryRun, func(row imports.Row) error {
+		return enc.Encode(row)
+	})
+
+	fmt.Fprintf(stderr, "created %d, skipped %d, failed %d", summary.Created, summary.Skipped, summary.Failed)
+	if summary.DryRun {
+		fmt.Fprint(stderr, " (dry run)")
+	}
+
+	fmt.Fprintln(stderr)
+
+	if err != nil {
+		fmt.Fprintln(stderr, "Import failed:", err)
+		return 1
+	}
+
+	if summary.Failed > 0 {
+		return 1
+	}
+
+	return 0
+}
+
+func formatFromExt(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return imports.FormatCSV
+	case ".ndjson", ".jsonl":
+		return imports.FormatNDJSON
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,36 @@
+package jobs
+
+import (
+	"Task_Manager/logging"
+	"context"
+	"time"
+)
+
+// Digester sends the queued notifications of each user as one message
+type Digester interface {
+	SendDigests(ctx context.Context) (int, error)
+}
+
+// SendDigests runs the digester once per interval until ctx is done. Unlike the trash purge it waits
+// for the first tick, so restarting the server does not send an early digest.
+func SendDigests(ctx context.Context, interval time.Duration, d Digester) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		n, err := d.SendDigests(ctx)
+		if err != nil {
+			logging.FromContext(ctx).Error("digest failed", "error", err)
+		}
+
+		if n > 0 {
+			logging.FromContext(ctx).Info("sent notification digests", "count", n)
+		}
+	}
+}
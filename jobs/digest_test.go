@@ -0,0 +1,60 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeDigester struct {
+	mu     sync.Mutex
+	calls  int
+	err    error
+	called chan struct{}
+}
+
+func (f *fakeDigester) SendDigests(context.Context) (int, error) {
+	f.mu.Lock()
+	f.calls++
+	f.mu.Unlock()
+
+	select {
+	case f.called <- struct{}{}:
+	default:
+	}
+
+	return 1, f.err
+}
+
+func Test_SendDigests(t *testing.T) {
+	d := &fakeDigester{err: errors.New("smtp down"), called: make(chan struct{}, 1)}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		SendDigests(ctx, 10*time.Millisecond, d)
+		close(done)
+	}()
+
+	// A failed run does not stop the job
+	for i := 0; i < 2; i++ {
+		select {
+		case <-d.called:
+		case <-time.After(time.Second):
+			t.Fatal("digester was not called")
+		}
+	}
+
+	cancel()
+	<-done
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	assert.GreaterOrEqual(t, d.calls, 2)
+}
@@ -0,0 +1,37 @@
+package jobs
+
+import (
+	"Task_Manager/logging"
+	"context"
+	"time"
+)
+
+// KeyExpirer removes the idempotency keys created before before
+type KeyExpirer interface {
+	DeleteExpiredIdempotency(ctx context.Context, before time.Time) (int, error)
+}
+
+// ExpireIdempotencyKeys removes the idempotency keys older than ttl once per interval until ctx is done.
+// Expired keys are ignored when claimed anyway; removing them only keeps the table small.
+func ExpireIdempotencyKeys(ctx context.Context, interval, ttl time.Duration, e KeyExpirer) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		n, err := e.DeleteExpiredIdempotency(ctx, time.Now().Add(-ttl))
+		if err != nil {
+			logging.FromContext(ctx).Error("idempotency key expiry failed", "error", err)
+			continue
+		}
+
+		if n > 0 {
+			logging.FromContext(ctx).Debug("removed expired idempotency keys", "count", n)
+		}
+	}
+}
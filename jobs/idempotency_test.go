@@ -0,0 +1,44 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeExpirer struct {
+	before chan time.Time
+}
+
+func (f *fakeExpirer) DeleteExpiredIdempotency(_ context.Context, before time.Time) (int, error) {
+	select {
+	case f.before <- before:
+	default:
+	}
+
+	return 1, nil
+}
+
+func Test_ExpireIdempotencyKeys(t *testing.T) {
+	e := &fakeExpirer{before: make(chan time.Time, 1)}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		ExpireIdempotencyKeys(ctx, 10*time.Millisecond, 24*time.Hour, e)
+		close(done)
+	}()
+
+	select {
+	case before := <-e.before:
+		assert.WithinDuration(t, time.Now().Add(-24*time.Hour), before, time.Second)
+	case <-time.After(time.Second):
+		t.Fatal("expirer was not called")
+	}
+
+	cancel()
+	<-done
+}
@@ -0,0 +1,42 @@
+package jobs
+
+import (
+	"Task_Manager/logging"
+	"context"
+	"time"
+)
+
+// Purger permanently removes the entries that have been in the trash since before the given time
+type Purger interface {
+	Purge(ctx context.Context, before time.Time) (int, error)
+}
+
+// PurgeTrash runs every purger once per interval, removing trash older than retention, until ctx is done
+func PurgeTrash(ctx context.Context, interval, retention time.Duration, purgers ...Purger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		purgeOnce(ctx, time.Now().Add(-retention), purgers)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func purgeOnce(ctx context.Context, before time.Time, purgers []Purger) {
+	for _, p := range purgers {
+		n, err := p.Purge(ctx, before)
+		if err != nil {
+			logging.FromContext(ctx).Error("trash purge failed", "error", err)
+			continue
+		}
+
+		if n > 0 {
+			logging.FromContext(ctx).Info("purged trashed entries", "count", n)
+		}
+	}
+}
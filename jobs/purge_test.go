@@ -0,0 +1,62 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakePurger struct {
+	mu     sync.Mutex
+	calls  []time.Time
+	err    error
+	called chan struct{}
+}
+
+func (f *fakePurger) Purge(_ context.Context, before time.Time) (int, error) {
+	f.mu.Lock()
+	f.calls = append(f.calls, before)
+	f.mu.Unlock()
+
+	select {
+	case f.called <- struct{}{}:
+	default:
+	}
+
+	return 1, f.err
+}
+
+func Test_PurgeTrash(t *testing.T) {
+	failing := &fakePurger{err: errors.New("db down"), called: make(chan struct{}, 1)}
+	ok := &fakePurger{called: make(chan struct{}, 1)}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	start := time.Now()
+
+	go func() {
+		PurgeTrash(ctx, time.Hour, 24*time.Hour, failing, ok)
+		close(done)
+	}()
+
+	select {
+	case <-ok.called:
+	case <-time.After(time.Second):
+		t.Fatal("purger was not called")
+	}
+
+	cancel()
+	<-done
+
+	ok.mu.Lock()
+	defer ok.mu.Unlock()
+
+	assert.Len(t, failing.calls, 1, "a failing purger must not stop the others")
+	assert.Len(t, ok.calls, 1)
+	assert.WithinDuration(t, start.Add(-24*time.Hour), ok.calls[0], time.Second)
+}
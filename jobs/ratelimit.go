@@ -0,0 +1,37 @@
+package jobs
+
+import (
+	"Task_Manager/logging"
+	"context"
+	"time"
+)
+
+// BucketCleaner removes the rate limit buckets unused since before
+type BucketCleaner interface {
+	DeleteIdleRateLimit(ctx context.Context, before time.Time) (int, error)
+}
+
+// CleanRateLimits removes the buckets idle for longer than idle once per interval until ctx is done.
+// Such buckets have filled up again, so removing them does not change any limit.
+func CleanRateLimits(ctx context.Context, interval, idle time.Duration, c BucketCleaner) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		n, err := c.DeleteIdleRateLimit(ctx, time.Now().Add(-idle))
+		if err != nil {
+			logging.FromContext(ctx).Error("rate limit cleanup failed", "error", err)
+			continue
+		}
+
+		if n > 0 {
+			logging.FromContext(ctx).Debug("removed idle rate limit buckets", "count", n)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeCleaner struct {
+	before chan time.Time
+}
+
+func (f *fakeCleaner) DeleteIdleRateLimit(_ context.Context, before time.Time) (int, error) {
+	select {
+	case f.before <- before:
+	default:
+	}
+
+	return 2, nil
+}
+
+func Test_CleanRateLimits(t *testing.T) {
+	c := &fakeCleaner{before: make(chan time.Time, 1)}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		CleanRateLimits(ctx, 10*time.Millisecond, time.Hour, c)
+		close(done)
+	}()
+
+	select {
+	case before := <-c.before:
+		assert.WithinDuration(t, time.Now().Add(-time.Hour), before, time.Second)
+	case <-time.After(time.Second):
+		t.Fatal("cleaner was not called")
+	}
+
+	cancel()
+	<-done
+}
@@ -0,0 +1,35 @@
+package jobs
+
+import (
+	"Task_Manager/logging"
+	"context"
+	"time"
+)
+
+// ReplicaChecker checks the health of the read replicas, returning why some are unhealthy
+type ReplicaChecker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckReplicas checks the read replicas once per interval until ctx is done. Reads only go to the
+// replicas found healthy by the last check; the others are left to the primary until they recover.
+func CheckReplicas(ctx context.Context, interval time.Duration, c ReplicaChecker) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		check, cancel := context.WithTimeout(ctx, interval)
+		err := c.Check(check)
+		cancel()
+
+		if err != nil {
+			logging.FromContext(ctx).Warn("unhealthy read replicas", "error", err)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeChecker struct {
+	checked chan bool
+}
+
+func (f *fakeChecker) Check(ctx context.Context) error {
+	_, hasDeadline := ctx.Deadline()
+
+	select {
+	case f.checked <- hasDeadline:
+	default:
+	}
+
+	return errors.New("replica db2:3306: replication stopped")
+}
+
+func Test_CheckReplicas(t *testing.T) {
+	c := &fakeChecker{checked: make(chan bool, 1)}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		CheckReplicas(ctx, 10*time.Millisecond, c)
+		close(done)
+	}()
+
+	select {
+	case hasDeadline := <-c.checked:
+		assert.True(t, hasDeadline, "a check must not outlast its interval")
+	case <-time.After(time.Second):
+		t.Fatal("checker was not called")
+	}
+
+	cancel()
+	<-done
+}
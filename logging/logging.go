@@ -0,0 +1,90 @@
+// Package logging sets up structured logging with log/slog. Each request gets a logger carrying its
+// request ID in the context, so everything logged while serving it can be found by that ID.
+package logging
+
+import (
+	"Task_Manager/request"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// New returns a logger writing records at or above level ("debug", "info", "warn" or "error") to w,
+// formatted as "json" or "text"
+func New(w io.Writer, level, format string) (*slog.Logger, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q", level)
+	}
+
+	opts := &slog.HandlerOptions{Level: l}
+
+	switch strings.ToLower(format) {
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	case "text":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	default:
+		return nil, fmt.Errorf("invalid log format %q, expected json or text", format)
+	}
+}
+
+type ctxKey struct{}
+
+// WithLogger returns a copy of ctx carrying the logger
+func WithLogger(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, l)
+}
+
+// FromContext returns the logger stored in ctx, or the default logger outside of a request
+func FromContext(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(ctxKey{}).(*slog.Logger); ok {
+		return l
+	}
+
+	return slog.Default()
+}
+
+// Middleware stores a logger carrying the request ID in the request context and writes one access log
+// record per request once it is served. It must run inside request.Middleware, which assigns the ID,
+// and inside tracing.Middleware for the records of traced requests to carry their trace ID.
+func Middleware(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			l := logger.With("request_id", request.ID(r.Context()))
+			if sc := trace.SpanContextFromContext(r.Context()); sc.IsValid() {
+				l = l.With("trace_id", sc.TraceID().String())
+			}
+
+			sw := request.NewStatusWriter(w)
+
+			next.ServeHTTP(sw, r.WithContext(WithLogger(r.Context(), l)))
+
+			if sw.Status == 0 {
+				sw.Status = http.StatusOK
+			}
+
+			level := slog.LevelInfo
+			if sw.Status >= http.StatusInternalServerError {
+				level = slog.LevelError
+			}
+
+			l.LogAttrs(r.Context(), level, "request",
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", sw.Status),
+				slog.Int64("bytes", sw.Bytes),
+				slog.Duration("latency", time.Since(start)),
+				slog.String("remote_ip", request.RemoteIP(r.Context())),
+				slog.String("user_agent", r.UserAgent()),
+			)
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package logging
+
+import (
+	"Task_Manager/request"
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_New(t *testing.T) {
+	tests := []struct {
+		name    string
+		level   string
+		format  string
+		expErr  bool
+		expText string
+	}{
+		{"JSON", "info", "json", false, `"msg":"hello"`},
+		{"Text", "INFO", "text", false, "msg=hello"},
+		{"Filtered by level", "error", "json", false, ""},
+		{"Unknown level", "loud", "json", true, ""},
+		{"Unknown format", "info", "xml", true, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			l, err := New(&buf, tt.level, tt.format)
+			if tt.expErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			l.Info("hello")
+
+			if tt.expText == "" {
+				assert.Empty(t, buf.String())
+			} else {
+				assert.Contains(t, buf.String(), tt.expText)
+			}
+		})
+	}
+}
+
+func Test_FromContext(t *testing.T) {
+	assert.Same(t, slog.Default(), FromContext(context.Background()))
+
+	l := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+	assert.Same(t, l, FromContext(WithLogger(context.Background(), l)))
+}
+
+func Test_Middleware(t *testing.T) {
+	tests := []struct {
+		name      string
+		handler   http.HandlerFunc
+		expStatus int
+		expBytes  int
+		expLevel  string
+	}{
+		{"Implicit OK", func(w http.ResponseWriter, r *http.Request) {
+			FromContext(r.Context()).Info("inside")
+			_, _ = w.Write([]byte("hello"))
+		}, http.StatusOK, 5, "INFO"},
+		{"Explicit status", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}, http.StatusNotFound, 0, "INFO"},
+		{"Server error", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte("boom"))
+		}, http.StatusInternalServerError, 4, "ERROR"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+			h := request.Middleware(Middleware(logger)(tt.handler))
+
+			req := httptest.NewRequest(http.MethodGet, "/task/9", nil)
+			req.Header.Set(request.HeaderID, "req-42")
+			rec := httptest.NewRecorder()
+
+			h.ServeHTTP(rec, req)
+
+			lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+
+			var access map[string]any
+			require.NoError(t, json.Unmarshal(lines[len(lines)-1], &access))
+
+			assert.Equal(t, "request", access["msg"])
+			assert.Equal(t, tt.expLevel, access["level"])
+			assert.Equal(t, "req-42", access["request_id"])
+			assert.Equal(t, "GET", access["method"])
+			assert.Equal(t, "/task/9", access["path"])
+			assert.Equal(t, float64(tt.expStatus), access["status"])
+			assert.Equal(t, float64(tt.expBytes), access["bytes"])
+			assert.Equal(t, "192.0.2.1", access["remote_ip"])
+			assert.Contains(t, access, "latency")
+
+			// Records logged while serving the request carry its ID too
+			for _, line := range lines[:len(lines)-1] {
+				assert.Contains(t, string(line), `"request_id":"req-42"`)
+			}
+		})
+	}
+}
+
+func Test_MiddlewareFlush(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+
+	var flushable bool
+	h := Middleware(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, flushable = w.(http.Flusher)
+		w.(http.Flusher).Flush()
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/task/export", nil))
+
+	assert.True(t, flushable)
+	assert.True(t, rec.Flushed)
+}
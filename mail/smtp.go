@@ -0,0 +1,198 @@
+// Package mail delivers notification messages by email
+package mail
+
+import (
+	"Task_Manager/logging"
+	"Task_Manager/model/notification"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultTimeout = 30 * time.Second
+
+// headerSafe keeps values from starting a new header line
+var headerSafe = strings.NewReplacer("\r", " ", "\n", " ")
+
+// SMTPConfig configures the server messages are relayed through. Username may be empty for servers
+// that accept mail without authentication.
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// SMTPSender sends messages through an SMTP server, upgrading to TLS when the server offers STARTTLS
+type SMTPSender struct {
+	cfg       SMTPConfig
+	tlsConfig *tls.Config
+	now       func() time.Time
+}
+
+func NewSMTPSender(cfg SMTPConfig) (*SMTPSender, error) {
+	if cfg.Host == "" || cfg.From == "" {
+		return nil, errors.New("smtp host and sender address must be set")
+	}
+
+	if cfg.Port == 0 {
+		cfg.Port = 587
+	}
+
+	return &SMTPSender{cfg: cfg, tlsConfig: &tls.Config{ServerName: cfg.Host}, now: time.Now}, nil
+}
+
+// Send delivers one message. The whole exchange is bound by the deadline of ctx, or a default timeout.
+func (s *SMTPSender) Send(ctx context.Context, m notification.Message) error {
+	body, err := s.compose(m)
+	if err != nil {
+		return err
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = s.now().Add(defaultTimeout)
+	}
+
+	dialer := net.Dialer{Deadline: deadline}
+
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(s.cfg.Host, strconv.Itoa(s.cfg.Port)))
+	if err != nil {
+		return err
+	}
+
+	if err := conn.SetDeadline(deadline); err != nil {
+		_ = conn.Close()
+		return err
+	}
+
+	c, err := smtp.NewClient(conn, s.cfg.Host)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+
+	defer func(c *smtp.Client) {
+		_ = c.Close()
+	}(c)
+
+	if err := s.deliver(c, m.To, body); err != nil {
+		return fmt.Errorf("sending mail to %s: %w", m.To, err)
+	}
+
+	return c.Quit()
+}
+
+func (s *SMTPSender) deliver(c *smtp.Client, to string, body []byte) error {
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(s.tlsConfig); err != nil {
+			return err
+		}
+	}
+
+	if s.cfg.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Mail(s.cfg.From); err != nil {
+		return err
+	}
+
+	if err := c.Rcpt(to); err != nil {
+		return err
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.Write(body); err != nil {
+		_ = w.Close()
+		return err
+	}
+
+	return w.Close()
+}
+
+// compose renders the message as multipart/alternative with the plain text part first, so clients
+// that can display HTML pick the last part
+func (s *SMTPSender) compose(m notification.Message) ([]byte, error) {
+	var buf bytes.Buffer
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	parts := multipart.NewWriter(&buf)
+	domain := s.cfg.From[strings.LastIndex(s.cfg.From, "@")+1:]
+
+	headers := []string{
+		"From: " + s.cfg.From,
+		"To: " + headerSafe.Replace(m.To),
+		"Subject: " + mime.QEncoding.Encode("utf-8", headerSafe.Replace(m.Subject)),
+		"Date: " + s.now().Format(time.RFC1123Z),
+		"Message-ID: <" + hex.EncodeToString(id) + "@" + domain + ">",
+		"MIME-Version: 1.0",
+		"Content-Type: multipart/alternative; boundary=" + parts.Boundary(),
+	}
+
+	buf.WriteString(strings.Join(headers, "\r\n") + "\r\n\r\n")
+
+	for _, part := range []struct {
+		mediaType string
+		content   string
+	}{
+		{"text/plain; charset=utf-8", m.Text},
+		{"text/html; charset=utf-8", m.HTML},
+	} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.mediaType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// LogSender logs the recipient and subject of messages instead of sending them, for running without a mail
+// server. Bodies are left out, as they may carry tokens such as the one confirming an email change.
+type LogSender struct{}
+
+func (LogSender) Send(ctx context.Context, m notification.Message) error {
+	logging.FromContext(ctx).Info("mail not sent, no SMTP server configured", "to", m.To, "subject", m.Subject)
+	return nil
+}
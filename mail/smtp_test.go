@@ -0,0 +1,200 @@
+package mail
+
+import (
+	"Task_Manager/logging"
+	"Task_Manager/model/notification"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSMTP is a minimal SMTP server that accepts every message and keeps it for inspection
+type fakeSMTP struct {
+	ln       net.Listener
+	mu       sync.Mutex
+	auth     []string
+	from     []string
+	to       []string
+	messages []string
+	rejectTo string
+}
+
+func newFakeSMTP(t *testing.T) *fakeSMTP {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	f := &fakeSMTP{ln: ln}
+	t.Cleanup(func() { _ = ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			go f.serve(conn)
+		}
+	}()
+
+	return f
+}
+
+func (f *fakeSMTP) port() int {
+	return f.ln.Addr().(*net.TCPAddr).Port
+}
+
+func (f *fakeSMTP) serve(conn net.Conn) {
+	defer func(conn net.Conn) {
+		_ = conn.Close()
+	}(conn)
+
+	tp := textproto.NewConn(conn)
+	_ = tp.PrintfLine("220 fake ESMTP")
+
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+
+		verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+
+		f.mu.Lock()
+		switch verb {
+		case "EHLO":
+			_ = tp.PrintfLine("250-fake\r\n250 AUTH PLAIN")
+		case "AUTH":
+			f.auth = append(f.auth, line)
+			_ = tp.PrintfLine("235 authenticated")
+		case "MAIL":
+			f.from = append(f.from, line)
+			_ = tp.PrintfLine("250 ok")
+		case "RCPT":
+			if f.rejectTo != "" && strings.Contains(line, f.rejectTo) {
+				_ = tp.PrintfLine("550 no such user")
+				break
+			}
+
+			f.to = append(f.to, line)
+			_ = tp.PrintfLine("250 ok")
+		case "DATA":
+			_ = tp.PrintfLine("354 go ahead")
+			f.mu.Unlock()
+			body, err := io.ReadAll(tp.DotReader())
+			f.mu.Lock()
+			if err == nil {
+				f.messages = append(f.messages, string(body))
+			}
+			_ = tp.PrintfLine("250 queued")
+		case "QUIT":
+			_ = tp.PrintfLine("221 bye")
+			f.mu.Unlock()
+			return
+		default:
+			_ = tp.PrintfLine("502 not implemented")
+		}
+		f.mu.Unlock()
+	}
+}
+
+func Test_SMTPSenderSend(t *testing.T) {
+	server := newFakeSMTP(t)
+
+	sender, err := NewSMTPSender(SMTPConfig{Host: "127.0.0.1", Port: server.port(), Username: "bot", Password: "secret",
+		From: "tasks@example.com"})
+	require.NoError(t, err)
+	sender.now = func() time.Time { return time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC) }
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err = sender.Send(ctx, notification.Message{To: "ana@example.com", Subject: "Task assigned: Ship – v2",
+		Text: "Hello Ana", HTML: "<p>Hello Ana</p>"})
+	require.NoError(t, err)
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	require.Equal(t, []string{"AUTH PLAIN " + base64.StdEncoding.EncodeToString([]byte("\x00bot\x00secret"))}, server.auth)
+	require.Equal(t, []string{"MAIL FROM:<tasks@example.com>"}, server.from)
+	require.Equal(t, []string{"RCPT TO:<ana@example.com>"}, server.to)
+	require.Len(t, server.messages, 1)
+
+	msg, err := mail.ReadMessage(strings.NewReader(server.messages[0]))
+	require.NoError(t, err)
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	require.NoError(t, err)
+	require.Equal(t, "Task assigned: Ship – v2", subject)
+	require.Equal(t, "ana@example.com", msg.Header.Get("To"))
+	require.Equal(t, "Wed, 01 May 2024 09:00:00 +0000", msg.Header.Get("Date"))
+	require.True(t, strings.HasSuffix(msg.Header.Get("Message-ID"), "@example.com>"))
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	require.Equal(t, "multipart/alternative", mediaType)
+
+	parts := multipart.NewReader(msg.Body, params["boundary"])
+	for _, exp := range []struct{ mediaType, content string }{
+		{"text/plain; charset=utf-8", "Hello Ana"},
+		{"text/html; charset=utf-8", "<p>Hello Ana</p>"},
+	} {
+		part, err := parts.NextPart()
+		require.NoError(t, err)
+		require.Equal(t, exp.mediaType, part.Header.Get("Content-Type"))
+
+		content, err := io.ReadAll(part)
+		require.NoError(t, err)
+		require.Equal(t, exp.content, string(content))
+	}
+}
+
+func Test_SMTPSenderRejected(t *testing.T) {
+	server := newFakeSMTP(t)
+	server.rejectTo = "ghost@example.com"
+
+	sender, err := NewSMTPSender(SMTPConfig{Host: "127.0.0.1", Port: server.port(), From: "tasks@example.com"})
+	require.NoError(t, err)
+
+	err = sender.Send(context.Background(), notification.Message{To: "ghost@example.com", Subject: "Hi", Text: "Hi", HTML: "Hi"})
+	require.ErrorContains(t, err, "sending mail to ghost@example.com")
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	require.Empty(t, server.messages)
+}
+
+func Test_NewSMTPSender(t *testing.T) {
+	_, err := NewSMTPSender(SMTPConfig{From: "tasks@example.com"})
+	require.Error(t, err)
+
+	sender, err := NewSMTPSender(SMTPConfig{Host: "mail.example.com", From: "tasks@example.com"})
+	require.NoError(t, err)
+	require.Equal(t, 587, sender.cfg.Port)
+}
+
+func Test_LogSender(t *testing.T) {
+	var buf bytes.Buffer
+	ctx := logging.WithLogger(context.Background(), slog.New(slog.NewTextHandler(&buf, nil)))
+
+	m := notification.Message{To: "ana@example.com", Subject: "Confirm your new email", Text: "Your token is s3cret", HTML: "<p>s3cret</p>"}
+	require.NoError(t, LogSender{}.Send(ctx, m))
+
+	require.Contains(t, buf.String(), "to=ana@example.com")
+	require.Contains(t, buf.String(), `subject="Confirm your new email"`)
+	require.NotContains(t, buf.String(), "s3cret")
+}
@@ -1,51 +1,425 @@
 package main
 
 import (
+	"Task_Manager/auth"
+	"Task_Manager/cache"
 	"Task_Manager/config"
+	"Task_Manager/handler/attachment"
+	"Task_Manager/handler/audit"
+	"Task_Manager/handler/authn"
+	"Task_Manager/handler/comment"
+	"Task_Manager/handler/graphql"
+	"Task_Manager/handler/imports"
+	"Task_Manager/handler/label"
+	"Task_Manager/handler/notification"
+	"Task_Manager/handler/rpc"
 	"Task_Manager/handler/task"
 	"Task_Manager/handler/user"
+	"Task_Manager/idempotency"
+	"Task_Manager/jobs"
+	"Task_Manager/logging"
+	"Task_Manager/mail"
+	"Task_Manager/metrics"
+	Task1 "Task_Manager/model/task"
+	User1 "Task_Manager/model/user"
+	"Task_Manager/ratelimit"
+	"Task_Manager/replica"
+	"Task_Manager/request"
+	Attachment2 "Task_Manager/service/attachment"
+	Audit2 "Task_Manager/service/audit"
+	Comment2 "Task_Manager/service/comment"
+	Import2 "Task_Manager/service/imports"
+	Label2 "Task_Manager/service/label"
+	Notification2 "Task_Manager/service/notification"
 	Task2 "Task_Manager/service/task"
 	User2 "Task_Manager/service/user"
+	Attachment3 "Task_Manager/store/attachment"
+	Audit3 "Task_Manager/store/audit"
+	"Task_Manager/store/blob"
+	"Task_Manager/store/cached"
+	Comment3 "Task_Manager/store/comment"
+	Idempotency3 "Task_Manager/store/idempotency"
+	Label3 "Task_Manager/store/label"
+	Notification3 "Task_Manager/store/notification"
+	Ratelimit3 "Task_Manager/store/ratelimit"
 	Task3 "Task_Manager/store/task"
+	"Task_Manager/store/uow"
 	User3 "Task_Manager/store/user"
+	"Task_Manager/tracing"
+	"context"
+	"database/sql"
 	"fmt"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
+	"os"
 
 	_ "Task_Manager/docs"
 	httpSwagger "github.com/swaggo/http-swagger"
 
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/gorilla/mux"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
 )
 
 func main() {
-	config.DataBaseConfig()
+	settings := config.LoadSettings()
+
+	logger, err := logging.New(os.Stdout, settings.LogLevel, settings.LogFormat)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Logging error:", err)
+		os.Exit(1)
+	}
+
+	slog.SetDefault(logger)
+
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Exporter:    settings.TraceExporter,
+		File:        settings.TraceFile,
+		Endpoint:    settings.TraceEndpoint,
+		ServiceName: "task-manager",
+		SampleRatio: settings.TraceSampleRatio,
+	})
+	if err != nil {
+		fatal("Tracing error", err)
+	}
+
+	config.DataBaseConfig(settings)
 	db := config.DB
+	// Route reads to the replicas found healthy, checked again periodically
+	if err := config.Replicas.Check(context.Background()); err != nil {
+		logger.Warn("Unhealthy read replicas", "error", err)
+	}
+
+	go jobs.CheckReplicas(context.Background(), settings.DBReplicaCheckInterval, config.Replicas)
+	unit := uow.New(db)
+	lookups, err := newCache(settings)
+	if err != nil {
+		fatal("Cache error", err)
+	}
+	// Init audit dependencies
+	auditStore := Audit3.NewStore(db)
+	auditService := Audit2.NewService(auditStore)
+	auditHandler := audit.NewHandler(auditService)
 	// Init user dependencies
-	userStore := User3.NewUserStore(db)
+	users := User3.NewUserStore(db)
+	users.SetReplicas(config.Replicas)
+
+	var userStore User2.UserStoreInterface = users
+	if lookups != nil {
+		userStore = cached.NewUserStore(users, lookups)
+	}
+
 	userService := User2.NewUserService(userStore)
+	userService.SetAudit(auditService)
+	userService.SetUnitOfWork(unit)
 	userHandler := user.NewUserHandler(userService)
+	// Init notification dependencies
+	notifier, err := newNotifier(settings)
+	if err != nil {
+		fatal("Notifier error", err)
+	}
+
+	notificationStore := Notification3.NewStore(db)
+	notificationService := Notification2.NewService(notificationStore, userService, notifier, settings.NotificationQueueSize)
+	notificationHandler := notification.NewHandler(notificationService)
+	// The confirmation is sent in the background so a slow mail server does not hold up the request
+	userService.OnEmailChange(func(u User1.User, c User1.EmailChange, token string) {
+		go func() {
+			if err := notificationService.EmailChange(context.Background(), u, c, token); err != nil {
+				logger.Error("Failed to send email change confirmation", "user_id", u.ID, "error", err)
+			}
+		}()
+	})
 	// Init task dependencies
-	taskStore := Task3.NewStore(db)
+	tasks := Task3.NewStore(db)
+	tasks.SetReplicas(config.Replicas)
+
+	var taskStore Task2.TaskStoreInterface = tasks
+	if lookups != nil {
+		taskStore = cached.NewTaskStore(tasks, lookups)
+	}
+
 	taskService := Task2.NewService(taskStore, userService)
+	taskService.SetAudit(auditService)
+	taskService.SetNotifier(notificationService)
+	taskService.SetUnitOfWork(unit)
+	userService.SetTasks(taskService)
+	taskService.SetQuotas(Task1.Quotas{Default: settings.TaskQuotaDefault, Workspaces: settings.TaskQuotas})
 	taskHandler := task.NewHandler(taskService)
+	// Init GraphQL dependencies; subscriptions receive the task changes made through this instance
+	feed := graphql.NewFeed()
+	taskService.OnChange(feed.Publish)
+	graphqlHandler := graphql.NewHandler(taskService, userService, feed)
+	// Init comment dependencies
+	commentStore := Comment3.NewStore(db)
+	commentService := Comment2.NewService(commentStore, taskService, userService)
+	commentHandler := comment.NewHandler(commentService)
+	// Init attachment dependencies
+	blobStore, err := newBlobStore(settings)
+	if err != nil {
+		fatal("Blob store error", err)
+	}
+
+	attachmentStore := Attachment3.NewStore(db)
+	attachmentService := Attachment2.NewService(attachmentStore, blobStore, taskService, Attachment2.Limits{
+		MaxBytes:     settings.AttachmentMaxBytes,
+		AllowedTypes: settings.AttachmentTypes,
+	})
+	attachmentHandler := attachment.NewHandler(attachmentService)
+	taskService.OnPurge(func(id int) {
+		if err := attachmentService.DeleteForTask(context.Background(), id); err != nil {
+			logger.Error("Failed to clean up attachments", "task_id", id, "error", err)
+		}
+	})
+	// Init label dependencies
+	labelStore := Label3.NewStore(db)
+	labelService := Label2.NewService(labelStore, taskService)
+	labelHandler := label.NewHandler(labelService)
+	// Init import dependencies
+	importService := Import2.NewService(taskService, userService)
+	importHandler := imports.NewHandler(importService)
+
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		// Deliver the notifications of imported tasks while the import runs, and those still queued once it ends
+		ctx, stop := context.WithCancel(context.Background())
+		stopped := make(chan struct{})
+		go func() {
+			notificationService.Run(ctx)
+			close(stopped)
+		}()
+		code := runImport(importService, os.Args[2:], os.Stdout, os.Stderr)
+		stop()
+		<-stopped
+		notificationService.Drain(context.Background())
+		_ = shutdownTracing(context.Background())
+		os.Exit(code)
+	}
+
+	registerMetrics(db, taskService)
+	// Purge trash past its retention period
+	go jobs.PurgeTrash(context.Background(), settings.TrashPurgeInterval, settings.TrashRetention, taskService, userService)
+	// Deliver notifications and send digests
+	go notificationService.Run(context.Background())
+	go jobs.SendDigests(context.Background(), settings.DigestInterval, notificationService)
+	// Rate limit clients per route
+	limiter, err := newLimiter(settings, db)
+	if err != nil {
+		fatal("Rate limit error", err)
+	}
+	apiKeys, err := ratelimit.ParseAPIKeys(settings.APIKeyHashes)
+	if err != nil {
+		fatal("API key error", err)
+	}
+	// Replay the responses of retried creations
+	idempotencyStore := Idempotency3.NewStore(db)
+	keys := idempotency.New(idempotencyStore, settings.IdempotencyKeyTTL)
+	go jobs.ExpireIdempotencyKeys(context.Background(), settings.IdempotencyCleanupInterval, settings.IdempotencyKeyTTL, idempotencyStore)
+	// Resolve the acting user of REST requests and gRPC calls
+	authenticator := auth.New(auth.Config{
+		Secret:      []byte(settings.AuthSecret),
+		TrustHeader: settings.AuthTrustHeader,
+		MaxAge:      settings.AuthSignatureMaxAge,
+		AdminIDs:    settings.AdminUserIDs,
+	})
+	if !authenticator.Identifies() {
+		logger.Warn("Neither AUTH_SECRET nor AUTH_TRUST_HEADER is set: requests naming a user are rejected")
+	}
+
 	// Setup router
 	r := mux.NewRouter()
+	r.Use(tracing.Route)
+	r.Use(metrics.Middleware)
+	r.Use(authn.Middleware(authenticator))
+	r.Use(apiKeys.Middleware)
+	r.Use(limiter.Middleware)
+	r.Use(replica.NewSessions(settings.DBReadYourWritesWindow).Middleware)
+	// Metrics route
+	r.Handle("/metrics", metrics.Handler(metrics.Default)).Methods("GET")
 	// Task routes
 	r.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler)
-	r.HandleFunc("/task", taskHandler.Create).Methods("POST")
+	r.Handle("/task", keys.Middleware(http.HandlerFunc(taskHandler.Create))).Methods("POST")
+	r.HandleFunc("/task/bulk", taskHandler.Bulk).Methods("POST")
 	r.HandleFunc("/task/{id}", taskHandler.GetTask).Methods("GET")
 	r.HandleFunc("/task/{id}", taskHandler.Complete).Methods("PUT")
 	r.HandleFunc("/task/{id}", taskHandler.Delete).Methods("DELETE")
 	r.HandleFunc("/task", taskHandler.All).Methods("GET")
 	r.HandleFunc("/task/user/{userid}", taskHandler.GetTasksByUserID).Methods("GET")
+	r.HandleFunc("/task/{id}/restore", taskHandler.Restore).Methods("POST")
+	r.HandleFunc("/task/{id}/revisions", taskHandler.Revisions).Methods("GET")
+	r.HandleFunc("/task/{id}/revisions/diff", taskHandler.DiffRevisions).Methods("GET")
+	r.HandleFunc("/task/{id}/revert", taskHandler.Revert).Methods("POST")
+	r.HandleFunc("/trash/tasks", taskHandler.Trash).Methods("GET")
+	// Comment routes
+	r.HandleFunc("/task/{id}/comments", commentHandler.List).Methods("GET")
+	r.HandleFunc("/task/{id}/comments", commentHandler.Create).Methods("POST")
+	r.HandleFunc("/task/{id}/comments/{commentid}", commentHandler.Edit).Methods("PUT")
+	r.HandleFunc("/task/{id}/comments/{commentid}", commentHandler.Delete).Methods("DELETE")
+	r.HandleFunc("/task/{id}/comments/{commentid}/history", commentHandler.History).Methods("GET")
+	// Attachment routes
+	r.HandleFunc("/task/{id}/attachments", attachmentHandler.List).Methods("GET")
+	r.HandleFunc("/task/{id}/attachments", attachmentHandler.Upload).Methods("POST")
+	r.HandleFunc("/task/{id}/attachments/{attachmentid}", attachmentHandler.Download).Methods("GET", "HEAD")
+	r.HandleFunc("/task/{id}/attachments/{attachmentid}", attachmentHandler.Delete).Methods("DELETE")
+	// Label routes
+	r.HandleFunc("/workspaces/{workspaceid}/labels", labelHandler.ListWorkspace).Methods("GET")
+	r.HandleFunc("/workspaces/{workspaceid}/labels", labelHandler.Create).Methods("POST")
+	r.HandleFunc("/labels/bulk", labelHandler.Relabel).Methods("POST")
+	r.HandleFunc("/labels/{id}", labelHandler.Delete).Methods("DELETE")
+	r.HandleFunc("/task/{id}/labels", labelHandler.ListForTask).Methods("GET")
+	r.HandleFunc("/task/{id}/labels", labelHandler.Attach).Methods("POST")
+	r.HandleFunc("/task/{id}/labels/{labelid}", labelHandler.Detach).Methods("DELETE")
 	// User routes
-	r.HandleFunc("/users", userHandler.CreateUser).Methods("POST")
+	r.Handle("/users", keys.Middleware(http.HandlerFunc(userHandler.CreateUser))).Methods("POST")
 	r.HandleFunc("/users", userHandler.GetAllUsers).Methods("GET")
 	r.HandleFunc("/users/{id}", userHandler.GetUser).Methods("GET")
+	r.HandleFunc("/users/{id}", userHandler.UpdateUser).Methods("PATCH")
 	r.HandleFunc("/users/{id}", userHandler.DeleteUser).Methods("DELETE")
+	r.HandleFunc("/users/{id}/email", userHandler.ChangeEmail).Methods("POST")
+	r.HandleFunc("/users/{id}/email/confirm", userHandler.ConfirmEmail).Methods("POST")
+	r.HandleFunc("/users/{id}/restore", userHandler.RestoreUser).Methods("POST")
+	r.HandleFunc("/users/{id}/notifications", notificationHandler.Get).Methods("GET")
+	r.HandleFunc("/users/{id}/notifications", notificationHandler.Update).Methods("PUT")
+	r.HandleFunc("/trash/users", userHandler.GetTrash).Methods("GET")
+
+	// Import routes
+	r.HandleFunc("/import/tasks", importHandler.Tasks).Methods("POST")
+	r.HandleFunc("/import/users", importHandler.Users).Methods("POST")
+
+	// Audit routes
+	r.HandleFunc("/audit", auditHandler.List).Methods("GET")
+	r.HandleFunc("/audit/export", auditHandler.Export).Methods("GET")
+	r.HandleFunc("/audit/verify", auditHandler.Verify).Methods("GET")
+
+	// GraphQL route; queries sent with GET may read the replicas
+	r.Handle("/graphql", graphqlHandler).Methods("GET", "POST")
+
+	// gRPC server; its calls run through the same services as the routes above
+	lis, err := net.Listen("tcp", settings.GRPCAddr)
+	if err != nil {
+		fatal("gRPC listen error", err)
+	}
+
+	grpcServer := rpc.NewServer(taskService, userService, logger, authenticator)
+
+	go func() {
+		logger.Info("gRPC server running", "addr", settings.GRPCAddr)
+		fatal("gRPC server stopped", grpcServer.Serve(lis))
+	}()
+	// The REST routes annotated in the protobuf definitions, served under /rpc by calling the gRPC server
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		fatal("gRPC gateway error", err)
+	}
+
+	gateway, err := rpc.NewGateway(context.Background(), conn)
+	if err != nil {
+		fatal("gRPC gateway error", err)
+	}
+
+	r.PathPrefix("/rpc/").Handler(http.StripPrefix("/rpc", gateway))
+
+	// Traces, request IDs and access logs wrap the whole router, so unmatched routes are covered too
+	handler := tracing.Middleware(request.Middleware(logging.Middleware(logger)(r)))
+
+	logger.Info("Server running", "addr", "http://localhost:8000")
+	err = http.ListenAndServe(":8000", handler)
+	// Flush the spans of the last requests before exiting
+	_ = shutdownTracing(context.Background())
+	fatal("Server stopped", err)
+}
+
+// fatal logs err and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
+
+// newBlobStore builds the attachment content backend selected in the settings
+func newBlobStore(settings config.Settings) (Attachment2.BlobStore, error) {
+	if settings.AttachmentBackend == "s3" {
+		return blob.NewS3Store(blob.S3Config{
+			Endpoint:  settings.S3Endpoint,
+			Bucket:    settings.S3Bucket,
+			Region:    settings.S3Region,
+			AccessKey: settings.S3AccessKey,
+			SecretKey: settings.S3SecretKey,
+		}, &http.Client{Transport: tracing.Transport(nil)})
+	}
+
+	return blob.NewLocalStore(settings.AttachmentDir)
+}
+
+// newLimiter builds the rate limiter from the configured rules. Buckets kept in the database are shared
+// by every instance and cleaned up once idle.
+func newLimiter(settings config.Settings, db *sql.DB) (*ratelimit.Limiter, error) {
+	rules, err := ratelimit.ParseRules(settings.RateLimits)
+	if err != nil {
+		return nil, err
+	}
+
+	switch settings.RateLimitBackend {
+	case "memory":
+		return ratelimit.New(ratelimit.NewMemory(), rules), nil
+	case "db":
+		store := Ratelimit3.NewStore(db)
+		limiter := ratelimit.New(store, rules)
+		go jobs.CleanRateLimits(context.Background(), settings.RateLimitCleanupInterval, limiter.Idle(), store)
+
+		return limiter, nil
+	default:
+		return nil, fmt.Errorf("unknown rate limit backend %q", settings.RateLimitBackend)
+	}
+}
+
+// newCache builds the cache of task and user lookups, nil when caching is off
+func newCache(settings config.Settings) (*cache.Cache, error) {
+	switch settings.CacheBackend {
+	case "none":
+		return nil, nil
+	case "memory":
+		return cache.New(cache.NewLRU(settings.CacheSize), settings.CacheTTL), nil
+	case "redis":
+		return cache.New(cache.NewRedis(cache.RedisConfig{
+			Addr:     settings.RedisAddr,
+			Password: settings.RedisPassword,
+			DB:       settings.RedisDB,
+		}), settings.CacheTTL), nil
+	default:
+		return nil, fmt.Errorf("unknown cache backend %q", settings.CacheBackend)
+	}
+}
+
+// newNotifier builds the mail backend of notifications, logging messages when no SMTP server is set
+func newNotifier(settings config.Settings) (Notification2.Notifier, error) {
+	if settings.SMTPHost == "" {
+		return mail.LogSender{}, nil
+	}
+
+	return mail.NewSMTPSender(mail.SMTPConfig{
+		Host:     settings.SMTPHost,
+		Port:     settings.SMTPPort,
+		Username: settings.SMTPUsername,
+		Password: settings.SMTPPassword,
+		From:     settings.SMTPFrom,
+	})
+}
+
+// registerMetrics exposes the database pool statistics, the health of the replicas and the business gauges
+// computed at scrape time
+func registerMetrics(db *sql.DB, taskService *Task2.TaskService) {
+	metrics.RegisterDBStats(metrics.Default, db)
+	config.Replicas.Register(metrics.Default)
+	metrics.Default.NewGaugeFunc("tasks", "Live tasks, by status", []string{"status"}, func(emit metrics.Emit) error {
+		counts, err := taskService.CountByStatus(context.Background())
+		if err != nil {
+			return err
+		}
+
+		emit(float64(counts[false]), "open")
+		emit(float64(counts[true]), "done")
 
-	fmt.Println("Server running at http://localhost:8000")
-	log.Fatal(http.ListenAndServe(":8000", r))
+		return nil
+	})
 }
@@ -0,0 +1,58 @@
+package metrics
+
+import (
+	"database/sql"
+	"time"
+)
+
+var queryDuration = Default.NewHistogramVec("store_query_duration_seconds",
+	"Time taken by store methods, including every query they run, by store and method", DefaultBuckets, "store", "method")
+
+// QueryTimer returns a function timing the methods of a store. Each method starts with
+//
+//	defer observe("CreateTask")()
+//
+// where observe is the function returned for its store.
+func QueryTimer(store string) func(method string) func() {
+	return func(method string) func() {
+		start := time.Now()
+
+		return func() {
+			queryDuration.Observe(time.Since(start).Seconds(), store, method)
+		}
+	}
+}
+
+// RegisterDBStats exposes the connection pool statistics of db
+func RegisterDBStats(reg *Registry, db *sql.DB) {
+	gauge := func(name, help string, value func(sql.DBStats) float64) {
+		reg.NewGaugeFunc(name, help, nil, func(emit Emit) error {
+			emit(value(db.Stats()))
+			return nil
+		})
+	}
+
+	counter := func(name, help string, value func(sql.DBStats) float64) {
+		reg.NewCounterFunc(name, help, nil, func(emit Emit) error {
+			emit(value(db.Stats()))
+			return nil
+		})
+	}
+
+	gauge("db_connections_max", "Maximum number of open connections to the database, 0 for unlimited",
+		func(s sql.DBStats) float64 { return float64(s.MaxOpenConnections) })
+	gauge("db_connections_open", "Connections to the database, in use or idle",
+		func(s sql.DBStats) float64 { return float64(s.OpenConnections) })
+	gauge("db_connections_in_use", "Connections to the database currently in use",
+		func(s sql.DBStats) float64 { return float64(s.InUse) })
+	gauge("db_connections_idle", "Idle connections to the database",
+		func(s sql.DBStats) float64 { return float64(s.Idle) })
+	counter("db_wait_count_total", "Times a query waited for a free connection",
+		func(s sql.DBStats) float64 { return float64(s.WaitCount) })
+	counter("db_wait_duration_seconds_total", "Time spent waiting for a free connection",
+		func(s sql.DBStats) float64 { return s.WaitDuration.Seconds() })
+	counter("db_connections_closed_max_idle_total", "Connections closed because the idle pool was full",
+		func(s sql.DBStats) float64 { return float64(s.MaxIdleClosed) })
+	counter("db_connections_closed_max_lifetime_total", "Connections closed because they reached their maximum lifetime",
+		func(s sql.DBStats) float64 { return float64(s.MaxLifetimeClosed) })
+}
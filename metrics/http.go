@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"Task_Manager/logging"
+	"Task_Manager/request"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// ContentType is the media type of the text exposition format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+var (
+	httpRequests = Default.NewCounterVec("http_requests_total",
+		"HTTP requests served, by method, route template and status", "method", "route", "status")
+	httpDuration = Default.NewHistogramVec("http_request_duration_seconds",
+		"Time taken to serve HTTP requests, by method, route template and status", DefaultBuckets, "method", "route", "status")
+)
+
+// Middleware counts and times requests. It labels them with the route template rather than the path,
+// so /task/{id} is one series however many tasks there are; it must therefore run as router middleware.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := request.NewStatusWriter(w)
+
+		next.ServeHTTP(sw, r)
+
+		if sw.Status == 0 {
+			sw.Status = http.StatusOK
+		}
+
+		route := "unmatched"
+		if current := mux.CurrentRoute(r); current != nil {
+			if tpl, err := current.GetPathTemplate(); err == nil {
+				route = tpl
+			}
+		}
+
+		status := strconv.Itoa(sw.Status)
+		httpRequests.Inc(r.Method, route, status)
+		httpDuration.Observe(time.Since(start).Seconds(), r.Method, route, status)
+	})
+}
+
+// Handler serves the metrics of the registry (GET /metrics)
+func Handler(reg *Registry) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", ContentType)
+
+		// Families that failed to collect are left out, so the rest can still be scraped
+		if err := reg.Write(w); err != nil {
+			logging.FromContext(r.Context()).Error("metrics collection failed", "error", err)
+		}
+	})
+}
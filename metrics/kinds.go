@@ -0,0 +1,160 @@
+package metrics
+
+import (
+	"bytes"
+	"sync"
+)
+
+// CounterVec is a counter per combination of label values
+type CounterVec struct {
+	desc
+	mu     sync.Mutex
+	series map[string]*counter
+}
+
+type counter struct {
+	values []string
+	value  float64
+}
+
+// NewCounterVec registers a counter with the given label names
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{desc: desc{name: name, help: help, kind: "counter", labels: labels}, series: make(map[string]*counter)}
+	r.register(c)
+
+	return c
+}
+
+// Inc adds one to the counter of the label values
+func (c *CounterVec) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Add adds v, which must not be negative, to the counter of the label values
+func (c *CounterVec) Add(v float64, values ...string) {
+	checkValues(&c.desc, values)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := seriesKey(values)
+
+	s, ok := c.series[key]
+	if !ok {
+		s = &counter{values: append([]string(nil), values...)}
+		c.series[key] = s
+	}
+
+	s.value += v
+}
+
+func (c *CounterVec) write(buf *bytes.Buffer) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range sortedKeys(c.series) {
+		s := c.series[key]
+		writeSample(buf, c.name, c.labels, s.values, [2]string{}, s.value)
+	}
+
+	return nil
+}
+
+// HistogramVec counts observations into buckets per combination of label values
+type HistogramVec struct {
+	desc
+	buckets []float64
+	mu      sync.Mutex
+	series  map[string]*histogram
+}
+
+type histogram struct {
+	values []string
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// NewHistogramVec registers a histogram with the given upper bounds, in increasing order, and label names
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	h := &HistogramVec{desc: desc{name: name, help: help, kind: "histogram", labels: labels}, buckets: buckets,
+		series: make(map[string]*histogram)}
+	r.register(h)
+
+	return h
+}
+
+// Observe records v in the histogram of the label values
+func (h *HistogramVec) Observe(v float64, values ...string) {
+	checkValues(&h.desc, values)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	key := seriesKey(values)
+
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogram{values: append([]string(nil), values...), counts: make([]uint64, len(h.buckets))}
+		h.series[key] = s
+	}
+
+	for i, upper := range h.buckets {
+		if v <= upper {
+			s.counts[i]++
+			break
+		}
+	}
+
+	s.sum += v
+	s.count++
+}
+
+func (h *HistogramVec) write(buf *bytes.Buffer) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, key := range sortedKeys(h.series) {
+		s := h.series[key]
+
+		// Buckets are cumulative in the exposition format
+		var cumulative uint64
+		for i, upper := range h.buckets {
+			cumulative += s.counts[i]
+			writeSample(buf, h.name+"_bucket", h.labels, s.values, [2]string{"le", formatFloat(upper)}, float64(cumulative))
+		}
+
+		writeSample(buf, h.name+"_bucket", h.labels, s.values, [2]string{"le", "+Inf"}, float64(s.count))
+		writeSample(buf, h.name+"_sum", h.labels, s.values, [2]string{}, s.sum)
+		writeSample(buf, h.name+"_count", h.labels, s.values, [2]string{}, float64(s.count))
+	}
+
+	return nil
+}
+
+// Emit reports one sample of a function metric
+type Emit func(v float64, values ...string)
+
+// funcMetric reads its samples when scraped, for values kept elsewhere such as pool statistics or counts
+// in the database
+type funcMetric struct {
+	desc
+	collect func(emit Emit) error
+}
+
+// NewGaugeFunc registers a gauge whose samples are reported by collect at every scrape
+func (r *Registry) NewGaugeFunc(name, help string, labels []string, collect func(emit Emit) error) {
+	r.register(&funcMetric{desc: desc{name: name, help: help, kind: "gauge", labels: labels}, collect: collect})
+}
+
+// NewCounterFunc registers a counter whose samples are reported by collect at every scrape
+func (r *Registry) NewCounterFunc(name, help string, labels []string, collect func(emit Emit) error) {
+	r.register(&funcMetric{desc: desc{name: name, help: help, kind: "counter", labels: labels}, collect: collect})
+}
+
+func (f *funcMetric) write(buf *bytes.Buffer) error {
+	return f.collect(func(v float64, values ...string) {
+		checkValues(&f.desc, values)
+		writeSample(buf, f.name, f.labels, values, [2]string{}, v)
+	})
+}
@@ -0,0 +1,166 @@
+// Package metrics collects counters, gauges and histograms and serves them in the Prometheus text
+// exposition format. It covers the few metric kinds this service needs without a client library.
+package metrics
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets are the upper bounds, in seconds, of latency histograms
+var DefaultBuckets = []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Default is the registry served on /metrics
+var Default = NewRegistry()
+
+// Registry holds metric families by name
+type Registry struct {
+	mu       sync.Mutex
+	families map[string]family
+}
+
+// family is one named metric with its samples
+type family interface {
+	// write appends the samples of the family, without the HELP and TYPE lines, to buf
+	write(buf *bytes.Buffer) error
+	meta() *desc
+}
+
+type desc struct {
+	name   string
+	help   string
+	kind   string
+	labels []string
+}
+
+func (d *desc) meta() *desc { return d }
+
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]family)}
+}
+
+// register adds f, panicking on a name already taken since that is a programming error
+func (r *Registry) register(f family) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	name := f.meta().name
+	if _, ok := r.families[name]; ok {
+		panic("metrics: " + name + " registered twice")
+	}
+
+	r.families[name] = f
+}
+
+// Write writes every family in the text exposition format, sorted by name. A family whose samples
+// cannot be collected is left out and its error returned once the others are written.
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.Lock()
+	families := make([]family, 0, len(r.families))
+	for _, f := range r.families {
+		families = append(families, f)
+	}
+	r.mu.Unlock()
+
+	sort.Slice(families, func(i, j int) bool { return families[i].meta().name < families[j].meta().name })
+
+	var (
+		out      bytes.Buffer
+		failures error
+	)
+
+	for _, f := range families {
+		var samples bytes.Buffer
+		if err := f.write(&samples); err != nil {
+			failures = errors.Join(failures, fmt.Errorf("collecting %s: %w", f.meta().name, err))
+			continue
+		}
+
+		d := f.meta()
+		fmt.Fprintf(&out, "# HELP %s %s\n# TYPE %s %s\n", d.name, helpEscaper.Replace(d.help), d.name, d.kind)
+		out.Write(samples.Bytes())
+	}
+
+	if _, err := w.Write(out.Bytes()); err != nil {
+		return err
+	}
+
+	return failures
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+// writeSample appends one sample line. extra is an additional label, such as the le of a bucket.
+func writeSample(buf *bytes.Buffer, name string, labels, values []string, extra [2]string, v float64) {
+	buf.WriteString(name)
+
+	if len(labels) > 0 || extra[0] != "" {
+		buf.WriteByte('{')
+
+		for i, l := range labels {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+
+			fmt.Fprintf(buf, `%s="%s"`, l, labelEscaper.Replace(values[i]))
+		}
+
+		if extra[0] != "" {
+			if len(labels) > 0 {
+				buf.WriteByte(',')
+			}
+
+			fmt.Fprintf(buf, `%s="%s"`, extra[0], extra[1])
+		}
+
+		buf.WriteByte('}')
+	}
+
+	buf.WriteByte(' ')
+	buf.WriteString(formatFloat(v))
+	buf.WriteByte('\n')
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+// seriesKey identifies a combination of label values
+func seriesKey(values []string) string {
+	return strings.Join(values, "\xff")
+}
+
+func checkValues(d *desc, values []string) {
+	if len(values) != len(d.labels) {
+		panic(fmt.Sprintf("metrics: %s takes %d label values, got %d", d.name, len(d.labels), len(values)))
+	}
+}
+
+// sortedKeys returns the keys of m in order, so series are written in a stable order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
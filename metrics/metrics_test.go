@@ -0,0 +1,147 @@
+package metrics
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_RegistryWrite(t *testing.T) {
+	reg := NewRegistry()
+
+	requests := reg.NewCounterVec("requests_total", "Requests\nserved", "route", "status")
+	requests.Inc("/task/{id}", "200")
+	requests.Add(2, "/task/{id}", "200")
+	requests.Inc(`/say "hi"`, "404")
+
+	latency := reg.NewHistogramVec("latency_seconds", "Latency", []float64{0.1, 1}, "route")
+	latency.Observe(0.05, "/task")
+	latency.Observe(0.5, "/task")
+	latency.Observe(3, "/task")
+
+	reg.NewGaugeFunc("tasks", "Live tasks", []string{"status"}, func(emit Emit) error {
+		emit(3, "open")
+		emit(1, "done")
+		return nil
+	})
+
+	var buf bytes.Buffer
+	require.NoError(t, reg.Write(&buf))
+
+	assert.Equal(t, `# HELP latency_seconds Latency
+# TYPE latency_seconds histogram
+latency_seconds_bucket{route="/task",le="0.1"} 1
+latency_seconds_bucket{route="/task",le="1"} 2
+latency_seconds_bucket{route="/task",le="+Inf"} 3
+latency_seconds_sum{route="/task"} 3.55
+latency_seconds_count{route="/task"} 3
+# HELP requests_total Requests\nserved
+# TYPE requests_total counter
+requests_total{route="/say \"hi\"",status="404"} 1
+requests_total{route="/task/{id}",status="200"} 3
+# HELP tasks Live tasks
+# TYPE tasks gauge
+tasks{status="open"} 3
+tasks{status="done"} 1
+`, buf.String())
+}
+
+func Test_RegistryWriteSkipsFailedFamilies(t *testing.T) {
+	reg := NewRegistry()
+
+	reg.NewGaugeFunc("broken", "Fails", nil, func(Emit) error { return errors.New("db down") })
+	reg.NewGaugeFunc("working", "Works", nil, func(emit Emit) error {
+		emit(1)
+		return nil
+	})
+
+	var buf bytes.Buffer
+	err := reg.Write(&buf)
+
+	assert.ErrorContains(t, err, "collecting broken: db down")
+	assert.Equal(t, "# HELP working Works\n# TYPE working gauge\nworking 1\n", buf.String())
+}
+
+func Test_RegisterTwicePanics(t *testing.T) {
+	reg := NewRegistry()
+	reg.NewCounterVec("requests_total", "Requests")
+
+	assert.Panics(t, func() { reg.NewCounterVec("requests_total", "Requests") })
+	assert.Panics(t, func() { reg.NewCounterVec("other_total", "Other", "route").Inc() })
+}
+
+func Test_Middleware(t *testing.T) {
+	r := mux.NewRouter()
+	r.Use(Middleware)
+	r.HandleFunc("/metrics-test/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}).Methods("GET")
+	r.Handle("/metrics", Handler(Default)).Methods("GET")
+
+	for _, id := range []string{"1", "2"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/metrics-test/"+id, nil))
+	}
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, ContentType, rec.Header().Get("Content-Type"))
+
+	body := rec.Body.String()
+	assert.Contains(t, body, `http_requests_total{method="GET",route="/metrics-test/{id}",status="418"} 2`)
+	assert.Contains(t, body, `http_request_duration_seconds_count{method="GET",route="/metrics-test/{id}",status="418"} 2`)
+	assert.NotContains(t, body, "/metrics-test/1")
+}
+
+func Test_HandlerMethod(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Handler(NewRegistry()).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/metrics", nil))
+
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func Test_QueryTimer(t *testing.T) {
+	observe := QueryTimer("task-test")
+	observe("CreateTask")()
+	observe("CreateTask")()
+
+	var buf bytes.Buffer
+	require.NoError(t, Default.Write(&buf))
+
+	assert.Contains(t, buf.String(), `store_query_duration_seconds_count{store="task-test",method="CreateTask"} 2`)
+}
+
+func Test_RegisterDBStats(t *testing.T) {
+	db, _, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	db.SetMaxOpenConns(10)
+
+	reg := NewRegistry()
+	RegisterDBStats(reg, db)
+
+	var buf bytes.Buffer
+	require.NoError(t, reg.Write(&buf))
+
+	out := buf.String()
+	for _, line := range []string{
+		"db_connections_max 10",
+		"db_connections_in_use 0",
+		"# TYPE db_wait_count_total counter",
+		"db_wait_duration_seconds_total 0",
+	} {
+		assert.Contains(t, out, line)
+	}
+
+	assert.Equal(t, 8, strings.Count(out, "# TYPE "))
+}
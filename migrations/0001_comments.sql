@@ -0,0 +1,22 @@
+CREATE TABLE comments (
+    id         INT AUTO_INCREMENT PRIMARY KEY,
+    task_id    INT          NOT NULL,
+    parent_id  INT          NULL,
+    author_id  INT          NOT NULL,
+    body       TEXT         NOT NULL,
+    created_at DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    edited_at  DATETIME     NULL,
+    deleted_at DATETIME     NULL,
+    INDEX idx_comments_task (task_id),
+    FOREIGN KEY (parent_id) REFERENCES comments (id)
+);
+
+CREATE TABLE comment_edits (
+    id         INT AUTO_INCREMENT PRIMARY KEY,
+    comment_id INT      NOT NULL,
+    editor_id  INT      NOT NULL,
+    body       TEXT     NOT NULL,
+    edited_at  DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_comment_edits_comment (comment_id),
+    FOREIGN KEY (comment_id) REFERENCES comments (id)
+);
@@ -0,0 +1,12 @@
+CREATE TABLE attachments (
+    id           INT AUTO_INCREMENT PRIMARY KEY,
+    task_id      INT          NOT NULL,
+    filename     VARCHAR(255) NOT NULL,
+    content_type VARCHAR(127) NOT NULL,
+    size         BIGINT       NOT NULL,
+    checksum     CHAR(64)     NOT NULL,
+    storage_key  VARCHAR(255) NOT NULL UNIQUE,
+    uploader_id  INT          NULL,
+    created_at   DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_attachments_task (task_id)
+);
@@ -0,0 +1,16 @@
+CREATE TABLE labels (
+    id           INT AUTO_INCREMENT PRIMARY KEY,
+    workspace_id INT         NOT NULL,
+    name         VARCHAR(50) NOT NULL,
+    color        CHAR(7)     NOT NULL,
+    UNIQUE KEY uq_labels_workspace_name (workspace_id, name)
+);
+
+CREATE TABLE task_labels (
+    task_id  INT NOT NULL,
+    label_id INT NOT NULL,
+    PRIMARY KEY (task_id, label_id),
+    INDEX idx_task_labels_label (label_id),
+    FOREIGN KEY (task_id) REFERENCES tasks (id) ON DELETE CASCADE,
+    FOREIGN KEY (label_id) REFERENCES labels (id) ON DELETE CASCADE
+);
@@ -0,0 +1,7 @@
+ALTER TABLE tasks
+    ADD COLUMN deleted_at DATETIME NULL,
+    ADD INDEX idx_tasks_deleted_at (deleted_at);
+
+ALTER TABLE users
+    ADD COLUMN deleted_at DATETIME NULL,
+    ADD INDEX idx_users_deleted_at (deleted_at);
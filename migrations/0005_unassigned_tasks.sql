@@ -0,0 +1,2 @@
+-- Tasks left behind by a user deleted with the "unassign" policy have no assignee
+ALTER TABLE tasks MODIFY userid INT NULL;
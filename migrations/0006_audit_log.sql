@@ -0,0 +1,31 @@
+CREATE TABLE audit_log (
+    id         BIGINT AUTO_INCREMENT PRIMARY KEY,
+    at         DATETIME(6)  NOT NULL,
+    actor_id   INT          NOT NULL,
+    action     VARCHAR(32)  NOT NULL,
+    entity     VARCHAR(32)  NOT NULL,
+    entity_id  INT          NOT NULL,
+    -- TEXT rather than JSON: MySQL normalises JSON values, which would change the hashed bytes
+    changes    TEXT         NULL,
+    request_id VARCHAR(128) NOT NULL DEFAULT '',
+    source_ip  VARCHAR(45)  NOT NULL DEFAULT '',
+    prev_hash  CHAR(64)     NOT NULL,
+    hash       CHAR(64)     NOT NULL,
+    INDEX idx_audit_entity (entity, entity_id),
+    INDEX idx_audit_actor (actor_id),
+    INDEX idx_audit_at (at)
+);
+
+-- Latest hash of the chain; locking this row serialises appends
+CREATE TABLE audit_head (
+    id   TINYINT PRIMARY KEY,
+    hash CHAR(64) NOT NULL
+);
+
+INSERT INTO audit_head (id, hash) VALUES (1, '');
+
+CREATE TRIGGER audit_log_no_update BEFORE UPDATE ON audit_log
+    FOR EACH ROW SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit_log is append-only';
+
+CREATE TRIGGER audit_log_no_delete BEFORE DELETE ON audit_log
+    FOR EACH ROW SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit_log is append-only';
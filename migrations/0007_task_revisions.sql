@@ -0,0 +1,15 @@
+CREATE TABLE task_revisions (
+    task_id     INT          NOT NULL,
+    revision    INT          NOT NULL,
+    at          DATETIME(6)  NOT NULL,
+    deleted     BOOLEAN      NOT NULL,
+    description VARCHAR(255) NOT NULL,
+    status      BOOLEAN      NOT NULL,
+    userid      INT          NULL,
+    PRIMARY KEY (task_id, revision),
+    INDEX idx_task_revisions_at (task_id, at)
+);
+
+-- Existing tasks start their history with their current state
+INSERT INTO task_revisions (task_id, revision, at, deleted, description, status, userid)
+SELECT id, 1, NOW(6), deleted_at IS NOT NULL, description, status, userid FROM tasks;
@@ -0,0 +1,3 @@
+-- Optional due dates, exported as the DUE of iCalendar to-dos; revisions track them like any other field
+ALTER TABLE tasks ADD COLUMN due_at DATETIME NULL;
+ALTER TABLE task_revisions ADD COLUMN due_at DATETIME NULL;
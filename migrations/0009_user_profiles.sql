@@ -0,0 +1,20 @@
+-- Emails are compared case-insensitively, so existing addresses are stored in their normalised form.
+-- Live users sharing an address after this step must be merged by hand before the unique index can be added.
+UPDATE users SET email = LOWER(TRIM(email));
+
+-- live_email is NULL for users in the trash, so only live users hold on to their address
+ALTER TABLE users
+    ADD COLUMN display_name VARCHAR(100)  NOT NULL DEFAULT '',
+    ADD COLUMN avatar_url   VARCHAR(2048) NOT NULL DEFAULT '',
+    ADD COLUMN timezone     VARCHAR(64)   NOT NULL DEFAULT '',
+    ADD COLUMN live_email   VARCHAR(254) AS (IF(deleted_at IS NULL, email, NULL)) STORED,
+    ADD UNIQUE INDEX ux_users_live_email (live_email);
+
+-- At most one pending email change per user; a new request replaces the previous one
+CREATE TABLE email_changes (
+    user_id    INT          NOT NULL PRIMARY KEY,
+    email      VARCHAR(254) NOT NULL,
+    token_hash CHAR(64)     NOT NULL,
+    expires_at DATETIME     NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
@@ -0,0 +1,22 @@
+-- Users without a row here get the default of immediate delivery for every kind of event
+CREATE TABLE notification_preferences (
+    user_id        INT         NOT NULL PRIMARY KEY,
+    task_assigned  VARCHAR(16) NOT NULL,
+    task_completed VARCHAR(16) NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+-- Events waiting for the next digest; rows are removed once the digest is sent
+CREATE TABLE notification_digest (
+    id          BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id     INT          NOT NULL,
+    kind        VARCHAR(32)  NOT NULL,
+    actor_id    INT          NULL,
+    task_id     INT          NOT NULL,
+    description TEXT         NOT NULL,
+    status      BOOLEAN      NOT NULL,
+    due_at      DATETIME     NULL,
+    at          DATETIME     NOT NULL,
+    INDEX idx_notification_digest_user (user_id, id),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
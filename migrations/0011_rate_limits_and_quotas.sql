@@ -0,0 +1,13 @@
+-- Token buckets of the database rate limit backend, one per client and rule. Rows unused for longer
+-- than the longest refill time are full again and are removed periodically.
+CREATE TABLE rate_limit_buckets (
+    bucket_key VARCHAR(191) NOT NULL PRIMARY KEY,
+    tokens     DOUBLE       NOT NULL,
+    updated_at DATETIME(6)  NULL,
+    INDEX idx_rate_limit_buckets_updated (updated_at)
+);
+
+-- Tasks may belong to a workspace, whose task quota they count against
+ALTER TABLE tasks
+    ADD COLUMN workspace_id INT NULL,
+    ADD INDEX idx_tasks_workspace (workspace_id);
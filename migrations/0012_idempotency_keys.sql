@@ -0,0 +1,12 @@
+-- Requests sent with an Idempotency-Key header, with the response replayed to their retries.
+-- idempotency_key hashes the client, route and header value; status stays 0 while the first
+-- request is being served. Rows expire after the configured window.
+CREATE TABLE idempotency_keys (
+    idempotency_key CHAR(64)     NOT NULL PRIMARY KEY,
+    fingerprint     CHAR(64)     NOT NULL,
+    status          SMALLINT     NOT NULL DEFAULT 0,
+    content_type    VARCHAR(255) NOT NULL DEFAULT '',
+    body            MEDIUMBLOB   NULL,
+    created_at      DATETIME(6)  NOT NULL,
+    INDEX idx_idempotency_keys_created (created_at)
+);
@@ -0,0 +1,5 @@
+-- One row per workspace with a task quota, locked by each creation while it counts the tasks of the
+-- workspace and inserts, so that concurrent creations cannot all pass the quota check
+CREATE TABLE workspace_quota_locks (
+    workspace_id INT NOT NULL PRIMARY KEY
+);
@@ -0,0 +1,37 @@
+package attachment
+
+import (
+	"Task_Manager/model/errs"
+	"time"
+)
+
+// Attachment describes a file uploaded to a task. The content itself lives in a blob store under StorageKey.
+type Attachment struct {
+	ID          int       `json:"id"`
+	TaskID      int       `json:"taskid"`
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	Checksum    string    `json:"sha256"`
+	StorageKey  string    `json:"-"`
+	UploaderID  int       `json:"uploaderid,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+var (
+	ErrMissingFilename = &errs.Validation{Code: "attachment_filename_required", Message: "attachment filename cannot be empty",
+		Fields: []errs.FieldError{{Field: "filename", Message: "cannot be empty"}}}
+	ErrTooLarge         = &errs.TooLarge{Code: "attachment_too_large", Message: "attachment exceeds the maximum allowed size"}
+	ErrTypeNotAllowed   = &errs.Unsupported{Code: "attachment_type_not_allowed", Message: "attachment type is not allowed"}
+	ErrChecksumMismatch = &errs.Validation{Code: "attachment_checksum_mismatch", Message: "attachment checksum does not match its content"}
+	ErrNotFound         = &errs.NotFound{Code: "attachment_not_found", Message: "attachment not found"}
+	ErrForbidden        = &errs.Forbidden{Code: "attachment_forbidden", Message: "only signed in users can upload, and only the uploader or an admin can delete"}
+)
+
+func (a *Attachment) Validate() error {
+	if a.Filename == "" {
+		return ErrMissingFilename
+	}
+
+	return nil
+}
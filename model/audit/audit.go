@@ -0,0 +1,173 @@
+package audit
+
+import (
+	"Task_Manager/model/errs"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"reflect"
+	"time"
+)
+
+// Actions recorded in the audit log
+const (
+	ActionCreate   = "create"
+	ActionUpdate   = "update"
+	ActionComplete = "complete"
+	ActionDelete   = "delete"
+	ActionRestore  = "restore"
+	ActionPurge    = "purge"
+	ActionReassign = "reassign"
+	ActionUnassign = "unassign"
+	ActionRevert   = "revert"
+)
+
+// Audited entities
+const (
+	EntityTask = "task"
+	EntityUser = "user"
+)
+
+// MaxLimit bounds the number of entries returned by one query
+const MaxLimit = 500
+
+// Entry is one append-only audit record. Hash covers every other field and the hash of the previous entry,
+// so altering or removing a stored entry breaks the chain from that point on.
+type Entry struct {
+	ID        int64     `json:"id"`
+	At        time.Time `json:"at"`
+	ActorID   int       `json:"actor_id"`
+	Action    string    `json:"action"`
+	Entity    string    `json:"entity"`
+	EntityID  int       `json:"entity_id"`
+	Changes   Changes   `json:"changes,omitempty"`
+	RequestID string    `json:"request_id,omitempty"`
+	SourceIP  string    `json:"source_ip,omitempty"`
+	PrevHash  string    `json:"prev_hash"`
+	Hash      string    `json:"hash"`
+}
+
+// Changes is the JSON encoded field-level diff of an entity, mapping each changed field to a Change.
+// It is kept as raw JSON so the bytes hashed on write are exactly the bytes read back.
+type Changes = json.RawMessage
+
+// Change is the value of one field before and after a mutation
+type Change struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// Filter selects audit entries. Zero values match everything; AfterID pages through results in ID order.
+type Filter struct {
+	ActorID  int
+	Action   string
+	Entity   string
+	EntityID int
+	Since    time.Time
+	Until    time.Time
+	AfterID  int64
+	Limit    int
+}
+
+// VerifyReport is the outcome of checking the hash chain. BrokenAt is the ID of the first entry that
+// fails the check, or the ID following the last entry when entries are missing from the end of the log.
+type VerifyReport struct {
+	OK       bool  `json:"ok"`
+	Entries  int   `json:"entries"`
+	BrokenAt int64 `json:"broken_at,omitempty"`
+}
+
+var (
+	ErrForbidden     = &errs.Forbidden{Code: "audit_forbidden", Message: "only admins can read the audit log"}
+	ErrInvalidFilter = &errs.Validation{Code: "audit_filter_invalid", Message: "invalid audit filter"}
+)
+
+// ComputeHash returns the chain hash of the entry, ignoring its ID and current Hash
+func (e Entry) ComputeHash() string {
+	fields, _ := json.Marshal([]any{
+		e.PrevHash,
+		e.At.UTC().Format(time.RFC3339Nano),
+		e.ActorID,
+		e.Action,
+		e.Entity,
+		e.EntityID,
+		string(e.Changes),
+		e.RequestID,
+		e.SourceIP,
+	})
+
+	sum := sha256.Sum256(fields)
+
+	return hex.EncodeToString(sum[:])
+}
+
+// Follows reports whether the entry is intact and chained onto the entry with the given hash
+func (e Entry) Follows(prevHash string) bool {
+	return e.PrevHash == prevHash && e.Hash == e.ComputeHash()
+}
+
+// Diff returns the fields that differ between the JSON forms of before and after. Either side may be nil,
+// for example on create or delete. The result is nil when nothing changed.
+func Diff(before, after any) (Changes, error) {
+	b, err := fields(before)
+	if err != nil {
+		return nil, err
+	}
+
+	a, err := fields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]Change)
+
+	for k, v := range b {
+		if !reflect.DeepEqual(v, a[k]) {
+			changes[k] = Change{Before: v, After: a[k]}
+		}
+	}
+
+	for k, v := range a {
+		if _, ok := b[k]; !ok {
+			changes[k] = Change{Before: nil, After: v}
+		}
+	}
+
+	if len(changes) == 0 {
+		return nil, nil
+	}
+
+	// Maps are marshalled with sorted keys, which keeps the hashed bytes deterministic
+	return json.Marshal(changes)
+}
+
+func fields(v any) (map[string]any, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var m map[string]any
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// Validate checks the filter bounds
+func (f Filter) Validate() error {
+	if f.Limit < 0 || f.Limit > MaxLimit || f.AfterID < 0 {
+		return ErrInvalidFilter
+	}
+
+	if !f.Since.IsZero() && !f.Until.IsZero() && f.Until.Before(f.Since) {
+		return ErrInvalidFilter
+	}
+
+	return nil
+}
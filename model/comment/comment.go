@@ -0,0 +1,53 @@
+package comment
+
+import (
+	"Task_Manager/model/errs"
+	"time"
+)
+
+// MaxBodyLength bounds the size of a comment body in bytes
+const MaxBodyLength = 10000
+
+// Comment is a Markdown note left on a task. ParentID is set for replies.
+type Comment struct {
+	ID        int        `json:"id"`
+	TaskID    int        `json:"taskid"`
+	ParentID  *int       `json:"parentid,omitempty"`
+	AuthorID  int        `json:"authorid"`
+	Body      string     `json:"body"`
+	CreatedAt time.Time  `json:"created_at"`
+	EditedAt  *time.Time `json:"edited_at,omitempty"`
+	Deleted   bool       `json:"deleted"`
+	Replies   []Comment  `json:"replies,omitempty"`
+}
+
+// Edit is a previous version of a comment body, recorded every time the comment is changed
+type Edit struct {
+	CommentID int       `json:"commentid"`
+	EditorID  int       `json:"editorid"`
+	Body      string    `json:"body"`
+	EditedAt  time.Time `json:"edited_at"`
+}
+
+var (
+	ErrEmptyBody = &errs.Validation{Code: "comment_body_required", Message: "comment body cannot be empty",
+		Fields: []errs.FieldError{{Field: "body", Message: "cannot be empty"}}}
+	ErrBodyTooLong = &errs.Validation{Code: "comment_body_too_long", Message: "comment body is too long",
+		Fields: []errs.FieldError{{Field: "body", Message: "is too long"}}}
+	ErrNotFound      = &errs.NotFound{Code: "comment_not_found", Message: "comment not found"}
+	ErrForbidden     = &errs.Forbidden{Code: "comment_forbidden", Message: "only the author or an admin can modify this comment"}
+	ErrInvalidParent = &errs.Validation{Code: "comment_parent_invalid", Message: "parent comment must belong to the same task",
+		Fields: []errs.FieldError{{Field: "parentid", Message: "must be a live comment of the same task"}}}
+)
+
+func (c *Comment) Validate() error {
+	if c.Body == "" {
+		return ErrEmptyBody
+	}
+
+	if len(c.Body) > MaxBodyLength {
+		return ErrBodyTooLong
+	}
+
+	return nil
+}
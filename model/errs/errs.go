@@ -0,0 +1,88 @@
+// Package errs defines the kinds of domain errors shared by the model and service packages. Each kind
+// carries a stable, machine readable Code; the HTTP layer maps every kind to a single status code.
+// Errors of a fixed meaning are declared once as package level values, so errors.Is keeps working.
+package errs
+
+// FieldError describes what is wrong with one field of the input
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// NotFound reports that the requested resource does not exist, or is not visible to the caller
+type NotFound struct {
+	Code    string
+	Message string
+}
+
+func (e *NotFound) Error() string { return e.Message }
+
+// Validation reports input that breaks a rule of the model, optionally naming the offending fields
+type Validation struct {
+	Code    string
+	Message string
+	Fields  []FieldError
+}
+
+func (e *Validation) Error() string { return e.Message }
+
+// Conflict reports a request that is valid on its own but clashes with the current state
+type Conflict struct {
+	Code    string
+	Message string
+}
+
+func (e *Conflict) Error() string { return e.Message }
+
+// Unauthorized reports a caller whose identity could not be verified
+type Unauthorized struct {
+	Code    string
+	Message string
+}
+
+func (e *Unauthorized) Error() string { return e.Message }
+
+// Forbidden reports that the caller may not perform the operation
+type Forbidden struct {
+	Code    string
+	Message string
+}
+
+func (e *Forbidden) Error() string { return e.Message }
+
+// TooLarge reports input that exceeds a size limit
+type TooLarge struct {
+	Code    string
+	Message string
+}
+
+func (e *TooLarge) Error() string { return e.Message }
+
+// Unsupported reports input of a media type that is not accepted
+type Unsupported struct {
+	Code    string
+	Message string
+}
+
+func (e *Unsupported) Error() string { return e.Message }
+
+// Unprocessable reports a well-formed request that cannot be acted upon as it stands
+type Unprocessable struct {
+	Code    string
+	Message string
+}
+
+func (e *Unprocessable) Error() string { return e.Message }
+
+// RateLimited reports a caller that sent more requests than it is allowed to
+type RateLimited struct {
+	Code    string
+	Message string
+}
+
+func (e *RateLimited) Error() string { return e.Message }
+
+// Invalid builds a Validation error for input only known at run time
+func Invalid(code, message string, fields ...FieldError) *Validation {
+	return &Validation{Code: code, Message: message, Fields: fields}
+}
@@ -0,0 +1,47 @@
+package idempotency
+
+import (
+	"Task_Manager/model/errs"
+	"time"
+)
+
+// MaxKeyLength bounds the Idempotency-Key header
+const MaxKeyLength = 255
+
+var (
+	ErrKeyInvalid = errs.Invalid("idempotency_key_invalid", "Idempotency-Key must be 1 to 255 printable ASCII characters")
+	ErrKeyReused  = &errs.Unprocessable{Code: "idempotency_key_reused", Message: "Idempotency-Key was already used with a different request"}
+	ErrInProgress = &errs.Conflict{Code: "idempotency_key_in_progress", Message: "a request with this Idempotency-Key is still being processed, retry later"}
+)
+
+// Record is what is kept of a request sent with an idempotency key. Key identifies the client, route
+// and header value together, so clients cannot collide; Fingerprint identifies the request itself.
+// Until the first request completes Status is zero and no response is kept.
+type Record struct {
+	Key         string
+	Fingerprint string
+	Status      int
+	ContentType string
+	Body        []byte
+	CreatedAt   time.Time
+}
+
+// Done reports whether the response of the first request is kept
+func (r Record) Done() bool {
+	return r.Status != 0
+}
+
+// ValidKey checks that key can be used as an idempotency key
+func ValidKey(key string) error {
+	if key == "" || len(key) > MaxKeyLength {
+		return ErrKeyInvalid
+	}
+
+	for i := 0; i < len(key); i++ {
+		if key[i] < ' ' || key[i] > '~' {
+			return ErrKeyInvalid
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package imports
+
+import "Task_Manager/model/errs"
+
+// Supported import file formats
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+// Outcomes of a single imported row
+const (
+	RowCreated = "created"
+	RowSkipped = "skipped"
+	RowFailed  = "failed"
+)
+
+// Row reports what happened to one record of an import file. Line is the line of the file the record
+// starts on, counting the CSV header. In a dry run, created means the row would have been created.
+type Row struct {
+	Line   int    `json:"line"`
+	Status string `json:"status"`
+	ID     int    `json:"id,omitempty"`
+	// Reason explains why a row was skipped
+	Reason string `json:"reason,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Summary counts the rows of an import by outcome
+type Summary struct {
+	DryRun  bool `json:"dry_run"`
+	Created int  `json:"created"`
+	Skipped int  `json:"skipped"`
+	Failed  int  `json:"failed"`
+}
+
+// Add counts a row in the summary
+func (s *Summary) Add(r Row) {
+	switch r.Status {
+	case RowCreated:
+		s.Created++
+	case RowSkipped:
+		s.Skipped++
+	default:
+		s.Failed++
+	}
+}
+
+var (
+	ErrFormat       = &errs.Validation{Code: "import_format_invalid", Message: "import format must be csv or ndjson"}
+	ErrHeader       = &errs.Validation{Code: "import_header_invalid", Message: "csv file must start with a header row"}
+	ErrMissingEmail = &errs.Validation{Code: "import_email_required", Message: "email cannot be empty"}
+	ErrUnknownUser  = &errs.NotFound{Code: "user_not_found", Message: "no user with this email"}
+	ErrStatus       = &errs.Validation{Code: "import_status_invalid", Message: "status must be true or false"}
+	ErrDue          = &errs.Validation{Code: "import_due_invalid", Message: "due must be an RFC 3339 time or a YYYY-MM-DD date"}
+)
@@ -0,0 +1,54 @@
+package label
+
+import (
+	"Task_Manager/model/errs"
+	"regexp"
+	"strings"
+)
+
+// MaxNameLength bounds the length of a label name
+const MaxNameLength = 50
+
+// Label categorises tasks. Names are unique within a workspace.
+type Label struct {
+	ID          int    `json:"id"`
+	WorkspaceID int    `json:"workspaceid"`
+	Name        string `json:"name"`
+	Color       string `json:"color"`
+}
+
+var (
+	ErrInvalidName = &errs.Validation{Code: "label_name_invalid", Message: "label name must be between 1 and 50 characters and contain no commas",
+		Fields: []errs.FieldError{{Field: "name", Message: "must be 1 to 50 characters without commas"}}}
+	ErrInvalidColor = &errs.Validation{Code: "label_color_invalid", Message: "label color must be a hex value like #1f883d",
+		Fields: []errs.FieldError{{Field: "color", Message: "must be a hex value like #1f883d"}}}
+	ErrInvalidSpace = &errs.Validation{Code: "label_workspace_required", Message: "label workspace must be set",
+		Fields: []errs.FieldError{{Field: "workspaceid", Message: "must be set"}}}
+	ErrNotFound       = &errs.NotFound{Code: "label_not_found", Message: "label not found"}
+	ErrDuplicate      = &errs.Conflict{Code: "label_exists", Message: "a label with this name already exists in the workspace"}
+	ErrUnknownTarget  = &errs.NotFound{Code: "label_target_not_found", Message: "unknown task or label"}
+	ErrTooManyTargets = &errs.Validation{Code: "label_too_many_targets", Message: "too many tasks or labels in one request"}
+	ErrOtherWorkspace = &errs.Unprocessable{Code: "label_other_workspace", Message: "labels can only be attached to tasks of their workspace"}
+)
+
+var colorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// Validate trims the name and checks every field
+func (l *Label) Validate() error {
+	l.Name = strings.TrimSpace(l.Name)
+
+	// Commas are reserved as the separator of the labels filter on GET /task
+	if l.Name == "" || len(l.Name) > MaxNameLength || strings.Contains(l.Name, ",") {
+		return ErrInvalidName
+	}
+
+	if !colorPattern.MatchString(l.Color) {
+		return ErrInvalidColor
+	}
+
+	if l.WorkspaceID <= 0 {
+		return ErrInvalidSpace
+	}
+
+	return nil
+}
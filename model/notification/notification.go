@@ -0,0 +1,97 @@
+package notification
+
+import (
+	"Task_Manager/model/errs"
+	"Task_Manager/model/task"
+	"time"
+)
+
+// Kinds of events users are notified about
+const (
+	KindTaskAssigned  = "task_assigned"
+	KindTaskCompleted = "task_completed"
+)
+
+// Delivery decides how the notifications of one kind reach a user
+type Delivery string
+
+const (
+	// DeliveryImmediate sends one email per event as it happens
+	DeliveryImmediate Delivery = "immediate"
+	// DeliveryDigest collects the events and sends them together in the next digest
+	DeliveryDigest Delivery = "digest"
+	// DeliveryOff drops the events
+	DeliveryOff Delivery = "off"
+)
+
+var (
+	ErrInvalidDelivery = &errs.Validation{Code: "notification_delivery_invalid", Message: "delivery must be immediate, digest or off"}
+	ErrForbidden       = &errs.Forbidden{Code: "notification_forbidden", Message: "only the user or an admin can change notification preferences"}
+)
+
+// Event is something a user is notified about. UserID is the recipient and ActorID the user who caused
+// the event, zero when unknown. Task is the task as it was right after the event.
+type Event struct {
+	ID      int64     `json:"-"`
+	Kind    string    `json:"kind"`
+	UserID  int       `json:"user_id"`
+	ActorID int       `json:"actor_id,omitempty"`
+	Task    task.Task `json:"task"`
+	At      time.Time `json:"at"`
+}
+
+// Preferences are the delivery choices of one user, per kind of event
+type Preferences struct {
+	UserID        int      `json:"user_id"`
+	TaskAssigned  Delivery `json:"task_assigned"`
+	TaskCompleted Delivery `json:"task_completed"`
+}
+
+// DefaultPreferences apply to users who never changed theirs
+func DefaultPreferences(userID int) Preferences {
+	return Preferences{UserID: userID, TaskAssigned: DeliveryImmediate, TaskCompleted: DeliveryImmediate}
+}
+
+// For returns the delivery chosen for a kind of event
+func (p Preferences) For(kind string) Delivery {
+	switch kind {
+	case KindTaskAssigned:
+		return p.TaskAssigned
+	case KindTaskCompleted:
+		return p.TaskCompleted
+	default:
+		return DeliveryOff
+	}
+}
+
+func (p Preferences) Validate() error {
+	var fields []errs.FieldError
+
+	for _, f := range []struct {
+		name  string
+		value Delivery
+	}{
+		{KindTaskAssigned, p.TaskAssigned},
+		{KindTaskCompleted, p.TaskCompleted},
+	} {
+		switch f.value {
+		case DeliveryImmediate, DeliveryDigest, DeliveryOff:
+		default:
+			fields = append(fields, errs.FieldError{Field: f.name, Message: "must be immediate, digest or off"})
+		}
+	}
+
+	if fields != nil {
+		return errs.Invalid(ErrInvalidDelivery.Code, ErrInvalidDelivery.Message, fields...)
+	}
+
+	return nil
+}
+
+// Message is an email ready to be sent, with a plain text and an HTML version of the same content
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
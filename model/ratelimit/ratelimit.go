@@ -0,0 +1,85 @@
+package ratelimit
+
+import (
+	"Task_Manager/model/errs"
+	"fmt"
+	"math"
+	"time"
+)
+
+var ErrRateLimited = &errs.RateLimited{Code: "rate_limited", Message: "too many requests, retry later"}
+
+// Limit allows Requests per Per on average, and bursts of up to Burst requests at once
+type Limit struct {
+	Requests int
+	Per      time.Duration
+	Burst    int
+}
+
+// rate is the number of tokens added to a bucket per second
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Per.Seconds()
+}
+
+// Refill is the time an empty bucket takes to fill up again
+func (l Limit) Refill() time.Duration {
+	return time.Duration(float64(l.Burst) / l.rate() * float64(time.Second))
+}
+
+// Validate rejects limits that would never let a request through or never refill
+func (l Limit) Validate() error {
+	if l.Requests <= 0 || l.Per <= 0 || l.Burst <= 0 {
+		return fmt.Errorf("rate limit needs positive requests, window and burst, got %d/%s burst %d", l.Requests, l.Per, l.Burst)
+	}
+
+	return nil
+}
+
+// Bucket is the state of the token bucket of one client. A zero Bucket is full.
+type Bucket struct {
+	Tokens float64
+	At     time.Time
+}
+
+// Decision is the outcome of taking a token. Remaining is the number of requests that may follow right
+// away, Reset the time until the bucket is full again and RetryAfter, for a denied request, the time
+// until a token is available.
+type Decision struct {
+	Allowed    bool
+	Limit      Limit
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+// Take refills the bucket for the time elapsed since it was last used and takes a token when one is
+// left. The bucket is updated either way, so it can be stored as it is.
+func (b *Bucket) Take(l Limit, now time.Time) Decision {
+	tokens := float64(l.Burst)
+	if !b.At.IsZero() {
+		// Clocks of several instances sharing a bucket may disagree; a bucket never drains by going back in time
+		elapsed := max(0, now.Sub(b.At))
+		tokens = math.Min(tokens, b.Tokens+elapsed.Seconds()*l.rate())
+	}
+
+	d := Decision{Limit: l}
+	if tokens >= 1 {
+		tokens--
+		d.Allowed = true
+	} else {
+		d.RetryAfter = seconds((1 - tokens) / l.rate())
+	}
+
+	b.Tokens = tokens
+	if now.After(b.At) {
+		b.At = now
+	}
+	d.Remaining = int(tokens)
+	d.Reset = seconds((float64(l.Burst) - tokens) / l.rate())
+
+	return d
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -1,19 +1,220 @@
 package task
 
-import "errors"
+import (
+	"Task_Manager/model/errs"
+	"time"
+)
 
+// Task is a unit of work; Userid is zero when the task has no assignee, Due is nil when it has no due date
+// and WorkspaceID is zero when it belongs to no workspace. The workspace is set on creation only.
 type Task struct {
-	ID     int    `json:"id"`
-	Desc   string `json:"desc"`
-	Status bool   `json:"status"`
-	Userid int    `json:"userid"`
+	ID          int        `json:"id"`
+	Desc        string     `json:"desc"`
+	Status      bool       `json:"status"`
+	Userid      int        `json:"userid"`
+	Due         *time.Time `json:"due,omitempty"`
+	WorkspaceID int        `json:"workspaceid,omitempty"`
 }
 
-var err = errors.New("description cannot be empty")
+// Filter selects live tasks. Zero fields match every task; Labels matches tasks carrying any of the
+// names, or all of them when MatchAll is set, among the labels of the task's own workspace, and
+// WorkspaceID restricts those tasks to one workspace.
+// AfterID and Limit page through the tasks in ID order: the page starts past AfterID and holds at most
+// Limit tasks.
+type Filter struct {
+	UserID      int
+	WorkspaceID int
+	Labels      []string
+	MatchAll    bool
+	// Status matches open tasks when false and done tasks when true
+	Status  *bool
+	AfterID int
+	Limit   int
+}
+
+// Change is a committed change of a task, as told to the subscribers of task changes. Action is one of
+// the audit actions. Task is the task after the change, or as it was for a deletion; it is nil for a purge.
+type Change struct {
+	Action string    `json:"action"`
+	TaskID int       `json:"task_id"`
+	Task   *Task     `json:"task,omitempty"`
+	At     time.Time `json:"at"`
+}
+
+// Trashed is a soft deleted task together with the time it was moved to the trash
+type Trashed struct {
+	Task
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// Revision is a snapshot of a task taken after each change. Number counts up from 1 per task.
+type Revision struct {
+	TaskID  int       `json:"task_id"`
+	Number  int       `json:"revision"`
+	At      time.Time `json:"at"`
+	Deleted bool      `json:"deleted"`
+	Task    Task      `json:"task"`
+}
+
+// FieldChange is one field that differs between two revisions
+type FieldChange struct {
+	Field string `json:"field"`
+	From  any    `json:"from"`
+	To    any    `json:"to"`
+}
+
+var (
+	ErrEmptyDesc = &errs.Validation{Code: "task_desc_required", Message: "description cannot be empty",
+		Fields: []errs.FieldError{{Field: "desc", Message: "cannot be empty"}}}
+	ErrNotFound = &errs.NotFound{Code: "task_not_found", Message: "task not found"}
+	// ErrNoRevision is returned when a task has no revision matching the request
+	ErrNoRevision = &errs.NotFound{Code: "revision_not_found", Message: "task revision not found"}
+	// ErrQuotaExceeded is returned when a task would take its workspace over its quota
+	ErrQuotaExceeded = &errs.Conflict{Code: "task_quota_exceeded", Message: "workspace has reached its task quota"}
+	// ErrOwnerTrashed is returned when restoring a task whose assignee is in the trash
+	ErrOwnerTrashed = &errs.Conflict{Code: "task_owner_trashed", Message: "the assignee of the task is in the trash; restore the user first"}
+)
+
+// Quotas cap the number of tasks of each workspace, counting tasks in the trash until they are purged.
+// Workspaces missing from Workspaces get Default; zero means no limit. Tasks outside any workspace
+// have no quota.
+type Quotas struct {
+	Default    int
+	Workspaces map[int]int
+}
+
+// For returns the quota of a workspace, zero when it has none
+func (q Quotas) For(workspaceID int) int {
+	if workspaceID == 0 {
+		return 0
+	}
+
+	if n, ok := q.Workspaces[workspaceID]; ok {
+		return n
+	}
+
+	return q.Default
+}
+
+// Diff lists the fields, by JSON name, that differ from r to other
+func (r Revision) Diff(other Revision) []FieldChange {
+	changes := []FieldChange{}
+
+	if r.Task.Desc != other.Task.Desc {
+		changes = append(changes, FieldChange{Field: "desc", From: r.Task.Desc, To: other.Task.Desc})
+	}
+
+	if r.Task.Status != other.Task.Status {
+		changes = append(changes, FieldChange{Field: "status", From: r.Task.Status, To: other.Task.Status})
+	}
+
+	if r.Task.Userid != other.Task.Userid {
+		changes = append(changes, FieldChange{Field: "userid", From: r.Task.Userid, To: other.Task.Userid})
+	}
+
+	if !sameTime(r.Task.Due, other.Task.Due) {
+		changes = append(changes, FieldChange{Field: "due", From: r.Task.Due, To: other.Task.Due})
+	}
+
+	if r.Deleted != other.Deleted {
+		changes = append(changes, FieldChange{Field: "deleted", From: r.Deleted, To: other.Deleted})
+	}
+
+	return changes
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return a.Equal(*b)
+}
 
 func (t *Task) Validate() error {
 	if t.Desc == "" {
-		return err
+		return ErrEmptyDesc
+	}
+
+	return nil
+}
+
+// Bulk operation kinds
+const (
+	BulkCreate   = "create"
+	BulkUpdate   = "update"
+	BulkComplete = "complete"
+	BulkDelete   = "delete"
+	BulkReassign = "reassign"
+)
+
+// Outcomes of a single bulk operation
+const (
+	BulkOK         = "ok"
+	BulkFailed     = "failed"
+	BulkRolledBack = "rolled_back"
+)
+
+// BulkOp is one operation of a bulk request. ID names the task for every kind but create;
+// Desc is used by create and update, Userid by create and reassign, WorkspaceID by create.
+type BulkOp struct {
+	Op          string `json:"op"`
+	ID          int    `json:"id,omitempty"`
+	Desc        string `json:"desc,omitempty"`
+	Userid      int    `json:"userid,omitempty"`
+	WorkspaceID int    `json:"workspaceid,omitempty"`
+}
+
+// BulkResult reports the outcome of the operation at Index of a bulk request
+type BulkResult struct {
+	Index  int    `json:"index"`
+	Op     string `json:"op"`
+	ID     int    `json:"id,omitempty"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	// Task is the state after the operation; Before the state it replaced
+	Task   *Task `json:"task,omitempty"`
+	Before *Task `json:"-"`
+}
+
+var (
+	ErrUnknownOp   = &errs.Validation{Code: "bulk_op_unknown", Message: "unknown bulk operation"}
+	ErrMissingID   = &errs.Validation{Code: "bulk_id_required", Message: "operation needs a task id"}
+	ErrMissingUser = &errs.Validation{Code: "bulk_userid_required", Message: "operation needs a userid"}
+	ErrDuplicateID = &errs.Validation{Code: "bulk_duplicate_id", Message: "task appears in more than one operation of the batch"}
+	ErrBulkSize    = &errs.Validation{Code: "bulk_size_invalid", Message: "batch is empty or too large"}
+	ErrBulkFailed  = &errs.Conflict{Code: "bulk_failed", Message: "bulk operation failed; no changes were applied"}
+)
+
+// Validate checks that the operation carries the fields its kind needs
+func (op BulkOp) Validate() error {
+	switch op.Op {
+	case BulkCreate:
+		if op.Userid <= 0 {
+			return ErrMissingUser
+		}
+
+		return (&Task{Desc: op.Desc}).Validate()
+	case BulkUpdate:
+		if op.ID <= 0 {
+			return ErrMissingID
+		}
+
+		return (&Task{Desc: op.Desc}).Validate()
+	case BulkComplete, BulkDelete:
+		if op.ID <= 0 {
+			return ErrMissingID
+		}
+	case BulkReassign:
+		if op.ID <= 0 {
+			return ErrMissingID
+		}
+
+		if op.Userid <= 0 {
+			return ErrMissingUser
+		}
+	default:
+		return ErrUnknownOp
 	}
 
 	return nil
@@ -1,21 +1,215 @@
 package user
 
 import (
-	"errors"
+	"Task_Manager/model/errs"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
 )
 
+// User is an account. The profile fields DisplayName, AvatarURL and Timezone are optional; Timezone is an
+// IANA name such as Europe/Berlin.
 type User struct {
-	ID    int    `json:"id"`
-	Name  string `json:"name"`
-	Email string `json:"email"`
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Email       string `json:"email"`
+	DisplayName string `json:"display_name,omitempty"`
+	AvatarURL   string `json:"avatar_url,omitempty"`
+	Timezone    string `json:"timezone,omitempty"`
 }
 
-var err = errors.New("name and email cannot be empty")
+// Trashed is a soft deleted user together with the time it was moved to the trash
+type Trashed struct {
+	User
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// Limits on the profile fields, matching the column sizes
+const (
+	MaxEmailLength       = 254
+	MaxDisplayNameLength = 100
+	MaxAvatarURLLength   = 2048
+)
 
+var (
+	ErrNotFound   = &errs.NotFound{Code: "user_not_found", Message: "user not found"}
+	ErrEmailTaken = &errs.Conflict{Code: "email_taken", Message: "email address is already in use"}
+	ErrForbidden  = &errs.Forbidden{Code: "user_forbidden", Message: "only the user or an admin can change this profile"}
+	// ErrEmailToken is returned when an email change is confirmed with an unknown or expired token
+	ErrEmailToken = &errs.Validation{Code: "email_token_invalid", Message: "confirmation token is invalid or has expired",
+		Fields: []errs.FieldError{{Field: "token", Message: "is invalid or has expired"}}}
+	ErrEmailUnchanged = &errs.Validation{Code: "email_unchanged", Message: "new email address is the current one",
+		Fields: []errs.FieldError{{Field: "email", Message: "is the current address"}}}
+)
+
+// NormalizeEmail checks that email is a bare RFC 5322 address, without a display name or angle brackets,
+// and returns it in the lower case form stored and compared by the application
+func NormalizeEmail(email string) (string, error) {
+	email = strings.TrimSpace(email)
+
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email || len(email) > MaxEmailLength {
+		return "", errs.Invalid("email_invalid", "email is not a valid address",
+			errs.FieldError{Field: "email", Message: "must be an address like name@example.com"})
+	}
+
+	return strings.ToLower(email), nil
+}
+
+// Validate checks the user and normalises its email address
 func (u *User) Validate() error {
-	if u.Name == "" || u.Email == "" {
-		return err
+	var fields []errs.FieldError
+
+	if u.Name == "" {
+		fields = append(fields, errs.FieldError{Field: "name", Message: "cannot be empty"})
+	}
+
+	if u.Email == "" {
+		fields = append(fields, errs.FieldError{Field: "email", Message: "cannot be empty"})
+	} else if email, err := NormalizeEmail(u.Email); err != nil {
+		fields = append(fields, errs.FieldError{Field: "email", Message: "must be an address like name@example.com"})
+	} else {
+		u.Email = email
+	}
+
+	fields = append(fields, u.profileErrors()...)
+
+	if fields != nil {
+		return errs.Invalid("user_invalid", "user is invalid", fields...)
 	}
 
 	return nil
 }
+
+func (u *User) profileErrors() []errs.FieldError {
+	var fields []errs.FieldError
+
+	if utf8.RuneCountInString(u.DisplayName) > MaxDisplayNameLength {
+		fields = append(fields, errs.FieldError{Field: "display_name", Message: "must be at most 100 characters"})
+	}
+
+	if u.AvatarURL != "" {
+		avatar, err := url.Parse(u.AvatarURL)
+		if err != nil || (avatar.Scheme != "https" && avatar.Scheme != "http") || avatar.Host == "" || len(u.AvatarURL) > MaxAvatarURLLength {
+			fields = append(fields, errs.FieldError{Field: "avatar_url", Message: "must be an absolute http or https URL"})
+		}
+	}
+
+	// "Local" would mean the server's zone, which is not a preference a user can hold
+	if u.Timezone != "" {
+		if _, err := time.LoadLocation(u.Timezone); err != nil || u.Timezone == "Local" {
+			fields = append(fields, errs.FieldError{Field: "timezone", Message: "must be an IANA time zone like Europe/Berlin"})
+		}
+	}
+
+	return fields
+}
+
+// Profile is a partial update of a user; nil fields are left unchanged and empty strings clear the optional ones.
+// The email address is changed separately, since the new address has to be confirmed first.
+type Profile struct {
+	Name        *string `json:"name"`
+	DisplayName *string `json:"display_name"`
+	AvatarURL   *string `json:"avatar_url"`
+	Timezone    *string `json:"timezone"`
+}
+
+// Apply returns u with the set fields of p
+func (p Profile) Apply(u User) User {
+	for _, f := range []struct {
+		src *string
+		dst *string
+	}{
+		{p.Name, &u.Name},
+		{p.DisplayName, &u.DisplayName},
+		{p.AvatarURL, &u.AvatarURL},
+		{p.Timezone, &u.Timezone},
+	} {
+		if f.src != nil {
+			*f.dst = strings.TrimSpace(*f.src)
+		}
+	}
+
+	return u
+}
+
+// EmailChangeTTL is how long a confirmation token for a new email address stays valid
+const EmailChangeTTL = 24 * time.Hour
+
+// EmailChange is a pending change of a user's email address, applied once the token sent to the new address is confirmed
+type EmailChange struct {
+	UserID    int       `json:"user_id"`
+	Email     string    `json:"email"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// DeletePolicy decides what happens to a user's tasks when the user is deleted
+type DeletePolicy string
+
+const (
+	// PolicyReject refuses to delete a user who still has live tasks; tasks in the trash are unassigned
+	PolicyReject DeletePolicy = "reject"
+	// PolicyReassign hands the tasks over to another user
+	PolicyReassign DeletePolicy = "reassign"
+	// PolicyUnassign keeps the tasks without an assignee
+	PolicyUnassign DeletePolicy = "unassign"
+	// PolicyCascade moves the tasks to the trash together with the user. Those tasks cannot be restored
+	// while the user is in the trash, and are unassigned when the user is purged.
+	PolicyCascade DeletePolicy = "cascade"
+)
+
+// Affected returns the tasks the policy changes, given the live and trashed tasks of the user. Cascade
+// only moves the live ones to the trash; every other policy also covers the trashed ones, so restoring
+// them never yields a task owned by a deleted user.
+func (p DeletePolicy) Affected(live, trashed []int) []int {
+	if p == PolicyCascade {
+		return live
+	}
+
+	return append(append([]int(nil), live...), trashed...)
+}
+
+var (
+	ErrInvalidPolicy = &errs.Validation{Code: "delete_policy_invalid", Message: "unknown delete policy",
+		Fields: []errs.FieldError{{Field: "policy", Message: "must be reject, reassign, unassign or cascade"}}}
+	ErrReassignTarget = &errs.Validation{Code: "reassign_target_invalid", Message: "tasks must be reassigned to another existing user",
+		Fields: []errs.FieldError{{Field: "reassign_to", Message: "must name another existing user"}}}
+	ErrHasTasks = &errs.Conflict{Code: "user_has_tasks", Message: "user still has tasks"}
+)
+
+// DeleteOptions selects how a user deletion treats the user's tasks
+type DeleteOptions struct {
+	Policy     DeletePolicy
+	ReassignTo int
+	// DryRun reports the affected tasks without changing anything
+	DryRun bool
+}
+
+// Validate checks the options for deleting the user with the given ID
+func (o DeleteOptions) Validate(userID int) error {
+	switch o.Policy {
+	case PolicyReject, PolicyUnassign, PolicyCascade:
+		return nil
+	case PolicyReassign:
+		if o.ReassignTo <= 0 || o.ReassignTo == userID {
+			return ErrReassignTarget
+		}
+
+		return nil
+	default:
+		return ErrInvalidPolicy
+	}
+}
+
+// DeleteReport describes the outcome, or for a dry run the expected outcome, of a user deletion
+type DeleteReport struct {
+	UserID     int          `json:"user_id"`
+	Policy     DeletePolicy `json:"policy"`
+	ReassignTo int          `json:"reassign_to,omitempty"`
+	DryRun     bool         `json:"dry_run"`
+	// Tasks are the IDs of the user's tasks affected by the policy, including those in the trash, or
+	// when the deletion is rejected the live tasks preventing it
+	Tasks []int `json:"tasks"`
+}
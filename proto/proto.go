@@ -0,0 +1,8 @@
+// Package proto holds the protobuf definitions of the gRPC API; the generated Go code is in
+// taskmanager/v1. google/api is a copy of the HTTP annotations from github.com/googleapis/googleapis,
+// kept here so the definitions compile with protoc alone. Regenerate after changing a definition with
+// go generate, which needs protoc, protoc-gen-go v1.34.2, protoc-gen-go-grpc v1.5.1 and
+// protoc-gen-grpc-gateway v2.20.0 on the PATH.
+package proto
+
+//go:generate protoc -I . --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative --grpc-gateway_out=. --grpc-gateway_opt=paths=source_relative taskmanager/v1/task.proto taskmanager/v1/user.proto
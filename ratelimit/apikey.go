@@ -0,0 +1,49 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// APIKeys are the API keys the server accepts, kept as SHA-256 hashes so that the keys themselves are
+// never stored. Requests carrying any other key are told apart as if they had none.
+type APIKeys map[[sha256.Size]byte]bool
+
+// ParseAPIKeys reads the hex encoded SHA-256 hashes of the accepted keys, as printed by sha256sum
+func ParseAPIKeys(hashes []string) (APIKeys, error) {
+	keys := make(APIKeys, len(hashes))
+
+	for _, h := range hashes {
+		var sum [sha256.Size]byte
+
+		b, err := hex.DecodeString(strings.TrimSpace(h))
+		if err != nil || len(b) != len(sum) {
+			return nil, fmt.Errorf("API key hash %q: expected %d hex encoded bytes", h, len(sum))
+		}
+
+		copy(sum[:], b)
+		keys[sum] = true
+	}
+
+	return keys, nil
+}
+
+type apiKeyCtx struct{}
+
+// Middleware marks requests whose API key is accepted, so that ClientKey tells their client apart by it.
+// It must run before the rate limit and idempotency middleware.
+func (k APIKeys) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if key := r.Header.Get(HeaderAPIKey); key != "" {
+			if sum := sha256.Sum256([]byte(key)); k[sum] {
+				r = r.WithContext(context.WithValue(r.Context(), apiKeyCtx{}, hex.EncodeToString(sum[:16])))
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
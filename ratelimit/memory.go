@@ -0,0 +1,60 @@
+package ratelimit
+
+import (
+	"Task_Manager/model/ratelimit"
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often the memory backend drops the buckets that have filled up again
+const sweepInterval = time.Minute
+
+// Memory keeps the buckets in process. Each instance limits on its own, so behind a load balancer
+// clients get the limit once per instance.
+type Memory struct {
+	mu        sync.Mutex
+	buckets   map[string]*entry
+	lastSweep time.Time
+}
+
+type entry struct {
+	bucket ratelimit.Bucket
+	// full is when the bucket will have filled up again if left alone
+	full time.Time
+}
+
+func NewMemory() *Memory {
+	return &Memory{buckets: make(map[string]*entry)}
+}
+
+func (m *Memory) TakeRateLimit(_ context.Context, key string, l ratelimit.Limit, now time.Time) (ratelimit.Decision, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if now.Sub(m.lastSweep) >= sweepInterval {
+		m.sweep(now)
+	}
+
+	e, ok := m.buckets[key]
+	if !ok {
+		e = &entry{}
+		m.buckets[key] = e
+	}
+
+	d := e.bucket.Take(l, now)
+	e.full = now.Add(d.Reset)
+
+	return d, nil
+}
+
+// sweep drops the buckets that are full, which behave exactly like missing ones
+func (m *Memory) sweep(now time.Time) {
+	for key, e := range m.buckets {
+		if !now.Before(e.full) {
+			delete(m.buckets, key)
+		}
+	}
+
+	m.lastSweep = now
+}
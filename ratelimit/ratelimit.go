@@ -0,0 +1,222 @@
+// Package ratelimit limits how fast each client may call the API, with one token bucket per client and
+// rule. Rules come from configuration and select requests by method and route template; the buckets
+// live in a Backend, in memory for a single instance or in the database when several instances share
+// the load.
+package ratelimit
+
+import (
+	"Task_Manager/auth"
+	"Task_Manager/handler/problem"
+	"Task_Manager/logging"
+	"Task_Manager/model/ratelimit"
+	"Task_Manager/request"
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// HeaderAPIKey identifies the client of scripts and integrations. It is only used to tell clients apart, and
+// only when the key is one of the configured APIKeys.
+const HeaderAPIKey = "X-API-Key"
+
+// Any matches every method or every route in a Rule
+const Any = "*"
+
+// Backend keeps the token buckets, taking a token from the bucket of key at now
+type Backend interface {
+	TakeRateLimit(ctx context.Context, key string, l ratelimit.Limit, now time.Time) (ratelimit.Decision, error)
+}
+
+// Rule limits the requests of each client matching a method and a route template. Clients get one bucket
+// per rule, so a rule for every route limits the total rate of a client.
+type Rule struct {
+	Method string
+	Route  string
+	Limit  ratelimit.Limit
+}
+
+func (r Rule) String() string {
+	return r.Method + " " + r.Route
+}
+
+func (r Rule) matches(method, route string) bool {
+	return (r.Method == Any || r.Method == method) && (r.Route == Any || r.Route == route)
+}
+
+// ParseRules reads a comma separated list of rules such as
+//
+//	POST /task=10/1m:20, GET *=100/1s, *=50/1s
+//
+// Each rule is an optional method, a route template or "*", then the number of requests allowed per
+// window and, after a colon, the burst, which defaults to the number of requests.
+func ParseRules(raw string) ([]Rule, error) {
+	var rules []Rule
+
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		target, limit, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("rate limit rule %q: expected ROUTE=REQUESTS/WINDOW", entry)
+		}
+
+		r := Rule{Method: Any, Route: strings.TrimSpace(target)}
+		if method, route, ok := strings.Cut(r.Route, " "); ok {
+			r.Method, r.Route = strings.ToUpper(method), strings.TrimSpace(route)
+		}
+
+		l, err := parseLimit(strings.TrimSpace(limit))
+		if err != nil {
+			return nil, fmt.Errorf("rate limit rule %q: %w", entry, err)
+		}
+
+		r.Limit = l
+		rules = append(rules, r)
+	}
+
+	return rules, nil
+}
+
+// parseLimit reads REQUESTS/WINDOW[:BURST], where the window is a duration such as 1s or 1m, or just a
+// unit such as s, m or h
+func parseLimit(raw string) (ratelimit.Limit, error) {
+	var l ratelimit.Limit
+
+	raw, burst, hasBurst := strings.Cut(raw, ":")
+
+	requests, window, ok := strings.Cut(raw, "/")
+	if !ok {
+		return l, fmt.Errorf("expected REQUESTS/WINDOW, got %q", raw)
+	}
+
+	var err error
+	if l.Requests, err = strconv.Atoi(requests); err != nil {
+		return l, fmt.Errorf("invalid number of requests %q", requests)
+	}
+
+	if window != "" && (window[0] < '0' || window[0] > '9') {
+		window = "1" + window
+	}
+
+	if l.Per, err = time.ParseDuration(window); err != nil {
+		return l, fmt.Errorf("invalid window %q", window)
+	}
+
+	l.Burst = l.Requests
+	if hasBurst {
+		if l.Burst, err = strconv.Atoi(burst); err != nil {
+			return l, fmt.Errorf("invalid burst %q", burst)
+		}
+	}
+
+	return l, l.Validate()
+}
+
+// Limiter applies the first rule matching each request
+type Limiter struct {
+	backend Backend
+	rules   []Rule
+	now     func() time.Time
+}
+
+func New(backend Backend, rules []Rule) *Limiter {
+	return &Limiter{backend: backend, rules: rules, now: time.Now}
+}
+
+// Idle is the longest time a bucket takes to fill up again. Buckets unused for longer are full, so a
+// backend may drop them.
+func (l *Limiter) Idle() time.Duration {
+	var idle time.Duration
+
+	for _, r := range l.rules {
+		idle = max(idle, r.Limit.Refill())
+	}
+
+	return idle
+}
+
+// Middleware rejects requests over their limit with 429 Too Many Requests. Responses of limited routes
+// carry the RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset and RateLimit-Policy headers, and
+// rejections a Retry-After header. It must run as router middleware, after the auth middleware, since
+// rules match the route template and clients are told apart by the acting user.
+//
+// When the backend fails the request is let through: an outage of the limiter should not become an
+// outage of the API.
+func (l *Limiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rule, ok := l.match(r)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		d, err := l.backend.TakeRateLimit(r.Context(), rule.String()+"|"+ClientKey(r), rule.Limit, l.now())
+		if err != nil {
+			logging.FromContext(r.Context()).Error("rate limit check failed", "rule", rule.String(), "error", err)
+			next.ServeHTTP(w, r)
+
+			return
+		}
+
+		setHeaders(w.Header(), d)
+
+		if !d.Allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(d.RetryAfter)))
+			problem.Write(w, r, ratelimit.ErrRateLimited)
+
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (l *Limiter) match(r *http.Request) (Rule, bool) {
+	route := ""
+	if current := mux.CurrentRoute(r); current != nil {
+		route, _ = current.GetPathTemplate()
+	}
+
+	for _, rule := range l.rules {
+		if rule.matches(r.Method, route) {
+			return rule, true
+		}
+	}
+
+	return Rule{}, false
+}
+
+// ClientKey tells the clients of the API apart: by API key when the request has one accepted by
+// APIKeys.Middleware, then by acting user, then by address. Unknown API keys are ignored, so sending a
+// new one with every request does not get a client a fresh bucket.
+func ClientKey(r *http.Request) string {
+	if key, ok := r.Context().Value(apiKeyCtx{}).(string); ok {
+		return "key:" + key
+	}
+
+	if a := auth.FromContext(r.Context()); a.UserID != 0 {
+		return "user:" + strconv.Itoa(a.UserID)
+	}
+
+	return "ip:" + request.RemoteIP(r.Context())
+}
+
+func setHeaders(h http.Header, d ratelimit.Decision) {
+	h.Set("RateLimit-Limit", strconv.Itoa(d.Limit.Burst))
+	h.Set("RateLimit-Remaining", strconv.Itoa(d.Remaining))
+	h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(d.Reset)))
+	h.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d;burst=%d", d.Limit.Requests, ceilSeconds(d.Limit.Per), d.Limit.Burst))
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,238 @@
+package ratelimit
+
+import (
+	"Task_Manager/auth"
+	"Task_Manager/handler/authn"
+	"Task_Manager/model/ratelimit"
+	"Task_Manager/request"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseRules(t *testing.T) {
+	tests := []struct {
+		name   string
+		raw    string
+		exp    []Rule
+		expErr string
+	}{
+		{"Empty", "", nil, ""},
+		{"Method, route and burst", "POST /task=10/1m:20", []Rule{
+			{Method: "POST", Route: "/task", Limit: ratelimit.Limit{Requests: 10, Per: time.Minute, Burst: 20}},
+		}, ""},
+		{"Unit without count and default burst", " get /task/{id} = 5/s , *=100/h", []Rule{
+			{Method: "GET", Route: "/task/{id}", Limit: ratelimit.Limit{Requests: 5, Per: time.Second, Burst: 5}},
+			{Method: Any, Route: Any, Limit: ratelimit.Limit{Requests: 100, Per: time.Hour, Burst: 100}},
+		}, ""},
+		{"Missing limit", "POST /task", nil, "expected ROUTE=REQUESTS/WINDOW"},
+		{"Missing window", "/task=10", nil, "expected REQUESTS/WINDOW"},
+		{"Bad window", "/task=10/fortnight", nil, "invalid window"},
+		{"Zero requests", "/task=0/s", nil, "positive requests"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := ParseRules(tt.raw)
+			if tt.expErr != "" {
+				require.ErrorContains(t, err, tt.expErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.exp, rules)
+		})
+	}
+}
+
+func Test_ClientKey(t *testing.T) {
+	keys, err := ParseAPIKeys([]string{"2bb80d537b1da3e38bd30361aa855686bde0eacd7162fef6a25fe97bf527a25b"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		apiKey string
+		actor  auth.Actor
+		exp    string
+	}{
+		{"Accepted API key first", "secret", auth.Actor{UserID: 7}, "key:2bb80d537b1da3e38bd30361aa855686"},
+		{"Unknown API key ignored", "guess", auth.Actor{UserID: 7}, "user:7"},
+		{"Then the user", "", auth.Actor{UserID: 7}, "user:7"},
+		{"Then the address", "guess", auth.Actor{}, "ip:10.0.0.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/task", nil)
+			if tt.apiKey != "" {
+				req.Header.Set(HeaderAPIKey, tt.apiKey)
+			}
+
+			ctx := auth.WithActor(request.WithInfo(req.Context(), "req-1", "10.0.0.1"), tt.actor)
+
+			var got string
+			keys.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = ClientKey(r)
+			})).ServeHTTP(httptest.NewRecorder(), req.WithContext(ctx))
+
+			assert.Equal(t, tt.exp, got)
+		})
+	}
+}
+
+func Test_ParseAPIKeys(t *testing.T) {
+	keys, err := ParseAPIKeys(nil)
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+
+	_, err = ParseAPIKeys([]string{"secret"})
+	require.ErrorContains(t, err, `API key hash "secret"`)
+
+	_, err = ParseAPIKeys([]string{"2bb80d537b1da3e38bd30361aa855686"})
+	require.ErrorContains(t, err, "expected 32 hex encoded bytes")
+}
+
+func Test_RotatingAPIKeys(t *testing.T) {
+	rules, err := ParseRules("GET /task=1/1m")
+	require.NoError(t, err)
+
+	l := New(NewMemory(), rules)
+	l.now = func() time.Time { return time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC) }
+
+	r := mux.NewRouter()
+	r.Use(APIKeys{}.Middleware)
+	r.Use(l.Middleware)
+	r.HandleFunc("/task", func(w http.ResponseWriter, r *http.Request) {}).Methods("GET")
+
+	codes := make([]int, 3)
+	for i := range codes {
+		req := httptest.NewRequest(http.MethodGet, "/task", nil)
+		req.Header.Set(HeaderAPIKey, fmt.Sprintf("made-up-%d", i))
+		req = req.WithContext(request.WithInfo(req.Context(), "req-1", "10.0.0.1"))
+
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		codes[i] = rec.Code
+	}
+
+	assert.Equal(t, []int{http.StatusOK, http.StatusTooManyRequests, http.StatusTooManyRequests}, codes)
+}
+
+// serve sends a request as user through a router limited by l
+func serve(l *Limiter, method, path string, user string) *httptest.ResponseRecorder {
+	r := mux.NewRouter()
+	r.Use(authn.Middleware(auth.New(auth.Config{TrustHeader: true})))
+	r.Use(l.Middleware)
+	r.HandleFunc("/task", func(w http.ResponseWriter, r *http.Request) {}).Methods("GET", "POST")
+	r.HandleFunc("/task/{id}", func(w http.ResponseWriter, r *http.Request) {}).Methods("GET")
+
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set(auth.HeaderUserID, user)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func Test_Middleware(t *testing.T) {
+	rules, err := ParseRules("POST /task=1/2s:2")
+	require.NoError(t, err)
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	l := New(NewMemory(), rules)
+	l.now = func() time.Time { return now }
+
+	rec := serve(l, http.MethodPost, "/task", "7")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", rec.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "2", rec.Header().Get("RateLimit-Reset"))
+	assert.Equal(t, "1;w=2;burst=2", rec.Header().Get("RateLimit-Policy"))
+
+	assert.Equal(t, http.StatusOK, serve(l, http.MethodPost, "/task", "7").Code)
+
+	rec = serve(l, http.MethodPost, "/task", "7")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get("Retry-After"))
+	assert.Equal(t, "0", rec.Header().Get("RateLimit-Remaining"))
+	assert.Contains(t, rec.Body.String(), `"code":"rate_limited"`)
+
+	// Other clients and unlimited routes are not affected
+	assert.Equal(t, http.StatusOK, serve(l, http.MethodPost, "/task", "8").Code)
+
+	rec = serve(l, http.MethodGet, "/task", "7")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get("RateLimit-Limit"))
+
+	// A token is back after the refill interval
+	now = now.Add(2 * time.Second)
+	assert.Equal(t, http.StatusOK, serve(l, http.MethodPost, "/task", "7").Code)
+}
+
+func Test_MiddlewareFirstRuleWins(t *testing.T) {
+	rules, err := ParseRules("GET /task/{id}=1/1m, *=100/1s")
+	require.NoError(t, err)
+
+	l := New(NewMemory(), rules)
+
+	assert.Equal(t, http.StatusOK, serve(l, http.MethodGet, "/task/1", "7").Code)
+	assert.Equal(t, http.StatusTooManyRequests, serve(l, http.MethodGet, "/task/2", "7").Code)
+	assert.Equal(t, "100", serve(l, http.MethodGet, "/task", "7").Header().Get("RateLimit-Limit"))
+}
+
+type failingBackend struct{}
+
+func (failingBackend) TakeRateLimit(context.Context, string, ratelimit.Limit, time.Time) (ratelimit.Decision, error) {
+	return ratelimit.Decision{}, errors.New("db down")
+}
+
+func Test_MiddlewareFailsOpen(t *testing.T) {
+	rules, err := ParseRules("*=1/1m")
+	require.NoError(t, err)
+
+	l := New(failingBackend{}, rules)
+
+	for range 3 {
+		assert.Equal(t, http.StatusOK, serve(l, http.MethodGet, "/task", "7").Code)
+	}
+}
+
+func Test_MemorySweep(t *testing.T) {
+	m := NewMemory()
+	limit := ratelimit.Limit{Requests: 1, Per: time.Second, Burst: 1}
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	_, err := m.TakeRateLimit(context.Background(), "a", limit, now)
+	require.NoError(t, err)
+	_, err = m.TakeRateLimit(context.Background(), "b", ratelimit.Limit{Requests: 1, Per: 2 * time.Second, Burst: 1}, now.Add(sweepInterval-time.Second))
+	require.NoError(t, err)
+	require.Len(t, m.buckets, 2)
+
+	// "a" has been full for a while and is dropped; "b" is still refilling
+	_, err = m.TakeRateLimit(context.Background(), "c", limit, now.Add(sweepInterval))
+	require.NoError(t, err)
+
+	var keys []string
+	for k := range m.buckets {
+		keys = append(keys, k)
+	}
+
+	assert.ElementsMatch(t, []string{"b", "c"}, keys)
+}
+
+func Test_Idle(t *testing.T) {
+	rules, err := ParseRules("POST /task=10/1m:20, *=100/1s")
+	require.NoError(t, err)
+
+	assert.Equal(t, 2*time.Minute, New(NewMemory(), rules).Idle())
+	assert.Equal(t, "POST /task", rules[0].String())
+}
@@ -0,0 +1,95 @@
+// Package replica gives each client read-your-writes consistency while reads go to replicas. Requests
+// that may write read the primary throughout, and so do the requests of the same client for a window
+// after, long enough for the replicas to apply the writes. Clients are told apart as for rate limiting.
+package replica
+
+import (
+	"Task_Manager/ratelimit"
+	replicaStore "Task_Manager/store/replica"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Sessions remembers when each client last wrote. It is kept per instance: behind a load balancer
+// without sticky sessions, a client reading through another instance right after a write may read a
+// replica that has not applied it yet.
+type Sessions struct {
+	window time.Duration
+
+	mu     sync.Mutex
+	writes map[string]time.Time
+	swept  time.Time
+	now    func() time.Time
+}
+
+// NewSessions pins the reads of a client to the primary for window after each of its writes. window
+// should exceed the replication delay tolerated of a healthy replica.
+func NewSessions(window time.Duration) *Sessions {
+	return &Sessions{window: window, writes: make(map[string]time.Time), now: time.Now}
+}
+
+// Middleware pins the reads of a request to the primary when it may write or when its client wrote
+// recently. It must run after authentication, which identifies the client.
+func (s *Sessions) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		client := ratelimit.ClientKey(r)
+
+		if safe(r.Method) {
+			if s.recent(client) {
+				r = r.WithContext(replicaStore.Primary(r.Context()))
+			}
+
+			next.ServeHTTP(w, r)
+
+			return
+		}
+
+		// Recorded once the request is served, so the window starts after its last write
+		defer s.wrote(client)
+
+		next.ServeHTTP(w, r.WithContext(replicaStore.Primary(r.Context())))
+	})
+}
+
+func safe(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+func (s *Sessions) recent(client string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	at, ok := s.writes[client]
+	if !ok {
+		return false
+	}
+
+	if s.now().Sub(at) >= s.window {
+		delete(s.writes, client)
+		return false
+	}
+
+	return true
+}
+
+func (s *Sessions) wrote(client string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.writes[client] = now
+
+	// Clients that wrote once and never read again would otherwise be kept forever
+	if now.Sub(s.swept) < s.window {
+		return
+	}
+
+	for c, at := range s.writes {
+		if now.Sub(at) >= s.window {
+			delete(s.writes, c)
+		}
+	}
+
+	s.swept = now
+}
@@ -0,0 +1,49 @@
+package replica
+
+import (
+	"Task_Manager/request"
+	replicaStore "Task_Manager/store/replica"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Sessions(t *testing.T) {
+	now := time.Date(2024, 5, 7, 12, 0, 0, 0, time.UTC)
+
+	s := NewSessions(10 * time.Second)
+	s.now = func() time.Time { return now }
+
+	var pinned bool
+	h := s.Middleware(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		pinned = replicaStore.Pinned(r.Context())
+	}))
+
+	serve := func(method, ip string) bool {
+		r := httptest.NewRequest(method, "/task", nil)
+		r = r.WithContext(request.WithInfo(r.Context(), "id", ip))
+		h.ServeHTTP(httptest.NewRecorder(), r)
+
+		return pinned
+	}
+
+	assert.False(t, serve(http.MethodGet, "10.0.0.1"), "reads go to the replicas")
+	assert.True(t, serve(http.MethodPost, "10.0.0.1"), "a request that may write reads the primary")
+	assert.True(t, serve(http.MethodGet, "10.0.0.1"), "the client reads its own writes")
+	assert.False(t, serve(http.MethodGet, "10.0.0.2"), "other clients read the replicas")
+
+	now = now.Add(10 * time.Second)
+	assert.False(t, serve(http.MethodGet, "10.0.0.1"), "the replicas have caught up")
+	assert.Empty(t, s.writes)
+
+	t.Run("Idle clients are forgotten", func(t *testing.T) {
+		serve(http.MethodDelete, "10.0.0.3")
+		now = now.Add(time.Minute)
+		serve(http.MethodPut, "10.0.0.4")
+
+		assert.Len(t, s.writes, 1)
+	})
+}
@@ -0,0 +1,116 @@
+package request
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"regexp"
+)
+
+// HeaderID carries the ID correlating a request across services and logs
+const HeaderID = "X-Request-ID"
+
+// validID limits accepted incoming IDs to a safe, bounded alphabet so they can be logged verbatim
+var validID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+type info struct {
+	id       string
+	remoteIP string
+}
+
+type ctxKey struct{}
+
+// WithInfo returns a copy of ctx carrying the request ID and the client address
+func WithInfo(ctx context.Context, id, remoteIP string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, info{id: id, remoteIP: remoteIP})
+}
+
+// ID returns the request ID stored in ctx, or an empty string outside of a request
+func ID(ctx context.Context) string {
+	i, _ := ctx.Value(ctxKey{}).(info)
+	return i.id
+}
+
+// RemoteIP returns the client address stored in ctx, or an empty string outside of a request
+func RemoteIP(ctx context.Context) string {
+	i, _ := ctx.Value(ctxKey{}).(info)
+	return i.remoteIP
+}
+
+// Middleware propagates the caller's X-Request-ID, or assigns a new one, echoes it in the response
+// and stores it in the request context together with the client address
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := AcceptID(r.Header.Get(HeaderID))
+
+		w.Header().Set(HeaderID, id)
+
+		ip, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			ip = r.RemoteAddr
+		}
+
+		next.ServeHTTP(w, r.WithContext(WithInfo(r.Context(), id, ip)))
+	})
+}
+
+// AcceptID returns id when it is fit to be a request ID, and a new ID otherwise
+func AcceptID(id string) string {
+	if validID.MatchString(id) {
+		return id
+	}
+
+	return newID()
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
+
+// StatusWriter records the status and size of a response, for middlewares reporting on served requests
+type StatusWriter struct {
+	http.ResponseWriter
+	// Status is the status sent, or zero before anything was written
+	Status int
+	Bytes  int64
+}
+
+func NewStatusWriter(w http.ResponseWriter) *StatusWriter {
+	return &StatusWriter{ResponseWriter: w}
+}
+
+func (w *StatusWriter) WriteHeader(status int) {
+	if w.Status == 0 {
+		w.Status = status
+	}
+
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *StatusWriter) Write(b []byte) (int, error) {
+	if w.Status == 0 {
+		w.Status = http.StatusOK
+	}
+
+	n, err := w.ResponseWriter.Write(b)
+	w.Bytes += int64(n)
+
+	return n, err
+}
+
+// Flush keeps streamed responses, such as exports, streaming through the wrapper
+func (w *StatusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap gives http.ResponseController access to the underlying writer
+func (w *StatusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,77 @@
+package request
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Middleware(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		keepID   bool
+		expectIP string
+	}{
+		{"Propagates caller ID", "abc-123", true, "192.0.2.1"},
+		{"Assigns missing ID", "", false, "192.0.2.1"},
+		{"Replaces unsafe ID", "bad id\nwith newline", false, "192.0.2.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotID, gotIP string
+
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotID = ID(r.Context())
+				gotIP = RemoteIP(r.Context())
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(HeaderID, tt.header)
+			}
+
+			rec := httptest.NewRecorder()
+			Middleware(next).ServeHTTP(rec, req)
+
+			assert.NotEmpty(t, gotID)
+			assert.Equal(t, gotID, rec.Header().Get(HeaderID))
+			assert.Equal(t, tt.expectIP, gotIP)
+
+			if tt.keepID {
+				assert.Equal(t, tt.header, gotID)
+			} else {
+				assert.NotEqual(t, tt.header, gotID)
+			}
+		})
+	}
+}
+
+func Test_EmptyContext(t *testing.T) {
+	assert.Empty(t, ID(context.Background()))
+	assert.Empty(t, RemoteIP(context.Background()))
+}
+
+func Test_StatusWriter(t *testing.T) {
+	rec := httptest.NewRecorder()
+	sw := NewStatusWriter(rec)
+
+	sw.WriteHeader(http.StatusCreated)
+	sw.WriteHeader(http.StatusInternalServerError)
+	_, _ = sw.Write([]byte("hello"))
+	sw.Flush()
+
+	assert.Equal(t, http.StatusCreated, sw.Status)
+	assert.Equal(t, int64(5), sw.Bytes)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.True(t, rec.Flushed)
+	assert.Same(t, rec, sw.Unwrap())
+
+	implicit := NewStatusWriter(httptest.NewRecorder())
+	_, _ = implicit.Write([]byte("ok"))
+	assert.Equal(t, http.StatusOK, implicit.Status)
+}
@@ -0,0 +1,26 @@
+package attachment
+
+import (
+	"Task_Manager/model/attachment"
+	"Task_Manager/model/task"
+	"context"
+	"io"
+)
+
+type AttachmentStoreInterface interface {
+	CreateAttachment(ctx context.Context, a attachment.Attachment) (attachment.Attachment, error)
+	GetByIDAttachment(ctx context.Context, id int) (attachment.Attachment, error)
+	GetByTaskIDAttachment(ctx context.Context, taskID int) ([]attachment.Attachment, error)
+	DeleteAttachment(ctx context.Context, id int) error
+}
+
+// BlobStore keeps the content of attachments. Open must return an error matching fs.ErrNotExist for missing keys.
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, checksum string) error
+	Open(ctx context.Context, key string) (io.ReadSeekCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+type TaskServiceInterface interface {
+	GetTask(ctx context.Context, id int) (task.Task, error)
+}
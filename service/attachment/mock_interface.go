@@ -0,0 +1,209 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: interface.go
+//
+// Generated by this command:
+//
+//	mockgen -source=interface.go -destination=mock_interface.go -package=attachment
+//
+
+// Package attachment is a generated GoMock package.
+package attachment
+
+import (
+	attachment "Task_Manager/model/attachment"
+	task "Task_Manager/model/task"
+	context "context"
+	io "io"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAttachmentStoreInterface is a mock of AttachmentStoreInterface interface.
+type MockAttachmentStoreInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockAttachmentStoreInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockAttachmentStoreInterfaceMockRecorder is the mock recorder for MockAttachmentStoreInterface.
+type MockAttachmentStoreInterfaceMockRecorder struct {
+	mock *MockAttachmentStoreInterface
+}
+
+// NewMockAttachmentStoreInterface creates a new mock instance.
+func NewMockAttachmentStoreInterface(ctrl *gomock.Controller) *MockAttachmentStoreInterface {
+	mock := &MockAttachmentStoreInterface{ctrl: ctrl}
+	mock.recorder = &MockAttachmentStoreInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAttachmentStoreInterface) EXPECT() *MockAttachmentStoreInterfaceMockRecorder {
+	return m.recorder
+}
+
+// CreateAttachment mocks base method.
+func (m *MockAttachmentStoreInterface) CreateAttachment(ctx context.Context, a attachment.Attachment) (attachment.Attachment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAttachment", ctx, a)
+	ret0, _ := ret[0].(attachment.Attachment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAttachment indicates an expected call of CreateAttachment.
+func (mr *MockAttachmentStoreInterfaceMockRecorder) CreateAttachment(ctx, a any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAttachment", reflect.TypeOf((*MockAttachmentStoreInterface)(nil).CreateAttachment), ctx, a)
+}
+
+// DeleteAttachment mocks base method.
+func (m *MockAttachmentStoreInterface) DeleteAttachment(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAttachment", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAttachment indicates an expected call of DeleteAttachment.
+func (mr *MockAttachmentStoreInterfaceMockRecorder) DeleteAttachment(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAttachment", reflect.TypeOf((*MockAttachmentStoreInterface)(nil).DeleteAttachment), ctx, id)
+}
+
+// GetByIDAttachment mocks base method.
+func (m *MockAttachmentStoreInterface) GetByIDAttachment(ctx context.Context, id int) (attachment.Attachment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByIDAttachment", ctx, id)
+	ret0, _ := ret[0].(attachment.Attachment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByIDAttachment indicates an expected call of GetByIDAttachment.
+func (mr *MockAttachmentStoreInterfaceMockRecorder) GetByIDAttachment(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByIDAttachment", reflect.TypeOf((*MockAttachmentStoreInterface)(nil).GetByIDAttachment), ctx, id)
+}
+
+// GetByTaskIDAttachment mocks base method.
+func (m *MockAttachmentStoreInterface) GetByTaskIDAttachment(ctx context.Context, taskID int) ([]attachment.Attachment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByTaskIDAttachment", ctx, taskID)
+	ret0, _ := ret[0].([]attachment.Attachment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByTaskIDAttachment indicates an expected call of GetByTaskIDAttachment.
+func (mr *MockAttachmentStoreInterfaceMockRecorder) GetByTaskIDAttachment(ctx, taskID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByTaskIDAttachment", reflect.TypeOf((*MockAttachmentStoreInterface)(nil).GetByTaskIDAttachment), ctx, taskID)
+}
+
+// MockBlobStore is a mock of BlobStore interface.
+type MockBlobStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockBlobStoreMockRecorder
+	isgomock struct{}
+}
+
+// MockBlobStoreMockRecorder is the mock recorder for MockBlobStore.
+type MockBlobStoreMockRecorder struct {
+	mock *MockBlobStore
+}
+
+// NewMockBlobStore creates a new mock instance.
+func NewMockBlobStore(ctrl *gomock.Controller) *MockBlobStore {
+	mock := &MockBlobStore{ctrl: ctrl}
+	mock.recorder = &MockBlobStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBlobStore) EXPECT() *MockBlobStoreMockRecorder {
+	return m.recorder
+}
+
+// Delete mocks base method.
+func (m *MockBlobStore) Delete(ctx context.Context, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockBlobStoreMockRecorder) Delete(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockBlobStore)(nil).Delete), ctx, key)
+}
+
+// Open mocks base method.
+func (m *MockBlobStore) Open(ctx context.Context, key string) (io.ReadSeekCloser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Open", ctx, key)
+	ret0, _ := ret[0].(io.ReadSeekCloser)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Open indicates an expected call of Open.
+func (mr *MockBlobStoreMockRecorder) Open(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Open", reflect.TypeOf((*MockBlobStore)(nil).Open), ctx, key)
+}
+
+// Put mocks base method.
+func (m *MockBlobStore) Put(ctx context.Context, key string, r io.Reader, size int64, checksum string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Put", ctx, key, r, size, checksum)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Put indicates an expected call of Put.
+func (mr *MockBlobStoreMockRecorder) Put(ctx, key, r, size, checksum any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Put", reflect.TypeOf((*MockBlobStore)(nil).Put), ctx, key, r, size, checksum)
+}
+
+// MockTaskServiceInterface is a mock of TaskServiceInterface interface.
+type MockTaskServiceInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockTaskServiceInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockTaskServiceInterfaceMockRecorder is the mock recorder for MockTaskServiceInterface.
+type MockTaskServiceInterfaceMockRecorder struct {
+	mock *MockTaskServiceInterface
+}
+
+// NewMockTaskServiceInterface creates a new mock instance.
+func NewMockTaskServiceInterface(ctrl *gomock.Controller) *MockTaskServiceInterface {
+	mock := &MockTaskServiceInterface{ctrl: ctrl}
+	mock.recorder = &MockTaskServiceInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTaskServiceInterface) EXPECT() *MockTaskServiceInterfaceMockRecorder {
+	return m.recorder
+}
+
+// GetTask mocks base method.
+func (m *MockTaskServiceInterface) GetTask(ctx context.Context, id int) (task.Task, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTask", ctx, id)
+	ret0, _ := ret[0].(task.Task)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTask indicates an expected call of GetTask.
+func (mr *MockTaskServiceInterfaceMockRecorder) GetTask(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTask", reflect.TypeOf((*MockTaskServiceInterface)(nil).GetTask), ctx, id)
+}
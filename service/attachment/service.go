@@ -0,0 +1,276 @@
+package attachment
+
+import (
+	"Task_Manager/auth"
+	"Task_Manager/model/attachment"
+	"Task_Manager/tracing"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// tracer records a span for every service method
+var tracer = tracing.Tracer("service/attachment")
+
+// sniffLen is the number of leading bytes http.DetectContentType looks at
+const sniffLen = 512
+
+// Limits restricts what may be uploaded. An empty AllowedTypes list allows every type.
+type Limits struct {
+	MaxBytes     int64
+	AllowedTypes []string
+}
+
+type AttachmentService struct {
+	str            AttachmentStoreInterface
+	blobs          BlobStore
+	taskServiceref TaskServiceInterface
+	limits         Limits
+}
+
+func NewService(s AttachmentStoreInterface, b BlobStore, ts TaskServiceInterface, limits Limits) *AttachmentService {
+	return &AttachmentService{
+		str:            s,
+		blobs:          b,
+		taskServiceref: ts,
+		limits:         limits,
+	}
+}
+
+// Upload streams r to a temporary file while enforcing the size limit and hashing it, checks the detected
+// MIME type and the optional expected SHA-256, then stores the content in the blob store.
+func (s *AttachmentService) Upload(ctx context.Context, actor auth.Actor, taskID int, filename string, r io.Reader,
+	expectedChecksum string) (attachment.Attachment, error) {
+	ctx, span := tracer.Start(ctx, "AttachmentService.Upload")
+	defer span.End()
+
+	if actor.UserID == 0 {
+		return attachment.Attachment{}, attachment.ErrForbidden
+	}
+
+	a := attachment.Attachment{TaskID: taskID, Filename: cleanFilename(filename), UploaderID: actor.UserID}
+
+	if err := a.Validate(); err != nil {
+		return a, err
+	}
+
+	if _, err := s.taskServiceref.GetTask(ctx, taskID); err != nil {
+		return a, fmt.Errorf("task with ID %d does not exist: %w", taskID, err)
+	}
+
+	tmp, err := os.CreateTemp("", "attachment-*")
+	if err != nil {
+		return a, err
+	}
+
+	defer func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+	}()
+
+	hash := sha256.New()
+	head := &prefixWriter{max: sniffLen}
+
+	size, err := io.Copy(io.MultiWriter(tmp, hash, head), io.LimitReader(r, s.limits.MaxBytes+1))
+	if err != nil {
+		return a, err
+	}
+
+	if size > s.limits.MaxBytes {
+		return a, attachment.ErrTooLarge
+	}
+
+	a.Size = size
+	a.Checksum = hex.EncodeToString(hash.Sum(nil))
+	a.ContentType = http.DetectContentType(head.buf)
+
+	if expectedChecksum != "" && !strings.EqualFold(expectedChecksum, a.Checksum) {
+		return a, attachment.ErrChecksumMismatch
+	}
+
+	if !s.allowed(a.ContentType) {
+		return a, fmt.Errorf("%w: %s", attachment.ErrTypeNotAllowed, a.ContentType)
+	}
+
+	if _, err = tmp.Seek(0, io.SeekStart); err != nil {
+		return a, err
+	}
+
+	if a.StorageKey, err = newKey(taskID); err != nil {
+		return a, err
+	}
+
+	if err = s.blobs.Put(ctx, a.StorageKey, tmp, a.Size, a.Checksum); err != nil {
+		return a, err
+	}
+
+	created, err := s.str.CreateAttachment(ctx, a)
+	if err != nil {
+		_ = s.blobs.Delete(ctx, a.StorageKey)
+		return a, err
+	}
+
+	return created, nil
+}
+
+// List returns the attachments of a task
+func (s *AttachmentService) List(ctx context.Context, taskID int) ([]attachment.Attachment, error) {
+	ctx, span := tracer.Start(ctx, "AttachmentService.List")
+	defer span.End()
+
+	if _, err := s.taskServiceref.GetTask(ctx, taskID); err != nil {
+		return nil, fmt.Errorf("task with ID %d does not exist: %w", taskID, err)
+	}
+
+	return s.str.GetByTaskIDAttachment(ctx, taskID)
+}
+
+// Open returns an attachment with a seekable reader over its content. The caller must close the reader.
+// Attachments of a task in the trash stay hidden until it is restored.
+func (s *AttachmentService) Open(ctx context.Context, taskID, id int) (attachment.Attachment, io.ReadSeekCloser, error) {
+	ctx, span := tracer.Start(ctx, "AttachmentService.Open")
+	defer span.End()
+
+	a, err := s.get(ctx, taskID, id)
+	if err != nil {
+		return a, nil, err
+	}
+
+	if _, err = s.taskServiceref.GetTask(ctx, taskID); err != nil {
+		return a, nil, fmt.Errorf("task with ID %d does not exist: %w", taskID, err)
+	}
+
+	content, err := s.blobs.Open(ctx, a.StorageKey)
+	if errors.Is(err, fs.ErrNotExist) {
+		return a, nil, attachment.ErrNotFound
+	}
+
+	return a, content, err
+}
+
+// Delete removes an attachment and its content. Only the uploader or an admin may delete.
+func (s *AttachmentService) Delete(ctx context.Context, actor auth.Actor, taskID, id int) error {
+	ctx, span := tracer.Start(ctx, "AttachmentService.Delete")
+	defer span.End()
+
+	a, err := s.get(ctx, taskID, id)
+	if err != nil {
+		return err
+	}
+
+	if !actor.Admin && (actor.UserID == 0 || actor.UserID != a.UploaderID) {
+		return attachment.ErrForbidden
+	}
+
+	if err = s.str.DeleteAttachment(ctx, id); err != nil {
+		return err
+	}
+
+	return s.blobs.Delete(ctx, a.StorageKey)
+}
+
+// DeleteForTask removes every attachment of a task. It is registered as a task deletion hook.
+func (s *AttachmentService) DeleteForTask(ctx context.Context, taskID int) error {
+	ctx, span := tracer.Start(ctx, "AttachmentService.DeleteForTask")
+	defer span.End()
+
+	attachments, err := s.str.GetByTaskIDAttachment(ctx, taskID)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+
+	for _, a := range attachments {
+		if err := s.str.DeleteAttachment(ctx, a.ID); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if err := s.blobs.Delete(ctx, a.StorageKey); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (s *AttachmentService) get(ctx context.Context, taskID, id int) (attachment.Attachment, error) {
+	a, err := s.str.GetByIDAttachment(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return a, attachment.ErrNotFound
+	}
+
+	if err != nil {
+		return a, err
+	}
+
+	if a.TaskID != taskID {
+		return a, attachment.ErrNotFound
+	}
+
+	return a, nil
+}
+
+func (s *AttachmentService) allowed(contentType string) bool {
+	if len(s.limits.AllowedTypes) == 0 {
+		return true
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	for _, t := range s.limits.AllowedTypes {
+		if strings.EqualFold(t, mediaType) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// cleanFilename strips any directory components a client may send along with the file name
+func cleanFilename(name string) string {
+	base := filepath.Base(filepath.Clean("/" + strings.ReplaceAll(name, "\\", "/")))
+	if base == "/" || base == "." {
+		return ""
+	}
+
+	return base
+}
+
+func newKey(taskID int) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("tasks/%d/%s", taskID, hex.EncodeToString(b)), nil
+}
+
+// prefixWriter keeps the first max bytes written to it
+type prefixWriter struct {
+	buf []byte
+	max int
+}
+
+func (p *prefixWriter) Write(b []byte) (int, error) {
+	if room := p.max - len(p.buf); room > 0 {
+		p.buf = append(p.buf, b[:min(room, len(b))]...)
+	}
+
+	return len(b), nil
+}
@@ -0,0 +1,272 @@
+package attachment
+
+import (
+	"Task_Manager/auth"
+	"Task_Manager/model/attachment"
+	"Task_Manager/model/task"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+type mocks struct {
+	store *MockAttachmentStoreInterface
+	blobs *MockBlobStore
+	tasks *MockTaskServiceInterface
+}
+
+func newTestService(t *testing.T, limits Limits) (*AttachmentService, mocks) {
+	ctrl := gomock.NewController(t)
+	m := mocks{
+		store: NewMockAttachmentStoreInterface(ctrl),
+		blobs: NewMockBlobStore(ctrl),
+		tasks: NewMockTaskServiceInterface(ctrl),
+	}
+
+	return NewService(m.store, m.blobs, m.tasks, limits), m
+}
+
+func sum(s string) string {
+	h := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(h[:])
+}
+
+type nopSeeker struct {
+	*strings.Reader
+}
+
+func (nopSeeker) Close() error {
+	return nil
+}
+
+func Test_Upload(t *testing.T) {
+	ctx := context.Background()
+	limits := Limits{MaxBytes: 16, AllowedTypes: []string{"text/plain", "image/png"}}
+	actor := auth.Actor{UserID: 3}
+
+	t.Run("Success", func(t *testing.T) {
+		svc, m := newTestService(t, limits)
+
+		m.tasks.EXPECT().GetTask(gomock.Any(), 1).Return(task.Task{ID: 1}, nil)
+		m.blobs.EXPECT().Put(gomock.Any(), gomock.Any(), gomock.Any(), int64(5), sum("hello")).
+			DoAndReturn(func(_ context.Context, key string, r io.Reader, _ int64, _ string) error {
+				body, _ := io.ReadAll(r)
+				assert.Equal(t, "hello", string(body))
+				assert.True(t, strings.HasPrefix(key, "tasks/1/"))
+
+				return nil
+			})
+		m.store.EXPECT().CreateAttachment(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, a attachment.Attachment) (attachment.Attachment, error) {
+			a.ID = 9
+			return a, nil
+		})
+
+		a, err := svc.Upload(ctx, actor, 1, "../../logs/run.txt", strings.NewReader("hello"), strings.ToUpper(sum("hello")))
+
+		assert.NoError(t, err)
+		assert.Equal(t, 9, a.ID)
+		assert.Equal(t, "run.txt", a.Filename)
+		assert.Equal(t, "text/plain; charset=utf-8", a.ContentType)
+		assert.Equal(t, 3, a.UploaderID)
+	})
+
+	t.Run("Missing filename", func(t *testing.T) {
+		svc, _ := newTestService(t, limits)
+
+		_, err := svc.Upload(ctx, actor, 1, "", strings.NewReader("hello"), "")
+		assert.ErrorIs(t, err, attachment.ErrMissingFilename)
+	})
+
+	t.Run("Anonymous", func(t *testing.T) {
+		svc, _ := newTestService(t, limits)
+
+		_, err := svc.Upload(ctx, auth.Actor{}, 1, "a.txt", strings.NewReader("hello"), "")
+		assert.ErrorIs(t, err, attachment.ErrForbidden)
+	})
+
+	t.Run("Unknown task", func(t *testing.T) {
+		svc, m := newTestService(t, limits)
+		m.tasks.EXPECT().GetTask(gomock.Any(), 1).Return(task.Task{}, sql.ErrNoRows)
+
+		_, err := svc.Upload(ctx, actor, 1, "a.txt", strings.NewReader("hello"), "")
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	})
+
+	t.Run("Too large", func(t *testing.T) {
+		svc, m := newTestService(t, limits)
+		m.tasks.EXPECT().GetTask(gomock.Any(), 1).Return(task.Task{ID: 1}, nil)
+
+		_, err := svc.Upload(ctx, actor, 1, "a.txt", strings.NewReader(strings.Repeat("x", 17)), "")
+		assert.ErrorIs(t, err, attachment.ErrTooLarge)
+	})
+
+	t.Run("Checksum mismatch", func(t *testing.T) {
+		svc, m := newTestService(t, limits)
+		m.tasks.EXPECT().GetTask(gomock.Any(), 1).Return(task.Task{ID: 1}, nil)
+
+		_, err := svc.Upload(ctx, actor, 1, "a.txt", strings.NewReader("hello"), sum("hallo"))
+		assert.ErrorIs(t, err, attachment.ErrChecksumMismatch)
+	})
+
+	t.Run("Type not allowed", func(t *testing.T) {
+		svc, m := newTestService(t, limits)
+		m.tasks.EXPECT().GetTask(gomock.Any(), 1).Return(task.Task{ID: 1}, nil)
+
+		_, err := svc.Upload(ctx, actor, 1, "a.pdf", strings.NewReader("%PDF-1.4"), "")
+		assert.ErrorIs(t, err, attachment.ErrTypeNotAllowed)
+	})
+
+	t.Run("Metadata failure removes blob", func(t *testing.T) {
+		svc, m := newTestService(t, limits)
+		m.tasks.EXPECT().GetTask(gomock.Any(), 1).Return(task.Task{ID: 1}, nil)
+		m.blobs.EXPECT().Put(gomock.Any(), gomock.Any(), gomock.Any(), int64(5), gomock.Any()).Return(nil)
+		m.store.EXPECT().CreateAttachment(gomock.Any(), gomock.Any()).Return(attachment.Attachment{}, errors.New("db down"))
+		m.blobs.EXPECT().Delete(gomock.Any(), gomock.Any()).Return(nil)
+
+		_, err := svc.Upload(ctx, actor, 1, "a.txt", strings.NewReader("hello"), "")
+		assert.EqualError(t, err, "db down")
+	})
+}
+
+func Test_List(t *testing.T) {
+	svc, m := newTestService(t, Limits{})
+
+	m.tasks.EXPECT().GetTask(gomock.Any(), 1).Return(task.Task{ID: 1}, nil)
+	m.store.EXPECT().GetByTaskIDAttachment(gomock.Any(), 1).Return([]attachment.Attachment{{ID: 1}}, nil)
+
+	list, err := svc.List(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+
+	m.tasks.EXPECT().GetTask(gomock.Any(), 2).Return(task.Task{}, sql.ErrNoRows)
+
+	_, err = svc.List(context.Background(), 2)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func Test_Open(t *testing.T) {
+	ctx := context.Background()
+	stored := attachment.Attachment{ID: 4, TaskID: 1, StorageKey: "tasks/1/k"}
+
+	t.Run("Success", func(t *testing.T) {
+		svc, m := newTestService(t, Limits{})
+		m.store.EXPECT().GetByIDAttachment(gomock.Any(), 4).Return(stored, nil)
+		m.tasks.EXPECT().GetTask(gomock.Any(), 1).Return(task.Task{ID: 1}, nil)
+		m.blobs.EXPECT().Open(gomock.Any(), "tasks/1/k").Return(nopSeeker{strings.NewReader("data")}, nil)
+
+		a, rc, err := svc.Open(ctx, 1, 4)
+		assert.NoError(t, err)
+		assert.Equal(t, 4, a.ID)
+		assert.NotNil(t, rc)
+	})
+
+	t.Run("Other task", func(t *testing.T) {
+		svc, m := newTestService(t, Limits{})
+		m.store.EXPECT().GetByIDAttachment(gomock.Any(), 4).Return(stored, nil)
+
+		_, _, err := svc.Open(ctx, 2, 4)
+		assert.ErrorIs(t, err, attachment.ErrNotFound)
+	})
+
+	t.Run("Missing metadata", func(t *testing.T) {
+		svc, m := newTestService(t, Limits{})
+		m.store.EXPECT().GetByIDAttachment(gomock.Any(), 4).Return(attachment.Attachment{}, sql.ErrNoRows)
+
+		_, _, err := svc.Open(ctx, 1, 4)
+		assert.ErrorIs(t, err, attachment.ErrNotFound)
+	})
+
+	t.Run("Trashed task", func(t *testing.T) {
+		svc, m := newTestService(t, Limits{})
+		m.store.EXPECT().GetByIDAttachment(gomock.Any(), 4).Return(stored, nil)
+		m.tasks.EXPECT().GetTask(gomock.Any(), 1).Return(task.Task{}, task.ErrNotFound)
+
+		_, _, err := svc.Open(ctx, 1, 4)
+		assert.ErrorIs(t, err, task.ErrNotFound)
+	})
+
+	t.Run("Missing blob", func(t *testing.T) {
+		svc, m := newTestService(t, Limits{})
+		m.store.EXPECT().GetByIDAttachment(gomock.Any(), 4).Return(stored, nil)
+		m.tasks.EXPECT().GetTask(gomock.Any(), 1).Return(task.Task{ID: 1}, nil)
+		m.blobs.EXPECT().Open(gomock.Any(), "tasks/1/k").Return(nil, fs.ErrNotExist)
+
+		_, _, err := svc.Open(ctx, 1, 4)
+		assert.ErrorIs(t, err, attachment.ErrNotFound)
+	})
+}
+
+func Test_Delete(t *testing.T) {
+	ctx := context.Background()
+	stored := attachment.Attachment{ID: 4, TaskID: 1, UploaderID: 3, StorageKey: "tasks/1/k"}
+
+	tests := []struct {
+		name   string
+		actor  auth.Actor
+		expErr error
+	}{
+		{"Uploader", auth.Actor{UserID: 3}, nil},
+		{"Admin", auth.Actor{UserID: 1, Admin: true}, nil},
+		{"Other user", auth.Actor{UserID: 5}, attachment.ErrForbidden},
+		{"Anonymous", auth.Actor{}, attachment.ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, m := newTestService(t, Limits{})
+			m.store.EXPECT().GetByIDAttachment(gomock.Any(), 4).Return(stored, nil)
+
+			if tt.expErr == nil {
+				m.store.EXPECT().DeleteAttachment(gomock.Any(), 4).Return(nil)
+				m.blobs.EXPECT().Delete(gomock.Any(), "tasks/1/k").Return(nil)
+			}
+
+			assert.ErrorIs(t, svc.Delete(ctx, tt.actor, 1, 4), tt.expErr)
+		})
+	}
+}
+
+func Test_DeleteForTask(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Removes everything", func(t *testing.T) {
+		svc, m := newTestService(t, Limits{})
+		m.store.EXPECT().GetByTaskIDAttachment(gomock.Any(), 1).Return([]attachment.Attachment{
+			{ID: 1, StorageKey: "a"}, {ID: 2, StorageKey: "b"},
+		}, nil)
+		m.store.EXPECT().DeleteAttachment(gomock.Any(), 1).Return(nil)
+		m.blobs.EXPECT().Delete(gomock.Any(), "a").Return(nil)
+		m.store.EXPECT().DeleteAttachment(gomock.Any(), 2).Return(nil)
+		m.blobs.EXPECT().Delete(gomock.Any(), "b").Return(nil)
+
+		assert.NoError(t, svc.DeleteForTask(ctx, 1))
+	})
+
+	t.Run("Continues after failures", func(t *testing.T) {
+		svc, m := newTestService(t, Limits{})
+		m.store.EXPECT().GetByTaskIDAttachment(gomock.Any(), 1).Return([]attachment.Attachment{
+			{ID: 1, StorageKey: "a"}, {ID: 2, StorageKey: "b"},
+		}, nil)
+		m.store.EXPECT().DeleteAttachment(gomock.Any(), 1).Return(errors.New("db down"))
+		m.store.EXPECT().DeleteAttachment(gomock.Any(), 2).Return(nil)
+		m.blobs.EXPECT().Delete(gomock.Any(), "b").Return(nil)
+
+		assert.EqualError(t, svc.DeleteForTask(ctx, 1), "db down")
+	})
+
+	t.Run("List failure", func(t *testing.T) {
+		svc, m := newTestService(t, Limits{})
+		m.store.EXPECT().GetByTaskIDAttachment(gomock.Any(), 1).Return(nil, sql.ErrConnDone)
+
+		assert.ErrorIs(t, svc.DeleteForTask(ctx, 1), sql.ErrConnDone)
+	})
+}
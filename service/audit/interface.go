@@ -0,0 +1,13 @@
+package audit
+
+import (
+	"Task_Manager/model/audit"
+	"context"
+)
+
+type AuditStoreInterface interface {
+	AppendAudit(ctx context.Context, e audit.Entry) (audit.Entry, error)
+	QueryAudit(ctx context.Context, f audit.Filter) ([]audit.Entry, error)
+	EachAudit(ctx context.Context, f audit.Filter, fn func(audit.Entry) error) error
+	AuditHead(ctx context.Context) (string, error)
+}
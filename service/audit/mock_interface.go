@@ -0,0 +1,101 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: interface.go
+//
+// Generated by this command:
+//
+//	mockgen -source=interface.go -destination=mock_interface.go -package=audit
+//
+
+// Package audit is a generated GoMock package.
+package audit
+
+import (
+	audit "Task_Manager/model/audit"
+	context "context"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAuditStoreInterface is a mock of AuditStoreInterface interface.
+type MockAuditStoreInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuditStoreInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockAuditStoreInterfaceMockRecorder is the mock recorder for MockAuditStoreInterface.
+type MockAuditStoreInterfaceMockRecorder struct {
+	mock *MockAuditStoreInterface
+}
+
+// NewMockAuditStoreInterface creates a new mock instance.
+func NewMockAuditStoreInterface(ctrl *gomock.Controller) *MockAuditStoreInterface {
+	mock := &MockAuditStoreInterface{ctrl: ctrl}
+	mock.recorder = &MockAuditStoreInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuditStoreInterface) EXPECT() *MockAuditStoreInterfaceMockRecorder {
+	return m.recorder
+}
+
+// AppendAudit mocks base method.
+func (m *MockAuditStoreInterface) AppendAudit(ctx context.Context, e audit.Entry) (audit.Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AppendAudit", ctx, e)
+	ret0, _ := ret[0].(audit.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AppendAudit indicates an expected call of AppendAudit.
+func (mr *MockAuditStoreInterfaceMockRecorder) AppendAudit(ctx, e any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AppendAudit", reflect.TypeOf((*MockAuditStoreInterface)(nil).AppendAudit), ctx, e)
+}
+
+// AuditHead mocks base method.
+func (m *MockAuditStoreInterface) AuditHead(ctx context.Context) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuditHead", ctx)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AuditHead indicates an expected call of AuditHead.
+func (mr *MockAuditStoreInterfaceMockRecorder) AuditHead(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuditHead", reflect.TypeOf((*MockAuditStoreInterface)(nil).AuditHead), ctx)
+}
+
+// EachAudit mocks base method.
+func (m *MockAuditStoreInterface) EachAudit(ctx context.Context, f audit.Filter, fn func(audit.Entry) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EachAudit", ctx, f, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EachAudit indicates an expected call of EachAudit.
+func (mr *MockAuditStoreInterfaceMockRecorder) EachAudit(ctx, f, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EachAudit", reflect.TypeOf((*MockAuditStoreInterface)(nil).EachAudit), ctx, f, fn)
+}
+
+// QueryAudit mocks base method.
+func (m *MockAuditStoreInterface) QueryAudit(ctx context.Context, f audit.Filter) ([]audit.Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "QueryAudit", ctx, f)
+	ret0, _ := ret[0].([]audit.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryAudit indicates an expected call of QueryAudit.
+func (mr *MockAuditStoreInterfaceMockRecorder) QueryAudit(ctx, f any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryAudit", reflect.TypeOf((*MockAuditStoreInterface)(nil).QueryAudit), ctx, f)
+}
@@ -0,0 +1,138 @@
+package audit
+
+import (
+	"Task_Manager/auth"
+	"Task_Manager/model/audit"
+	"Task_Manager/request"
+	"Task_Manager/tracing"
+	"context"
+)
+
+// tracer records a span for every service method
+var tracer = tracing.Tracer("service/audit")
+
+// DefaultLimit is the page size of Query when the filter sets none
+const DefaultLimit = 100
+
+type AuditService struct {
+	str AuditStoreInterface
+}
+
+func NewService(s AuditStoreInterface) *AuditService {
+	return &AuditService{str: s}
+}
+
+// Record appends an entry for a mutation of an entity, in the unit of work of ctx when there is one so the
+// entry and the mutation commit together. The actor, request ID and source IP are taken from ctx; before
+// and after are the entity states, either of which may be nil.
+func (s *AuditService) Record(ctx context.Context, action, entity string, entityID int, before, after any) error {
+	ctx, span := tracer.Start(ctx, "AuditService.Record")
+	defer span.End()
+
+	changes, err := audit.Diff(before, after)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.str.AppendAudit(ctx, audit.Entry{
+		ActorID:   auth.FromContext(ctx).UserID,
+		Action:    action,
+		Entity:    entity,
+		EntityID:  entityID,
+		Changes:   changes,
+		RequestID: request.ID(ctx),
+		SourceIP:  request.RemoteIP(ctx),
+	})
+
+	return err
+}
+
+// Query returns one page of entries matching the filter
+func (s *AuditService) Query(ctx context.Context, actor auth.Actor, f audit.Filter) ([]audit.Entry, error) {
+	ctx, span := tracer.Start(ctx, "AuditService.Query")
+	defer span.End()
+
+	if !actor.Admin {
+		return nil, audit.ErrForbidden
+	}
+
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+
+	if f.Limit == 0 {
+		f.Limit = DefaultLimit
+	}
+
+	return s.str.QueryAudit(ctx, f)
+}
+
+// Export streams every entry matching the filter to fn; a zero Limit exports them all
+func (s *AuditService) Export(ctx context.Context, actor auth.Actor, f audit.Filter, fn func(audit.Entry) error) error {
+	ctx, span := tracer.Start(ctx, "AuditService.Export")
+	defer span.End()
+
+	if !actor.Admin {
+		return audit.ErrForbidden
+	}
+
+	if err := f.Validate(); err != nil {
+		return err
+	}
+
+	return s.str.EachAudit(ctx, f, fn)
+}
+
+// Verify walks the whole log and reports the first entry whose hash or link to its predecessor does not
+// match. The head is read before the walk and must be the hash of one of the entries walked, so entries
+// removed from the end of the log are caught too; entries appended meanwhile chain on after it.
+func (s *AuditService) Verify(ctx context.Context, actor auth.Actor) (audit.VerifyReport, error) {
+	ctx, span := tracer.Start(ctx, "AuditService.Verify")
+	defer span.End()
+
+	report := audit.VerifyReport{OK: true}
+
+	if !actor.Admin {
+		return report, audit.ErrForbidden
+	}
+
+	head, err := s.str.AuditHead(ctx)
+	if err != nil {
+		return report, err
+	}
+
+	var (
+		prev   string
+		lastID int64
+	)
+
+	reached := head == ""
+
+	err = s.str.EachAudit(ctx, audit.Filter{}, func(e audit.Entry) error {
+		report.Entries++
+
+		if report.OK && !e.Follows(prev) {
+			report.OK = false
+			report.BrokenAt = e.ID
+		}
+
+		if e.Hash == head {
+			reached = true
+		}
+
+		prev, lastID = e.Hash, e.ID
+
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+
+	// The log ends before its head: the entries after the last one walked are missing
+	if report.OK && !reached {
+		report.OK = false
+		report.BrokenAt = lastID + 1
+	}
+
+	return report, nil
+}
@@ -0,0 +1,161 @@
+package audit
+
+import (
+	"Task_Manager/auth"
+	"Task_Manager/model/audit"
+	"Task_Manager/model/task"
+	"Task_Manager/request"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+var admin = auth.Actor{UserID: 1, Admin: true}
+
+func Test_Record(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockStore := NewMockAuditStoreInterface(ctrl)
+	service := NewService(mockStore)
+
+	ctx := auth.WithActor(context.Background(), auth.Actor{UserID: 7})
+	ctx = request.WithInfo(ctx, "req-1", "192.0.2.1")
+
+	mockStore.EXPECT().AppendAudit(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, e audit.Entry) (audit.Entry, error) {
+		assert.Equal(t, 7, e.ActorID)
+		assert.Equal(t, audit.ActionComplete, e.Action)
+		assert.Equal(t, audit.EntityTask, e.Entity)
+		assert.Equal(t, 3, e.EntityID)
+		assert.Equal(t, "req-1", e.RequestID)
+		assert.Equal(t, "192.0.2.1", e.SourceIP)
+		assert.JSONEq(t, `{"status":{"before":false,"after":true}}`, string(e.Changes))
+
+		return e, nil
+	})
+
+	before := task.Task{ID: 3, Desc: "Ship", Userid: 2}
+	after := before
+	after.Status = true
+
+	assert.NoError(t, service.Record(ctx, audit.ActionComplete, audit.EntityTask, 3, before, after))
+
+	mockStore.EXPECT().AppendAudit(gomock.Any(), gomock.Any()).Return(audit.Entry{}, errors.New("db down"))
+	assert.Error(t, service.Record(ctx, audit.ActionDelete, audit.EntityTask, 3, before, nil))
+}
+
+func Test_Query(t *testing.T) {
+	tests := []struct {
+		name      string
+		actor     auth.Actor
+		filter    audit.Filter
+		callStore bool
+		expErr    error
+	}{
+		{"Admin with default limit", admin, audit.Filter{Entity: "task"}, true, nil},
+		{"Not an admin", auth.Actor{UserID: 2}, audit.Filter{}, false, audit.ErrForbidden},
+		{"Limit too large", admin, audit.Filter{Limit: audit.MaxLimit + 1}, false, audit.ErrInvalidFilter},
+		{"Until before since", admin, audit.Filter{Since: time.Now(), Until: time.Now().Add(-time.Hour)}, false, audit.ErrInvalidFilter},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockStore := NewMockAuditStoreInterface(ctrl)
+			service := NewService(mockStore)
+
+			if tt.callStore {
+				expected := tt.filter
+				expected.Limit = DefaultLimit
+				mockStore.EXPECT().QueryAudit(gomock.Any(), expected).Return([]audit.Entry{{ID: 1}}, nil)
+			}
+
+			_, err := service.Query(context.Background(), tt.actor, tt.filter)
+			if tt.expErr != nil {
+				assert.ErrorIs(t, err, tt.expErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func Test_Export(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockStore := NewMockAuditStoreInterface(ctrl)
+	service := NewService(mockStore)
+
+	mockStore.EXPECT().EachAudit(gomock.Any(), audit.Filter{Action: "delete"}, gomock.Any()).Return(nil)
+	assert.NoError(t, service.Export(context.Background(), admin, audit.Filter{Action: "delete"}, func(audit.Entry) error { return nil }))
+
+	assert.ErrorIs(t, service.Export(context.Background(), auth.Actor{}, audit.Filter{}, nil), audit.ErrForbidden)
+}
+
+func Test_Verify(t *testing.T) {
+	chain := func() []audit.Entry {
+		var (
+			entries []audit.Entry
+			prev    string
+		)
+
+		for i := 1; i <= 3; i++ {
+			e := audit.Entry{ID: int64(i), At: time.Unix(int64(i), 0), ActorID: 1, Action: "create", Entity: "task", EntityID: i, PrevHash: prev}
+			e.Hash = e.ComputeHash()
+			prev = e.Hash
+			entries = append(entries, e)
+		}
+
+		return entries
+	}
+
+	tampered := chain()
+	tampered[1].ActorID = 9
+
+	removed := chain()
+	removed = append(removed[:1], removed[2:]...)
+
+	head := chain()[2].Hash
+
+	tests := []struct {
+		name     string
+		entries  []audit.Entry
+		head     string
+		expOK    bool
+		expBreak int64
+	}{
+		{"Intact chain", chain(), head, true, 0},
+		{"Empty log", nil, "", true, 0},
+		{"Appended during the walk", chain(), chain()[1].Hash, true, 0},
+		{"Tampered entry", tampered, head, false, 2},
+		{"Removed entry", removed, head, false, 3},
+		{"Truncated tail", chain()[:2], head, false, 3},
+		{"Truncated log", nil, head, false, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockStore := NewMockAuditStoreInterface(ctrl)
+			service := NewService(mockStore)
+
+			mockStore.EXPECT().AuditHead(gomock.Any()).Return(tt.head, nil)
+			mockStore.EXPECT().EachAudit(gomock.Any(), audit.Filter{}, gomock.Any()).DoAndReturn(func(_ context.Context, _ audit.Filter, fn func(audit.Entry) error) error {
+				for _, e := range tt.entries {
+					if err := fn(e); err != nil {
+						return err
+					}
+				}
+
+				return nil
+			})
+
+			report, err := service.Verify(context.Background(), admin)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expOK, report.OK)
+			assert.Equal(t, tt.expBreak, report.BrokenAt)
+			assert.Equal(t, len(tt.entries), report.Entries)
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package comment
+
+import (
+	"Task_Manager/model/comment"
+	"Task_Manager/model/task"
+	"Task_Manager/model/user"
+	"context"
+)
+
+type CommentStoreInterface interface {
+	CreateComment(ctx context.Context, c comment.Comment) (comment.Comment, error)
+	GetByIDComment(ctx context.Context, id int) (comment.Comment, error)
+	GetByTaskIDComment(ctx context.Context, taskID int) ([]comment.Comment, error)
+	UpdateComment(ctx context.Context, id, editorID int, body string) error
+	DeleteComment(ctx context.Context, id int) error
+	GetEditsComment(ctx context.Context, id int) ([]comment.Edit, error)
+}
+
+type TaskServiceInterface interface {
+	GetTask(ctx context.Context, id int) (task.Task, error)
+}
+
+type UserServiceInterface interface {
+	Get(ctx context.Context, id int) (user.User, error)
+}
@@ -0,0 +1,210 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: interface.go
+//
+// Generated by this command:
+//
+//	mockgen -source=interface.go -destination=mock_interface.go -package=comment
+//
+
+// Package comment is a generated GoMock package.
+package comment
+
+import (
+	comment "Task_Manager/model/comment"
+	task "Task_Manager/model/task"
+	user "Task_Manager/model/user"
+	context "context"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockCommentStoreInterface is a mock of CommentStoreInterface interface.
+type MockCommentStoreInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockCommentStoreInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockCommentStoreInterfaceMockRecorder is the mock recorder for MockCommentStoreInterface.
+type MockCommentStoreInterfaceMockRecorder struct {
+	mock *MockCommentStoreInterface
+}
+
+// NewMockCommentStoreInterface creates a new mock instance.
+func NewMockCommentStoreInterface(ctrl *gomock.Controller) *MockCommentStoreInterface {
+	mock := &MockCommentStoreInterface{ctrl: ctrl}
+	mock.recorder = &MockCommentStoreInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCommentStoreInterface) EXPECT() *MockCommentStoreInterfaceMockRecorder {
+	return m.recorder
+}
+
+// CreateComment mocks base method.
+func (m *MockCommentStoreInterface) CreateComment(ctx context.Context, c comment.Comment) (comment.Comment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateComment", ctx, c)
+	ret0, _ := ret[0].(comment.Comment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateComment indicates an expected call of CreateComment.
+func (mr *MockCommentStoreInterfaceMockRecorder) CreateComment(ctx, c any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateComment", reflect.TypeOf((*MockCommentStoreInterface)(nil).CreateComment), ctx, c)
+}
+
+// DeleteComment mocks base method.
+func (m *MockCommentStoreInterface) DeleteComment(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteComment", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteComment indicates an expected call of DeleteComment.
+func (mr *MockCommentStoreInterfaceMockRecorder) DeleteComment(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteComment", reflect.TypeOf((*MockCommentStoreInterface)(nil).DeleteComment), ctx, id)
+}
+
+// GetByIDComment mocks base method.
+func (m *MockCommentStoreInterface) GetByIDComment(ctx context.Context, id int) (comment.Comment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByIDComment", ctx, id)
+	ret0, _ := ret[0].(comment.Comment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByIDComment indicates an expected call of GetByIDComment.
+func (mr *MockCommentStoreInterfaceMockRecorder) GetByIDComment(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByIDComment", reflect.TypeOf((*MockCommentStoreInterface)(nil).GetByIDComment), ctx, id)
+}
+
+// GetByTaskIDComment mocks base method.
+func (m *MockCommentStoreInterface) GetByTaskIDComment(ctx context.Context, taskID int) ([]comment.Comment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByTaskIDComment", ctx, taskID)
+	ret0, _ := ret[0].([]comment.Comment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByTaskIDComment indicates an expected call of GetByTaskIDComment.
+func (mr *MockCommentStoreInterfaceMockRecorder) GetByTaskIDComment(ctx, taskID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByTaskIDComment", reflect.TypeOf((*MockCommentStoreInterface)(nil).GetByTaskIDComment), ctx, taskID)
+}
+
+// GetEditsComment mocks base method.
+func (m *MockCommentStoreInterface) GetEditsComment(ctx context.Context, id int) ([]comment.Edit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEditsComment", ctx, id)
+	ret0, _ := ret[0].([]comment.Edit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEditsComment indicates an expected call of GetEditsComment.
+func (mr *MockCommentStoreInterfaceMockRecorder) GetEditsComment(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEditsComment", reflect.TypeOf((*MockCommentStoreInterface)(nil).GetEditsComment), ctx, id)
+}
+
+// UpdateComment mocks base method.
+func (m *MockCommentStoreInterface) UpdateComment(ctx context.Context, id, editorID int, body string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateComment", ctx, id, editorID, body)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateComment indicates an expected call of UpdateComment.
+func (mr *MockCommentStoreInterfaceMockRecorder) UpdateComment(ctx, id, editorID, body any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateComment", reflect.TypeOf((*MockCommentStoreInterface)(nil).UpdateComment), ctx, id, editorID, body)
+}
+
+// MockTaskServiceInterface is a mock of TaskServiceInterface interface.
+type MockTaskServiceInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockTaskServiceInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockTaskServiceInterfaceMockRecorder is the mock recorder for MockTaskServiceInterface.
+type MockTaskServiceInterfaceMockRecorder struct {
+	mock *MockTaskServiceInterface
+}
+
+// NewMockTaskServiceInterface creates a new mock instance.
+func NewMockTaskServiceInterface(ctrl *gomock.Controller) *MockTaskServiceInterface {
+	mock := &MockTaskServiceInterface{ctrl: ctrl}
+	mock.recorder = &MockTaskServiceInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTaskServiceInterface) EXPECT() *MockTaskServiceInterfaceMockRecorder {
+	return m.recorder
+}
+
+// GetTask mocks base method.
+func (m *MockTaskServiceInterface) GetTask(ctx context.Context, id int) (task.Task, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTask", ctx, id)
+	ret0, _ := ret[0].(task.Task)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTask indicates an expected call of GetTask.
+func (mr *MockTaskServiceInterfaceMockRecorder) GetTask(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTask", reflect.TypeOf((*MockTaskServiceInterface)(nil).GetTask), ctx, id)
+}
+
+// MockUserServiceInterface is a mock of UserServiceInterface interface.
+type MockUserServiceInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserServiceInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockUserServiceInterfaceMockRecorder is the mock recorder for MockUserServiceInterface.
+type MockUserServiceInterfaceMockRecorder struct {
+	mock *MockUserServiceInterface
+}
+
+// NewMockUserServiceInterface creates a new mock instance.
+func NewMockUserServiceInterface(ctrl *gomock.Controller) *MockUserServiceInterface {
+	mock := &MockUserServiceInterface{ctrl: ctrl}
+	mock.recorder = &MockUserServiceInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUserServiceInterface) EXPECT() *MockUserServiceInterfaceMockRecorder {
+	return m.recorder
+}
+
+// Get mocks base method.
+func (m *MockUserServiceInterface) Get(ctx context.Context, id int) (user.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, id)
+	ret0, _ := ret[0].(user.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockUserServiceInterfaceMockRecorder) Get(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockUserServiceInterface)(nil).Get), ctx, id)
+}
@@ -0,0 +1,190 @@
+package comment
+
+import (
+	"Task_Manager/auth"
+	"Task_Manager/model/comment"
+	"Task_Manager/tracing"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// tracer records a span for every service method
+var tracer = tracing.Tracer("service/comment")
+
+type CommentService struct {
+	str            CommentStoreInterface
+	taskServiceref TaskServiceInterface
+	userServiceref UserServiceInterface
+}
+
+func NewService(s CommentStoreInterface, ts TaskServiceInterface, us UserServiceInterface) *CommentService {
+	return &CommentService{
+		str:            s,
+		taskServiceref: ts,
+		userServiceref: us,
+	}
+}
+
+// Create adds a comment, or a reply when ParentID is set, authored by the actor
+func (s *CommentService) Create(ctx context.Context, actor auth.Actor, c comment.Comment) (comment.Comment, error) {
+	ctx, span := tracer.Start(ctx, "CommentService.Create")
+	defer span.End()
+
+	if actor.UserID == 0 {
+		return c, comment.ErrForbidden
+	}
+
+	if err := c.Validate(); err != nil {
+		return c, err
+	}
+
+	if _, err := s.userServiceref.Get(ctx, actor.UserID); err != nil {
+		return c, fmt.Errorf("user with ID %d does not exist: %w", actor.UserID, err)
+	}
+
+	if _, err := s.taskServiceref.GetTask(ctx, c.TaskID); err != nil {
+		return c, fmt.Errorf("task with ID %d does not exist: %w", c.TaskID, err)
+	}
+
+	if c.ParentID != nil {
+		parent, err := s.str.GetByIDComment(ctx, *c.ParentID)
+		if err != nil || parent.TaskID != c.TaskID || parent.Deleted {
+			return c, comment.ErrInvalidParent
+		}
+	}
+
+	c.AuthorID = actor.UserID
+	c.EditedAt = nil
+	c.Deleted = false
+	c.Replies = nil
+
+	return s.str.CreateComment(ctx, c)
+}
+
+// List returns the comments of a task as a thread: top level comments with their replies nested.
+// Deleted comments that still have replies are kept with an empty body so the thread stays intact.
+func (s *CommentService) List(ctx context.Context, taskID int) ([]comment.Comment, error) {
+	ctx, span := tracer.Start(ctx, "CommentService.List")
+	defer span.End()
+
+	if _, err := s.taskServiceref.GetTask(ctx, taskID); err != nil {
+		return nil, fmt.Errorf("task with ID %d does not exist: %w", taskID, err)
+	}
+
+	comments, err := s.str.GetByTaskIDComment(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	children := make(map[int][]comment.Comment)
+
+	for _, c := range comments {
+		parent := 0
+		if c.ParentID != nil {
+			parent = *c.ParentID
+		}
+
+		children[parent] = append(children[parent], c)
+	}
+
+	return thread(children, 0), nil
+}
+
+func thread(children map[int][]comment.Comment, parent int) []comment.Comment {
+	thr := make([]comment.Comment, 0, len(children[parent]))
+
+	for _, c := range children[parent] {
+		c.Replies = thread(children, c.ID)
+
+		if c.Deleted {
+			if len(c.Replies) == 0 {
+				continue
+			}
+
+			c.Body = ""
+		}
+
+		thr = append(thr, c)
+	}
+
+	return thr
+}
+
+// Edit replaces the body of a comment. Only the author or an admin may edit.
+func (s *CommentService) Edit(ctx context.Context, actor auth.Actor, taskID, id int, body string) (comment.Comment, error) {
+	ctx, span := tracer.Start(ctx, "CommentService.Edit")
+	defer span.End()
+
+	c, err := s.get(ctx, taskID, id)
+	if err != nil {
+		return c, err
+	}
+
+	if !canModify(actor, c) {
+		return c, comment.ErrForbidden
+	}
+
+	c.Body = body
+	if err = c.Validate(); err != nil {
+		return c, err
+	}
+
+	if err = s.str.UpdateComment(ctx, id, actor.UserID, body); err != nil {
+		return c, err
+	}
+
+	return s.str.GetByIDComment(ctx, id)
+}
+
+// Delete soft deletes a comment. Only the author or an admin may delete.
+func (s *CommentService) Delete(ctx context.Context, actor auth.Actor, taskID, id int) error {
+	ctx, span := tracer.Start(ctx, "CommentService.Delete")
+	defer span.End()
+
+	c, err := s.get(ctx, taskID, id)
+	if err != nil {
+		return err
+	}
+
+	if !canModify(actor, c) {
+		return comment.ErrForbidden
+	}
+
+	return s.str.DeleteComment(ctx, id)
+}
+
+// History returns the previous bodies of a comment, oldest first
+func (s *CommentService) History(ctx context.Context, taskID, id int) ([]comment.Edit, error) {
+	ctx, span := tracer.Start(ctx, "CommentService.History")
+	defer span.End()
+
+	if _, err := s.get(ctx, taskID, id); err != nil {
+		return nil, err
+	}
+
+	return s.str.GetEditsComment(ctx, id)
+}
+
+// get loads a live comment and checks that it belongs to the task
+func (s *CommentService) get(ctx context.Context, taskID, id int) (comment.Comment, error) {
+	c, err := s.str.GetByIDComment(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c, comment.ErrNotFound
+	}
+
+	if err != nil {
+		return c, err
+	}
+
+	if c.TaskID != taskID || c.Deleted {
+		return c, comment.ErrNotFound
+	}
+
+	return c, nil
+}
+
+func canModify(actor auth.Actor, c comment.Comment) bool {
+	return actor.Admin || (actor.UserID != 0 && actor.UserID == c.AuthorID)
+}
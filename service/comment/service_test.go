@@ -0,0 +1,279 @@
+package comment
+
+import (
+	"Task_Manager/auth"
+	"Task_Manager/model/comment"
+	"Task_Manager/model/task"
+	"Task_Manager/model/user"
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+type mocks struct {
+	store *MockCommentStoreInterface
+	tasks *MockTaskServiceInterface
+	users *MockUserServiceInterface
+}
+
+func newTestService(t *testing.T) (*CommentService, mocks) {
+	ctrl := gomock.NewController(t)
+	m := mocks{
+		store: NewMockCommentStoreInterface(ctrl),
+		tasks: NewMockTaskServiceInterface(ctrl),
+		users: NewMockUserServiceInterface(ctrl),
+	}
+
+	return NewService(m.store, m.tasks, m.users), m
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func Test_Create(t *testing.T) {
+	author := auth.Actor{UserID: 2}
+
+	tests := []struct {
+		name    string
+		actor   auth.Actor
+		input   comment.Comment
+		setup   func(m mocks)
+		expErr  error
+		wantErr bool
+	}{
+		{
+			name:  "Valid comment",
+			actor: author,
+			input: comment.Comment{TaskID: 1, Body: "Looks good"},
+			setup: func(m mocks) {
+				m.users.EXPECT().Get(gomock.Any(), 2).Return(user.User{ID: 2}, nil)
+				m.tasks.EXPECT().GetTask(gomock.Any(), 1).Return(task.Task{ID: 1}, nil)
+				m.store.EXPECT().CreateComment(gomock.Any(), comment.Comment{TaskID: 1, AuthorID: 2, Body: "Looks good"}).
+					Return(comment.Comment{ID: 3, TaskID: 1, AuthorID: 2, Body: "Looks good"}, nil)
+			},
+		},
+		{
+			name:  "Valid reply",
+			actor: author,
+			input: comment.Comment{TaskID: 1, ParentID: intPtr(3), Body: "Agreed"},
+			setup: func(m mocks) {
+				m.users.EXPECT().Get(gomock.Any(), 2).Return(user.User{ID: 2}, nil)
+				m.tasks.EXPECT().GetTask(gomock.Any(), 1).Return(task.Task{ID: 1}, nil)
+				m.store.EXPECT().GetByIDComment(gomock.Any(), 3).Return(comment.Comment{ID: 3, TaskID: 1}, nil)
+				m.store.EXPECT().CreateComment(gomock.Any(), gomock.Any()).Return(comment.Comment{ID: 4}, nil)
+			},
+		},
+		{
+			name:   "Anonymous actor",
+			input:  comment.Comment{TaskID: 1, Body: "Hi"},
+			setup:  func(mocks) {},
+			expErr: comment.ErrForbidden,
+		},
+		{
+			name:   "Empty body",
+			actor:  author,
+			input:  comment.Comment{TaskID: 1},
+			setup:  func(mocks) {},
+			expErr: comment.ErrEmptyBody,
+		},
+		{
+			name:  "Unknown author",
+			actor: author,
+			input: comment.Comment{TaskID: 1, Body: "Hi"},
+			setup: func(m mocks) {
+				m.users.EXPECT().Get(gomock.Any(), 2).Return(user.User{}, sql.ErrNoRows)
+			},
+			expErr: sql.ErrNoRows,
+		},
+		{
+			name:  "Unknown task",
+			actor: author,
+			input: comment.Comment{TaskID: 9, Body: "Hi"},
+			setup: func(m mocks) {
+				m.users.EXPECT().Get(gomock.Any(), 2).Return(user.User{ID: 2}, nil)
+				m.tasks.EXPECT().GetTask(gomock.Any(), 9).Return(task.Task{}, sql.ErrNoRows)
+			},
+			expErr: sql.ErrNoRows,
+		},
+		{
+			name:  "Parent on another task",
+			actor: author,
+			input: comment.Comment{TaskID: 1, ParentID: intPtr(3), Body: "Hi"},
+			setup: func(m mocks) {
+				m.users.EXPECT().Get(gomock.Any(), 2).Return(user.User{ID: 2}, nil)
+				m.tasks.EXPECT().GetTask(gomock.Any(), 1).Return(task.Task{ID: 1}, nil)
+				m.store.EXPECT().GetByIDComment(gomock.Any(), 3).Return(comment.Comment{ID: 3, TaskID: 7}, nil)
+			},
+			expErr: comment.ErrInvalidParent,
+		},
+		{
+			name:  "Store error",
+			actor: author,
+			input: comment.Comment{TaskID: 1, Body: "Hi"},
+			setup: func(m mocks) {
+				m.users.EXPECT().Get(gomock.Any(), 2).Return(user.User{ID: 2}, nil)
+				m.tasks.EXPECT().GetTask(gomock.Any(), 1).Return(task.Task{ID: 1}, nil)
+				m.store.EXPECT().CreateComment(gomock.Any(), gomock.Any()).Return(comment.Comment{}, errors.New("db down"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, m := newTestService(t)
+			tt.setup(m)
+
+			_, err := svc.Create(context.Background(), tt.actor, tt.input)
+
+			switch {
+			case tt.expErr != nil:
+				assert.ErrorIs(t, err, tt.expErr)
+			case tt.wantErr:
+				assert.Error(t, err)
+			default:
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func Test_List(t *testing.T) {
+	svc, m := newTestService(t)
+
+	m.tasks.EXPECT().GetTask(gomock.Any(), 1).Return(task.Task{ID: 1}, nil)
+	m.store.EXPECT().GetByTaskIDComment(gomock.Any(), 1).Return([]comment.Comment{
+		{ID: 1, TaskID: 1, Body: "root"},
+		{ID: 2, TaskID: 1, Body: "removed", Deleted: true},
+		{ID: 3, TaskID: 1, ParentID: intPtr(2), Body: "reply to removed"},
+		{ID: 4, TaskID: 1, ParentID: intPtr(1), Body: "reply"},
+		{ID: 5, TaskID: 1, ParentID: intPtr(1), Body: "dropped", Deleted: true},
+	}, nil)
+
+	thr, err := svc.List(context.Background(), 1)
+
+	assert.NoError(t, err)
+	assert.Len(t, thr, 2)
+	assert.Equal(t, "root", thr[0].Body)
+	assert.Len(t, thr[0].Replies, 1)
+	assert.Equal(t, 4, thr[0].Replies[0].ID)
+	assert.Empty(t, thr[1].Body)
+	assert.Equal(t, 3, thr[1].Replies[0].ID)
+}
+
+func Test_List_Errors(t *testing.T) {
+	t.Run("Unknown task", func(t *testing.T) {
+		svc, m := newTestService(t)
+		m.tasks.EXPECT().GetTask(gomock.Any(), 1).Return(task.Task{}, sql.ErrNoRows)
+
+		_, err := svc.List(context.Background(), 1)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	})
+
+	t.Run("Store error", func(t *testing.T) {
+		svc, m := newTestService(t)
+		m.tasks.EXPECT().GetTask(gomock.Any(), 1).Return(task.Task{ID: 1}, nil)
+		m.store.EXPECT().GetByTaskIDComment(gomock.Any(), 1).Return(nil, errors.New("db down"))
+
+		_, err := svc.List(context.Background(), 1)
+		assert.Error(t, err)
+	})
+}
+
+func Test_Edit(t *testing.T) {
+	existing := comment.Comment{ID: 3, TaskID: 1, AuthorID: 2, Body: "old"}
+
+	tests := []struct {
+		name   string
+		actor  auth.Actor
+		taskID int
+		body   string
+		stored comment.Comment
+		getErr error
+		update bool
+		expErr error
+	}{
+		{name: "Author edits", actor: auth.Actor{UserID: 2}, taskID: 1, body: "new", stored: existing, update: true},
+		{name: "Admin edits", actor: auth.Actor{UserID: 9, Admin: true}, taskID: 1, body: "new", stored: existing, update: true},
+		{name: "Other user", actor: auth.Actor{UserID: 5}, taskID: 1, body: "new", stored: existing, expErr: comment.ErrForbidden},
+		{name: "Anonymous", taskID: 1, body: "new", stored: existing, expErr: comment.ErrForbidden},
+		{name: "Empty body", actor: auth.Actor{UserID: 2}, taskID: 1, stored: existing, expErr: comment.ErrEmptyBody},
+		{name: "Wrong task", actor: auth.Actor{UserID: 2}, taskID: 8, body: "new", stored: existing, expErr: comment.ErrNotFound},
+		{name: "Missing", actor: auth.Actor{UserID: 2}, taskID: 1, body: "new", getErr: sql.ErrNoRows, expErr: comment.ErrNotFound},
+		{
+			name: "Deleted", actor: auth.Actor{UserID: 2}, taskID: 1, body: "new",
+			stored: comment.Comment{ID: 3, TaskID: 1, AuthorID: 2, Deleted: true}, expErr: comment.ErrNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, m := newTestService(t)
+
+			m.store.EXPECT().GetByIDComment(gomock.Any(), 3).Return(tt.stored, tt.getErr)
+
+			if tt.update {
+				m.store.EXPECT().UpdateComment(gomock.Any(), 3, tt.actor.UserID, tt.body).Return(nil)
+				m.store.EXPECT().GetByIDComment(gomock.Any(), 3).Return(comment.Comment{ID: 3, Body: tt.body}, nil)
+			}
+
+			res, err := svc.Edit(context.Background(), tt.actor, tt.taskID, 3, tt.body)
+
+			if tt.expErr != nil {
+				assert.ErrorIs(t, err, tt.expErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.body, res.Body)
+		})
+	}
+}
+
+func Test_Delete(t *testing.T) {
+	existing := comment.Comment{ID: 3, TaskID: 1, AuthorID: 2}
+
+	t.Run("Author deletes", func(t *testing.T) {
+		svc, m := newTestService(t)
+		m.store.EXPECT().GetByIDComment(gomock.Any(), 3).Return(existing, nil)
+		m.store.EXPECT().DeleteComment(gomock.Any(), 3).Return(nil)
+
+		assert.NoError(t, svc.Delete(context.Background(), auth.Actor{UserID: 2}, 1, 3))
+	})
+
+	t.Run("Other user", func(t *testing.T) {
+		svc, m := newTestService(t)
+		m.store.EXPECT().GetByIDComment(gomock.Any(), 3).Return(existing, nil)
+
+		assert.ErrorIs(t, svc.Delete(context.Background(), auth.Actor{UserID: 4}, 1, 3), comment.ErrForbidden)
+	})
+
+	t.Run("Store error", func(t *testing.T) {
+		svc, m := newTestService(t)
+		m.store.EXPECT().GetByIDComment(gomock.Any(), 3).Return(comment.Comment{}, sql.ErrConnDone)
+
+		assert.ErrorIs(t, svc.Delete(context.Background(), auth.Actor{UserID: 2}, 1, 3), sql.ErrConnDone)
+	})
+}
+
+func Test_History(t *testing.T) {
+	svc, m := newTestService(t)
+
+	m.store.EXPECT().GetByIDComment(gomock.Any(), 3).Return(comment.Comment{ID: 3, TaskID: 1}, nil)
+	m.store.EXPECT().GetEditsComment(gomock.Any(), 3).Return([]comment.Edit{{CommentID: 3, Body: "v1"}}, nil)
+
+	edits, err := svc.History(context.Background(), 1, 3)
+
+	assert.NoError(t, err)
+	assert.Len(t, edits, 1)
+
+	m.store.EXPECT().GetByIDComment(gomock.Any(), 3).Return(comment.Comment{ID: 3, TaskID: 2}, nil)
+
+	_, err = svc.History(context.Background(), 1, 3)
+	assert.ErrorIs(t, err, comment.ErrNotFound)
+}
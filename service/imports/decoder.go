@@ -0,0 +1,158 @@
+package imports
+
+import (
+	"Task_Manager/model/imports"
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// maxLineBytes bounds a single NDJSON line so one malformed record cannot exhaust memory
+const maxLineBytes = 1 << 20
+
+// record is one row of an import file, keyed by column name. err is set when the row could not be
+// decoded; the file itself is still readable and decoding continues with the next row.
+type record struct {
+	line   int
+	fields map[string]string
+	err    error
+}
+
+// decoder reads an import file one record at a time and returns io.EOF after the last one
+type decoder interface {
+	next() (record, error)
+}
+
+func newDecoder(r io.Reader, format string) (decoder, error) {
+	switch format {
+	case imports.FormatCSV:
+		return newCSVDecoder(r)
+	case imports.FormatNDJSON:
+		sc := bufio.NewScanner(r)
+		sc.Buffer(make([]byte, 0, 64<<10), maxLineBytes)
+
+		return &ndjsonDecoder{sc: sc}, nil
+	default:
+		return nil, imports.ErrFormat
+	}
+}
+
+// csvDecoder maps each CSV row onto the column names of the header row
+type csvDecoder struct {
+	r      *csv.Reader
+	header []string
+}
+
+func newCSVDecoder(r io.Reader) (*csvDecoder, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	cr.ReuseRecord = true
+
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, imports.ErrHeader
+	}
+
+	var perr *csv.ParseError
+	if errors.As(err, &perr) {
+		return nil, fmt.Errorf("%w: %v", imports.ErrHeader, perr)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	d := &csvDecoder{r: cr, header: make([]string, len(header))}
+	for i, h := range header {
+		d.header[i] = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+	}
+
+	return d, nil
+}
+
+func (d *csvDecoder) next() (record, error) {
+	row, err := d.r.Read()
+
+	var perr *csv.ParseError
+	if errors.As(err, &perr) {
+		return record{line: perr.StartLine, err: perr.Err}, nil
+	}
+
+	if err != nil {
+		return record{}, err
+	}
+
+	line, _ := d.r.FieldPos(0)
+	if len(row) > len(d.header) {
+		return record{line: line, err: fmt.Errorf("row has %d fields, header has %d", len(row), len(d.header))}, nil
+	}
+
+	fields := make(map[string]string, len(d.header))
+	for i, v := range row {
+		fields[d.header[i]] = strings.TrimSpace(v)
+	}
+
+	return record{line: line, fields: fields}, nil
+}
+
+// ndjsonDecoder reads one JSON object per line, skipping blank lines
+type ndjsonDecoder struct {
+	sc   *bufio.Scanner
+	line int
+}
+
+func (d *ndjsonDecoder) next() (record, error) {
+	for d.sc.Scan() {
+		d.line++
+
+		text := strings.TrimSpace(d.sc.Text())
+		if text == "" {
+			continue
+		}
+
+		var obj map[string]any
+		if err := json.Unmarshal([]byte(text), &obj); err != nil {
+			return record{line: d.line, err: fmt.Errorf("invalid JSON: %w", err)}, nil
+		}
+
+		fields := make(map[string]string, len(obj))
+		for k, v := range obj {
+			s, err := scalar(v)
+			if err != nil {
+				return record{line: d.line, err: fmt.Errorf("field %q: %w", k, err)}, nil
+			}
+
+			fields[strings.ToLower(k)] = s
+		}
+
+		return record{line: d.line, fields: fields}, nil
+	}
+
+	if err := d.sc.Err(); err != nil {
+		return record{}, err
+	}
+
+	return record{}, io.EOF
+}
+
+// scalar renders a JSON value the way the same value would appear in a CSV cell
+func scalar(v any) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return strings.TrimSpace(v), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	default:
+		return "", errors.New("must be a string, number or boolean")
+	}
+}
@@ -0,0 +1,80 @@
+package imports
+
+import (
+	"Task_Manager/model/imports"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func drain(t *testing.T, d decoder) []record {
+	var recs []record
+
+	for {
+		rec, err := d.next()
+		if errors.Is(err, io.EOF) {
+			return recs
+		}
+
+		require.NoError(t, err)
+		recs = append(recs, rec)
+	}
+}
+
+func Test_CSVDecoder(t *testing.T) {
+	in := "\ufeffDesc, Status,email\n" +
+		"Write docs,true,ann@example.com\n" +
+		"\"Multi\nline\",false,bob@example.com\n" +
+		"Bad \"quote,false,bob@example.com\n" +
+		"Too,many,fields,here\n" +
+		"Short\n"
+
+	d, err := newDecoder(strings.NewReader(in), imports.FormatCSV)
+	require.NoError(t, err)
+
+	recs := drain(t, d)
+	require.Len(t, recs, 5)
+
+	assert.Equal(t, 2, recs[0].line)
+	assert.Equal(t, map[string]string{"desc": "Write docs", "status": "true", "email": "ann@example.com"}, recs[0].fields)
+	assert.Equal(t, 3, recs[1].line)
+	assert.Equal(t, "Multi\nline", recs[1].fields["desc"])
+	assert.Equal(t, 5, recs[2].line)
+	assert.Error(t, recs[2].err)
+	assert.Error(t, recs[3].err)
+	assert.Equal(t, map[string]string{"desc": "Short"}, recs[4].fields)
+}
+
+func Test_CSVDecoderEmptyFile(t *testing.T) {
+	_, err := newDecoder(strings.NewReader(""), imports.FormatCSV)
+	assert.ErrorIs(t, err, imports.ErrHeader)
+}
+
+func Test_NDJSONDecoder(t *testing.T) {
+	in := `{"desc":"Write docs","status":true,"email":"ann@example.com"}` + "\n\n" +
+		`{"desc":` + "\n" +
+		`{"desc":"Nested","tags":["a"]}` + "\n" +
+		`{"Name":"Bob","id":7,"note":null}`
+
+	d, err := newDecoder(strings.NewReader(in), imports.FormatNDJSON)
+	require.NoError(t, err)
+
+	recs := drain(t, d)
+	require.Len(t, recs, 4)
+
+	assert.Equal(t, 1, recs[0].line)
+	assert.Equal(t, map[string]string{"desc": "Write docs", "status": "true", "email": "ann@example.com"}, recs[0].fields)
+	assert.Equal(t, 3, recs[1].line)
+	assert.Error(t, recs[1].err)
+	assert.Error(t, recs[2].err)
+	assert.Equal(t, map[string]string{"name": "Bob", "id": "7", "note": ""}, recs[3].fields)
+}
+
+func Test_UnknownFormat(t *testing.T) {
+	_, err := newDecoder(strings.NewReader(""), "xlsx")
+	assert.ErrorIs(t, err, imports.ErrFormat)
+}
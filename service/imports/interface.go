@@ -0,0 +1,17 @@
+package imports
+
+import (
+	"Task_Manager/model/task"
+	"Task_Manager/model/user"
+	"context"
+)
+
+type TaskServiceInterface interface {
+	Create(ctx context.Context, t task.Task) (task.Task, error)
+	Exists(ctx context.Context, desc string, userid int) (bool, error)
+}
+
+type UserServiceInterface interface {
+	Create(ctx context.Context, u user.User) (user.User, error)
+	ByEmail(ctx context.Context, email string) (user.User, error)
+}
@@ -0,0 +1,221 @@
+package imports
+
+import (
+	"Task_Manager/model/imports"
+	"Task_Manager/model/task"
+	"Task_Manager/model/user"
+	"Task_Manager/tracing"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// tracer records a span for every service method
+var tracer = tracing.Tracer("service/imports")
+
+type ImportService struct {
+	taskServiceref TaskServiceInterface
+	userServiceref UserServiceInterface
+}
+
+func NewService(ts TaskServiceInterface, us UserServiceInterface) *ImportService {
+	return &ImportService{
+		taskServiceref: ts,
+		userServiceref: us,
+	}
+}
+
+// Tasks imports tasks from a file with the columns desc, status, due and email, the email naming the assignee.
+// A task is skipped when the assignee already has a live task with the same description, so a file can be
+// imported again after fixing its failed rows. emit receives each row's report as soon as it is processed.
+// A dry run checks each row against the database only, so duplicates within the file are not detected.
+func (s *ImportService) Tasks(ctx context.Context, r io.Reader, format string, dryRun bool, emit func(imports.Row) error) (imports.Summary, error) {
+	ctx, span := tracer.Start(ctx, "ImportService.Tasks")
+	defer span.End()
+
+	users := make(map[string]int)
+
+	return run(ctx, r, format, dryRun, emit, func(fields map[string]string) (imports.Row, error) {
+		return s.importTask(ctx, fields, dryRun, users)
+	})
+}
+
+// Users imports users from a file with the columns name and email. Users whose email is already taken
+// are skipped. See Tasks for how rows are reported.
+func (s *ImportService) Users(ctx context.Context, r io.Reader, format string, dryRun bool, emit func(imports.Row) error) (imports.Summary, error) {
+	ctx, span := tracer.Start(ctx, "ImportService.Users")
+	defer span.End()
+
+	return run(ctx, r, format, dryRun, emit, func(fields map[string]string) (imports.Row, error) {
+		return s.importUser(ctx, fields, dryRun)
+	})
+}
+
+// run decodes the file one record at a time and reports every record through emit. It stops early only
+// when the file cannot be read any further, emit fails or the context is cancelled.
+func run(ctx context.Context, r io.Reader, format string, dryRun bool, emit func(imports.Row) error,
+	apply func(fields map[string]string) (imports.Row, error)) (imports.Summary, error) {
+	summary := imports.Summary{DryRun: dryRun}
+
+	dec, err := newDecoder(r, format)
+	if err != nil {
+		return summary, err
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return summary, err
+		}
+
+		rec, err := dec.next()
+		if errors.Is(err, io.EOF) {
+			return summary, nil
+		}
+
+		if err != nil {
+			return summary, err
+		}
+
+		row := imports.Row{Status: imports.RowFailed}
+		if rec.err == nil {
+			row, rec.err = apply(rec.fields)
+		}
+
+		if rec.err != nil {
+			row.Status = imports.RowFailed
+			row.Error = rec.err.Error()
+		}
+
+		row.Line = rec.line
+		summary.Add(row)
+
+		if err := emit(row); err != nil {
+			return summary, err
+		}
+	}
+}
+
+func (s *ImportService) importTask(ctx context.Context, fields map[string]string, dryRun bool, users map[string]int) (imports.Row, error) {
+	t := task.Task{Desc: fields["desc"]}
+	if t.Desc == "" {
+		t.Desc = fields["description"]
+	}
+
+	if err := t.Validate(); err != nil {
+		return imports.Row{}, err
+	}
+
+	if v := fields["status"]; v != "" {
+		status, err := strconv.ParseBool(v)
+		if err != nil {
+			return imports.Row{}, imports.ErrStatus
+		}
+
+		t.Status = status
+	}
+
+	if v := fields["due"]; v != "" {
+		due, err := parseDue(v)
+		if err != nil {
+			return imports.Row{}, err
+		}
+
+		t.Due = &due
+	}
+
+	userid, err := s.resolve(ctx, fields["email"], users)
+	if err != nil {
+		return imports.Row{}, err
+	}
+
+	t.Userid = userid
+
+	exists, err := s.taskServiceref.Exists(ctx, t.Desc, t.Userid)
+	if err != nil {
+		return imports.Row{}, err
+	}
+
+	if exists {
+		return imports.Row{Status: imports.RowSkipped, Reason: "task already exists for this user"}, nil
+	}
+
+	if dryRun {
+		return imports.Row{Status: imports.RowCreated}, nil
+	}
+
+	created, err := s.taskServiceref.Create(ctx, t)
+	if err != nil {
+		return imports.Row{}, err
+	}
+
+	return imports.Row{Status: imports.RowCreated, ID: created.ID}, nil
+}
+
+// parseDue accepts a full RFC 3339 time or a bare date, which is taken as midnight UTC
+func parseDue(v string) (time.Time, error) {
+	if due, err := time.Parse(time.RFC3339, v); err == nil {
+		return due, nil
+	}
+
+	due, err := time.Parse(time.DateOnly, v)
+	if err != nil {
+		return due, imports.ErrDue
+	}
+
+	return due, nil
+}
+
+// resolve looks up the ID of the user with the given email, remembering the answer for later rows
+func (s *ImportService) resolve(ctx context.Context, email string, users map[string]int) (int, error) {
+	if email == "" {
+		return 0, imports.ErrMissingEmail
+	}
+
+	id, ok := users[email]
+	if !ok {
+		u, err := s.userServiceref.ByEmail(ctx, email)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return 0, err
+		}
+
+		id = u.ID
+		users[email] = id
+	}
+
+	if id == 0 {
+		return 0, fmt.Errorf("%w: %s", imports.ErrUnknownUser, email)
+	}
+
+	return id, nil
+}
+
+func (s *ImportService) importUser(ctx context.Context, fields map[string]string, dryRun bool) (imports.Row, error) {
+	u := user.User{Name: fields["name"], Email: fields["email"]}
+	if err := u.Validate(); err != nil {
+		return imports.Row{}, err
+	}
+
+	existing, err := s.userServiceref.ByEmail(ctx, u.Email)
+	if err == nil {
+		return imports.Row{Status: imports.RowSkipped, ID: existing.ID, Reason: "email is already taken"}, nil
+	}
+
+	if !errors.Is(err, sql.ErrNoRows) {
+		return imports.Row{}, err
+	}
+
+	if dryRun {
+		return imports.Row{Status: imports.RowCreated}, nil
+	}
+
+	created, err := s.userServiceref.Create(ctx, u)
+	if err != nil {
+		return imports.Row{}, err
+	}
+
+	return imports.Row{Status: imports.RowCreated, ID: created.ID}, nil
+}
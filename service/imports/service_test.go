@@ -0,0 +1,153 @@
+package imports
+
+import (
+	"Task_Manager/model/imports"
+	"Task_Manager/model/task"
+	"Task_Manager/model/user"
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func collect(rows *[]imports.Row) func(imports.Row) error {
+	return func(r imports.Row) error {
+		*rows = append(*rows, r)
+		return nil
+	}
+}
+
+func Test_ImportTasks(t *testing.T) {
+	ctx := context.Background()
+	in := "desc,status,email,due\n" +
+		"Write docs,true,ann@example.com,2024-05-07\n" +
+		"Review,,ann@example.com,2024-05-08T09:00:00+02:00\n" +
+		"Existing,false,ann@example.com\n" +
+		",false,ann@example.com\n" +
+		"Orphan,false,nobody@example.com\n" +
+		"Maybe,later,ann@example.com\n" +
+		"Someday,false,ann@example.com,soon\n"
+
+	docsDue := time.Date(2024, 5, 7, 0, 0, 0, 0, time.UTC)
+	reviewDue := time.Date(2024, 5, 8, 7, 0, 0, 0, time.UTC)
+
+	t.Run("Creates, skips and reports failures per row", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockTask := NewMockTaskServiceInterface(ctrl)
+		mockUser := NewMockUserServiceInterface(ctrl)
+		service := NewService(mockTask, mockUser)
+
+		// Each email is looked up once per import
+		mockUser.EXPECT().ByEmail(gomock.Any(), "ann@example.com").Return(user.User{ID: 2}, nil)
+		mockUser.EXPECT().ByEmail(gomock.Any(), "nobody@example.com").Return(user.User{}, sql.ErrNoRows)
+		mockTask.EXPECT().Exists(gomock.Any(), "Write docs", 2).Return(false, nil)
+		mockTask.EXPECT().Exists(gomock.Any(), "Review", 2).Return(false, nil)
+		mockTask.EXPECT().Exists(gomock.Any(), "Existing", 2).Return(true, nil)
+		mockTask.EXPECT().Create(gomock.Any(), task.Task{Desc: "Write docs", Status: true, Userid: 2, Due: &docsDue}).Return(task.Task{ID: 10}, nil)
+		mockTask.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, tsk task.Task) (task.Task, error) {
+			assert.True(t, reviewDue.Equal(*tsk.Due))
+			return task.Task{}, errors.New("db down")
+		})
+
+		var rows []imports.Row
+		summary, err := service.Tasks(ctx, strings.NewReader(in), imports.FormatCSV, false, collect(&rows))
+		require.NoError(t, err)
+
+		assert.Equal(t, imports.Summary{Created: 1, Skipped: 1, Failed: 5}, summary)
+		require.Len(t, rows, 7)
+		assert.Equal(t, imports.Row{Line: 2, Status: imports.RowCreated, ID: 10}, rows[0])
+		assert.Equal(t, imports.Row{Line: 3, Status: imports.RowFailed, Error: "db down"}, rows[1])
+		assert.Equal(t, imports.RowSkipped, rows[2].Status)
+		assert.Equal(t, imports.RowFailed, rows[3].Status)
+		assert.Equal(t, "no user with this email: nobody@example.com", rows[4].Error)
+		assert.Equal(t, imports.ErrStatus.Error(), rows[5].Error)
+		assert.Equal(t, imports.ErrDue.Error(), rows[6].Error)
+	})
+
+	t.Run("Dry run creates nothing", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockTask := NewMockTaskServiceInterface(ctrl)
+		mockUser := NewMockUserServiceInterface(ctrl)
+		service := NewService(mockTask, mockUser)
+
+		mockUser.EXPECT().ByEmail(gomock.Any(), "ann@example.com").Return(user.User{ID: 2}, nil)
+		mockTask.EXPECT().Exists(gomock.Any(), "Write docs", 2).Return(false, nil)
+
+		var rows []imports.Row
+		summary, err := service.Tasks(ctx, strings.NewReader(`{"desc":"Write docs","email":"ann@example.com"}`),
+			imports.FormatNDJSON, true, collect(&rows))
+		require.NoError(t, err)
+
+		assert.Equal(t, imports.Summary{DryRun: true, Created: 1}, summary)
+		assert.Equal(t, imports.Row{Line: 1, Status: imports.RowCreated}, rows[0])
+	})
+
+	t.Run("Stops when the report cannot be written", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := NewService(NewMockTaskServiceInterface(ctrl), NewMockUserServiceInterface(ctrl))
+
+		_, err := service.Tasks(ctx, strings.NewReader("desc\n\n,\n,\n"), imports.FormatCSV, false, func(imports.Row) error {
+			return errors.New("client gone")
+		})
+		assert.EqualError(t, err, "client gone")
+	})
+
+	t.Run("Stops when cancelled", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := NewService(NewMockTaskServiceInterface(ctrl), NewMockUserServiceInterface(ctrl))
+
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+
+		_, err := service.Tasks(cancelled, strings.NewReader(in), imports.FormatCSV, false, collect(new([]imports.Row)))
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func Test_ImportUsers(t *testing.T) {
+	ctx := context.Background()
+	in := "name,email\n" +
+		"Ann,ann@example.com\n" +
+		"Bob,bob@example.com\n" +
+		"NoEmail,\n"
+
+	t.Run("Creates new users and skips taken emails", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockUser := NewMockUserServiceInterface(ctrl)
+		service := NewService(NewMockTaskServiceInterface(ctrl), mockUser)
+
+		mockUser.EXPECT().ByEmail(gomock.Any(), "ann@example.com").Return(user.User{ID: 2}, nil)
+		mockUser.EXPECT().ByEmail(gomock.Any(), "bob@example.com").Return(user.User{}, sql.ErrNoRows)
+		mockUser.EXPECT().Create(gomock.Any(), user.User{Name: "Bob", Email: "bob@example.com"}).Return(user.User{ID: 3}, nil)
+
+		var rows []imports.Row
+		summary, err := service.Users(ctx, strings.NewReader(in), imports.FormatCSV, false, collect(&rows))
+		require.NoError(t, err)
+
+		assert.Equal(t, imports.Summary{Created: 1, Skipped: 1, Failed: 1}, summary)
+		assert.Equal(t, imports.Row{Line: 2, Status: imports.RowSkipped, ID: 2, Reason: "email is already taken"}, rows[0])
+		assert.Equal(t, imports.Row{Line: 3, Status: imports.RowCreated, ID: 3}, rows[1])
+		assert.Equal(t, imports.RowFailed, rows[2].Status)
+	})
+
+	t.Run("Lookup errors fail the row", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockUser := NewMockUserServiceInterface(ctrl)
+		service := NewService(NewMockTaskServiceInterface(ctrl), mockUser)
+
+		mockUser.EXPECT().ByEmail(gomock.Any(), "ann@example.com").Return(user.User{}, errors.New("db down"))
+
+		var rows []imports.Row
+		summary, err := service.Users(ctx, strings.NewReader("name,email\nAnn,ann@example.com\n"), imports.FormatCSV, true, collect(&rows))
+		require.NoError(t, err)
+
+		assert.Equal(t, 1, summary.Failed)
+		assert.Equal(t, "db down", rows[0].Error)
+	})
+}
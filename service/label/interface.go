@@ -0,0 +1,21 @@
+package label
+
+import (
+	"Task_Manager/model/label"
+	"Task_Manager/model/task"
+	"context"
+)
+
+type LabelStoreInterface interface {
+	CreateLabel(ctx context.Context, l label.Label) (label.Label, error)
+	GetByIDLabel(ctx context.Context, id int) (label.Label, error)
+	GetByWorkspaceLabel(ctx context.Context, workspaceID int) ([]label.Label, error)
+	GetByTaskIDLabel(ctx context.Context, taskID int) ([]label.Label, error)
+	DeleteLabel(ctx context.Context, id int) error
+	DetachLabel(ctx context.Context, taskID, labelID int) error
+	RelabelTasks(ctx context.Context, taskIDs, add, remove []int) error
+}
+
+type TaskServiceInterface interface {
+	GetTask(ctx context.Context, id int) (task.Task, error)
+}
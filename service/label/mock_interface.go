@@ -0,0 +1,184 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: interface.go
+//
+// Generated by this command:
+//
+//	mockgen -source=interface.go -destination=mock_interface.go -package=label
+//
+
+// Package label is a generated GoMock package.
+package label
+
+import (
+	label "Task_Manager/model/label"
+	task "Task_Manager/model/task"
+	context "context"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockLabelStoreInterface is a mock of LabelStoreInterface interface.
+type MockLabelStoreInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockLabelStoreInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockLabelStoreInterfaceMockRecorder is the mock recorder for MockLabelStoreInterface.
+type MockLabelStoreInterfaceMockRecorder struct {
+	mock *MockLabelStoreInterface
+}
+
+// NewMockLabelStoreInterface creates a new mock instance.
+func NewMockLabelStoreInterface(ctrl *gomock.Controller) *MockLabelStoreInterface {
+	mock := &MockLabelStoreInterface{ctrl: ctrl}
+	mock.recorder = &MockLabelStoreInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLabelStoreInterface) EXPECT() *MockLabelStoreInterfaceMockRecorder {
+	return m.recorder
+}
+
+// CreateLabel mocks base method.
+func (m *MockLabelStoreInterface) CreateLabel(ctx context.Context, l label.Label) (label.Label, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateLabel", ctx, l)
+	ret0, _ := ret[0].(label.Label)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateLabel indicates an expected call of CreateLabel.
+func (mr *MockLabelStoreInterfaceMockRecorder) CreateLabel(ctx, l any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateLabel", reflect.TypeOf((*MockLabelStoreInterface)(nil).CreateLabel), ctx, l)
+}
+
+// DeleteLabel mocks base method.
+func (m *MockLabelStoreInterface) DeleteLabel(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteLabel", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteLabel indicates an expected call of DeleteLabel.
+func (mr *MockLabelStoreInterfaceMockRecorder) DeleteLabel(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteLabel", reflect.TypeOf((*MockLabelStoreInterface)(nil).DeleteLabel), ctx, id)
+}
+
+// DetachLabel mocks base method.
+func (m *MockLabelStoreInterface) DetachLabel(ctx context.Context, taskID, labelID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DetachLabel", ctx, taskID, labelID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DetachLabel indicates an expected call of DetachLabel.
+func (mr *MockLabelStoreInterfaceMockRecorder) DetachLabel(ctx, taskID, labelID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DetachLabel", reflect.TypeOf((*MockLabelStoreInterface)(nil).DetachLabel), ctx, taskID, labelID)
+}
+
+// GetByIDLabel mocks base method.
+func (m *MockLabelStoreInterface) GetByIDLabel(ctx context.Context, id int) (label.Label, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByIDLabel", ctx, id)
+	ret0, _ := ret[0].(label.Label)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByIDLabel indicates an expected call of GetByIDLabel.
+func (mr *MockLabelStoreInterfaceMockRecorder) GetByIDLabel(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByIDLabel", reflect.TypeOf((*MockLabelStoreInterface)(nil).GetByIDLabel), ctx, id)
+}
+
+// GetByTaskIDLabel mocks base method.
+func (m *MockLabelStoreInterface) GetByTaskIDLabel(ctx context.Context, taskID int) ([]label.Label, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByTaskIDLabel", ctx, taskID)
+	ret0, _ := ret[0].([]label.Label)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByTaskIDLabel indicates an expected call of GetByTaskIDLabel.
+func (mr *MockLabelStoreInterfaceMockRecorder) GetByTaskIDLabel(ctx, taskID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByTaskIDLabel", reflect.TypeOf((*MockLabelStoreInterface)(nil).GetByTaskIDLabel), ctx, taskID)
+}
+
+// GetByWorkspaceLabel mocks base method.
+func (m *MockLabelStoreInterface) GetByWorkspaceLabel(ctx context.Context, workspaceID int) ([]label.Label, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByWorkspaceLabel", ctx, workspaceID)
+	ret0, _ := ret[0].([]label.Label)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByWorkspaceLabel indicates an expected call of GetByWorkspaceLabel.
+func (mr *MockLabelStoreInterfaceMockRecorder) GetByWorkspaceLabel(ctx, workspaceID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByWorkspaceLabel", reflect.TypeOf((*MockLabelStoreInterface)(nil).GetByWorkspaceLabel), ctx, workspaceID)
+}
+
+// RelabelTasks mocks base method.
+func (m *MockLabelStoreInterface) RelabelTasks(ctx context.Context, taskIDs, add, remove []int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RelabelTasks", ctx, taskIDs, add, remove)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RelabelTasks indicates an expected call of RelabelTasks.
+func (mr *MockLabelStoreInterfaceMockRecorder) RelabelTasks(ctx, taskIDs, add, remove any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RelabelTasks", reflect.TypeOf((*MockLabelStoreInterface)(nil).RelabelTasks), ctx, taskIDs, add, remove)
+}
+
+// MockTaskServiceInterface is a mock of TaskServiceInterface interface.
+type MockTaskServiceInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockTaskServiceInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockTaskServiceInterfaceMockRecorder is the mock recorder for MockTaskServiceInterface.
+type MockTaskServiceInterfaceMockRecorder struct {
+	mock *MockTaskServiceInterface
+}
+
+// NewMockTaskServiceInterface creates a new mock instance.
+func NewMockTaskServiceInterface(ctrl *gomock.Controller) *MockTaskServiceInterface {
+	mock := &MockTaskServiceInterface{ctrl: ctrl}
+	mock.recorder = &MockTaskServiceInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTaskServiceInterface) EXPECT() *MockTaskServiceInterfaceMockRecorder {
+	return m.recorder
+}
+
+// GetTask mocks base method.
+func (m *MockTaskServiceInterface) GetTask(ctx context.Context, id int) (task.Task, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTask", ctx, id)
+	ret0, _ := ret[0].(task.Task)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTask indicates an expected call of GetTask.
+func (mr *MockTaskServiceInterfaceMockRecorder) GetTask(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTask", reflect.TypeOf((*MockTaskServiceInterface)(nil).GetTask), ctx, id)
+}
@@ -0,0 +1,130 @@
+package label
+
+import (
+	"Task_Manager/model/label"
+	"Task_Manager/tracing"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// tracer records a span for every service method
+var tracer = tracing.Tracer("service/label")
+
+const (
+	// MaxBulkTasks bounds the number of tasks relabelled in one call
+	MaxBulkTasks = 500
+	// MaxBulkLabels bounds the number of labels added or removed in one call
+	MaxBulkLabels = 50
+)
+
+type LabelService struct {
+	str            LabelStoreInterface
+	taskServiceref TaskServiceInterface
+}
+
+func NewService(s LabelStoreInterface, ts TaskServiceInterface) *LabelService {
+	return &LabelService{
+		str:            s,
+		taskServiceref: ts,
+	}
+}
+
+func (s *LabelService) Create(ctx context.Context, l label.Label) (label.Label, error) {
+	ctx, span := tracer.Start(ctx, "LabelService.Create")
+	defer span.End()
+
+	if err := l.Validate(); err != nil {
+		return l, err
+	}
+
+	return s.str.CreateLabel(ctx, l)
+}
+
+func (s *LabelService) ListWorkspace(ctx context.Context, workspaceID int) ([]label.Label, error) {
+	ctx, span := tracer.Start(ctx, "LabelService.ListWorkspace")
+	defer span.End()
+
+	return s.str.GetByWorkspaceLabel(ctx, workspaceID)
+}
+
+func (s *LabelService) Delete(ctx context.Context, id int) error {
+	ctx, span := tracer.Start(ctx, "LabelService.Delete")
+	defer span.End()
+
+	return notFound(s.str.DeleteLabel(ctx, id))
+}
+
+// ListForTask returns the labels attached to a task
+func (s *LabelService) ListForTask(ctx context.Context, taskID int) ([]label.Label, error) {
+	ctx, span := tracer.Start(ctx, "LabelService.ListForTask")
+	defer span.End()
+
+	if _, err := s.taskServiceref.GetTask(ctx, taskID); err != nil {
+		return nil, fmt.Errorf("task with ID %d does not exist: %w", taskID, err)
+	}
+
+	return s.str.GetByTaskIDLabel(ctx, taskID)
+}
+
+// Attach adds labels to a task; labels already attached are left as they are
+func (s *LabelService) Attach(ctx context.Context, taskID int, labelIDs []int) error {
+	ctx, span := tracer.Start(ctx, "LabelService.Attach")
+	defer span.End()
+
+	if _, err := s.taskServiceref.GetTask(ctx, taskID); err != nil {
+		return fmt.Errorf("task with ID %d does not exist: %w", taskID, err)
+	}
+
+	return s.Relabel(ctx, []int{taskID}, labelIDs, nil)
+}
+
+// Detach removes a label from a task
+func (s *LabelService) Detach(ctx context.Context, taskID, labelID int) error {
+	ctx, span := tracer.Start(ctx, "LabelService.Detach")
+	defer span.End()
+
+	return notFound(s.str.DetachLabel(ctx, taskID, labelID))
+}
+
+// Relabel adds and removes labels on many tasks at once, atomically
+func (s *LabelService) Relabel(ctx context.Context, taskIDs, add, remove []int) error {
+	ctx, span := tracer.Start(ctx, "LabelService.Relabel")
+	defer span.End()
+
+	taskIDs, add, remove = unique(taskIDs), unique(add), unique(remove)
+
+	if len(taskIDs) > MaxBulkTasks || len(add) > MaxBulkLabels || len(remove) > MaxBulkLabels {
+		return label.ErrTooManyTargets
+	}
+
+	if len(taskIDs) == 0 || len(add)+len(remove) == 0 {
+		return nil
+	}
+
+	return s.str.RelabelTasks(ctx, taskIDs, add, remove)
+}
+
+func notFound(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return label.ErrNotFound
+	}
+
+	return err
+}
+
+// unique drops duplicate IDs while keeping the original order
+func unique(ids []int) []int {
+	seen := make(map[int]bool, len(ids))
+	out := make([]int, 0, len(ids))
+
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,146 @@
+package label
+
+import (
+	"Task_Manager/model/label"
+	"Task_Manager/model/task"
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func newTestService(t *testing.T) (*LabelService, *MockLabelStoreInterface, *MockTaskServiceInterface) {
+	ctrl := gomock.NewController(t)
+	store := NewMockLabelStoreInterface(ctrl)
+	tasks := NewMockTaskServiceInterface(ctrl)
+
+	return NewService(store, tasks), store, tasks
+}
+
+func Test_Create(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    label.Label
+		storeErr error
+		callStr  bool
+		expErr   error
+	}{
+		{"Valid label", label.Label{WorkspaceID: 1, Name: " bug ", Color: "#FF0000"}, nil, true, nil},
+		{"Empty name", label.Label{WorkspaceID: 1, Color: "#ff0000"}, nil, false, label.ErrInvalidName},
+		{"Comma in name", label.Label{WorkspaceID: 1, Name: "a,b", Color: "#ff0000"}, nil, false, label.ErrInvalidName},
+		{"Bad color", label.Label{WorkspaceID: 1, Name: "bug", Color: "red"}, nil, false, label.ErrInvalidColor},
+		{"No workspace", label.Label{Name: "bug", Color: "#ff0000"}, nil, false, label.ErrInvalidSpace},
+		{"Duplicate", label.Label{WorkspaceID: 1, Name: "bug", Color: "#ff0000"}, label.ErrDuplicate, true, label.ErrDuplicate},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, store, _ := newTestService(t)
+
+			if tt.callStr {
+				store.EXPECT().CreateLabel(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, l label.Label) (label.Label, error) {
+					assert.Equal(t, "bug", l.Name)
+					l.ID = 1
+
+					return l, tt.storeErr
+				})
+			}
+
+			_, err := svc.Create(context.Background(), tt.input)
+			assert.ErrorIs(t, err, tt.expErr)
+		})
+	}
+}
+
+func Test_ListWorkspace(t *testing.T) {
+	svc, store, _ := newTestService(t)
+	store.EXPECT().GetByWorkspaceLabel(gomock.Any(), 1).Return([]label.Label{{ID: 1}}, nil)
+
+	labels, err := svc.ListWorkspace(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Len(t, labels, 1)
+}
+
+func Test_Delete(t *testing.T) {
+	svc, store, _ := newTestService(t)
+
+	store.EXPECT().DeleteLabel(gomock.Any(), 1).Return(nil)
+	assert.NoError(t, svc.Delete(context.Background(), 1))
+
+	store.EXPECT().DeleteLabel(gomock.Any(), 2).Return(sql.ErrNoRows)
+	assert.ErrorIs(t, svc.Delete(context.Background(), 2), label.ErrNotFound)
+}
+
+func Test_ListForTask(t *testing.T) {
+	svc, store, tasks := newTestService(t)
+
+	tasks.EXPECT().GetTask(gomock.Any(), 1).Return(task.Task{ID: 1}, nil)
+	store.EXPECT().GetByTaskIDLabel(gomock.Any(), 1).Return([]label.Label{{ID: 1}}, nil)
+
+	labels, err := svc.ListForTask(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Len(t, labels, 1)
+
+	tasks.EXPECT().GetTask(gomock.Any(), 2).Return(task.Task{}, sql.ErrNoRows)
+
+	_, err = svc.ListForTask(context.Background(), 2)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func Test_Attach(t *testing.T) {
+	svc, store, tasks := newTestService(t)
+
+	tasks.EXPECT().GetTask(gomock.Any(), 1).Return(task.Task{ID: 1}, nil)
+	store.EXPECT().RelabelTasks(gomock.Any(), []int{1}, []int{3, 4}, []int{}).Return(nil)
+	assert.NoError(t, svc.Attach(context.Background(), 1, []int{3, 4, 3}))
+
+	tasks.EXPECT().GetTask(gomock.Any(), 2).Return(task.Task{}, sql.ErrNoRows)
+	assert.ErrorIs(t, svc.Attach(context.Background(), 2, []int{3}), sql.ErrNoRows)
+}
+
+func Test_Detach(t *testing.T) {
+	svc, store, _ := newTestService(t)
+
+	store.EXPECT().DetachLabel(gomock.Any(), 1, 3).Return(nil)
+	assert.NoError(t, svc.Detach(context.Background(), 1, 3))
+
+	store.EXPECT().DetachLabel(gomock.Any(), 1, 4).Return(sql.ErrNoRows)
+	assert.ErrorIs(t, svc.Detach(context.Background(), 1, 4), label.ErrNotFound)
+}
+
+func Test_Relabel(t *testing.T) {
+	t.Run("Deduplicates and applies", func(t *testing.T) {
+		svc, store, _ := newTestService(t)
+		store.EXPECT().RelabelTasks(gomock.Any(), []int{1, 2}, []int{3}, []int{4}).Return(nil)
+
+		assert.NoError(t, svc.Relabel(context.Background(), []int{1, 2, 1}, []int{3, 3}, []int{4}))
+	})
+
+	t.Run("Nothing to do", func(t *testing.T) {
+		svc, _, _ := newTestService(t)
+
+		assert.NoError(t, svc.Relabel(context.Background(), []int{1}, nil, nil))
+		assert.NoError(t, svc.Relabel(context.Background(), nil, []int{1}, nil))
+	})
+
+	t.Run("Too many tasks", func(t *testing.T) {
+		svc, _, _ := newTestService(t)
+
+		ids := make([]int, MaxBulkTasks+1)
+		for i := range ids {
+			ids[i] = i + 1
+		}
+
+		assert.ErrorIs(t, svc.Relabel(context.Background(), ids, []int{1}, nil), label.ErrTooManyTargets)
+	})
+
+	t.Run("Store failure", func(t *testing.T) {
+		svc, store, _ := newTestService(t)
+		store.EXPECT().RelabelTasks(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("db down"))
+
+		assert.Error(t, svc.Relabel(context.Background(), []int{1}, []int{2}, nil))
+	})
+}
@@ -0,0 +1,24 @@
+package notification
+
+import (
+	"Task_Manager/model/notification"
+	"Task_Manager/model/user"
+	"context"
+)
+
+type NotificationStoreInterface interface {
+	GetPreferencesNotification(ctx context.Context, userID int) (notification.Preferences, error)
+	SavePreferencesNotification(ctx context.Context, p notification.Preferences) error
+	QueueNotification(ctx context.Context, e notification.Event) error
+	GetPendingNotification(ctx context.Context, after, users int) ([]notification.Event, error)
+	DeleteNotification(ctx context.Context, ids ...int64) error
+}
+
+type UserServiceInterface interface {
+	Get(ctx context.Context, id int) (user.User, error)
+}
+
+// Notifier delivers a rendered message to its recipient
+type Notifier interface {
+	Send(ctx context.Context, m notification.Message) error
+}
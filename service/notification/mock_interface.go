@@ -0,0 +1,197 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: interface.go
+//
+// Generated by this command:
+//
+//	mockgen -source=interface.go -destination=mock_interface.go -package=notification
+//
+
+// Package notification is a generated GoMock package.
+package notification
+
+import (
+	notification "Task_Manager/model/notification"
+	user "Task_Manager/model/user"
+	context "context"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockNotificationStoreInterface is a mock of NotificationStoreInterface interface.
+type MockNotificationStoreInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockNotificationStoreInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockNotificationStoreInterfaceMockRecorder is the mock recorder for MockNotificationStoreInterface.
+type MockNotificationStoreInterfaceMockRecorder struct {
+	mock *MockNotificationStoreInterface
+}
+
+// NewMockNotificationStoreInterface creates a new mock instance.
+func NewMockNotificationStoreInterface(ctrl *gomock.Controller) *MockNotificationStoreInterface {
+	mock := &MockNotificationStoreInterface{ctrl: ctrl}
+	mock.recorder = &MockNotificationStoreInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockNotificationStoreInterface) EXPECT() *MockNotificationStoreInterfaceMockRecorder {
+	return m.recorder
+}
+
+// DeleteNotification mocks base method.
+func (m *MockNotificationStoreInterface) DeleteNotification(ctx context.Context, ids ...int64) error {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx}
+	for _, a := range ids {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DeleteNotification", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteNotification indicates an expected call of DeleteNotification.
+func (mr *MockNotificationStoreInterfaceMockRecorder) DeleteNotification(ctx any, ids ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx}, ids...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteNotification", reflect.TypeOf((*MockNotificationStoreInterface)(nil).DeleteNotification), varargs...)
+}
+
+// GetPendingNotification mocks base method.
+func (m *MockNotificationStoreInterface) GetPendingNotification(ctx context.Context, after, users int) ([]notification.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPendingNotification", ctx, after, users)
+	ret0, _ := ret[0].([]notification.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPendingNotification indicates an expected call of GetPendingNotification.
+func (mr *MockNotificationStoreInterfaceMockRecorder) GetPendingNotification(ctx, after, users any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPendingNotification", reflect.TypeOf((*MockNotificationStoreInterface)(nil).GetPendingNotification), ctx, after, users)
+}
+
+// GetPreferencesNotification mocks base method.
+func (m *MockNotificationStoreInterface) GetPreferencesNotification(ctx context.Context, userID int) (notification.Preferences, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPreferencesNotification", ctx, userID)
+	ret0, _ := ret[0].(notification.Preferences)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPreferencesNotification indicates an expected call of GetPreferencesNotification.
+func (mr *MockNotificationStoreInterfaceMockRecorder) GetPreferencesNotification(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPreferencesNotification", reflect.TypeOf((*MockNotificationStoreInterface)(nil).GetPreferencesNotification), ctx, userID)
+}
+
+// QueueNotification mocks base method.
+func (m *MockNotificationStoreInterface) QueueNotification(ctx context.Context, e notification.Event) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "QueueNotification", ctx, e)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// QueueNotification indicates an expected call of QueueNotification.
+func (mr *MockNotificationStoreInterfaceMockRecorder) QueueNotification(ctx, e any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueueNotification", reflect.TypeOf((*MockNotificationStoreInterface)(nil).QueueNotification), ctx, e)
+}
+
+// SavePreferencesNotification mocks base method.
+func (m *MockNotificationStoreInterface) SavePreferencesNotification(ctx context.Context, p notification.Preferences) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SavePreferencesNotification", ctx, p)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SavePreferencesNotification indicates an expected call of SavePreferencesNotification.
+func (mr *MockNotificationStoreInterfaceMockRecorder) SavePreferencesNotification(ctx, p any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SavePreferencesNotification", reflect.TypeOf((*MockNotificationStoreInterface)(nil).SavePreferencesNotification), ctx, p)
+}
+
+// MockUserServiceInterface is a mock of UserServiceInterface interface.
+type MockUserServiceInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserServiceInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockUserServiceInterfaceMockRecorder is the mock recorder for MockUserServiceInterface.
+type MockUserServiceInterfaceMockRecorder struct {
+	mock *MockUserServiceInterface
+}
+
+// NewMockUserServiceInterface creates a new mock instance.
+func NewMockUserServiceInterface(ctrl *gomock.Controller) *MockUserServiceInterface {
+	mock := &MockUserServiceInterface{ctrl: ctrl}
+	mock.recorder = &MockUserServiceInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUserServiceInterface) EXPECT() *MockUserServiceInterfaceMockRecorder {
+	return m.recorder
+}
+
+// Get mocks base method.
+func (m *MockUserServiceInterface) Get(ctx context.Context, id int) (user.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, id)
+	ret0, _ := ret[0].(user.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockUserServiceInterfaceMockRecorder) Get(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockUserServiceInterface)(nil).Get), ctx, id)
+}
+
+// MockNotifier is a mock of Notifier interface.
+type MockNotifier struct {
+	ctrl     *gomock.Controller
+	recorder *MockNotifierMockRecorder
+	isgomock struct{}
+}
+
+// MockNotifierMockRecorder is the mock recorder for MockNotifier.
+type MockNotifierMockRecorder struct {
+	mock *MockNotifier
+}
+
+// NewMockNotifier creates a new mock instance.
+func NewMockNotifier(ctrl *gomock.Controller) *MockNotifier {
+	mock := &MockNotifier{ctrl: ctrl}
+	mock.recorder = &MockNotifierMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockNotifier) EXPECT() *MockNotifierMockRecorder {
+	return m.recorder
+}
+
+// Send mocks base method.
+func (m_2 *MockNotifier) Send(ctx context.Context, m notification.Message) error {
+	m_2.ctrl.T.Helper()
+	ret := m_2.ctrl.Call(m_2, "Send", ctx, m)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Send indicates an expected call of Send.
+func (mr *MockNotifierMockRecorder) Send(ctx, m any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Send", reflect.TypeOf((*MockNotifier)(nil).Send), ctx, m)
+}
@@ -0,0 +1,104 @@
+package notification
+
+import (
+	"Task_Manager/model/notification"
+	"Task_Manager/model/task"
+	"Task_Manager/model/user"
+	"bytes"
+	"embed"
+	htmlTemplate "html/template"
+	"strings"
+	textTemplate "text/template"
+	"time"
+)
+
+// dateLayout is how times are shown to recipients, in their own time zone
+const dateLayout = "Mon 2 Jan 2006 15:04 MST"
+
+// kindDigest and kindEmailChange name the templates of messages that are not about a single event
+const (
+	kindDigest      = "digest"
+	kindEmailChange = "email_change"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+// Each kind of message has a "<kind>.subject" and a "<kind>.text" template in its .txt.tmpl file and a
+// "<kind>.html" template in its .html.tmpl file. HTML is rendered with html/template so task
+// descriptions are escaped.
+var (
+	textTemplates = textTemplate.Must(textTemplate.ParseFS(templateFS, "templates/*.txt.tmpl"))
+	htmlTemplates = htmlTemplate.Must(htmlTemplate.ParseFS(templateFS, "templates/*.html.tmpl"))
+)
+
+// item is one event as shown in a message
+type item struct {
+	Kind  string
+	Actor string
+	Task  task.Task
+	Due   string
+}
+
+// view is the data passed to the templates
+type view struct {
+	Name   string
+	UserID int
+	Item   item
+	Items  []item
+
+	Email   string
+	Token   string
+	Expires string
+}
+
+// newView starts the data of a message to u, formatting times in the time zone of u
+func newView(u user.User) (view, *time.Location) {
+	name := u.DisplayName
+	if name == "" {
+		name = u.Name
+	}
+
+	loc := time.UTC
+	if u.Timezone != "" {
+		if l, err := time.LoadLocation(u.Timezone); err == nil {
+			loc = l
+		}
+	}
+
+	return view{Name: name, UserID: u.ID}, loc
+}
+
+func newItem(e notification.Event, actor string, loc *time.Location) item {
+	it := item{Kind: e.Kind, Actor: actor, Task: e.Task}
+	if e.Task.Due != nil {
+		it.Due = e.Task.Due.In(loc).Format(dateLayout)
+	}
+
+	return it
+}
+
+// render builds the message of the given kind to u
+func render(kind string, u user.User, v view) (notification.Message, error) {
+	var subject, text, html bytes.Buffer
+
+	if err := textTemplates.ExecuteTemplate(&subject, kind+".subject", v); err != nil {
+		return notification.Message{}, err
+	}
+
+	if err := textTemplates.ExecuteTemplate(&text, kind+".text", v); err != nil {
+		return notification.Message{}, err
+	}
+
+	if err := htmlTemplates.ExecuteTemplate(&html, kind+".html", v); err != nil {
+		return notification.Message{}, err
+	}
+
+	return notification.Message{
+		To: u.Email,
+		// Descriptions may span lines, which a subject cannot
+		Subject: strings.Join(strings.Fields(subject.String()), " "),
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
@@ -0,0 +1,268 @@
+package notification
+
+import (
+	"Task_Manager/auth"
+	"Task_Manager/logging"
+	"Task_Manager/model/notification"
+	"Task_Manager/model/user"
+	"Task_Manager/tracing"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// tracer records a span for every service method
+var tracer = tracing.Tracer("service/notification")
+
+// digestBatch caps how many recipients have their queued events read from the store at once
+const digestBatch = 500
+
+// ErrQueueFull is returned by Enqueue when the worker is too far behind to take another event
+var ErrQueueFull = errors.New("notification queue is full")
+
+type NotificationService struct {
+	str            NotificationStoreInterface
+	userServiceref UserServiceInterface
+	notifier       Notifier
+	queue          chan notification.Event
+}
+
+// NewService returns a service delivering messages through n. queueSize bounds the events waiting for
+// the worker started with Run.
+func NewService(s NotificationStoreInterface, us UserServiceInterface, n Notifier, queueSize int) *NotificationService {
+	return &NotificationService{
+		str:            s,
+		userServiceref: us,
+		notifier:       n,
+		queue:          make(chan notification.Event, queueSize),
+	}
+}
+
+// Enqueue hands an event to the worker without waiting for it to be delivered, so a slow mail server
+// never holds up the change that caused the event
+func (s *NotificationService) Enqueue(e notification.Event) error {
+	select {
+	case s.queue <- e:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Run delivers enqueued events one at a time until ctx is done
+func (s *NotificationService) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e := <-s.queue:
+			if err := s.Notify(ctx, e); err != nil {
+				logging.FromContext(ctx).Error("notification failed", "kind", e.Kind, "user_id", e.UserID, "error", err)
+			}
+		}
+	}
+}
+
+// Drain delivers the events still queued once the worker has stopped, and returns when the queue is empty
+func (s *NotificationService) Drain(ctx context.Context) {
+	for {
+		select {
+		case e := <-s.queue:
+			if err := s.Notify(ctx, e); err != nil {
+				logging.FromContext(ctx).Error("notification failed", "kind", e.Kind, "user_id", e.UserID, "error", err)
+			}
+		default:
+			return
+		}
+	}
+}
+
+// Notify delivers an event as its recipient prefers: sent right away, kept for the next digest, or dropped
+func (s *NotificationService) Notify(ctx context.Context, e notification.Event) error {
+	ctx, span := tracer.Start(ctx, "NotificationService.Notify")
+	defer span.End()
+
+	p, err := s.Preferences(ctx, e.UserID)
+	if err != nil {
+		return err
+	}
+
+	switch p.For(e.Kind) {
+	case notification.DeliveryOff:
+		return nil
+	case notification.DeliveryDigest:
+		return s.str.QueueNotification(ctx, e)
+	}
+
+	u, err := s.userServiceref.Get(ctx, e.UserID)
+	if err != nil {
+		return err
+	}
+
+	v, loc := newView(u)
+	v.Item = newItem(e, s.actorName(ctx, e.ActorID), loc)
+
+	m, err := render(e.Kind, u, v)
+	if err != nil {
+		return err
+	}
+
+	return s.notifier.Send(ctx, m)
+}
+
+// SendDigests sends every user with queued events one message listing them, and returns the number of
+// messages sent. Events are removed only once their digest is sent, so a failed digest is retried on
+// the next run. Events of users that no longer exist are dropped.
+func (s *NotificationService) SendDigests(ctx context.Context) (int, error) {
+	ctx, span := tracer.Start(ctx, "NotificationService.SendDigests")
+	defer span.End()
+
+	var (
+		sent   int
+		failed error
+	)
+
+	// Recipients are read in ascending ID order, so a failed digest never holds up those after it
+	for after := 0; ; {
+		events, err := s.str.GetPendingNotification(ctx, after, digestBatch)
+		if err != nil {
+			return sent, errors.Join(failed, err)
+		}
+
+		users := 0
+
+		for start := 0; start < len(events); {
+			end := start
+			for end < len(events) && events[end].UserID == events[start].UserID {
+				end++
+			}
+
+			ok, err := s.sendDigest(ctx, events[start:end])
+			if err != nil {
+				failed = errors.Join(failed, fmt.Errorf("digest for user %d: %w", events[start].UserID, err))
+			} else if ok {
+				sent++
+			}
+
+			after = events[start].UserID
+			users++
+			start = end
+		}
+
+		if users < digestBatch {
+			return sent, failed
+		}
+	}
+}
+
+// sendDigest sends one user all of their queued events, and reports whether a message was sent. The
+// events of a user that no longer exists are dropped without one.
+func (s *NotificationService) sendDigest(ctx context.Context, events []notification.Event) (bool, error) {
+	ids := make([]int64, len(events))
+	for i, e := range events {
+		ids[i] = e.ID
+	}
+
+	u, err := s.userServiceref.Get(ctx, events[0].UserID)
+	if errors.Is(err, user.ErrNotFound) {
+		return false, s.str.DeleteNotification(ctx, ids...)
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	v, loc := newView(u)
+	names := make(map[int]string)
+
+	for _, e := range events {
+		if _, ok := names[e.ActorID]; !ok {
+			names[e.ActorID] = s.actorName(ctx, e.ActorID)
+		}
+
+		v.Items = append(v.Items, newItem(e, names[e.ActorID], loc))
+	}
+
+	m, err := render(kindDigest, u, v)
+	if err != nil {
+		return false, err
+	}
+
+	if err := s.notifier.Send(ctx, m); err != nil {
+		return false, err
+	}
+
+	return true, s.str.DeleteNotification(ctx, ids...)
+}
+
+// EmailChange sends the confirmation token of a requested email change to the new address
+func (s *NotificationService) EmailChange(ctx context.Context, u user.User, c user.EmailChange, token string) error {
+	ctx, span := tracer.Start(ctx, "NotificationService.EmailChange")
+	defer span.End()
+
+	v, loc := newView(u)
+	v.Email = c.Email
+	v.Token = token
+	v.Expires = c.ExpiresAt.In(loc).Format(dateLayout)
+
+	m, err := render(kindEmailChange, u, v)
+	if err != nil {
+		return err
+	}
+
+	m.To = c.Email
+
+	return s.notifier.Send(ctx, m)
+}
+
+// Preferences returns the delivery choices of a user, or the defaults when they never changed them
+func (s *NotificationService) Preferences(ctx context.Context, userID int) (notification.Preferences, error) {
+	ctx, span := tracer.Start(ctx, "NotificationService.Preferences")
+	defer span.End()
+
+	p, err := s.str.GetPreferencesNotification(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return notification.DefaultPreferences(userID), nil
+	}
+
+	return p, err
+}
+
+// SetPreferences replaces the delivery choices of a user. Only the user or an admin may change them.
+func (s *NotificationService) SetPreferences(ctx context.Context, actor auth.Actor, p notification.Preferences) (notification.Preferences, error) {
+	ctx, span := tracer.Start(ctx, "NotificationService.SetPreferences")
+	defer span.End()
+
+	if !actor.Admin && (actor.UserID == 0 || actor.UserID != p.UserID) {
+		return p, notification.ErrForbidden
+	}
+
+	if err := p.Validate(); err != nil {
+		return p, err
+	}
+
+	if _, err := s.userServiceref.Get(ctx, p.UserID); err != nil {
+		return p, err
+	}
+
+	return p, s.str.SavePreferencesNotification(ctx, p)
+}
+
+// actorName is the name shown for the user who caused an event, empty when unknown
+func (s *NotificationService) actorName(ctx context.Context, id int) string {
+	if id == 0 {
+		return ""
+	}
+
+	u, err := s.userServiceref.Get(ctx, id)
+	if err != nil {
+		return ""
+	}
+
+	if u.DisplayName != "" {
+		return u.DisplayName
+	}
+
+	return u.Name
+}
@@ -0,0 +1,299 @@
+package notification
+
+import (
+	"Task_Manager/auth"
+	"Task_Manager/model/errs"
+	"Task_Manager/model/notification"
+	"Task_Manager/model/task"
+	"Task_Manager/model/user"
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+type mocks struct {
+	store    *MockNotificationStoreInterface
+	users    *MockUserServiceInterface
+	notifier *MockNotifier
+}
+
+func newTestService(t *testing.T, queueSize int) (*NotificationService, mocks) {
+	ctrl := gomock.NewController(t)
+	m := mocks{
+		store:    NewMockNotificationStoreInterface(ctrl),
+		users:    NewMockUserServiceInterface(ctrl),
+		notifier: NewMockNotifier(ctrl),
+	}
+
+	return NewService(m.store, m.users, m.notifier, queueSize), m
+}
+
+var (
+	ana = user.User{ID: 4, Name: "ana", DisplayName: "Ana", Email: "ana@example.com", Timezone: "Europe/Berlin"}
+	bob = user.User{ID: 2, Name: "bob", Email: "bob@example.com"}
+)
+
+func Test_Notify(t *testing.T) {
+	due := time.Date(2024, 5, 3, 15, 0, 0, 0, time.UTC)
+	assigned := notification.Event{Kind: notification.KindTaskAssigned, UserID: 4, ActorID: 2,
+		Task: task.Task{ID: 9, Desc: "Ship <v2>", Userid: 4, Due: &due}}
+
+	t.Run("Immediate", func(t *testing.T) {
+		s, m := newTestService(t, 1)
+
+		m.store.EXPECT().GetPreferencesNotification(gomock.Any(), 4).Return(notification.Preferences{}, sql.ErrNoRows)
+		m.users.EXPECT().Get(gomock.Any(), 4).Return(ana, nil)
+		m.users.EXPECT().Get(gomock.Any(), 2).Return(bob, nil)
+		m.notifier.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, msg notification.Message) error {
+			assert.Equal(t, "ana@example.com", msg.To)
+			assert.Equal(t, "Task assigned: Ship <v2>", msg.Subject)
+			assert.Contains(t, msg.Text, "Hi Ana,")
+			assert.Contains(t, msg.Text, "bob assigned you task #9")
+			assert.Contains(t, msg.Text, "Due Fri 3 May 2024 17:00 CEST.")
+			assert.Contains(t, msg.HTML, "Ship &lt;v2&gt;")
+			return nil
+		})
+
+		require.NoError(t, s.Notify(context.Background(), assigned))
+	})
+
+	t.Run("Digest", func(t *testing.T) {
+		s, m := newTestService(t, 1)
+
+		m.store.EXPECT().GetPreferencesNotification(gomock.Any(), 4).
+			Return(notification.Preferences{UserID: 4, TaskAssigned: notification.DeliveryDigest}, nil)
+		m.store.EXPECT().QueueNotification(gomock.Any(), assigned).Return(nil)
+
+		require.NoError(t, s.Notify(context.Background(), assigned))
+	})
+
+	t.Run("Off", func(t *testing.T) {
+		s, m := newTestService(t, 1)
+
+		m.store.EXPECT().GetPreferencesNotification(gomock.Any(), 4).
+			Return(notification.Preferences{UserID: 4, TaskAssigned: notification.DeliveryOff}, nil)
+
+		require.NoError(t, s.Notify(context.Background(), assigned))
+	})
+
+	t.Run("Send failure", func(t *testing.T) {
+		s, m := newTestService(t, 1)
+
+		m.store.EXPECT().GetPreferencesNotification(gomock.Any(), 4).Return(notification.Preferences{}, sql.ErrNoRows)
+		m.users.EXPECT().Get(gomock.Any(), 4).Return(ana, nil)
+		m.users.EXPECT().Get(gomock.Any(), 2).Return(user.User{}, user.ErrNotFound)
+		m.notifier.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, msg notification.Message) error {
+			assert.Contains(t, msg.Text, "You were assigned task #9")
+			return errors.New("connection refused")
+		})
+
+		require.Error(t, s.Notify(context.Background(), assigned))
+	})
+}
+
+func Test_SendDigests(t *testing.T) {
+	s, m := newTestService(t, 1)
+
+	events := []notification.Event{
+		{ID: 1, Kind: notification.KindTaskAssigned, UserID: 2, ActorID: 4, Task: task.Task{ID: 9, Desc: "Ship", Userid: 2}},
+		{ID: 2, Kind: notification.KindTaskAssigned, UserID: 4, ActorID: 2, Task: task.Task{ID: 10, Desc: "Test", Userid: 4}},
+		{ID: 3, Kind: notification.KindTaskCompleted, UserID: 4, ActorID: 2, Task: task.Task{ID: 11, Desc: "Docs", Status: true, Userid: 4}},
+	}
+
+	m.store.EXPECT().GetPendingNotification(gomock.Any(), 0, digestBatch).Return(events, nil)
+	m.users.EXPECT().Get(gomock.Any(), 2).Return(bob, nil).AnyTimes()
+	m.users.EXPECT().Get(gomock.Any(), 4).Return(ana, nil).AnyTimes()
+	m.notifier.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, msg notification.Message) error {
+		if msg.To == bob.Email {
+			return errors.New("mailbox full")
+		}
+
+		assert.Equal(t, "2 task updates", msg.Subject)
+		assert.Contains(t, msg.Text, "#10 Test: assigned to you by bob")
+		assert.Contains(t, msg.Text, "#11 Docs: completed by bob")
+		return nil
+	}).Times(2)
+	m.store.EXPECT().DeleteNotification(gomock.Any(), int64(2), int64(3)).Return(nil)
+
+	sent, err := s.SendDigests(context.Background())
+	require.ErrorContains(t, err, "digest for user 2: mailbox full")
+	require.Equal(t, 1, sent)
+}
+
+func Test_SendDigestsDropsMissingUsers(t *testing.T) {
+	s, m := newTestService(t, 1)
+
+	events := []notification.Event{
+		{ID: 1, Kind: notification.KindTaskAssigned, UserID: 3, Task: task.Task{ID: 9, Desc: "Ship", Userid: 3}},
+		{ID: 2, Kind: notification.KindTaskAssigned, UserID: 4, Task: task.Task{ID: 10, Desc: "Test", Userid: 4}},
+	}
+
+	m.store.EXPECT().GetPendingNotification(gomock.Any(), 0, digestBatch).Return(events, nil)
+	m.users.EXPECT().Get(gomock.Any(), 3).Return(user.User{}, user.ErrNotFound)
+	m.users.EXPECT().Get(gomock.Any(), 4).Return(ana, nil)
+	m.store.EXPECT().DeleteNotification(gomock.Any(), int64(1)).Return(nil)
+	m.notifier.EXPECT().Send(gomock.Any(), gomock.Any()).Return(nil)
+	m.store.EXPECT().DeleteNotification(gomock.Any(), int64(2)).Return(nil)
+
+	sent, err := s.SendDigests(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, sent)
+}
+
+func Test_SendDigestsPagesByRecipient(t *testing.T) {
+	s, m := newTestService(t, 1)
+
+	// A full page of recipients whose digests all fail must not keep the next page from being read
+	page := make([]notification.Event, digestBatch)
+	for i := range page {
+		page[i] = notification.Event{ID: int64(i + 1), Kind: notification.KindTaskAssigned, UserID: i + 1, Task: task.Task{ID: 9, Userid: i + 1}}
+	}
+
+	last := notification.Event{ID: 1000, Kind: notification.KindTaskAssigned, UserID: 4000, Task: task.Task{ID: 9, Desc: "Ship", Userid: 4000}}
+
+	gomock.InOrder(
+		m.store.EXPECT().GetPendingNotification(gomock.Any(), 0, digestBatch).Return(page, nil),
+		m.store.EXPECT().GetPendingNotification(gomock.Any(), digestBatch, digestBatch).Return([]notification.Event{last}, nil),
+	)
+	m.users.EXPECT().Get(gomock.Any(), gomock.Not(4000)).Return(user.User{}, errors.New("connection reset")).Times(digestBatch)
+	m.users.EXPECT().Get(gomock.Any(), 4000).Return(ana, nil)
+	m.notifier.EXPECT().Send(gomock.Any(), gomock.Any()).Return(nil)
+	m.store.EXPECT().DeleteNotification(gomock.Any(), int64(1000)).Return(nil)
+
+	sent, err := s.SendDigests(context.Background())
+	require.ErrorContains(t, err, "digest for user 1: connection reset")
+	require.Equal(t, 1, sent)
+}
+
+func Test_EmailChange(t *testing.T) {
+	s, m := newTestService(t, 1)
+
+	change := user.EmailChange{UserID: 4, Email: "ana@new.example.com", ExpiresAt: time.Date(2024, 5, 2, 9, 0, 0, 0, time.UTC)}
+
+	m.notifier.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, msg notification.Message) error {
+		assert.Equal(t, "ana@new.example.com", msg.To)
+		assert.Equal(t, "Confirm your new email address", msg.Subject)
+		assert.Contains(t, msg.Text, "POST /users/4/email/confirm before Thu 2 May 2024 11:00 CEST")
+		assert.Contains(t, msg.Text, "abc123")
+		assert.Contains(t, msg.HTML, "<code>abc123</code>")
+		return nil
+	})
+
+	require.NoError(t, s.EmailChange(context.Background(), ana, change, "abc123"))
+}
+
+func Test_SetPreferences(t *testing.T) {
+	valid := notification.Preferences{UserID: 4, TaskAssigned: notification.DeliveryDigest, TaskCompleted: notification.DeliveryOff}
+
+	tests := []struct {
+		name      string
+		actor     auth.Actor
+		prefs     notification.Preferences
+		mock      func(m mocks)
+		expErr    error
+		expFields []errs.FieldError
+	}{
+		{"Own preferences", auth.Actor{UserID: 4}, valid, func(m mocks) {
+			m.users.EXPECT().Get(gomock.Any(), 4).Return(ana, nil)
+			m.store.EXPECT().SavePreferencesNotification(gomock.Any(), valid).Return(nil)
+		}, nil, nil},
+		{"Admin", auth.Actor{UserID: 1, Admin: true}, valid, func(m mocks) {
+			m.users.EXPECT().Get(gomock.Any(), 4).Return(ana, nil)
+			m.store.EXPECT().SavePreferencesNotification(gomock.Any(), valid).Return(nil)
+		}, nil, nil},
+		{"Someone else", auth.Actor{UserID: 2}, valid, func(mocks) {}, notification.ErrForbidden, nil},
+		{"Anonymous", auth.Actor{}, valid, func(mocks) {}, notification.ErrForbidden, nil},
+		{"Invalid delivery", auth.Actor{UserID: 4}, notification.Preferences{UserID: 4, TaskAssigned: "weekly",
+			TaskCompleted: notification.DeliveryOff}, func(mocks) {}, nil,
+			[]errs.FieldError{{Field: "task_assigned", Message: "must be immediate, digest or off"}}},
+		{"Unknown user", auth.Actor{UserID: 1, Admin: true}, valid, func(m mocks) {
+			m.users.EXPECT().Get(gomock.Any(), 4).Return(user.User{}, user.ErrNotFound)
+		}, user.ErrNotFound, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, m := newTestService(t, 1)
+			tt.mock(m)
+
+			_, err := s.SetPreferences(context.Background(), tt.actor, tt.prefs)
+
+			switch {
+			case tt.expFields != nil:
+				var v *errs.Validation
+				require.ErrorAs(t, err, &v)
+				assert.Equal(t, tt.expFields, v.Fields)
+			case tt.expErr != nil:
+				require.ErrorIs(t, err, tt.expErr)
+			default:
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func Test_Preferences(t *testing.T) {
+	s, m := newTestService(t, 1)
+
+	m.store.EXPECT().GetPreferencesNotification(gomock.Any(), 4).Return(notification.Preferences{}, sql.ErrNoRows)
+
+	p, err := s.Preferences(context.Background(), 4)
+	require.NoError(t, err)
+	require.Equal(t, notification.DefaultPreferences(4), p)
+}
+
+func Test_EnqueueAndRun(t *testing.T) {
+	s, m := newTestService(t, 1)
+
+	e := notification.Event{Kind: notification.KindTaskCompleted, UserID: 4, Task: task.Task{ID: 9, Desc: "Ship", Userid: 4}}
+	require.NoError(t, s.Enqueue(e))
+	require.ErrorIs(t, s.Enqueue(e), ErrQueueFull)
+
+	delivered := make(chan struct{})
+
+	m.store.EXPECT().GetPreferencesNotification(gomock.Any(), 4).Return(notification.Preferences{}, sql.ErrNoRows)
+	m.users.EXPECT().Get(gomock.Any(), 4).Return(ana, nil)
+	m.notifier.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(func(context.Context, notification.Message) error {
+		close(delivered)
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+
+	select {
+	case <-delivered:
+	case <-time.After(time.Second):
+		t.Fatal("event was not delivered")
+	}
+
+	cancel()
+	<-done
+}
+
+func Test_Drain(t *testing.T) {
+	s, m := newTestService(t, 2)
+
+	e := notification.Event{Kind: notification.KindTaskCompleted, UserID: 4, Task: task.Task{ID: 9, Desc: "Ship", Userid: 4}}
+	require.NoError(t, s.Enqueue(e))
+	require.NoError(t, s.Enqueue(e))
+
+	m.store.EXPECT().GetPreferencesNotification(gomock.Any(), 4).Return(notification.Preferences{}, sql.ErrNoRows).Times(2)
+	m.users.EXPECT().Get(gomock.Any(), 4).Return(ana, nil).Times(2)
+	m.notifier.EXPECT().Send(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+
+	s.Drain(context.Background())
+
+	require.NoError(t, s.Enqueue(e), "the queue is empty once drained")
+}
@@ -1,19 +1,51 @@
 package task
 
 import (
+	"Task_Manager/model/notification"
 	"Task_Manager/model/task"
 	userModel "Task_Manager/model/user"
+	"context"
+	"time"
 )
 
 type TaskStoreInterface interface {
-	CreateTask(task task.Task) (task.Task, error)
-	GetByIDTask(id int) (task.Task, error)
-	GetAllTask() ([]task.Task, error)
-	CompleteTask(id int) error
-	DeleteTask(id int) error
-	GetTasksByUserIDTask(userId int) ([]task.Task, error)
+	CreateTask(ctx context.Context, task task.Task) (task.Task, error)
+	GetByIDTask(ctx context.Context, id int) (task.Task, error)
+	ExistsTask(ctx context.Context, desc string, userid int) (bool, error)
+	GetAllTask(ctx context.Context) ([]task.Task, error)
+	CountByStatusTask(ctx context.Context) (map[bool]int, error)
+	CountByWorkspaceTask(ctx context.Context, workspaceID int) (int, error)
+	CompleteTask(ctx context.Context, id int) error
+	DeleteTask(ctx context.Context, id int) error
+	GetTasksByUserIDTask(ctx context.Context, userId int) ([]task.Task, error)
+	GetByUserIDsTask(ctx context.Context, userids []int) ([]task.Task, error)
+	GetByLabelsTask(ctx context.Context, workspaceID int, labels []string, matchAll bool) ([]task.Task, error)
+	EachTask(ctx context.Context, f task.Filter, fn func(task.Task) error) error
+	GetTrashTask(ctx context.Context) ([]task.Trashed, error)
+	RestoreTask(ctx context.Context, id int) error
+	PurgeTask(ctx context.Context, before time.Time) ([]int, error)
+	RevertTask(ctx context.Context, id int, to task.Task) error
+	GetRevisionsTask(ctx context.Context, id int) ([]task.Revision, error)
+	GetRevisionTask(ctx context.Context, id, number int) (task.Revision, error)
+	GetAsOfTask(ctx context.Context, id int, at time.Time) (task.Revision, error)
+	BulkTask(ctx context.Context, ops []task.BulkOp, atomic bool) ([]task.BulkResult, error)
+	SnapshotTask(ctx context.Context, ids ...int) error
 }
 
 type UserServiceInterface interface {
-	Get(id int) (userModel.User, error)
+	Get(ctx context.Context, id int) (userModel.User, error)
+	Lock(ctx context.Context, id int) (userModel.User, error)
+}
+
+// UnitOfWork runs fn with the store calls it makes in one transaction
+type UnitOfWork interface {
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type AuditServiceInterface interface {
+	Record(ctx context.Context, action, entity string, entityID int, before, after any) error
+}
+
+type NotifierInterface interface {
+	Enqueue(e notification.Event) error
 }
@@ -10,9 +10,12 @@
 package task
 
 import (
+	notification "Task_Manager/model/notification"
 	task "Task_Manager/model/task"
 	user "Task_Manager/model/user"
+	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "go.uber.org/mock/gomock"
 )
@@ -41,92 +44,318 @@ func (m *MockTaskStoreInterface) EXPECT() *MockTaskStoreInterfaceMockRecorder {
 	return m.recorder
 }
 
+// BulkTask mocks base method.
+func (m *MockTaskStoreInterface) BulkTask(ctx context.Context, ops []task.BulkOp, atomic bool) ([]task.BulkResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BulkTask", ctx, ops, atomic)
+	ret0, _ := ret[0].([]task.BulkResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BulkTask indicates an expected call of BulkTask.
+func (mr *MockTaskStoreInterfaceMockRecorder) BulkTask(ctx, ops, atomic any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BulkTask", reflect.TypeOf((*MockTaskStoreInterface)(nil).BulkTask), ctx, ops, atomic)
+}
+
 // CompleteTask mocks base method.
-func (m *MockTaskStoreInterface) CompleteTask(id int) error {
+func (m *MockTaskStoreInterface) CompleteTask(ctx context.Context, id int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CompleteTask", id)
+	ret := m.ctrl.Call(m, "CompleteTask", ctx, id)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // CompleteTask indicates an expected call of CompleteTask.
-func (mr *MockTaskStoreInterfaceMockRecorder) CompleteTask(id any) *gomock.Call {
+func (mr *MockTaskStoreInterfaceMockRecorder) CompleteTask(ctx, id any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteTask", reflect.TypeOf((*MockTaskStoreInterface)(nil).CompleteTask), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteTask", reflect.TypeOf((*MockTaskStoreInterface)(nil).CompleteTask), ctx, id)
+}
+
+// CountByStatusTask mocks base method.
+func (m *MockTaskStoreInterface) CountByStatusTask(ctx context.Context) (map[bool]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountByStatusTask", ctx)
+	ret0, _ := ret[0].(map[bool]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountByStatusTask indicates an expected call of CountByStatusTask.
+func (mr *MockTaskStoreInterfaceMockRecorder) CountByStatusTask(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountByStatusTask", reflect.TypeOf((*MockTaskStoreInterface)(nil).CountByStatusTask), ctx)
+}
+
+// CountByWorkspaceTask mocks base method.
+func (m *MockTaskStoreInterface) CountByWorkspaceTask(ctx context.Context, workspaceID int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountByWorkspaceTask", ctx, workspaceID)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountByWorkspaceTask indicates an expected call of CountByWorkspaceTask.
+func (mr *MockTaskStoreInterfaceMockRecorder) CountByWorkspaceTask(ctx, workspaceID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountByWorkspaceTask", reflect.TypeOf((*MockTaskStoreInterface)(nil).CountByWorkspaceTask), ctx, workspaceID)
 }
 
 // CreateTask mocks base method.
-func (m *MockTaskStoreInterface) CreateTask(arg0 task.Task) (task.Task, error) {
+func (m *MockTaskStoreInterface) CreateTask(ctx context.Context, arg1 task.Task) (task.Task, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateTask", arg0)
+	ret := m.ctrl.Call(m, "CreateTask", ctx, arg1)
 	ret0, _ := ret[0].(task.Task)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateTask indicates an expected call of CreateTask.
-func (mr *MockTaskStoreInterfaceMockRecorder) CreateTask(arg0 any) *gomock.Call {
+func (mr *MockTaskStoreInterfaceMockRecorder) CreateTask(ctx, arg1 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTask", reflect.TypeOf((*MockTaskStoreInterface)(nil).CreateTask), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTask", reflect.TypeOf((*MockTaskStoreInterface)(nil).CreateTask), ctx, arg1)
 }
 
 // DeleteTask mocks base method.
-func (m *MockTaskStoreInterface) DeleteTask(id int) error {
+func (m *MockTaskStoreInterface) DeleteTask(ctx context.Context, id int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteTask", id)
+	ret := m.ctrl.Call(m, "DeleteTask", ctx, id)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteTask indicates an expected call of DeleteTask.
-func (mr *MockTaskStoreInterfaceMockRecorder) DeleteTask(id any) *gomock.Call {
+func (mr *MockTaskStoreInterfaceMockRecorder) DeleteTask(ctx, id any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTask", reflect.TypeOf((*MockTaskStoreInterface)(nil).DeleteTask), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTask", reflect.TypeOf((*MockTaskStoreInterface)(nil).DeleteTask), ctx, id)
+}
+
+// EachTask mocks base method.
+func (m *MockTaskStoreInterface) EachTask(ctx context.Context, f task.Filter, fn func(task.Task) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EachTask", ctx, f, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EachTask indicates an expected call of EachTask.
+func (mr *MockTaskStoreInterfaceMockRecorder) EachTask(ctx, f, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EachTask", reflect.TypeOf((*MockTaskStoreInterface)(nil).EachTask), ctx, f, fn)
+}
+
+// ExistsTask mocks base method.
+func (m *MockTaskStoreInterface) ExistsTask(ctx context.Context, desc string, userid int) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExistsTask", ctx, desc, userid)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExistsTask indicates an expected call of ExistsTask.
+func (mr *MockTaskStoreInterfaceMockRecorder) ExistsTask(ctx, desc, userid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExistsTask", reflect.TypeOf((*MockTaskStoreInterface)(nil).ExistsTask), ctx, desc, userid)
 }
 
 // GetAllTask mocks base method.
-func (m *MockTaskStoreInterface) GetAllTask() ([]task.Task, error) {
+func (m *MockTaskStoreInterface) GetAllTask(ctx context.Context) ([]task.Task, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAllTask")
+	ret := m.ctrl.Call(m, "GetAllTask", ctx)
 	ret0, _ := ret[0].([]task.Task)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetAllTask indicates an expected call of GetAllTask.
-func (mr *MockTaskStoreInterfaceMockRecorder) GetAllTask() *gomock.Call {
+func (mr *MockTaskStoreInterfaceMockRecorder) GetAllTask(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllTask", reflect.TypeOf((*MockTaskStoreInterface)(nil).GetAllTask), ctx)
+}
+
+// GetAsOfTask mocks base method.
+func (m *MockTaskStoreInterface) GetAsOfTask(ctx context.Context, id int, at time.Time) (task.Revision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAsOfTask", ctx, id, at)
+	ret0, _ := ret[0].(task.Revision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAsOfTask indicates an expected call of GetAsOfTask.
+func (mr *MockTaskStoreInterfaceMockRecorder) GetAsOfTask(ctx, id, at any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllTask", reflect.TypeOf((*MockTaskStoreInterface)(nil).GetAllTask))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAsOfTask", reflect.TypeOf((*MockTaskStoreInterface)(nil).GetAsOfTask), ctx, id, at)
 }
 
 // GetByIDTask mocks base method.
-func (m *MockTaskStoreInterface) GetByIDTask(id int) (task.Task, error) {
+func (m *MockTaskStoreInterface) GetByIDTask(ctx context.Context, id int) (task.Task, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetByIDTask", id)
+	ret := m.ctrl.Call(m, "GetByIDTask", ctx, id)
 	ret0, _ := ret[0].(task.Task)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetByIDTask indicates an expected call of GetByIDTask.
-func (mr *MockTaskStoreInterfaceMockRecorder) GetByIDTask(id any) *gomock.Call {
+func (mr *MockTaskStoreInterfaceMockRecorder) GetByIDTask(ctx, id any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByIDTask", reflect.TypeOf((*MockTaskStoreInterface)(nil).GetByIDTask), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByIDTask", reflect.TypeOf((*MockTaskStoreInterface)(nil).GetByIDTask), ctx, id)
+}
+
+// GetByLabelsTask mocks base method.
+func (m *MockTaskStoreInterface) GetByLabelsTask(ctx context.Context, workspaceID int, labels []string, matchAll bool) ([]task.Task, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByLabelsTask", ctx, workspaceID, labels, matchAll)
+	ret0, _ := ret[0].([]task.Task)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByLabelsTask indicates an expected call of GetByLabelsTask.
+func (mr *MockTaskStoreInterfaceMockRecorder) GetByLabelsTask(ctx, workspaceID, labels, matchAll any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByLabelsTask", reflect.TypeOf((*MockTaskStoreInterface)(nil).GetByLabelsTask), ctx, workspaceID, labels, matchAll)
+}
+
+// GetByUserIDsTask mocks base method.
+func (m *MockTaskStoreInterface) GetByUserIDsTask(ctx context.Context, userids []int) ([]task.Task, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByUserIDsTask", ctx, userids)
+	ret0, _ := ret[0].([]task.Task)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByUserIDsTask indicates an expected call of GetByUserIDsTask.
+func (mr *MockTaskStoreInterfaceMockRecorder) GetByUserIDsTask(ctx, userids any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByUserIDsTask", reflect.TypeOf((*MockTaskStoreInterface)(nil).GetByUserIDsTask), ctx, userids)
+}
+
+// GetRevisionTask mocks base method.
+func (m *MockTaskStoreInterface) GetRevisionTask(ctx context.Context, id, number int) (task.Revision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRevisionTask", ctx, id, number)
+	ret0, _ := ret[0].(task.Revision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRevisionTask indicates an expected call of GetRevisionTask.
+func (mr *MockTaskStoreInterfaceMockRecorder) GetRevisionTask(ctx, id, number any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRevisionTask", reflect.TypeOf((*MockTaskStoreInterface)(nil).GetRevisionTask), ctx, id, number)
+}
+
+// GetRevisionsTask mocks base method.
+func (m *MockTaskStoreInterface) GetRevisionsTask(ctx context.Context, id int) ([]task.Revision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRevisionsTask", ctx, id)
+	ret0, _ := ret[0].([]task.Revision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRevisionsTask indicates an expected call of GetRevisionsTask.
+func (mr *MockTaskStoreInterfaceMockRecorder) GetRevisionsTask(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRevisionsTask", reflect.TypeOf((*MockTaskStoreInterface)(nil).GetRevisionsTask), ctx, id)
 }
 
 // GetTasksByUserIDTask mocks base method.
-func (m *MockTaskStoreInterface) GetTasksByUserIDTask(userId int) ([]task.Task, error) {
+func (m *MockTaskStoreInterface) GetTasksByUserIDTask(ctx context.Context, userId int) ([]task.Task, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetTasksByUserIDTask", userId)
+	ret := m.ctrl.Call(m, "GetTasksByUserIDTask", ctx, userId)
 	ret0, _ := ret[0].([]task.Task)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetTasksByUserIDTask indicates an expected call of GetTasksByUserIDTask.
-func (mr *MockTaskStoreInterfaceMockRecorder) GetTasksByUserIDTask(userId any) *gomock.Call {
+func (mr *MockTaskStoreInterfaceMockRecorder) GetTasksByUserIDTask(ctx, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTasksByUserIDTask", reflect.TypeOf((*MockTaskStoreInterface)(nil).GetTasksByUserIDTask), ctx, userId)
+}
+
+// GetTrashTask mocks base method.
+func (m *MockTaskStoreInterface) GetTrashTask(ctx context.Context) ([]task.Trashed, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTrashTask", ctx)
+	ret0, _ := ret[0].([]task.Trashed)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTrashTask indicates an expected call of GetTrashTask.
+func (mr *MockTaskStoreInterfaceMockRecorder) GetTrashTask(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTrashTask", reflect.TypeOf((*MockTaskStoreInterface)(nil).GetTrashTask), ctx)
+}
+
+// PurgeTask mocks base method.
+func (m *MockTaskStoreInterface) PurgeTask(ctx context.Context, before time.Time) ([]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeTask", ctx, before)
+	ret0, _ := ret[0].([]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeTask indicates an expected call of PurgeTask.
+func (mr *MockTaskStoreInterfaceMockRecorder) PurgeTask(ctx, before any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeTask", reflect.TypeOf((*MockTaskStoreInterface)(nil).PurgeTask), ctx, before)
+}
+
+// RestoreTask mocks base method.
+func (m *MockTaskStoreInterface) RestoreTask(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreTask", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RestoreTask indicates an expected call of RestoreTask.
+func (mr *MockTaskStoreInterfaceMockRecorder) RestoreTask(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreTask", reflect.TypeOf((*MockTaskStoreInterface)(nil).RestoreTask), ctx, id)
+}
+
+// RevertTask mocks base method.
+func (m *MockTaskStoreInterface) RevertTask(ctx context.Context, id int, to task.Task) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevertTask", ctx, id, to)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevertTask indicates an expected call of RevertTask.
+func (mr *MockTaskStoreInterfaceMockRecorder) RevertTask(ctx, id, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevertTask", reflect.TypeOf((*MockTaskStoreInterface)(nil).RevertTask), ctx, id, to)
+}
+
+// SnapshotTask mocks base method.
+func (m *MockTaskStoreInterface) SnapshotTask(ctx context.Context, ids ...int) error {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx}
+	for _, a := range ids {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "SnapshotTask", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SnapshotTask indicates an expected call of SnapshotTask.
+func (mr *MockTaskStoreInterfaceMockRecorder) SnapshotTask(ctx any, ids ...any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTasksByUserIDTask", reflect.TypeOf((*MockTaskStoreInterface)(nil).GetTasksByUserIDTask), userId)
+	varargs := append([]any{ctx}, ids...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SnapshotTask", reflect.TypeOf((*MockTaskStoreInterface)(nil).SnapshotTask), varargs...)
 }
 
 // MockUserServiceInterface is a mock of UserServiceInterface interface.
@@ -154,16 +383,145 @@ func (m *MockUserServiceInterface) EXPECT() *MockUserServiceInterfaceMockRecorde
 }
 
 // Get mocks base method.
-func (m *MockUserServiceInterface) Get(id int) (user.User, error) {
+func (m *MockUserServiceInterface) Get(ctx context.Context, id int) (user.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Get", id)
+	ret := m.ctrl.Call(m, "Get", ctx, id)
 	ret0, _ := ret[0].(user.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Get indicates an expected call of Get.
-func (mr *MockUserServiceInterfaceMockRecorder) Get(id any) *gomock.Call {
+func (mr *MockUserServiceInterfaceMockRecorder) Get(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockUserServiceInterface)(nil).Get), ctx, id)
+}
+
+// Lock mocks base method.
+func (m *MockUserServiceInterface) Lock(ctx context.Context, id int) (user.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Lock", ctx, id)
+	ret0, _ := ret[0].(user.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Lock indicates an expected call of Lock.
+func (mr *MockUserServiceInterfaceMockRecorder) Lock(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Lock", reflect.TypeOf((*MockUserServiceInterface)(nil).Lock), ctx, id)
+}
+
+// MockUnitOfWork is a mock of UnitOfWork interface.
+type MockUnitOfWork struct {
+	ctrl     *gomock.Controller
+	recorder *MockUnitOfWorkMockRecorder
+	isgomock struct{}
+}
+
+// MockUnitOfWorkMockRecorder is the mock recorder for MockUnitOfWork.
+type MockUnitOfWorkMockRecorder struct {
+	mock *MockUnitOfWork
+}
+
+// NewMockUnitOfWork creates a new mock instance.
+func NewMockUnitOfWork(ctrl *gomock.Controller) *MockUnitOfWork {
+	mock := &MockUnitOfWork{ctrl: ctrl}
+	mock.recorder = &MockUnitOfWorkMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUnitOfWork) EXPECT() *MockUnitOfWorkMockRecorder {
+	return m.recorder
+}
+
+// WithTx mocks base method.
+func (m *MockUnitOfWork) WithTx(ctx context.Context, fn func(context.Context) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithTx", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WithTx indicates an expected call of WithTx.
+func (mr *MockUnitOfWorkMockRecorder) WithTx(ctx, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithTx", reflect.TypeOf((*MockUnitOfWork)(nil).WithTx), ctx, fn)
+}
+
+// MockAuditServiceInterface is a mock of AuditServiceInterface interface.
+type MockAuditServiceInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuditServiceInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockAuditServiceInterfaceMockRecorder is the mock recorder for MockAuditServiceInterface.
+type MockAuditServiceInterfaceMockRecorder struct {
+	mock *MockAuditServiceInterface
+}
+
+// NewMockAuditServiceInterface creates a new mock instance.
+func NewMockAuditServiceInterface(ctrl *gomock.Controller) *MockAuditServiceInterface {
+	mock := &MockAuditServiceInterface{ctrl: ctrl}
+	mock.recorder = &MockAuditServiceInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuditServiceInterface) EXPECT() *MockAuditServiceInterfaceMockRecorder {
+	return m.recorder
+}
+
+// Record mocks base method.
+func (m *MockAuditServiceInterface) Record(ctx context.Context, action, entity string, entityID int, before, after any) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Record", ctx, action, entity, entityID, before, after)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Record indicates an expected call of Record.
+func (mr *MockAuditServiceInterfaceMockRecorder) Record(ctx, action, entity, entityID, before, after any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Record", reflect.TypeOf((*MockAuditServiceInterface)(nil).Record), ctx, action, entity, entityID, before, after)
+}
+
+// MockNotifierInterface is a mock of NotifierInterface interface.
+type MockNotifierInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockNotifierInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockNotifierInterfaceMockRecorder is the mock recorder for MockNotifierInterface.
+type MockNotifierInterfaceMockRecorder struct {
+	mock *MockNotifierInterface
+}
+
+// NewMockNotifierInterface creates a new mock instance.
+func NewMockNotifierInterface(ctrl *gomock.Controller) *MockNotifierInterface {
+	mock := &MockNotifierInterface{ctrl: ctrl}
+	mock.recorder = &MockNotifierInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockNotifierInterface) EXPECT() *MockNotifierInterfaceMockRecorder {
+	return m.recorder
+}
+
+// Enqueue mocks base method.
+func (m *MockNotifierInterface) Enqueue(e notification.Event) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Enqueue", e)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Enqueue indicates an expected call of Enqueue.
+func (mr *MockNotifierInterfaceMockRecorder) Enqueue(e any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockUserServiceInterface)(nil).Get), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Enqueue", reflect.TypeOf((*MockNotifierInterface)(nil).Enqueue), e)
 }
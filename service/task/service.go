@@ -1,57 +1,717 @@
 package task
 
 import (
+	"Task_Manager/auth"
+	"Task_Manager/logging"
+	"Task_Manager/model/audit"
+	"Task_Manager/model/errs"
+	"Task_Manager/model/notification"
 	"Task_Manager/model/task"
+	"Task_Manager/model/user"
+	"Task_Manager/tracing"
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"time"
 )
 
+// tracer records a span for every service method
+var tracer = tracing.Tracer("service/task")
+
 type TaskService struct {
 	str            TaskStoreInterface
 	userServiceref UserServiceInterface
+	auditref       AuditServiceInterface
+	notifierref    NotifierInterface
+	purgeHooks     []func(id int)
+	changeHooks    []func(c task.Change)
+	quotas         task.Quotas
+	unit           UnitOfWork
 }
 
 func NewService(s TaskStoreInterface, us UserServiceInterface) *TaskService {
 	return &TaskService{
 		str:            s,
 		userServiceref: us,
+		unit:           noUnit{},
 	}
 }
 
-func (s *TaskService) Create(t task.Task) (task.Task, error) {
+// noUnit runs functions without a transaction, until SetUnitOfWork provides one
+type noUnit struct{}
+
+func (noUnit) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// SetUnitOfWork makes the operations spanning several statements, such as checking the assignee of a new
+// task and inserting it, run in one transaction
+func (s *TaskService) SetUnitOfWork(u UnitOfWork) {
+	s.unit = u
+}
+
+func (s *TaskService) Create(ctx context.Context, t task.Task) (task.Task, error) {
+	ctx, span := tracer.Start(ctx, "TaskService.Create")
+	defer span.End()
+
 	if err := t.Validate(); err != nil {
 		return t, err
 	}
 
-	_, err := s.userServiceref.Get(t.Userid)
+	// The assignee stays locked until the task is inserted, so a concurrent deletion of the user waits
+	// and then finds the task, instead of leaving it orphaned
+	var created task.Task
+	err := s.unit.WithTx(ctx, func(ctx context.Context) error {
+		if err := s.assignee(ctx, t.Userid); err != nil {
+			return err
+		}
+
+		if err := s.quota(ctx, t.WorkspaceID, 1); err != nil {
+			return err
+		}
+
+		var err error
+		if created, err = s.str.CreateTask(ctx, t); err != nil {
+			return err
+		}
+
+		return s.record(ctx, audit.ActionCreate, created.ID, nil, created)
+	})
+	if err != nil {
+		return created, err
+	}
+
+	s.changed(ctx, audit.ActionCreate, created.ID, nil, created)
+	s.notify(ctx, nil, created)
+
+	return created, nil
+}
+
+func (s *TaskService) GetTask(ctx context.Context, id int) (task.Task, error) {
+	ctx, span := tracer.Start(ctx, "TaskService.GetTask")
+	defer span.End()
+
+	return s.get(ctx, id)
+}
+
+// get reads a live task, reporting a missing one as task.ErrNotFound
+func (s *TaskService) get(ctx context.Context, id int) (task.Task, error) {
+	t, err := s.str.GetByIDTask(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return t, task.ErrNotFound
+	}
+
+	return t, err
+}
+
+// assignee checks that the user a task is assigned to exists
+func (s *TaskService) assignee(ctx context.Context, userid int) error {
+	_, err := s.userServiceref.Lock(ctx, userid)
+	if errors.Is(err, user.ErrNotFound) || errors.Is(err, sql.ErrNoRows) {
+		return errs.Invalid("task_user_invalid", fmt.Sprintf("user with ID %d does not exist", userid),
+			errs.FieldError{Field: "userid", Message: "must name an existing user"})
+	}
+
+	return err
+}
+
+// Exists reports whether a live task with the given description is assigned to the user
+func (s *TaskService) Exists(ctx context.Context, desc string, userid int) (bool, error) {
+	ctx, span := tracer.Start(ctx, "TaskService.Exists")
+	defer span.End()
+
+	return s.str.ExistsTask(ctx, desc, userid)
+}
+
+func (s *TaskService) Complete(ctx context.Context, id int) error {
+	ctx, span := tracer.Start(ctx, "TaskService.Complete")
+	defer span.End()
+
+	before, err := s.get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	after := before
+	after.Status = true
+
+	err = s.unit.WithTx(ctx, func(ctx context.Context) error {
+		if err := s.str.CompleteTask(ctx, id); err != nil {
+			return err
+		}
+
+		return s.record(ctx, audit.ActionComplete, id, before, after)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.changed(ctx, audit.ActionComplete, id, before, after)
+	s.notify(ctx, &before, after)
+
+	return nil
+}
+
+// Delete moves a task to the trash
+func (s *TaskService) Delete(ctx context.Context, id int) error {
+	ctx, span := tracer.Start(ctx, "TaskService.Delete")
+	defer span.End()
+
+	before, err := s.get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	err = s.unit.WithTx(ctx, func(ctx context.Context) error {
+		if err := s.str.DeleteTask(ctx, id); err != nil {
+			return err
+		}
+
+		return s.record(ctx, audit.ActionDelete, id, before, nil)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.changed(ctx, audit.ActionDelete, id, before, nil)
+
+	return nil
+}
+
+// Trash lists the deleted tasks that can still be restored
+func (s *TaskService) Trash(ctx context.Context) ([]task.Trashed, error) {
+	ctx, span := tracer.Start(ctx, "TaskService.Trash")
+	defer span.End()
+
+	return s.str.GetTrashTask(ctx)
+}
+
+// Restore takes a task out of the trash
+func (s *TaskService) Restore(ctx context.Context, id int) error {
+	ctx, span := tracer.Start(ctx, "TaskService.Restore")
+	defer span.End()
+
+	err := s.unit.WithTx(ctx, func(ctx context.Context) error {
+		if err := s.str.RestoreTask(ctx, id); err != nil {
+			return err
+		}
+
+		return s.record(ctx, audit.ActionRestore, id, nil, nil)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return task.ErrNotFound
+	}
+
+	if err != nil {
+		return err
+	}
+
+	s.changed(ctx, audit.ActionRestore, id, nil, nil)
+
+	return nil
+}
+
+// Snapshot records a revision of tasks changed by another service, such as the reassignments of a user
+// deletion. It must run in the unit of work of the change.
+func (s *TaskService) Snapshot(ctx context.Context, ids ...int) error {
+	ctx, span := tracer.Start(ctx, "TaskService.Snapshot")
+	defer span.End()
+
+	return s.str.SnapshotTask(ctx, ids...)
+}
+
+// Purge permanently deletes the tasks trashed before the given time, then runs the purge hooks for each of them
+func (s *TaskService) Purge(ctx context.Context, before time.Time) (int, error) {
+	ctx, span := tracer.Start(ctx, "TaskService.Purge")
+	defer span.End()
+
+	var ids []int
+
+	err := s.unit.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		if ids, err = s.str.PurgeTask(ctx, before); err != nil {
+			return err
+		}
+
+		for _, id := range ids {
+			if err := s.record(ctx, audit.ActionPurge, id, nil, nil); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, id := range ids {
+		s.changed(ctx, audit.ActionPurge, id, nil, nil)
+
+		for _, hook := range s.purgeHooks {
+			hook(id)
+		}
+	}
+
+	return len(ids), nil
+}
+
+// Revisions lists every recorded version of a task, oldest first
+func (s *TaskService) Revisions(ctx context.Context, id int) ([]task.Revision, error) {
+	ctx, span := tracer.Start(ctx, "TaskService.Revisions")
+	defer span.End()
+
+	revisions, err := s.str.GetRevisionsTask(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(revisions) == 0 {
+		return nil, task.ErrNoRevision
+	}
+
+	return revisions, nil
+}
+
+// AsOf returns the task as it was at the given time. A task that did not exist yet, or was in the trash, is not found.
+func (s *TaskService) AsOf(ctx context.Context, id int, at time.Time) (task.Task, error) {
+	ctx, span := tracer.Start(ctx, "TaskService.AsOf")
+	defer span.End()
+
+	r, err := s.str.GetAsOfTask(ctx, id, at)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && r.Deleted) {
+		return task.Task{}, task.ErrNoRevision
+	}
+
+	return r.Task, err
+}
+
+// DiffRevisions lists the fields changed between two revisions of a task
+func (s *TaskService) DiffRevisions(ctx context.Context, id, from, to int) ([]task.FieldChange, error) {
+	ctx, span := tracer.Start(ctx, "TaskService.DiffRevisions")
+	defer span.End()
+
+	a, err := s.revision(ctx, id, from)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := s.revision(ctx, id, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.Diff(b), nil
+}
+
+// Revert sets the description, status and assignee of a live task back to those of an earlier revision.
+// The revert itself becomes a new revision, so it can be undone the same way.
+func (s *TaskService) Revert(ctx context.Context, id, number int) (task.Task, error) {
+	ctx, span := tracer.Start(ctx, "TaskService.Revert")
+	defer span.End()
+
+	r, err := s.revision(ctx, id, number)
+	if err != nil {
+		return task.Task{}, err
+	}
+
+	current, err := s.get(ctx, id)
+	if err != nil {
+		return task.Task{}, err
+	}
+
+	// Revisions do not track the workspace, which never changes
+	r.Task.WorkspaceID = current.WorkspaceID
+
+	err = s.unit.WithTx(ctx, func(ctx context.Context) error {
+		// The assignee of an old revision may have been deleted since
+		if r.Task.Userid != 0 {
+			if err := s.assignee(ctx, r.Task.Userid); err != nil {
+				return err
+			}
+		}
+
+		if err := s.str.RevertTask(ctx, id, r.Task); err != nil {
+			return err
+		}
+
+		return s.record(ctx, audit.ActionRevert, id, current, r.Task)
+	})
+	if err != nil {
+		return task.Task{}, err
+	}
+
+	s.changed(ctx, audit.ActionRevert, id, current, r.Task)
+	s.notify(ctx, &current, r.Task)
+
+	return r.Task, nil
+}
+
+func (s *TaskService) revision(ctx context.Context, id, number int) (task.Revision, error) {
+	r, err := s.str.GetRevisionTask(ctx, id, number)
+	if errors.Is(err, sql.ErrNoRows) {
+		return r, task.ErrNoRevision
+	}
+
+	return r, err
+}
+
+// SetQuotas caps the number of tasks per workspace
+func (s *TaskService) SetQuotas(q task.Quotas) {
+	s.quotas = q
+}
+
+// quota checks that adding n tasks keeps a workspace within its quota. Counting locks the workspace until
+// the unit of work ends, so concurrent creations are checked one after another.
+func (s *TaskService) quota(ctx context.Context, workspaceID, n int) error {
+	limit := s.quotas.For(workspaceID)
+	if limit == 0 {
+		return nil
+	}
+
+	count, err := s.str.CountByWorkspaceTask(ctx, workspaceID)
+	if err != nil {
+		return err
+	}
+
+	if count+n > limit {
+		return fmt.Errorf("%w: workspace %d allows %d tasks", task.ErrQuotaExceeded, workspaceID, limit)
+	}
+
+	return nil
+}
+
+// SetAudit makes every mutation append an entry to the audit log
+func (s *TaskService) SetAudit(a AuditServiceInterface) {
+	s.auditref = a
+}
+
+// record appends an audit entry for a mutation. It runs in the unit of work of the mutation, so a failed
+// append fails the mutation too and neither is committed without the other.
+func (s *TaskService) record(ctx context.Context, action string, id int, before, after any) error {
+	if s.auditref == nil {
+		return nil
+	}
+
+	return s.auditref.Record(ctx, action, audit.EntityTask, id, before, after)
+}
+
+// SetNotifier makes assignments and completions notify the assignee
+func (s *TaskService) SetNotifier(n NotifierInterface) {
+	s.notifierref = n
+}
+
+// notify tells the assignee that a task was assigned to them or completed. before is nil for a new task.
+// Nobody is told about their own actions. Notifications go out once the change is committed and never fail it.
+func (s *TaskService) notify(ctx context.Context, before *task.Task, after task.Task) {
+	if s.notifierref == nil || after.Userid == 0 {
+		return
+	}
+
+	actor := auth.FromContext(ctx).UserID
+	if actor == after.Userid {
+		return
+	}
+
+	var kind string
+
+	switch {
+	case before == nil || before.Userid != after.Userid:
+		kind = notification.KindTaskAssigned
+	case after.Status && !before.Status:
+		kind = notification.KindTaskCompleted
+	default:
+		return
+	}
+
+	e := notification.Event{Kind: kind, UserID: after.Userid, ActorID: actor, Task: after, At: time.Now().UTC()}
+	if err := s.notifierref.Enqueue(e); err != nil {
+		logging.FromContext(ctx).Warn("notification dropped", "kind", kind, "task_id", after.ID, "error", err)
+	}
+}
+
+// OnChange registers fn to run after every committed change of a task, e.g. to push it to subscribers.
+// fn runs on the request that made the change and must not block.
+func (s *TaskService) OnChange(fn func(c task.Change)) {
+	s.changeHooks = append(s.changeHooks, fn)
+}
+
+// changed runs the change hooks. A restore records no task, so the restored task is read back.
+func (s *TaskService) changed(ctx context.Context, action string, id int, before, after any) {
+	if len(s.changeHooks) == 0 {
+		return
+	}
+
+	c := task.Change{Action: action, TaskID: id, At: time.Now().UTC()}
+
+	switch {
+	case after != nil:
+		if t, ok := after.(task.Task); ok {
+			c.Task = &t
+		}
+	case before != nil:
+		if t, ok := before.(task.Task); ok {
+			c.Task = &t
+		}
+	case action == audit.ActionRestore:
+		if t, err := s.get(ctx, id); err == nil {
+			c.Task = &t
+		}
+	}
+
+	for _, hook := range s.changeHooks {
+		hook(c)
+	}
+}
+
+// OnPurge registers fn to run after a task has been permanently deleted, e.g. to clean up data attached to it
+func (s *TaskService) OnPurge(fn func(id int)) {
+	s.purgeHooks = append(s.purgeHooks, fn)
+}
+
+func (s *TaskService) All(ctx context.Context) ([]task.Task, error) {
+	ctx, span := tracer.Start(ctx, "TaskService.All")
+	defer span.End()
+
+	return s.str.GetAllTask(ctx)
+}
+
+// CountByStatus counts the live tasks, by status: false for open and true for done
+func (s *TaskService) CountByStatus(ctx context.Context) (map[bool]int, error) {
+	ctx, span := tracer.Start(ctx, "TaskService.CountByStatus")
+	defer span.End()
+
+	return s.str.CountByStatusTask(ctx)
+}
+
+func (s *TaskService) GetTasksByUserID(ctx context.Context, userid int) ([]task.Task, error) {
+	ctx, span := tracer.Start(ctx, "TaskService.GetTasksByUserID")
+	defer span.End()
+
+	_, err := s.userServiceref.Get(ctx, userid)
+
 	if err != nil {
-		return t, fmt.Errorf("user with ID %d does not exist: %v", t.Userid, err)
+		return nil, err
 	}
 
-	return s.str.CreateTask(t)
+	return s.str.GetTasksByUserIDTask(ctx, userid)
 }
 
-func (s *TaskService) GetTask(id int) (task.Task, error) {
-	return s.str.GetByIDTask(id)
+// GetTasksByUserIDs returns the tasks of each of the given users, read in one query. Users without
+// tasks, and IDs of users that do not exist, are missing from the map.
+func (s *TaskService) GetTasksByUserIDs(ctx context.Context, userids []int) (map[int][]task.Task, error) {
+	ctx, span := tracer.Start(ctx, "TaskService.GetTasksByUserIDs")
+	defer span.End()
+
+	tasks, err := s.str.GetByUserIDsTask(ctx, userids)
+	if err != nil {
+		return nil, err
+	}
+
+	byUser := make(map[int][]task.Task, len(userids))
+	for _, t := range tasks {
+		byUser[t.Userid] = append(byUser[t.Userid], t)
+	}
+
+	return byUser, nil
 }
 
-func (s *TaskService) Complete(id int) error {
-	return s.str.CompleteTask(id)
+// Export calls fn for every task matching the filter as it is read from the store, for exports too
+// large to hold in memory
+func (s *TaskService) Export(ctx context.Context, f task.Filter, fn func(task.Task) error) error {
+	ctx, span := tracer.Start(ctx, "TaskService.Export")
+	defer span.End()
+
+	if f.UserID != 0 {
+		if _, err := s.userServiceref.Get(ctx, f.UserID); err != nil {
+			return err
+		}
+	}
+
+	return s.str.EachTask(ctx, f, fn)
 }
 
-func (s *TaskService) Delete(id int) error {
-	return s.str.DeleteTask(id)
+// ByLabels returns the tasks tagged with any, or with all, of the given label names
+func (s *TaskService) ByLabels(ctx context.Context, workspaceID int, labels []string, matchAll bool) ([]task.Task, error) {
+	ctx, span := tracer.Start(ctx, "TaskService.ByLabels")
+	defer span.End()
+
+	if len(labels) == 0 {
+		return s.str.GetAllTask(ctx)
+	}
+
+	return s.str.GetByLabelsTask(ctx, workspaceID, labels, matchAll)
 }
 
-func (s *TaskService) All() ([]task.Task, error) {
-	return s.str.GetAllTask()
+// MaxBulkOps caps the number of operations in one bulk request
+const MaxBulkOps = 500
+
+var bulkActions = map[string]string{
+	task.BulkCreate:   audit.ActionCreate,
+	task.BulkUpdate:   audit.ActionUpdate,
+	task.BulkComplete: audit.ActionComplete,
+	task.BulkDelete:   audit.ActionDelete,
+	task.BulkReassign: audit.ActionReassign,
 }
 
-func (s *TaskService) GetTasksByUserID(userid int) ([]task.Task, error) {
-	_, err := s.userServiceref.Get(userid)
+// Bulk applies a batch of operations. Atomic batches apply all operations or none; otherwise the valid
+// operations are applied and the rest reported as failed. Each task may appear in only one operation,
+// since the store applies the operations grouped by kind rather than in request order.
+func (s *TaskService) Bulk(ctx context.Context, ops []task.BulkOp, atomic bool) ([]task.BulkResult, error) {
+	ctx, span := tracer.Start(ctx, "TaskService.Bulk")
+	defer span.End()
+
+	if len(ops) == 0 || len(ops) > MaxBulkOps {
+		return nil, task.ErrBulkSize
+	}
+
+	// The assignees stay locked until the batch is applied; a failed atomic batch rolls back as a whole
+	var results []task.BulkResult
+	err := s.unit.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		if results, err = s.bulk(ctx, ops, atomic); err != nil {
+			return err
+		}
 
+		for _, r := range results {
+			if r.Status != task.BulkOK {
+				continue
+			}
+
+			before, after := bulkStates(r)
+			if err := s.record(ctx, bulkActions[r.Op], r.ID, before, after); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
 	if err != nil {
+		return results, err
+	}
+
+	for _, r := range results {
+		if r.Status != task.BulkOK {
+			continue
+		}
+
+		before, after := bulkStates(r)
+		s.changed(ctx, bulkActions[r.Op], r.ID, before, after)
+
+		if r.Task != nil {
+			s.notify(ctx, r.Before, *r.Task)
+		}
+	}
+
+	return results, nil
+}
+
+// bulkStates returns the task before and after an applied operation, nil where there is none
+func bulkStates(r task.BulkResult) (before, after any) {
+	if r.Before != nil {
+		before = *r.Before
+	}
+
+	if r.Task != nil {
+		after = *r.Task
+	}
+
+	return before, after
+}
+
+// bulk validates a batch and applies its valid operations
+func (s *TaskService) bulk(ctx context.Context, ops []task.BulkOp, atomic bool) ([]task.BulkResult, error) {
+	results := make([]task.BulkResult, len(ops))
+	seen := make(map[int]bool)
+	users := make(map[int]error)
+	// created counts the tasks each workspace already holds plus those created by earlier operations
+	created := make(map[int]int)
+
+	var valid []int
+
+	for i, op := range ops {
+		results[i] = task.BulkResult{Index: i, Op: op.Op, ID: op.ID, Status: task.BulkOK}
+
+		err := op.Validate()
+		if err == nil && op.Op != task.BulkCreate {
+			if seen[op.ID] {
+				err = task.ErrDuplicateID
+			}
+
+			seen[op.ID] = true
+		}
+
+		if err == nil && (op.Op == task.BulkCreate || op.Op == task.BulkReassign) {
+			uerr, ok := users[op.Userid]
+			if !ok {
+				_, uerr = s.userServiceref.Lock(ctx, op.Userid)
+				users[op.Userid] = uerr
+			}
+
+			// Only a missing user fails the operation; anything else, such as a lost connection, fails the batch
+			switch {
+			case errors.Is(uerr, user.ErrNotFound) || errors.Is(uerr, sql.ErrNoRows):
+				err = fmt.Errorf("user with ID %d does not exist", op.Userid)
+			case uerr != nil:
+				return nil, uerr
+			}
+		}
+
+		if limit := s.quotas.For(op.WorkspaceID); err == nil && op.Op == task.BulkCreate && limit != 0 {
+			if _, ok := created[op.WorkspaceID]; !ok {
+				n, err := s.str.CountByWorkspaceTask(ctx, op.WorkspaceID)
+				if err != nil {
+					return nil, err
+				}
+
+				created[op.WorkspaceID] = n
+			}
+
+			if created[op.WorkspaceID] >= limit {
+				err = task.ErrQuotaExceeded
+			} else {
+				created[op.WorkspaceID]++
+			}
+		}
+
+		if err != nil {
+			results[i].Status = task.BulkFailed
+			results[i].Error = err.Error()
+
+			continue
+		}
+
+		valid = append(valid, i)
+	}
+
+	if atomic && len(valid) < len(ops) {
+		for _, i := range valid {
+			results[i].Status = task.BulkRolledBack
+		}
+
+		return results, task.ErrBulkFailed
+	}
+
+	if len(valid) == 0 {
+		return results, nil
+	}
+
+	batch := make([]task.BulkOp, len(valid))
+	for j, i := range valid {
+		batch[j] = ops[i]
+	}
+
+	applied, err := s.str.BulkTask(ctx, batch, atomic)
+	if err != nil && !errors.Is(err, task.ErrBulkFailed) {
 		return nil, err
 	}
 
-	return s.str.GetTasksByUserIDTask(userid)
+	for j, i := range valid {
+		r := applied[j]
+		r.Index = i
+		results[i] = r
+	}
+
+	return results, err
 }
@@ -1,12 +1,19 @@
 package task
 
 import (
+	"Task_Manager/auth"
+	"Task_Manager/model/audit"
+	"Task_Manager/model/errs"
+	"Task_Manager/model/notification"
 	"Task_Manager/model/task"
 	"Task_Manager/model/user"
+	"context"
+	"database/sql"
 	"errors"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
 	"testing"
+	"time"
 )
 
 func Test_Create(t *testing.T) {
@@ -56,17 +63,17 @@ func Test_Create(t *testing.T) {
 
 		if err := tt.input.Validate(); err == nil {
 			mockUserServ.EXPECT().
-				Get(tt.input.Userid).
+				Lock(gomock.Any(), tt.input.Userid).
 				Return(tt.mockUser, tt.userErr)
 
 			if tt.userErr == nil {
 				mockStore.EXPECT().
-					CreateTask(tt.input).
+					CreateTask(gomock.Any(), tt.input).
 					Return(tt.mockTaskOut, tt.taskErr)
 			}
 		}
 
-		result, err := service.Create(tt.input)
+		result, err := service.Create(context.Background(), tt.input)
 
 		if tt.expErr {
 			assert.Error(t, err, tt.name)
@@ -85,7 +92,7 @@ func Test_GetTask(t *testing.T) {
 		mockErr    error
 		expErr     bool
 	}{
-		{"Valid Id", 1, task.Task{1, "Working", false, 1}, nil, false},
+		{"Valid Id", 1, task.Task{ID: 1, Desc: "Working", Status: false, Userid: 1}, nil, false},
 		{"Task Not found", 1, task.Task{}, errors.New("task not found"), true},
 	}
 
@@ -97,10 +104,10 @@ func Test_GetTask(t *testing.T) {
 		service := NewService(mockStore, mockUserServ)
 
 		mockStore.EXPECT().
-			GetByIDTask(tt.id).
+			GetByIDTask(gomock.Any(), tt.id).
 			Return(tt.mockOutput, tt.mockErr)
 
-		res, err := service.GetTask(tt.id)
+		res, err := service.GetTask(context.Background(), tt.id)
 
 		if tt.expErr {
 			assert.Error(t, err, tt.name)
@@ -112,6 +119,33 @@ func Test_GetTask(t *testing.T) {
 	}
 }
 
+func Test_NotFound(t *testing.T) {
+	t.Run("Missing task", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockStore := NewMockTaskStoreInterface(ctrl)
+		service := NewService(mockStore, nil)
+
+		mockStore.EXPECT().GetByIDTask(gomock.Any(), 7).Return(task.Task{}, sql.ErrNoRows)
+
+		_, err := service.GetTask(context.Background(), 7)
+		assert.ErrorIs(t, err, task.ErrNotFound)
+	})
+
+	t.Run("Missing assignee", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockUserServ := NewMockUserServiceInterface(ctrl)
+		service := NewService(nil, mockUserServ)
+
+		mockUserServ.EXPECT().Lock(gomock.Any(), 20).Return(user.User{}, user.ErrNotFound)
+
+		_, err := service.Create(context.Background(), task.Task{Desc: "Plan", Userid: 20})
+
+		var verr *errs.Validation
+		assert.ErrorAs(t, err, &verr)
+		assert.Equal(t, "userid", verr.Fields[0].Field)
+	})
+}
+
 func Test_AllTasks(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -119,7 +153,7 @@ func Test_AllTasks(t *testing.T) {
 		mockErr    error
 		expErr     bool
 	}{
-		{"Data fetched", []task.Task{{1, "Working", false, 1}}, nil, false},
+		{"Data fetched", []task.Task{{ID: 1, Desc: "Working", Status: false, Userid: 1}}, nil, false},
 		{"Unable to fetch", []task.Task{}, errors.New("task not found"), true},
 	}
 
@@ -129,9 +163,9 @@ func Test_AllTasks(t *testing.T) {
 		mockStore := NewMockTaskStoreInterface(ctrl)
 		mockUserServ := NewMockUserServiceInterface(ctrl)
 		service := NewService(mockStore, mockUserServ)
-		mockStore.EXPECT().GetAllTask().Return(tt.mockOutput, tt.mockErr)
+		mockStore.EXPECT().GetAllTask(gomock.Any()).Return(tt.mockOutput, tt.mockErr)
 
-		res, err := service.All()
+		res, err := service.All(context.Background())
 		if tt.expErr {
 			assert.Error(t, err, tt.name)
 		} else {
@@ -142,6 +176,18 @@ func Test_AllTasks(t *testing.T) {
 	}
 }
 
+func Test_CountByStatus(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockStore := NewMockTaskStoreInterface(ctrl)
+	service := NewService(mockStore, NewMockUserServiceInterface(ctrl))
+
+	mockStore.EXPECT().CountByStatusTask(gomock.Any()).Return(map[bool]int{false: 3, true: 1}, nil)
+
+	counts, err := service.CountByStatus(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, map[bool]int{false: 3, true: 1}, counts)
+}
+
 func Test_CompleteTask(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -168,9 +214,10 @@ func Test_CompleteTask(t *testing.T) {
 		mockStore := NewMockTaskStoreInterface(ctrl)
 
 		service := NewService(mockStore, nil)
-		mockStore.EXPECT().CompleteTask(tt.input).Return(tt.taskErr).AnyTimes()
+		mockStore.EXPECT().GetByIDTask(gomock.Any(), tt.input).Return(task.Task{ID: tt.input, Desc: "Work"}, nil)
+		mockStore.EXPECT().CompleteTask(gomock.Any(), tt.input).Return(tt.taskErr).AnyTimes()
 
-		err := service.Complete(tt.input)
+		err := service.Complete(context.Background(), tt.input)
 		if tt.expErr {
 			assert.Error(t, err, tt.name)
 		} else {
@@ -206,8 +253,9 @@ func Test_DeleteTask(t *testing.T) {
 		defer ctrl.Finish()
 		mockStore := NewMockTaskStoreInterface(ctrl)
 		service := NewService(mockStore, nil)
-		mockStore.EXPECT().DeleteTask(tt.input).Return(tt.taskErr).AnyTimes()
-		err := service.Delete(tt.input)
+		mockStore.EXPECT().GetByIDTask(gomock.Any(), tt.input).Return(task.Task{ID: tt.input, Desc: "Work"}, nil)
+		mockStore.EXPECT().DeleteTask(gomock.Any(), tt.input).Return(tt.taskErr).AnyTimes()
+		err := service.Delete(context.Background(), tt.input)
 		if tt.expErr {
 			assert.Error(t, err, tt.name)
 		} else {
@@ -254,16 +302,16 @@ func Test_GetTasksByUserId(t *testing.T) {
 		service := NewService(mockStore, mockUserServ)
 
 		mockUserServ.EXPECT().
-			Get(tt.input).
+			Get(gomock.Any(), tt.input).
 			Return(tt.mockUser, tt.userErr)
 
 		if tt.userErr == nil {
 			mockStore.EXPECT().
-				GetTasksByUserIDTask(tt.input).
+				GetTasksByUserIDTask(gomock.Any(), tt.input).
 				Return(tt.mockOutput, tt.mockErr)
 		}
 
-		res, err := service.GetTasksByUserID(tt.input)
+		res, err := service.GetTasksByUserID(context.Background(), tt.input)
 
 		if tt.expErr {
 			assert.Error(t, err, tt.name)
@@ -273,3 +321,643 @@ func Test_GetTasksByUserId(t *testing.T) {
 		}
 	}
 }
+
+func Test_GetTasksByUserIDs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockStore := NewMockTaskStoreInterface(ctrl)
+	service := NewService(mockStore, nil)
+
+	mockStore.EXPECT().GetByUserIDsTask(gomock.Any(), []int{1, 2, 3}).
+		Return([]task.Task{{ID: 1, Userid: 2}, {ID: 2, Userid: 1}, {ID: 3, Userid: 2}}, nil)
+
+	byUser, err := service.GetTasksByUserIDs(context.Background(), []int{1, 2, 3})
+	assert.NoError(t, err)
+	assert.Equal(t, map[int][]task.Task{1: {{ID: 2, Userid: 1}}, 2: {{ID: 1, Userid: 2}, {ID: 3, Userid: 2}}}, byUser)
+
+	mockStore.EXPECT().GetByUserIDsTask(gomock.Any(), []int{4}).Return(nil, errors.New("db down"))
+
+	_, err = service.GetTasksByUserIDs(context.Background(), []int{4})
+	assert.Error(t, err)
+}
+
+func Test_ByLabels(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockStore := NewMockTaskStoreInterface(ctrl)
+	service := NewService(mockStore, nil)
+
+	mockStore.EXPECT().GetByLabelsTask(gomock.Any(), 2, []string{"bug", "urgent"}, true).
+		Return([]task.Task{{ID: 1, Desc: "Fix login", Userid: 1}}, nil)
+
+	res, err := service.ByLabels(context.Background(), 2, []string{"bug", "urgent"}, true)
+	assert.NoError(t, err)
+	assert.Len(t, res, 1)
+
+	mockStore.EXPECT().GetAllTask(gomock.Any()).Return([]task.Task{}, nil)
+
+	_, err = service.ByLabels(context.Background(), 0, nil, false)
+	assert.NoError(t, err)
+}
+
+func Test_Export(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockStore := NewMockTaskStoreInterface(ctrl)
+	mockUserServ := NewMockUserServiceInterface(ctrl)
+	service := NewService(mockStore, mockUserServ)
+
+	fn := func(task.Task) error { return nil }
+
+	mockStore.EXPECT().EachTask(gomock.Any(), task.Filter{Labels: []string{"bug"}}, gomock.Any()).Return(nil)
+	assert.NoError(t, service.Export(context.Background(), task.Filter{Labels: []string{"bug"}}, fn))
+
+	mockUserServ.EXPECT().Get(gomock.Any(), 2).Return(user.User{ID: 2}, nil)
+	mockStore.EXPECT().EachTask(gomock.Any(), task.Filter{UserID: 2}, gomock.Any()).Return(nil)
+	assert.NoError(t, service.Export(context.Background(), task.Filter{UserID: 2}, fn))
+
+	// An unknown user fails before anything is streamed
+	mockUserServ.EXPECT().Get(gomock.Any(), 3).Return(user.User{}, sql.ErrNoRows)
+	assert.ErrorIs(t, service.Export(context.Background(), task.Filter{UserID: 3}, fn), sql.ErrNoRows)
+}
+
+func Test_TrashAndRestore(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockStore := NewMockTaskStoreInterface(ctrl)
+	service := NewService(mockStore, nil)
+
+	mockStore.EXPECT().GetTrashTask(gomock.Any()).Return([]task.Trashed{{Task: task.Task{ID: 1, Desc: "Old"}}}, nil)
+
+	trash, err := service.Trash(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, trash, 1)
+
+	mockStore.EXPECT().RestoreTask(gomock.Any(), 1).Return(nil)
+	assert.NoError(t, service.Restore(context.Background(), 1))
+
+	mockStore.EXPECT().RestoreTask(gomock.Any(), 2).Return(errors.New("not in trash"))
+	assert.Error(t, service.Restore(context.Background(), 2))
+}
+
+func Test_Purge(t *testing.T) {
+	before := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("Runs hooks for purged tasks", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockStore := NewMockTaskStoreInterface(ctrl)
+		service := NewService(mockStore, nil)
+
+		var hooked []int
+		service.OnPurge(func(id int) { hooked = append(hooked, id) })
+
+		mockStore.EXPECT().PurgeTask(gomock.Any(), before).Return([]int{4, 7}, nil)
+
+		n, err := service.Purge(context.Background(), before)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, n)
+		assert.Equal(t, []int{4, 7}, hooked)
+	})
+
+	t.Run("Store error skips hooks", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockStore := NewMockTaskStoreInterface(ctrl)
+		service := NewService(mockStore, nil)
+
+		service.OnPurge(func(int) { t.Error("hook must not run") })
+
+		mockStore.EXPECT().PurgeTask(gomock.Any(), before).Return(nil, errors.New("db down"))
+
+		_, err := service.Purge(context.Background(), before)
+		assert.Error(t, err)
+	})
+}
+
+func Test_AuditedMutations(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockStore := NewMockTaskStoreInterface(ctrl)
+	mockUserServ := NewMockUserServiceInterface(ctrl)
+	mockAudit := NewMockAuditServiceInterface(ctrl)
+
+	service := NewService(mockStore, mockUserServ)
+	service.SetAudit(mockAudit)
+
+	ctx := context.Background()
+	open := task.Task{ID: 5, Desc: "Ship", Userid: 2}
+	done := open
+	done.Status = true
+
+	mockUserServ.EXPECT().Lock(gomock.Any(), 2).Return(user.User{ID: 2}, nil)
+	mockStore.EXPECT().CreateTask(gomock.Any(), task.Task{Desc: "Ship", Userid: 2}).Return(open, nil)
+	mockAudit.EXPECT().Record(gomock.Any(), audit.ActionCreate, audit.EntityTask, 5, nil, open).Return(nil)
+
+	_, err := service.Create(ctx, task.Task{Desc: "Ship", Userid: 2})
+	assert.NoError(t, err)
+
+	mockStore.EXPECT().GetByIDTask(gomock.Any(), 5).Return(open, nil)
+	mockStore.EXPECT().CompleteTask(gomock.Any(), 5).Return(nil)
+	mockAudit.EXPECT().Record(gomock.Any(), audit.ActionComplete, audit.EntityTask, 5, open, done).Return(nil)
+
+	assert.NoError(t, service.Complete(ctx, 5))
+
+	// The entry is appended in the unit of work of the mutation, so a failing audit log fails the
+	// mutation, which is rolled back and never reported as a change
+	var changes int
+	service.OnChange(func(task.Change) { changes++ })
+
+	mockStore.EXPECT().GetByIDTask(gomock.Any(), 5).Return(done, nil)
+	mockStore.EXPECT().DeleteTask(gomock.Any(), 5).Return(nil)
+	mockAudit.EXPECT().Record(gomock.Any(), audit.ActionDelete, audit.EntityTask, 5, done, nil).Return(errors.New("audit down"))
+
+	assert.EqualError(t, service.Delete(ctx, 5), "audit down")
+	assert.Zero(t, changes)
+
+	// Failed mutations are not audited
+	mockStore.EXPECT().GetByIDTask(gomock.Any(), 6).Return(task.Task{}, errors.New("task not found"))
+
+	assert.Error(t, service.Complete(ctx, 6))
+}
+
+func Test_ChangeHooks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockStore := NewMockTaskStoreInterface(ctrl)
+	service := NewService(mockStore, nil)
+
+	var changes []task.Change
+	service.OnChange(func(c task.Change) { changes = append(changes, c) })
+
+	ctx := context.Background()
+	open := task.Task{ID: 5, Desc: "Ship", Userid: 2}
+	done := open
+	done.Status = true
+
+	mockStore.EXPECT().GetByIDTask(gomock.Any(), 5).Return(open, nil)
+	mockStore.EXPECT().CompleteTask(gomock.Any(), 5).Return(nil)
+	assert.NoError(t, service.Complete(ctx, 5))
+
+	mockStore.EXPECT().GetByIDTask(gomock.Any(), 5).Return(done, nil)
+	mockStore.EXPECT().DeleteTask(gomock.Any(), 5).Return(nil)
+	assert.NoError(t, service.Delete(ctx, 5))
+
+	// The restored task is read back, since the restore itself records none
+	mockStore.EXPECT().RestoreTask(gomock.Any(), 5).Return(nil)
+	mockStore.EXPECT().GetByIDTask(gomock.Any(), 5).Return(done, nil)
+	assert.NoError(t, service.Restore(ctx, 5))
+
+	mockStore.EXPECT().PurgeTask(gomock.Any(), gomock.Any()).Return([]int{7}, nil)
+	_, err := service.Purge(ctx, time.Now())
+	assert.NoError(t, err)
+
+	// Failed changes are not told
+	mockStore.EXPECT().GetByIDTask(gomock.Any(), 6).Return(task.Task{}, errors.New("task not found"))
+	assert.Error(t, service.Complete(ctx, 6))
+
+	assert.Len(t, changes, 4)
+	assert.Equal(t, []string{audit.ActionComplete, audit.ActionDelete, audit.ActionRestore, audit.ActionPurge},
+		[]string{changes[0].Action, changes[1].Action, changes[2].Action, changes[3].Action})
+	assert.Equal(t, &done, changes[0].Task)
+	assert.Equal(t, &done, changes[1].Task)
+	assert.Equal(t, &done, changes[2].Task)
+	assert.Nil(t, changes[3].Task)
+	assert.Equal(t, 7, changes[3].TaskID)
+}
+
+func Test_Notifications(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockStore := NewMockTaskStoreInterface(ctrl)
+	mockUserServ := NewMockUserServiceInterface(ctrl)
+	mockNotifier := NewMockNotifierInterface(ctrl)
+
+	service := NewService(mockStore, mockUserServ)
+	service.SetNotifier(mockNotifier)
+
+	var events []notification.Event
+	mockNotifier.EXPECT().Enqueue(gomock.Any()).DoAndReturn(func(e notification.Event) error {
+		assert.False(t, e.At.IsZero())
+		e.At = time.Time{}
+		events = append(events, e)
+		return nil
+	}).AnyTimes()
+
+	manager := auth.WithActor(context.Background(), auth.Actor{UserID: 1})
+	assignee := auth.WithActor(context.Background(), auth.Actor{UserID: 2})
+
+	open := task.Task{ID: 5, Desc: "Ship", Userid: 2}
+	done := open
+	done.Status = true
+
+	// Assigning a task to someone else notifies them
+	mockUserServ.EXPECT().Lock(gomock.Any(), 2).Return(user.User{ID: 2}, nil).Times(2)
+	mockStore.EXPECT().CreateTask(gomock.Any(), gomock.Any()).Return(open, nil).Times(2)
+
+	_, err := service.Create(manager, task.Task{Desc: "Ship", Userid: 2})
+	assert.NoError(t, err)
+
+	// Creating a task for oneself does not
+	_, err = service.Create(assignee, task.Task{Desc: "Ship", Userid: 2})
+	assert.NoError(t, err)
+
+	// Completion by someone else notifies the assignee
+	mockStore.EXPECT().GetByIDTask(gomock.Any(), 5).Return(open, nil).Times(2)
+	mockStore.EXPECT().CompleteTask(gomock.Any(), 5).Return(nil).Times(2)
+
+	assert.NoError(t, service.Complete(manager, 5))
+	assert.NoError(t, service.Complete(assignee, 5))
+
+	// Reassignment in bulk notifies the new assignee only
+	reassigned := task.Task{ID: 6, Desc: "Test", Userid: 3}
+
+	mockUserServ.EXPECT().Lock(gomock.Any(), 3).Return(user.User{ID: 3}, nil)
+	mockStore.EXPECT().BulkTask(gomock.Any(), gomock.Any(), false).Return([]task.BulkResult{
+		{Op: task.BulkReassign, ID: 6, Status: task.BulkOK, Task: &reassigned, Before: &task.Task{ID: 6, Desc: "Test", Userid: 2}},
+	}, nil)
+
+	_, err = service.Bulk(manager, []task.BulkOp{{Op: task.BulkReassign, ID: 6, Userid: 3}}, false)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []notification.Event{
+		{Kind: notification.KindTaskAssigned, UserID: 2, ActorID: 1, Task: open},
+		{Kind: notification.KindTaskCompleted, UserID: 2, ActorID: 1, Task: done},
+		{Kind: notification.KindTaskAssigned, UserID: 3, ActorID: 1, Task: reassigned},
+	}, events)
+}
+
+func Test_Revisions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockStore := NewMockTaskStoreInterface(ctrl)
+	service := NewService(mockStore, nil)
+
+	mockStore.EXPECT().GetRevisionsTask(gomock.Any(), 1).Return([]task.Revision{{TaskID: 1, Number: 1}}, nil)
+
+	revisions, err := service.Revisions(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Len(t, revisions, 1)
+
+	mockStore.EXPECT().GetRevisionsTask(gomock.Any(), 2).Return(nil, nil)
+
+	_, err = service.Revisions(context.Background(), 2)
+	assert.ErrorIs(t, err, task.ErrNoRevision)
+}
+
+func Test_AsOf(t *testing.T) {
+	at := time.Date(2024, 5, 7, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		rev     task.Revision
+		storErr error
+		expErr  error
+	}{
+		{"Existing version", task.Revision{TaskID: 1, Task: task.Task{ID: 1, Desc: "Then"}}, nil, nil},
+		{"Trashed at the time", task.Revision{TaskID: 1, Deleted: true}, nil, task.ErrNoRevision},
+		{"Not created yet", task.Revision{}, sql.ErrNoRows, task.ErrNoRevision},
+		{"Store error", task.Revision{}, errors.New("db down"), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockStore := NewMockTaskStoreInterface(ctrl)
+			service := NewService(mockStore, nil)
+
+			mockStore.EXPECT().GetAsOfTask(gomock.Any(), 1, at).Return(tt.rev, tt.storErr)
+
+			res, err := service.AsOf(context.Background(), 1, at)
+			switch {
+			case tt.expErr != nil:
+				assert.ErrorIs(t, err, tt.expErr)
+			case tt.storErr != nil:
+				assert.Error(t, err)
+			default:
+				assert.NoError(t, err)
+				assert.Equal(t, "Then", res.Desc)
+			}
+		})
+	}
+}
+
+func Test_DiffRevisions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockStore := NewMockTaskStoreInterface(ctrl)
+	service := NewService(mockStore, nil)
+
+	mockStore.EXPECT().GetRevisionTask(gomock.Any(), 1, 1).Return(task.Revision{TaskID: 1, Number: 1, Task: task.Task{Desc: "Draft", Userid: 2}}, nil)
+	due := time.Date(2024, 5, 7, 12, 0, 0, 0, time.UTC)
+	mockStore.EXPECT().GetRevisionTask(gomock.Any(), 1, 3).Return(task.Revision{TaskID: 1, Number: 3, Task: task.Task{Desc: "Final", Status: true, Userid: 2, Due: &due}}, nil)
+
+	changes, err := service.DiffRevisions(context.Background(), 1, 1, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, []task.FieldChange{
+		{Field: "desc", From: "Draft", To: "Final"},
+		{Field: "status", From: false, To: true},
+		{Field: "due", From: (*time.Time)(nil), To: &due},
+	}, changes)
+
+	mockStore.EXPECT().GetRevisionTask(gomock.Any(), 1, 9).Return(task.Revision{}, sql.ErrNoRows)
+
+	_, err = service.DiffRevisions(context.Background(), 1, 9, 3)
+	assert.ErrorIs(t, err, task.ErrNoRevision)
+}
+
+func Test_Revert(t *testing.T) {
+	old := task.Revision{TaskID: 1, Number: 1, Task: task.Task{ID: 1, Desc: "Draft", Userid: 2}}
+	current := task.Task{ID: 1, Desc: "Final", Status: true, Userid: 3}
+
+	t.Run("Reverts and audits", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockStore := NewMockTaskStoreInterface(ctrl)
+		mockUserServ := NewMockUserServiceInterface(ctrl)
+		mockAudit := NewMockAuditServiceInterface(ctrl)
+		service := NewService(mockStore, mockUserServ)
+		service.SetAudit(mockAudit)
+
+		mockStore.EXPECT().GetRevisionTask(gomock.Any(), 1, 1).Return(old, nil)
+		mockStore.EXPECT().GetByIDTask(gomock.Any(), 1).Return(current, nil)
+		mockUserServ.EXPECT().Lock(gomock.Any(), 2).Return(user.User{ID: 2}, nil)
+		mockStore.EXPECT().RevertTask(gomock.Any(), 1, old.Task).Return(nil)
+		mockAudit.EXPECT().Record(gomock.Any(), audit.ActionRevert, audit.EntityTask, 1, current, old.Task).Return(nil)
+
+		res, err := service.Revert(context.Background(), 1, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, "Draft", res.Desc)
+	})
+
+	t.Run("Former assignee deleted", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockStore := NewMockTaskStoreInterface(ctrl)
+		mockUserServ := NewMockUserServiceInterface(ctrl)
+		service := NewService(mockStore, mockUserServ)
+
+		mockStore.EXPECT().GetRevisionTask(gomock.Any(), 1, 1).Return(old, nil)
+		mockStore.EXPECT().GetByIDTask(gomock.Any(), 1).Return(current, nil)
+		mockUserServ.EXPECT().Lock(gomock.Any(), 2).Return(user.User{}, sql.ErrNoRows)
+
+		_, err := service.Revert(context.Background(), 1, 1)
+		assert.Error(t, err)
+	})
+
+	t.Run("Task in trash", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockStore := NewMockTaskStoreInterface(ctrl)
+		service := NewService(mockStore, nil)
+
+		mockStore.EXPECT().GetRevisionTask(gomock.Any(), 1, 1).Return(old, nil)
+		mockStore.EXPECT().GetByIDTask(gomock.Any(), 1).Return(task.Task{}, sql.ErrNoRows)
+
+		_, err := service.Revert(context.Background(), 1, 1)
+		assert.ErrorIs(t, err, task.ErrNotFound)
+	})
+}
+
+func Test_Bulk(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Rejects an empty batch", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := NewService(NewMockTaskStoreInterface(ctrl), NewMockUserServiceInterface(ctrl))
+
+		_, err := service.Bulk(ctx, nil, false)
+		assert.ErrorIs(t, err, task.ErrBulkSize)
+	})
+
+	t.Run("Atomic batch with an invalid operation applies nothing", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockUserServ := NewMockUserServiceInterface(ctrl)
+		service := NewService(NewMockTaskStoreInterface(ctrl), mockUserServ)
+
+		mockUserServ.EXPECT().Lock(gomock.Any(), 9).Return(user.User{}, user.ErrNotFound)
+
+		results, err := service.Bulk(ctx, []task.BulkOp{
+			{Op: task.BulkComplete, ID: 1},
+			{Op: task.BulkComplete, ID: 1},
+			{Op: task.BulkReassign, ID: 2, Userid: 9},
+			{Op: "archive", ID: 3},
+		}, true)
+
+		assert.ErrorIs(t, err, task.ErrBulkFailed)
+		assert.Equal(t, task.BulkRolledBack, results[0].Status)
+		assert.Equal(t, task.ErrDuplicateID.Error(), results[1].Error)
+		assert.Equal(t, task.BulkFailed, results[2].Status)
+		assert.Equal(t, task.ErrUnknownOp.Error(), results[3].Error)
+	})
+
+	t.Run("Assignee lookup error aborts the batch", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockUserServ := NewMockUserServiceInterface(ctrl)
+		service := NewService(NewMockTaskStoreInterface(ctrl), mockUserServ)
+
+		// A failing database is not a missing user, so nothing is reported per operation
+		mockUserServ.EXPECT().Lock(gomock.Any(), 2).Return(user.User{}, sql.ErrConnDone)
+
+		results, err := service.Bulk(ctx, []task.BulkOp{{Op: task.BulkCreate, Desc: "New", Userid: 2}}, false)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+		assert.Nil(t, results)
+	})
+
+	t.Run("Best effort applies the valid operations and audits them", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockStore := NewMockTaskStoreInterface(ctrl)
+		mockUserServ := NewMockUserServiceInterface(ctrl)
+		mockAudit := NewMockAuditServiceInterface(ctrl)
+
+		service := NewService(mockStore, mockUserServ)
+		service.SetAudit(mockAudit)
+
+		created := task.Task{ID: 7, Desc: "New", Userid: 2}
+		open := task.Task{ID: 4, Desc: "Open", Userid: 2}
+		done := open
+		done.Status = true
+
+		mockUserServ.EXPECT().Lock(gomock.Any(), 2).Return(user.User{ID: 2}, nil)
+		mockStore.EXPECT().BulkTask(gomock.Any(), []task.BulkOp{
+			{Op: task.BulkCreate, Desc: "New", Userid: 2},
+			{Op: task.BulkCreate, Desc: "Again", Userid: 2},
+			{Op: task.BulkComplete, ID: 4},
+		}, false).Return([]task.BulkResult{
+			{Index: 0, Op: task.BulkCreate, ID: 7, Status: task.BulkOK, Task: &created},
+			{Index: 1, Op: task.BulkCreate, ID: 8, Status: task.BulkOK, Task: &task.Task{ID: 8, Desc: "Again", Userid: 2}},
+			{Index: 2, Op: task.BulkComplete, ID: 4, Status: task.BulkOK, Task: &done, Before: &open},
+		}, nil)
+		mockAudit.EXPECT().Record(gomock.Any(), audit.ActionCreate, audit.EntityTask, 7, nil, created).Return(nil)
+		mockAudit.EXPECT().Record(gomock.Any(), audit.ActionCreate, audit.EntityTask, 8, nil, gomock.Any()).Return(nil)
+		mockAudit.EXPECT().Record(gomock.Any(), audit.ActionComplete, audit.EntityTask, 4, open, done).Return(nil)
+
+		results, err := service.Bulk(ctx, []task.BulkOp{
+			{Op: task.BulkCreate, Desc: "New", Userid: 2},
+			{Op: task.BulkUpdate, ID: 3},
+			{Op: task.BulkCreate, Desc: "Again", Userid: 2},
+			{Op: task.BulkComplete, ID: 4},
+		}, false)
+
+		assert.NoError(t, err)
+		assert.Equal(t, task.BulkFailed, results[1].Status)
+		assert.Equal(t, 2, results[2].Index)
+		assert.Equal(t, 3, results[3].Index)
+		assert.Equal(t, task.BulkOK, results[3].Status)
+	})
+}
+
+func Test_Quotas(t *testing.T) {
+	ctx := context.Background()
+	quotas := task.Quotas{Default: 2, Workspaces: map[int]int{9: 0}}
+
+	t.Run("Create within the quota", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockStore := NewMockTaskStoreInterface(ctrl)
+		mockUserServ := NewMockUserServiceInterface(ctrl)
+		service := NewService(mockStore, mockUserServ)
+		service.SetQuotas(quotas)
+
+		in := task.Task{Desc: "Plan", Userid: 1, WorkspaceID: 5}
+		mockUserServ.EXPECT().Lock(gomock.Any(), 1).Return(user.User{ID: 1}, nil)
+		mockStore.EXPECT().CountByWorkspaceTask(gomock.Any(), 5).Return(1, nil)
+		mockStore.EXPECT().CreateTask(gomock.Any(), in).Return(task.Task{ID: 3, Desc: "Plan", Userid: 1, WorkspaceID: 5}, nil)
+
+		_, err := service.Create(ctx, in)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Create over the quota", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockStore := NewMockTaskStoreInterface(ctrl)
+		mockUserServ := NewMockUserServiceInterface(ctrl)
+		service := NewService(mockStore, mockUserServ)
+		service.SetQuotas(quotas)
+
+		mockUserServ.EXPECT().Lock(gomock.Any(), 1).Return(user.User{ID: 1}, nil)
+		mockStore.EXPECT().CountByWorkspaceTask(gomock.Any(), 5).Return(2, nil)
+
+		_, err := service.Create(ctx, task.Task{Desc: "Plan", Userid: 1, WorkspaceID: 5})
+		assert.ErrorIs(t, err, task.ErrQuotaExceeded)
+	})
+
+	t.Run("Unlimited workspaces and tasks outside any are not counted", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockStore := NewMockTaskStoreInterface(ctrl)
+		mockUserServ := NewMockUserServiceInterface(ctrl)
+		service := NewService(mockStore, mockUserServ)
+		service.SetQuotas(quotas)
+
+		for _, ws := range []int{0, 9} {
+			in := task.Task{Desc: "Plan", Userid: 1, WorkspaceID: ws}
+			mockUserServ.EXPECT().Lock(gomock.Any(), 1).Return(user.User{ID: 1}, nil)
+			mockStore.EXPECT().CreateTask(gomock.Any(), in).Return(in, nil)
+
+			_, err := service.Create(ctx, in)
+			assert.NoError(t, err)
+		}
+	})
+
+	t.Run("Bulk fails the creations past the quota", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockStore := NewMockTaskStoreInterface(ctrl)
+		mockUserServ := NewMockUserServiceInterface(ctrl)
+		service := NewService(mockStore, mockUserServ)
+		service.SetQuotas(quotas)
+
+		ops := []task.BulkOp{
+			{Op: task.BulkCreate, Desc: "A", Userid: 1, WorkspaceID: 5},
+			{Op: task.BulkCreate, Desc: "B", Userid: 1, WorkspaceID: 5},
+			{Op: task.BulkCreate, Desc: "C", Userid: 1, WorkspaceID: 6},
+		}
+
+		mockUserServ.EXPECT().Lock(gomock.Any(), 1).Return(user.User{ID: 1}, nil)
+		mockStore.EXPECT().CountByWorkspaceTask(gomock.Any(), 5).Return(1, nil)
+		mockStore.EXPECT().CountByWorkspaceTask(gomock.Any(), 6).Return(0, nil)
+		mockStore.EXPECT().BulkTask(gomock.Any(), []task.BulkOp{ops[0], ops[2]}, false).Return([]task.BulkResult{
+			{Index: 0, Op: task.BulkCreate, ID: 7, Status: task.BulkOK, Task: &task.Task{ID: 7}},
+			{Index: 1, Op: task.BulkCreate, ID: 8, Status: task.BulkOK, Task: &task.Task{ID: 8}},
+		}, nil)
+
+		results, err := service.Bulk(ctx, ops, false)
+		assert.NoError(t, err)
+		assert.Equal(t, task.BulkOK, results[0].Status)
+		assert.Equal(t, task.BulkFailed, results[1].Status)
+		assert.Equal(t, task.ErrQuotaExceeded.Error(), results[1].Error)
+		assert.Equal(t, task.BulkOK, results[2].Status)
+	})
+
+	t.Run("Bulk count error aborts the batch", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockStore := NewMockTaskStoreInterface(ctrl)
+		mockUserServ := NewMockUserServiceInterface(ctrl)
+		service := NewService(mockStore, mockUserServ)
+		service.SetQuotas(quotas)
+
+		mockUserServ.EXPECT().Lock(gomock.Any(), 1).Return(user.User{ID: 1}, nil)
+		mockStore.EXPECT().CountByWorkspaceTask(gomock.Any(), 5).Return(0, sql.ErrConnDone)
+
+		_, err := service.Bulk(ctx, []task.BulkOp{{Op: task.BulkCreate, Desc: "A", Userid: 1, WorkspaceID: 5}}, false)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+}
+
+// unitKey marks the context a unit of work hands to the function it runs
+type unitKey struct{}
+
+func Test_UnitOfWork(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Create checks the assignee, inserts and audits in one unit", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockStore := NewMockTaskStoreInterface(ctrl)
+		mockUserServ := NewMockUserServiceInterface(ctrl)
+		mockUnit := NewMockUnitOfWork(ctrl)
+		mockAudit := NewMockAuditServiceInterface(ctrl)
+
+		service := NewService(mockStore, mockUserServ)
+		service.SetUnitOfWork(mockUnit)
+		service.SetAudit(mockAudit)
+
+		in := task.Task{Desc: "Plan", Userid: 1}
+		out := task.Task{ID: 4, Desc: "Plan", Userid: 1}
+		unitCtx := context.WithValue(ctx, unitKey{}, "unit")
+
+		gomock.InOrder(
+			mockUnit.EXPECT().WithTx(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, fn func(context.Context) error) error {
+				return fn(unitCtx)
+			}),
+			mockUserServ.EXPECT().Lock(unitCtx, 1).Return(user.User{ID: 1}, nil),
+			mockStore.EXPECT().CreateTask(unitCtx, in).Return(out, nil),
+			mockAudit.EXPECT().Record(unitCtx, audit.ActionCreate, audit.EntityTask, 4, nil, out).Return(nil),
+		)
+
+		created, err := service.Create(ctx, in)
+		assert.NoError(t, err)
+		assert.Equal(t, 4, created.ID)
+	})
+
+	t.Run("A failed unit creates nothing and audits nothing", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockUnit := NewMockUnitOfWork(ctrl)
+
+		service := NewService(NewMockTaskStoreInterface(ctrl), NewMockUserServiceInterface(ctrl))
+		service.SetAudit(NewMockAuditServiceInterface(ctrl))
+		service.SetUnitOfWork(mockUnit)
+
+		mockUnit.EXPECT().WithTx(gomock.Any(), gomock.Any()).Return(errors.New("deadlock"))
+
+		_, err := service.Create(ctx, task.Task{Desc: "Plan", Userid: 1})
+		assert.EqualError(t, err, "deadlock")
+	})
+
+	t.Run("Revert checks the former assignee and reverts in one unit", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockStore := NewMockTaskStoreInterface(ctrl)
+		mockUserServ := NewMockUserServiceInterface(ctrl)
+		mockUnit := NewMockUnitOfWork(ctrl)
+
+		service := NewService(mockStore, mockUserServ)
+		service.SetUnitOfWork(mockUnit)
+
+		draft := task.Task{ID: 1, Desc: "Draft", Userid: 2}
+		unitCtx := context.WithValue(ctx, unitKey{}, "unit")
+
+		mockStore.EXPECT().GetRevisionTask(gomock.Any(), 1, 1).Return(task.Revision{TaskID: 1, Number: 1, Task: draft}, nil)
+		mockStore.EXPECT().GetByIDTask(gomock.Any(), 1).Return(task.Task{ID: 1, Desc: "Final", Userid: 3}, nil)
+		mockUnit.EXPECT().WithTx(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, fn func(context.Context) error) error {
+			return fn(unitCtx)
+		})
+		mockUserServ.EXPECT().Lock(unitCtx, 2).Return(user.User{ID: 2}, nil)
+		mockStore.EXPECT().RevertTask(unitCtx, 1, draft).Return(nil)
+
+		_, err := service.Revert(ctx, 1, 1)
+		assert.NoError(t, err)
+	})
+}
@@ -1,10 +1,39 @@
 package user
 
-import "Task_Manager/model/user"
+import (
+	"Task_Manager/model/user"
+	"context"
+	"time"
+)
 
 type UserStoreInterface interface {
-	CreateUser(u user.User) (user.User, error)
-	GetByIDUser(id int) (user.User, error)
-	DeleteUser(id int) error
-	GetAllUser() ([]user.User, error)
+	CreateUser(ctx context.Context, u user.User) (user.User, error)
+	GetByIDUser(ctx context.Context, id int) (user.User, error)
+	GetByIDsUser(ctx context.Context, ids []int) ([]user.User, error)
+	LockUser(ctx context.Context, id int) (user.User, error)
+	GetByEmailUser(ctx context.Context, email string) (user.User, error)
+	UpdateUser(ctx context.Context, u user.User) error
+	SaveEmailChangeUser(ctx context.Context, c user.EmailChange, tokenHash string) error
+	ConfirmEmailChangeUser(ctx context.Context, id int, tokenHash string, now time.Time) (user.User, string, error)
+	DeleteWithTasksUser(ctx context.Context, id int, opts user.DeleteOptions) ([]int, error)
+	GetTaskIDsUser(ctx context.Context, id int) (live, trashed []int, err error)
+	UnassignTasksUser(ctx context.Context, id int) ([]int, error)
+	GetAllUser(ctx context.Context) ([]user.User, error)
+	GetTrashUser(ctx context.Context) ([]user.Trashed, error)
+	RestoreUser(ctx context.Context, id int) error
+	PurgeUser(ctx context.Context, before time.Time) ([]int, error)
+}
+
+// TaskServiceInterface records the revisions of the tasks changed along with a user
+type TaskServiceInterface interface {
+	Snapshot(ctx context.Context, ids ...int) error
+}
+
+// UnitOfWork runs fn with the store calls it makes in one transaction
+type UnitOfWork interface {
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type AuditServiceInterface interface {
+	Record(ctx context.Context, action, entity string, entityID int, before, after any) error
 }
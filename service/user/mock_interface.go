@@ -11,7 +11,9 @@ package user
 
 import (
 	user "Task_Manager/model/user"
+	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "go.uber.org/mock/gomock"
 )
@@ -40,62 +42,345 @@ func (m *MockUserStoreInterface) EXPECT() *MockUserStoreInterfaceMockRecorder {
 	return m.recorder
 }
 
+// ConfirmEmailChangeUser mocks base method.
+func (m *MockUserStoreInterface) ConfirmEmailChangeUser(ctx context.Context, id int, tokenHash string, now time.Time) (user.User, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmEmailChangeUser", ctx, id, tokenHash, now)
+	ret0, _ := ret[0].(user.User)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ConfirmEmailChangeUser indicates an expected call of ConfirmEmailChangeUser.
+func (mr *MockUserStoreInterfaceMockRecorder) ConfirmEmailChangeUser(ctx, id, tokenHash, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmEmailChangeUser", reflect.TypeOf((*MockUserStoreInterface)(nil).ConfirmEmailChangeUser), ctx, id, tokenHash, now)
+}
+
 // CreateUser mocks base method.
-func (m *MockUserStoreInterface) CreateUser(u user.User) (user.User, error) {
+func (m *MockUserStoreInterface) CreateUser(ctx context.Context, u user.User) (user.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateUser", u)
+	ret := m.ctrl.Call(m, "CreateUser", ctx, u)
 	ret0, _ := ret[0].(user.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateUser indicates an expected call of CreateUser.
-func (mr *MockUserStoreInterfaceMockRecorder) CreateUser(u any) *gomock.Call {
+func (mr *MockUserStoreInterfaceMockRecorder) CreateUser(ctx, u any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockUserStoreInterface)(nil).CreateUser), u)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockUserStoreInterface)(nil).CreateUser), ctx, u)
 }
 
-// DeleteUser mocks base method.
-func (m *MockUserStoreInterface) DeleteUser(id int) error {
+// DeleteWithTasksUser mocks base method.
+func (m *MockUserStoreInterface) DeleteWithTasksUser(ctx context.Context, id int, opts user.DeleteOptions) ([]int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteUser", id)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "DeleteWithTasksUser", ctx, id, opts)
+	ret0, _ := ret[0].([]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-
-// DeleteUser indicates an expected call of DeleteUser.
-func (mr *MockUserStoreInterfaceMockRecorder) DeleteUser(id any) *gomock.Call {
+// DeleteWithTasksUser indicates an expected call of DeleteWithTasksUser.
+func (mr *MockUserStoreInterfaceMockRecorder) DeleteWithTasksUser(ctx, id, opts any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockUserStoreInterface)(nil).DeleteUser), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWithTasksUser", reflect.TypeOf((*MockUserStoreInterface)(nil).DeleteWithTasksUser), ctx, id, opts)
 }
 
 // GetAllUser mocks base method.
-func (m *MockUserStoreInterface) GetAllUser() ([]user.User, error) {
+func (m *MockUserStoreInterface) GetAllUser(ctx context.Context) ([]user.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAllUser")
+	ret := m.ctrl.Call(m, "GetAllUser", ctx)
 	ret0, _ := ret[0].([]user.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetAllUser indicates an expected call of GetAllUser.
-func (mr *MockUserStoreInterfaceMockRecorder) GetAllUser() *gomock.Call {
+func (mr *MockUserStoreInterfaceMockRecorder) GetAllUser(ctx any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllUser", reflect.TypeOf((*MockUserStoreInterface)(nil).GetAllUser))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllUser", reflect.TypeOf((*MockUserStoreInterface)(nil).GetAllUser), ctx)
+}
+
+// GetByEmailUser mocks base method.
+func (m *MockUserStoreInterface) GetByEmailUser(ctx context.Context, email string) (user.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByEmailUser", ctx, email)
+	ret0, _ := ret[0].(user.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByEmailUser indicates an expected call of GetByEmailUser.
+func (mr *MockUserStoreInterfaceMockRecorder) GetByEmailUser(ctx, email any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByEmailUser", reflect.TypeOf((*MockUserStoreInterface)(nil).GetByEmailUser), ctx, email)
 }
 
 // GetByIDUser mocks base method.
-func (m *MockUserStoreInterface) GetByIDUser(id int) (user.User, error) {
+func (m *MockUserStoreInterface) GetByIDUser(ctx context.Context, id int) (user.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetByIDUser", id)
+	ret := m.ctrl.Call(m, "GetByIDUser", ctx, id)
 	ret0, _ := ret[0].(user.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetByIDUser indicates an expected call of GetByIDUser.
-func (mr *MockUserStoreInterfaceMockRecorder) GetByIDUser(id any) *gomock.Call {
+func (mr *MockUserStoreInterfaceMockRecorder) GetByIDUser(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByIDUser", reflect.TypeOf((*MockUserStoreInterface)(nil).GetByIDUser), ctx, id)
+}
+
+// GetByIDsUser mocks base method.
+func (m *MockUserStoreInterface) GetByIDsUser(ctx context.Context, ids []int) ([]user.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByIDsUser", ctx, ids)
+	ret0, _ := ret[0].([]user.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByIDsUser indicates an expected call of GetByIDsUser.
+func (mr *MockUserStoreInterfaceMockRecorder) GetByIDsUser(ctx, ids any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByIDsUser", reflect.TypeOf((*MockUserStoreInterface)(nil).GetByIDsUser), ctx, ids)
+}
+
+// GetTaskIDsUser mocks base method.
+func (m *MockUserStoreInterface) GetTaskIDsUser(ctx context.Context, id int) ([]int, []int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTaskIDsUser", ctx, id)
+	ret0, _ := ret[0].([]int)
+	ret1, _ := ret[1].([]int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetTaskIDsUser indicates an expected call of GetTaskIDsUser.
+func (mr *MockUserStoreInterfaceMockRecorder) GetTaskIDsUser(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTaskIDsUser", reflect.TypeOf((*MockUserStoreInterface)(nil).GetTaskIDsUser), ctx, id)
+}
+
+// GetTrashUser mocks base method.
+func (m *MockUserStoreInterface) GetTrashUser(ctx context.Context) ([]user.Trashed, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTrashUser", ctx)
+	ret0, _ := ret[0].([]user.Trashed)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTrashUser indicates an expected call of GetTrashUser.
+func (mr *MockUserStoreInterfaceMockRecorder) GetTrashUser(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTrashUser", reflect.TypeOf((*MockUserStoreInterface)(nil).GetTrashUser), ctx)
+}
+
+// LockUser mocks base method.
+func (m *MockUserStoreInterface) LockUser(ctx context.Context, id int) (user.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockUser", ctx, id)
+	ret0, _ := ret[0].(user.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LockUser indicates an expected call of LockUser.
+func (mr *MockUserStoreInterfaceMockRecorder) LockUser(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockUser", reflect.TypeOf((*MockUserStoreInterface)(nil).LockUser), ctx, id)
+}
+
+// PurgeUser mocks base method.
+func (m *MockUserStoreInterface) PurgeUser(ctx context.Context, before time.Time) ([]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeUser", ctx, before)
+	ret0, _ := ret[0].([]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeUser indicates an expected call of PurgeUser.
+func (mr *MockUserStoreInterfaceMockRecorder) PurgeUser(ctx, before any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeUser", reflect.TypeOf((*MockUserStoreInterface)(nil).PurgeUser), ctx, before)
+}
+
+// RestoreUser mocks base method.
+func (m *MockUserStoreInterface) RestoreUser(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreUser", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RestoreUser indicates an expected call of RestoreUser.
+func (mr *MockUserStoreInterfaceMockRecorder) RestoreUser(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreUser", reflect.TypeOf((*MockUserStoreInterface)(nil).RestoreUser), ctx, id)
+}
+
+// SaveEmailChangeUser mocks base method.
+func (m *MockUserStoreInterface) SaveEmailChangeUser(ctx context.Context, c user.EmailChange, tokenHash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveEmailChangeUser", ctx, c, tokenHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveEmailChangeUser indicates an expected call of SaveEmailChangeUser.
+func (mr *MockUserStoreInterfaceMockRecorder) SaveEmailChangeUser(ctx, c, tokenHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveEmailChangeUser", reflect.TypeOf((*MockUserStoreInterface)(nil).SaveEmailChangeUser), ctx, c, tokenHash)
+}
+
+// UnassignTasksUser mocks base method.
+func (m *MockUserStoreInterface) UnassignTasksUser(ctx context.Context, id int) ([]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnassignTasksUser", ctx, id)
+	ret0, _ := ret[0].([]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UnassignTasksUser indicates an expected call of UnassignTasksUser.
+func (mr *MockUserStoreInterfaceMockRecorder) UnassignTasksUser(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnassignTasksUser", reflect.TypeOf((*MockUserStoreInterface)(nil).UnassignTasksUser), ctx, id)
+}
+
+// UpdateUser mocks base method.
+func (m *MockUserStoreInterface) UpdateUser(ctx context.Context, u user.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUser", ctx, u)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateUser indicates an expected call of UpdateUser.
+func (mr *MockUserStoreInterfaceMockRecorder) UpdateUser(ctx, u any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUser", reflect.TypeOf((*MockUserStoreInterface)(nil).UpdateUser), ctx, u)
+}
+
+// MockTaskServiceInterface is a mock of TaskServiceInterface interface.
+type MockTaskServiceInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockTaskServiceInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockTaskServiceInterfaceMockRecorder is the mock recorder for MockTaskServiceInterface.
+type MockTaskServiceInterfaceMockRecorder struct {
+	mock *MockTaskServiceInterface
+}
+
+// NewMockTaskServiceInterface creates a new mock instance.
+func NewMockTaskServiceInterface(ctrl *gomock.Controller) *MockTaskServiceInterface {
+	mock := &MockTaskServiceInterface{ctrl: ctrl}
+	mock.recorder = &MockTaskServiceInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTaskServiceInterface) EXPECT() *MockTaskServiceInterfaceMockRecorder {
+	return m.recorder
+}
+
+// Snapshot mocks base method.
+func (m *MockTaskServiceInterface) Snapshot(ctx context.Context, ids ...int) error {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx}
+	for _, a := range ids {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Snapshot", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Snapshot indicates an expected call of Snapshot.
+func (mr *MockTaskServiceInterfaceMockRecorder) Snapshot(ctx any, ids ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx}, ids...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Snapshot", reflect.TypeOf((*MockTaskServiceInterface)(nil).Snapshot), varargs...)
+}
+
+// MockUnitOfWork is a mock of UnitOfWork interface.
+type MockUnitOfWork struct {
+	ctrl     *gomock.Controller
+	recorder *MockUnitOfWorkMockRecorder
+	isgomock struct{}
+}
+
+// MockUnitOfWorkMockRecorder is the mock recorder for MockUnitOfWork.
+type MockUnitOfWorkMockRecorder struct {
+	mock *MockUnitOfWork
+}
+
+// NewMockUnitOfWork creates a new mock instance.
+func NewMockUnitOfWork(ctrl *gomock.Controller) *MockUnitOfWork {
+	mock := &MockUnitOfWork{ctrl: ctrl}
+	mock.recorder = &MockUnitOfWorkMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUnitOfWork) EXPECT() *MockUnitOfWorkMockRecorder {
+	return m.recorder
+}
+
+// WithTx mocks base method.
+func (m *MockUnitOfWork) WithTx(ctx context.Context, fn func(context.Context) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithTx", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WithTx indicates an expected call of WithTx.
+func (mr *MockUnitOfWorkMockRecorder) WithTx(ctx, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithTx", reflect.TypeOf((*MockUnitOfWork)(nil).WithTx), ctx, fn)
+}
+
+// MockAuditServiceInterface is a mock of AuditServiceInterface interface.
+type MockAuditServiceInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuditServiceInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockAuditServiceInterfaceMockRecorder is the mock recorder for MockAuditServiceInterface.
+type MockAuditServiceInterfaceMockRecorder struct {
+	mock *MockAuditServiceInterface
+}
+
+// NewMockAuditServiceInterface creates a new mock instance.
+func NewMockAuditServiceInterface(ctrl *gomock.Controller) *MockAuditServiceInterface {
+	mock := &MockAuditServiceInterface{ctrl: ctrl}
+	mock.recorder = &MockAuditServiceInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuditServiceInterface) EXPECT() *MockAuditServiceInterfaceMockRecorder {
+	return m.recorder
+}
+
+// Record mocks base method.
+func (m *MockAuditServiceInterface) Record(ctx context.Context, action, entity string, entityID int, before, after any) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Record", ctx, action, entity, entityID, before, after)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Record indicates an expected call of Record.
+func (mr *MockAuditServiceInterfaceMockRecorder) Record(ctx, action, entity, entityID, before, after any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByIDUser", reflect.TypeOf((*MockUserStoreInterface)(nil).GetByIDUser), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Record", reflect.TypeOf((*MockAuditServiceInterface)(nil).Record), ctx, action, entity, entityID, before, after)
 }
@@ -1,34 +1,481 @@
 package user
 
 import (
+	"Task_Manager/auth"
+	"Task_Manager/model/audit"
 	"Task_Manager/model/user"
+	"Task_Manager/tracing"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"time"
 )
 
+// tracer records a span for every service method
+var tracer = tracing.Tracer("service/user")
+
 type UserService struct {
-	store UserStoreInterface
+	store          UserStoreInterface
+	auditref       AuditServiceInterface
+	taskServiceref TaskServiceInterface
+	emailHooks     []func(u user.User, c user.EmailChange, token string)
+	unit           UnitOfWork
 }
 
 func NewUserService(store UserStoreInterface) *UserService {
-	return &UserService{store: store}
+	return &UserService{store: store, unit: noUnit{}}
+}
+
+// noUnit runs functions without a transaction, until SetUnitOfWork provides one
+type noUnit struct{}
+
+func (noUnit) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// SetUnitOfWork makes every mutation run in one transaction with its audit entries and, for deletions and
+// purges, the changes to the tasks of the users
+func (s *UserService) SetUnitOfWork(u UnitOfWork) {
+	s.unit = u
+}
+
+// SetTasks records revisions of the tasks changed by the deletion or purge of their user
+func (s *UserService) SetTasks(ts TaskServiceInterface) {
+	s.taskServiceref = ts
 }
 
-func (s *UserService) Create(u user.User) (user.User, error) {
+func (s *UserService) Create(ctx context.Context, u user.User) (user.User, error) {
+	ctx, span := tracer.Start(ctx, "UserService.Create")
+	defer span.End()
+
 	if err := u.Validate(); err != nil {
 		return u, err
 	}
 
-	return s.store.CreateUser(u)
+	var created user.User
+
+	err := s.unit.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		if created, err = s.store.CreateUser(ctx, u); err != nil {
+			return err
+		}
+
+		return s.record(ctx, audit.ActionCreate, audit.EntityUser, created.ID, nil, created)
+	})
+
+	return created, err
+}
+
+func (s *UserService) Get(ctx context.Context, id int) (user.User, error) {
+	ctx, span := tracer.Start(ctx, "UserService.Get")
+	defer span.End()
+
+	u, err := s.store.GetByIDUser(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return u, user.ErrNotFound
+	}
+
+	return u, err
+}
+
+// GetMany returns the live users with the given IDs, read in one query and keyed by ID. IDs of users
+// that do not exist are missing from the map.
+func (s *UserService) GetMany(ctx context.Context, ids []int) (map[int]user.User, error) {
+	ctx, span := tracer.Start(ctx, "UserService.GetMany")
+	defer span.End()
+
+	users, err := s.store.GetByIDsUser(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[int]user.User, len(users))
+	for _, u := range users {
+		byID[u.ID] = u
+	}
+
+	return byID, nil
+}
+
+// Lock returns a live user and keeps it from being deleted until the unit of work in ctx ends. Outside a
+// unit of work it is the same as Get.
+func (s *UserService) Lock(ctx context.Context, id int) (user.User, error) {
+	ctx, span := tracer.Start(ctx, "UserService.Lock")
+	defer span.End()
+
+	u, err := s.store.LockUser(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return u, user.ErrNotFound
+	}
+
+	return u, err
+}
+
+// ByEmail returns the live user with the given email address, compared case-insensitively
+func (s *UserService) ByEmail(ctx context.Context, email string) (user.User, error) {
+	ctx, span := tracer.Start(ctx, "UserService.ByEmail")
+	defer span.End()
+
+	email, err := user.NormalizeEmail(email)
+	if err != nil {
+		return user.User{}, err
+	}
+
+	return s.store.GetByEmailUser(ctx, email)
+}
+
+// Update changes the name and profile of a user. Users may edit their own profile, admins any profile.
+func (s *UserService) Update(ctx context.Context, actor auth.Actor, id int, p user.Profile) (user.User, error) {
+	ctx, span := tracer.Start(ctx, "UserService.Update")
+	defer span.End()
+
+	if !canModify(actor, id) {
+		return user.User{}, user.ErrForbidden
+	}
+
+	before, err := s.Get(ctx, id)
+	if err != nil {
+		return before, err
+	}
+
+	after := p.Apply(before)
+	if err := after.Validate(); err != nil {
+		return before, err
+	}
+
+	err = s.unit.WithTx(ctx, func(ctx context.Context) error {
+		if err := s.store.UpdateUser(ctx, after); err != nil {
+			return err
+		}
+
+		return s.record(ctx, audit.ActionUpdate, audit.EntityUser, id, before, after)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return before, user.ErrNotFound
+	}
+
+	if err != nil {
+		return before, err
+	}
+
+	return after, nil
+}
+
+// RequestEmailChange starts changing a user's email address. The change is only applied by ConfirmEmailChange
+// with the token handed to the email hooks, which proves the user controls the new address.
+func (s *UserService) RequestEmailChange(ctx context.Context, actor auth.Actor, id int, email string) (user.EmailChange, error) {
+	ctx, span := tracer.Start(ctx, "UserService.RequestEmailChange")
+	defer span.End()
+
+	if !canModify(actor, id) {
+		return user.EmailChange{}, user.ErrForbidden
+	}
+
+	email, err := user.NormalizeEmail(email)
+	if err != nil {
+		return user.EmailChange{}, err
+	}
+
+	u, err := s.Get(ctx, id)
+	if err != nil {
+		return user.EmailChange{}, err
+	}
+
+	if email == u.Email {
+		return user.EmailChange{}, user.ErrEmailUnchanged
+	}
+
+	// Checked again by the unique index on confirmation, in case the address is taken in between
+	_, err = s.store.GetByEmailUser(ctx, email)
+	if err == nil {
+		return user.EmailChange{}, user.ErrEmailTaken
+	}
+
+	if !errors.Is(err, sql.ErrNoRows) {
+		return user.EmailChange{}, err
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return user.EmailChange{}, err
+	}
+
+	c := user.EmailChange{UserID: id, Email: email, ExpiresAt: time.Now().UTC().Add(user.EmailChangeTTL)}
+	if err := s.store.SaveEmailChangeUser(ctx, c, hashToken(token)); err != nil {
+		return c, err
+	}
+
+	for _, hook := range s.emailHooks {
+		hook(u, c, token)
+	}
+
+	return c, nil
+}
+
+// ConfirmEmailChange applies the pending email change of a user with the token sent to the new address
+func (s *UserService) ConfirmEmailChange(ctx context.Context, id int, token string) (user.User, error) {
+	ctx, span := tracer.Start(ctx, "UserService.ConfirmEmailChange")
+	defer span.End()
+
+	if token == "" {
+		return user.User{}, user.ErrEmailToken
+	}
+
+	var after user.User
+
+	err := s.unit.WithTx(ctx, func(ctx context.Context) error {
+		before, email, err := s.store.ConfirmEmailChangeUser(ctx, id, hashToken(token), time.Now().UTC())
+		if err != nil {
+			return err
+		}
+
+		after = before
+		after.Email = email
+
+		return s.record(ctx, audit.ActionUpdate, audit.EntityUser, id, before, after)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return user.User{}, user.ErrEmailToken
+	}
+
+	if err != nil {
+		return user.User{}, err
+	}
+
+	return after, nil
+}
+
+// OnEmailChange registers fn to deliver the confirmation token of a requested email change to the new address
+func (s *UserService) OnEmailChange(fn func(u user.User, c user.EmailChange, token string)) {
+	s.emailHooks = append(s.emailHooks, fn)
+}
+
+func canModify(actor auth.Actor, id int) bool {
+	return actor.Admin || (actor.UserID != 0 && actor.UserID == id)
+}
+
+// newToken returns 32 random bytes, hex encoded
+func newToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
 }
 
-func (s *UserService) Get(id int) (user.User, error) {
-	return s.store.GetByIDUser(id)
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
 
-func (s *UserService) Delete(id int) error {
-	return s.store.DeleteUser(id)
+// Delete moves a user without tasks to the trash
+func (s *UserService) Delete(ctx context.Context, id int) error {
+	ctx, span := tracer.Start(ctx, "UserService.Delete")
+	defer span.End()
+
+	_, err := s.DeleteWithPolicy(ctx, id, user.DeleteOptions{Policy: user.PolicyReject})
+	return err
 }
 
-func (s *UserService) All() ([]user.User, error) {
-	return s.store.GetAllUser()
+// DeleteWithPolicy moves a user to the trash and applies the policy to the user's tasks atomically.
+// An empty policy means PolicyReject. With DryRun set nothing is changed and the report lists the tasks
+// that would be affected.
+func (s *UserService) DeleteWithPolicy(ctx context.Context, id int, opts user.DeleteOptions) (user.DeleteReport, error) {
+	ctx, span := tracer.Start(ctx, "UserService.DeleteWithPolicy")
+	defer span.End()
+
+	if opts.Policy == "" {
+		opts.Policy = user.PolicyReject
+	}
+
+	report := user.DeleteReport{UserID: id, Policy: opts.Policy, ReassignTo: opts.ReassignTo, DryRun: opts.DryRun}
+
+	if err := opts.Validate(id); err != nil {
+		return report, err
+	}
+
+	before, err := s.Get(ctx, id)
+	if err != nil {
+		return report, err
+	}
+
+	if !opts.DryRun {
+		err := s.unit.WithTx(ctx, func(ctx context.Context) error {
+			tasks, err := s.store.DeleteWithTasksUser(ctx, id, opts)
+			report.Tasks = tasks
+
+			if err != nil {
+				return err
+			}
+
+			if err := s.snapshot(ctx, tasks); err != nil {
+				return err
+			}
+
+			return s.recordDelete(ctx, before, opts, tasks)
+		})
+
+		return report, err
+	}
+
+	if opts.Policy == user.PolicyReassign {
+		_, err := s.store.GetByIDUser(ctx, opts.ReassignTo)
+		if errors.Is(err, sql.ErrNoRows) {
+			return report, user.ErrReassignTarget
+		}
+
+		if err != nil {
+			return report, err
+		}
+	}
+
+	live, trashed, err := s.store.GetTaskIDsUser(ctx, id)
+	if err != nil {
+		return report, err
+	}
+
+	if opts.Policy == user.PolicyReject && len(live) > 0 {
+		report.Tasks = live
+		return report, user.ErrHasTasks
+	}
+
+	report.Tasks = opts.Policy.Affected(live, trashed)
+
+	return report, nil
+}
+
+func (s *UserService) All(ctx context.Context) ([]user.User, error) {
+	ctx, span := tracer.Start(ctx, "UserService.All")
+	defer span.End()
+
+	return s.store.GetAllUser(ctx)
+
+}
+
+// Trash lists the deleted users that can still be restored
+func (s *UserService) Trash(ctx context.Context) ([]user.Trashed, error) {
+	ctx, span := tracer.Start(ctx, "UserService.Trash")
+	defer span.End()
+
+	return s.store.GetTrashUser(ctx)
+}
+
+// Restore takes a user out of the trash
+func (s *UserService) Restore(ctx context.Context, id int) error {
+	ctx, span := tracer.Start(ctx, "UserService.Restore")
+	defer span.End()
+
+	err := s.unit.WithTx(ctx, func(ctx context.Context) error {
+		if err := s.store.RestoreUser(ctx, id); err != nil {
+			return err
+		}
+
+		return s.record(ctx, audit.ActionRestore, audit.EntityUser, id, nil, nil)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return user.ErrNotFound
+	}
+
+	return err
+}
+
+// Purge permanently deletes the users trashed before the given time. Their remaining tasks, which are
+// all in the trash, are unassigned, so restoring one never yields a task owned by a missing user.
+func (s *UserService) Purge(ctx context.Context, before time.Time) (int, error) {
+	ctx, span := tracer.Start(ctx, "UserService.Purge")
+	defer span.End()
+
+	var ids []int
+
+	err := s.unit.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		if ids, err = s.store.PurgeUser(ctx, before); err != nil {
+			return err
+		}
+
+		for _, id := range ids {
+			if err := s.record(ctx, audit.ActionPurge, audit.EntityUser, id, nil, nil); err != nil {
+				return err
+			}
+
+			tasks, err := s.store.UnassignTasksUser(ctx, id)
+			if err != nil {
+				return err
+			}
+
+			if err := s.snapshot(ctx, tasks); err != nil {
+				return err
+			}
+
+			for _, taskID := range tasks {
+				err := s.record(ctx, audit.ActionUnassign, audit.EntityTask, taskID, map[string]int{"userid": id}, map[string]int{"userid": 0})
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return len(ids), nil
+}
+
+// snapshot records revisions of the tasks changed along with a user
+func (s *UserService) snapshot(ctx context.Context, tasks []int) error {
+	if s.taskServiceref == nil || len(tasks) == 0 {
+		return nil
+	}
+
+	return s.taskServiceref.Snapshot(ctx, tasks...)
+}
+
+// SetAudit makes every mutation append an entry to the audit log
+func (s *UserService) SetAudit(a AuditServiceInterface) {
+	s.auditref = a
+}
+
+// recordDelete audits a user deletion together with what the policy did to each of the user's tasks
+func (s *UserService) recordDelete(ctx context.Context, u user.User, opts user.DeleteOptions, tasks []int) error {
+	if err := s.record(ctx, audit.ActionDelete, audit.EntityUser, u.ID, u, nil); err != nil {
+		return err
+	}
+
+	owner := map[string]int{"userid": u.ID}
+
+	for _, id := range tasks {
+		var err error
+
+		switch opts.Policy {
+		case user.PolicyReassign:
+			err = s.record(ctx, audit.ActionReassign, audit.EntityTask, id, owner, map[string]int{"userid": opts.ReassignTo})
+		case user.PolicyReject, user.PolicyUnassign:
+			err = s.record(ctx, audit.ActionUnassign, audit.EntityTask, id, owner, map[string]int{"userid": 0})
+		case user.PolicyCascade:
+			err = s.record(ctx, audit.ActionDelete, audit.EntityTask, id, owner, nil)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// record appends an audit entry for a mutation. It runs in the unit of work of the mutation, so a failed
+// append fails the mutation too and neither is committed without the other.
+func (s *UserService) record(ctx context.Context, action, entity string, id int, before, after any) error {
+	if s.auditref == nil {
+		return nil
+	}
 
+	return s.auditref.Record(ctx, action, entity, id, before, after)
 }
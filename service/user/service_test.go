@@ -1,12 +1,18 @@
 package user
 
 import (
+	"Task_Manager/auth"
+	"Task_Manager/model/audit"
+	"Task_Manager/model/errs"
 	_ "Task_Manager/model/task"
 	"Task_Manager/model/user"
+	"context"
+	"database/sql"
 	"errors"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
 	"testing"
+	"time"
 )
 
 func Test_CreateUser(t *testing.T) {
@@ -45,9 +51,9 @@ func Test_CreateUser(t *testing.T) {
 
 			mockstore := NewMockUserStoreInterface(ctrl)
 			service := NewUserService(mockstore)
-			mockstore.EXPECT().CreateUser(gomock.Any()).Return(tt.mockOutput, tt.mockError).AnyTimes()
+			mockstore.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Return(tt.mockOutput, tt.mockError).AnyTimes()
 
-			result, err := service.Create(tt.input)
+			result, err := service.Create(context.Background(), tt.input)
 
 			if tt.expErr {
 				assert.Error(t, err, tt.name)
@@ -67,7 +73,7 @@ func Test_GetUser(t *testing.T) {
 		mockErr    error
 		expErr     bool
 	}{
-		{"Valid Id", 1, user.User{1, "John", "mail"}, nil, false},
+		{"Valid Id", 1, user.User{ID: 1, Name: "John", Email: "mail"}, nil, false},
 		{"User not found", 2, user.User{}, errors.New("task not found"), true},
 	}
 	for _, tt := range tests {
@@ -76,8 +82,8 @@ func Test_GetUser(t *testing.T) {
 		mockstore := NewMockUserStoreInterface(ctrl)
 		service := NewUserService(mockstore)
 
-		mockstore.EXPECT().GetByIDUser(tt.id).Return(tt.mockOutput, tt.mockErr).AnyTimes()
-		result, err := service.Get(tt.id)
+		mockstore.EXPECT().GetByIDUser(gomock.Any(), tt.id).Return(tt.mockOutput, tt.mockErr).AnyTimes()
+		result, err := service.Get(context.Background(), tt.id)
 		if tt.expErr {
 			assert.Error(t, err, tt.name)
 		} else {
@@ -87,6 +93,50 @@ func Test_GetUser(t *testing.T) {
 	}
 }
 
+func Test_GetMany(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockstore := NewMockUserStoreInterface(ctrl)
+	service := NewUserService(mockstore)
+
+	mockstore.EXPECT().GetByIDsUser(gomock.Any(), []int{1, 2}).Return([]user.User{{ID: 2, Name: "Alice"}}, nil)
+
+	users, err := service.GetMany(context.Background(), []int{1, 2})
+	assert.NoError(t, err)
+	assert.Equal(t, map[int]user.User{2: {ID: 2, Name: "Alice"}}, users)
+
+	mockstore.EXPECT().GetByIDsUser(gomock.Any(), []int{3}).Return(nil, errors.New("db down"))
+
+	_, err = service.GetMany(context.Background(), []int{3})
+	assert.Error(t, err)
+}
+
+func Test_GetUserNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockstore := NewMockUserStoreInterface(ctrl)
+	service := NewUserService(mockstore)
+
+	mockstore.EXPECT().GetByIDUser(gomock.Any(), 2).Return(user.User{}, sql.ErrNoRows)
+
+	_, err := service.Get(context.Background(), 2)
+	assert.ErrorIs(t, err, user.ErrNotFound)
+}
+
+func Test_LockUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockstore := NewMockUserStoreInterface(ctrl)
+	service := NewUserService(mockstore)
+
+	mockstore.EXPECT().LockUser(gomock.Any(), 1).Return(user.User{ID: 1, Name: "John"}, nil)
+	mockstore.EXPECT().LockUser(gomock.Any(), 2).Return(user.User{}, sql.ErrNoRows)
+
+	u, err := service.Lock(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, u.ID)
+
+	_, err = service.Lock(context.Background(), 2)
+	assert.ErrorIs(t, err, user.ErrNotFound)
+}
+
 func Test_DeleteUser(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -111,8 +161,9 @@ func Test_DeleteUser(t *testing.T) {
 		defer ctrl.Finish()
 		mockstore := NewMockUserStoreInterface(ctrl)
 		service := NewUserService(mockstore)
-		mockstore.EXPECT().DeleteUser(tt.input).Return(tt.taskErr).AnyTimes()
-		err := service.Delete(tt.input)
+		mockstore.EXPECT().GetByIDUser(gomock.Any(), tt.input).Return(user.User{ID: tt.input}, nil)
+		mockstore.EXPECT().DeleteWithTasksUser(gomock.Any(), tt.input, user.DeleteOptions{Policy: user.PolicyReject}).Return(nil, tt.taskErr).AnyTimes()
+		err := service.Delete(context.Background(), tt.input)
 		if tt.expErr {
 			assert.Error(t, err, tt.name)
 		} else {
@@ -130,7 +181,7 @@ func Test_GetAllUsers(t *testing.T) {
 		mockErr    error
 		expErr     bool
 	}{
-		{"Data fetched", []user.User{{1, "John", "mail"}}, nil, false},
+		{"Data fetched", []user.User{{ID: 1, Name: "John", Email: "mail"}}, nil, false},
 		{"Unable to fetch", []user.User{}, errors.New("task not found"), true},
 	}
 
@@ -140,9 +191,9 @@ func Test_GetAllUsers(t *testing.T) {
 		mockstore := NewMockUserStoreInterface(ctrl)
 		service := NewUserService(mockstore)
 
-		mockstore.EXPECT().GetAllUser().Return(tt.mockOutput, tt.mockErr).AnyTimes()
+		mockstore.EXPECT().GetAllUser(gomock.Any()).Return(tt.mockOutput, tt.mockErr).AnyTimes()
 
-		res, err := service.All()
+		res, err := service.All(context.Background())
 		if tt.expErr {
 			assert.Error(t, err, tt.name)
 		} else {
@@ -151,3 +202,445 @@ func Test_GetAllUsers(t *testing.T) {
 		}
 	}
 }
+
+func Test_TrashRestorePurge(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockstore := NewMockUserStoreInterface(ctrl)
+	service := NewUserService(mockstore)
+
+	mockstore.EXPECT().GetTrashUser(gomock.Any()).Return([]user.Trashed{{User: user.User{ID: 1, Name: "John", Email: "mail"}}}, nil)
+
+	trash, err := service.Trash(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, trash, 1)
+
+	mockstore.EXPECT().RestoreUser(gomock.Any(), 1).Return(nil)
+	assert.NoError(t, service.Restore(context.Background(), 1))
+
+	before := time.Now()
+	mockstore.EXPECT().PurgeUser(gomock.Any(), before).Return([]int{3, 4}, nil)
+	mockstore.EXPECT().UnassignTasksUser(gomock.Any(), 3).Return(nil, nil)
+	mockstore.EXPECT().UnassignTasksUser(gomock.Any(), 4).Return(nil, nil)
+
+	n, err := service.Purge(context.Background(), before)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+}
+
+func Test_PurgeUnassignsTasks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockstore := NewMockUserStoreInterface(ctrl)
+	mockTasks := NewMockTaskServiceInterface(ctrl)
+	mockAudit := NewMockAuditServiceInterface(ctrl)
+
+	service := NewUserService(mockstore)
+	service.SetTasks(mockTasks)
+	service.SetAudit(mockAudit)
+
+	before := time.Now()
+
+	t.Run("Unassigned and audited", func(t *testing.T) {
+		// The tasks cascaded into the trash with the user would otherwise point at a missing user
+		mockstore.EXPECT().PurgeUser(gomock.Any(), before).Return([]int{3}, nil)
+		mockstore.EXPECT().UnassignTasksUser(gomock.Any(), 3).Return([]int{6, 7}, nil)
+		mockTasks.EXPECT().Snapshot(gomock.Any(), 6, 7).Return(nil)
+
+		gomock.InOrder(
+			mockAudit.EXPECT().Record(gomock.Any(), audit.ActionPurge, audit.EntityUser, 3, nil, nil).Return(nil),
+			mockAudit.EXPECT().Record(gomock.Any(), audit.ActionUnassign, audit.EntityTask, 6, map[string]int{"userid": 3}, map[string]int{"userid": 0}).Return(nil),
+			mockAudit.EXPECT().Record(gomock.Any(), audit.ActionUnassign, audit.EntityTask, 7, map[string]int{"userid": 3}, map[string]int{"userid": 0}).Return(nil),
+		)
+
+		n, err := service.Purge(context.Background(), before)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, n)
+	})
+
+	t.Run("Snapshot failure", func(t *testing.T) {
+		mockstore.EXPECT().PurgeUser(gomock.Any(), before).Return([]int{3}, nil)
+		mockAudit.EXPECT().Record(gomock.Any(), audit.ActionPurge, audit.EntityUser, 3, nil, nil).Return(nil)
+		mockstore.EXPECT().UnassignTasksUser(gomock.Any(), 3).Return([]int{6}, nil)
+		mockTasks.EXPECT().Snapshot(gomock.Any(), 6).Return(sql.ErrConnDone)
+
+		_, err := service.Purge(context.Background(), before)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+}
+
+func Test_DeleteWithPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		id       int
+		opts     user.DeleteOptions
+		mock     func(m *MockUserStoreInterface)
+		expTasks []int
+		expErr   error
+	}{
+		{
+			name: "Defaults to reject",
+			id:   1,
+			opts: user.DeleteOptions{},
+			mock: func(m *MockUserStoreInterface) {
+				m.EXPECT().GetByIDUser(gomock.Any(), 1).Return(user.User{ID: 1}, nil)
+				m.EXPECT().DeleteWithTasksUser(gomock.Any(), 1, user.DeleteOptions{Policy: user.PolicyReject}).Return(nil, nil)
+			},
+		},
+		{
+			name: "Cascade",
+			id:   1,
+			opts: user.DeleteOptions{Policy: user.PolicyCascade},
+			mock: func(m *MockUserStoreInterface) {
+				m.EXPECT().GetByIDUser(gomock.Any(), 1).Return(user.User{ID: 1}, nil)
+				m.EXPECT().DeleteWithTasksUser(gomock.Any(), 1, user.DeleteOptions{Policy: user.PolicyCascade}).Return([]int{3, 4}, nil)
+			},
+			expTasks: []int{3, 4},
+		},
+		{
+			name:   "Unknown policy",
+			id:     1,
+			opts:   user.DeleteOptions{Policy: "archive"},
+			mock:   func(m *MockUserStoreInterface) {},
+			expErr: user.ErrInvalidPolicy,
+		},
+		{
+			name:   "Reassign to self",
+			id:     1,
+			opts:   user.DeleteOptions{Policy: user.PolicyReassign, ReassignTo: 1},
+			mock:   func(m *MockUserStoreInterface) {},
+			expErr: user.ErrReassignTarget,
+		},
+		{
+			name: "Dry run reject with tasks",
+			id:   1,
+			opts: user.DeleteOptions{Policy: user.PolicyReject, DryRun: true},
+			mock: func(m *MockUserStoreInterface) {
+				m.EXPECT().GetByIDUser(gomock.Any(), 1).Return(user.User{ID: 1}, nil)
+				m.EXPECT().GetTaskIDsUser(gomock.Any(), 1).Return([]int{7}, []int{9}, nil)
+			},
+			expTasks: []int{7},
+			expErr:   user.ErrHasTasks,
+		},
+		{
+			name: "Dry run reject with trashed tasks",
+			id:   1,
+			opts: user.DeleteOptions{Policy: user.PolicyReject, DryRun: true},
+			mock: func(m *MockUserStoreInterface) {
+				m.EXPECT().GetByIDUser(gomock.Any(), 1).Return(user.User{ID: 1}, nil)
+				m.EXPECT().GetTaskIDsUser(gomock.Any(), 1).Return(nil, []int{9}, nil)
+			},
+			expTasks: []int{9},
+		},
+		{
+			name: "Dry run cascade",
+			id:   1,
+			opts: user.DeleteOptions{Policy: user.PolicyCascade, DryRun: true},
+			mock: func(m *MockUserStoreInterface) {
+				m.EXPECT().GetByIDUser(gomock.Any(), 1).Return(user.User{ID: 1}, nil)
+				m.EXPECT().GetTaskIDsUser(gomock.Any(), 1).Return([]int{7}, []int{9}, nil)
+			},
+			expTasks: []int{7},
+		},
+		{
+			name: "Dry run reassign",
+			id:   1,
+			opts: user.DeleteOptions{Policy: user.PolicyReassign, ReassignTo: 2, DryRun: true},
+			mock: func(m *MockUserStoreInterface) {
+				m.EXPECT().GetByIDUser(gomock.Any(), 1).Return(user.User{ID: 1}, nil)
+				m.EXPECT().GetByIDUser(gomock.Any(), 2).Return(user.User{ID: 2}, nil)
+				m.EXPECT().GetTaskIDsUser(gomock.Any(), 1).Return([]int{7}, []int{8}, nil)
+			},
+			expTasks: []int{7, 8},
+		},
+		{
+			name: "Dry run reassign to missing user",
+			id:   1,
+			opts: user.DeleteOptions{Policy: user.PolicyReassign, ReassignTo: 2, DryRun: true},
+			mock: func(m *MockUserStoreInterface) {
+				m.EXPECT().GetByIDUser(gomock.Any(), 1).Return(user.User{ID: 1}, nil)
+				m.EXPECT().GetByIDUser(gomock.Any(), 2).Return(user.User{}, sql.ErrNoRows)
+			},
+			expErr: user.ErrReassignTarget,
+		},
+		{
+			name: "Dry run missing user",
+			id:   5,
+			opts: user.DeleteOptions{Policy: user.PolicyUnassign, DryRun: true},
+			mock: func(m *MockUserStoreInterface) {
+				m.EXPECT().GetByIDUser(gomock.Any(), 5).Return(user.User{}, sql.ErrNoRows)
+			},
+			expErr: user.ErrNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockstore := NewMockUserStoreInterface(ctrl)
+			service := NewUserService(mockstore)
+			tt.mock(mockstore)
+
+			report, err := service.DeleteWithPolicy(context.Background(), tt.id, tt.opts)
+			if tt.expErr != nil {
+				assert.ErrorIs(t, err, tt.expErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.expTasks, report.Tasks)
+			assert.Equal(t, tt.opts.DryRun, report.DryRun)
+		})
+	}
+}
+
+func Test_DeleteSnapshotsTasks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockstore := NewMockUserStoreInterface(ctrl)
+	mockTasks := NewMockTaskServiceInterface(ctrl)
+
+	service := NewUserService(mockstore)
+	service.SetTasks(mockTasks)
+
+	opts := user.DeleteOptions{Policy: user.PolicyUnassign}
+
+	mockstore.EXPECT().GetByIDUser(gomock.Any(), 1).Return(user.User{ID: 1}, nil).Times(2)
+	mockstore.EXPECT().DeleteWithTasksUser(gomock.Any(), 1, opts).Return([]int{7, 8}, nil).Times(2)
+
+	mockTasks.EXPECT().Snapshot(gomock.Any(), 7, 8).Return(nil)
+
+	report, err := service.DeleteWithPolicy(context.Background(), 1, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{7, 8}, report.Tasks)
+
+	// Without the revisions the whole deletion fails, so the unit of work rolls it back
+	mockTasks.EXPECT().Snapshot(gomock.Any(), 7, 8).Return(sql.ErrConnDone)
+
+	_, err = service.DeleteWithPolicy(context.Background(), 1, opts)
+	assert.ErrorIs(t, err, sql.ErrConnDone)
+}
+
+func Test_AuditedDelete(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockstore := NewMockUserStoreInterface(ctrl)
+	mockAudit := NewMockAuditServiceInterface(ctrl)
+
+	service := NewUserService(mockstore)
+	service.SetAudit(mockAudit)
+
+	ctx := context.Background()
+	john := user.User{ID: 1, Name: "John", Email: "john@example.com"}
+	opts := user.DeleteOptions{Policy: user.PolicyReassign, ReassignTo: 2}
+
+	mockstore.EXPECT().GetByIDUser(gomock.Any(), 1).Return(john, nil)
+	mockstore.EXPECT().DeleteWithTasksUser(gomock.Any(), 1, opts).Return([]int{7, 8}, nil)
+
+	gomock.InOrder(
+		mockAudit.EXPECT().Record(gomock.Any(), audit.ActionDelete, audit.EntityUser, 1, john, nil).Return(nil),
+		mockAudit.EXPECT().Record(gomock.Any(), audit.ActionReassign, audit.EntityTask, 7, map[string]int{"userid": 1}, map[string]int{"userid": 2}).Return(nil),
+		mockAudit.EXPECT().Record(gomock.Any(), audit.ActionReassign, audit.EntityTask, 8, map[string]int{"userid": 1}, map[string]int{"userid": 2}).Return(nil),
+	)
+
+	_, err := service.DeleteWithPolicy(ctx, 1, opts)
+	assert.NoError(t, err)
+
+	// Entries are appended in the unit of work of the deletion, so a failed append fails the deletion too
+	mockstore.EXPECT().GetByIDUser(gomock.Any(), 1).Return(john, nil)
+	mockstore.EXPECT().DeleteWithTasksUser(gomock.Any(), 1, opts).Return([]int{7, 8}, nil)
+
+	gomock.InOrder(
+		mockAudit.EXPECT().Record(gomock.Any(), audit.ActionDelete, audit.EntityUser, 1, john, nil).Return(nil),
+		mockAudit.EXPECT().Record(gomock.Any(), audit.ActionReassign, audit.EntityTask, 7, map[string]int{"userid": 1}, map[string]int{"userid": 2}).
+			Return(errors.New("audit down")),
+	)
+
+	_, err = service.DeleteWithPolicy(ctx, 1, opts)
+	assert.EqualError(t, err, "audit down")
+
+	// Trashed tasks unassigned by a rejecting deletion are audited too
+	mockstore.EXPECT().GetByIDUser(gomock.Any(), 1).Return(john, nil)
+	mockstore.EXPECT().DeleteWithTasksUser(gomock.Any(), 1, user.DeleteOptions{Policy: user.PolicyReject}).Return([]int{9}, nil)
+
+	gomock.InOrder(
+		mockAudit.EXPECT().Record(gomock.Any(), audit.ActionDelete, audit.EntityUser, 1, john, nil).Return(nil),
+		mockAudit.EXPECT().Record(gomock.Any(), audit.ActionUnassign, audit.EntityTask, 9, map[string]int{"userid": 1}, map[string]int{"userid": 0}).Return(nil),
+	)
+
+	_, err = service.DeleteWithPolicy(ctx, 1, user.DeleteOptions{Policy: user.PolicyReject})
+	assert.NoError(t, err)
+
+	// Dry runs change nothing and are not audited
+	mockstore.EXPECT().GetByIDUser(gomock.Any(), 1).Return(john, nil)
+	mockstore.EXPECT().GetTaskIDsUser(gomock.Any(), 1).Return(nil, nil, nil)
+
+	_, err = service.DeleteWithPolicy(ctx, 1, user.DeleteOptions{Policy: user.PolicyCascade, DryRun: true})
+	assert.NoError(t, err)
+}
+
+func Test_CreateNormalizesEmail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockstore := NewMockUserStoreInterface(ctrl)
+	service := NewUserService(mockstore)
+
+	mockstore.EXPECT().CreateUser(gomock.Any(), user.User{Name: "John", Email: "john.doe@example.com"}).
+		Return(user.User{ID: 1, Name: "John", Email: "john.doe@example.com"}, nil)
+
+	_, err := service.Create(context.Background(), user.User{Name: "John", Email: "  John.Doe@Example.COM "})
+	assert.NoError(t, err)
+
+	for _, email := range []string{"john", "john@", "John <john@example.com>", "a@b@example.com"} {
+		_, err = service.Create(context.Background(), user.User{Name: "John", Email: email})
+
+		var verr *errs.Validation
+		assert.ErrorAs(t, err, &verr, email)
+	}
+
+	mockstore.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Return(user.User{}, user.ErrEmailTaken)
+
+	_, err = service.Create(context.Background(), user.User{Name: "John", Email: "taken@example.com"})
+	assert.ErrorIs(t, err, user.ErrEmailTaken)
+}
+
+func Test_Update(t *testing.T) {
+	john := user.User{ID: 1, Name: "John", Email: "john@example.com"}
+	name, tz, badTZ := "John Doe", "Europe/Berlin", "Mars/Olympus"
+
+	tests := []struct {
+		name     string
+		actor    auth.Actor
+		profile  user.Profile
+		mock     func(m *MockUserStoreInterface)
+		expErr   error
+		expEntry bool
+	}{
+		{
+			name:    "Own profile",
+			actor:   auth.Actor{UserID: 1},
+			profile: user.Profile{Name: &name, Timezone: &tz},
+			mock: func(m *MockUserStoreInterface) {
+				m.EXPECT().GetByIDUser(gomock.Any(), 1).Return(john, nil)
+				m.EXPECT().UpdateUser(gomock.Any(), user.User{ID: 1, Name: "John Doe", Email: "john@example.com", Timezone: "Europe/Berlin"}).Return(nil)
+			},
+			expEntry: true,
+		},
+		{
+			name:     "Admin",
+			actor:    auth.Actor{UserID: 9, Admin: true},
+			profile:  user.Profile{Name: &name},
+			expEntry: true,
+			mock: func(m *MockUserStoreInterface) {
+				m.EXPECT().GetByIDUser(gomock.Any(), 1).Return(john, nil)
+				m.EXPECT().UpdateUser(gomock.Any(), gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name:    "Someone else",
+			actor:   auth.Actor{UserID: 2},
+			profile: user.Profile{Name: &name},
+			mock:    func(m *MockUserStoreInterface) {},
+			expErr:  user.ErrForbidden,
+		},
+		{
+			name:    "Unknown time zone",
+			actor:   auth.Actor{UserID: 1},
+			profile: user.Profile{Timezone: &badTZ},
+			mock: func(m *MockUserStoreInterface) {
+				m.EXPECT().GetByIDUser(gomock.Any(), 1).Return(john, nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockstore := NewMockUserStoreInterface(ctrl)
+			mockAudit := NewMockAuditServiceInterface(ctrl)
+			service := NewUserService(mockstore)
+			service.SetAudit(mockAudit)
+			tt.mock(mockstore)
+
+			if tt.expEntry {
+				mockAudit.EXPECT().Record(gomock.Any(), audit.ActionUpdate, audit.EntityUser, 1, john, gomock.Any()).Return(nil)
+			}
+
+			_, err := service.Update(context.Background(), tt.actor, 1, tt.profile)
+
+			switch {
+			case tt.expErr != nil:
+				assert.ErrorIs(t, err, tt.expErr)
+			case tt.expEntry:
+				assert.NoError(t, err)
+			default:
+				var verr *errs.Validation
+				assert.ErrorAs(t, err, &verr)
+			}
+		})
+	}
+}
+
+func Test_EmailChange(t *testing.T) {
+	john := user.User{ID: 1, Name: "John", Email: "john@example.com"}
+	actor := auth.Actor{UserID: 1}
+
+	t.Run("Requested and confirmed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockstore := NewMockUserStoreInterface(ctrl)
+		service := NewUserService(mockstore)
+
+		var sent, saved string
+		service.OnEmailChange(func(u user.User, c user.EmailChange, token string) {
+			assert.Equal(t, "new@example.com", c.Email)
+			sent = token
+		})
+
+		mockstore.EXPECT().GetByIDUser(gomock.Any(), 1).Return(john, nil)
+		mockstore.EXPECT().GetByEmailUser(gomock.Any(), "new@example.com").Return(user.User{}, sql.ErrNoRows)
+		mockstore.EXPECT().SaveEmailChangeUser(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, c user.EmailChange, hash string) error {
+			saved = hash
+			return nil
+		})
+
+		c, err := service.RequestEmailChange(context.Background(), actor, 1, "New@Example.com")
+		assert.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(user.EmailChangeTTL), c.ExpiresAt, time.Minute)
+		assert.Len(t, sent, 64)
+		assert.NotEqual(t, sent, saved, "only the hash of the token is stored")
+
+		mockstore.EXPECT().ConfirmEmailChangeUser(gomock.Any(), 1, saved, gomock.Any()).Return(john, "new@example.com", nil)
+
+		u, err := service.ConfirmEmailChange(context.Background(), 1, sent)
+		assert.NoError(t, err)
+		assert.Equal(t, "new@example.com", u.Email)
+	})
+
+	t.Run("Rejected requests", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockstore := NewMockUserStoreInterface(ctrl)
+		service := NewUserService(mockstore)
+
+		_, err := service.RequestEmailChange(context.Background(), auth.Actor{UserID: 2}, 1, "new@example.com")
+		assert.ErrorIs(t, err, user.ErrForbidden)
+
+		mockstore.EXPECT().GetByIDUser(gomock.Any(), 1).Return(john, nil).Times(2)
+
+		_, err = service.RequestEmailChange(context.Background(), actor, 1, "JOHN@example.com")
+		assert.ErrorIs(t, err, user.ErrEmailUnchanged)
+
+		mockstore.EXPECT().GetByEmailUser(gomock.Any(), "alice@example.com").Return(user.User{ID: 2}, nil)
+
+		_, err = service.RequestEmailChange(context.Background(), actor, 1, "alice@example.com")
+		assert.ErrorIs(t, err, user.ErrEmailTaken)
+	})
+
+	t.Run("Invalid token", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockstore := NewMockUserStoreInterface(ctrl)
+		service := NewUserService(mockstore)
+
+		mockstore.EXPECT().ConfirmEmailChangeUser(gomock.Any(), 1, gomock.Any(), gomock.Any()).Return(user.User{}, "", sql.ErrNoRows)
+
+		_, err := service.ConfirmEmailChange(context.Background(), 1, "guess")
+		assert.ErrorIs(t, err, user.ErrEmailToken)
+
+		_, err = service.ConfirmEmailChange(context.Background(), 1, "")
+		assert.ErrorIs(t, err, user.ErrEmailToken)
+	})
+}
@@ -0,0 +1,125 @@
+package attachment
+
+import (
+	"Task_Manager/metrics"
+	"Task_Manager/model/attachment"
+	"context"
+	"database/sql"
+	"time"
+)
+
+// observe times every store method
+var observe = metrics.QueryTimer("attachment")
+
+const selectAttachment = "SELECT id, task_id, filename, content_type, size, checksum, storage_key, uploader_id, created_at FROM attachments"
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+// scanner is implemented by both *sql.Row and *sql.Rows
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAttachment(sc scanner) (attachment.Attachment, error) {
+	var (
+		a        attachment.Attachment
+		uploader sql.NullInt64
+	)
+
+	err := sc.Scan(&a.ID, &a.TaskID, &a.Filename, &a.ContentType, &a.Size, &a.Checksum, &a.StorageKey, &uploader, &a.CreatedAt)
+	a.UploaderID = int(uploader.Int64)
+
+	return a, err
+}
+
+// CreateAttachment records the metadata of an uploaded file
+func (s *Store) CreateAttachment(ctx context.Context, a attachment.Attachment) (attachment.Attachment, error) {
+	defer observe("CreateAttachment")()
+
+	a.CreatedAt = time.Now().UTC()
+
+	var uploader sql.NullInt64
+	if a.UploaderID != 0 {
+		uploader = sql.NullInt64{Int64: int64(a.UploaderID), Valid: true}
+	}
+
+	res, err := s.db.ExecContext(ctx, "INSERT INTO attachments (task_id, filename, content_type, size, checksum, storage_key, uploader_id, created_at) "+
+		"VALUES (?, ?, ?, ?, ?, ?, ?, ?)", a.TaskID, a.Filename, a.ContentType, a.Size, a.Checksum, a.StorageKey, uploader, a.CreatedAt)
+	if err != nil {
+		return a, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return a, err
+	}
+
+	a.ID = int(id)
+
+	return a, nil
+}
+
+// GetByIDAttachment fetches the metadata of a single attachment
+func (s *Store) GetByIDAttachment(ctx context.Context, id int) (attachment.Attachment, error) {
+	defer observe("GetByIDAttachment")()
+
+	return scanAttachment(s.db.QueryRowContext(ctx, selectAttachment+" WHERE id = ?", id))
+}
+
+// GetByTaskIDAttachment lists the attachments of a task, oldest first
+func (s *Store) GetByTaskIDAttachment(ctx context.Context, taskID int) ([]attachment.Attachment, error) {
+	defer observe("GetByTaskIDAttachment")()
+
+	rows, err := s.db.QueryContext(ctx, selectAttachment+" WHERE task_id = ? ORDER BY id", taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	var attachments []attachment.Attachment
+
+	for rows.Next() {
+		a, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		attachments = append(attachments, a)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return attachments, nil
+}
+
+// DeleteAttachment removes the metadata of an attachment
+func (s *Store) DeleteAttachment(ctx context.Context, id int) error {
+	defer observe("DeleteAttachment")()
+
+	res, err := s.db.ExecContext(ctx, "DELETE FROM attachments WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
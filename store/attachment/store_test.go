@@ -0,0 +1,149 @@
+package attachment
+
+import (
+	attachmentModel "Task_Manager/model/attachment"
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+var attachmentColumns = []string{"id", "task_id", "filename", "content_type", "size", "checksum", "storage_key", "uploader_id", "created_at"}
+
+func setup(t *testing.T) (*Store, sqlmock.Sqlmock, func()) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	cleanup := func() { _ = db.Close() }
+	return NewStore(db), mock, cleanup
+}
+
+func Test_CreateAttachment(t *testing.T) {
+	store, mock, cleanup := setup(t)
+	defer cleanup()
+
+	query := regexp.QuoteMeta("INSERT INTO attachments (task_id, filename, content_type, size, checksum, storage_key, uploader_id, created_at) " +
+		"VALUES (?, ?, ?, ?, ?, ?, ?, ?)")
+	a := attachmentModel.Attachment{TaskID: 1, Filename: "log.txt", ContentType: "text/plain", Size: 3, Checksum: "abc", StorageKey: "tasks/1/k"}
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectExec(query).
+			WithArgs(1, "log.txt", "text/plain", int64(3), "abc", "tasks/1/k", nil, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(4, 1))
+
+		created, err := store.CreateAttachment(context.Background(), a)
+		require.NoError(t, err)
+		require.Equal(t, 4, created.ID)
+	})
+
+	t.Run("With uploader", func(t *testing.T) {
+		withUploader := a
+		withUploader.UploaderID = 7
+
+		mock.ExpectExec(query).
+			WithArgs(1, "log.txt", "text/plain", int64(3), "abc", "tasks/1/k", 7, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(5, 1))
+
+		_, err := store.CreateAttachment(context.Background(), withUploader)
+		require.NoError(t, err)
+	})
+
+	t.Run("Exec Error", func(t *testing.T) {
+		mock.ExpectExec(query).WillReturnError(errors.New("insert failed"))
+
+		_, err := store.CreateAttachment(context.Background(), a)
+		require.EqualError(t, err, "insert failed")
+	})
+
+	t.Run("LastInsertId Error", func(t *testing.T) {
+		mock.ExpectExec(query).WillReturnResult(sqlmock.NewErrorResult(errors.New("lastInsertId failed")))
+
+		_, err := store.CreateAttachment(context.Background(), a)
+		require.EqualError(t, err, "lastInsertId failed")
+	})
+}
+
+func Test_GetByIDAttachment(t *testing.T) {
+	store, mock, cleanup := setup(t)
+	defer cleanup()
+
+	query := regexp.QuoteMeta(selectAttachment + " WHERE id = ?")
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectQuery(query).WithArgs(4).
+			WillReturnRows(sqlmock.NewRows(attachmentColumns).AddRow(4, 1, "a.png", "image/png", 10, "abc", "tasks/1/k", nil, time.Now()))
+
+		a, err := store.GetByIDAttachment(context.Background(), 4)
+		require.NoError(t, err)
+		require.Equal(t, "a.png", a.Filename)
+		require.Zero(t, a.UploaderID)
+	})
+
+	t.Run("Not Found", func(t *testing.T) {
+		mock.ExpectQuery(query).WithArgs(5).WillReturnError(sql.ErrNoRows)
+
+		_, err := store.GetByIDAttachment(context.Background(), 5)
+		require.ErrorIs(t, err, sql.ErrNoRows)
+	})
+}
+
+func Test_GetByTaskIDAttachment(t *testing.T) {
+	store, mock, cleanup := setup(t)
+	defer cleanup()
+
+	query := regexp.QuoteMeta(selectAttachment + " WHERE task_id = ? ORDER BY id")
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectQuery(query).WithArgs(1).
+			WillReturnRows(sqlmock.NewRows(attachmentColumns).
+				AddRow(4, 1, "a.png", "image/png", 10, "abc", "tasks/1/a", 2, time.Now()).
+				AddRow(5, 1, "b.txt", "text/plain", 3, "def", "tasks/1/b", nil, time.Now()))
+
+		list, err := store.GetByTaskIDAttachment(context.Background(), 1)
+		require.NoError(t, err)
+		require.Len(t, list, 2)
+		require.Equal(t, 2, list[0].UploaderID)
+	})
+
+	t.Run("Query Error", func(t *testing.T) {
+		mock.ExpectQuery(query).WithArgs(1).WillReturnError(sql.ErrConnDone)
+
+		_, err := store.GetByTaskIDAttachment(context.Background(), 1)
+		require.Error(t, err)
+	})
+
+	t.Run("Row Error", func(t *testing.T) {
+		rows := sqlmock.NewRows(attachmentColumns).AddRow(4, 1, "a.png", "image/png", 10, "abc", "tasks/1/a", 2, time.Now())
+		rows.RowError(0, errors.New("row error"))
+		mock.ExpectQuery(query).WithArgs(1).WillReturnRows(rows)
+
+		_, err := store.GetByTaskIDAttachment(context.Background(), 1)
+		require.Error(t, err)
+	})
+}
+
+func Test_DeleteAttachment(t *testing.T) {
+	store, mock, cleanup := setup(t)
+	defer cleanup()
+
+	query := regexp.QuoteMeta("DELETE FROM attachments WHERE id = ?")
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectExec(query).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+		require.NoError(t, store.DeleteAttachment(context.Background(), 1))
+	})
+
+	t.Run("No Rows Deleted", func(t *testing.T) {
+		mock.ExpectExec(query).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 0))
+		require.ErrorIs(t, store.DeleteAttachment(context.Background(), 2), sql.ErrNoRows)
+	})
+
+	t.Run("Exec Error", func(t *testing.T) {
+		mock.ExpectExec(query).WithArgs(3).WillReturnError(sql.ErrConnDone)
+		require.Error(t, store.DeleteAttachment(context.Background(), 3))
+	})
+}
@@ -0,0 +1,193 @@
+package audit
+
+import (
+	"Task_Manager/metrics"
+	"Task_Manager/model/audit"
+	"Task_Manager/store/uow"
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// observe times every store method
+var observe = metrics.QueryTimer("audit")
+
+const columns = "id, at, actor_id, action, entity, entity_id, changes, request_id, source_ip, prev_hash, hash"
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+// AppendAudit chains the entry onto the log and inserts it. It joins the unit of work of ctx, so the entry
+// commits or rolls back together with the mutation it records. The single audit_head row is locked until
+// the transaction ends, so concurrent appends are serialised and the chain never forks.
+func (s *Store) AppendAudit(ctx context.Context, e audit.Entry) (_ audit.Entry, err error) {
+	defer observe("AppendAudit")()
+
+	tx, err := uow.Begin(ctx, s.db)
+	if err != nil {
+		return e, err
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err = tx.QueryRowContext(ctx, "SELECT hash FROM audit_head WHERE id = 1 FOR UPDATE").Scan(&e.PrevHash); err != nil {
+		return e, err
+	}
+
+	// DATETIME(6) keeps microseconds; truncating first makes the hashed time equal the stored one
+	e.At = time.Now().UTC().Truncate(time.Microsecond)
+	e.Hash = e.ComputeHash()
+
+	res, err := tx.ExecContext(ctx, "INSERT INTO audit_log (at, actor_id, action, entity, entity_id, changes, request_id, source_ip, prev_hash, hash) "+
+		"VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		e.At, e.ActorID, e.Action, e.Entity, e.EntityID, nullable(e.Changes), e.RequestID, e.SourceIP, e.PrevHash, e.Hash)
+	if err != nil {
+		return e, err
+	}
+
+	if e.ID, err = res.LastInsertId(); err != nil {
+		return e, err
+	}
+
+	if _, err = tx.ExecContext(ctx, "UPDATE audit_head SET hash = ? WHERE id = 1", e.Hash); err != nil {
+		return e, err
+	}
+
+	return e, tx.Commit()
+}
+
+// AuditHead returns the hash of the last entry appended, empty while the log is empty
+func (s *Store) AuditHead(ctx context.Context) (string, error) {
+	defer observe("AuditHead")()
+
+	var hash string
+	err := s.db.QueryRowContext(ctx, "SELECT hash FROM audit_head WHERE id = 1").Scan(&hash)
+
+	return hash, err
+}
+
+// QueryAudit returns the entries matching the filter in ID order
+func (s *Store) QueryAudit(ctx context.Context, f audit.Filter) ([]audit.Entry, error) {
+	defer observe("QueryAudit")()
+
+	var entries []audit.Entry
+
+	err := s.EachAudit(ctx, f, func(e audit.Entry) error {
+		entries = append(entries, e)
+		return nil
+	})
+
+	return entries, err
+}
+
+// EachAudit streams the entries matching the filter in ID order to fn, stopping at the first error
+func (s *Store) EachAudit(ctx context.Context, f audit.Filter, fn func(audit.Entry) error) error {
+	defer observe("EachAudit")()
+
+	query, args := selectQuery(f)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	for rows.Next() {
+		var (
+			e       audit.Entry
+			changes []byte
+		)
+
+		if err := rows.Scan(&e.ID, &e.At, &e.ActorID, &e.Action, &e.Entity, &e.EntityID, &changes,
+			&e.RequestID, &e.SourceIP, &e.PrevHash, &e.Hash); err != nil {
+			return err
+		}
+
+		if len(changes) > 0 {
+			e.Changes = changes
+		}
+
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func selectQuery(f audit.Filter) (string, []any) {
+	var (
+		conds []string
+		args  []any
+	)
+
+	if f.ActorID != 0 {
+		conds = append(conds, "actor_id = ?")
+		args = append(args, f.ActorID)
+	}
+
+	if f.Action != "" {
+		conds = append(conds, "action = ?")
+		args = append(args, f.Action)
+	}
+
+	if f.Entity != "" {
+		conds = append(conds, "entity = ?")
+		args = append(args, f.Entity)
+	}
+
+	if f.EntityID != 0 {
+		conds = append(conds, "entity_id = ?")
+		args = append(args, f.EntityID)
+	}
+
+	if !f.Since.IsZero() {
+		conds = append(conds, "at >= ?")
+		args = append(args, f.Since)
+	}
+
+	if !f.Until.IsZero() {
+		conds = append(conds, "at < ?")
+		args = append(args, f.Until)
+	}
+
+	if f.AfterID != 0 {
+		conds = append(conds, "id > ?")
+		args = append(args, f.AfterID)
+	}
+
+	query := "SELECT " + columns + " FROM audit_log"
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+
+	query += " ORDER BY id"
+
+	if f.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, f.Limit)
+	}
+
+	return query, args
+}
+
+func nullable(b []byte) any {
+	if len(b) == 0 {
+		return nil
+	}
+
+	return string(b)
+}
@@ -0,0 +1,157 @@
+package audit
+
+import (
+	auditModel "Task_Manager/model/audit"
+	"Task_Manager/store/uow"
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+var auditColumns = []string{"id", "at", "actor_id", "action", "entity", "entity_id", "changes", "request_id", "source_ip", "prev_hash", "hash"}
+
+func setup(t *testing.T) (*Store, sqlmock.Sqlmock, func()) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	cleanup := func() { _ = db.Close() }
+	return NewStore(db), mock, cleanup
+}
+
+func Test_AppendAudit(t *testing.T) {
+	head := regexp.QuoteMeta("SELECT hash FROM audit_head WHERE id = 1 FOR UPDATE")
+	insert := regexp.QuoteMeta("INSERT INTO audit_log (at, actor_id, action, entity, entity_id, changes, request_id, source_ip, prev_hash, hash) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+	moveHead := regexp.QuoteMeta("UPDATE audit_head SET hash = ? WHERE id = 1")
+
+	e := auditModel.Entry{ActorID: 3, Action: auditModel.ActionCreate, Entity: auditModel.EntityTask, EntityID: 9,
+		Changes: []byte(`{"desc":{"before":null,"after":"Ship"}}`), RequestID: "req-1", SourceIP: "192.0.2.1"}
+
+	t.Run("Chains onto head", func(t *testing.T) {
+		store, mock, cleanup := setup(t)
+		defer cleanup()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(head).WillReturnRows(sqlmock.NewRows([]string{"hash"}).AddRow("abc"))
+		mock.ExpectExec(insert).
+			WithArgs(sqlmock.AnyArg(), 3, "create", "task", 9, string(e.Changes), "req-1", "192.0.2.1", "abc", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(42, 1))
+		mock.ExpectExec(moveHead).WithArgs(sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		got, err := store.AppendAudit(context.Background(), e)
+		require.NoError(t, err)
+		require.Equal(t, int64(42), got.ID)
+		require.Equal(t, "abc", got.PrevHash)
+		require.True(t, got.Follows("abc"))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Joins the unit of work", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer func() { _ = db.Close() }()
+
+		store := NewStore(db)
+
+		// One transaction for the mutation and its entry; the entry does not commit on its own
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE tasks").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(head).WillReturnRows(sqlmock.NewRows([]string{"hash"}).AddRow("abc"))
+		mock.ExpectExec(insert).WillReturnResult(sqlmock.NewResult(43, 1))
+		mock.ExpectExec(moveHead).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectRollback()
+
+		failed := errors.New("mutation failed later")
+		err = uow.New(db).WithTx(context.Background(), func(ctx context.Context) error {
+			if _, err := uow.Conn(ctx, db).ExecContext(ctx, "UPDATE tasks SET status = 1"); err != nil {
+				return err
+			}
+
+			if _, err := store.AppendAudit(ctx, e); err != nil {
+				return err
+			}
+
+			return failed
+		})
+		require.ErrorIs(t, err, failed)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Insert failure rolls back", func(t *testing.T) {
+		store, mock, cleanup := setup(t)
+		defer cleanup()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(head).WillReturnRows(sqlmock.NewRows([]string{"hash"}).AddRow(""))
+		mock.ExpectExec(insert).WillReturnError(errors.New("insert failed"))
+		mock.ExpectRollback()
+
+		_, err := store.AppendAudit(context.Background(), e)
+		require.Error(t, err)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func Test_QueryAudit(t *testing.T) {
+	store, mock, cleanup := setup(t)
+	defer cleanup()
+
+	since := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	query := regexp.QuoteMeta("SELECT id, at, actor_id, action, entity, entity_id, changes, request_id, source_ip, prev_hash, hash FROM audit_log " +
+		"WHERE actor_id = ? AND entity = ? AND entity_id = ? AND at >= ? AND id > ? ORDER BY id LIMIT ?")
+
+	mock.ExpectQuery(query).
+		WithArgs(3, "task", 9, since, int64(10), 50).
+		WillReturnRows(sqlmock.NewRows(auditColumns).
+			AddRow(11, since, 3, "create", "task", 9, []byte(`{"desc":{}}`), "req-1", "", "", "h1").
+			AddRow(12, since, 3, "complete", "task", 9, nil, "", "", "h1", "h2"))
+
+	entries, err := store.QueryAudit(context.Background(), auditModel.Filter{ActorID: 3, Entity: "task", EntityID: 9, Since: since, AfterID: 10, Limit: 50})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	require.JSONEq(t, `{"desc":{}}`, string(entries[0].Changes))
+	require.Nil(t, entries[1].Changes)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, at, actor_id, action, entity, entity_id, changes, request_id, source_ip, prev_hash, hash FROM audit_log ORDER BY id")).
+		WillReturnError(errors.New("query failed"))
+
+	_, err = store.QueryAudit(context.Background(), auditModel.Filter{})
+	require.Error(t, err)
+}
+
+func Test_EachAudit_StopsOnError(t *testing.T) {
+	store, mock, cleanup := setup(t)
+	defer cleanup()
+
+	mock.ExpectQuery(regexp.QuoteMeta("FROM audit_log WHERE action = ? ORDER BY id")).
+		WithArgs("delete").
+		WillReturnRows(sqlmock.NewRows(auditColumns).
+			AddRow(1, time.Now(), 1, "delete", "task", 1, nil, "", "", "", "h1").
+			AddRow(2, time.Now(), 1, "delete", "task", 2, nil, "", "", "h1", "h2"))
+
+	calls := 0
+	err := store.EachAudit(context.Background(), auditModel.Filter{Action: "delete"}, func(auditModel.Entry) error {
+		calls++
+		return errors.New("client gone")
+	})
+
+	require.Error(t, err)
+	require.Equal(t, 1, calls)
+}
+
+func Test_AuditHead(t *testing.T) {
+	store, mock, cleanup := setup(t)
+	defer cleanup()
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT hash FROM audit_head WHERE id = 1")).
+		WillReturnRows(sqlmock.NewRows([]string{"hash"}).AddRow("h2"))
+
+	head, err := store.AuditHead(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "h2", head)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,50 @@
+package blob
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_LocalStore(t *testing.T) {
+	ctx := context.Background()
+
+	store, err := NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+
+	require.NoError(t, store.Put(ctx, "tasks/1/a", strings.NewReader("hello world"), 11, ""))
+
+	f, err := store.Open(ctx, "tasks/1/a")
+	require.NoError(t, err)
+
+	_, err = f.Seek(6, io.SeekStart)
+	require.NoError(t, err)
+
+	rest, err := io.ReadAll(f)
+	require.NoError(t, err)
+	require.Equal(t, "world", string(rest))
+	require.NoError(t, f.Close())
+
+	require.NoError(t, store.Delete(ctx, "tasks/1/a"))
+	require.NoError(t, store.Delete(ctx, "tasks/1/a"))
+
+	_, err = store.Open(ctx, "tasks/1/a")
+	require.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func Test_LocalStore_InvalidKey(t *testing.T) {
+	ctx := context.Background()
+
+	store, err := NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+
+	require.Error(t, store.Put(ctx, "../escape", strings.NewReader("x"), 1, ""))
+
+	_, err = store.Open(ctx, "/etc/passwd")
+	require.Error(t, err)
+	require.Error(t, store.Delete(ctx, "a/../../b"))
+}
@@ -0,0 +1,156 @@
+package blob
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeS3 is a minimal in-memory stand-in for an S3-compatible server
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	ranges  []string
+	// fullGet answers GET requests with the whole object, ignoring their range
+	fullGet bool
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=access/20240102/eu-west-1/s3/aws4_request") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+
+		sum := sha256.Sum256(body)
+		if hex.EncodeToString(sum[:]) != r.Header.Get("X-Amz-Content-Sha256") {
+			http.Error(w, "XAmzContentSHA256Mismatch", http.StatusBadRequest)
+			return
+		}
+
+		f.objects[r.URL.Path] = body
+	case http.MethodGet, http.MethodHead:
+		obj, ok := f.objects[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		f.ranges = append(f.ranges, r.Header.Get("Range"))
+		if f.fullGet && r.Method == http.MethodGet {
+			_, _ = w.Write(obj)
+			return
+		}
+
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(obj))
+	case http.MethodDelete:
+		delete(f.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func newTestS3(t *testing.T) (*S3Store, *fakeS3) {
+	fake := &fakeS3{objects: map[string][]byte{}}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	store, err := NewS3Store(S3Config{Endpoint: srv.URL, Bucket: "attachments", Region: "eu-west-1", AccessKey: "access", SecretKey: "secret"}, srv.Client())
+	require.NoError(t, err)
+
+	store.now = func() time.Time { return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+	return store, fake
+}
+
+func checksum(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func Test_S3Store_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	store, fake := newTestS3(t)
+
+	content := "0123456789abcdef"
+	require.NoError(t, store.Put(ctx, "tasks/1/a", strings.NewReader(content), int64(len(content)), checksum(content)))
+	require.Contains(t, fake.objects, "/attachments/tasks/1/a")
+
+	obj, err := store.Open(ctx, "tasks/1/a")
+	require.NoError(t, err)
+
+	end, err := obj.Seek(0, io.SeekEnd)
+	require.NoError(t, err)
+	require.Equal(t, int64(len(content)), end)
+
+	_, err = obj.Seek(10, io.SeekStart)
+	require.NoError(t, err)
+
+	tail, err := io.ReadAll(obj)
+	require.NoError(t, err)
+	require.Equal(t, "abcdef", string(tail))
+	require.Contains(t, fake.ranges, "bytes=10-")
+	require.NoError(t, obj.Close())
+
+	require.NoError(t, store.Delete(ctx, "tasks/1/a"))
+
+	_, err = store.Open(ctx, "tasks/1/a")
+	require.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func Test_S3Store_RangeIgnored(t *testing.T) {
+	ctx := context.Background()
+	store, fake := newTestS3(t)
+
+	content := "0123456789abcdef"
+	require.NoError(t, store.Put(ctx, "tasks/1/a", strings.NewReader(content), int64(len(content)), checksum(content)))
+
+	fake.fullGet = true
+
+	obj, err := store.Open(ctx, "tasks/1/a")
+	require.NoError(t, err)
+
+	// From the start the whole object is the content asked for
+	head, err := io.ReadAll(obj)
+	require.NoError(t, err)
+	require.Equal(t, content, string(head))
+
+	// Past the start it is not, and must not be returned as if it were
+	_, err = obj.Seek(10, io.SeekStart)
+	require.NoError(t, err)
+
+	_, err = io.ReadAll(obj)
+	require.ErrorContains(t, err, "unexpected 200 OK")
+	require.NoError(t, obj.Close())
+}
+
+func Test_S3Store_ChecksumRejected(t *testing.T) {
+	store, _ := newTestS3(t)
+
+	err := store.Put(context.Background(), "tasks/1/a", strings.NewReader("abc"), 3, checksum("abd"))
+	require.ErrorContains(t, err, "XAmzContentSHA256Mismatch")
+}
+
+func Test_NewS3Store_Invalid(t *testing.T) {
+	_, err := NewS3Store(S3Config{Endpoint: "http://localhost:9000"}, nil)
+	require.Error(t, err)
+
+	_, err = NewS3Store(S3Config{Endpoint: "::", Bucket: "b"}, nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,175 @@
+package cached
+
+import (
+	"Task_Manager/cache"
+	"Task_Manager/model/user"
+	taskStore "Task_Manager/store/task"
+	"Task_Manager/store/uow"
+	userStore "Task_Manager/store/user"
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	selectTask = regexp.QuoteMeta("SELECT id, description, status, userid, due_at, workspace_id FROM tasks WHERE id = ? AND deleted_at IS NULL")
+	selectUser = regexp.QuoteMeta("SELECT id, name, email, display_name, avatar_url, timezone FROM users WHERE id = ? AND deleted_at IS NULL")
+	taskCols   = []string{"id", "description", "status", "userid", "due_at", "workspace_id"}
+	userCols   = []string{"id", "name", "email", "display_name", "avatar_url", "timezone"}
+)
+
+func setup(t *testing.T) (*TaskStore, *UserStore, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	c := cache.New(cache.NewLRU(100), time.Minute)
+
+	return NewTaskStore(taskStore.NewStore(db), c), NewUserStore(userStore.NewUserStore(db), c), mock
+}
+
+func expectTask(mock sqlmock.Sqlmock, id int, desc string) {
+	mock.ExpectQuery(selectTask).
+		WithArgs(id).
+		WillReturnRows(sqlmock.NewRows(taskCols).AddRow(id, desc, false, 1, nil, nil))
+}
+
+func Test_TaskStore(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Lookups are cached", func(t *testing.T) {
+		tasks, _, mock := setup(t)
+
+		expectTask(mock, 1, "Do homework")
+
+		for range 2 {
+			tsk, err := tasks.GetByIDTask(ctx, 1)
+			require.NoError(t, err)
+			require.Equal(t, "Do homework", tsk.Desc)
+		}
+
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Errors are not cached", func(t *testing.T) {
+		tasks, _, mock := setup(t)
+
+		mock.ExpectQuery(selectTask).WithArgs(1).WillReturnError(errors.New("db down"))
+		expectTask(mock, 1, "Do homework")
+
+		_, err := tasks.GetByIDTask(ctx, 1)
+		require.Error(t, err)
+
+		_, err = tasks.GetByIDTask(ctx, 1)
+		require.NoError(t, err)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Changes invalidate", func(t *testing.T) {
+		tasks, _, mock := setup(t)
+
+		expectTask(mock, 1, "Do homework")
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE tasks SET status = true WHERE id = ? AND deleted_at IS NULL")).
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO task_revisions").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		mock.ExpectQuery(selectTask).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows(taskCols).AddRow(1, "Do homework", true, 1, nil, nil))
+
+		_, err := tasks.GetByIDTask(ctx, 1)
+		require.NoError(t, err)
+		require.NoError(t, tasks.CompleteTask(ctx, 1))
+
+		tsk, err := tasks.GetByIDTask(ctx, 1)
+		require.NoError(t, err)
+		require.True(t, tsk.Status)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Failed changes keep the cache", func(t *testing.T) {
+		tasks, _, mock := setup(t)
+
+		expectTask(mock, 1, "Do homework")
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE tasks SET status = true WHERE id = ? AND deleted_at IS NULL")).
+			WithArgs(1).
+			WillReturnError(errors.New("update failed"))
+		mock.ExpectRollback()
+
+		_, err := tasks.GetByIDTask(ctx, 1)
+		require.NoError(t, err)
+		require.Error(t, tasks.CompleteTask(ctx, 1))
+
+		_, err = tasks.GetByIDTask(ctx, 1)
+		require.NoError(t, err)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Units of work bypass the cache and invalidate on commit", func(t *testing.T) {
+		tasks, users, mock := setup(t)
+
+		expectTask(mock, 1, "Do homework")
+		mock.ExpectBegin()
+		expectTask(mock, 1, "Do homework")
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE tasks SET status = true WHERE id = ? AND deleted_at IS NULL")).
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO task_revisions").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		expectTask(mock, 1, "Do homework")
+
+		_, err := tasks.GetByIDTask(ctx, 1)
+		require.NoError(t, err)
+
+		err = uow.New(users.DB).WithTx(ctx, func(ctx context.Context) error {
+			if _, err := tasks.GetByIDTask(ctx, 1); err != nil {
+				return err
+			}
+
+			return tasks.CompleteTask(ctx, 1)
+		})
+		require.NoError(t, err)
+
+		_, err = tasks.GetByIDTask(ctx, 1)
+		require.NoError(t, err)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func Test_UserStore(t *testing.T) {
+	ctx := context.Background()
+	_, users, mock := setup(t)
+
+	update := regexp.QuoteMeta("UPDATE users SET name = ?, display_name = ?, avatar_url = ?, timezone = ? WHERE id = ? AND deleted_at IS NULL")
+
+	mock.ExpectQuery(selectUser).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(userCols).AddRow(1, "John", "john@example.com", "", "", ""))
+	mock.ExpectExec(update).
+		WithArgs("Johnny", "", "", "", 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(selectUser).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(userCols).AddRow(1, "Johnny", "john@example.com", "", "", ""))
+
+	for range 2 {
+		u, err := users.GetByIDUser(ctx, 1)
+		require.NoError(t, err)
+		require.Equal(t, "John", u.Name)
+	}
+
+	require.NoError(t, users.UpdateUser(ctx, user.User{ID: 1, Name: "Johnny"}))
+
+	u, err := users.GetByIDUser(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, "Johnny", u.Name)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
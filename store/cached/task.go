@@ -0,0 +1,122 @@
+// Package cached decorates stores with a read-through cache. The decorators embed the store they wrap,
+// cache its frequent lookups and invalidate them on every mutation; any method not overridden reads the
+// database directly. Within a unit of work the cache is bypassed, since what is read there may not be
+// committed, and invalidations wait for the commit. Values are loaded from the primary: one read from a
+// lagging replica right after an invalidation would be cached for the whole ttl.
+package cached
+
+import (
+	"Task_Manager/cache"
+	"Task_Manager/model/task"
+	"Task_Manager/store/replica"
+	taskStore "Task_Manager/store/task"
+	"Task_Manager/store/uow"
+	"context"
+	"strconv"
+	"time"
+)
+
+// tasks is the namespace of the cached tasks. Changes to a task may move it between the lists of two
+// users, and some only know the task ID, so every change invalidates all of them by bumping the namespace.
+const tasks = "tasks"
+
+type TaskStore struct {
+	*taskStore.Store
+	cache *cache.Cache
+}
+
+func NewTaskStore(s *taskStore.Store, c *cache.Cache) *TaskStore {
+	return &TaskStore{Store: s, cache: c}
+}
+
+func (s *TaskStore) GetByIDTask(ctx context.Context, id int) (task.Task, error) {
+	prefix, ok := s.generation(ctx)
+	if !ok {
+		return s.Store.GetByIDTask(ctx, id)
+	}
+
+	return cache.Fetch(ctx, s.cache, "task", prefix+strconv.Itoa(id), func() (task.Task, error) {
+		return s.Store.GetByIDTask(replica.Primary(ctx), id)
+	})
+}
+
+func (s *TaskStore) GetTasksByUserIDTask(ctx context.Context, userid int) ([]task.Task, error) {
+	prefix, ok := s.generation(ctx)
+	if !ok {
+		return s.Store.GetTasksByUserIDTask(ctx, userid)
+	}
+
+	return cache.Fetch(ctx, s.cache, "tasks_by_user", prefix+"user:"+strconv.Itoa(userid), func() ([]task.Task, error) {
+		return s.Store.GetTasksByUserIDTask(replica.Primary(ctx), userid)
+	})
+}
+
+func (s *TaskStore) CreateTask(ctx context.Context, t task.Task) (task.Task, error) {
+	created, err := s.Store.CreateTask(ctx, t)
+	s.invalidate(ctx, err)
+
+	return created, err
+}
+
+func (s *TaskStore) CompleteTask(ctx context.Context, id int) error {
+	err := s.Store.CompleteTask(ctx, id)
+	s.invalidate(ctx, err)
+
+	return err
+}
+
+func (s *TaskStore) DeleteTask(ctx context.Context, id int) error {
+	err := s.Store.DeleteTask(ctx, id)
+	s.invalidate(ctx, err)
+
+	return err
+}
+
+func (s *TaskStore) RestoreTask(ctx context.Context, id int) error {
+	err := s.Store.RestoreTask(ctx, id)
+	s.invalidate(ctx, err)
+
+	return err
+}
+
+func (s *TaskStore) RevertTask(ctx context.Context, id int, to task.Task) error {
+	err := s.Store.RevertTask(ctx, id, to)
+	s.invalidate(ctx, err)
+
+	return err
+}
+
+func (s *TaskStore) PurgeTask(ctx context.Context, before time.Time) ([]int, error) {
+	ids, err := s.Store.PurgeTask(ctx, before)
+	s.invalidate(ctx, err)
+
+	return ids, err
+}
+
+func (s *TaskStore) BulkTask(ctx context.Context, ops []task.BulkOp, atomic bool) ([]task.BulkResult, error) {
+	results, err := s.Store.BulkTask(ctx, ops, atomic)
+	s.invalidate(ctx, err)
+
+	return results, err
+}
+
+// generation returns the prefix of the current task keys; ok is false when the cache must not be used
+func (s *TaskStore) generation(ctx context.Context) (string, bool) {
+	if uow.Active(ctx) {
+		return "", false
+	}
+
+	return s.cache.Generation(ctx, tasks)
+}
+
+// invalidate drops the cached tasks once a successful change is committed
+func (s *TaskStore) invalidate(ctx context.Context, err error) {
+	if err == nil {
+		bumpTasks(ctx, s.cache)
+	}
+}
+
+func bumpTasks(ctx context.Context, c *cache.Cache) {
+	ctx = context.WithoutCancel(ctx)
+	uow.AfterCommit(ctx, func() { c.Bump(ctx, tasks) })
+}
@@ -0,0 +1,94 @@
+package cached
+
+import (
+	"Task_Manager/cache"
+	"Task_Manager/model/user"
+	"Task_Manager/store/replica"
+	"Task_Manager/store/uow"
+	userStore "Task_Manager/store/user"
+	"context"
+	"strconv"
+	"time"
+)
+
+type UserStore struct {
+	*userStore.UserStore
+	cache *cache.Cache
+}
+
+func NewUserStore(s *userStore.UserStore, c *cache.Cache) *UserStore {
+	return &UserStore{UserStore: s, cache: c}
+}
+
+func userKey(id int) string {
+	return "user:" + strconv.Itoa(id)
+}
+
+func (s *UserStore) GetByIDUser(ctx context.Context, id int) (user.User, error) {
+	if uow.Active(ctx) {
+		return s.UserStore.GetByIDUser(ctx, id)
+	}
+
+	return cache.Fetch(ctx, s.cache, "user", userKey(id), func() (user.User, error) {
+		return s.UserStore.GetByIDUser(replica.Primary(ctx), id)
+	})
+}
+
+func (s *UserStore) UpdateUser(ctx context.Context, u user.User) error {
+	err := s.UserStore.UpdateUser(ctx, u)
+	s.invalidate(ctx, err, u.ID)
+
+	return err
+}
+
+func (s *UserStore) ConfirmEmailChangeUser(ctx context.Context, id int, tokenHash string, now time.Time) (user.User, string, error) {
+	before, email, err := s.UserStore.ConfirmEmailChangeUser(ctx, id, tokenHash, now)
+	s.invalidate(ctx, err, id)
+
+	return before, email, err
+}
+
+func (s *UserStore) DeleteUser(ctx context.Context, id int) error {
+	err := s.UserStore.DeleteUser(ctx, id)
+	s.invalidate(ctx, err, id)
+
+	return err
+}
+
+// DeleteWithTasksUser also changes the tasks of the user, whose cached copies are dropped too
+func (s *UserStore) DeleteWithTasksUser(ctx context.Context, id int, opts user.DeleteOptions) ([]int, error) {
+	ids, err := s.UserStore.DeleteWithTasksUser(ctx, id, opts)
+	s.invalidate(ctx, err, id)
+
+	if err == nil {
+		bumpTasks(ctx, s.cache)
+	}
+
+	return ids, err
+}
+
+// UnassignTasksUser changes the tasks of the user, whose cached copies are dropped
+func (s *UserStore) UnassignTasksUser(ctx context.Context, id int) ([]int, error) {
+	ids, err := s.UserStore.UnassignTasksUser(ctx, id)
+	if err == nil && len(ids) > 0 {
+		bumpTasks(ctx, s.cache)
+	}
+
+	return ids, err
+}
+
+// invalidate drops the cached copies of users once a successful change is committed. A reader that
+// loaded a user just before the change may still cache the old copy, which then lasts until it expires.
+func (s *UserStore) invalidate(ctx context.Context, err error, ids ...int) {
+	if err != nil {
+		return
+	}
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = userKey(id)
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	uow.AfterCommit(ctx, func() { s.cache.Invalidate(ctx, keys...) })
+}
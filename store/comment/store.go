@@ -0,0 +1,203 @@
+package comment
+
+import (
+	"Task_Manager/metrics"
+	"Task_Manager/model/comment"
+	"context"
+	"database/sql"
+	"time"
+)
+
+// observe times every store method
+var observe = metrics.QueryTimer("comment")
+
+const selectComment = "SELECT id, task_id, parent_id, author_id, body, created_at, edited_at, deleted_at FROM comments"
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+// scanner is implemented by both *sql.Row and *sql.Rows
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanComment(sc scanner) (comment.Comment, error) {
+	var (
+		c        comment.Comment
+		parentID sql.NullInt64
+		editedAt sql.NullTime
+		deleted  sql.NullTime
+	)
+
+	if err := sc.Scan(&c.ID, &c.TaskID, &parentID, &c.AuthorID, &c.Body, &c.CreatedAt, &editedAt, &deleted); err != nil {
+		return c, err
+	}
+
+	if parentID.Valid {
+		id := int(parentID.Int64)
+		c.ParentID = &id
+	}
+
+	if editedAt.Valid {
+		c.EditedAt = &editedAt.Time
+	}
+
+	c.Deleted = deleted.Valid
+
+	return c, nil
+}
+
+// CreateComment inserts a new comment and returns it with its ID and creation time
+func (s *Store) CreateComment(ctx context.Context, c comment.Comment) (comment.Comment, error) {
+	defer observe("CreateComment")()
+
+	c.CreatedAt = time.Now().UTC()
+
+	res, err := s.db.ExecContext(ctx, "INSERT INTO comments (task_id, parent_id, author_id, body, created_at) VALUES (?, ?, ?, ?, ?)",
+		c.TaskID, c.ParentID, c.AuthorID, c.Body, c.CreatedAt)
+	if err != nil {
+		return c, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return c, err
+	}
+
+	c.ID = int(id)
+
+	return c, nil
+}
+
+// GetByIDComment fetches a single comment, including soft deleted ones
+func (s *Store) GetByIDComment(ctx context.Context, id int) (comment.Comment, error) {
+	defer observe("GetByIDComment")()
+
+	return scanComment(s.db.QueryRowContext(ctx, selectComment+" WHERE id = ?", id))
+}
+
+// GetByTaskIDComment returns every comment of a task in creation order
+func (s *Store) GetByTaskIDComment(ctx context.Context, taskID int) ([]comment.Comment, error) {
+	defer observe("GetByTaskIDComment")()
+
+	rows, err := s.db.QueryContext(ctx, selectComment+" WHERE task_id = ? ORDER BY created_at, id", taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	var comments []comment.Comment
+
+	for rows.Next() {
+		c, err := scanComment(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		comments = append(comments, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return comments, nil
+}
+
+// UpdateComment replaces the body of a comment, keeping the previous body in the edit history
+func (s *Store) UpdateComment(ctx context.Context, id, editorID int, body string) (err error) {
+	defer observe("UpdateComment")()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var previous string
+
+	err = tx.QueryRowContext(ctx, "SELECT body FROM comments WHERE id = ? AND deleted_at IS NULL FOR UPDATE", id).Scan(&previous)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO comment_edits (comment_id, editor_id, body, edited_at) VALUES (?, ?, ?, ?)",
+		id, editorID, previous, now)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE comments SET body = ?, edited_at = ? WHERE id = ?", body, now, id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteComment soft deletes a comment so that its replies stay attached to the thread
+func (s *Store) DeleteComment(ctx context.Context, id int) error {
+	defer observe("DeleteComment")()
+
+	res, err := s.db.ExecContext(ctx, "UPDATE comments SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL", time.Now().UTC(), id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// GetEditsComment returns the previous bodies of a comment, oldest first
+func (s *Store) GetEditsComment(ctx context.Context, id int) ([]comment.Edit, error) {
+	defer observe("GetEditsComment")()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT comment_id, editor_id, body, edited_at FROM comment_edits WHERE comment_id = ? ORDER BY edited_at, id", id)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	var edits []comment.Edit
+
+	for rows.Next() {
+		var e comment.Edit
+		if err := rows.Scan(&e.CommentID, &e.EditorID, &e.Body, &e.EditedAt); err != nil {
+			return nil, err
+		}
+
+		edits = append(edits, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return edits, nil
+}
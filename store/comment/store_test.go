@@ -0,0 +1,221 @@
+package comment
+
+import (
+	commentModel "Task_Manager/model/comment"
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+var commentColumns = []string{"id", "task_id", "parent_id", "author_id", "body", "created_at", "edited_at", "deleted_at"}
+
+func setup(t *testing.T) (*Store, sqlmock.Sqlmock, func()) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	cleanup := func() { _ = db.Close() }
+	return NewStore(db), mock, cleanup
+}
+
+func Test_CreateComment(t *testing.T) {
+	store, mock, cleanup := setup(t)
+	defer cleanup()
+
+	c := commentModel.Comment{TaskID: 1, AuthorID: 2, Body: "Looks good"}
+	query := regexp.QuoteMeta("INSERT INTO comments (task_id, parent_id, author_id, body, created_at) VALUES (?, ?, ?, ?, ?)")
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectExec(query).
+			WithArgs(1, nil, 2, "Looks good", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(5, 1))
+
+		created, err := store.CreateComment(context.Background(), c)
+		require.NoError(t, err)
+		require.Equal(t, 5, created.ID)
+		require.False(t, created.CreatedAt.IsZero())
+	})
+
+	t.Run("Exec Error", func(t *testing.T) {
+		mock.ExpectExec(query).WillReturnError(errors.New("insert failed"))
+
+		_, err := store.CreateComment(context.Background(), c)
+		require.EqualError(t, err, "insert failed")
+	})
+
+	t.Run("LastInsertId Error", func(t *testing.T) {
+		mock.ExpectExec(query).WillReturnResult(sqlmock.NewErrorResult(errors.New("lastInsertId failed")))
+
+		_, err := store.CreateComment(context.Background(), c)
+		require.EqualError(t, err, "lastInsertId failed")
+	})
+}
+
+func Test_GetByIDComment(t *testing.T) {
+	store, mock, cleanup := setup(t)
+	defer cleanup()
+
+	now := time.Now()
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(selectComment + " WHERE id = ?")).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows(commentColumns).AddRow(1, 3, 9, 2, "reply", now, now, now))
+
+		c, err := store.GetByIDComment(context.Background(), 1)
+		require.NoError(t, err)
+		require.Equal(t, 9, *c.ParentID)
+		require.NotNil(t, c.EditedAt)
+		require.True(t, c.Deleted)
+	})
+
+	t.Run("Not Found", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(selectComment + " WHERE id = ?")).
+			WithArgs(2).
+			WillReturnError(sql.ErrNoRows)
+
+		_, err := store.GetByIDComment(context.Background(), 2)
+		require.ErrorIs(t, err, sql.ErrNoRows)
+	})
+}
+
+func Test_GetByTaskIDComment(t *testing.T) {
+	store, mock, cleanup := setup(t)
+	defer cleanup()
+
+	query := regexp.QuoteMeta(selectComment + " WHERE task_id = ? ORDER BY created_at, id")
+	now := time.Now()
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectQuery(query).
+			WithArgs(3).
+			WillReturnRows(sqlmock.NewRows(commentColumns).
+				AddRow(1, 3, nil, 2, "first", now, nil, nil).
+				AddRow(2, 3, 1, 4, "reply", now, nil, nil))
+
+		comments, err := store.GetByTaskIDComment(context.Background(), 3)
+		require.NoError(t, err)
+		require.Len(t, comments, 2)
+		require.Nil(t, comments[0].ParentID)
+		require.False(t, comments[0].Deleted)
+	})
+
+	t.Run("Query Error", func(t *testing.T) {
+		mock.ExpectQuery(query).WithArgs(3).WillReturnError(sql.ErrConnDone)
+
+		_, err := store.GetByTaskIDComment(context.Background(), 3)
+		require.Error(t, err)
+	})
+
+	t.Run("Row Error", func(t *testing.T) {
+		rows := sqlmock.NewRows(commentColumns).AddRow(1, 3, nil, 2, "first", now, nil, nil)
+		rows.RowError(0, errors.New("row error"))
+		mock.ExpectQuery(query).WithArgs(3).WillReturnRows(rows)
+
+		_, err := store.GetByTaskIDComment(context.Background(), 3)
+		require.Error(t, err)
+	})
+}
+
+func Test_UpdateComment(t *testing.T) {
+	store, mock, cleanup := setup(t)
+	defer cleanup()
+
+	lock := regexp.QuoteMeta("SELECT body FROM comments WHERE id = ? AND deleted_at IS NULL FOR UPDATE")
+	history := regexp.QuoteMeta("INSERT INTO comment_edits (comment_id, editor_id, body, edited_at) VALUES (?, ?, ?, ?)")
+	update := regexp.QuoteMeta("UPDATE comments SET body = ?, edited_at = ? WHERE id = ?")
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(lock).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"body"}).AddRow("old"))
+		mock.ExpectExec(history).WithArgs(1, 2, "old", sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(update).WithArgs("new", sqlmock.AnyArg(), 1).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		require.NoError(t, store.UpdateComment(context.Background(), 1, 2, "new"))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Comment Missing", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(lock).WithArgs(1).WillReturnError(sql.ErrNoRows)
+		mock.ExpectRollback()
+
+		require.ErrorIs(t, store.UpdateComment(context.Background(), 1, 2, "new"), sql.ErrNoRows)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Update Error", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(lock).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"body"}).AddRow("old"))
+		mock.ExpectExec(history).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(update).WillReturnError(errors.New("update failed"))
+		mock.ExpectRollback()
+
+		require.EqualError(t, store.UpdateComment(context.Background(), 1, 2, "new"), "update failed")
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Begin Error", func(t *testing.T) {
+		mock.ExpectBegin().WillReturnError(sql.ErrConnDone)
+
+		require.ErrorIs(t, store.UpdateComment(context.Background(), 1, 2, "new"), sql.ErrConnDone)
+	})
+}
+
+func Test_DeleteComment(t *testing.T) {
+	store, mock, cleanup := setup(t)
+	defer cleanup()
+
+	query := regexp.QuoteMeta("UPDATE comments SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL")
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectExec(query).WithArgs(sqlmock.AnyArg(), 1).WillReturnResult(sqlmock.NewResult(0, 1))
+		require.NoError(t, store.DeleteComment(context.Background(), 1))
+	})
+
+	t.Run("Already Deleted", func(t *testing.T) {
+		mock.ExpectExec(query).WithArgs(sqlmock.AnyArg(), 2).WillReturnResult(sqlmock.NewResult(0, 0))
+		require.ErrorIs(t, store.DeleteComment(context.Background(), 2), sql.ErrNoRows)
+	})
+
+	t.Run("Exec Error", func(t *testing.T) {
+		mock.ExpectExec(query).WillReturnError(sql.ErrConnDone)
+		require.Error(t, store.DeleteComment(context.Background(), 3))
+	})
+
+	t.Run("RowsAffected Error", func(t *testing.T) {
+		mock.ExpectExec(query).WillReturnResult(sqlmock.NewErrorResult(errors.New("RowsAffected fail")))
+		require.Error(t, store.DeleteComment(context.Background(), 4))
+	})
+}
+
+func Test_GetEditsComment(t *testing.T) {
+	store, mock, cleanup := setup(t)
+	defer cleanup()
+
+	query := regexp.QuoteMeta("SELECT comment_id, editor_id, body, edited_at FROM comment_edits WHERE comment_id = ? ORDER BY edited_at, id")
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectQuery(query).WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"comment_id", "editor_id", "body", "edited_at"}).
+				AddRow(1, 2, "v1", time.Now()).
+				AddRow(1, 2, "v2", time.Now()))
+
+		edits, err := store.GetEditsComment(context.Background(), 1)
+		require.NoError(t, err)
+		require.Len(t, edits, 2)
+		require.Equal(t, "v1", edits[0].Body)
+	})
+
+	t.Run("Query Error", func(t *testing.T) {
+		mock.ExpectQuery(query).WithArgs(1).WillReturnError(sql.ErrConnDone)
+
+		_, err := store.GetEditsComment(context.Background(), 1)
+		require.Error(t, err)
+	})
+}
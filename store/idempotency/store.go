@@ -0,0 +1,86 @@
+package idempotency
+
+import (
+	"Task_Manager/metrics"
+	"Task_Manager/model/idempotency"
+	"context"
+	"database/sql"
+	"time"
+)
+
+// observe times every store method
+var observe = metrics.QueryTimer("idempotency")
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+// ClaimIdempotency reserves rec.Key for the request being served. When the key is taken the record
+// holding it is returned instead, with claimed false. A record created before expired no longer
+// holds its key.
+func (s *Store) ClaimIdempotency(ctx context.Context, rec idempotency.Record, expired time.Time) (idempotency.Record, bool, error) {
+	defer observe("ClaimIdempotency")()
+
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE idempotency_key = ? AND created_at < ?", rec.Key, expired.UTC()); err != nil {
+		return idempotency.Record{}, false, err
+	}
+
+	res, err := s.db.ExecContext(ctx, "INSERT IGNORE INTO idempotency_keys (idempotency_key, fingerprint, status, created_at) VALUES (?, ?, 0, ?)",
+		rec.Key, rec.Fingerprint, rec.CreatedAt.UTC())
+	if err != nil {
+		return idempotency.Record{}, false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return idempotency.Record{}, false, err
+	}
+
+	if n == 1 {
+		return rec, true, nil
+	}
+
+	existing := idempotency.Record{Key: rec.Key}
+	err = s.db.QueryRowContext(ctx, "SELECT fingerprint, status, content_type, body, created_at FROM idempotency_keys WHERE idempotency_key = ?", rec.Key).
+		Scan(&existing.Fingerprint, &existing.Status, &existing.ContentType, &existing.Body, &existing.CreatedAt)
+
+	return existing, false, err
+}
+
+// CompleteIdempotency keeps the response to the request holding key, for its retries to replay
+func (s *Store) CompleteIdempotency(ctx context.Context, key string, status int, contentType string, body []byte) error {
+	defer observe("CompleteIdempotency")()
+
+	_, err := s.db.ExecContext(ctx, "UPDATE idempotency_keys SET status = ?, content_type = ?, body = ? WHERE idempotency_key = ?",
+		status, contentType, body, key)
+
+	return err
+}
+
+// ReleaseIdempotency frees a key whose request failed without a response worth replaying, so that
+// a retry is served afresh
+func (s *Store) ReleaseIdempotency(ctx context.Context, key string) error {
+	defer observe("ReleaseIdempotency")()
+
+	_, err := s.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE idempotency_key = ? AND status = 0", key)
+
+	return err
+}
+
+// DeleteExpiredIdempotency removes the records created before before
+func (s *Store) DeleteExpiredIdempotency(ctx context.Context, before time.Time) (int, error) {
+	defer observe("DeleteExpiredIdempotency")()
+
+	res, err := s.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE created_at < ?", before.UTC())
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := res.RowsAffected()
+
+	return int(n), err
+}
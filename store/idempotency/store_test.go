@@ -0,0 +1,109 @@
+package idempotency
+
+import (
+	"Task_Manager/model/idempotency"
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func setup(t *testing.T) (*Store, sqlmock.Sqlmock, func()) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	cleanup := func() { _ = db.Close() }
+	return NewStore(db), mock, cleanup
+}
+
+func Test_ClaimIdempotency(t *testing.T) {
+	expire := regexp.QuoteMeta("DELETE FROM idempotency_keys WHERE idempotency_key = ? AND created_at < ?")
+	insert := regexp.QuoteMeta("INSERT IGNORE INTO idempotency_keys (idempotency_key, fingerprint, status, created_at) VALUES (?, ?, 0, ?)")
+	get := regexp.QuoteMeta("SELECT fingerprint, status, content_type, body, created_at FROM idempotency_keys WHERE idempotency_key = ?")
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	expired := now.Add(-24 * time.Hour)
+	rec := idempotency.Record{Key: "k", Fingerprint: "f", CreatedAt: now}
+
+	t.Run("Claims a free key", func(t *testing.T) {
+		store, mock, cleanup := setup(t)
+		defer cleanup()
+
+		mock.ExpectExec(expire).WithArgs("k", expired).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(insert).WithArgs("k", "f", now).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		got, claimed, err := store.ClaimIdempotency(context.Background(), rec, expired)
+		require.NoError(t, err)
+		require.True(t, claimed)
+		require.Equal(t, rec, got)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Returns the record holding the key", func(t *testing.T) {
+		store, mock, cleanup := setup(t)
+		defer cleanup()
+
+		mock.ExpectExec(expire).WithArgs("k", expired).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(insert).WithArgs("k", "f", now).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(get).WithArgs("k").WillReturnRows(sqlmock.NewRows([]string{"fingerprint", "status", "content_type", "body", "created_at"}).
+			AddRow("f", 201, "application/json", []byte(`{"id":1}`), now.Add(-time.Minute)))
+
+		got, claimed, err := store.ClaimIdempotency(context.Background(), rec, expired)
+		require.NoError(t, err)
+		require.False(t, claimed)
+		require.Equal(t, idempotency.Record{Key: "k", Fingerprint: "f", Status: 201, ContentType: "application/json",
+			Body: []byte(`{"id":1}`), CreatedAt: now.Add(-time.Minute)}, got)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Insert error", func(t *testing.T) {
+		store, mock, cleanup := setup(t)
+		defer cleanup()
+
+		mock.ExpectExec(expire).WithArgs("k", expired).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(insert).WithArgs("k", "f", now).WillReturnError(errors.New("db down"))
+
+		_, claimed, err := store.ClaimIdempotency(context.Background(), rec, expired)
+		require.Error(t, err)
+		require.False(t, claimed)
+	})
+}
+
+func Test_CompleteIdempotency(t *testing.T) {
+	store, mock, cleanup := setup(t)
+	defer cleanup()
+
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE idempotency_keys SET status = ?, content_type = ?, body = ? WHERE idempotency_key = ?")).
+		WithArgs(201, "application/json", []byte("{}"), "k").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, store.CompleteIdempotency(context.Background(), "k", 201, "application/json", []byte("{}")))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func Test_ReleaseIdempotency(t *testing.T) {
+	store, mock, cleanup := setup(t)
+	defer cleanup()
+
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM idempotency_keys WHERE idempotency_key = ? AND status = 0")).
+		WithArgs("k").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, store.ReleaseIdempotency(context.Background(), "k"))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func Test_DeleteExpiredIdempotency(t *testing.T) {
+	store, mock, cleanup := setup(t)
+	defer cleanup()
+
+	before := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM idempotency_keys WHERE created_at < ?")).
+		WithArgs(before).WillReturnResult(sqlmock.NewResult(0, 3))
+
+	n, err := store.DeleteExpiredIdempotency(context.Background(), before)
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
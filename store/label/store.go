@@ -0,0 +1,294 @@
+package label
+
+import (
+	"Task_Manager/metrics"
+	"Task_Manager/model/label"
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// observe times every store method
+var observe = metrics.QueryTimer("label")
+
+const (
+	mysqlDuplicateEntry  = 1062
+	mysqlNoReferencedRow = 1452
+)
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+// translate maps MySQL constraint violations onto label errors
+func translate(err error) error {
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		return err
+	}
+
+	switch mysqlErr.Number {
+	case mysqlDuplicateEntry:
+		return label.ErrDuplicate
+	case mysqlNoReferencedRow:
+		return label.ErrUnknownTarget
+	default:
+		return err
+	}
+}
+
+// placeholders returns "?, ?, ?" for n arguments
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+// CreateLabel inserts a new label
+func (s *Store) CreateLabel(ctx context.Context, l label.Label) (label.Label, error) {
+	defer observe("CreateLabel")()
+
+	res, err := s.db.ExecContext(ctx, "INSERT INTO labels (workspace_id, name, color) VALUES (?, ?, ?)", l.WorkspaceID, l.Name, l.Color)
+	if err != nil {
+		return l, translate(err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return l, err
+	}
+
+	l.ID = int(id)
+
+	return l, nil
+}
+
+// GetByIDLabel fetches a label by its ID
+func (s *Store) GetByIDLabel(ctx context.Context, id int) (label.Label, error) {
+	defer observe("GetByIDLabel")()
+
+	var l label.Label
+
+	err := s.db.QueryRowContext(ctx, "SELECT id, workspace_id, name, color FROM labels WHERE id = ?", id).
+		Scan(&l.ID, &l.WorkspaceID, &l.Name, &l.Color)
+
+	return l, err
+}
+
+// GetByWorkspaceLabel lists the labels of a workspace ordered by name
+func (s *Store) GetByWorkspaceLabel(ctx context.Context, workspaceID int) ([]label.Label, error) {
+	defer observe("GetByWorkspaceLabel")()
+
+	return s.query(ctx, "SELECT id, workspace_id, name, color FROM labels WHERE workspace_id = ? ORDER BY name", workspaceID)
+}
+
+// GetByTaskIDLabel lists the labels attached to a task ordered by name
+func (s *Store) GetByTaskIDLabel(ctx context.Context, taskID int) ([]label.Label, error) {
+	defer observe("GetByTaskIDLabel")()
+
+	return s.query(ctx, "SELECT l.id, l.workspace_id, l.name, l.color FROM labels l "+
+		"JOIN task_labels tl ON tl.label_id = l.id WHERE tl.task_id = ? ORDER BY l.name", taskID)
+}
+
+func (s *Store) query(ctx context.Context, query string, args ...any) ([]label.Label, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	var labels []label.Label
+
+	for rows.Next() {
+		var l label.Label
+		if err := rows.Scan(&l.ID, &l.WorkspaceID, &l.Name, &l.Color); err != nil {
+			return nil, err
+		}
+
+		labels = append(labels, l)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return labels, nil
+}
+
+// DeleteLabel removes a label; its task associations go with it through the foreign key
+func (s *Store) DeleteLabel(ctx context.Context, id int) error {
+	defer observe("DeleteLabel")()
+
+	res, err := s.db.ExecContext(ctx, "DELETE FROM labels WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// DetachLabel removes a label from a live task
+func (s *Store) DetachLabel(ctx context.Context, taskID, labelID int) error {
+	defer observe("DetachLabel")()
+
+	res, err := s.db.ExecContext(ctx, "DELETE tl FROM task_labels tl JOIN tasks t ON t.id = tl.task_id "+
+		"WHERE tl.task_id = ? AND tl.label_id = ? AND t.deleted_at IS NULL", taskID, labelID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// RelabelTasks adds and removes labels on a set of live tasks in one transaction, using a single
+// multi-row statement for each direction; the IDs must be distinct. Adding a label a task already has
+// is a no-op. It fails with ErrUnknownTarget when a task is missing or trashed or a label is missing,
+// and with ErrOtherWorkspace when a label added belongs to another workspace than one of the tasks.
+func (s *Store) RelabelTasks(ctx context.Context, taskIDs, add, remove []int) (err error) {
+	defer observe("RelabelTasks")()
+
+	if len(taskIDs) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	// Locking the tasks keeps them from being trashed before the labels are written
+	workspaces, err := workspacesOf(ctx, tx, "SELECT id, workspace_id FROM tasks WHERE id IN ("+placeholders(len(taskIDs))+
+		") AND deleted_at IS NULL FOR UPDATE", taskIDs)
+	if err != nil {
+		return err
+	}
+
+	if len(workspaces) != len(taskIDs) {
+		return label.ErrUnknownTarget
+	}
+
+	if len(add) > 0 {
+		var labelSpaces map[int]int
+
+		labelSpaces, err = workspacesOf(ctx, tx, "SELECT id, workspace_id FROM labels WHERE id IN ("+placeholders(len(add))+")", add)
+		if err != nil {
+			return err
+		}
+
+		if len(labelSpaces) != len(add) {
+			return label.ErrUnknownTarget
+		}
+
+		for _, taskSpace := range workspaces {
+			for _, labelSpace := range labelSpaces {
+				if labelSpace != taskSpace {
+					return label.ErrOtherWorkspace
+				}
+			}
+		}
+	}
+
+	if len(remove) > 0 {
+		args := make([]any, 0, len(taskIDs)+len(remove))
+		for _, id := range taskIDs {
+			args = append(args, id)
+		}
+
+		for _, id := range remove {
+			args = append(args, id)
+		}
+
+		_, err = tx.ExecContext(ctx, "DELETE FROM task_labels WHERE task_id IN ("+placeholders(len(taskIDs))+
+			") AND label_id IN ("+placeholders(len(remove))+")", args...)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(add) > 0 {
+		values := make([]string, 0, len(taskIDs)*len(add))
+		args := make([]any, 0, 2*len(taskIDs)*len(add))
+
+		for _, taskID := range taskIDs {
+			for _, labelID := range add {
+				values = append(values, "(?, ?)")
+				args = append(args, taskID, labelID)
+			}
+		}
+
+		// ON DUPLICATE KEY rather than INSERT IGNORE, which would also swallow foreign key violations
+		_, err = tx.ExecContext(ctx, "INSERT INTO task_labels (task_id, label_id) VALUES "+strings.Join(values, ", ")+
+			" ON DUPLICATE KEY UPDATE task_id = task_id", args...)
+		if err != nil {
+			return translate(err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// workspacesOf maps the IDs returned by an "id, workspace_id" query onto their workspace, zero for none
+func workspacesOf(ctx context.Context, tx *sql.Tx, query string, ids []int) (map[int]int, error) {
+	args := make([]any, 0, len(ids))
+	for _, id := range ids {
+		args = append(args, id)
+	}
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	workspaces := make(map[int]int, len(ids))
+
+	for rows.Next() {
+		var (
+			id        int
+			workspace sql.NullInt64
+		)
+
+		if err := rows.Scan(&id, &workspace); err != nil {
+			return nil, err
+		}
+
+		workspaces[id] = int(workspace.Int64)
+	}
+
+	return workspaces, rows.Err()
+}
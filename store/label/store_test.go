@@ -0,0 +1,246 @@
+package label
+
+import (
+	labelModel "Task_Manager/model/label"
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/require"
+)
+
+var labelColumns = []string{"id", "workspace_id", "name", "color"}
+
+func setup(t *testing.T) (*Store, sqlmock.Sqlmock, func()) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	cleanup := func() { _ = db.Close() }
+	return NewStore(db), mock, cleanup
+}
+
+func Test_CreateLabel(t *testing.T) {
+	store, mock, cleanup := setup(t)
+	defer cleanup()
+
+	query := regexp.QuoteMeta("INSERT INTO labels (workspace_id, name, color) VALUES (?, ?, ?)")
+	l := labelModel.Label{WorkspaceID: 1, Name: "bug", Color: "#ff0000"}
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectExec(query).WithArgs(1, "bug", "#ff0000").WillReturnResult(sqlmock.NewResult(3, 1))
+
+		created, err := store.CreateLabel(context.Background(), l)
+		require.NoError(t, err)
+		require.Equal(t, 3, created.ID)
+	})
+
+	t.Run("Duplicate", func(t *testing.T) {
+		mock.ExpectExec(query).WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry"})
+
+		_, err := store.CreateLabel(context.Background(), l)
+		require.ErrorIs(t, err, labelModel.ErrDuplicate)
+	})
+
+	t.Run("Exec Error", func(t *testing.T) {
+		mock.ExpectExec(query).WillReturnError(errors.New("insert failed"))
+
+		_, err := store.CreateLabel(context.Background(), l)
+		require.EqualError(t, err, "insert failed")
+	})
+}
+
+func Test_GetByIDLabel(t *testing.T) {
+	store, mock, cleanup := setup(t)
+	defer cleanup()
+
+	query := regexp.QuoteMeta("SELECT id, workspace_id, name, color FROM labels WHERE id = ?")
+
+	mock.ExpectQuery(query).WithArgs(3).WillReturnRows(sqlmock.NewRows(labelColumns).AddRow(3, 1, "bug", "#ff0000"))
+
+	l, err := store.GetByIDLabel(context.Background(), 3)
+	require.NoError(t, err)
+	require.Equal(t, "bug", l.Name)
+
+	mock.ExpectQuery(query).WithArgs(4).WillReturnError(sql.ErrNoRows)
+
+	_, err = store.GetByIDLabel(context.Background(), 4)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func Test_GetByWorkspaceLabel(t *testing.T) {
+	store, mock, cleanup := setup(t)
+	defer cleanup()
+
+	query := regexp.QuoteMeta("SELECT id, workspace_id, name, color FROM labels WHERE workspace_id = ? ORDER BY name")
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectQuery(query).WithArgs(1).
+			WillReturnRows(sqlmock.NewRows(labelColumns).AddRow(3, 1, "bug", "#ff0000").AddRow(4, 1, "urgent", "#00ff00"))
+
+		labels, err := store.GetByWorkspaceLabel(context.Background(), 1)
+		require.NoError(t, err)
+		require.Len(t, labels, 2)
+	})
+
+	t.Run("Query Error", func(t *testing.T) {
+		mock.ExpectQuery(query).WithArgs(1).WillReturnError(sql.ErrConnDone)
+
+		_, err := store.GetByWorkspaceLabel(context.Background(), 1)
+		require.Error(t, err)
+	})
+
+	t.Run("Row Error", func(t *testing.T) {
+		rows := sqlmock.NewRows(labelColumns).AddRow(3, 1, "bug", "#ff0000")
+		rows.RowError(0, errors.New("row error"))
+		mock.ExpectQuery(query).WithArgs(1).WillReturnRows(rows)
+
+		_, err := store.GetByWorkspaceLabel(context.Background(), 1)
+		require.Error(t, err)
+	})
+}
+
+func Test_GetByTaskIDLabel(t *testing.T) {
+	store, mock, cleanup := setup(t)
+	defer cleanup()
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT l.id, l.workspace_id, l.name, l.color FROM labels l " +
+		"JOIN task_labels tl ON tl.label_id = l.id WHERE tl.task_id = ? ORDER BY l.name")).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows(labelColumns).AddRow(3, 1, "bug", "#ff0000"))
+
+	labels, err := store.GetByTaskIDLabel(context.Background(), 7)
+	require.NoError(t, err)
+	require.Len(t, labels, 1)
+}
+
+func Test_DeleteLabel(t *testing.T) {
+	store, mock, cleanup := setup(t)
+	defer cleanup()
+
+	query := regexp.QuoteMeta("DELETE FROM labels WHERE id = ?")
+
+	mock.ExpectExec(query).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, store.DeleteLabel(context.Background(), 1))
+
+	mock.ExpectExec(query).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 0))
+	require.ErrorIs(t, store.DeleteLabel(context.Background(), 2), sql.ErrNoRows)
+
+	mock.ExpectExec(query).WithArgs(3).WillReturnError(sql.ErrConnDone)
+	require.Error(t, store.DeleteLabel(context.Background(), 3))
+}
+
+func Test_DetachLabel(t *testing.T) {
+	store, mock, cleanup := setup(t)
+	defer cleanup()
+
+	query := regexp.QuoteMeta("DELETE tl FROM task_labels tl JOIN tasks t ON t.id = tl.task_id " +
+		"WHERE tl.task_id = ? AND tl.label_id = ? AND t.deleted_at IS NULL")
+
+	mock.ExpectExec(query).WithArgs(1, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, store.DetachLabel(context.Background(), 1, 2))
+
+	mock.ExpectExec(query).WithArgs(1, 3).WillReturnResult(sqlmock.NewResult(0, 0))
+	require.ErrorIs(t, store.DetachLabel(context.Background(), 1, 3), sql.ErrNoRows)
+}
+
+func Test_RelabelTasks(t *testing.T) {
+	store, mock, cleanup := setup(t)
+	defer cleanup()
+
+	tasks := regexp.QuoteMeta("SELECT id, workspace_id FROM tasks WHERE id IN (?, ?) AND deleted_at IS NULL FOR UPDATE")
+	labels := regexp.QuoteMeta("SELECT id, workspace_id FROM labels WHERE id IN (?, ?)")
+	remove := regexp.QuoteMeta("DELETE FROM task_labels WHERE task_id IN (?, ?) AND label_id IN (?)")
+	insert := regexp.QuoteMeta("INSERT INTO task_labels (task_id, label_id) VALUES (?, ?), (?, ?), (?, ?), (?, ?) " +
+		"ON DUPLICATE KEY UPDATE task_id = task_id")
+
+	workspaces := func(rows ...[2]any) *sqlmock.Rows {
+		r := sqlmock.NewRows([]string{"id", "workspace_id"})
+		for _, row := range rows {
+			r.AddRow(row[0], row[1])
+		}
+
+		return r
+	}
+
+	t.Run("Add and remove", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(tasks).WithArgs(1, 2).WillReturnRows(workspaces([2]any{1, 5}, [2]any{2, 5}))
+		mock.ExpectQuery(labels).WithArgs(3, 4).WillReturnRows(workspaces([2]any{3, 5}, [2]any{4, 5}))
+		mock.ExpectExec(remove).WithArgs(1, 2, 9).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(insert).WithArgs(1, 3, 1, 4, 2, 3, 2, 4).WillReturnResult(sqlmock.NewResult(0, 4))
+		mock.ExpectCommit()
+
+		require.NoError(t, store.RelabelTasks(context.Background(), []int{1, 2}, []int{3, 4}, []int{9}))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Label of another workspace", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(tasks).WithArgs(1, 2).WillReturnRows(workspaces([2]any{1, 5}, [2]any{2, 6}))
+		mock.ExpectQuery(labels).WithArgs(3, 4).WillReturnRows(workspaces([2]any{3, 5}, [2]any{4, 5}))
+		mock.ExpectRollback()
+
+		require.ErrorIs(t, store.RelabelTasks(context.Background(), []int{1, 2}, []int{3, 4}, nil), labelModel.ErrOtherWorkspace)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Task outside any workspace", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(tasks).WithArgs(1, 2).WillReturnRows(workspaces([2]any{1, 5}, [2]any{2, nil}))
+		mock.ExpectQuery(labels).WithArgs(3, 4).WillReturnRows(workspaces([2]any{3, 5}, [2]any{4, 5}))
+		mock.ExpectRollback()
+
+		require.ErrorIs(t, store.RelabelTasks(context.Background(), []int{1, 2}, []int{3, 4}, nil), labelModel.ErrOtherWorkspace)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Trashed task", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(tasks).WithArgs(1, 2).WillReturnRows(workspaces([2]any{1, 5}))
+		mock.ExpectRollback()
+
+		require.ErrorIs(t, store.RelabelTasks(context.Background(), []int{1, 2}, nil, []int{9}), labelModel.ErrUnknownTarget)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Unknown label", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(tasks).WithArgs(1, 2).WillReturnRows(workspaces([2]any{1, 5}, [2]any{2, 5}))
+		mock.ExpectQuery(labels).WithArgs(3, 4).WillReturnRows(workspaces([2]any{3, 5}))
+		mock.ExpectRollback()
+
+		require.ErrorIs(t, store.RelabelTasks(context.Background(), []int{1, 2}, []int{3, 4}, []int{9}), labelModel.ErrUnknownTarget)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Label deleted meanwhile rolls back", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(tasks).WithArgs(1, 2).WillReturnRows(workspaces([2]any{1, 5}, [2]any{2, 5}))
+		mock.ExpectQuery(labels).WithArgs(3, 4).WillReturnRows(workspaces([2]any{3, 5}, [2]any{4, 5}))
+		mock.ExpectExec(remove).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(insert).WillReturnError(&mysql.MySQLError{Number: 1452, Message: "foreign key"})
+		mock.ExpectRollback()
+
+		require.ErrorIs(t, store.RelabelTasks(context.Background(), []int{1, 2}, []int{3, 4}, []int{9}), labelModel.ErrUnknownTarget)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Remove failure rolls back", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(tasks).WithArgs(1, 2).WillReturnRows(workspaces([2]any{1, 5}, [2]any{2, 5}))
+		mock.ExpectExec(remove).WillReturnError(sql.ErrConnDone)
+		mock.ExpectRollback()
+
+		require.ErrorIs(t, store.RelabelTasks(context.Background(), []int{1, 2}, nil, []int{9}), sql.ErrConnDone)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Begin Error", func(t *testing.T) {
+		mock.ExpectBegin().WillReturnError(sql.ErrConnDone)
+
+		require.Error(t, store.RelabelTasks(context.Background(), []int{1}, []int{2}, nil))
+	})
+}
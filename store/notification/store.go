@@ -0,0 +1,123 @@
+package notification
+
+import (
+	"Task_Manager/metrics"
+	"Task_Manager/model/notification"
+	"context"
+	"database/sql"
+	"strings"
+)
+
+// observe times every store method
+var observe = metrics.QueryTimer("notification")
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+// GetPreferencesNotification returns the stored preferences of a user, or sql.ErrNoRows when there are none
+func (s *Store) GetPreferencesNotification(ctx context.Context, userID int) (notification.Preferences, error) {
+	defer observe("GetPreferencesNotification")()
+
+	p := notification.Preferences{UserID: userID}
+
+	err := s.db.QueryRowContext(ctx, "SELECT task_assigned, task_completed FROM notification_preferences WHERE user_id = ?", userID).
+		Scan(&p.TaskAssigned, &p.TaskCompleted)
+
+	return p, err
+}
+
+// SavePreferencesNotification inserts or replaces the preferences of a user
+func (s *Store) SavePreferencesNotification(ctx context.Context, p notification.Preferences) error {
+	defer observe("SavePreferencesNotification")()
+
+	_, err := s.db.ExecContext(ctx, "INSERT INTO notification_preferences (user_id, task_assigned, task_completed) VALUES (?, ?, ?) "+
+		"ON DUPLICATE KEY UPDATE task_assigned = VALUES(task_assigned), task_completed = VALUES(task_completed)",
+		p.UserID, p.TaskAssigned, p.TaskCompleted)
+
+	return err
+}
+
+// QueueNotification keeps an event for the next digest of its recipient
+func (s *Store) QueueNotification(ctx context.Context, e notification.Event) error {
+	defer observe("QueueNotification")()
+
+	var actor, due any
+	if e.ActorID != 0 {
+		actor = e.ActorID
+	}
+
+	if e.Task.Due != nil {
+		due = *e.Task.Due
+	}
+
+	_, err := s.db.ExecContext(ctx, "INSERT INTO notification_digest (user_id, kind, actor_id, task_id, description, status, due_at, at) "+
+		"VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		e.UserID, e.Kind, actor, e.Task.ID, e.Task.Desc, e.Task.Status, due, e.At)
+
+	return err
+}
+
+// GetPendingNotification returns every queued event of the first users recipients after user ID after,
+// grouped by recipient and oldest first within each. A recipient's events are never split across calls.
+func (s *Store) GetPendingNotification(ctx context.Context, after, users int) ([]notification.Event, error) {
+	defer observe("GetPendingNotification")()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT n.id, n.user_id, n.kind, n.actor_id, n.task_id, n.description, n.status, n.due_at, n.at "+
+		"FROM notification_digest n JOIN (SELECT DISTINCT user_id FROM notification_digest WHERE user_id > ? ORDER BY user_id LIMIT ?) r "+
+		"ON r.user_id = n.user_id ORDER BY n.user_id, n.id", after, users)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	var events []notification.Event
+
+	for rows.Next() {
+		var (
+			e     notification.Event
+			actor sql.NullInt64
+			due   sql.NullTime
+		)
+
+		if err := rows.Scan(&e.ID, &e.UserID, &e.Kind, &actor, &e.Task.ID, &e.Task.Desc, &e.Task.Status, &due, &e.At); err != nil {
+			return nil, err
+		}
+
+		e.ActorID = int(actor.Int64)
+		e.Task.Userid = e.UserID
+
+		if due.Valid {
+			e.Task.Due = &due.Time
+		}
+
+		events = append(events, e)
+	}
+
+	return events, rows.Err()
+}
+
+// DeleteNotification removes queued events once their digest has been sent
+func (s *Store) DeleteNotification(ctx context.Context, ids ...int64) error {
+	defer observe("DeleteNotification")()
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+
+	_, err := s.db.ExecContext(ctx, "DELETE FROM notification_digest WHERE id IN ("+strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")+")", args...)
+
+	return err
+}
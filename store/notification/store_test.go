@@ -0,0 +1,133 @@
+package notification
+
+import (
+	"Task_Manager/model/notification"
+	"Task_Manager/model/task"
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func setup(t *testing.T) (*Store, sqlmock.Sqlmock, func()) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	cleanup := func() { _ = db.Close() }
+	return NewStore(db), mock, cleanup
+}
+
+func Test_GetPreferencesNotification(t *testing.T) {
+	query := regexp.QuoteMeta("SELECT task_assigned, task_completed FROM notification_preferences WHERE user_id = ?")
+
+	t.Run("Stored", func(t *testing.T) {
+		store, mock, cleanup := setup(t)
+		defer cleanup()
+
+		mock.ExpectQuery(query).WithArgs(4).
+			WillReturnRows(sqlmock.NewRows([]string{"task_assigned", "task_completed"}).AddRow("digest", "off"))
+
+		p, err := store.GetPreferencesNotification(context.Background(), 4)
+		require.NoError(t, err)
+		require.Equal(t, notification.Preferences{UserID: 4, TaskAssigned: notification.DeliveryDigest,
+			TaskCompleted: notification.DeliveryOff}, p)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Unset", func(t *testing.T) {
+		store, mock, cleanup := setup(t)
+		defer cleanup()
+
+		mock.ExpectQuery(query).WithArgs(4).WillReturnError(sql.ErrNoRows)
+
+		_, err := store.GetPreferencesNotification(context.Background(), 4)
+		require.ErrorIs(t, err, sql.ErrNoRows)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func Test_SavePreferencesNotification(t *testing.T) {
+	store, mock, cleanup := setup(t)
+	defer cleanup()
+
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO notification_preferences (user_id, task_assigned, task_completed) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE")).
+		WithArgs(4, notification.DeliveryDigest, notification.DeliveryImmediate).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := store.SavePreferencesNotification(context.Background(), notification.Preferences{UserID: 4, TaskAssigned: notification.DeliveryDigest,
+		TaskCompleted: notification.DeliveryImmediate})
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func Test_QueueNotification(t *testing.T) {
+	insert := regexp.QuoteMeta("INSERT INTO notification_digest (user_id, kind, actor_id, task_id, description, status, due_at, at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)")
+	at := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	due := at.Add(48 * time.Hour)
+
+	tests := []struct {
+		name  string
+		event notification.Event
+		actor any
+		due   any
+	}{
+		{"With actor and due date", notification.Event{Kind: notification.KindTaskAssigned, UserID: 4, ActorID: 2,
+			Task: task.Task{ID: 9, Desc: "Ship", Userid: 4, Due: &due}, At: at}, 2, due},
+		{"Unknown actor", notification.Event{Kind: notification.KindTaskCompleted, UserID: 4,
+			Task: task.Task{ID: 9, Desc: "Ship", Status: true, Userid: 4}, At: at}, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, mock, cleanup := setup(t)
+			defer cleanup()
+
+			mock.ExpectExec(insert).
+				WithArgs(4, tt.event.Kind, tt.actor, 9, "Ship", tt.event.Task.Status, tt.due, at).
+				WillReturnResult(sqlmock.NewResult(1, 1))
+
+			require.NoError(t, store.QueueNotification(context.Background(), tt.event))
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_GetPendingNotification(t *testing.T) {
+	store, mock, cleanup := setup(t)
+	defer cleanup()
+
+	at := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	due := at.Add(24 * time.Hour)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT n.id, n.user_id, n.kind, n.actor_id, n.task_id, n.description, n.status, n.due_at, n.at "+
+		"FROM notification_digest n JOIN (SELECT DISTINCT user_id FROM notification_digest WHERE user_id > ? ORDER BY user_id LIMIT ?) r "+
+		"ON r.user_id = n.user_id ORDER BY n.user_id, n.id")).
+		WithArgs(3, 100).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "kind", "actor_id", "task_id", "description", "status", "due_at", "at"}).
+			AddRow(1, 4, "task_assigned", 2, 9, "Ship", false, due, at).
+			AddRow(2, 4, "task_completed", nil, 10, "Test", true, nil, at))
+
+	events, err := store.GetPendingNotification(context.Background(), 3, 100)
+	require.NoError(t, err)
+	require.Equal(t, []notification.Event{
+		{ID: 1, Kind: notification.KindTaskAssigned, UserID: 4, ActorID: 2, Task: task.Task{ID: 9, Desc: "Ship", Userid: 4, Due: &due}, At: at},
+		{ID: 2, Kind: notification.KindTaskCompleted, UserID: 4, Task: task.Task{ID: 10, Desc: "Test", Status: true, Userid: 4}, At: at},
+	}, events)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func Test_DeleteNotification(t *testing.T) {
+	store, mock, cleanup := setup(t)
+	defer cleanup()
+
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM notification_digest WHERE id IN (?, ?)")).
+		WithArgs(int64(1), int64(2)).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	require.NoError(t, store.DeleteNotification(context.Background(), 1, 2))
+	require.NoError(t, store.DeleteNotification(context.Background()))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
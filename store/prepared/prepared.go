@@ -0,0 +1,224 @@
+// Package prepared keeps the statements of a store prepared, instead of parsing their SQL on each call.
+// Run with arguments and without a prepared statement, the MySQL driver prepares the statement, runs it
+// and closes it again, three commands where a prepared statement takes one.
+//
+// A Registry is created with its store and prepares each statement the first time it runs, once per
+// database. database/sql then prepares it again on every connection it runs on, including connections
+// opened after one is lost, so statements outlive connection failures. Only statements with fixed SQL
+// should go through the registry: each is kept open on the server for as long as the registry lives.
+package prepared
+
+import (
+	"Task_Manager/store/uow"
+	"context"
+	"database/sql"
+	"sync"
+)
+
+// MaxStatements caps the statements of a registry, across databases. Past it statements run unprepared;
+// the server limits the prepared statements of all its connections together.
+const MaxStatements = 200
+
+// Registry holds the prepared statements of a store
+type Registry struct {
+	// primary is the database the transactions of the store run on
+	primary *sql.DB
+
+	mu    sync.RWMutex
+	stmts map[key]*sql.Stmt
+	// missed are the statements run unprepared in transactions, to prepare once they end
+	missed map[string]bool
+}
+
+type key struct {
+	db    *sql.DB
+	query string
+}
+
+func New(primary *sql.DB) *Registry {
+	return &Registry{primary: primary, stmts: make(map[key]*sql.Stmt), missed: make(map[string]bool)}
+}
+
+// On returns conn running its statements prepared. conn is what uow.Conn, uow.Begin or a replica set
+// returned; anything else is returned as is. A nil registry returns conn.
+func (r *Registry) On(conn uow.DB) uow.DB {
+	if r == nil {
+		return conn
+	}
+
+	switch c := conn.(type) {
+	case *sql.DB:
+		return dbConn{r: r, db: c}
+	case *sql.Tx:
+		return txConn{r: r, tx: c}
+	case *uow.Tx:
+		return txConn{r: r, tx: c.Tx}
+	default:
+		return conn
+	}
+}
+
+// Len is the number of statements prepared
+func (r *Registry) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return len(r.stmts)
+}
+
+// Close closes the prepared statements
+func (r *Registry) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var first error
+
+	for k, st := range r.stmts {
+		if err := st.Close(); err != nil && first == nil {
+			first = err
+		}
+
+		delete(r.stmts, k)
+	}
+
+	return first
+}
+
+// stmt returns query prepared on db, nil when it cannot be prepared and must run as is. A failure is
+// not remembered: the statement is prepared again on its next run.
+func (r *Registry) stmt(ctx context.Context, db *sql.DB, query string) *sql.Stmt {
+	k := key{db: db, query: query}
+
+	r.mu.RLock()
+	st, ok := r.stmts[k]
+	full := len(r.stmts) >= MaxStatements
+	r.mu.RUnlock()
+
+	if ok {
+		return st
+	}
+
+	if full {
+		return nil
+	}
+
+	// Prepared without the lock, so a slow server does not hold up the statements already prepared
+	st, err := db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.stmts[k]; ok {
+		_ = st.Close()
+		return existing
+	}
+
+	if len(r.stmts) >= MaxStatements {
+		_ = st.Close()
+		return nil
+	}
+
+	r.stmts[k] = st
+
+	return st
+}
+
+type dbConn struct {
+	r  *Registry
+	db *sql.DB
+}
+
+func (c dbConn) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	if st := c.r.stmt(ctx, c.db, query); st != nil {
+		return st.ExecContext(ctx, args...)
+	}
+
+	return c.db.ExecContext(ctx, query, args...)
+}
+
+func (c dbConn) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	if st := c.r.stmt(ctx, c.db, query); st != nil {
+		return st.QueryContext(ctx, args...)
+	}
+
+	return c.db.QueryContext(ctx, query, args...)
+}
+
+func (c dbConn) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	if st := c.r.stmt(ctx, c.db, query); st != nil {
+		return st.QueryRowContext(ctx, args...)
+	}
+
+	return c.db.QueryRowContext(ctx, query, args...)
+}
+
+// prepared returns query when it is prepared on the primary. Otherwise the transaction runs it as is:
+// preparing it now would take a second connection from the pool while the transaction holds one, and
+// with every connection held by such transactions none would get one. It is prepared by PrepareMissed.
+func (r *Registry) prepared(query string) *sql.Stmt {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	st, ok := r.stmts[key{db: r.primary, query: query}]
+	if !ok && len(r.stmts)+len(r.missed) < MaxStatements {
+		r.missed[query] = true
+	}
+
+	return st
+}
+
+// PrepareMissed prepares the statements transactions ran unprepared. It must not be called while a
+// transaction of the store holds a connection.
+func (r *Registry) PrepareMissed(ctx context.Context) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	queries := make([]string, 0, len(r.missed))
+
+	for q := range r.missed {
+		queries = append(queries, q)
+		delete(r.missed, q)
+	}
+	r.mu.Unlock()
+
+	for _, q := range queries {
+		r.stmt(ctx, r.primary, q)
+	}
+}
+
+// txConn runs the statements of the registry in a transaction of the primary. database/sql reuses the
+// statement when it is already prepared on the connection of the transaction, and prepares it there
+// otherwise; the statement of the transaction is closed when it ends.
+type txConn struct {
+	r  *Registry
+	tx *sql.Tx
+}
+
+func (c txConn) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	if st := c.r.prepared(query); st != nil {
+		return c.tx.StmtContext(ctx, st).ExecContext(ctx, args...)
+	}
+
+	return c.tx.ExecContext(ctx, query, args...)
+}
+
+func (c txConn) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	if st := c.r.prepared(query); st != nil {
+		return c.tx.StmtContext(ctx, st).QueryContext(ctx, args...)
+	}
+
+	return c.tx.QueryContext(ctx, query, args...)
+}
+
+func (c txConn) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	if st := c.r.prepared(query); st != nil {
+		return c.tx.StmtContext(ctx, st).QueryRowContext(ctx, args...)
+	}
+
+	return c.tx.QueryRowContext(ctx, query, args...)
+}
@@ -0,0 +1,342 @@
+package prepared
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// server stands in for the MySQL server and its driver, counting the commands sent to it. Like the
+// MySQL driver, a statement run on the connection with arguments is refused with driver.ErrSkip, so
+// database/sql prepares it, runs it and closes it again. sqlmock would run such statements directly
+// and hide the cost the registry removes.
+type server struct {
+	mu                      sync.Mutex
+	prepares, execs, closes int
+	// epoch is bumped by kill; connections of earlier epochs are lost
+	epoch int
+}
+
+func (s *server) Open(string) (driver.Conn, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return &conn{s: s, epoch: s.epoch}, nil
+}
+
+func (s *server) count(n *int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	*n++
+}
+
+// kill drops every open connection, as a restart of the server would
+func (s *server) kill() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.epoch++
+}
+
+func (s *server) commands() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.prepares + s.execs + s.closes
+}
+
+type conn struct {
+	s     *server
+	epoch int
+}
+
+func (c *conn) lost() bool {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+
+	return c.epoch != c.s.epoch
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	if c.lost() {
+		return nil, driver.ErrBadConn
+	}
+
+	c.s.count(&c.s.prepares)
+
+	return &stmt{c: c}, nil
+}
+
+func (c *conn) QueryContext(context.Context, string, []driver.NamedValue) (driver.Rows, error) {
+	return nil, driver.ErrSkip
+}
+
+func (c *conn) ExecContext(context.Context, string, []driver.NamedValue) (driver.Result, error) {
+	return nil, driver.ErrSkip
+}
+
+func (c *conn) IsValid() bool { return !c.lost() }
+
+func (c *conn) Close() error { return nil }
+
+func (c *conn) Begin() (driver.Tx, error) { return c, nil }
+
+func (c *conn) Commit() error { return nil }
+
+func (c *conn) Rollback() error { return nil }
+
+type stmt struct {
+	c *conn
+}
+
+func (s *stmt) Close() error {
+	s.c.s.count(&s.c.s.closes)
+	return nil
+}
+
+func (s *stmt) NumInput() int { return -1 }
+
+func (s *stmt) Exec([]driver.Value) (driver.Result, error) {
+	if s.c.lost() {
+		return nil, driver.ErrBadConn
+	}
+
+	s.c.s.count(&s.c.s.execs)
+
+	return driver.RowsAffected(1), nil
+}
+
+func (s *stmt) Query([]driver.Value) (driver.Rows, error) {
+	if s.c.lost() {
+		return nil, driver.ErrBadConn
+	}
+
+	s.c.s.count(&s.c.s.execs)
+
+	return &rows{}, nil
+}
+
+// rows is one task
+type rows struct {
+	done bool
+}
+
+func (r *rows) Columns() []string { return []string{"id", "description"} }
+
+func (r *rows) Close() error { return nil }
+
+func (r *rows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+
+	r.done = true
+	dest[0], dest[1] = int64(1), "Do homework"
+
+	return nil
+}
+
+func (s *server) Driver() driver.Driver { return s }
+
+func (s *server) Connect(context.Context) (driver.Conn, error) { return s.Open("") }
+
+func open(t testing.TB) (*sql.DB, *server) {
+	s := &server{}
+	db := sql.OpenDB(s)
+	t.Cleanup(func() { _ = db.Close() })
+
+	return db, s
+}
+
+const query = "SELECT id, description FROM tasks WHERE id = ? AND deleted_at IS NULL"
+
+func get(t testing.TB, conn interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}) {
+	var (
+		id   int
+		desc string
+	)
+
+	require.NoError(t, conn.QueryRowContext(context.Background(), query, 1).Scan(&id, &desc))
+	require.Equal(t, "Do homework", desc)
+}
+
+func Test_Registry(t *testing.T) {
+	t.Run("Without a registry each run prepares and closes", func(t *testing.T) {
+		db, s := open(t)
+
+		var r *Registry
+		for range 3 {
+			get(t, r.On(db))
+		}
+
+		require.Equal(t, 3, s.prepares)
+		require.Equal(t, 3, s.closes)
+	})
+
+	t.Run("Statements are prepared once", func(t *testing.T) {
+		db, s := open(t)
+		db.SetMaxOpenConns(1)
+
+		r := New(db)
+		for range 3 {
+			get(t, r.On(db))
+		}
+
+		require.Equal(t, 1, s.prepares)
+		require.Equal(t, 3, s.execs)
+		require.Zero(t, s.closes)
+		require.Equal(t, 1, r.Len())
+
+		require.NoError(t, r.Close())
+		require.Equal(t, 1, s.closes)
+		require.Zero(t, r.Len())
+	})
+
+	t.Run("Statements are prepared again after the connection is lost", func(t *testing.T) {
+		db, s := open(t)
+		db.SetMaxOpenConns(1)
+
+		r := New(db)
+		get(t, r.On(db))
+
+		s.kill()
+		get(t, r.On(db))
+
+		require.Equal(t, 2, s.prepares)
+		require.Equal(t, 1, r.Len())
+	})
+
+	t.Run("Transactions reuse the statement of their connection", func(t *testing.T) {
+		db, s := open(t)
+		db.SetMaxOpenConns(1)
+
+		r := New(db)
+		get(t, r.On(db))
+
+		tx, err := db.Begin()
+		require.NoError(t, err)
+
+		for range 2 {
+			get(t, r.On(tx))
+		}
+
+		require.Equal(t, 1, s.prepares)
+
+		// A statement not prepared yet runs as is, and is prepared once the transaction has released its
+		// connection, the only one of the pool
+		update := func(tx *sql.Tx) {
+			_, err := r.On(tx).ExecContext(context.Background(), "UPDATE tasks SET status = true WHERE id = ?", 1)
+			require.NoError(t, err)
+		}
+
+		update(tx)
+		require.Equal(t, 2, s.prepares)
+		require.Equal(t, 1, s.closes)
+		require.NoError(t, tx.Commit())
+		r.PrepareMissed(context.Background())
+		require.Equal(t, 2, r.Len())
+
+		tx, err = db.Begin()
+		require.NoError(t, err)
+		update(tx)
+		require.NoError(t, tx.Commit())
+
+		require.Equal(t, 3, s.prepares)
+		require.Equal(t, 1, s.closes)
+	})
+
+	t.Run("Past the cap statements run unprepared", func(t *testing.T) {
+		db, s := open(t)
+		r := New(db)
+
+		for i := range MaxStatements + 1 {
+			_, err := r.On(db).ExecContext(context.Background(), "UPDATE tasks SET status = true WHERE id = "+strconv.Itoa(i)+" AND ?", true)
+			require.NoError(t, err)
+		}
+
+		require.Equal(t, MaxStatements, r.Len())
+		require.Equal(t, 1, s.closes)
+	})
+
+	t.Run("Unknown connections are returned as is", func(t *testing.T) {
+		db, _ := open(t)
+		var conn other
+
+		require.Equal(t, conn, New(db).On(conn))
+	})
+}
+
+type other struct{}
+
+func (other) ExecContext(context.Context, string, ...any) (sql.Result, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (other) QueryContext(context.Context, string, ...any) (*sql.Rows, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (other) QueryRowContext(context.Context, string, ...any) *sql.Row { return nil }
+
+// BenchmarkQueryRow compares running a lookup as the stores did, unprepared, with running it through
+// the registry. commands/op is what each lookup sends the server; against a real server each costs
+// about a network round trip, which dwarfs the time measured here.
+func BenchmarkQueryRow(b *testing.B) {
+	for _, bc := range []struct {
+		name     string
+		registry bool
+	}{
+		{name: "unprepared"},
+		{name: "prepared", registry: true},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			db, s := open(b)
+
+			var r *Registry
+			if bc.registry {
+				r = New(db)
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for range b.N {
+				get(b, r.On(db))
+			}
+
+			b.ReportMetric(float64(s.commands())/float64(b.N), "commands/op")
+		})
+
+		b.Run(bc.name+"/parallel", func(b *testing.B) {
+			db, s := open(b)
+			db.SetMaxOpenConns(8)
+			db.SetMaxIdleConns(8)
+
+			var r *Registry
+			if bc.registry {
+				r = New(db)
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					get(b, r.On(db))
+				}
+			})
+
+			b.ReportMetric(float64(s.commands())/float64(b.N), "commands/op")
+		})
+	}
+}
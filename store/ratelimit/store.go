@@ -0,0 +1,77 @@
+package ratelimit
+
+import (
+	"Task_Manager/metrics"
+	"Task_Manager/model/ratelimit"
+	"context"
+	"database/sql"
+	"time"
+)
+
+// observe times every store method
+var observe = metrics.QueryTimer("ratelimit")
+
+// Store keeps the token buckets in the database, so that every instance of the API shares them
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+// TakeRateLimit takes a token from the bucket of key. The bucket row is locked for the duration of the
+// transaction, so concurrent requests of the same client, on any instance, take their turn.
+func (s *Store) TakeRateLimit(ctx context.Context, key string, l ratelimit.Limit, now time.Time) (d ratelimit.Decision, err error) {
+	defer observe("TakeRateLimit")()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return d, err
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	// New buckets start full, which a zero Bucket stands for
+	if _, err = tx.ExecContext(ctx, "INSERT IGNORE INTO rate_limit_buckets (bucket_key, tokens, updated_at) VALUES (?, 0, NULL)", key); err != nil {
+		return d, err
+	}
+
+	var (
+		b  ratelimit.Bucket
+		at sql.NullTime
+	)
+
+	err = tx.QueryRowContext(ctx, "SELECT tokens, updated_at FROM rate_limit_buckets WHERE bucket_key = ? FOR UPDATE", key).Scan(&b.Tokens, &at)
+	if err != nil {
+		return d, err
+	}
+
+	b.At = at.Time
+	d = b.Take(l, now.UTC())
+
+	if _, err = tx.ExecContext(ctx, "UPDATE rate_limit_buckets SET tokens = ?, updated_at = ? WHERE bucket_key = ?", b.Tokens, b.At, key); err != nil {
+		return d, err
+	}
+
+	return d, tx.Commit()
+}
+
+// DeleteIdleRateLimit removes the buckets unused since before, which have filled up again when before
+// is at least the longest refill time of the limits
+func (s *Store) DeleteIdleRateLimit(ctx context.Context, before time.Time) (int, error) {
+	defer observe("DeleteIdleRateLimit")()
+
+	res, err := s.db.ExecContext(ctx, "DELETE FROM rate_limit_buckets WHERE updated_at < ?", before.UTC())
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := res.RowsAffected()
+
+	return int(n), err
+}
@@ -0,0 +1,89 @@
+package ratelimit
+
+import (
+	"Task_Manager/model/ratelimit"
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func setup(t *testing.T) (*Store, sqlmock.Sqlmock, func()) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	cleanup := func() { _ = db.Close() }
+	return NewStore(db), mock, cleanup
+}
+
+func Test_TakeRateLimit(t *testing.T) {
+	insert := regexp.QuoteMeta("INSERT IGNORE INTO rate_limit_buckets (bucket_key, tokens, updated_at) VALUES (?, 0, NULL)")
+	lock := regexp.QuoteMeta("SELECT tokens, updated_at FROM rate_limit_buckets WHERE bucket_key = ? FOR UPDATE")
+	update := regexp.QuoteMeta("UPDATE rate_limit_buckets SET tokens = ?, updated_at = ? WHERE bucket_key = ?")
+
+	limit := ratelimit.Limit{Requests: 1, Per: time.Second, Burst: 3}
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	key := "POST /task|user:7"
+
+	tests := []struct {
+		name       string
+		tokens     float64
+		at         any
+		expTokens  float64
+		expAllowed bool
+	}{
+		{"New bucket starts full", 0, nil, 2, true},
+		{"Refills for the time elapsed", 0.5, now.Add(-time.Second), 0.5, true},
+		{"Empty bucket denies", 0.25, now, 0.25, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, mock, cleanup := setup(t)
+			defer cleanup()
+
+			mock.ExpectBegin()
+			mock.ExpectExec(insert).WithArgs(key).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectQuery(lock).WithArgs(key).
+				WillReturnRows(sqlmock.NewRows([]string{"tokens", "updated_at"}).AddRow(tt.tokens, tt.at))
+			mock.ExpectExec(update).WithArgs(tt.expTokens, now, key).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+
+			d, err := store.TakeRateLimit(context.Background(), key, limit, now)
+			require.NoError(t, err)
+			require.Equal(t, tt.expAllowed, d.Allowed)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+
+	t.Run("Failure rolls back", func(t *testing.T) {
+		store, mock, cleanup := setup(t)
+		defer cleanup()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(insert).WithArgs(key).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(lock).WithArgs(key).WillReturnError(errors.New("lock wait timeout"))
+		mock.ExpectRollback()
+
+		_, err := store.TakeRateLimit(context.Background(), key, limit, now)
+		require.Error(t, err)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func Test_DeleteIdleRateLimit(t *testing.T) {
+	store, mock, cleanup := setup(t)
+	defer cleanup()
+
+	before := time.Date(2024, 5, 1, 11, 0, 0, 0, time.UTC)
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM rate_limit_buckets WHERE updated_at < ?")).
+		WithArgs(before).WillReturnResult(sqlmock.NewResult(0, 4))
+
+	n, err := store.DeleteIdleRateLimit(context.Background(), before)
+	require.NoError(t, err)
+	require.Equal(t, 4, n)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,198 @@
+// Package replica routes the read-only statements of the stores to read replicas of the database. A
+// store runs its reads on Set.Conn(ctx, db): a healthy replica, taken in turn, or db itself when none is
+// healthy, inside a unit of work, or when ctx is pinned to the primary because the caller must see its
+// own writes, which the replicas may not have applied yet.
+package replica
+
+import (
+	"Task_Manager/metrics"
+	"Task_Manager/store/uow"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+var reads = metrics.Default.NewCounterVec("db_reads_total",
+	"Read-only store statements by the database they were routed to: a replica, or the primary", "target")
+
+type pinKey struct{}
+
+// Primary returns a copy of ctx whose reads all go to the primary
+func Primary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, pinKey{}, true)
+}
+
+// Pinned reports whether the reads of ctx go to the primary
+func Pinned(ctx context.Context) bool {
+	pinned, _ := ctx.Value(pinKey{}).(bool)
+	return pinned
+}
+
+// Set is the read replicas of the primary database. Replicas start unhealthy, and are only read once
+// Check has found them up and close enough to the primary.
+type Set struct {
+	replicas []*member
+	next     atomic.Uint64
+	maxLag   time.Duration
+}
+
+type member struct {
+	name    string
+	db      *sql.DB
+	healthy atomic.Bool
+	// lag is the replication delay seen by the last check, in seconds
+	lag atomic.Int64
+}
+
+// New returns an empty set, whose replicas may lag up to maxLag behind the primary
+func New(maxLag time.Duration) *Set {
+	return &Set{maxLag: maxLag}
+}
+
+// Add adds a replica to the set. It must be called before the set is used.
+func (s *Set) Add(name string, db *sql.DB) {
+	s.replicas = append(s.replicas, &member{name: name, db: db})
+}
+
+// Conn is what a store runs a read-only statement on. A nil set reads primary, as Conn of uow does.
+func (s *Set) Conn(ctx context.Context, primary *sql.DB) uow.DB {
+	if s == nil || len(s.replicas) == 0 || uow.Active(ctx) || Pinned(ctx) {
+		return uow.Conn(ctx, primary)
+	}
+
+	if m := s.pick(); m != nil {
+		reads.Inc(m.name)
+		return m.db
+	}
+
+	reads.Inc("primary")
+
+	return primary
+}
+
+// pick returns the next healthy replica, nil when there is none
+func (s *Set) pick() *member {
+	start := s.next.Add(1)
+
+	for i := range uint64(len(s.replicas)) {
+		m := s.replicas[(start+i)%uint64(len(s.replicas))]
+		if m.healthy.Load() {
+			return m
+		}
+	}
+
+	return nil
+}
+
+// Check pings every replica and reads how far behind the primary it is. A replica is healthy when it
+// answers, replicates, and lags at most maxLag. The error lists the replicas found unhealthy.
+func (s *Set) Check(ctx context.Context) error {
+	var errs []error
+
+	for _, m := range s.replicas {
+		lag, err := m.check(ctx)
+		if err == nil && lag > s.maxLag {
+			err = fmt.Errorf("lagging %s behind the primary", lag)
+		}
+
+		m.healthy.Store(err == nil)
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("replica %s: %w", m.name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (m *member) check(ctx context.Context) (time.Duration, error) {
+	if err := m.db.PingContext(ctx); err != nil {
+		return 0, err
+	}
+
+	rows, err := m.db.QueryContext(ctx, "SHOW REPLICA STATUS")
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	lag, err := secondsBehind(rows)
+	if err != nil {
+		return 0, err
+	}
+
+	m.lag.Store(lag)
+
+	return time.Duration(lag) * time.Second, nil
+}
+
+// secondsBehind reads the Seconds_Behind_Source column of the replica status. It is NULL while
+// replication is stopped, and there is no row at all on a server that does not replicate.
+func secondsBehind(rows *sql.Rows) (int64, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return 0, err
+		}
+
+		return 0, errors.New("not replicating")
+	}
+
+	values := make([]sql.RawBytes, len(columns))
+	dest := make([]any, len(columns))
+
+	for i := range values {
+		dest[i] = &values[i]
+	}
+
+	if err := rows.Scan(dest...); err != nil {
+		return 0, err
+	}
+
+	for i, c := range columns {
+		if c != "Seconds_Behind_Source" {
+			continue
+		}
+
+		if values[i] == nil {
+			return 0, errors.New("replication stopped")
+		}
+
+		return strconv.ParseInt(string(values[i]), 10, 64)
+	}
+
+	return 0, errors.New("no Seconds_Behind_Source in the replica status")
+}
+
+// Register exposes the health and the replication delay of every replica
+func (s *Set) Register(reg *metrics.Registry) {
+	reg.NewGaugeFunc("db_replica_healthy", "Whether the replica is read from: 1 when healthy, 0 otherwise", []string{"replica"},
+		func(emit metrics.Emit) error {
+			for _, m := range s.replicas {
+				healthy := 0.0
+				if m.healthy.Load() {
+					healthy = 1
+				}
+
+				emit(healthy, m.name)
+			}
+
+			return nil
+		})
+	reg.NewGaugeFunc("db_replica_lag_seconds", "Replication delay of the replica seen by the last health check", []string{"replica"},
+		func(emit metrics.Emit) error {
+			for _, m := range s.replicas {
+				emit(float64(m.lag.Load()), m.name)
+			}
+
+			return nil
+		})
+}
@@ -0,0 +1,126 @@
+package replica
+
+import (
+	"Task_Manager/store/uow"
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func newDB(t *testing.T) (*sql.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	return db, mock
+}
+
+// expectStatus expects a health check of a replica answering with the given delay
+func expectStatus(mock sqlmock.Sqlmock, seconds any) {
+	mock.ExpectPing()
+	mock.ExpectQuery("SHOW REPLICA STATUS").
+		WillReturnRows(sqlmock.NewRows([]string{"Replica_IO_State", "Source_Host", "Seconds_Behind_Source"}).
+			AddRow("Waiting for source to send event", "db1", seconds))
+}
+
+func Test_Conn(t *testing.T) {
+	ctx := context.Background()
+	primary, _ := newDB(t)
+
+	t.Run("Without replicas", func(t *testing.T) {
+		var s *Set
+		require.Same(t, primary, s.Conn(ctx, primary))
+		require.Same(t, primary, New(time.Second).Conn(ctx, primary))
+	})
+
+	first, firstMock := newDB(t)
+	second, secondMock := newDB(t)
+
+	s := New(5 * time.Second)
+	s.Add("db2", first)
+	s.Add("db3", second)
+
+	t.Run("Replicas start unhealthy", func(t *testing.T) {
+		require.Same(t, primary, s.Conn(ctx, primary))
+	})
+
+	expectStatus(firstMock, 0)
+	expectStatus(secondMock, 1)
+	require.NoError(t, s.Check(ctx))
+
+	t.Run("Healthy replicas are read in turn", func(t *testing.T) {
+		a, b := s.Conn(ctx, primary), s.Conn(ctx, primary)
+		require.NotSame(t, primary, a)
+		require.NotSame(t, primary, b)
+		require.NotEqual(t, a, b)
+		require.Equal(t, a, s.Conn(ctx, primary))
+	})
+
+	t.Run("Pinned reads go to the primary", func(t *testing.T) {
+		require.Same(t, primary, s.Conn(Primary(ctx), primary))
+	})
+
+	t.Run("Units of work read their transaction", func(t *testing.T) {
+		db, mock := newDB(t)
+		mock.ExpectBegin()
+		mock.ExpectCommit()
+
+		err := uow.New(db).WithTx(ctx, func(ctx context.Context) error {
+			require.Equal(t, uow.Conn(ctx, db), s.Conn(ctx, db))
+			return nil
+		})
+		require.NoError(t, err)
+	})
+
+	expectStatus(firstMock, 0)
+	expectStatus(secondMock, 30)
+	require.ErrorContains(t, s.Check(ctx), "replica db3: lagging 30s behind the primary")
+
+	t.Run("Lagging replicas are skipped", func(t *testing.T) {
+		for range 3 {
+			require.Same(t, first, s.Conn(ctx, primary))
+		}
+	})
+
+	firstMock.ExpectPing().WillReturnError(errors.New("connection refused"))
+	expectStatus(secondMock, nil)
+	err := s.Check(ctx)
+	require.ErrorContains(t, err, "replica db2: connection refused")
+	require.ErrorContains(t, err, "replica db3: replication stopped")
+
+	t.Run("Without healthy replicas reads go to the primary", func(t *testing.T) {
+		require.Same(t, primary, s.Conn(ctx, primary))
+	})
+
+	require.NoError(t, firstMock.ExpectationsWereMet())
+	require.NoError(t, secondMock.ExpectationsWereMet())
+}
+
+func Test_Check(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Not replicating", func(t *testing.T) {
+		db, mock := newDB(t)
+		mock.ExpectPing()
+		mock.ExpectQuery("SHOW REPLICA STATUS").WillReturnRows(sqlmock.NewRows([]string{"Seconds_Behind_Source"}))
+
+		s := New(time.Second)
+		s.Add("db2", db)
+		require.ErrorContains(t, s.Check(ctx), "not replicating")
+	})
+
+	t.Run("Status error", func(t *testing.T) {
+		db, mock := newDB(t)
+		mock.ExpectPing()
+		mock.ExpectQuery("SHOW REPLICA STATUS").WillReturnError(errors.New("access denied"))
+
+		s := New(time.Second)
+		s.Add("db2", db)
+		require.ErrorContains(t, s.Check(ctx), "access denied")
+	})
+}
@@ -1,59 +1,113 @@
 package task
 
 import (
+	"Task_Manager/metrics"
 	"Task_Manager/model/task"
+	"Task_Manager/store/prepared"
+	"Task_Manager/store/replica"
+	"Task_Manager/store/uow"
+	"context"
 	"database/sql"
+	"strings"
+	"time"
 )
 
+// observe times every store method
+var observe = metrics.QueryTimer("task")
+
 type Store struct {
-	db *sql.DB
+	db       *sql.DB
+	replicas *replica.Set
+	// stmts keeps the statements with fixed SQL prepared; those built from their arguments run as is
+	stmts *prepared.Registry
 }
 
 func NewStore(db *sql.DB) *Store {
-	return &Store{db: db}
+	return &Store{db: db, stmts: prepared.New(db)}
 }
 
-// CreateTask inserts a new task into the database
-func (s *Store) CreateTask(t task.Task) (task.Task, error) {
-	res, err := s.db.Exec("INSERT INTO tasks (description, status,userid) VALUES (?, ?,?)", t.Desc, t.Status, t.Userid)
+// SetReplicas routes the reads of the store to replicas of db
+func (s *Store) SetReplicas(r *replica.Set) {
+	s.replicas = r
+}
 
-	if err != nil {
-		return t, err
-	}
+// reader is what the read-only methods run their statements on
+func (s *Store) reader(ctx context.Context) uow.DB {
+	return s.stmts.On(s.replicas.Conn(ctx, s.db))
+}
 
-	id, err := res.LastInsertId()
-	if err != nil {
-		return t, err
+// taskColumns are the columns read into a task.Task by scanTask
+const taskColumns = "id, description, status, userid, due_at, workspace_id"
+
+const revisionColumns = "SELECT task_id, revision, at, deleted, description, status, userid, due_at FROM task_revisions"
+
+// snapshotQuery copies the current state of tasks into task_revisions, numbering each copy after the latest revision of its task
+const snapshotQuery = "INSERT INTO task_revisions (task_id, revision, at, deleted, description, status, userid, due_at) " +
+	"SELECT t.id, (SELECT COALESCE(MAX(r.revision), 0) + 1 FROM task_revisions r WHERE r.task_id = t.id), ?, t.deleted_at IS NOT NULL, " +
+	"t.description, t.status, t.userid, t.due_at FROM tasks t WHERE t.id IN ("
+
+// Snapshot records a revision of each of the given tasks as part of tx. Every statement that changes a task
+// must be followed by a snapshot in the same transaction, so the revision history never misses a change.
+func Snapshot(ctx context.Context, tx uow.DB, at time.Time, ids ...int) error {
+	if len(ids) == 0 {
+		return nil
 	}
 
-	t.ID = int(id)
+	_, err := tx.ExecContext(ctx, snapshotQuery+placeholders(len(ids))+")", append([]any{at}, intArgs(ids)...)...)
 
-	return t, nil
+	return err
+}
 
+// SnapshotTask records a revision of each of the given tasks, for changes made to them by other stores. It
+// runs in the unit of work of ctx, which must be the one that made the change.
+func (s *Store) SnapshotTask(ctx context.Context, ids ...int) error {
+	defer observe("SnapshotTask")()
+
+	return Snapshot(ctx, uow.Conn(ctx, s.db), time.Now().UTC(), ids...)
 }
 
-// GetByIDTask fetches a task by its ID
-func (s *Store) GetByIDTask(id int) (task.Task, error) {
-	var t task.Task
-	err := s.db.QueryRow("SELECT * FROM tasks WHERE id = ?", id).
-		Scan(&t.ID, &t.Desc, &t.Status, &t.Userid)
+// withRevision runs fn, which changes the task whose ID it returns, and the snapshot of that task in one transaction
+func (s *Store) withRevision(ctx context.Context, fn func(tx uow.DB) (int, error)) (err error) {
+	tx, err := uow.Begin(ctx, s.db)
+	if err != nil {
+		return err
+	}
 
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	conn := s.stmts.On(tx)
+
+	id, err := fn(conn)
 	if err != nil {
-		return t, err
+		return err
 	}
 
-	return t, err
+	if err = Snapshot(ctx, conn, time.Now().UTC(), id); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+
+	// Statements the transaction ran unprepared are prepared once it no longer holds a connection
+	uow.AfterCommit(ctx, func() { s.stmts.PrepareMissed(context.WithoutCancel(ctx)) })
+
+	return nil
 }
 
-// CompleteTask marks a task as completed
-func (s *Store) CompleteTask(id int) error {
-	res, err := s.db.Exec("UPDATE tasks SET status = true WHERE id = ?", id)
+// execOne runs an update that must change exactly one task, returning sql.ErrNoRows otherwise
+func execOne(ctx context.Context, tx uow.DB, query string, args ...any) error {
+	res, err := tx.ExecContext(ctx, query, args...)
 	if err != nil {
 		return err
 	}
 
 	affected, err := res.RowsAffected()
-
 	if err != nil {
 		return err
 	}
@@ -65,28 +119,150 @@ func (s *Store) CompleteTask(id int) error {
 	return nil
 }
 
-// DeleteTask removes a task by ID
-func (s *Store) DeleteTask(id int) error {
-	res, err := s.db.Exec("DELETE FROM tasks WHERE id = ?", id)
-	if err != nil {
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanRevision(row rowScanner) (task.Revision, error) {
+	var r task.Revision
+
+	err := row.Scan(&r.TaskID, &r.Number, &r.At, &r.Deleted, &r.Task.Desc, &r.Task.Status, (*nullID)(&r.Task.Userid), dueAt{&r.Task.Due})
+	r.Task.ID = r.TaskID
+
+	return r, err
+}
+
+func scanTask(row rowScanner) (task.Task, error) {
+	var t task.Task
+	err := row.Scan(&t.ID, &t.Desc, &t.Status, (*nullID)(&t.Userid), dueAt{&t.Due}, (*nullID)(&t.WorkspaceID))
+
+	return t, err
+}
+
+// nullableID stores a missing assignee or workspace as NULL
+func nullableID(id int) any {
+	if id == 0 {
+		return nil
+	}
+
+	return id
+}
+
+// nullID scans the nullable userid and workspace_id columns; tasks without an assignee or a workspace
+// read back as zero
+type nullID int
+
+func (a *nullID) Scan(src any) error {
+	var n sql.NullInt64
+	if err := n.Scan(src); err != nil {
 		return err
 	}
 
-	affected, err := res.RowsAffected()
-	if err != nil {
+	*a = nullID(n.Int64)
+
+	return nil
+}
+
+// nullableDue stores a missing due date as NULL
+func nullableDue(due *time.Time) any {
+	if due == nil {
+		return nil
+	}
+
+	return due.UTC()
+}
+
+// dueAt scans the nullable due_at column; tasks without a due date read back as nil
+type dueAt struct {
+	dst **time.Time
+}
+
+func (d dueAt) Scan(src any) error {
+	var n sql.NullTime
+	if err := n.Scan(src); err != nil {
 		return err
 	}
 
-	if affected == 0 {
-		return sql.ErrNoRows
+	*d.dst = nil
+	if n.Valid {
+		due := n.Time.UTC()
+		*d.dst = &due
 	}
 
 	return nil
 }
 
-// GetAllTask returns all tasks from the database
-func (s *Store) GetAllTask() ([]task.Task, error) {
-	rows, err := s.db.Query("SELECT id, description, status , userid FROM tasks")
+// CreateTask inserts a new task into the database
+func (s *Store) CreateTask(ctx context.Context, t task.Task) (task.Task, error) {
+	defer observe("CreateTask")()
+
+	err := s.withRevision(ctx, func(tx uow.DB) (int, error) {
+		res, err := tx.ExecContext(ctx, "INSERT INTO tasks (description, status, userid, due_at, workspace_id) VALUES (?, ?, ?, ?, ?)",
+			t.Desc, t.Status, nullableID(t.Userid), nullableDue(t.Due), nullableID(t.WorkspaceID))
+		if err != nil {
+			return 0, err
+		}
+
+		id, err := res.LastInsertId()
+		if err != nil {
+			return 0, err
+		}
+
+		t.ID = int(id)
+
+		return t.ID, nil
+	})
+
+	return t, err
+}
+
+// GetByIDTask fetches a task by its ID
+func (s *Store) GetByIDTask(ctx context.Context, id int) (task.Task, error) {
+	defer observe("GetByIDTask")()
+
+	t, err := scanTask(s.reader(ctx).QueryRowContext(ctx, "SELECT "+taskColumns+" FROM tasks WHERE id = ? AND deleted_at IS NULL", id))
+
+	if err != nil {
+		return t, err
+	}
+
+	return t, err
+}
+
+// ExistsTask reports whether a live task with the given description is assigned to the user
+func (s *Store) ExistsTask(ctx context.Context, desc string, userid int) (bool, error) {
+	defer observe("ExistsTask")()
+
+	var exists bool
+	err := s.reader(ctx).QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM tasks WHERE description = ? AND userid = ? AND deleted_at IS NULL)", desc, userid).
+		Scan(&exists)
+
+	return exists, err
+}
+
+// CompleteTask marks a task as completed
+func (s *Store) CompleteTask(ctx context.Context, id int) error {
+	defer observe("CompleteTask")()
+
+	return s.withRevision(ctx, func(tx uow.DB) (int, error) {
+		return id, execOne(ctx, tx, "UPDATE tasks SET status = true WHERE id = ? AND deleted_at IS NULL", id)
+	})
+}
+
+// DeleteTask moves a task to the trash; it stays restorable until purged
+func (s *Store) DeleteTask(ctx context.Context, id int) error {
+	defer observe("DeleteTask")()
+
+	return s.withRevision(ctx, func(tx uow.DB) (int, error) {
+		return id, execOne(ctx, tx, "UPDATE tasks SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL", time.Now().UTC(), id)
+	})
+}
+
+// GetAllTask returns all tasks that are not in the trash
+func (s *Store) GetAllTask(ctx context.Context) ([]task.Task, error) {
+	defer observe("GetAllTask")()
+
+	rows, err := s.reader(ctx).QueryContext(ctx, "SELECT "+taskColumns+" FROM tasks WHERE deleted_at IS NULL")
 	if err != nil {
 		return nil, err
 	}
@@ -101,8 +277,8 @@ func (s *Store) GetAllTask() ([]task.Task, error) {
 	var tasks []task.Task
 
 	for rows.Next() {
-		var t task.Task
-		if err := rows.Scan(&t.ID, &t.Desc, &t.Status, &t.Userid); err != nil {
+		t, err := scanTask(rows)
+		if err != nil {
 			return nil, err
 		}
 
@@ -116,9 +292,63 @@ func (s *Store) GetAllTask() ([]task.Task, error) {
 	return tasks, nil
 }
 
+// CountByStatusTask counts the live tasks, by status: false for open and true for done
+func (s *Store) CountByStatusTask(ctx context.Context) (map[bool]int, error) {
+	defer observe("CountByStatusTask")()
+
+	rows, err := s.reader(ctx).QueryContext(ctx, "SELECT status, COUNT(*) FROM tasks WHERE deleted_at IS NULL GROUP BY status")
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	counts := map[bool]int{false: 0, true: 0}
+
+	for rows.Next() {
+		var (
+			status bool
+			n      int
+		)
+
+		if err := rows.Scan(&status, &n); err != nil {
+			return nil, err
+		}
+
+		counts[status] = n
+	}
+
+	return counts, rows.Err()
+}
+
+// CountByWorkspaceTask counts the tasks of a workspace, including those in the trash. It first locks the
+// row of the workspace in workspace_quota_locks, held until the unit of work ends, so that concurrent
+// creations in one workspace count and insert one after another. The count is a locking read, which sees
+// the tasks committed while waiting for the lock.
+func (s *Store) CountByWorkspaceTask(ctx context.Context, workspaceID int) (int, error) {
+	defer observe("CountByWorkspaceTask")()
+
+	conn := uow.Conn(ctx, s.db)
+
+	// Inserting or updating the row takes its exclusive lock in one statement
+	if _, err := conn.ExecContext(ctx, "INSERT INTO workspace_quota_locks (workspace_id) VALUES (?) "+
+		"ON DUPLICATE KEY UPDATE workspace_id = workspace_id", workspaceID); err != nil {
+		return 0, err
+	}
+
+	var n int
+	err := conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM tasks WHERE workspace_id = ? LOCK IN SHARE MODE", workspaceID).Scan(&n)
+
+	return n, err
+}
+
 // GetTasksByUserID it will send the tasks , which are assigned to user
-func (s *Store) GetTasksByUserIDTask(userid int) ([]task.Task, error) {
-	rows, err := s.db.Query("SELECT id, description, status , userid FROM tasks where userid =?", userid)
+func (s *Store) GetTasksByUserIDTask(ctx context.Context, userid int) ([]task.Task, error) {
+	defer observe("GetTasksByUserIDTask")()
+
+	rows, err := s.reader(ctx).QueryContext(ctx, "SELECT "+taskColumns+" FROM tasks where userid =? AND deleted_at IS NULL", userid)
 
 	if err != nil {
 		return nil, err
@@ -134,8 +364,8 @@ func (s *Store) GetTasksByUserIDTask(userid int) ([]task.Task, error) {
 	var tasks []task.Task
 
 	for rows.Next() {
-		var t task.Task
-		if err := rows.Scan(&t.ID, &t.Desc, &t.Status, &t.Userid); err != nil {
+		t, err := scanTask(rows)
+		if err != nil {
 			return nil, err
 		}
 
@@ -148,3 +378,510 @@ func (s *Store) GetTasksByUserIDTask(userid int) ([]task.Task, error) {
 
 	return tasks, nil
 }
+
+// GetByUserIDsTask returns the live tasks of all the given users in one query, in ID order, for
+// callers that would otherwise query the tasks of each user in turn
+func (s *Store) GetByUserIDsTask(ctx context.Context, userids []int) ([]task.Task, error) {
+	defer observe("GetByUserIDsTask")()
+
+	if len(userids) == 0 {
+		return nil, nil
+	}
+
+	// The number of IDs makes too many variants of the statement to keep them all prepared
+	rows, err := s.replicas.Conn(ctx, s.db).QueryContext(ctx, "SELECT "+taskColumns+" FROM tasks WHERE userid IN ("+
+		placeholders(len(userids))+") AND deleted_at IS NULL ORDER BY id", intArgs(userids)...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	var tasks []task.Task
+
+	for rows.Next() {
+		t, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		tasks = append(tasks, t)
+	}
+
+	return tasks, rows.Err()
+}
+
+// GetByLabelsTask returns the tasks carrying any (or, when matchAll is set, all) of the named labels,
+// names being looked up in the workspace of each task. A non-zero workspaceID restricts the match to
+// that workspace.
+func (s *Store) GetByLabelsTask(ctx context.Context, workspaceID int, labels []string, matchAll bool) ([]task.Task, error) {
+	defer observe("GetByLabelsTask")()
+
+	var tasks []task.Task
+
+	err := s.EachTask(ctx, task.Filter{WorkspaceID: workspaceID, Labels: labels, MatchAll: matchAll}, func(t task.Task) error {
+		tasks = append(tasks, t)
+		return nil
+	})
+
+	return tasks, err
+}
+
+// EachTask calls fn for every live task matching the filter, in ID order, while reading them from
+// the database cursor, so the caller never holds the whole result in memory. An error from fn stops
+// the iteration and is returned.
+func (s *Store) EachTask(ctx context.Context, f task.Filter, fn func(task.Task) error) error {
+	defer observe("EachTask")()
+
+	query := "SELECT t.id, t.description, t.status, t.userid, t.due_at, t.workspace_id FROM tasks t "
+	where := "WHERE t.deleted_at IS NULL"
+	args := make([]any, 0, len(f.Labels)+6)
+
+	if len(f.Labels) > 0 {
+		query += "JOIN task_labels tl ON tl.task_id = t.id JOIN labels l ON l.id = tl.label_id AND l.workspace_id = t.workspace_id "
+		where += " AND l.name IN (" + placeholders(len(f.Labels)) + ")"
+
+		for _, name := range f.Labels {
+			args = append(args, name)
+		}
+
+		if f.WorkspaceID != 0 {
+			where += " AND l.workspace_id = ?"
+			args = append(args, f.WorkspaceID)
+		}
+	}
+
+	if f.UserID != 0 {
+		where += " AND t.userid = ?"
+		args = append(args, f.UserID)
+	}
+
+	if f.Status != nil {
+		where += " AND t.status = ?"
+		args = append(args, *f.Status)
+	}
+
+	if f.AfterID != 0 {
+		where += " AND t.id > ?"
+		args = append(args, f.AfterID)
+	}
+
+	query += where
+
+	if len(f.Labels) > 0 {
+		// Grouping collapses the one row per matching label into one row per task; HAVING then
+		// keeps only the tasks that matched every requested name
+		query += " GROUP BY t.id, t.description, t.status, t.userid, t.due_at, t.workspace_id"
+		if f.MatchAll {
+			query += " HAVING COUNT(DISTINCT l.name) = ?"
+			args = append(args, len(f.Labels))
+		}
+	}
+
+	query += " ORDER BY t.id"
+	if f.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, f.Limit)
+	}
+
+	// Filters make too many variants of the statement to keep them all prepared
+	rows, err := s.replicas.Conn(ctx, s.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	for rows.Next() {
+		t, err := scanTask(rows)
+		if err != nil {
+			return err
+		}
+
+		if err := fn(t); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// GetTrashTask lists the tasks in the trash, most recently deleted first
+func (s *Store) GetTrashTask(ctx context.Context) ([]task.Trashed, error) {
+	defer observe("GetTrashTask")()
+
+	rows, err := s.reader(ctx).QueryContext(ctx, "SELECT "+taskColumns+", deleted_at FROM tasks WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC")
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	var tasks []task.Trashed
+
+	for rows.Next() {
+		var t task.Trashed
+		if err := rows.Scan(&t.ID, &t.Desc, &t.Status, (*nullID)(&t.Userid), dueAt{&t.Due}, (*nullID)(&t.WorkspaceID), &t.DeletedAt); err != nil {
+			return nil, err
+		}
+
+		tasks = append(tasks, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+// RestoreTask takes a task out of the trash. It fails with ErrOwnerTrashed while the assignee of the
+// task is in the trash too.
+func (s *Store) RestoreTask(ctx context.Context, id int) error {
+	defer observe("RestoreTask")()
+
+	return s.withRevision(ctx, func(tx uow.DB) (int, error) {
+		// Locking the assignee along with the task keeps it from being deleted or purged meanwhile
+		var ownerTrashed bool
+
+		err := tx.QueryRowContext(ctx, "SELECT u.deleted_at IS NOT NULL FROM tasks t LEFT JOIN users u ON u.id = t.userid "+
+			"WHERE t.id = ? AND t.deleted_at IS NOT NULL FOR UPDATE", id).Scan(&ownerTrashed)
+		if err != nil {
+			return id, err
+		}
+
+		if ownerTrashed {
+			return id, task.ErrOwnerTrashed
+		}
+
+		// An assignee that no longer exists at all is dropped rather than restored along with the task
+		return id, execOne(ctx, tx, "UPDATE tasks t LEFT JOIN users u ON u.id = t.userid SET t.deleted_at = NULL, t.userid = u.id "+
+			"WHERE t.id = ? AND t.deleted_at IS NOT NULL", id)
+	})
+}
+
+// RevertTask overwrites the fields of a live task with those of an earlier version
+func (s *Store) RevertTask(ctx context.Context, id int, to task.Task) error {
+	defer observe("RevertTask")()
+
+	return s.withRevision(ctx, func(tx uow.DB) (int, error) {
+		return id, execOne(ctx, tx, "UPDATE tasks SET description = ?, status = ?, userid = ?, due_at = ? WHERE id = ? AND deleted_at IS NULL",
+			to.Desc, to.Status, nullableID(to.Userid), nullableDue(to.Due), id)
+	})
+}
+
+// GetRevisionsTask lists every revision of a task, oldest first
+func (s *Store) GetRevisionsTask(ctx context.Context, id int) ([]task.Revision, error) {
+	defer observe("GetRevisionsTask")()
+
+	rows, err := s.reader(ctx).QueryContext(ctx, revisionColumns+" WHERE task_id = ? ORDER BY revision", id)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	var revisions []task.Revision
+
+	for rows.Next() {
+		r, err := scanRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		revisions = append(revisions, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return revisions, nil
+}
+
+// GetRevisionTask fetches one revision of a task by number
+func (s *Store) GetRevisionTask(ctx context.Context, id, number int) (task.Revision, error) {
+	defer observe("GetRevisionTask")()
+
+	return scanRevision(s.reader(ctx).QueryRowContext(ctx, revisionColumns+" WHERE task_id = ? AND revision = ?", id, number))
+}
+
+// GetAsOfTask fetches the revision of a task that was current at the given time
+func (s *Store) GetAsOfTask(ctx context.Context, id int, at time.Time) (task.Revision, error) {
+	defer observe("GetAsOfTask")()
+
+	return scanRevision(s.reader(ctx).QueryRowContext(ctx, revisionColumns+" WHERE task_id = ? AND at <= ? ORDER BY revision DESC LIMIT 1", id, at))
+}
+
+// PurgeTask permanently deletes the tasks trashed before the given time and returns their IDs
+func (s *Store) PurgeTask(ctx context.Context, before time.Time) (ids []int, err error) {
+	defer observe("PurgeTask")()
+
+	tx, err := uow.Begin(ctx, s.db)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	rows, err := tx.QueryContext(ctx, "SELECT id FROM tasks WHERE deleted_at IS NOT NULL AND deleted_at < ? FOR UPDATE", before)
+	if err != nil {
+		return nil, err
+	}
+
+	for rows.Next() {
+		var id int
+		if err = rows.Scan(&id); err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(ids) == 0 {
+		return nil, tx.Commit()
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM tasks WHERE id IN ("+placeholders(len(ids))+")", intArgs(ids)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, tx.Commit()
+}
+
+// BulkTask applies a batch of operations in one transaction, with one statement per kind of operation
+// rather than one per task, creations aside. Results line up with ops. Operations on missing or trashed tasks fail; when
+// atomic is set such a failure rolls the whole batch back, reports the rest as rolled back and returns
+// task.ErrBulkFailed, otherwise only the failed operations are skipped.
+func (s *Store) BulkTask(ctx context.Context, ops []task.BulkOp, atomic bool) (results []task.BulkResult, err error) {
+	defer observe("BulkTask")()
+
+	results = make([]task.BulkResult, len(ops))
+
+	var ids []int
+
+	for i, op := range ops {
+		results[i] = task.BulkResult{Index: i, Op: op.Op, ID: op.ID, Status: task.BulkOK}
+
+		if op.Op != task.BulkCreate {
+			ids = append(ids, op.ID)
+		}
+	}
+
+	tx, err := uow.Begin(ctx, s.db)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	current, err := lockTasks(ctx, tx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	byKind := make(map[string][]int)
+	failed := false
+
+	for i, op := range ops {
+		before, ok := current[op.ID]
+		if op.Op != task.BulkCreate && !ok {
+			results[i].Status = task.BulkFailed
+			results[i].Error = task.ErrNotFound.Error()
+			failed = true
+
+			continue
+		}
+
+		after := before
+
+		switch op.Op {
+		case task.BulkCreate:
+			after = task.Task{Desc: op.Desc, Userid: op.Userid, WorkspaceID: op.WorkspaceID}
+		case task.BulkUpdate:
+			after.Desc = op.Desc
+		case task.BulkComplete:
+			after.Status = true
+		case task.BulkReassign:
+			after.Userid = op.Userid
+		}
+
+		if op.Op != task.BulkCreate {
+			results[i].Before = &before
+		}
+
+		if op.Op != task.BulkDelete {
+			results[i].Task = &after
+		}
+
+		byKind[op.Op] = append(byKind[op.Op], i)
+	}
+
+	if failed && atomic {
+		for i := range results {
+			if results[i].Status == task.BulkOK {
+				results[i].Status = task.BulkRolledBack
+				results[i].Task, results[i].Before = nil, nil
+			}
+		}
+
+		err = task.ErrBulkFailed
+
+		return results, err
+	}
+
+	now := time.Now().UTC()
+
+	if err = bulkCreate(ctx, tx, ops, results, byKind[task.BulkCreate]); err != nil {
+		return nil, err
+	}
+
+	if err = bulkSet(ctx, tx, "description", ops, byKind[task.BulkUpdate], func(op task.BulkOp) any { return op.Desc }); err != nil {
+		return nil, err
+	}
+
+	if err = bulkSet(ctx, tx, "userid", ops, byKind[task.BulkReassign], func(op task.BulkOp) any { return op.Userid }); err != nil {
+		return nil, err
+	}
+
+	if err = bulkExec(ctx, tx, "UPDATE tasks SET status = true WHERE id IN", nil, ops, byKind[task.BulkComplete]); err != nil {
+		return nil, err
+	}
+
+	if err = bulkExec(ctx, tx, "UPDATE tasks SET deleted_at = ? WHERE id IN", []any{now}, ops, byKind[task.BulkDelete]); err != nil {
+		return nil, err
+	}
+
+	var changed []int
+
+	for i := range results {
+		if results[i].Status == task.BulkOK {
+			changed = append(changed, results[i].ID)
+		}
+	}
+
+	if err = Snapshot(ctx, tx, now, changed...); err != nil {
+		return nil, err
+	}
+
+	return results, tx.Commit()
+}
+
+// lockTasks loads and locks the live tasks with the given IDs
+func lockTasks(ctx context.Context, tx uow.DB, ids []int) (map[int]task.Task, error) {
+	current := make(map[int]task.Task, len(ids))
+	if len(ids) == 0 {
+		return current, nil
+	}
+
+	rows, err := tx.QueryContext(ctx, "SELECT "+taskColumns+" FROM tasks WHERE id IN ("+placeholders(len(ids))+") AND deleted_at IS NULL FOR UPDATE", intArgs(ids)...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	for rows.Next() {
+		t, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		current[t.ID] = t
+	}
+
+	return current, rows.Err()
+}
+
+// bulkCreate inserts the create operations one row at a time and takes each ID from its own insert. The
+// IDs of a multi-row INSERT are only consecutive when auto_increment_increment is 1 and concurrent
+// inserts do not interleave, which neither multi-primary clusters nor innodb_autoinc_lock_mode=2 promise.
+func bulkCreate(ctx context.Context, tx uow.DB, ops []task.BulkOp, results []task.BulkResult, idx []int) error {
+	for _, i := range idx {
+		res, err := tx.ExecContext(ctx, "INSERT INTO tasks (description, status, userid, workspace_id) VALUES (?, ?, ?, ?)",
+			ops[i].Desc, false, ops[i].Userid, nullableID(ops[i].WorkspaceID))
+		if err != nil {
+			return err
+		}
+
+		id, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		results[i].ID = int(id)
+		results[i].Task.ID = results[i].ID
+	}
+
+	return nil
+}
+
+// bulkSet assigns a per-task value to one column with a single UPDATE ... CASE statement
+func bulkSet(ctx context.Context, tx uow.DB, column string, ops []task.BulkOp, idx []int, value func(task.BulkOp) any) error {
+	if len(idx) == 0 {
+		return nil
+	}
+
+	args := make([]any, 0, 3*len(idx))
+
+	for _, i := range idx {
+		args = append(args, ops[i].ID, value(ops[i]))
+	}
+
+	query := "UPDATE tasks SET " + column + " = CASE id" + strings.Repeat(" WHEN ? THEN ?", len(idx)) + " END WHERE id IN"
+
+	return bulkExec(ctx, tx, query, args, ops, idx)
+}
+
+// bulkExec runs query, which ends in "WHERE id IN", for the tasks of the given operations
+func bulkExec(ctx context.Context, tx uow.DB, query string, args []any, ops []task.BulkOp, idx []int) error {
+	if len(idx) == 0 {
+		return nil
+	}
+
+	for _, i := range idx {
+		args = append(args, ops[i].ID)
+	}
+
+	_, err := tx.ExecContext(ctx, query+" ("+placeholders(len(idx))+")", args...)
+
+	return err
+}
+
+// placeholders returns "?, ?, ?" for n arguments
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+func intArgs(ids []int) []any {
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+
+	return args
+}
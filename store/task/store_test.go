@@ -2,15 +2,32 @@ package task
 
 import (
 	taskModel "Task_Manager/model/task"
+	"Task_Manager/store/replica"
+	"Task_Manager/store/uow"
+	"context"
 	"database/sql"
+	"database/sql/driver"
 	"errors"
 	"regexp"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/require"
 )
 
+var snapshotPrefix = regexp.QuoteMeta("INSERT INTO task_revisions (task_id, revision, at, deleted, description, status, userid, due_at) SELECT")
+
+// expectSnapshot expects the revision of the given tasks to be recorded
+func expectSnapshot(mock sqlmock.Sqlmock, ids ...int) {
+	args := []driver.Value{sqlmock.AnyArg()}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+
+	mock.ExpectExec(snapshotPrefix).WithArgs(args...).WillReturnResult(sqlmock.NewResult(0, int64(len(ids))))
+}
+
 func setup(t *testing.T) (*Store, sqlmock.Sqlmock, func()) {
 	db, mock, err := sqlmock.New()
 	require.NoError(t, err)
@@ -23,36 +40,57 @@ func Test_CreateTask(t *testing.T) {
 	defer cleanup()
 
 	tsk := taskModel.Task{Desc: "New Task", Status: false, Userid: 2}
+	insert := regexp.QuoteMeta("INSERT INTO tasks (description, status, userid, due_at, workspace_id) VALUES (?, ?, ?, ?, ?)")
 
 	t.Run("Success", func(t *testing.T) {
-		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO tasks (description, status,userid) VALUES (?, ?,?)")).
-			WithArgs(tsk.Desc, tsk.Status, tsk.Userid).
+		mock.ExpectBegin()
+		mock.ExpectExec(insert).
+			WithArgs(tsk.Desc, tsk.Status, tsk.Userid, nil, nil).
 			WillReturnResult(sqlmock.NewResult(1, 1))
+		expectSnapshot(mock, 1)
+		mock.ExpectCommit()
 
-		created, err := store.CreateTask(tsk)
+		created, err := store.CreateTask(context.Background(), tsk)
 		require.NoError(t, err)
 		require.Equal(t, 1, created.ID)
 	})
 
 	t.Run("Exec Error", func(t *testing.T) {
-		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO tasks (description, status,userid) VALUES (?, ?,?)")).
-			WithArgs(tsk.Desc, tsk.Status, tsk.Userid).
+		mock.ExpectBegin()
+		mock.ExpectExec(insert).
+			WithArgs(tsk.Desc, tsk.Status, tsk.Userid, nil, nil).
 			WillReturnError(errors.New("insert failed"))
+		mock.ExpectRollback()
 
-		_, err := store.CreateTask(tsk)
+		_, err := store.CreateTask(context.Background(), tsk)
 		require.Error(t, err)
 		require.EqualError(t, err, "insert failed")
 	})
 
 	t.Run("LastInsertId Error", func(t *testing.T) {
-		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO tasks (description, status,userid) VALUES (?, ?,?)")).
-			WithArgs(tsk.Desc, tsk.Status, tsk.Userid).
+		mock.ExpectBegin()
+		mock.ExpectExec(insert).
+			WithArgs(tsk.Desc, tsk.Status, tsk.Userid, nil, nil).
 			WillReturnResult(sqlmock.NewErrorResult(errors.New("lastInsertId failed")))
+		mock.ExpectRollback()
 
-		_, err := store.CreateTask(tsk)
+		_, err := store.CreateTask(context.Background(), tsk)
 		require.Error(t, err)
 		require.EqualError(t, err, "lastInsertId failed")
 	})
+
+	t.Run("Snapshot Error", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(insert).
+			WithArgs(tsk.Desc, tsk.Status, tsk.Userid, nil, nil).
+			WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectExec(snapshotPrefix).WillReturnError(errors.New("revision insert failed"))
+		mock.ExpectRollback()
+
+		_, err := store.CreateTask(context.Background(), tsk)
+		require.Error(t, err)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
 }
 
 func Test_GetByIDTask(t *testing.T) {
@@ -60,57 +98,107 @@ func Test_GetByIDTask(t *testing.T) {
 	defer cleanup()
 
 	t.Run("Success", func(t *testing.T) {
-		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM tasks WHERE id = ?")).
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, description, status, userid, due_at, workspace_id FROM tasks WHERE id = ? AND deleted_at IS NULL")).
 			WithArgs(1).
-			WillReturnRows(sqlmock.NewRows([]string{"id", "description", "status", "userid"}).
-				AddRow(1, "Do homework", false, 1))
-		tsk, err := store.GetByIDTask(1)
+			WillReturnRows(sqlmock.NewRows([]string{"id", "description", "status", "userid", "due_at", "workspace_id"}).
+				AddRow(1, "Do homework", false, 1, time.Date(2024, 5, 7, 12, 0, 0, 0, time.UTC), 3))
+		tsk, err := store.GetByIDTask(context.Background(), 1)
 		require.NoError(t, err)
 		require.Equal(t, 1, tsk.ID)
+		require.Equal(t, 3, tsk.WorkspaceID)
+		require.Equal(t, time.Date(2024, 5, 7, 12, 0, 0, 0, time.UTC), *tsk.Due)
+	})
+
+	t.Run("Unassigned", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, description, status, userid, due_at, workspace_id FROM tasks WHERE id = ? AND deleted_at IS NULL")).
+			WithArgs(2).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "description", "status", "userid", "due_at", "workspace_id"}).
+				AddRow(2, "Orphaned", false, nil, nil, nil))
+		tsk, err := store.GetByIDTask(context.Background(), 2)
+		require.NoError(t, err)
+		require.Equal(t, 0, tsk.Userid)
+		require.Nil(t, tsk.Due)
 	})
 
 	t.Run("Not Found", func(t *testing.T) {
-		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM tasks WHERE id = ?")).
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, description, status, userid, due_at, workspace_id FROM tasks WHERE id = ? AND deleted_at IS NULL")).
 			WithArgs(999).
 			WillReturnError(sql.ErrNoRows)
-		_, err := store.GetByIDTask(999)
+		_, err := store.GetByIDTask(context.Background(), 999)
 		require.Error(t, err)
 	})
 }
 
+func Test_ExistsTask(t *testing.T) {
+	store, mock, cleanup := setup(t)
+	defer cleanup()
+
+	query := regexp.QuoteMeta("SELECT EXISTS (SELECT 1 FROM tasks WHERE description = ? AND userid = ? AND deleted_at IS NULL)")
+
+	mock.ExpectQuery(query).WithArgs("Do homework", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+	exists, err := store.ExistsTask(context.Background(), "Do homework", 1)
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	mock.ExpectQuery(query).WithArgs("Do homework", 2).WillReturnError(errors.New("db down"))
+
+	_, err = store.ExistsTask(context.Background(), "Do homework", 2)
+	require.Error(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
 func Test_CompleteTask(t *testing.T) {
 	store, mock, cleanup := setup(t)
 	defer cleanup()
 
+	query := regexp.QuoteMeta("UPDATE tasks SET status = true WHERE id = ? AND deleted_at IS NULL")
+
 	t.Run("Success", func(t *testing.T) {
-		mock.ExpectExec(regexp.QuoteMeta("UPDATE tasks SET status = true WHERE id = ?")).
+		mock.ExpectBegin()
+		mock.ExpectExec(query).
 			WithArgs(1).
 			WillReturnResult(sqlmock.NewResult(0, 1))
-		err := store.CompleteTask(1)
+		expectSnapshot(mock, 1)
+		mock.ExpectCommit()
+		err := store.CompleteTask(context.Background(), 1)
 		require.NoError(t, err)
 	})
 
 	t.Run("No Rows Updated", func(t *testing.T) {
-		mock.ExpectExec(regexp.QuoteMeta("UPDATE tasks SET status = true WHERE id = ?")).
+		mock.ExpectBegin()
+		mock.ExpectExec(query).
 			WithArgs(2).
 			WillReturnResult(sqlmock.NewResult(0, 0))
-		err := store.CompleteTask(2)
+		mock.ExpectRollback()
+		err := store.CompleteTask(context.Background(), 2)
 		require.ErrorIs(t, err, sql.ErrNoRows)
 	})
 
 	t.Run("Exec Error", func(t *testing.T) {
-		mock.ExpectExec(regexp.QuoteMeta("UPDATE tasks SET status = true WHERE id = ?")).
+		mock.ExpectBegin()
+		mock.ExpectExec(query).
 			WithArgs(3).
 			WillReturnError(errors.New("db error"))
-		err := store.CompleteTask(3)
+		mock.ExpectRollback()
+		err := store.CompleteTask(context.Background(), 3)
 		require.Error(t, err)
 	})
 
 	t.Run("RowsAffected Error", func(t *testing.T) {
-		mock.ExpectExec(regexp.QuoteMeta("UPDATE tasks SET status = true WHERE id = ?")).
+		mock.ExpectBegin()
+		mock.ExpectExec(query).
 			WithArgs(4).
 			WillReturnResult(sqlmock.NewErrorResult(errors.New("RowsAffected fail")))
-		err := store.CompleteTask(4)
+		mock.ExpectRollback()
+		err := store.CompleteTask(context.Background(), 4)
+		require.Error(t, err)
+	})
+
+	t.Run("Begin Error", func(t *testing.T) {
+		mock.ExpectBegin().WillReturnError(sql.ErrConnDone)
+		err := store.CompleteTask(context.Background(), 5)
 		require.Error(t, err)
 	})
 }
@@ -119,35 +207,46 @@ func Test_DeleteTask(t *testing.T) {
 	store, mock, cleanup := setup(t)
 	defer cleanup()
 
+	query := regexp.QuoteMeta("UPDATE tasks SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL")
+
 	t.Run("Success", func(t *testing.T) {
-		mock.ExpectExec(regexp.QuoteMeta("DELETE FROM tasks WHERE id = ?")).
-			WithArgs(1).
+		mock.ExpectBegin()
+		mock.ExpectExec(query).
+			WithArgs(sqlmock.AnyArg(), 1).
 			WillReturnResult(sqlmock.NewResult(0, 1))
-		err := store.DeleteTask(1)
+		expectSnapshot(mock, 1)
+		mock.ExpectCommit()
+		err := store.DeleteTask(context.Background(), 1)
 		require.NoError(t, err)
 	})
 
 	t.Run("No Rows Deleted", func(t *testing.T) {
-		mock.ExpectExec(regexp.QuoteMeta("DELETE FROM tasks WHERE id = ?")).
-			WithArgs(2).
+		mock.ExpectBegin()
+		mock.ExpectExec(query).
+			WithArgs(sqlmock.AnyArg(), 2).
 			WillReturnResult(sqlmock.NewResult(0, 0))
-		err := store.DeleteTask(2)
+		mock.ExpectRollback()
+		err := store.DeleteTask(context.Background(), 2)
 		require.ErrorIs(t, err, sql.ErrNoRows)
 	})
 
 	t.Run("Exec Error", func(t *testing.T) {
-		mock.ExpectExec(regexp.QuoteMeta("DELETE FROM tasks WHERE id = ?")).
-			WithArgs(3).
+		mock.ExpectBegin()
+		mock.ExpectExec(query).
+			WithArgs(sqlmock.AnyArg(), 3).
 			WillReturnError(sql.ErrConnDone)
-		err := store.DeleteTask(3)
+		mock.ExpectRollback()
+		err := store.DeleteTask(context.Background(), 3)
 		require.Error(t, err)
 	})
 
 	t.Run("RowsAffected Error", func(t *testing.T) {
-		mock.ExpectExec(regexp.QuoteMeta("DELETE FROM tasks WHERE id = ?")).
-			WithArgs(4).
+		mock.ExpectBegin()
+		mock.ExpectExec(query).
+			WithArgs(sqlmock.AnyArg(), 4).
 			WillReturnResult(sqlmock.NewErrorResult(errors.New("RowsAffected fail")))
-		err := store.DeleteTask(4)
+		mock.ExpectRollback()
+		err := store.DeleteTask(context.Background(), 4)
 		require.Error(t, err)
 	})
 }
@@ -157,65 +256,616 @@ func Test_GetAllTask(t *testing.T) {
 	defer cleanup()
 
 	t.Run("Success", func(t *testing.T) {
-		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, description, status , userid FROM tasks")).
-			WillReturnRows(sqlmock.NewRows([]string{"id", "description", "status", "userid"}).
-				AddRow(1, "Task1", false, 1).
-				AddRow(2, "Task2", true, 2))
-		tasks, err := store.GetAllTask()
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, description, status, userid, due_at, workspace_id FROM tasks WHERE deleted_at IS NULL")).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "description", "status", "userid", "due_at", "workspace_id"}).
+				AddRow(1, "Task1", false, 1, nil, nil).
+				AddRow(2, "Task2", true, 2, nil, nil))
+		tasks, err := store.GetAllTask(context.Background())
 		require.NoError(t, err)
 		require.Len(t, tasks, 2)
 	})
 
 	t.Run("Query Error", func(t *testing.T) {
-		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, description, status , userid FROM tasks")).
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, description, status, userid, due_at, workspace_id FROM tasks WHERE deleted_at IS NULL")).
 			WillReturnError(sql.ErrConnDone)
-		_, err := store.GetAllTask()
+		_, err := store.GetAllTask(context.Background())
 		require.Error(t, err)
 	})
 
 	t.Run("Scan Error", func(t *testing.T) {
-		rows := sqlmock.NewRows([]string{"id", "description", "status", "userid"}).
-			AddRow(1, "X", true, 1)
-		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, description, status , userid FROM tasks")).
+		rows := sqlmock.NewRows([]string{"id", "description", "status", "userid", "due_at", "workspace_id"}).
+			AddRow(1, "X", true, 1, nil, nil)
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, description, status, userid, due_at, workspace_id FROM tasks WHERE deleted_at IS NULL")).
 			WillReturnRows(rows)
 		rows.RowError(0, errors.New("scan error"))
-		_, err := store.GetAllTask()
+		_, err := store.GetAllTask(context.Background())
 		require.Error(t, err)
 	})
 }
 
+func Test_CountByStatusTask(t *testing.T) {
+	store, mock, cleanup := setup(t)
+	defer cleanup()
+
+	query := regexp.QuoteMeta("SELECT status, COUNT(*) FROM tasks WHERE deleted_at IS NULL GROUP BY status")
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows([]string{"status", "count"}).AddRow(false, 3))
+
+		counts, err := store.CountByStatusTask(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, map[bool]int{false: 3, true: 0}, counts)
+	})
+
+	t.Run("Query Error", func(t *testing.T) {
+		mock.ExpectQuery(query).WillReturnError(sql.ErrConnDone)
+
+		_, err := store.CountByStatusTask(context.Background())
+		require.Error(t, err)
+	})
+}
+
+func Test_CountByWorkspaceTask(t *testing.T) {
+	store, mock, cleanup := setup(t)
+	defer cleanup()
+
+	lock := regexp.QuoteMeta("INSERT INTO workspace_quota_locks (workspace_id) VALUES (?) ON DUPLICATE KEY UPDATE workspace_id = workspace_id")
+	query := regexp.QuoteMeta("SELECT COUNT(*) FROM tasks WHERE workspace_id = ? LOCK IN SHARE MODE")
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectExec(lock).WithArgs(5).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(query).WithArgs(5).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(12))
+
+		n, err := store.CountByWorkspaceTask(context.Background(), 5)
+		require.NoError(t, err)
+		require.Equal(t, 12, n)
+	})
+
+	t.Run("Within the unit of work", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(lock).WithArgs(5).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(query).WithArgs(5).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+		mock.ExpectCommit()
+
+		err := uow.New(store.db).WithTx(context.Background(), func(ctx context.Context) error {
+			n, err := store.CountByWorkspaceTask(ctx, 5)
+			require.Equal(t, 3, n)
+			return err
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("Lock Error", func(t *testing.T) {
+		mock.ExpectExec(lock).WithArgs(5).WillReturnError(sql.ErrConnDone)
+
+		_, err := store.CountByWorkspaceTask(context.Background(), 5)
+		require.ErrorIs(t, err, sql.ErrConnDone)
+	})
+
+	t.Run("Query Error", func(t *testing.T) {
+		mock.ExpectExec(lock).WithArgs(5).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(query).WithArgs(5).WillReturnError(sql.ErrConnDone)
+
+		_, err := store.CountByWorkspaceTask(context.Background(), 5)
+		require.Error(t, err)
+	})
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
 func Test_GetTasksByUserIDTask(t *testing.T) {
 	store, mock, cleanup := setup(t)
 	defer cleanup()
 
 	t.Run("Success", func(t *testing.T) {
-		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, description, status , userid FROM tasks where userid =?")).
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, description, status, userid, due_at, workspace_id FROM tasks where userid =? AND deleted_at IS NULL")).
 			WithArgs(1).
-			WillReturnRows(sqlmock.NewRows([]string{"id", "description", "status", "userid"}).
-				AddRow(1, "User task", true, 1))
-		tasks, err := store.GetTasksByUserIDTask(1)
+			WillReturnRows(sqlmock.NewRows([]string{"id", "description", "status", "userid", "due_at", "workspace_id"}).
+				AddRow(1, "User task", true, 1, nil, nil))
+		tasks, err := store.GetTasksByUserIDTask(context.Background(), 1)
 		require.NoError(t, err)
 		require.Len(t, tasks, 1)
 		require.Equal(t, 1, tasks[0].Userid)
 	})
 
 	t.Run("Query Error", func(t *testing.T) {
-		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, description, status , userid FROM tasks where userid =?")).
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, description, status, userid, due_at, workspace_id FROM tasks where userid =? AND deleted_at IS NULL")).
 			WithArgs(999).
 			WillReturnError(sql.ErrConnDone)
-		_, err := store.GetTasksByUserIDTask(999)
+		_, err := store.GetTasksByUserIDTask(context.Background(), 999)
 		require.Error(t, err)
 	})
 
 	t.Run("Scan Error", func(t *testing.T) {
-		rows := sqlmock.NewRows([]string{"id", "description", "status", "userid"}).
-			AddRow(2, "B", false, 999)
-		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, description, status , userid FROM tasks where userid =?")).
+		rows := sqlmock.NewRows([]string{"id", "description", "status", "userid", "due_at", "workspace_id"}).
+			AddRow(2, "B", false, 999, nil, nil)
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, description, status, userid, due_at, workspace_id FROM tasks where userid =? AND deleted_at IS NULL")).
 			WithArgs(999).
 			WillReturnRows(rows)
 		rows.RowError(0, errors.New("scan error"))
-		_, err := store.GetTasksByUserIDTask(999)
+		_, err := store.GetTasksByUserIDTask(context.Background(), 999)
+		require.Error(t, err)
+	})
+
+}
+
+func Test_GetByUserIDsTask(t *testing.T) {
+	store, mock, cleanup := setup(t)
+	defer cleanup()
+
+	query := regexp.QuoteMeta("SELECT id, description, status, userid, due_at, workspace_id FROM tasks WHERE userid IN (?, ?) AND deleted_at IS NULL ORDER BY id")
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectQuery(query).
+			WithArgs(1, 2).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "description", "status", "userid", "due_at", "workspace_id"}).
+				AddRow(1, "A", false, 2, nil, nil).
+				AddRow(2, "B", true, 1, nil, nil))
+
+		tasks, err := store.GetByUserIDsTask(context.Background(), []int{1, 2})
+		require.NoError(t, err)
+		require.Len(t, tasks, 2)
+	})
+
+	t.Run("No users", func(t *testing.T) {
+		tasks, err := store.GetByUserIDsTask(context.Background(), nil)
+		require.NoError(t, err)
+		require.Empty(t, tasks)
+	})
+
+	t.Run("Query Error", func(t *testing.T) {
+		mock.ExpectQuery(query).WithArgs(1, 2).WillReturnError(sql.ErrConnDone)
+
+		_, err := store.GetByUserIDsTask(context.Background(), []int{1, 2})
+		require.Error(t, err)
+	})
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func Test_GetByLabelsTask(t *testing.T) {
+	store, mock, cleanup := setup(t)
+	defer cleanup()
+
+	base := "SELECT t.id, t.description, t.status, t.userid, t.due_at, t.workspace_id FROM tasks t " +
+		"JOIN task_labels tl ON tl.task_id = t.id JOIN labels l ON l.id = tl.label_id AND l.workspace_id = t.workspace_id " +
+		"WHERE t.deleted_at IS NULL AND l.name IN (?, ?)"
+	group := " GROUP BY t.id, t.description, t.status, t.userid, t.due_at, t.workspace_id"
+
+	t.Run("Any label", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(base+group+" ORDER BY t.id")).
+			WithArgs("bug", "urgent").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "description", "status", "userid", "due_at", "workspace_id"}).
+				AddRow(1, "Fix login", false, 1, nil, nil).
+				AddRow(2, "Ship it", false, 2, nil, nil))
+
+		tasks, err := store.GetByLabelsTask(context.Background(), 0, []string{"bug", "urgent"}, false)
+		require.NoError(t, err)
+		require.Len(t, tasks, 2)
+	})
+
+	t.Run("All labels in workspace", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(base+" AND l.workspace_id = ?"+group+" HAVING COUNT(DISTINCT l.name) = ? ORDER BY t.id")).
+			WithArgs("bug", "urgent", 3, 2).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "description", "status", "userid", "due_at", "workspace_id"}).
+				AddRow(1, "Fix login", false, 1, nil, nil))
+
+		tasks, err := store.GetByLabelsTask(context.Background(), 3, []string{"bug", "urgent"}, true)
+		require.NoError(t, err)
+		require.Len(t, tasks, 1)
+	})
+
+	t.Run("Query Error", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(base + group + " ORDER BY t.id")).
+			WillReturnError(sql.ErrConnDone)
+
+		_, err := store.GetByLabelsTask(context.Background(), 0, []string{"bug", "urgent"}, false)
 		require.Error(t, err)
 	})
+}
+
+func Test_EachTask(t *testing.T) {
+	store, mock, cleanup := setup(t)
+	defer cleanup()
+
+	columns := []string{"id", "description", "status", "userid", "due_at", "workspace_id"}
+
+	t.Run("Streams the tasks of a user", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT t.id, t.description, t.status, t.userid, t.due_at, t.workspace_id FROM tasks t WHERE t.deleted_at IS NULL AND t.userid = ? ORDER BY t.id")).
+			WithArgs(2).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "A", false, 2, nil, nil).AddRow(3, "B", true, 2, nil, nil))
+
+		var ids []int
+		err := store.EachTask(context.Background(), taskModel.Filter{UserID: 2}, func(t taskModel.Task) error {
+			ids = append(ids, t.ID)
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, []int{1, 3}, ids)
+	})
+
+	t.Run("Labels and user combined", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT t.id, t.description, t.status, t.userid, t.due_at, t.workspace_id FROM tasks t "+
+			"JOIN task_labels tl ON tl.task_id = t.id JOIN labels l ON l.id = tl.label_id AND l.workspace_id = t.workspace_id "+
+			"WHERE t.deleted_at IS NULL AND l.name IN (?) AND t.userid = ? GROUP BY t.id, t.description, t.status, t.userid, t.due_at, t.workspace_id ORDER BY t.id")).
+			WithArgs("bug", 2).
+			WillReturnRows(sqlmock.NewRows(columns))
+
+		err := store.EachTask(context.Background(), taskModel.Filter{UserID: 2, Labels: []string{"bug"}}, func(taskModel.Task) error { return nil })
+		require.NoError(t, err)
+	})
+
+	t.Run("Status and page", func(t *testing.T) {
+		done := true
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT t.id, t.description, t.status, t.userid, t.due_at, t.workspace_id FROM tasks t WHERE t.deleted_at IS NULL AND t.status = ? AND t.id > ? ORDER BY t.id LIMIT ?")).
+			WithArgs(true, 3, 2).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(4, "C", true, 2, nil, nil))
+
+		err := store.EachTask(context.Background(), taskModel.Filter{Status: &done, AfterID: 3, Limit: 2}, func(taskModel.Task) error { return nil })
+		require.NoError(t, err)
+	})
+
+	t.Run("Callback error stops the iteration", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT t.id, t.description, t.status, t.userid, t.due_at, t.workspace_id FROM tasks t WHERE t.deleted_at IS NULL ORDER BY t.id")).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "A", false, 2, nil, nil).AddRow(3, "B", true, 2, nil, nil))
+
+		calls := 0
+		err := store.EachTask(context.Background(), taskModel.Filter{}, func(taskModel.Task) error {
+			calls++
+			return errors.New("client gone")
+		})
+		require.EqualError(t, err, "client gone")
+		require.Equal(t, 1, calls)
+	})
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func Test_GetTrashTask(t *testing.T) {
+	store, mock, cleanup := setup(t)
+	defer cleanup()
+
+	query := regexp.QuoteMeta("SELECT id, description, status, userid, due_at, workspace_id, deleted_at FROM tasks WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC")
+
+	t.Run("Success", func(t *testing.T) {
+		deletedAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+		mock.ExpectQuery(query).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "description", "status", "userid", "due_at", "workspace_id", "deleted_at"}).
+				AddRow(3, "Old task", false, 1, nil, nil, deletedAt))
+
+		tasks, err := store.GetTrashTask(context.Background())
+		require.NoError(t, err)
+		require.Len(t, tasks, 1)
+		require.Equal(t, deletedAt, tasks[0].DeletedAt)
+	})
+
+	t.Run("Query Error", func(t *testing.T) {
+		mock.ExpectQuery(query).WillReturnError(sql.ErrConnDone)
+
+		_, err := store.GetTrashTask(context.Background())
+		require.Error(t, err)
+	})
+}
+
+func Test_RestoreTask(t *testing.T) {
+	store, mock, cleanup := setup(t)
+	defer cleanup()
+
+	owner := regexp.QuoteMeta("SELECT u.deleted_at IS NOT NULL FROM tasks t LEFT JOIN users u ON u.id = t.userid " +
+		"WHERE t.id = ? AND t.deleted_at IS NOT NULL FOR UPDATE")
+	query := regexp.QuoteMeta("UPDATE tasks t LEFT JOIN users u ON u.id = t.userid SET t.deleted_at = NULL, t.userid = u.id " +
+		"WHERE t.id = ? AND t.deleted_at IS NOT NULL")
+	trashed := func(v bool) *sqlmock.Rows { return sqlmock.NewRows([]string{"trashed"}).AddRow(v) }
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(owner).WithArgs(1).WillReturnRows(trashed(false))
+	mock.ExpectExec(query).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	expectSnapshot(mock, 1)
+	mock.ExpectCommit()
+	require.NoError(t, store.RestoreTask(context.Background(), 1))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(owner).WithArgs(2).WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+	require.ErrorIs(t, store.RestoreTask(context.Background(), 2), sql.ErrNoRows)
+
+	// Cascading a user deletion trashes the tasks, which wait for the user to come back
+	mock.ExpectBegin()
+	mock.ExpectQuery(owner).WithArgs(3).WillReturnRows(trashed(true))
+	mock.ExpectRollback()
+	require.ErrorIs(t, store.RestoreTask(context.Background(), 3), taskModel.ErrOwnerTrashed)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(owner).WithArgs(4).WillReturnRows(trashed(false))
+	mock.ExpectExec(query).WithArgs(4).WillReturnError(sql.ErrConnDone)
+	mock.ExpectRollback()
+	require.Error(t, store.RestoreTask(context.Background(), 4))
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func Test_SnapshotTask(t *testing.T) {
+	store, mock, cleanup := setup(t)
+	defer cleanup()
+
+	expectSnapshot(mock, 3, 4)
+	require.NoError(t, store.SnapshotTask(context.Background(), 3, 4))
+
+	// Nothing changed, nothing to record
+	require.NoError(t, store.SnapshotTask(context.Background()))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func Test_PurgeTask(t *testing.T) {
+	store, mock, cleanup := setup(t)
+	defer cleanup()
+
+	before := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	selectIDs := regexp.QuoteMeta("SELECT id FROM tasks WHERE deleted_at IS NOT NULL AND deleted_at < ? FOR UPDATE")
+
+	t.Run("Purges expired tasks", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(selectIDs).WithArgs(before).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4).AddRow(7))
+		mock.ExpectExec(regexp.QuoteMeta("DELETE FROM tasks WHERE id IN (?, ?)")).WithArgs(4, 7).WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+
+		ids, err := store.PurgeTask(context.Background(), before)
+		require.NoError(t, err)
+		require.Equal(t, []int{4, 7}, ids)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Nothing to purge", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(selectIDs).WithArgs(before).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectCommit()
+
+		ids, err := store.PurgeTask(context.Background(), before)
+		require.NoError(t, err)
+		require.Empty(t, ids)
+	})
+
+	t.Run("Delete Error", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(selectIDs).WithArgs(before).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+		mock.ExpectExec(regexp.QuoteMeta("DELETE FROM tasks WHERE id IN (?)")).WillReturnError(sql.ErrConnDone)
+		mock.ExpectRollback()
+
+		_, err := store.PurgeTask(context.Background(), before)
+		require.Error(t, err)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func Test_RevertTask(t *testing.T) {
+	store, mock, cleanup := setup(t)
+	defer cleanup()
+
+	query := regexp.QuoteMeta("UPDATE tasks SET description = ?, status = ?, userid = ?, due_at = ? WHERE id = ? AND deleted_at IS NULL")
+	due := time.Date(2024, 5, 7, 12, 0, 0, 0, time.FixedZone("CEST", 2*60*60))
+
+	mock.ExpectBegin()
+	mock.ExpectExec(query).WithArgs("Old", false, 2, due.UTC(), 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	expectSnapshot(mock, 1)
+	mock.ExpectCommit()
+	require.NoError(t, store.RevertTask(context.Background(), 1, taskModel.Task{Desc: "Old", Userid: 2, Due: &due}))
+
+	mock.ExpectBegin()
+	mock.ExpectExec(query).WithArgs("Old", true, nil, nil, 2).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+	require.ErrorIs(t, store.RevertTask(context.Background(), 2, taskModel.Task{Desc: "Old", Status: true}), sql.ErrNoRows)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func Test_GetRevisionsTask(t *testing.T) {
+	store, mock, cleanup := setup(t)
+	defer cleanup()
+
+	columns := []string{"task_id", "revision", "at", "deleted", "description", "status", "userid", "due_at"}
+	at := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("All revisions", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT task_id, revision, at, deleted, description, status, userid, due_at FROM task_revisions WHERE task_id = ? ORDER BY revision")).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(1, 1, at, false, "Draft", false, 2, nil).
+				AddRow(1, 2, at.Add(time.Hour), false, "Draft", true, nil, nil))
+
+		revisions, err := store.GetRevisionsTask(context.Background(), 1)
+		require.NoError(t, err)
+		require.Len(t, revisions, 2)
+		require.Equal(t, 1, revisions[1].Task.ID)
+		require.Equal(t, 0, revisions[1].Task.Userid)
+	})
+
+	t.Run("One revision", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta("FROM task_revisions WHERE task_id = ? AND revision = ?")).
+			WithArgs(1, 2).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(1, 2, at, false, "Draft", true, 2, nil))
+
+		r, err := store.GetRevisionTask(context.Background(), 1, 2)
+		require.NoError(t, err)
+		require.Equal(t, 2, r.Number)
+	})
+
+	t.Run("As of", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta("FROM task_revisions WHERE task_id = ? AND at <= ? ORDER BY revision DESC LIMIT 1")).
+			WithArgs(1, at).
+			WillReturnError(sql.ErrNoRows)
+
+		_, err := store.GetAsOfTask(context.Background(), 1, at)
+		require.ErrorIs(t, err, sql.ErrNoRows)
+	})
+}
+
+func Test_BulkTask(t *testing.T) {
+	lockQuery := regexp.QuoteMeta("SELECT id, description, status, userid, due_at, workspace_id FROM tasks WHERE id IN (?, ?, ?, ?) AND deleted_at IS NULL FOR UPDATE")
+	insert := regexp.QuoteMeta("INSERT INTO tasks (description, status, userid, workspace_id) VALUES (?, ?, ?, ?)")
+	columns := []string{"id", "description", "status", "userid", "due_at", "workspace_id"}
+
+	ops := []taskModel.BulkOp{
+		{Op: taskModel.BulkCreate, Desc: "New", Userid: 2},
+		{Op: taskModel.BulkCreate, Desc: "Newer", Userid: 3, WorkspaceID: 5},
+		{Op: taskModel.BulkUpdate, ID: 10, Desc: "Renamed"},
+		{Op: taskModel.BulkReassign, ID: 11, Userid: 3},
+		{Op: taskModel.BulkComplete, ID: 12},
+		{Op: taskModel.BulkDelete, ID: 13},
+	}
+
+	t.Run("Applies each kind of operation", func(t *testing.T) {
+		store, mock, cleanup := setup(t)
+		defer cleanup()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(lockQuery).WithArgs(10, 11, 12, 13).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(10, "Old", false, 2, nil, nil).
+				AddRow(11, "Mine", false, 2, nil, nil).
+				AddRow(12, "Open", false, 2, nil, nil).
+				AddRow(13, "Stale", false, nil, nil, nil))
+		// Creations are inserted one by one, so IDs need not be consecutive
+		mock.ExpectExec(insert).WithArgs("New", false, 2, nil).WillReturnResult(sqlmock.NewResult(20, 1))
+		mock.ExpectExec(insert).WithArgs("Newer", false, 3, 5).WillReturnResult(sqlmock.NewResult(22, 1))
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE tasks SET description = CASE id WHEN ? THEN ? END WHERE id IN (?)")).
+			WithArgs(10, "Renamed", 10).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE tasks SET userid = CASE id WHEN ? THEN ? END WHERE id IN (?)")).
+			WithArgs(11, 3, 11).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE tasks SET status = true WHERE id IN (?)")).
+			WithArgs(12).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE tasks SET deleted_at = ? WHERE id IN (?)")).
+			WithArgs(sqlmock.AnyArg(), 13).WillReturnResult(sqlmock.NewResult(0, 1))
+		expectSnapshot(mock, 20, 22, 10, 11, 12, 13)
+		mock.ExpectCommit()
+
+		results, err := store.BulkTask(context.Background(), ops, true)
+		require.NoError(t, err)
+		require.Len(t, results, len(ops))
+
+		for _, r := range results {
+			require.Equal(t, taskModel.BulkOK, r.Status, r.Op)
+		}
+
+		require.Equal(t, 20, results[0].Task.ID)
+		require.Equal(t, 22, results[1].Task.ID)
+		require.Equal(t, 5, results[1].Task.WorkspaceID)
+		require.Equal(t, "Old", results[2].Before.Desc)
+		require.Equal(t, "Renamed", results[2].Task.Desc)
+		require.Equal(t, 3, results[3].Task.Userid)
+		require.True(t, results[4].Task.Status)
+		require.Nil(t, results[5].Task)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Atomic batch rolls back on a missing task", func(t *testing.T) {
+		store, mock, cleanup := setup(t)
+		defer cleanup()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(lockQuery).WithArgs(10, 11, 12, 13).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(10, "Old", false, 2, nil, nil).AddRow(11, "Mine", false, 2, nil, nil).AddRow(12, "Open", false, 2, nil, nil))
+		mock.ExpectRollback()
+
+		results, err := store.BulkTask(context.Background(), ops, true)
+		require.ErrorIs(t, err, taskModel.ErrBulkFailed)
+		require.Equal(t, taskModel.BulkFailed, results[5].Status)
+		require.Equal(t, taskModel.BulkRolledBack, results[0].Status)
+		require.Nil(t, results[0].Task)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Best effort skips a missing task", func(t *testing.T) {
+		store, mock, cleanup := setup(t)
+		defer cleanup()
+
+		best := []taskModel.BulkOp{{Op: taskModel.BulkComplete, ID: 12}, {Op: taskModel.BulkComplete, ID: 14}}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, description, status, userid, due_at, workspace_id FROM tasks WHERE id IN (?, ?) AND deleted_at IS NULL FOR UPDATE")).
+			WithArgs(12, 14).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(12, "Open", false, 2, nil, nil))
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE tasks SET status = true WHERE id IN (?)")).
+			WithArgs(12).WillReturnResult(sqlmock.NewResult(0, 1))
+		expectSnapshot(mock, 12)
+		mock.ExpectCommit()
+
+		results, err := store.BulkTask(context.Background(), best, false)
+		require.NoError(t, err)
+		require.Equal(t, taskModel.BulkOK, results[0].Status)
+		require.Equal(t, taskModel.BulkFailed, results[1].Status)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Statement error rolls back", func(t *testing.T) {
+		store, mock, cleanup := setup(t)
+		defer cleanup()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(insert).
+			WillReturnError(errors.New("deadlock"))
+		mock.ExpectRollback()
+
+		_, err := store.BulkTask(context.Background(), ops[:1], false)
+		require.Error(t, err)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func Test_ReplicaReads(t *testing.T) {
+	store, mock, cleanup := setup(t)
+	defer cleanup()
+
+	db, replicaMock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	replicas := replica.New(time.Second)
+	replicas.Add("db2", db)
+	store.SetReplicas(replicas)
+
+	replicaMock.ExpectPing()
+	replicaMock.ExpectQuery(regexp.QuoteMeta("SHOW REPLICA STATUS")).
+		WillReturnRows(sqlmock.NewRows([]string{"Seconds_Behind_Source"}).AddRow(0))
+	require.NoError(t, replicas.Check(context.Background()))
+
+	query := regexp.QuoteMeta("SELECT id, description, status, userid, due_at, workspace_id FROM tasks WHERE id = ? AND deleted_at IS NULL")
+	rows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "description", "status", "userid", "due_at", "workspace_id"}).
+			AddRow(1, "Do homework", false, 1, nil, nil)
+	}
+
+	replicaMock.ExpectQuery(query).WithArgs(1).WillReturnRows(rows())
+	_, err = store.GetByIDTask(context.Background(), 1)
+	require.NoError(t, err)
+
+	// A client that just wrote reads the primary
+	mock.ExpectQuery(query).WithArgs(1).WillReturnRows(rows())
+	_, err = store.GetByIDTask(replica.Primary(context.Background()), 1)
+	require.NoError(t, err)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+	require.NoError(t, replicaMock.ExpectationsWereMet())
+}
+
+func Test_PreparedStatements(t *testing.T) {
+	store, mock, cleanup := setup(t)
+	defer cleanup()
+
+	query := regexp.QuoteMeta("SELECT id, description, status, userid, due_at, workspace_id FROM tasks WHERE id = ? AND deleted_at IS NULL")
+	rows := func(id int) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "description", "status", "userid", "due_at", "workspace_id"}).
+			AddRow(id, "Do homework", false, 1, nil, nil)
+	}
+
+	// Prepared on the first lookup, then reused by the next ones
+	mock.ExpectPrepare(query).ExpectQuery().WithArgs(1).WillReturnRows(rows(1))
+	mock.ExpectQuery(query).WithArgs(2).WillReturnRows(rows(2))
+
+	for _, id := range []int{1, 2} {
+		tsk, err := store.GetByIDTask(context.Background(), id)
+		require.NoError(t, err)
+		require.Equal(t, id, tsk.ID)
+	}
 
+	require.NoError(t, mock.ExpectationsWereMet())
 }
@@ -0,0 +1,214 @@
+// Package uow runs the statements of several stores in one database transaction. WithTx starts a
+// transaction and carries it in the context; store methods run their statements on Conn(ctx, db), and
+// begin their own transactions with Begin(ctx, db), so that inside a unit of work they all join the
+// same transaction and outside one they behave as before.
+package uow
+
+import (
+	"Task_Manager/metrics"
+	"context"
+	"database/sql"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+const (
+	mysqlLockWaitTimeout = 1205
+	mysqlDeadlock        = 1213
+
+	// DefaultAttempts is how many times a unit of work is run before its error is returned
+	DefaultAttempts = 3
+	// DefaultBackoff is the wait before the first retry; it doubles for each further retry
+	DefaultBackoff = 20 * time.Millisecond
+)
+
+var retries = metrics.Default.NewCounterVec("db_transaction_retries_total",
+	"Units of work run again after the database aborted their transaction, by reason", "reason")
+
+// DB runs statements, on the database itself or on a transaction
+type DB interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+type txKey struct{}
+
+// active is the state of the unit of work running in a context
+type active struct {
+	tx *sql.Tx
+	// committed are run once the transaction has committed
+	committed []func()
+}
+
+func from(ctx context.Context) (*active, bool) {
+	a, ok := ctx.Value(txKey{}).(*active)
+	return a, ok
+}
+
+// Active reports whether ctx runs in a unit of work. What is read there may not be committed yet.
+func Active(ctx context.Context) bool {
+	_, ok := from(ctx)
+	return ok
+}
+
+// Conn is what a store runs a statement on: the transaction of the unit of work in ctx, or db
+func Conn(ctx context.Context, db *sql.DB) DB {
+	if a, ok := from(ctx); ok {
+		return a.tx
+	}
+
+	return db
+}
+
+// AfterCommit runs fn once the changes made so far are visible to other connections: when the unit of
+// work in ctx commits, or right away outside a unit. fn is dropped when the unit rolls back.
+func AfterCommit(ctx context.Context, fn func()) {
+	if a, ok := from(ctx); ok {
+		a.committed = append(a.committed, fn)
+		return
+	}
+
+	fn()
+}
+
+// Tx is a transaction begun by a store method. Within a unit of work it is the transaction of the unit,
+// and Commit and Rollback are left to the unit of work.
+type Tx struct {
+	*sql.Tx
+	joined bool
+}
+
+// Begin starts a transaction on db, or joins the one of the unit of work in ctx
+func Begin(ctx context.Context, db *sql.DB) (*Tx, error) {
+	if a, ok := from(ctx); ok {
+		return &Tx{Tx: a.tx, joined: true}, nil
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Tx{Tx: tx}, nil
+}
+
+func (t *Tx) Commit() error {
+	if t.joined {
+		return nil
+	}
+
+	return t.Tx.Commit()
+}
+
+// Rollback leaves a joined transaction alone: the error that made the store roll back fails the
+// unit of work, which then rolls the whole transaction back
+func (t *Tx) Rollback() error {
+	if t.joined {
+		return nil
+	}
+
+	return t.Tx.Rollback()
+}
+
+// UnitOfWork runs functions in a transaction, again when the database aborts it to break a deadlock
+type UnitOfWork struct {
+	db       *sql.DB
+	attempts int
+	backoff  time.Duration
+}
+
+func New(db *sql.DB) *UnitOfWork {
+	return &UnitOfWork{db: db, attempts: DefaultAttempts, backoff: DefaultBackoff}
+}
+
+// WithTx runs fn in a transaction, committed when fn returns nil and rolled back otherwise. When the
+// transaction is aborted by a deadlock or a lock wait timeout, fn is run again in a new transaction, so
+// fn must not have effects outside the database it would be wrong to repeat. Called within a unit of
+// work, fn joins the transaction already running, and retrying is left to the outer unit.
+func (u *UnitOfWork) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := from(ctx); ok {
+		return fn(ctx)
+	}
+
+	var err error
+
+	for attempt := 0; attempt < u.attempts; attempt++ {
+		if attempt > 0 {
+			retries.Inc(reason(err))
+
+			wait := u.backoff << (attempt - 1)
+			if u.backoff > 0 {
+				// The jitter keeps the transactions that deadlocked from meeting again
+				wait += rand.N(u.backoff)
+			}
+
+			select {
+			case <-ctx.Done():
+				return errors.Join(err, ctx.Err())
+			case <-time.After(wait):
+			}
+		}
+
+		err = u.run(ctx, fn)
+		if !Retryable(err) {
+			return err
+		}
+	}
+
+	return err
+}
+
+func (u *UnitOfWork) run(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	a := &active{tx: tx}
+	if err = fn(context.WithValue(ctx, txKey{}, a)); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+
+	for _, f := range a.committed {
+		f()
+	}
+
+	return nil
+}
+
+// Retryable reports whether err aborted a transaction that may succeed when run again
+func Retryable(err error) bool {
+	return reason(err) != ""
+}
+
+func reason(err error) string {
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		return ""
+	}
+
+	switch mysqlErr.Number {
+	case mysqlDeadlock:
+		return "deadlock"
+	case mysqlLockWaitTimeout:
+		return "lock_wait_timeout"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,211 @@
+package uow
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/require"
+)
+
+func setup(t *testing.T) (*UnitOfWork, sqlmock.Sqlmock, func()) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	cleanup := func() { _ = db.Close() }
+	u := New(db)
+	u.backoff = 0
+	return u, mock, cleanup
+}
+
+var deadlock = &mysql.MySQLError{Number: mysqlDeadlock, Message: "Deadlock found when trying to get lock"}
+
+func Test_WithTx(t *testing.T) {
+	t.Run("Commits the statements of every store", func(t *testing.T) {
+		u, mock, cleanup := setup(t)
+		defer cleanup()
+
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE users").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO tasks").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		err := u.WithTx(context.Background(), func(ctx context.Context) error {
+			if _, err := Conn(ctx, u.db).ExecContext(ctx, "UPDATE users SET name = 'a'"); err != nil {
+				return err
+			}
+
+			// A store beginning its own transaction joins the unit's
+			tx, err := Begin(ctx, u.db)
+			if err != nil {
+				return err
+			}
+
+			if _, err := tx.ExecContext(ctx, "INSERT INTO tasks VALUES (1)"); err != nil {
+				return err
+			}
+
+			return tx.Commit()
+		})
+
+		require.NoError(t, err)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Rolls back on error", func(t *testing.T) {
+		u, mock, cleanup := setup(t)
+		defer cleanup()
+
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+
+		err := u.WithTx(context.Background(), func(ctx context.Context) error {
+			return errors.New("user gone")
+		})
+
+		require.EqualError(t, err, "user gone")
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Retries after a deadlock", func(t *testing.T) {
+		u, mock, cleanup := setup(t)
+		defer cleanup()
+
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO tasks").WillReturnError(deadlock)
+		mock.ExpectRollback()
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO tasks").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		calls := 0
+		err := u.WithTx(context.Background(), func(ctx context.Context) error {
+			calls++
+			_, err := Conn(ctx, u.db).ExecContext(ctx, "INSERT INTO tasks VALUES (1)")
+			return err
+		})
+
+		require.NoError(t, err)
+		require.Equal(t, 2, calls)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Gives up after the last attempt", func(t *testing.T) {
+		u, mock, cleanup := setup(t)
+		defer cleanup()
+
+		for range DefaultAttempts {
+			mock.ExpectBegin()
+			mock.ExpectRollback()
+		}
+
+		calls := 0
+		err := u.WithTx(context.Background(), func(ctx context.Context) error {
+			calls++
+			return deadlock
+		})
+
+		require.ErrorIs(t, err, deadlock)
+		require.Equal(t, DefaultAttempts, calls)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("A nested unit joins the outer transaction", func(t *testing.T) {
+		u, mock, cleanup := setup(t)
+		defer cleanup()
+
+		mock.ExpectBegin()
+		mock.ExpectCommit()
+
+		err := u.WithTx(context.Background(), func(ctx context.Context) error {
+			return u.WithTx(ctx, func(context.Context) error { return nil })
+		})
+
+		require.NoError(t, err)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Rolls back when fn panics", func(t *testing.T) {
+		u, mock, cleanup := setup(t)
+		defer cleanup()
+
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+
+		require.Panics(t, func() {
+			_ = u.WithTx(context.Background(), func(context.Context) error { panic("boom") })
+		})
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func Test_OutsideUnit(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	ctx := context.Background()
+	require.Equal(t, db, Conn(ctx, db))
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	tx, err := Begin(ctx, db)
+	require.NoError(t, err)
+	require.NoError(t, tx.Rollback())
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func Test_Retryable(t *testing.T) {
+	require.True(t, Retryable(deadlock))
+	require.True(t, Retryable(&mysql.MySQLError{Number: mysqlLockWaitTimeout}))
+	require.False(t, Retryable(&mysql.MySQLError{Number: 1062}))
+	require.False(t, Retryable(errors.New("connection refused")))
+	require.False(t, Retryable(nil))
+}
+
+func Test_AfterCommit(t *testing.T) {
+	t.Run("Runs once the unit commits", func(t *testing.T) {
+		u, mock, cleanup := setup(t)
+		defer cleanup()
+
+		ran := false
+
+		mock.ExpectBegin()
+		mock.ExpectCommit()
+
+		err := u.WithTx(context.Background(), func(ctx context.Context) error {
+			AfterCommit(ctx, func() { ran = true })
+			require.False(t, ran)
+
+			return nil
+		})
+
+		require.NoError(t, err)
+		require.True(t, ran)
+	})
+
+	t.Run("Is dropped when the unit rolls back", func(t *testing.T) {
+		u, mock, cleanup := setup(t)
+		defer cleanup()
+
+		ran := false
+
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+
+		_ = u.WithTx(context.Background(), func(ctx context.Context) error {
+			AfterCommit(ctx, func() { ran = true })
+			return errors.New("failed")
+		})
+
+		require.False(t, ran)
+	})
+
+	t.Run("Runs right away outside a unit", func(t *testing.T) {
+		ran := false
+		AfterCommit(context.Background(), func() { ran = true })
+		require.True(t, ran)
+	})
+}
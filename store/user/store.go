@@ -1,24 +1,83 @@
 package user
 
 import (
+	"Task_Manager/metrics"
 	"Task_Manager/model/user"
+	"Task_Manager/store/prepared"
+	"Task_Manager/store/replica"
+	"Task_Manager/store/uow"
+	"context"
 	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
 )
 
+// observe times every store method
+var observe = metrics.QueryTimer("user")
+
+const mysqlDuplicateEntry = 1062
+
+// userColumns are the columns read into a user.User by scanUser
+const userColumns = "id, name, email, display_name, avatar_url, timezone"
+
 type UserStore struct {
-	DB *sql.DB
+	DB       *sql.DB
+	replicas *replica.Set
+	// stmts keeps the statements of the single statement methods prepared
+	stmts *prepared.Registry
 }
 
 func NewUserStore(db *sql.DB) *UserStore {
-	return &UserStore{DB: db}
+	return &UserStore{DB: db, stmts: prepared.New(db)}
+}
+
+// SetReplicas routes the reads of the store to replicas of DB
+func (us *UserStore) SetReplicas(r *replica.Set) {
+	us.replicas = r
+}
+
+// reader is what the read-only methods run their statements on
+func (us *UserStore) reader(ctx context.Context) uow.DB {
+	return us.stmts.On(us.replicas.Conn(ctx, us.DB))
+}
+
+// writer is what the single statement methods that change or lock users run their statement on
+func (us *UserStore) writer(ctx context.Context) uow.DB {
+	return us.stmts.On(uow.Conn(ctx, us.DB))
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanUser(row rowScanner, dest ...any) (user.User, error) {
+	var u user.User
+	err := row.Scan(append([]any{&u.ID, &u.Name, &u.Email, &u.DisplayName, &u.AvatarURL, &u.Timezone}, dest...)...)
+
+	return u, err
+}
+
+// translate maps a clash on the unique index of live emails onto user.ErrEmailTaken
+func translate(err error) error {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry {
+		return user.ErrEmailTaken
+	}
+
+	return err
 }
 
-func (us *UserStore) CreateUser(user user.User) (user.User, error) {
-	query := "INSERT INTO users (name, email) VALUES (?, ?)"
-	result, err := us.DB.Exec(query, user.Name, user.Email)
+func (us *UserStore) CreateUser(ctx context.Context, user user.User) (user.User, error) {
+	defer observe("CreateUser")()
+
+	query := "INSERT INTO users (name, email, display_name, avatar_url, timezone) VALUES (?, ?, ?, ?, ?)"
+	result, err := us.writer(ctx).ExecContext(ctx, query, user.Name, user.Email, user.DisplayName, user.AvatarURL, user.Timezone)
 
 	if err != nil {
-		return user, err
+		return user, translate(err)
 	}
 
 	id, _ := result.LastInsertId()
@@ -27,27 +86,137 @@ func (us *UserStore) CreateUser(user user.User) (user.User, error) {
 	return user, nil
 }
 
-func (us *UserStore) GetByIDUser(id int) (user.User, error) {
-	var user user.User
+func (us *UserStore) GetByIDUser(ctx context.Context, id int) (user.User, error) {
+	defer observe("GetByIDUser")()
+
+	query := "SELECT " + userColumns + " FROM users WHERE id = ? AND deleted_at IS NULL"
+
+	return scanUser(us.reader(ctx).QueryRowContext(ctx, query, id))
+}
+
+// LockUser fetches a live user and keeps it from being changed or deleted until the transaction of the unit
+// of work in ctx ends, so that what is created for the user within the unit cannot be left orphaned
+func (us *UserStore) LockUser(ctx context.Context, id int) (user.User, error) {
+	defer observe("LockUser")()
+
+	query := "SELECT " + userColumns + " FROM users WHERE id = ? AND deleted_at IS NULL LOCK IN SHARE MODE"
+
+	return scanUser(us.writer(ctx).QueryRowContext(ctx, query, id))
+}
+
+// GetByEmailUser fetches a live user by normalised email address
+func (us *UserStore) GetByEmailUser(ctx context.Context, email string) (user.User, error) {
+	defer observe("GetByEmailUser")()
+
+	query := "SELECT " + userColumns + " FROM users WHERE live_email = ?"
+
+	return scanUser(us.reader(ctx).QueryRowContext(ctx, query, email))
+}
 
-	query := "SELECT id, name, email FROM users WHERE id = ?"
-	err := us.DB.QueryRow(query, id).Scan(&user.ID, &user.Name, &user.Email)
+// UpdateUser saves the name and profile fields of a live user; the email address is left alone
+func (us *UserStore) UpdateUser(ctx context.Context, u user.User) error {
+	defer observe("UpdateUser")()
 
+	res, err := us.writer(ctx).ExecContext(ctx, "UPDATE users SET name = ?, display_name = ?, avatar_url = ?, timezone = ? WHERE id = ? AND deleted_at IS NULL",
+		u.Name, u.DisplayName, u.AvatarURL, u.Timezone, u.ID)
 	if err != nil {
-		return user, err
+		return err
 	}
 
-	return user, err
+	return oneRow(res)
 }
 
-func (us *UserStore) DeleteUser(id int) error {
-	_, err := us.DB.Exec("DELETE FROM users WHERE id = ?", id)
+// SaveEmailChangeUser records a pending email change, replacing any earlier one of the same user.
+// Only the SHA-256 of the token is stored, so a leaked table cannot be used to confirm changes.
+func (us *UserStore) SaveEmailChangeUser(ctx context.Context, c user.EmailChange, tokenHash string) error {
+	defer observe("SaveEmailChangeUser")()
+
+	_, err := us.writer(ctx).ExecContext(ctx, "INSERT INTO email_changes (user_id, email, token_hash, expires_at) VALUES (?, ?, ?, ?) "+
+		"ON DUPLICATE KEY UPDATE email = VALUES(email), token_hash = VALUES(token_hash), expires_at = VALUES(expires_at)",
+		c.UserID, c.Email, tokenHash, c.ExpiresAt)
+
 	return err
 }
 
-func (us *UserStore) GetAllUser() ([]user.User, error) {
-	query := "SELECT id, name, email FROM users"
-	rows, err := us.DB.Query(query)
+// ConfirmEmailChangeUser applies the pending email change of a user whose token hash matches and has not
+// expired at now, returning the user as before the change. sql.ErrNoRows means there is no such change.
+func (us *UserStore) ConfirmEmailChangeUser(ctx context.Context, id int, tokenHash string, now time.Time) (before user.User, email string, err error) {
+	defer observe("ConfirmEmailChangeUser")()
+
+	tx, err := uow.Begin(ctx, us.DB)
+	if err != nil {
+		return before, "", err
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	before, err = scanUser(tx.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE id = ? AND deleted_at IS NULL FOR UPDATE", id))
+	if err != nil {
+		return before, "", err
+	}
+
+	err = tx.QueryRowContext(ctx, "SELECT email FROM email_changes WHERE user_id = ? AND token_hash = ? AND expires_at > ? FOR UPDATE",
+		id, tokenHash, now).Scan(&email)
+	if err != nil {
+		return before, "", err
+	}
+
+	if _, err = tx.ExecContext(ctx, "UPDATE users SET email = ? WHERE id = ?", email, id); err != nil {
+		err = translate(err)
+		return before, "", err
+	}
+
+	if _, err = tx.ExecContext(ctx, "DELETE FROM email_changes WHERE user_id = ?", id); err != nil {
+		return before, "", err
+	}
+
+	return before, email, tx.Commit()
+}
+
+// oneRow turns an update that matched no row into sql.ErrNoRows
+func oneRow(res sql.Result) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// DeleteUser moves a user to the trash; it stays restorable until purged
+func (us *UserStore) DeleteUser(ctx context.Context, id int) error {
+	defer observe("DeleteUser")()
+
+	res, err := us.writer(ctx).ExecContext(ctx, "UPDATE users SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL", time.Now().UTC(), id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (us *UserStore) GetAllUser(ctx context.Context) ([]user.User, error) {
+	defer observe("GetAllUser")()
+
+	query := "SELECT " + userColumns + " FROM users WHERE deleted_at IS NULL"
+	rows, err := us.reader(ctx).QueryContext(ctx, query)
 
 	if err != nil {
 		return nil, err
@@ -63,8 +232,8 @@ func (us *UserStore) GetAllUser() ([]user.User, error) {
 	var users []user.User
 
 	for rows.Next() {
-		var u user.User
-		if err := rows.Scan(&u.ID, &u.Name, &u.Email); err != nil {
+		u, err := scanUser(rows)
+		if err != nil {
 			return nil, err
 		}
 
@@ -74,3 +243,312 @@ func (us *UserStore) GetAllUser() ([]user.User, error) {
 	return users, nil
 
 }
+
+// GetByIDsUser returns the live users with the given IDs in one query, in ID order. IDs of missing or
+// deleted users are skipped.
+func (us *UserStore) GetByIDsUser(ctx context.Context, ids []int) ([]user.User, error) {
+	defer observe("GetByIDsUser")()
+
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+
+	// Not prepared: every number of IDs is another statement
+	query := "SELECT " + userColumns + " FROM users WHERE id IN (" + strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ") +
+		") AND deleted_at IS NULL ORDER BY id"
+
+	rows, err := us.replicas.Conn(ctx, us.DB).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	var users []user.User
+
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		users = append(users, u)
+	}
+
+	return users, rows.Err()
+}
+
+// GetTrashUser lists the users in the trash, most recently deleted first
+func (us *UserStore) GetTrashUser(ctx context.Context) ([]user.Trashed, error) {
+	defer observe("GetTrashUser")()
+
+	rows, err := us.reader(ctx).QueryContext(ctx, "SELECT "+userColumns+", deleted_at FROM users WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC")
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	var users []user.Trashed
+
+	for rows.Next() {
+		var u user.Trashed
+
+		u.User, err = scanUser(rows, &u.DeletedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		users = append(users, u)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// RestoreUser takes a user out of the trash
+func (us *UserStore) RestoreUser(ctx context.Context, id int) error {
+	defer observe("RestoreUser")()
+
+	res, err := us.writer(ctx).ExecContext(ctx, "UPDATE users SET deleted_at = NULL WHERE id = ? AND deleted_at IS NOT NULL", id)
+	if err != nil {
+		// Another live user may have taken the address while this one was in the trash
+		return translate(err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// PurgeUser permanently deletes the users trashed before the given time and returns their IDs
+func (us *UserStore) PurgeUser(ctx context.Context, before time.Time) (ids []int, err error) {
+	defer observe("PurgeUser")()
+
+	tx, err := uow.Begin(ctx, us.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	rows, err := tx.QueryContext(ctx, "SELECT id FROM users WHERE deleted_at IS NOT NULL AND deleted_at < ? FOR UPDATE", before)
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err = scanIDs(rows)
+	_ = rows.Close()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ids) == 0 {
+		return nil, tx.Commit()
+	}
+
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM users WHERE id IN ("+strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")+")", args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, tx.Commit()
+}
+
+// GetTaskIDsUser returns the IDs of the live and of the trashed tasks assigned to a user
+func (us *UserStore) GetTaskIDsUser(ctx context.Context, id int) (live, trashed []int, err error) {
+	defer observe("GetTaskIDsUser")()
+
+	rows, err := us.reader(ctx).QueryContext(ctx, "SELECT id, deleted_at IS NOT NULL FROM tasks WHERE userid = ? ORDER BY id", id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	return scanTaskIDs(rows)
+}
+
+// UnassignTasksUser removes the assignee of every task of a user, live or in the trash, and returns their IDs
+func (us *UserStore) UnassignTasksUser(ctx context.Context, id int) (ids []int, err error) {
+	defer observe("UnassignTasksUser")()
+
+	tx, err := uow.Begin(ctx, us.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	rows, err := tx.QueryContext(ctx, "SELECT id FROM tasks WHERE userid = ? ORDER BY id FOR UPDATE", id)
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err = scanIDs(rows)
+	_ = rows.Close()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ids) > 0 {
+		if _, err = tx.ExecContext(ctx, "UPDATE tasks SET userid = NULL WHERE userid = ?", id); err != nil {
+			return nil, err
+		}
+	}
+
+	return ids, tx.Commit()
+}
+
+// DeleteWithTasksUser moves a user to the trash and applies the delete policy to the user's tasks in one
+// transaction. It returns the IDs of the tasks the policy changed, as DeletePolicy.Affected does, or with
+// ErrHasTasks the live tasks preventing the deletion; nothing is changed when an error is returned.
+// Recording revisions of the changed tasks is up to the caller, in the same unit of work.
+func (us *UserStore) DeleteWithTasksUser(ctx context.Context, id int, opts user.DeleteOptions) (ids []int, err error) {
+	defer observe("DeleteWithTasksUser")()
+
+	tx, err := uow.Begin(ctx, us.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var locked int
+	if err = tx.QueryRowContext(ctx, "SELECT id FROM users WHERE id = ? AND deleted_at IS NULL FOR UPDATE", id).Scan(&locked); err != nil {
+		return nil, err
+	}
+
+	if opts.Policy == user.PolicyReassign {
+		// The share lock keeps the new assignee from being deleted before the tasks are handed over
+		err = tx.QueryRowContext(ctx, "SELECT id FROM users WHERE id = ? AND deleted_at IS NULL LOCK IN SHARE MODE", opts.ReassignTo).Scan(&locked)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, user.ErrReassignTarget
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rows, err := tx.QueryContext(ctx, "SELECT id, deleted_at IS NOT NULL FROM tasks WHERE userid = ? ORDER BY id FOR UPDATE", id)
+	if err != nil {
+		return nil, err
+	}
+
+	live, trashed, err := scanTaskIDs(rows)
+	_ = rows.Close()
+
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+
+	switch opts.Policy {
+	case user.PolicyReject:
+		if len(live) > 0 {
+			err = user.ErrHasTasks
+			return live, err
+		}
+
+		if len(trashed) > 0 {
+			_, err = tx.ExecContext(ctx, "UPDATE tasks SET userid = NULL WHERE userid = ?", id)
+		}
+	case user.PolicyReassign:
+		_, err = tx.ExecContext(ctx, "UPDATE tasks SET userid = ? WHERE userid = ?", opts.ReassignTo, id)
+	case user.PolicyUnassign:
+		_, err = tx.ExecContext(ctx, "UPDATE tasks SET userid = NULL WHERE userid = ?", id)
+	case user.PolicyCascade:
+		_, err = tx.ExecContext(ctx, "UPDATE tasks SET deleted_at = ? WHERE userid = ? AND deleted_at IS NULL", now, id)
+	default:
+		err = user.ErrInvalidPolicy
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = tx.ExecContext(ctx, "UPDATE users SET deleted_at = ? WHERE id = ?", now, id); err != nil {
+		return nil, err
+	}
+
+	return opts.Policy.Affected(live, trashed), tx.Commit()
+}
+
+// scanTaskIDs splits rows of task IDs and whether the task is in the trash into live and trashed IDs
+func scanTaskIDs(rows *sql.Rows) (live, trashed []int, err error) {
+	for rows.Next() {
+		var (
+			id      int
+			inTrash bool
+		)
+
+		if err := rows.Scan(&id, &inTrash); err != nil {
+			return nil, nil, err
+		}
+
+		if inTrash {
+			trashed = append(trashed, id)
+		} else {
+			live = append(live, id)
+		}
+	}
+
+	return live, trashed, rows.Err()
+}
+
+func scanIDs(rows *sql.Rows) ([]int, error) {
+	var ids []int
+
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
@@ -2,12 +2,16 @@ package user
 
 import (
 	model "Task_Manager/model/user"
+	"Task_Manager/store/uow"
+	"context"
 	"database/sql"
 	"errors"
 	"regexp"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
 	"github.com/stretchr/testify/require"
 )
 
@@ -23,55 +27,125 @@ func Test_CreateUser(t *testing.T) {
 
 	u := model.User{Name: "John", Email: "john@example.com"}
 
-	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO users (name, email) VALUES (?, ?)")).
-		WithArgs(u.Name, u.Email).
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO users (name, email, display_name, avatar_url, timezone) VALUES (?, ?, ?, ?, ?)")).
+		WithArgs(u.Name, u.Email, "", "", "").
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
-	created, err := store.CreateUser(u)
+	created, err := store.CreateUser(context.Background(), u)
 	require.NoError(t, err)
 	require.Equal(t, 1, created.ID)
 
-	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO users (name, email) VALUES (?, ?)")).
-		WithArgs(u.Name, u.Email).
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO users (name, email, display_name, avatar_url, timezone) VALUES (?, ?, ?, ?, ?)")).
+		WithArgs(u.Name, u.Email, "", "", "").
 		WillReturnError(errors.New("insert failed"))
-	_, err = store.CreateUser(u)
+	_, err = store.CreateUser(context.Background(), u)
 	require.Error(t, err)
 }
 
+func Test_CreateUserDuplicateEmail(t *testing.T) {
+	store, mock, cleanup := setupDB(t)
+	defer cleanup()
+
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO users")).
+		WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'john@example.com' for key 'ux_users_live_email'"})
+
+	_, err := store.CreateUser(context.Background(), model.User{Name: "John", Email: "john@example.com"})
+	require.ErrorIs(t, err, model.ErrEmailTaken)
+}
+
 func Test_GetByIDUser(t *testing.T) {
 	store, mock, cleanup := setupDB(t)
 	defer cleanup()
 
-	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, name, email FROM users WHERE id = ?")).
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, name, email, display_name, avatar_url, timezone FROM users WHERE id = ? AND deleted_at IS NULL")).
 		WithArgs(1).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "email"}).
-			AddRow(1, "John", "john@example.com"))
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "email", "display_name", "avatar_url", "timezone"}).
+			AddRow(1, "John", "john@example.com", "", "", ""))
 
-	u, err := store.GetByIDUser(1)
+	u, err := store.GetByIDUser(context.Background(), 1)
 	require.NoError(t, err)
 	require.Equal(t, 1, u.ID)
 
-	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, name, email FROM users WHERE id = ?")).
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, name, email, display_name, avatar_url, timezone FROM users WHERE id = ? AND deleted_at IS NULL")).
 		WithArgs(999).
 		WillReturnError(sql.ErrNoRows)
-	_, err = store.GetByIDUser(999)
+	_, err = store.GetByIDUser(context.Background(), 999)
 	require.Error(t, err)
 }
 
-func Test_DeleteUser(t *testing.T) {
+func Test_LockUser(t *testing.T) {
 	store, mock, cleanup := setupDB(t)
 	defer cleanup()
 
-	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM users WHERE id = ?")).
+	lock := regexp.QuoteMeta("SELECT id, name, email, display_name, avatar_url, timezone FROM users WHERE id = ? AND deleted_at IS NULL LOCK IN SHARE MODE")
+
+	// Within a unit of work the lock is taken in the unit's transaction and held until it commits
+	mock.ExpectBegin()
+	mock.ExpectQuery(lock).
 		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "email", "display_name", "avatar_url", "timezone"}).
+			AddRow(1, "John", "john@example.com", "", "", ""))
+	mock.ExpectCommit()
+
+	err := uow.New(store.DB).WithTx(context.Background(), func(ctx context.Context) error {
+		u, err := store.LockUser(ctx, 1)
+		require.Equal(t, 1, u.ID)
+
+		return err
+	})
+	require.NoError(t, err)
+
+	mock.ExpectQuery(lock).WithArgs(999).WillReturnError(sql.ErrNoRows)
+	_, err = store.LockUser(context.Background(), 999)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func Test_GetByEmailUser(t *testing.T) {
+	store, mock, cleanup := setupDB(t)
+	defer cleanup()
+
+	query := regexp.QuoteMeta("SELECT id, name, email, display_name, avatar_url, timezone FROM users WHERE live_email = ?")
+
+	mock.ExpectQuery(query).
+		WithArgs("john@example.com").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "email", "display_name", "avatar_url", "timezone"}).
+			AddRow(1, "John", "john@example.com", "", "", ""))
+
+	u, err := store.GetByEmailUser(context.Background(), "john@example.com")
+	require.NoError(t, err)
+	require.Equal(t, 1, u.ID)
+
+	mock.ExpectQuery(query).
+		WithArgs("nobody@example.com").
+		WillReturnError(sql.ErrNoRows)
+
+	_, err = store.GetByEmailUser(context.Background(), "nobody@example.com")
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func Test_DeleteUser(t *testing.T) {
+	store, mock, cleanup := setupDB(t)
+	defer cleanup()
+
+	query := regexp.QuoteMeta("UPDATE users SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL")
+
+	mock.ExpectExec(query).
+		WithArgs(sqlmock.AnyArg(), 1).
 		WillReturnResult(sqlmock.NewResult(0, 1))
-	err := store.DeleteUser(1)
+	err := store.DeleteUser(context.Background(), 1)
 	require.NoError(t, err)
 
-	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM users WHERE id = ?")).
-		WithArgs(999).
+	mock.ExpectExec(query).
+		WithArgs(sqlmock.AnyArg(), 2).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	err = store.DeleteUser(context.Background(), 2)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	mock.ExpectExec(query).
+		WithArgs(sqlmock.AnyArg(), 999).
 		WillReturnError(errors.New("delete failed"))
-	err = store.DeleteUser(999)
+	err = store.DeleteUser(context.Background(), 999)
 	require.Error(t, err)
 }
 
@@ -80,23 +154,23 @@ func Test_GetAllUser(t *testing.T) {
 	defer cleanup()
 
 	t.Run("success", func(t *testing.T) {
-		rows := sqlmock.NewRows([]string{"id", "name", "email"}).
-			AddRow(1, "John", "john@example.com").
-			AddRow(2, "Alice", "alice@example.com")
+		rows := sqlmock.NewRows([]string{"id", "name", "email", "display_name", "avatar_url", "timezone"}).
+			AddRow(1, "John", "john@example.com", "", "", "").
+			AddRow(2, "Alice", "alice@example.com", "", "", "")
 
-		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, name, email FROM users")).
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, name, email, display_name, avatar_url, timezone FROM users WHERE deleted_at IS NULL")).
 			WillReturnRows(rows)
 
-		users, err := store.GetAllUser()
+		users, err := store.GetAllUser(context.Background())
 		require.NoError(t, err)
 		require.Len(t, users, 2)
 	})
 
 	t.Run("query error", func(t *testing.T) {
-		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, name, email FROM users")).
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, name, email, display_name, avatar_url, timezone FROM users WHERE deleted_at IS NULL")).
 			WillReturnError(errors.New("query failed"))
 
-		_, err := store.GetAllUser()
+		_, err := store.GetAllUser(context.Background())
 		require.Error(t, err)
 	})
 
@@ -105,11 +179,444 @@ func Test_GetAllUser(t *testing.T) {
 		rows := sqlmock.NewRows([]string{"id", "name"}).
 			AddRow(1, "John")
 
-		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, name, email FROM users")).
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, name, email, display_name, avatar_url, timezone FROM users WHERE deleted_at IS NULL")).
 			WillReturnRows(rows)
 
-		_, err := store.GetAllUser()
+		_, err := store.GetAllUser(context.Background())
+		require.Error(t, err)
+	})
+
+}
+
+func Test_GetByIDsUser(t *testing.T) {
+	store, mock, cleanup := setupDB(t)
+	defer cleanup()
+
+	query := regexp.QuoteMeta("SELECT id, name, email, display_name, avatar_url, timezone FROM users WHERE id IN (?, ?, ?) AND deleted_at IS NULL ORDER BY id")
+
+	mock.ExpectQuery(query).
+		WithArgs(1, 2, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "email", "display_name", "avatar_url", "timezone"}).
+			AddRow(1, "John", "john@example.com", "", "", "").
+			AddRow(3, "Alice", "alice@example.com", "", "", ""))
+
+	users, err := store.GetByIDsUser(context.Background(), []int{1, 2, 3})
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+
+	users, err = store.GetByIDsUser(context.Background(), nil)
+	require.NoError(t, err)
+	require.Empty(t, users)
+
+	mock.ExpectQuery(query).WillReturnError(errors.New("query failed"))
+
+	_, err = store.GetByIDsUser(context.Background(), []int{1, 2, 3})
+	require.Error(t, err)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func Test_GetTrashUser(t *testing.T) {
+	store, mock, cleanup := setupDB(t)
+	defer cleanup()
+
+	query := regexp.QuoteMeta("SELECT id, name, email, display_name, avatar_url, timezone, deleted_at FROM users WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC")
+
+	mock.ExpectQuery(query).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "email", "display_name", "avatar_url", "timezone", "deleted_at"}).
+			AddRow(1, "John", "john@example.com", "", "", "", time.Now()))
+
+	users, err := store.GetTrashUser(context.Background())
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+
+	mock.ExpectQuery(query).WillReturnError(errors.New("query failed"))
+
+	_, err = store.GetTrashUser(context.Background())
+	require.Error(t, err)
+}
+
+func Test_RestoreUser(t *testing.T) {
+	store, mock, cleanup := setupDB(t)
+	defer cleanup()
+
+	query := regexp.QuoteMeta("UPDATE users SET deleted_at = NULL WHERE id = ? AND deleted_at IS NOT NULL")
+
+	mock.ExpectExec(query).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, store.RestoreUser(context.Background(), 1))
+
+	mock.ExpectExec(query).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 0))
+	require.ErrorIs(t, store.RestoreUser(context.Background(), 2), sql.ErrNoRows)
+}
+
+func Test_PurgeUser(t *testing.T) {
+	before := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	selectQuery := regexp.QuoteMeta("SELECT id FROM users WHERE deleted_at IS NOT NULL AND deleted_at < ? FOR UPDATE")
+
+	t.Run("Purges expired users", func(t *testing.T) {
+		store, mock, cleanup := setupDB(t)
+		defer cleanup()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(selectQuery).WithArgs(before).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3).AddRow(5))
+		mock.ExpectExec(regexp.QuoteMeta("DELETE FROM users WHERE id IN (?, ?)")).WithArgs(3, 5).WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+
+		ids, err := store.PurgeUser(context.Background(), before)
+		require.NoError(t, err)
+		require.Equal(t, []int{3, 5}, ids)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Nothing to purge", func(t *testing.T) {
+		store, mock, cleanup := setupDB(t)
+		defer cleanup()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(selectQuery).WithArgs(before).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectCommit()
+
+		ids, err := store.PurgeUser(context.Background(), before)
+		require.NoError(t, err)
+		require.Empty(t, ids)
+	})
+
+	t.Run("Delete failure rolls back", func(t *testing.T) {
+		store, mock, cleanup := setupDB(t)
+		defer cleanup()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(selectQuery).WithArgs(before).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+		mock.ExpectExec(regexp.QuoteMeta("DELETE FROM users WHERE id IN (?)")).WithArgs(3).WillReturnError(errors.New("delete failed"))
+		mock.ExpectRollback()
+
+		_, err := store.PurgeUser(context.Background(), before)
+		require.Error(t, err)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func Test_GetTaskIDsUser(t *testing.T) {
+	store, mock, cleanup := setupDB(t)
+	defer cleanup()
+
+	query := regexp.QuoteMeta("SELECT id, deleted_at IS NOT NULL FROM tasks WHERE userid = ? ORDER BY id")
+
+	mock.ExpectQuery(query).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "trashed"}).
+		AddRow(3, false).AddRow(4, true).AddRow(5, false))
+
+	live, trashed, err := store.GetTaskIDsUser(context.Background(), 1)
+	require.NoError(t, err)
+	require.Equal(t, []int{3, 5}, live)
+	require.Equal(t, []int{4}, trashed)
+
+	mock.ExpectQuery(query).WithArgs(2).WillReturnError(errors.New("query failed"))
+
+	_, _, err = store.GetTaskIDsUser(context.Background(), 2)
+	require.Error(t, err)
+}
+
+func Test_UnassignTasksUser(t *testing.T) {
+	lockTasks := regexp.QuoteMeta("SELECT id FROM tasks WHERE userid = ? ORDER BY id FOR UPDATE")
+	unassign := regexp.QuoteMeta("UPDATE tasks SET userid = NULL WHERE userid = ?")
+
+	t.Run("Unassigns", func(t *testing.T) {
+		store, mock, cleanup := setupDB(t)
+		defer cleanup()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(lockTasks).WithArgs(3).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(6).AddRow(7))
+		mock.ExpectExec(unassign).WithArgs(3).WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+
+		ids, err := store.UnassignTasksUser(context.Background(), 3)
+		require.NoError(t, err)
+		require.Equal(t, []int{6, 7}, ids)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("No tasks", func(t *testing.T) {
+		store, mock, cleanup := setupDB(t)
+		defer cleanup()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(lockTasks).WithArgs(3).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectCommit()
+
+		ids, err := store.UnassignTasksUser(context.Background(), 3)
+		require.NoError(t, err)
+		require.Empty(t, ids)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Update failure rolls back", func(t *testing.T) {
+		store, mock, cleanup := setupDB(t)
+		defer cleanup()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(lockTasks).WithArgs(3).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(6))
+		mock.ExpectExec(unassign).WithArgs(3).WillReturnError(errors.New("deadlock"))
+		mock.ExpectRollback()
+
+		_, err := store.UnassignTasksUser(context.Background(), 3)
 		require.Error(t, err)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func Test_DeleteWithTasksUser(t *testing.T) {
+	lockUser := regexp.QuoteMeta("SELECT id FROM users WHERE id = ? AND deleted_at IS NULL FOR UPDATE")
+	lockTarget := regexp.QuoteMeta("SELECT id FROM users WHERE id = ? AND deleted_at IS NULL LOCK IN SHARE MODE")
+	lockTasks := regexp.QuoteMeta("SELECT id, deleted_at IS NOT NULL FROM tasks WHERE userid = ? ORDER BY id FOR UPDATE")
+	trashUser := regexp.QuoteMeta("UPDATE users SET deleted_at = ? WHERE id = ?")
+
+	userRow := func(id int) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id"}).AddRow(id)
+	}
+
+	// taskRows returns the user's live tasks followed by the trashed ones
+	taskRows := func(live []int, trashed ...int) *sqlmock.Rows {
+		rows := sqlmock.NewRows([]string{"id", "trashed"})
+		for _, id := range live {
+			rows.AddRow(id, false)
+		}
+
+		for _, id := range trashed {
+			rows.AddRow(id, true)
+		}
+
+		return rows
+	}
+
+	t.Run("reject without tasks", func(t *testing.T) {
+		store, mock, cleanup := setupDB(t)
+		defer cleanup()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(lockUser).WithArgs(1).WillReturnRows(userRow(1))
+		mock.ExpectQuery(lockTasks).WithArgs(1).WillReturnRows(taskRows(nil))
+		mock.ExpectExec(trashUser).WithArgs(sqlmock.AnyArg(), 1).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		ids, err := store.DeleteWithTasksUser(context.Background(), 1, model.DeleteOptions{Policy: model.PolicyReject})
+		require.NoError(t, err)
+		require.Empty(t, ids)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("reject with tasks rolls back", func(t *testing.T) {
+		store, mock, cleanup := setupDB(t)
+		defer cleanup()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(lockUser).WithArgs(1).WillReturnRows(userRow(1))
+		mock.ExpectQuery(lockTasks).WithArgs(1).WillReturnRows(taskRows([]int{4, 9}, 6))
+		mock.ExpectRollback()
+
+		ids, err := store.DeleteWithTasksUser(context.Background(), 1, model.DeleteOptions{Policy: model.PolicyReject})
+		require.ErrorIs(t, err, model.ErrHasTasks)
+		require.Equal(t, []int{4, 9}, ids)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("reject with only trashed tasks unassigns them", func(t *testing.T) {
+		store, mock, cleanup := setupDB(t)
+		defer cleanup()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(lockUser).WithArgs(1).WillReturnRows(userRow(1))
+		mock.ExpectQuery(lockTasks).WithArgs(1).WillReturnRows(taskRows(nil, 6, 7))
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE tasks SET userid = NULL WHERE userid = ?")).
+			WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(trashUser).WithArgs(sqlmock.AnyArg(), 1).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		ids, err := store.DeleteWithTasksUser(context.Background(), 1, model.DeleteOptions{Policy: model.PolicyReject})
+		require.NoError(t, err)
+		require.Equal(t, []int{6, 7}, ids, "the unassigned trashed tasks are reported")
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("reassign", func(t *testing.T) {
+		store, mock, cleanup := setupDB(t)
+		defer cleanup()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(lockUser).WithArgs(1).WillReturnRows(userRow(1))
+		mock.ExpectQuery(lockTarget).WithArgs(2).WillReturnRows(userRow(2))
+		mock.ExpectQuery(lockTasks).WithArgs(1).WillReturnRows(taskRows([]int{4}, 6))
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE tasks SET userid = ? WHERE userid = ?")).
+			WithArgs(2, 1).WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(trashUser).WithArgs(sqlmock.AnyArg(), 1).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		ids, err := store.DeleteWithTasksUser(context.Background(), 1, model.DeleteOptions{Policy: model.PolicyReassign, ReassignTo: 2})
+		require.NoError(t, err)
+		require.Equal(t, []int{4, 6}, ids, "trashed tasks are reported too")
+		require.NoError(t, mock.ExpectationsWereMet())
 	})
 
+	t.Run("reassign to missing user", func(t *testing.T) {
+		store, mock, cleanup := setupDB(t)
+		defer cleanup()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(lockUser).WithArgs(1).WillReturnRows(userRow(1))
+		mock.ExpectQuery(lockTarget).WithArgs(7).WillReturnError(sql.ErrNoRows)
+		mock.ExpectRollback()
+
+		_, err := store.DeleteWithTasksUser(context.Background(), 1, model.DeleteOptions{Policy: model.PolicyReassign, ReassignTo: 7})
+		require.ErrorIs(t, err, model.ErrReassignTarget)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("unassign", func(t *testing.T) {
+		store, mock, cleanup := setupDB(t)
+		defer cleanup()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(lockUser).WithArgs(1).WillReturnRows(userRow(1))
+		mock.ExpectQuery(lockTasks).WithArgs(1).WillReturnRows(taskRows([]int{4}))
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE tasks SET userid = NULL WHERE userid = ?")).
+			WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(trashUser).WithArgs(sqlmock.AnyArg(), 1).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		_, err := store.DeleteWithTasksUser(context.Background(), 1, model.DeleteOptions{Policy: model.PolicyUnassign})
+		require.NoError(t, err)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("cascade", func(t *testing.T) {
+		store, mock, cleanup := setupDB(t)
+		defer cleanup()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(lockUser).WithArgs(1).WillReturnRows(userRow(1))
+		mock.ExpectQuery(lockTasks).WithArgs(1).WillReturnRows(taskRows([]int{4, 5}, 6))
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE tasks SET deleted_at = ? WHERE userid = ? AND deleted_at IS NULL")).
+			WithArgs(sqlmock.AnyArg(), 1).WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(trashUser).WithArgs(sqlmock.AnyArg(), 1).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		ids, err := store.DeleteWithTasksUser(context.Background(), 1, model.DeleteOptions{Policy: model.PolicyCascade})
+		require.NoError(t, err)
+		require.Equal(t, []int{4, 5}, ids, "tasks already in the trash are left as they are")
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("cascade failure rolls back", func(t *testing.T) {
+		store, mock, cleanup := setupDB(t)
+		defer cleanup()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(lockUser).WithArgs(1).WillReturnRows(userRow(1))
+		mock.ExpectQuery(lockTasks).WithArgs(1).WillReturnRows(taskRows([]int{4, 5}))
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE tasks SET deleted_at = ? WHERE userid = ? AND deleted_at IS NULL")).
+			WithArgs(sqlmock.AnyArg(), 1).WillReturnError(errors.New("deadlock"))
+		mock.ExpectRollback()
+
+		_, err := store.DeleteWithTasksUser(context.Background(), 1, model.DeleteOptions{Policy: model.PolicyCascade})
+		require.Error(t, err)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("missing user", func(t *testing.T) {
+		store, mock, cleanup := setupDB(t)
+		defer cleanup()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(lockUser).WithArgs(9).WillReturnError(sql.ErrNoRows)
+		mock.ExpectRollback()
+
+		_, err := store.DeleteWithTasksUser(context.Background(), 9, model.DeleteOptions{Policy: model.PolicyCascade})
+		require.ErrorIs(t, err, sql.ErrNoRows)
+	})
+}
+
+func Test_UpdateUser(t *testing.T) {
+	store, mock, cleanup := setupDB(t)
+	defer cleanup()
+
+	query := regexp.QuoteMeta("UPDATE users SET name = ?, display_name = ?, avatar_url = ?, timezone = ? WHERE id = ? AND deleted_at IS NULL")
+	u := model.User{ID: 1, Name: "John", DisplayName: "Johnny", Timezone: "Europe/Berlin"}
+
+	mock.ExpectExec(query).WithArgs("John", "Johnny", "", "Europe/Berlin", 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, store.UpdateUser(context.Background(), u))
+
+	mock.ExpectExec(query).WithArgs("John", "Johnny", "", "Europe/Berlin", 1).WillReturnResult(sqlmock.NewResult(0, 0))
+	require.ErrorIs(t, store.UpdateUser(context.Background(), u), sql.ErrNoRows)
+}
+
+func Test_SaveEmailChangeUser(t *testing.T) {
+	store, mock, cleanup := setupDB(t)
+	defer cleanup()
+
+	expires := time.Date(2024, 5, 8, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO email_changes (user_id, email, token_hash, expires_at) VALUES (?, ?, ?, ?) ON DUPLICATE KEY UPDATE")).
+		WithArgs(1, "new@example.com", "hash", expires).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, store.SaveEmailChangeUser(context.Background(), model.EmailChange{UserID: 1, Email: "new@example.com", ExpiresAt: expires}, "hash"))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func Test_ConfirmEmailChangeUser(t *testing.T) {
+	lockUser := regexp.QuoteMeta("SELECT id, name, email, display_name, avatar_url, timezone FROM users WHERE id = ? AND deleted_at IS NULL FOR UPDATE")
+	pending := regexp.QuoteMeta("SELECT email FROM email_changes WHERE user_id = ? AND token_hash = ? AND expires_at > ? FOR UPDATE")
+	setEmail := regexp.QuoteMeta("UPDATE users SET email = ? WHERE id = ?")
+	now := time.Date(2024, 5, 7, 12, 0, 0, 0, time.UTC)
+
+	userRow := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "name", "email", "display_name", "avatar_url", "timezone"}).
+			AddRow(1, "John", "old@example.com", "", "", "")
+	}
+
+	t.Run("applied", func(t *testing.T) {
+		store, mock, cleanup := setupDB(t)
+		defer cleanup()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(lockUser).WithArgs(1).WillReturnRows(userRow())
+		mock.ExpectQuery(pending).WithArgs(1, "hash", now).WillReturnRows(sqlmock.NewRows([]string{"email"}).AddRow("new@example.com"))
+		mock.ExpectExec(setEmail).WithArgs("new@example.com", 1).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta("DELETE FROM email_changes WHERE user_id = ?")).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		before, email, err := store.ConfirmEmailChangeUser(context.Background(), 1, "hash", now)
+		require.NoError(t, err)
+		require.Equal(t, "old@example.com", before.Email)
+		require.Equal(t, "new@example.com", email)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("unknown or expired token", func(t *testing.T) {
+		store, mock, cleanup := setupDB(t)
+		defer cleanup()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(lockUser).WithArgs(1).WillReturnRows(userRow())
+		mock.ExpectQuery(pending).WithArgs(1, "stale", now).WillReturnError(sql.ErrNoRows)
+		mock.ExpectRollback()
+
+		_, _, err := store.ConfirmEmailChangeUser(context.Background(), 1, "stale", now)
+		require.ErrorIs(t, err, sql.ErrNoRows)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("address taken meanwhile", func(t *testing.T) {
+		store, mock, cleanup := setupDB(t)
+		defer cleanup()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(lockUser).WithArgs(1).WillReturnRows(userRow())
+		mock.ExpectQuery(pending).WithArgs(1, "hash", now).WillReturnRows(sqlmock.NewRows([]string{"email"}).AddRow("new@example.com"))
+		mock.ExpectExec(setEmail).WithArgs("new@example.com", 1).WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry"})
+		mock.ExpectRollback()
+
+		_, _, err := store.ConfirmEmailChangeUser(context.Background(), 1, "hash", now)
+		require.ErrorIs(t, err, model.ErrEmailTaken)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
 }
@@ -0,0 +1,366 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var sqlTracer = Tracer("tracing/sql")
+
+// OpenDB opens a database through d, like sql.Open, and records a span for every statement run on it.
+// The span carries the statement text with its literals replaced by "?"; the arguments are never
+// recorded. Only statements given a context belonging to a trace are recorded.
+func OpenDB(d driver.Driver, dsn string) (*sql.DB, error) {
+	var c driver.Connector = dsnConnector{dsn: dsn, driver: d}
+
+	if dc, ok := d.(driver.DriverContext); ok {
+		var err error
+		if c, err = dc.OpenConnector(dsn); err != nil {
+			return nil, err
+		}
+	}
+
+	return sql.OpenDB(connector{c}), nil
+}
+
+// dsnConnector adapts drivers that cannot open a connector themselves
+type dsnConnector struct {
+	dsn    string
+	driver driver.Driver
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) { return c.driver.Open(c.dsn) }
+
+func (c dsnConnector) Driver() driver.Driver { return c.driver }
+
+type connector struct {
+	driver.Connector
+}
+
+func (c connector) Connect(ctx context.Context) (driver.Conn, error) {
+	cn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &conn{Conn: cn}, nil
+}
+
+// conn records the statements run directly on a connection. Statements with arguments are usually
+// prepared by database/sql instead (the driver answers driver.ErrSkip), and recorded by stmt.
+type conn struct {
+	driver.Conn
+}
+
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var (
+		s   driver.Stmt
+		err error
+	)
+
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		s, err = p.PrepareContext(ctx, query)
+	} else {
+		s, err = c.Conn.Prepare(query)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &stmt{Stmt: s, query: query}, nil
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, opts)
+	}
+
+	return c.Conn.Begin() //nolint:staticcheck // fallback for drivers without BeginTx
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	e, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	start := time.Now()
+	res, err := e.ExecContext(ctx, query, args)
+	record(ctx, query, start, err)
+
+	return res, err
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	q, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	start := time.Now()
+	rows, err := q.QueryContext(ctx, query, args)
+	record(ctx, query, start, err)
+
+	return rows, err
+}
+
+func (c *conn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+
+	return nil
+}
+
+func (c *conn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+
+	return nil
+}
+
+func (c *conn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+
+	return true
+}
+
+func (c *conn) CheckNamedValue(v *driver.NamedValue) error {
+	if n, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return n.CheckNamedValue(v)
+	}
+
+	return driver.ErrSkip
+}
+
+type stmt struct {
+	driver.Stmt
+	query string
+}
+
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	start := time.Now()
+
+	var (
+		res driver.Result
+		err error
+	)
+
+	if e, ok := s.Stmt.(driver.StmtExecContext); ok {
+		res, err = e.ExecContext(ctx, args)
+	} else {
+		var values []driver.Value
+		if values, err = positional(args); err == nil {
+			res, err = s.Stmt.Exec(values) //nolint:staticcheck // fallback for drivers without ExecContext
+		}
+	}
+
+	record(ctx, s.query, start, err)
+
+	return res, err
+}
+
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	start := time.Now()
+
+	var (
+		rows driver.Rows
+		err  error
+	)
+
+	if q, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		rows, err = q.QueryContext(ctx, args)
+	} else {
+		var values []driver.Value
+		if values, err = positional(args); err == nil {
+			rows, err = s.Stmt.Query(values) //nolint:staticcheck // fallback for drivers without QueryContext
+		}
+	}
+
+	record(ctx, s.query, start, err)
+
+	return rows, err
+}
+
+func positional(args []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(args))
+
+	for i, a := range args {
+		if a.Name != "" {
+			return nil, errors.New("named arguments are not supported by the driver")
+		}
+
+		values[i] = a.Value
+	}
+
+	return values, nil
+}
+
+// record adds the span of a statement that started at start and just finished with err. The span is
+// created afterwards so that statements the driver skipped, to be run again as prepared statements,
+// are not recorded twice. For queries it covers the time until the first rows arrived.
+func record(ctx context.Context, query string, start time.Time, err error) {
+	if errors.Is(err, driver.ErrSkip) || !trace.SpanFromContext(ctx).SpanContext().IsValid() {
+		return
+	}
+
+	text := Sanitize(query)
+	op, table := summary(text)
+	name := op
+	if table != "" {
+		name += " " + table
+	}
+
+	_, span := sqlTracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithTimestamp(start),
+		trace.WithAttributes(semconv.DBSystemMySQL, semconv.DBOperationName(op), semconv.DBQueryText(text)))
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
+
+// Sanitize replaces the string and number literals of a SQL statement with "?", drops its comments and
+// collapses its white space, so that statements built with inline values can be recorded safely
+func Sanitize(query string) string {
+	var b strings.Builder
+
+	b.Grow(len(query))
+	space := false
+
+	for i := 0; i < len(query); {
+		c := query[i]
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			space = true
+			i++
+
+			continue
+		case strings.HasPrefix(query[i:], "--") || c == '#':
+			i = skipPast(query, i, "\n")
+			space = true
+
+			continue
+		case strings.HasPrefix(query[i:], "/*"):
+			i = skipPast(query, i+2, "*/")
+			space = true
+
+			continue
+		}
+
+		if space && b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+
+		space = false
+
+		switch {
+		case c == '\'' || c == '"':
+			i = skipQuoted(query, i)
+			b.WriteByte('?')
+		case c == '`':
+			end := skipPast(query, i+1, "`")
+			b.WriteString(query[i:end])
+			i = end
+		case isDigit(c) && (i == 0 || !isWord(query[i-1])):
+			for i < len(query) && (isWord(query[i]) || query[i] == '.') {
+				i++
+			}
+
+			b.WriteByte('?')
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+
+	return b.String()
+}
+
+// skipPast returns the index right after the first sep found from i, or the end of s
+func skipPast(s string, i int, sep string) int {
+	end := strings.Index(s[i:], sep)
+	if end < 0 {
+		return len(s)
+	}
+
+	return i + end + len(sep)
+}
+
+// skipQuoted returns the index right after the string literal starting at i, which may escape its
+// quote by doubling it or with a backslash
+func skipQuoted(s string, i int) int {
+	quote := s[i]
+
+	for i++; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case quote:
+			if i+1 < len(s) && s[i+1] == quote {
+				i++
+				continue
+			}
+
+			return i + 1
+		}
+	}
+
+	return len(s)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isWord(c byte) bool {
+	return isDigit(c) || c == '_' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+// summary returns the operation of a sanitized statement and the table it works on, when easy to tell
+func summary(text string) (op, table string) {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return "", ""
+	}
+
+	op = strings.ToUpper(words[0])
+
+	next := 0
+	switch op {
+	case "UPDATE":
+		next = 1
+	case "SELECT", "DELETE", "INSERT", "REPLACE":
+		for i, w := range words {
+			if strings.EqualFold(w, "FROM") || strings.EqualFold(w, "INTO") {
+				next = i + 1
+				break
+			}
+		}
+	}
+
+	if next == 0 || next >= len(words) || strings.HasPrefix(words[next], "(") {
+		return op, ""
+	}
+
+	table, _, _ = strings.Cut(words[next], "(")
+
+	return op, strings.Trim(table, "`,;")
+}
@@ -0,0 +1,128 @@
+// Package tracing sets up OpenTelemetry tracing. Requests get a server span named after their route,
+// service methods and SQL statements get child spans, and W3C trace context is read from incoming
+// requests and written to outgoing ones, so a slow request can be followed down to the query.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentation prefixes the names of the tracers handed out by Tracer
+const instrumentation = "Task_Manager/"
+
+// Exporters accepted in Config
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterFile   = "file"
+	ExporterOTLP   = "otlp"
+)
+
+// Config selects where finished spans are sent
+type Config struct {
+	// Exporter is "none", "stdout", "file" or "otlp"
+	Exporter string
+	// File receives the spans of the file exporter, one JSON document each
+	File string
+	// Endpoint is the OTLP/HTTP collector URL, e.g. http://localhost:4318. When empty the standard
+	// OTEL_EXPORTER_OTLP_* variables apply.
+	Endpoint string
+	// ServiceName identifies this process in the traces
+	ServiceName string
+	// SampleRatio is the share of new traces recorded, from 0 to 1. Requests arriving with a
+	// sampled parent are always recorded.
+	SampleRatio float64
+}
+
+// Setup installs the global tracer provider and the W3C trace context propagator, and returns a
+// function flushing the spans still buffered, to be called before the process exits. With the "none"
+// exporter spans are not recorded, but trace context is still passed on.
+func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	exporter, closer, err := newExporter(ctx, cfg)
+	if err != nil || exporter == nil {
+		return func(context.Context) error { return nil }, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(cfg.ServiceName)))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closer != nil {
+			err = errors.Join(err, closer.Close())
+		}
+
+		return err
+	}, nil
+}
+
+// newExporter builds the exporter named in cfg, with the file it writes to when it has one
+func newExporter(ctx context.Context, cfg Config) (sdktrace.SpanExporter, io.Closer, error) {
+	switch strings.ToLower(cfg.Exporter) {
+	case "", ExporterNone:
+		return nil, nil, nil
+	case ExporterStdout:
+		exp, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		return exp, nil, err
+	case ExporterFile:
+		if cfg.File == "" {
+			return nil, nil, errors.New("the file trace exporter needs a file")
+		}
+
+		f, err := os.OpenFile(cfg.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		exp, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			_ = f.Close()
+			return nil, nil, err
+		}
+
+		return exp, f, nil
+	case ExporterOTLP:
+		var opts []otlptracehttp.Option
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpointURL(cfg.Endpoint))
+		}
+
+		exp, err := otlptracehttp.New(ctx, opts...)
+		return exp, nil, err
+	default:
+		return nil, nil, fmt.Errorf("invalid trace exporter %q, expected none, stdout, file or otlp", cfg.Exporter)
+	}
+}
+
+// Tracer returns the tracer of a package, named after its import path. It follows the provider
+// installed by Setup, so packages can keep it in a variable initialised before Setup runs:
+//
+//	var tracer = tracing.Tracer("service/task")
+func Tracer(pkg string) trace.Tracer {
+	return otel.Tracer(instrumentation + pkg)
+}
@@ -0,0 +1,242 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// spans receives every span ended in the tests. The tracers of the package follow the first provider
+// installed, so it is installed once for all of them.
+var spans = tracetest.NewInMemoryExporter()
+
+func TestMain(m *testing.M) {
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(spans)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	os.Exit(m.Run())
+}
+
+func attr(s tracetest.SpanStub, key attribute.Key) attribute.Value {
+	for _, kv := range s.Attributes {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+
+	return attribute.Value{}
+}
+
+func Test_Sanitize(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		exp   string
+	}{
+		{"placeholders kept", "SELECT id FROM tasks WHERE id = ?", "SELECT id FROM tasks WHERE id = ?"},
+		{"white space collapsed", "SELECT id\n\t FROM tasks\n", "SELECT id FROM tasks"},
+		{"strings", `UPDATE users SET email = 'a@b.c', name = "O""Neil" WHERE note = 'it\'s'`,
+			"UPDATE users SET email = ?, name = ? WHERE note = ?"},
+		{"numbers", "SELECT * FROM tasks WHERE id IN (1, 22) AND due_at > 1.5 LIMIT 10", "SELECT * FROM tasks WHERE id IN (?, ?) AND due_at > ? LIMIT ?"},
+		{"identifiers with digits", "SELECT t2.id FROM tasks t2", "SELECT t2.id FROM tasks t2"},
+		{"quoted identifiers", "SELECT `select 1` FROM `tasks`", "SELECT `select 1` FROM `tasks`"},
+		{"comments", "SELECT 1 -- secret 'x'\nFROM /* 2 */ dual # end", "SELECT ? FROM dual"},
+		{"unterminated string", "SELECT 'abc", "SELECT ?"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.exp, Sanitize(tt.query))
+		})
+	}
+}
+
+func Test_Summary(t *testing.T) {
+	tests := []struct {
+		text  string
+		op    string
+		table string
+	}{
+		{"SELECT id FROM tasks WHERE id = ?", "SELECT", "tasks"},
+		{"select id from `labels` l", "SELECT", "labels"},
+		{"INSERT INTO tasks(description, status) VALUES (?, ?)", "INSERT", "tasks"},
+		{"UPDATE users SET name = ?", "UPDATE", "users"},
+		{"DELETE FROM notification_digest WHERE id IN (?)", "DELETE", "notification_digest"},
+		{"SELECT COUNT(*) FROM (SELECT id FROM tasks) t", "SELECT", ""},
+		{"COMMIT", "COMMIT", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			op, table := summary(tt.text)
+			assert.Equal(t, tt.op, op)
+			assert.Equal(t, tt.table, table)
+		})
+	}
+}
+
+func Test_Middleware(t *testing.T) {
+	parent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	tests := []struct {
+		name      string
+		path      string
+		header    string
+		status    int
+		expName   string
+		expTrace  string
+		expStatus codes.Code
+	}{
+		{"continues the caller's trace", "/task/7", parent, http.StatusOK, "GET /task/{id}", "4bf92f3577b34da6a3ce929d0e0e4736", codes.Unset},
+		{"server errors", "/task/7", "", http.StatusInternalServerError, "GET /task/{id}", "", codes.Error},
+		{"unmatched routes", "/nope", "", http.StatusNotFound, "GET", "", codes.Unset},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spans.Reset()
+
+			r := mux.NewRouter()
+			r.Use(Route)
+			r.HandleFunc("/task/{id}", func(w http.ResponseWriter, r *http.Request) {
+				assert.True(t, trace.SpanContextFromContext(r.Context()).IsValid())
+				w.WriteHeader(tt.status)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set("traceparent", tt.header)
+			}
+
+			Middleware(r).ServeHTTP(httptest.NewRecorder(), req)
+
+			got := spans.GetSpans()
+			require.Len(t, got, 1)
+			assert.Equal(t, tt.expName, got[0].Name)
+			assert.Equal(t, trace.SpanKindServer, got[0].SpanKind)
+			assert.Equal(t, int64(tt.status), attr(got[0], semconv.HTTPResponseStatusCodeKey).AsInt64())
+			assert.Equal(t, tt.expStatus, got[0].Status.Code)
+
+			if tt.expTrace != "" {
+				assert.Equal(t, tt.expTrace, got[0].SpanContext.TraceID().String())
+				assert.Equal(t, "00f067aa0ba902b7", got[0].Parent.SpanID().String())
+			}
+		})
+	}
+}
+
+func Test_Transport(t *testing.T) {
+	spans.Reset()
+
+	var header string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	ctx, parent := Tracer("test").Start(context.Background(), "parent")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, srv.URL+"/bucket/key?X-Amz-Signature=secret", nil)
+	require.NoError(t, err)
+
+	resp, err := (&http.Client{Transport: Transport(nil)}).Do(req)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	parent.End()
+
+	assert.Empty(t, req.Header.Get("traceparent"), "the caller's request must not be modified")
+
+	got := spans.GetSpans()
+	require.Len(t, got, 2)
+
+	client := got[0]
+	assert.Equal(t, trace.SpanKindClient, client.SpanKind)
+	assert.Equal(t, parent.SpanContext().SpanID(), client.Parent.SpanID())
+	assert.Equal(t, srv.URL+"/bucket/key", attr(client, semconv.URLFullKey).AsString())
+	assert.Equal(t, codes.Error, client.Status.Code)
+	assert.Equal(t, "00-"+client.SpanContext.TraceID().String()+"-"+client.SpanContext.SpanID().String()+"-01", header)
+}
+
+func Test_OpenDB(t *testing.T) {
+	mockDB, mock, err := sqlmock.NewWithDSN("tracing_test")
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	db, err := OpenDB(mockDB.Driver(), "tracing_test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectExec("UPDATE tasks").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT id FROM tasks").WillReturnError(errors.New("deadlock"))
+	mock.ExpectExec("DELETE FROM tasks").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	spans.Reset()
+	ctx, parent := Tracer("test").Start(context.Background(), "parent")
+
+	_, err = db.ExecContext(ctx, "UPDATE tasks\n SET status = true, description = 'secret' WHERE id = ?", 1)
+	require.NoError(t, err)
+
+	_, err = db.QueryContext(ctx, "SELECT id FROM tasks WHERE userid = 42")
+	require.Error(t, err)
+
+	// Statements outside of a trace are not recorded
+	_, err = db.ExecContext(context.Background(), "DELETE FROM tasks")
+	require.NoError(t, err)
+
+	parent.End()
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	got := spans.GetSpans()
+	require.Len(t, got, 3)
+
+	update, query := got[0], got[1]
+	assert.Equal(t, "UPDATE tasks", update.Name)
+	assert.Equal(t, "UPDATE tasks SET status = true, description = ? WHERE id = ?", attr(update, semconv.DBQueryTextKey).AsString())
+	assert.Equal(t, "mysql", attr(update, semconv.DBSystemKey).AsString())
+	assert.Equal(t, parent.SpanContext().SpanID(), update.Parent.SpanID())
+	assert.Equal(t, codes.Unset, update.Status.Code)
+
+	assert.Equal(t, "SELECT tasks", query.Name)
+	assert.Equal(t, "SELECT id FROM tasks WHERE userid = ?", attr(query, semconv.DBQueryTextKey).AsString())
+	assert.Equal(t, codes.Error, query.Status.Code)
+}
+
+func Test_Setup(t *testing.T) {
+	t.Run("invalid exporter", func(t *testing.T) {
+		_, err := Setup(context.Background(), Config{Exporter: "zipkin"})
+		assert.ErrorContains(t, err, "invalid trace exporter")
+	})
+
+	t.Run("file exporter", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "traces.json")
+
+		shutdown, err := Setup(context.Background(), Config{Exporter: ExporterFile, File: path, ServiceName: "test", SampleRatio: 1})
+		require.NoError(t, err)
+
+		_, span := otel.Tracer("test").Start(context.Background(), "written")
+		span.End()
+		require.NoError(t, shutdown(context.Background()))
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), `"Name":"written"`)
+		assert.Contains(t, string(data), `"Value":"test"`)
+	})
+}